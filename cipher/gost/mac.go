@@ -0,0 +1,117 @@
+package gost
+
+import (
+    "errors"
+)
+
+// MAC is the GOST 28147-89 MAC (imitovstavka) mode.
+// The blocks are encrypted with the first 16 rounds of the cipher, a
+// last partial block is padded with zeros and a single block message
+// is extended with a zero block.
+type MAC struct {
+    c      *gostCipher
+    size   int
+    iv     []byte
+    prev   []byte
+    buf    []byte
+    blocks int
+}
+
+// NewMAC returns a new MAC with the 32 byte key, the sbox, the MAC size
+// in bytes, from 1 to 8, and the 8 byte iv.
+func NewMAC(key []byte, sbox [][]byte, size int, iv []byte) (*MAC, error) {
+    if size <= 0 || size > BlockSize {
+        return nil, errors.New("cryptobin/gost: invalid mac size")
+    }
+
+    if len(iv) != BlockSize {
+        return nil, errors.New("cryptobin/gost: invalid mac iv size")
+    }
+
+    block, err := NewCipher(key, sbox)
+    if err != nil {
+        return nil, err
+    }
+
+    m := &MAC{
+        c:    block.(*gostCipher),
+        size: size,
+        iv:   append([]byte(nil), iv...),
+    }
+    m.Reset()
+
+    return m, nil
+}
+
+func (m *MAC) Reset() {
+    m.prev = append(m.prev[:0], m.iv...)
+    m.buf = m.buf[:0]
+    m.blocks = 0
+}
+
+func (m *MAC) Size() int {
+    return m.size
+}
+
+func (m *MAC) BlockSize() int {
+    return BlockSize
+}
+
+func (m *MAC) Write(p []byte) (int, error) {
+    m.buf = append(m.buf, p...)
+
+    // the last full block is kept for Sum
+    for len(m.buf) > BlockSize {
+        m.block(m.buf[:BlockSize])
+        m.buf = m.buf[BlockSize:]
+    }
+
+    return len(p), nil
+}
+
+func (m *MAC) Sum(b []byte) []byte {
+    prev := append([]byte(nil), m.prev...)
+    blocks := m.blocks
+
+    last := make([]byte, BlockSize)
+    copy(last, m.buf)
+
+    m.block(last)
+    if m.blocks == 1 {
+        m.block(make([]byte, BlockSize))
+    }
+
+    sum := append(b, m.prev[:m.size]...)
+
+    m.prev = prev
+    m.blocks = blocks
+
+    return sum
+}
+
+func (m *MAC) block(data []byte) {
+    for i := 0; i < BlockSize; i++ {
+        m.prev[i] ^= data[i]
+    }
+
+    n := bytesToUint32s(m.prev)
+    n1, n2 := m.c.mac(n[0], n[1])
+    copy(m.prev, uint32sToBytes([]uint32{n1, n2}))
+
+    m.blocks++
+}
+
+// mac encrypts one block with the 16 rounds of the MAC mode.
+func (this *gostCipher) mac(n1, n2 uint32) (uint32, uint32) {
+    for i := 0; i < 2; i++ {
+        for j := 0; j < 8; j++ {
+            if j%2 == 0 {
+                n2 = n2 ^ this.round(n1 + this.key[j])
+            } else {
+                n1 = n1 ^ this.round(n2 + this.key[j])
+            }
+        }
+    }
+
+    return n1, n2
+}
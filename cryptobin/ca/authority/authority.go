@@ -0,0 +1,534 @@
+package authority
+
+import (
+    "io"
+    "sync"
+    "time"
+    "bytes"
+    "errors"
+    "math/big"
+    "crypto"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+    std_x509 "crypto/x509"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/x509/lint"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+)
+
+// serialAttempts is the number of serial numbers tried before giving
+// up on an issuance.
+const serialAttempts = 8
+
+// Authority is a certificate authority keeping track of the
+// certificates it issues in a Store. It issues certificates from
+// certificate requests with its profiles, renews and re-keys them,
+// revokes them and publishes CRLs. The issuer key can be a RSA, ECDSA,
+// Ed25519, SM2 or GOST key.
+type Authority struct {
+    mu       sync.Mutex
+    cert     *x509.Certificate
+    signer   crypto.Signer
+    store    Store
+    profiles map[string]*Profile
+
+    // CRLDistributionPoints, OCSPServer and IssuingCertificateURL are
+    // set in the issued certificates.
+    CRLDistributionPoints []string
+    OCSPServer            []string
+    IssuingCertificateURL []string
+
+    // CRLValidity is the time between two CRLs, 7 days if zero.
+    CRLValidity time.Duration
+
+    // Backdate is subtracted from the current time for the start of
+    // the validity of the certificates, to allow for clock skew.
+    Backdate time.Duration
+
+    // Linter, if not nil, lints the certificates before they are
+    // signed. The ones with findings of LintLevel or higher are not
+    // issued, and a *lint.CertificateError is returned.
+    Linter *lint.Registry
+
+    // LintLevel is the lowest severity refused, lint.Error if zero.
+    LintLevel lint.Severity
+
+    // Rand is the source of randomness, crypto/rand.Reader if nil.
+    Rand io.Reader
+
+    // Now returns the current time, time.Now if nil.
+    Now func() time.Time
+}
+
+// New returns an Authority issuing with cert and its private key
+// signer. cert must be a CA certificate.
+func New(cert *x509.Certificate, signer crypto.Signer, store Store) (*Authority, error) {
+    if !cert.BasicConstraintsValid || !cert.IsCA {
+        return nil, errors.New("go-cryptobin/ca: certificate is not a CA certificate")
+    }
+
+    if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+        return nil, errors.New("go-cryptobin/ca: certificate can not sign certificates")
+    }
+
+    certKey, err := marshalPublicKey(cert.PublicKey)
+    if err != nil {
+        return nil, err
+    }
+
+    signerKey, err := marshalPublicKey(signer.Public())
+    if err != nil {
+        return nil, err
+    }
+
+    if !bytes.Equal(certKey, signerKey) {
+        return nil, errors.New("go-cryptobin/ca: private key does not match the certificate")
+    }
+
+    a := &Authority{
+        cert:     cert,
+        signer:   signer,
+        store:    store,
+        profiles: make(map[string]*Profile),
+    }
+
+    for _, p := range []*Profile{ServerProfile(), ClientProfile(), CodeSigningProfile()} {
+        a.profiles[p.Name] = p
+    }
+
+    return a, nil
+}
+
+// NewRoot creates a self-signed root certificate for signer, valid for
+// validity, and returns an Authority issuing with it.
+func NewRoot(subject pkix.Name, signer crypto.Signer, validity time.Duration, store Store) (*Authority, error) {
+    serial, err := randomSerial(rand.Reader)
+    if err != nil {
+        return nil, err
+    }
+
+    keyId, err := subjectKeyId(signer.Public())
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now().UTC().Truncate(time.Second)
+
+    tmpl := &x509.Certificate{
+        SerialNumber:          serial,
+        Subject:               subject,
+        NotBefore:             now,
+        NotAfter:              now.Add(validity),
+        KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SubjectKeyId:          keyId,
+    }
+
+    der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+    if err != nil {
+        return nil, err
+    }
+
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        return nil, err
+    }
+
+    return New(cert, signer, store)
+}
+
+// Certificate returns the CA certificate.
+func (a *Authority) Certificate() *x509.Certificate {
+    return a.cert
+}
+
+// Store returns the store of the authority.
+func (a *Authority) Store() Store {
+    return a.store
+}
+
+// AddProfile adds p, replacing the profile with the same name. The
+// server, client and code-signing profiles are added by New.
+func (a *Authority) AddProfile(p *Profile) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    a.profiles[p.Name] = p
+}
+
+// Profile returns the profile with the name, or nil.
+func (a *Authority) Profile(name string) *Profile {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    return a.profiles[name]
+}
+
+// Issue checks csr against the policy of the profile and issues a
+// certificate for it.
+func (a *Authority) Issue(csr *x509.CertificateRequest, profile string) (*x509.Certificate, error) {
+    p := a.Profile(profile)
+    if p == nil {
+        return nil, errors.New("go-cryptobin/ca: unknown profile " + profile)
+    }
+
+    if err := p.Policy.Check(csr); err != nil {
+        return nil, err
+    }
+
+    return a.issue(csr, p)
+}
+
+// Renew issues a new certificate for the key, subject and names of
+// cert, with the profile cert was issued with. cert must have been
+// issued by the authority and not be revoked.
+func (a *Authority) Renew(cert *x509.Certificate) (*x509.Certificate, error) {
+    _, p, err := a.current(cert)
+    if err != nil {
+        return nil, err
+    }
+
+    req := &x509.CertificateRequest{
+        RawSubject:         cert.RawSubject,
+        Subject:            cert.Subject,
+        PublicKeyAlgorithm: cert.PublicKeyAlgorithm,
+        PublicKey:          cert.PublicKey,
+        DNSNames:           cert.DNSNames,
+        IPAddresses:        cert.IPAddresses,
+        EmailAddresses:     cert.EmailAddresses,
+        URIs:               cert.URIs,
+    }
+
+    // the key may not be accepted anymore
+    if err = p.Policy.checkKey(req); err != nil {
+        return nil, err
+    }
+
+    return a.issue(req, p)
+}
+
+// Rekey issues a certificate for the new key of csr, replacing cert,
+// which is then revoked as superseded. csr must have the subject of
+// cert and is checked against the policy of the profile cert was
+// issued with.
+func (a *Authority) Rekey(cert *x509.Certificate, csr *x509.CertificateRequest) (*x509.Certificate, error) {
+    _, p, err := a.current(cert)
+    if err != nil {
+        return nil, err
+    }
+
+    if !bytes.Equal(csr.RawSubject, cert.RawSubject) {
+        return nil, PolicyError{"subject does not match the certificate"}
+    }
+
+    if bytes.Equal(csr.RawSubjectPublicKeyInfo, cert.RawSubjectPublicKeyInfo) {
+        return nil, PolicyError{"the key is not new"}
+    }
+
+    if err = p.Policy.Check(csr); err != nil {
+        return nil, err
+    }
+
+    newCert, err := a.issue(csr, p)
+    if err != nil {
+        return nil, err
+    }
+
+    if err = a.Revoke(cert.SerialNumber, x509.ReasonSuperseded); err != nil {
+        return nil, err
+    }
+
+    return newCert, nil
+}
+
+// Revoke revokes the certificate with the serial number for reason, a
+// CRL reason code. The revocation is published by the next CRL.
+func (a *Authority) Revoke(serial *big.Int, reason int) error {
+    if reason < x509.ReasonUnspecified || reason > x509.ReasonAACompromise ||
+        reason == 7 || reason == x509.ReasonRemoveFromCRL {
+        return errors.New("go-cryptobin/ca: invalid revocation reason")
+    }
+
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    rec, err := a.store.Get(serial)
+    if err != nil {
+        return err
+    }
+
+    if rec.Revoked {
+        return errors.New("go-cryptobin/ca: certificate is revoked already")
+    }
+
+    rec.Revoked = true
+    rec.RevocationTime = a.now()
+    rec.ReasonCode = reason
+
+    return a.store.Update(rec)
+}
+
+// Lookup returns the record of the certificate with the serial number.
+func (a *Authority) Lookup(serial *big.Int) (*Record, error) {
+    return a.store.Get(serial)
+}
+
+// PublishCRL creates a CRL of the revoked certificates that have not
+// expired yet, with the next CRL number, saves it in the store and
+// returns it DER-encoded.
+func (a *Authority) PublishCRL() ([]byte, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+
+    recs, err := a.store.List()
+    if err != nil {
+        return nil, err
+    }
+
+    now := a.now()
+
+    var entries []x509.RevocationListEntry
+    for _, rec := range recs {
+        if !rec.Revoked {
+            continue
+        }
+
+        cert, err := x509.ParseCertificate(rec.Raw)
+        if err != nil {
+            return nil, err
+        }
+
+        // expired certificates can be dropped from the CRL
+        if now.After(cert.NotAfter) {
+            continue
+        }
+
+        entries = append(entries, x509.RevocationListEntry{
+            SerialNumber:   rec.SerialNumber,
+            RevocationTime: rec.RevocationTime,
+            ReasonCode:     rec.ReasonCode,
+        })
+    }
+
+    number, err := a.store.NextCRLNumber()
+    if err != nil {
+        return nil, err
+    }
+
+    validity := a.CRLValidity
+    if validity == 0 {
+        validity = 7 * 24 * time.Hour
+    }
+
+    der, err := x509.CreateRevocationList(a.rand(), &x509.RevocationList{
+        Number:                    number,
+        ThisUpdate:                now,
+        NextUpdate:                now.Add(validity),
+        RevokedCertificateEntries: entries,
+    }, a.cert, a.signer)
+    if err != nil {
+        return nil, err
+    }
+
+    if err = a.store.SaveCRL(der); err != nil {
+        return nil, err
+    }
+
+    return der, nil
+}
+
+// CRL returns the last published CRL, publishing one if there is none.
+func (a *Authority) CRL() ([]byte, error) {
+    der, err := a.store.LoadCRL()
+    if errors.Is(err, ErrNotFound) {
+        return a.PublishCRL()
+    }
+
+    return der, err
+}
+
+// current returns the record and the profile of cert, which must be a
+// certificate of the authority that is not revoked.
+func (a *Authority) current(cert *x509.Certificate) (*Record, *Profile, error) {
+    if err := cert.CheckSignatureFrom(a.cert); err != nil {
+        return nil, nil, errors.New("go-cryptobin/ca: certificate is not issued by the authority")
+    }
+
+    rec, err := a.store.Get(cert.SerialNumber)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if !bytes.Equal(rec.Raw, cert.Raw) {
+        return nil, nil, errors.New("go-cryptobin/ca: certificate does not match the record")
+    }
+
+    if rec.Revoked {
+        return nil, nil, errors.New("go-cryptobin/ca: certificate is revoked")
+    }
+
+    p := a.Profile(rec.Profile)
+    if p == nil {
+        return nil, nil, errors.New("go-cryptobin/ca: unknown profile " + rec.Profile)
+    }
+
+    return rec, p, nil
+}
+
+// issue signs a certificate for req with the profile p and records it
+// with a new serial number.
+func (a *Authority) issue(req *x509.CertificateRequest, p *Profile) (*x509.Certificate, error) {
+    keyId, err := subjectKeyId(req.PublicKey)
+    if err != nil {
+        return nil, err
+    }
+
+    notBefore := a.now().Add(-a.Backdate)
+    if notBefore.Before(a.cert.NotBefore) {
+        notBefore = a.cert.NotBefore
+    }
+
+    tmpl := p.template(req, notBefore)
+    tmpl.RawSubject = req.RawSubject
+    tmpl.SubjectKeyId = keyId
+    tmpl.CRLDistributionPoints = a.CRLDistributionPoints
+    tmpl.OCSPServer = a.OCSPServer
+    tmpl.IssuingCertificateURL = a.IssuingCertificateURL
+
+    if tmpl.NotAfter.After(a.cert.NotAfter) {
+        tmpl.NotAfter = a.cert.NotAfter
+    }
+
+    // the path length constraint of the authority caps the one of the
+    // CA certificates it issues
+    if p.IsCA && (a.cert.MaxPathLen > 0 || a.cert.MaxPathLenZero) {
+        if a.cert.MaxPathLenZero {
+            return nil, errors.New("go-cryptobin/ca: authority can not issue CA certificates")
+        }
+
+        if tmpl.MaxPathLen < 0 || tmpl.MaxPathLen >= a.cert.MaxPathLen {
+            tmpl.MaxPathLen = a.cert.MaxPathLen - 1
+            tmpl.MaxPathLenZero = tmpl.MaxPathLen == 0
+        }
+    }
+
+    for i := 0; i < serialAttempts; i++ {
+        tmpl.SerialNumber, err = randomSerial(a.rand())
+        if err != nil {
+            return nil, err
+        }
+
+        if err = a.lint(tmpl, req.PublicKey); err != nil {
+            return nil, err
+        }
+
+        der, err := x509.CreateCertificate(a.rand(), tmpl, a.cert, req.PublicKey, a.signer)
+        if err != nil {
+            return nil, err
+        }
+
+        cert, err := x509.ParseCertificate(der)
+        if err != nil {
+            return nil, err
+        }
+
+        err = a.store.Put(&Record{
+            SerialNumber: cert.SerialNumber,
+            Raw:          cert.Raw,
+            Profile:      p.Name,
+        })
+        if errors.Is(err, ErrSerialExists) {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        return cert, nil
+    }
+
+    return nil, errors.New("go-cryptobin/ca: no unused serial number found")
+}
+
+// lint runs the linter of the authority on the template tmpl for pub.
+func (a *Authority) lint(tmpl *x509.Certificate, pub crypto.PublicKey) error {
+    if a.Linter == nil {
+        return nil
+    }
+
+    res, err := a.Linter.RunTemplate(tmpl, a.cert, pub, a.signer.Public())
+    if err != nil {
+        return err
+    }
+
+    level := a.LintLevel
+    if level == 0 {
+        level = lint.Error
+    }
+
+    return res.Err(level)
+}
+
+func (a *Authority) now() time.Time {
+    if a.Now != nil {
+        return a.Now().UTC().Truncate(time.Second)
+    }
+
+    return time.Now().UTC().Truncate(time.Second)
+}
+
+func (a *Authority) rand() io.Reader {
+    if a.Rand != nil {
+        return a.Rand
+    }
+
+    return rand.Reader
+}
+
+// randomSerial returns a positive serial number of 159 random bits,
+// that fits the 20 octets limit of RFC 5280.
+func randomSerial(r io.Reader) (*big.Int, error) {
+    serial, err := rand.Int(r, new(big.Int).Lsh(big.NewInt(1), 159))
+    if err != nil {
+        return nil, err
+    }
+
+    return serial.Add(serial, big.NewInt(1)), nil
+}
+
+// marshalPublicKey returns the DER-encoded SubjectPublicKeyInfo of pub.
+func marshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+    switch k := pub.(type) {
+        case *sm2.PublicKey:
+            return sm2.MarshalPublicKey(k)
+        case *gost.PublicKey:
+            return gost.MarshalPublicKey(k)
+    }
+
+    return std_x509.MarshalPKIXPublicKey(pub)
+}
+
+// subjectKeyId returns the SHA-1 hash of the public key bits, RFC 5280
+// section 4.2.1.2 method (1).
+func subjectKeyId(pub crypto.PublicKey) ([]byte, error) {
+    der, err := marshalPublicKey(pub)
+    if err != nil {
+        return nil, err
+    }
+
+    var spki struct {
+        Algorithm pkix.AlgorithmIdentifier
+        PublicKey asn1.BitString
+    }
+    if _, err = asn1.Unmarshal(der, &spki); err != nil {
+        return nil, err
+    }
+
+    h := sha1.Sum(spki.PublicKey.Bytes)
+    return h[:], nil
+}
@@ -0,0 +1,589 @@
+package authority
+
+import (
+    "net"
+    "time"
+    "errors"
+    "context"
+    "testing"
+    "math/big"
+    "net/url"
+    "crypto"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/x509/lint"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+const year = 365 * 24 * time.Hour
+
+func newSigner(t *testing.T, name string) crypto.Signer {
+    var key crypto.Signer
+    var err error
+
+    switch name {
+        case "RSA":
+            key, err = rsa.GenerateKey(rand.Reader, 2048)
+        case "ECDSA":
+            key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+        case "Ed25519":
+            _, key, err = ed25519.GenerateKey(rand.Reader)
+        case "SM2":
+            key, err = sm2.GenerateKey(rand.Reader)
+    }
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return key
+}
+
+func newCSR(t *testing.T, key crypto.Signer, cn string, dnsNames ...string) *x509.CertificateRequest {
+    der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+        Subject:            pkix.Name{
+            CommonName: cn,
+        },
+        DNSNames:           dnsNames,
+        SignatureAlgorithm: signatureAlgorithm(key),
+    }, key)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    csr, err := x509.ParseCertificateRequest(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return csr
+}
+
+func signatureAlgorithm(key crypto.Signer) x509.SignatureAlgorithm {
+    switch key.(type) {
+        case *rsa.PrivateKey:
+            return x509.SHA256WithRSA
+        case *ecdsa.PrivateKey:
+            return x509.ECDSAWithSHA256
+        case ed25519.PrivateKey:
+            return x509.PureEd25519
+        default:
+            return x509.SM2WithSM3
+    }
+}
+
+func Test_Authority(t *testing.T) {
+    for _, name := range []string{"RSA", "ECDSA", "Ed25519", "SM2"} {
+        t.Run(name, func(t *testing.T) {
+            testAuthority(t, name)
+        })
+    }
+}
+
+func testAuthority(t *testing.T, name string) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ca, err := NewRoot(pkix.Name{CommonName: "Root " + name}, newSigner(t, name), 10 * year, NewMemoryStore())
+    assertError(err, "NewRoot")
+
+    ca.CRLDistributionPoints = []string{"http://crl.example.com/root.crl"}
+
+    roots := x509.NewCertPool()
+    roots.AddCert(ca.Certificate())
+
+    key := newSigner(t, "ECDSA")
+
+    cert, err := ca.Issue(newCSR(t, key, "www.example.com", "www.example.com"), "server")
+    assertError(err, "Issue")
+    assertEqual(cert.DNSNames, []string{"www.example.com"}, "DNSNames")
+    assertEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, "ExtKeyUsage")
+    assertEqual(cert.AuthorityKeyId, ca.Certificate().SubjectKeyId, "AuthorityKeyId")
+    assertBool(len(cert.SubjectKeyId) > 0, "SubjectKeyId")
+
+    _, err = cert.Verify(x509.VerifyOptions{
+        Roots:   roots,
+        DNSName: "www.example.com",
+    })
+    assertError(err, "Verify")
+
+    rec, err := ca.Lookup(cert.SerialNumber)
+    assertError(err, "Lookup")
+    assertEqual(rec.Profile, "server", "Lookup")
+    assertEqual(rec.Raw, cert.Raw, "Lookup")
+
+    // renewal keeps the key and the names
+    renewed, err := ca.Renew(cert)
+    assertError(err, "Renew")
+    assertEqual(renewed.RawSubjectPublicKeyInfo, cert.RawSubjectPublicKeyInfo, "Renew")
+    assertEqual(renewed.DNSNames, cert.DNSNames, "Renew")
+    assertBool(renewed.SerialNumber.Cmp(cert.SerialNumber) != 0, "Renew serial")
+
+    // re-key revokes the replaced certificate
+    rekeyed, err := ca.Rekey(renewed, newCSR(t, newSigner(t, "ECDSA"), "www.example.com", "www.example.com"))
+    assertError(err, "Rekey")
+
+    rec, err = ca.Lookup(renewed.SerialNumber)
+    assertError(err, "Lookup")
+    assertBool(rec.Revoked, "Rekey revoked")
+    assertEqual(rec.ReasonCode, x509.ReasonSuperseded, "Rekey reason")
+
+    _, err = ca.Renew(renewed)
+    assertBool(err != nil, "Renew revoked")
+
+    err = ca.Revoke(cert.SerialNumber, x509.ReasonKeyCompromise)
+    assertError(err, "Revoke")
+
+    err = ca.Revoke(cert.SerialNumber, x509.ReasonKeyCompromise)
+    assertBool(err != nil, "Revoke twice")
+
+    der, err := ca.PublishCRL()
+    assertError(err, "PublishCRL")
+
+    crl, err := x509.ParseRevocationList(der)
+    assertError(err, "ParseRevocationList")
+    assertError(crl.CheckSignatureFrom(ca.Certificate()), "CheckSignatureFrom")
+    assertEqual(crl.Number.Int64(), int64(1), "Number")
+    assertEqual(len(crl.RevokedCertificateEntries), 2, "RevokedCertificateEntries")
+    assertEqual(crl.Entry(cert.SerialNumber).ReasonCode, x509.ReasonKeyCompromise, "ReasonCode")
+
+    last, err := ca.CRL()
+    assertError(err, "CRL")
+    assertEqual(last, der, "CRL")
+
+    revocation := &x509.RevocationOptions{
+        CRLs:          []*x509.RevocationList{crl},
+        RequireStatus: true,
+    }
+
+    _, err = cert.Verify(x509.VerifyOptions{
+        Roots:      roots,
+        Revocation: revocation,
+    })
+    assertBool(err != nil, "Verify revoked")
+
+    _, err = rekeyed.Verify(x509.VerifyOptions{
+        Roots:      roots,
+        Revocation: revocation,
+    })
+    assertError(err, "Verify rekeyed")
+}
+
+func Test_Profiles(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ca, err := NewRoot(pkix.Name{CommonName: "Root"}, newSigner(t, "ECDSA"), 10 * year, NewMemoryStore())
+    assertError(err, "NewRoot")
+
+    key := newSigner(t, "Ed25519")
+
+    client, err := ca.Issue(newCSR(t, key, "alice"), "client")
+    assertError(err, "client")
+    assertEqual(client.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, "client")
+    assertEqual(client.KeyUsage, x509.KeyUsageDigitalSignature, "client")
+
+    // Ed25519 keys can not encipher keys
+    server, err := ca.Issue(newCSR(t, key, "www.example.com", "www.example.com"), "server")
+    assertError(err, "server")
+    assertEqual(server.KeyUsage, x509.KeyUsageDigitalSignature, "server")
+
+    codeSigning, err := ca.Issue(newCSR(t, key, "Signer"), "code-signing")
+    assertError(err, "code-signing")
+    assertEqual(codeSigning.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}, "code-signing")
+
+    _, err = ca.Issue(newCSR(t, key, "Signer", "www.example.com"), "code-signing")
+    assertBool(errors.As(err, new(PolicyError)), "code-signing SAN")
+
+    _, err = ca.Issue(newCSR(t, key, "www.example.com"), "server")
+    assertBool(errors.As(err, new(PolicyError)), "server no SAN")
+
+    _, err = ca.Issue(newCSR(t, key, "alice"), "unknown")
+    assertBool(err != nil, "unknown profile")
+
+    // policies
+    small, err := rsa.GenerateKey(rand.Reader, 1024)
+    assertError(err, "GenerateKey")
+
+    _, err = ca.Issue(newCSR(t, small, "alice"), "client")
+    assertBool(errors.As(err, new(PolicyError)), "small RSA key")
+
+    p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    _, err = ca.Issue(newCSR(t, p224, "alice"), "client")
+    assertBool(errors.As(err, new(PolicyError)), "P-224 key")
+
+    csr := newCSR(t, key, "alice")
+    csr.Signature[0] ^= 1
+    _, err = ca.Issue(csr, "client")
+    assertBool(errors.As(err, new(PolicyError)), "bad signature")
+
+    server2 := ServerProfile()
+    server2.Name = "internal"
+    server2.Policy.AllowedDNSDomains = []string{"internal.example.com"}
+    ca.AddProfile(server2)
+
+    _, err = ca.Issue(newCSR(t, key, "a", "a.internal.example.com"), "internal")
+    assertError(err, "allowed domain")
+
+    _, err = ca.Issue(newCSR(t, key, "a", "a.example.com"), "internal")
+    assertBool(errors.As(err, new(PolicyError)), "not allowed domain")
+}
+
+func Test_PolicySAN(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    key := newSigner(t, "ECDSA")
+
+    newSANCSR := func(dns []string, ips []net.IP, emails []string, uris []string) *x509.CertificateRequest {
+        var parsed []*url.URL
+        for _, uri := range uris {
+            u, err := url.Parse(uri)
+            assertError(err, "url.Parse")
+
+            parsed = append(parsed, u)
+        }
+
+        der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+            Subject:            pkix.Name{CommonName: "a"},
+            DNSNames:           dns,
+            IPAddresses:        ips,
+            EmailAddresses:     emails,
+            URIs:               parsed,
+            SignatureAlgorithm: x509.ECDSAWithSHA256,
+        }, key)
+        assertError(err, "CreateCertificateRequest")
+
+        csr, err := x509.ParseCertificateRequest(der)
+        assertError(err, "ParseCertificateRequest")
+
+        return csr
+    }
+
+    _, ipRange, _ := net.ParseCIDR("10.0.0.0/8")
+
+    p := Policy{
+        AllowedDNSDomains:   []string{"example.com"},
+        AllowedIPRanges:     []*net.IPNet{ipRange},
+        AllowedEmailDomains: []string{"example.com"},
+        AllowedURIDomains:   []string{"spiffe.example.com"},
+    }
+
+    err := p.Check(newSANCSR(
+        []string{"a.example.com"},
+        []net.IP{net.ParseIP("10.1.2.3")},
+        []string{"alice@mail.example.com"},
+        []string{"spiffe://spiffe.example.com/workload"},
+    ))
+    assertError(err, "Check allowed names")
+
+    rejected := map[string]*x509.CertificateRequest{
+        "DNS":   newSANCSR([]string{"a.example.org"}, nil, nil, nil),
+        "IP":    newSANCSR(nil, []net.IP{net.ParseIP("192.168.1.1")}, nil, nil),
+        "email": newSANCSR(nil, nil, []string{"alice@example.org"}, nil),
+        "URI":   newSANCSR(nil, nil, nil, []string{"https://www.example.org/"}),
+    }
+
+    for name, csr := range rejected {
+        err = p.Check(csr)
+        assertBool(errors.As(err, new(PolicyError)), "Check " + name)
+    }
+
+    // the SAN types without rule are rejected once a rule is set
+    dnsOnly := Policy{
+        AllowedDNSDomains: []string{"example.com"},
+    }
+
+    err = dnsOnly.Check(newSANCSR([]string{"a.example.com"}, nil, nil, nil))
+    assertError(err, "Check DNS only")
+
+    uncovered := map[string]*x509.CertificateRequest{
+        "IP":    newSANCSR([]string{"a.example.com"}, []net.IP{net.ParseIP("10.1.2.3")}, nil, nil),
+        "email": newSANCSR([]string{"a.example.com"}, nil, []string{"alice@example.com"}, nil),
+        "URI":   newSANCSR([]string{"a.example.com"}, nil, nil, []string{"https://a.example.com/"}),
+    }
+
+    for name, csr := range uncovered {
+        err = dnsOnly.Check(csr)
+        assertBool(errors.As(err, new(PolicyError)), "Check uncovered " + name)
+    }
+
+    // without rule, the names are not restricted
+    err = Policy{}.Check(uncovered["URI"])
+    assertError(err, "Check without rule")
+}
+
+func Test_KeyUsage(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    ca, err := NewRoot(pkix.Name{CommonName: "Root"}, newSigner(t, "ECDSA"), 10 * year, NewMemoryStore())
+    assertError(err, "NewRoot")
+
+    // the server profile asks for digitalSignature and keyEncipherment,
+    // keyEncipherment is kept for the keys that can encipher keys
+    tests := map[string]x509.KeyUsage{
+        "RSA":     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+        "SM2":     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+        "ECDSA":   x509.KeyUsageDigitalSignature,
+        "Ed25519": x509.KeyUsageDigitalSignature,
+    }
+
+    for name, want := range tests {
+        cert, err := ca.Issue(newCSR(t, newSigner(t, name), "a", "a.example.com"), "server")
+        assertError(err, "Issue " + name)
+        assertEqual(cert.KeyUsage, want, "KeyUsage " + name)
+    }
+}
+
+func Test_Linter(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ca, err := NewRoot(pkix.Name{CommonName: "Root"}, newSigner(t, "SM2"), 10 * year, NewMemoryStore())
+    assertError(err, "NewRoot")
+
+    ca.Linter = lint.DefaultRegistry()
+
+    // ECDSA keys can not encipher keys
+    server, err := ca.Issue(newCSR(t, newSigner(t, "ECDSA"), "www.example.com", "www.example.com"), "server")
+    assertError(err, "server")
+    assertEqual(server.KeyUsage, x509.KeyUsageDigitalSignature, "server")
+
+    // the common name is not one of the DNS names
+    _, err = ca.Issue(newCSR(t, newSigner(t, "ECDSA"), "mail.example.com", "www.example.com"), "server")
+
+    var lintErr *lint.CertificateError
+    assertBool(errors.As(err, &lintErr), "common name")
+    assertEqual(lintErr.Findings[0].Lint, "tls_common_name_not_in_san", "common name")
+
+    long := ServerProfile()
+    long.Name = "long"
+    long.Validity = 2 * year
+    ca.AddProfile(long)
+
+    _, err = ca.Issue(newCSR(t, newSigner(t, "ECDSA"), "www.example.com", "www.example.com"), "long")
+    assertBool(errors.As(err, &lintErr), "validity")
+    assertEqual(lintErr.Findings[0].Lint, "tls_validity_period", "validity")
+
+    // nothing was issued for the refused templates
+    recs, err := ca.Store().List()
+    assertError(err, "List")
+    assertEqual(len(recs), 1, "List")
+
+    // SM2 keys for signature and encryption only warn
+    key := newSigner(t, "SM2")
+
+    _, err = ca.Issue(newCSR(t, key, "sm2.example.com", "sm2.example.com"), "server")
+    assertError(err, "SM2 server")
+
+    ca.LintLevel = lint.Warning
+
+    _, err = ca.Issue(newCSR(t, key, "sm2.example.com", "sm2.example.com"), "server")
+    assertBool(errors.As(err, &lintErr), "SM2 server warning")
+    assertEqual(lintErr.Findings[0].Lint, "sm2_dual_certificate_key_usage", "SM2 server warning")
+}
+
+func Test_SubCA(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    root, err := NewRoot(pkix.Name{CommonName: "Root"}, newSigner(t, "SM2"), 10 * year, NewMemoryStore())
+    assertError(err, "NewRoot")
+
+    root.AddProfile(SubCAProfile(0, "example.com"))
+
+    subKey := newSigner(t, "SM2")
+
+    subCert, err := root.Issue(newCSR(t, subKey, "Sub CA"), "sub-ca")
+    assertError(err, "Issue sub-ca")
+    assertBool(subCert.IsCA, "IsCA")
+    assertBool(subCert.MaxPathLenZero, "MaxPathLenZero")
+    assertEqual(subCert.PermittedDNSDomains, []string{"example.com"}, "PermittedDNSDomains")
+    assertBool(subCert.PermittedDNSDomainsCritical, "PermittedDNSDomainsCritical")
+
+    sub, err := New(subCert, subKey, NewMemoryStore())
+    assertError(err, "New")
+
+    _, err = New(subCert, newSigner(t, "SM2"), NewMemoryStore())
+    assertBool(err != nil, "New with other key")
+
+    roots := x509.NewCertPool()
+    roots.AddCert(root.Certificate())
+
+    inters := x509.NewCertPool()
+    inters.AddCert(subCert)
+
+    good, err := sub.Issue(newCSR(t, newSigner(t, "SM2"), "a", "a.example.com"), "server")
+    assertError(err, "Issue")
+
+    _, err = good.Verify(x509.VerifyOptions{
+        Roots:         roots,
+        Intermediates: inters,
+    })
+    assertError(err, "Verify")
+
+    bad, err := sub.Issue(newCSR(t, newSigner(t, "SM2"), "a", "a.example.org"), "server")
+    assertError(err, "Issue")
+
+    _, err = bad.Verify(x509.VerifyOptions{
+        Roots:         roots,
+        Intermediates: inters,
+    })
+    assertBool(err != nil, "Verify outside name constraints")
+
+    // a sub-CA with a zero path length can not issue CA certificates
+    sub.AddProfile(SubCAProfile(-1))
+    _, err = sub.Issue(newCSR(t, newSigner(t, "SM2"), "Sub Sub CA"), "sub-ca")
+    assertBool(err != nil, "Issue sub-sub-ca")
+}
+
+func Test_ServiceLabels(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    ca, err := NewRoot(pkix.Name{CommonName: "Root"}, newSigner(t, "SM2"), 10 * year, NewMemoryStore())
+    assertError(err, "NewRoot")
+
+    ca.AddProfile(SubCAProfile(0))
+
+    s := NewService(ca)
+    s.Labels["device"] = "client"
+    s.Labels["ca"] = "sub-ca"
+
+    key := newSigner(t, "SM2")
+
+    cert, err := s.Enroll(ctx, newCSR(t, key, "a", "a.example.com"), "")
+    assertError(err, "Enroll")
+    assertEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, "Enroll")
+
+    cert, err = s.Enroll(ctx, newCSR(t, key, "a"), "device")
+    assertError(err, "Enroll device")
+    assertEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, "Enroll device")
+
+    // registered profiles out of Labels
+    _, err = s.Enroll(ctx, newCSR(t, key, "a"), "code-signing")
+    assertBool(err != nil, "Enroll code-signing")
+
+    // CA profiles, with a label or as the default
+    _, err = s.Enroll(ctx, newCSR(t, key, "a"), "ca")
+    assertBool(err != nil, "Enroll ca")
+
+    s.DefaultProfile = "sub-ca"
+    _, err = s.Enroll(ctx, newCSR(t, key, "a"), "")
+    assertBool(err != nil, "Enroll default sub-ca")
+}
+
+func Test_FileStore(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    dir := t.TempDir()
+
+    store, err := NewFileStore(dir)
+    assertError(err, "NewFileStore")
+
+    signer := newSigner(t, "ECDSA")
+
+    ca, err := NewRoot(pkix.Name{CommonName: "Root"}, signer, 10 * year, store)
+    assertError(err, "NewRoot")
+
+    cert, err := ca.Issue(newCSR(t, newSigner(t, "ECDSA"), "alice"), "client")
+    assertError(err, "Issue")
+
+    err = ca.Revoke(cert.SerialNumber, x509.ReasonCessationOfOperation)
+    assertError(err, "Revoke")
+
+    crl1, err := ca.PublishCRL()
+    assertError(err, "PublishCRL")
+
+    // reopen the store
+    store2, err := NewFileStore(dir)
+    assertError(err, "NewFileStore")
+
+    ca2, err := New(ca.Certificate(), signer, store2)
+    assertError(err, "New")
+
+    rec, err := ca2.Lookup(cert.SerialNumber)
+    assertError(err, "Lookup")
+    assertBool(rec.Revoked, "Revoked")
+    assertEqual(rec.ReasonCode, x509.ReasonCessationOfOperation, "ReasonCode")
+    assertEqual(rec.Raw, cert.Raw, "Raw")
+
+    last, err := ca2.CRL()
+    assertError(err, "CRL")
+    assertEqual(last, crl1, "CRL")
+
+    crl2, err := ca2.PublishCRL()
+    assertError(err, "PublishCRL")
+
+    crl, err := x509.ParseRevocationList(crl2)
+    assertError(err, "ParseRevocationList")
+    assertEqual(crl.Number.Int64(), int64(2), "Number")
+
+    // serial numbers are unique
+    err = store2.Put(rec)
+    assertBool(errors.Is(err, ErrSerialExists), "Put")
+
+    recs, err := store2.List()
+    assertError(err, "List")
+    assertEqual(len(recs), 1, "List")
+
+    _, err = store2.Get(ca.Certificate().SerialNumber)
+    assertBool(errors.Is(err, ErrNotFound), "Get")
+}
+
+func Test_MemoryStore(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    store := NewMemoryStore()
+
+    rec := &Record{
+        SerialNumber: big.NewInt(2),
+        Raw:          []byte("cert"),
+        Profile:      "server",
+    }
+
+    assertError(store.Put(rec), "Put")
+    assertBool(errors.Is(store.Put(rec), ErrSerialExists), "Put twice")
+    assertError(store.Put(&Record{SerialNumber: big.NewInt(1)}), "Put")
+
+    rec.Revoked = true
+    assertError(store.Update(rec), "Update")
+    assertBool(errors.Is(store.Update(&Record{SerialNumber: big.NewInt(3)}), ErrNotFound), "Update unknown")
+
+    got, err := store.Get(big.NewInt(2))
+    assertError(err, "Get")
+    assertEqual(got, rec, "Get")
+
+    recs, err := store.List()
+    assertError(err, "List")
+    assertEqual(len(recs), 2, "List")
+    assertEqual(recs[0].SerialNumber.Int64(), int64(1), "List order")
+
+    _, err = store.LoadCRL()
+    assertBool(errors.Is(err, ErrNotFound), "LoadCRL")
+
+    n, err := store.NextCRLNumber()
+    assertError(err, "NextCRLNumber")
+    assertEqual(n.Int64(), int64(1), "NextCRLNumber")
+}
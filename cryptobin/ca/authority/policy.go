@@ -0,0 +1,193 @@
+package authority
+
+import (
+    "fmt"
+    "net"
+    "strings"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+)
+
+// DefaultMinRSABits is the smallest RSA key accepted when the policy
+// does not set one.
+const DefaultMinRSABits = 2048
+
+// PolicyError is returned for certificate requests a profile does not
+// accept.
+type PolicyError struct {
+    Reason string
+}
+
+func (e PolicyError) Error() string {
+    return "go-cryptobin/ca: request rejected: " + e.Reason
+}
+
+// Policy restricts the certificate requests accepted by a profile.
+// Every request must be self-signed with a RSA, ECDSA P-256, P-384 or
+// P-521, Ed25519, SM2 or GOST key.
+type Policy struct {
+    // RequireCommonName rejects requests without a subject common name.
+    RequireCommonName bool
+
+    // RequireSAN rejects requests without a DNS name or IP address.
+    RequireSAN bool
+
+    // ForbidSAN rejects requests with subject alternative names.
+    ForbidSAN bool
+
+    // AllowedDNSDomains, AllowedIPRanges, AllowedEmailDomains and
+    // AllowedURIDomains restrict the subject alternative names: the DNS
+    // names, the domains of the email addresses and the hosts of the
+    // URIs must be in the domains, the IP addresses in the ranges.
+    // When one of them is set, the names of a type without rule are
+    // rejected.
+    AllowedDNSDomains   []string
+    AllowedIPRanges     []*net.IPNet
+    AllowedEmailDomains []string
+    AllowedURIDomains   []string
+
+    // MinRSABits is the smallest RSA key accepted, DefaultMinRSABits
+    // if zero.
+    MinRSABits int
+
+    // KeyAlgorithms, if not empty, are the public key algorithms
+    // accepted.
+    KeyAlgorithms []x509.PublicKeyAlgorithm
+}
+
+// Check returns a PolicyError if the policy does not accept csr.
+func (p Policy) Check(csr *x509.CertificateRequest) error {
+    if err := csr.CheckSignature(); err != nil {
+        return PolicyError{"invalid signature: " + err.Error()}
+    }
+
+    if err := p.checkKey(csr); err != nil {
+        return err
+    }
+
+    if p.RequireCommonName && csr.Subject.CommonName == "" {
+        return PolicyError{"no common name"}
+    }
+
+    hasSAN := len(csr.DNSNames) > 0 || len(csr.IPAddresses) > 0
+    if p.RequireSAN && !hasSAN {
+        return PolicyError{"no DNS name or IP address"}
+    }
+
+    if p.ForbidSAN && (hasSAN || len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0) {
+        return PolicyError{"subject alternative names are not allowed"}
+    }
+
+    return p.checkSAN(csr)
+}
+
+func (p Policy) checkSAN(csr *x509.CertificateRequest) error {
+    if len(p.AllowedDNSDomains) == 0 && len(p.AllowedIPRanges) == 0 &&
+        len(p.AllowedEmailDomains) == 0 && len(p.AllowedURIDomains) == 0 {
+        return nil
+    }
+
+    for _, name := range csr.DNSNames {
+        if !inDomains(name, p.AllowedDNSDomains) {
+            return PolicyError{fmt.Sprintf("DNS name %q is not allowed", name)}
+        }
+    }
+
+    for _, ip := range csr.IPAddresses {
+        if !inIPRanges(ip, p.AllowedIPRanges) {
+            return PolicyError{fmt.Sprintf("IP address %s is not allowed", ip)}
+        }
+    }
+
+    for _, email := range csr.EmailAddresses {
+        i := strings.LastIndexByte(email, '@')
+        if i < 0 || !inDomains(email[i+1:], p.AllowedEmailDomains) {
+            return PolicyError{fmt.Sprintf("email address %q is not allowed", email)}
+        }
+    }
+
+    for _, uri := range csr.URIs {
+        if !inDomains(uri.Hostname(), p.AllowedURIDomains) {
+            return PolicyError{fmt.Sprintf("URI %q is not allowed", uri.String())}
+        }
+    }
+
+    return nil
+}
+
+func (p Policy) checkKey(csr *x509.CertificateRequest) error {
+    if len(p.KeyAlgorithms) > 0 {
+        allowed := false
+        for _, algo := range p.KeyAlgorithms {
+            if algo == csr.PublicKeyAlgorithm {
+                allowed = true
+                break
+            }
+        }
+
+        if !allowed {
+            return PolicyError{fmt.Sprintf("key algorithm %d is not allowed", csr.PublicKeyAlgorithm)}
+        }
+    }
+
+    switch pub := csr.PublicKey.(type) {
+        case *rsa.PublicKey:
+            minBits := p.MinRSABits
+            if minBits == 0 {
+                minBits = DefaultMinRSABits
+            }
+
+            if pub.N.BitLen() < minBits {
+                return PolicyError{fmt.Sprintf("RSA key of %d bits is too small", pub.N.BitLen())}
+            }
+        case *ecdsa.PublicKey:
+            switch pub.Curve {
+                case elliptic.P256(), elliptic.P384(), elliptic.P521():
+                default:
+                    return PolicyError{"ECDSA curve is not allowed"}
+            }
+        case ed25519.PublicKey:
+        case *sm2.PublicKey:
+        case *gost.PublicKey:
+        default:
+            return PolicyError{fmt.Sprintf("key type %T is not allowed", pub)}
+    }
+
+    return nil
+}
+
+// inDomains reports whether name is one of the domains or a subdomain
+// of one of them.
+func inDomains(name string, domains []string) bool {
+    name = strings.ToLower(strings.TrimSuffix(name, "."))
+    if name == "" {
+        return false
+    }
+
+    for _, domain := range domains {
+        domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+
+        if name == domain || strings.HasSuffix(name, "."+domain) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// inIPRanges reports whether ip is in one of the ranges.
+func inIPRanges(ip net.IP, ranges []*net.IPNet) bool {
+    for _, r := range ranges {
+        if r.Contains(ip) {
+            return true
+        }
+    }
+
+    return false
+}
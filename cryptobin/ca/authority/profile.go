@@ -0,0 +1,154 @@
+package authority
+
+import (
+    "net"
+    "time"
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// Profile describes a kind of certificate an Authority issues and the
+// certificate requests it accepts for it.
+type Profile struct {
+    // Name of the profile, used to select it when issuing.
+    Name string
+
+    // Validity of the certificates. It is cut to the validity of the
+    // CA certificate.
+    Validity time.Duration
+
+    // KeyUsage and ExtKeyUsage of the certificates. The digital
+    // signature and key encipherment usages are only kept when the
+    // public key algorithm allows them.
+    KeyUsage    x509.KeyUsage
+    ExtKeyUsage []x509.ExtKeyUsage
+
+    // IsCA issues CA certificates, with a path length constraint of
+    // MaxPathLen. A negative MaxPathLen means no constraint.
+    IsCA       bool
+    MaxPathLen int
+
+    // Name constraints set in the CA certificates.
+    PermittedDNSDomainsCritical bool
+    PermittedDNSDomains         []string
+    ExcludedDNSDomains          []string
+    PermittedIPRanges           []*net.IPNet
+    ExcludedIPRanges            []*net.IPNet
+    PermittedEmailAddresses     []string
+    ExcludedEmailAddresses      []string
+
+    // Policy is checked against the certificate requests.
+    Policy Policy
+}
+
+// ServerProfile returns the profile of TLS server certificates. The
+// requests must name at least one DNS name or IP address.
+func ServerProfile() *Profile {
+    return &Profile{
+        Name:        "server",
+        Validity:    365 * 24 * time.Hour,
+        KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+        ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+        MaxPathLen:  -1,
+        Policy:      Policy{
+            RequireSAN: true,
+        },
+    }
+}
+
+// ClientProfile returns the profile of TLS client certificates.
+func ClientProfile() *Profile {
+    return &Profile{
+        Name:        "client",
+        Validity:    365 * 24 * time.Hour,
+        KeyUsage:    x509.KeyUsageDigitalSignature,
+        ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+        MaxPathLen:  -1,
+        Policy:      Policy{
+            RequireCommonName: true,
+        },
+    }
+}
+
+// CodeSigningProfile returns the profile of code signing certificates.
+// The requests can not ask for DNS names or IP addresses.
+func CodeSigningProfile() *Profile {
+    return &Profile{
+        Name:        "code-signing",
+        Validity:    3 * 365 * 24 * time.Hour,
+        KeyUsage:    x509.KeyUsageDigitalSignature,
+        ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+        MaxPathLen:  -1,
+        Policy:      Policy{
+            RequireCommonName: true,
+            ForbidSAN:         true,
+        },
+    }
+}
+
+// SubCAProfile returns the profile of intermediate CA certificates with
+// the path length constraint maxPathLen, limited to the DNS domains
+// permitted, if any.
+func SubCAProfile(maxPathLen int, permitted ...string) *Profile {
+    return &Profile{
+        Name:                        "sub-ca",
+        Validity:                    5 * 365 * 24 * time.Hour,
+        KeyUsage:                    x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+        IsCA:                        true,
+        MaxPathLen:                  maxPathLen,
+        PermittedDNSDomainsCritical: len(permitted) > 0,
+        PermittedDNSDomains:         permitted,
+        Policy:                      Policy{
+            RequireCommonName: true,
+            ForbidSAN:         true,
+        },
+    }
+}
+
+// template returns the certificate template of the profile for the
+// request, valid from notBefore.
+func (p *Profile) template(csr *x509.CertificateRequest, notBefore time.Time) *x509.Certificate {
+    tmpl := &x509.Certificate{
+        Subject:                     csr.Subject,
+        NotBefore:                   notBefore,
+        NotAfter:                    notBefore.Add(p.Validity),
+        KeyUsage:                    keyUsageFor(p.KeyUsage, csr.PublicKey),
+        ExtKeyUsage:                 p.ExtKeyUsage,
+        BasicConstraintsValid:       true,
+        IsCA:                        p.IsCA,
+        PermittedDNSDomainsCritical: p.PermittedDNSDomainsCritical,
+        PermittedDNSDomains:         p.PermittedDNSDomains,
+        ExcludedDNSDomains:          p.ExcludedDNSDomains,
+        PermittedIPRanges:           p.PermittedIPRanges,
+        ExcludedIPRanges:            p.ExcludedIPRanges,
+        PermittedEmailAddresses:     p.PermittedEmailAddresses,
+        ExcludedEmailAddresses:      p.ExcludedEmailAddresses,
+    }
+
+    if p.IsCA {
+        tmpl.MaxPathLen = p.MaxPathLen
+        tmpl.MaxPathLenZero = p.MaxPathLen == 0
+    } else {
+        tmpl.MaxPathLen = -1
+        tmpl.DNSNames = csr.DNSNames
+        tmpl.IPAddresses = csr.IPAddresses
+        tmpl.EmailAddresses = csr.EmailAddresses
+        tmpl.URIs = csr.URIs
+    }
+
+    return tmpl
+}
+
+// keyUsageFor drops the key usages the public key can not be used for:
+// ECDSA and Ed25519 keys can not encipher keys, SM2 keys can.
+func keyUsageFor(usage x509.KeyUsage, pub crypto.PublicKey) x509.KeyUsage {
+    switch pub.(type) {
+        case *ecdsa.PublicKey, ed25519.PublicKey:
+            usage &^= x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment
+    }
+
+    return usage
+}
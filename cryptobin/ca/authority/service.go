@@ -0,0 +1,98 @@
+package authority
+
+import (
+    "bytes"
+    "errors"
+    "context"
+    "math/big"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// Service serves an Authority to the enrollment protocols, it is a
+// CA of the pkcs7/est and pkcs7/scep packages. The label of a request selects the
+// profile in Labels, DefaultProfile if the label is empty.
+type Service struct {
+    Authority *Authority
+
+    // DefaultProfile is the profile of the requests without label,
+    // "server" if empty.
+    DefaultProfile string
+
+    // Labels maps the labels the clients can ask for to the profiles.
+    // The requests with other labels are rejected, and the profiles
+    // issuing CA certificates can not be selected.
+    Labels map[string]string
+
+    // Chain are the certificates from the issuer of the authority
+    // certificate up to the root, returned by CACerts.
+    Chain []*x509.Certificate
+}
+
+// NewService returns a Service of a.
+func NewService(a *Authority) *Service {
+    return &Service{
+        Authority: a,
+        Labels:    map[string]string{
+            "server": "server",
+            "client": "client",
+        },
+    }
+}
+
+// CACerts returns the authority certificate and the chain.
+func (s *Service) CACerts(ctx context.Context, label string) ([]*x509.Certificate, error) {
+    certs := []*x509.Certificate{s.Authority.Certificate()}
+
+    return append(certs, s.Chain...), nil
+}
+
+// Enroll issues a certificate for csr with the profile of label.
+func (s *Service) Enroll(ctx context.Context, csr *x509.CertificateRequest, label string) (*x509.Certificate, error) {
+    profile, err := s.profile(label)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.Authority.Issue(csr, profile)
+}
+
+// Reenroll renews cert if csr has the same key, otherwise it re-keys
+// cert.
+func (s *Service) Reenroll(ctx context.Context, cert *x509.Certificate, csr *x509.CertificateRequest, label string) (*x509.Certificate, error) {
+    if bytes.Equal(csr.RawSubjectPublicKeyInfo, cert.RawSubjectPublicKeyInfo) {
+        return s.Authority.Renew(cert)
+    }
+
+    return s.Authority.Rekey(cert, csr)
+}
+
+// Certificate returns the issued certificate with the serial number.
+func (s *Service) Certificate(ctx context.Context, serial *big.Int) (*x509.Certificate, error) {
+    rec, err := s.Authority.Lookup(serial)
+    if err != nil {
+        return nil, err
+    }
+
+    return x509.ParseCertificate(rec.Raw)
+}
+
+func (s *Service) profile(label string) (string, error) {
+    name := s.DefaultProfile
+    if name == "" {
+        name = "server"
+    }
+
+    if label != "" {
+        var ok bool
+        if name, ok = s.Labels[label]; !ok {
+            return "", errors.New("go-cryptobin/ca: unknown label " + label)
+        }
+    }
+
+    if p := s.Authority.Profile(name); p != nil && p.IsCA {
+        return "", errors.New("go-cryptobin/ca: profile " + name + " issues CA certificates")
+    }
+
+    return name, nil
+}
@@ -0,0 +1,171 @@
+package authority
+
+import (
+    "sort"
+    "sync"
+    "time"
+    "errors"
+    "math/big"
+)
+
+var (
+    ErrNotFound     = errors.New("go-cryptobin/ca: certificate not found")
+    ErrSerialExists = errors.New("go-cryptobin/ca: serial number already used")
+)
+
+// Record is a certificate issued by an Authority.
+type Record struct {
+    // SerialNumber of the certificate.
+    SerialNumber *big.Int
+
+    // Raw is the DER-encoded certificate.
+    Raw []byte
+
+    // Profile is the name of the profile the certificate was issued
+    // with.
+    Profile string
+
+    // Revoked is set once the certificate is revoked, with the time and
+    // the reason code of the revocation.
+    Revoked        bool
+    RevocationTime time.Time
+    ReasonCode     int
+}
+
+// Store persists the state of an Authority: the issued certificates,
+// the CRL number and the last published CRL.
+type Store interface {
+    // Put saves a new record. It returns ErrSerialExists if a record
+    // with the same serial number is stored already.
+    Put(rec *Record) error
+
+    // Update replaces a stored record.
+    Update(rec *Record) error
+
+    // Get returns the record with the serial number, or ErrNotFound.
+    Get(serial *big.Int) (*Record, error)
+
+    // List returns all the records, ordered by serial number.
+    List() ([]*Record, error)
+
+    // NextCRLNumber increments and returns the CRL number.
+    NextCRLNumber() (*big.Int, error)
+
+    // SaveCRL replaces the last published CRL.
+    SaveCRL(der []byte) error
+
+    // LoadCRL returns the last published CRL, or ErrNotFound.
+    LoadCRL() ([]byte, error)
+}
+
+// MemoryStore is a Store keeping the state in memory.
+type MemoryStore struct {
+    mu        sync.Mutex
+    records   map[string]*Record
+    crlNumber *big.Int
+    crl       []byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+    return &MemoryStore{
+        records:   make(map[string]*Record),
+        crlNumber: new(big.Int),
+    }
+}
+
+func (s *MemoryStore) Put(rec *Record) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := serialKey(rec.SerialNumber)
+    if _, ok := s.records[key]; ok {
+        return ErrSerialExists
+    }
+
+    s.records[key] = copyRecord(rec)
+    return nil
+}
+
+func (s *MemoryStore) Update(rec *Record) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := serialKey(rec.SerialNumber)
+    if _, ok := s.records[key]; !ok {
+        return ErrNotFound
+    }
+
+    s.records[key] = copyRecord(rec)
+    return nil
+}
+
+func (s *MemoryStore) Get(serial *big.Int) (*Record, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    rec, ok := s.records[serialKey(serial)]
+    if !ok {
+        return nil, ErrNotFound
+    }
+
+    return copyRecord(rec), nil
+}
+
+func (s *MemoryStore) List() ([]*Record, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    recs := make([]*Record, 0, len(s.records))
+    for _, rec := range s.records {
+        recs = append(recs, copyRecord(rec))
+    }
+
+    sortRecords(recs)
+    return recs, nil
+}
+
+func (s *MemoryStore) NextCRLNumber() (*big.Int, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.crlNumber = new(big.Int).Add(s.crlNumber, big.NewInt(1))
+    return new(big.Int).Set(s.crlNumber), nil
+}
+
+func (s *MemoryStore) SaveCRL(der []byte) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.crl = append([]byte(nil), der...)
+    return nil
+}
+
+func (s *MemoryStore) LoadCRL() ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if s.crl == nil {
+        return nil, ErrNotFound
+    }
+
+    return append([]byte(nil), s.crl...), nil
+}
+
+func serialKey(serial *big.Int) string {
+    return serial.Text(16)
+}
+
+func copyRecord(rec *Record) *Record {
+    c := *rec
+    c.SerialNumber = new(big.Int).Set(rec.SerialNumber)
+    c.Raw = append([]byte(nil), rec.Raw...)
+
+    return &c
+}
+
+func sortRecords(recs []*Record) {
+    sort.Slice(recs, func(i, j int) bool {
+        return recs[i].SerialNumber.Cmp(recs[j].SerialNumber) < 0
+    })
+}
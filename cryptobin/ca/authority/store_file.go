@@ -0,0 +1,211 @@
+package authority
+
+import (
+    "os"
+    "sync"
+    "errors"
+    "strings"
+    "math/big"
+    "encoding/json"
+    "path/filepath"
+
+    "github.com/deatil/go-cryptobin/pubkey/stateful"
+)
+
+// FileStore is a Store keeping the state in a directory:
+//
+//     certs/<serial>.json  the records, serial in hexadecimal
+//     crlnumber            the last CRL number, in decimal
+//     crl.der              the last published CRL
+//
+// New records are created exclusively, and the other files are replaced
+// atomically, so a serial number is never handed out twice even if the
+// process stops in the middle of an issuance.
+type FileStore struct {
+    mu  sync.Mutex
+    dir string
+}
+
+// NewFileStore returns a FileStore using dir, which is created if
+// needed.
+func NewFileStore(dir string) (*FileStore, error) {
+    err := os.MkdirAll(filepath.Join(dir, "certs"), 0700)
+    if err != nil {
+        return nil, err
+    }
+
+    return &FileStore{
+        dir: dir,
+    }, nil
+}
+
+// Dir returns the directory of the store.
+func (s *FileStore) Dir() string {
+    return s.dir
+}
+
+func (s *FileStore) Put(rec *Record) (err error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    data, err := json.Marshal(rec)
+    if err != nil {
+        return err
+    }
+
+    path := s.recordPath(rec.SerialNumber)
+
+    f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+    if err != nil {
+        if errors.Is(err, os.ErrExist) {
+            return ErrSerialExists
+        }
+
+        return err
+    }
+
+    defer func() {
+        if err != nil {
+            os.Remove(path)
+        }
+    }()
+
+    if _, err = f.Write(data); err != nil {
+        f.Close()
+        return err
+    }
+
+    if err = f.Sync(); err != nil {
+        f.Close()
+        return err
+    }
+
+    return f.Close()
+}
+
+func (s *FileStore) Update(rec *Record) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    path := s.recordPath(rec.SerialNumber)
+    if _, err := os.Stat(path); err != nil {
+        if errors.Is(err, os.ErrNotExist) {
+            return ErrNotFound
+        }
+
+        return err
+    }
+
+    data, err := json.Marshal(rec)
+    if err != nil {
+        return err
+    }
+
+    return stateful.NewFileStore(path).Save(data)
+}
+
+func (s *FileStore) Get(serial *big.Int) (*Record, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    return s.readRecord(s.recordPath(serial))
+}
+
+func (s *FileStore) List() ([]*Record, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    entries, err := os.ReadDir(filepath.Join(s.dir, "certs"))
+    if err != nil {
+        return nil, err
+    }
+
+    var recs []*Record
+    for _, entry := range entries {
+        if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+            continue
+        }
+
+        rec, err := s.readRecord(filepath.Join(s.dir, "certs", entry.Name()))
+        if err != nil {
+            return nil, err
+        }
+
+        recs = append(recs, rec)
+    }
+
+    sortRecords(recs)
+    return recs, nil
+}
+
+func (s *FileStore) NextCRLNumber() (*big.Int, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    store := stateful.NewFileStore(filepath.Join(s.dir, "crlnumber"))
+
+    number := new(big.Int)
+
+    data, err := store.Load()
+    switch {
+        case err == nil:
+            if _, ok := number.SetString(strings.TrimSpace(string(data)), 10); !ok {
+                return nil, errors.New("go-cryptobin/ca: invalid crl number file")
+            }
+        case !errors.Is(err, stateful.ErrNoState):
+            return nil, err
+    }
+
+    number.Add(number, big.NewInt(1))
+
+    if err = store.Save([]byte(number.String())); err != nil {
+        return nil, err
+    }
+
+    return number, nil
+}
+
+func (s *FileStore) SaveCRL(der []byte) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    return stateful.NewFileStore(filepath.Join(s.dir, "crl.der")).Save(der)
+}
+
+func (s *FileStore) LoadCRL() ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    der, err := stateful.NewFileStore(filepath.Join(s.dir, "crl.der")).Load()
+    if errors.Is(err, stateful.ErrNoState) {
+        return nil, ErrNotFound
+    }
+
+    return der, err
+}
+
+func (s *FileStore) recordPath(serial *big.Int) string {
+    return filepath.Join(s.dir, "certs", serialKey(serial) + ".json")
+}
+
+func (s *FileStore) readRecord(path string) (*Record, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        if errors.Is(err, os.ErrNotExist) {
+            return nil, ErrNotFound
+        }
+
+        return nil, err
+    }
+
+    rec := new(Record)
+    if err = json.Unmarshal(data, rec); err != nil {
+        return nil, err
+    }
+
+    if rec.SerialNumber == nil {
+        return nil, errors.New("go-cryptobin/ca: invalid record " + filepath.Base(path))
+    }
+
+    return rec, nil
+}
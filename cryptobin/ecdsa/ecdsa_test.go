@@ -598,3 +598,23 @@ func Test_SignWithEncoding_Two_Check(t *testing.T) {
 
     assertNotEqual(signed2, signed, "Test_SignWithEncoding_Two_Check")
 }
+
+func Test_SplitPrivateKey(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertNotErrorNil := cryptobin_test.AssertNotErrorNilT(t)
+
+    gen := GenerateKey("P256")
+
+    shares, err := gen.SplitPrivateKey(5, 3)
+    assertError(err, "SplitPrivateKey")
+    assertEqual(len(shares), 5, "SplitPrivateKey")
+
+    obj := FromPrivateKeyShares([][]byte{shares[4], shares[0], shares[2]})
+    assertError(obj.Error(), "FromPrivateKeyShares")
+
+    assertEqual(obj.CreatePKCS8PrivateKey().ToKeyString(), gen.CreatePKCS8PrivateKey().ToKeyString(), "FromPrivateKeyShares")
+
+    obj2 := FromPrivateKeyShares(shares[:2])
+    assertNotErrorNil(obj2.Error(), "FromPrivateKeyShares-fail")
+}
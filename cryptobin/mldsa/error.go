@@ -0,0 +1,17 @@
+package mldsa
+
+import (
+    "github.com/deatil/go-cryptobin/tool"
+)
+
+// 添加错误
+func (this MLDSA) AppendError(err ...error) MLDSA {
+    this.Errors = append(this.Errors, err...)
+
+    return this
+}
+
+// 获取错误
+func (this MLDSA) Error() error {
+    return tool.NewError(this.Errors...)
+}
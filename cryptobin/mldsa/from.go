@@ -0,0 +1,185 @@
+package mldsa
+
+import (
+    "io"
+    "crypto/rand"
+
+    "github.com/deatil/go-cryptobin/tool"
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+// 生成密钥
+func (this MLDSA) GenerateKeyWithSeed(reader io.Reader) MLDSA {
+    privateKey, err := mldsa.GenerateKey(reader, this.params)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.privateKey = privateKey
+    this.publicKey  = &privateKey.PublicKey
+
+    return this
+}
+
+// 生成密钥
+// 可选 [ML-DSA-44 | ML-DSA-65 | ML-DSA-87]
+func GenerateKeyWithSeed(reader io.Reader, params string) MLDSA {
+    return defaultMLDSA.SetParams(params).GenerateKeyWithSeed(reader)
+}
+
+// 生成密钥
+func (this MLDSA) GenerateKey() MLDSA {
+    return this.GenerateKeyWithSeed(rand.Reader)
+}
+
+// 生成密钥
+// 可选 [ML-DSA-44 | ML-DSA-65 | ML-DSA-87]
+func GenerateKey(params string) MLDSA {
+    return defaultMLDSA.SetParams(params).GenerateKey()
+}
+
+// ==========
+
+// 私钥
+func (this MLDSA) FromPrivateKey(key []byte) MLDSA {
+    parsedKey, err := this.ParsePrivateKeyFromPEM(key)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.privateKey = parsedKey
+    this.params     = parsedKey.Params
+
+    return this
+}
+
+// 私钥
+func FromPrivateKey(key []byte) MLDSA {
+    return defaultMLDSA.FromPrivateKey(key)
+}
+
+// 私钥带密码
+func (this MLDSA) FromPrivateKeyWithPassword(key []byte, password string) MLDSA {
+    parsedKey, err := this.ParsePrivateKeyFromPEMWithPassword(key, password)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.privateKey = parsedKey
+    this.params     = parsedKey.Params
+
+    return this
+}
+
+// 私钥
+func FromPrivateKeyWithPassword(key []byte, password string) MLDSA {
+    return defaultMLDSA.FromPrivateKeyWithPassword(key, password)
+}
+
+// 公钥
+func (this MLDSA) FromPublicKey(key []byte) MLDSA {
+    parsedKey, err := this.ParsePublicKeyFromPEM(key)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.publicKey = parsedKey
+    this.params    = parsedKey.Params
+
+    return this
+}
+
+// 公钥
+func FromPublicKey(key []byte) MLDSA {
+    return defaultMLDSA.FromPublicKey(key)
+}
+
+// ==========
+
+// DER 私钥
+func (this MLDSA) FromPrivateKeyDer(der []byte) MLDSA {
+    key := tool.EncodeDerToPem(der, "PRIVATE KEY")
+
+    return this.FromPrivateKey(key)
+}
+
+// DER 公钥
+func (this MLDSA) FromPublicKeyDer(der []byte) MLDSA {
+    key := tool.EncodeDerToPem(der, "PUBLIC KEY")
+
+    return this.FromPublicKey(key)
+}
+
+// ==========
+
+// 私钥 Seed
+func (this MLDSA) FromPrivateKeySeed(seed []byte) MLDSA {
+    privateKey, err := mldsa.NewKeyFromSeed(this.params, seed)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.privateKey = privateKey
+
+    return this
+}
+
+// 私钥 Seed
+// 可选 [ML-DSA-44 | ML-DSA-65 | ML-DSA-87]
+func FromPrivateKeySeed(seed []byte, params string) MLDSA {
+    return defaultMLDSA.SetParams(params).FromPrivateKeySeed(seed)
+}
+
+// ==========
+
+// 字节
+func (this MLDSA) FromBytes(data []byte) MLDSA {
+    this.data = data
+
+    return this
+}
+
+// 字节
+func FromBytes(data []byte) MLDSA {
+    return defaultMLDSA.FromBytes(data)
+}
+
+// 字符
+func (this MLDSA) FromString(data string) MLDSA {
+    this.data = []byte(data)
+
+    return this
+}
+
+// 字符
+func FromString(data string) MLDSA {
+    return defaultMLDSA.FromString(data)
+}
+
+// Base64
+func (this MLDSA) FromBase64String(data string) MLDSA {
+    newData, err := tool.Base64Decode(data)
+
+    this.data = newData
+
+    return this.AppendError(err)
+}
+
+// Base64
+func FromBase64String(data string) MLDSA {
+    return defaultMLDSA.FromBase64String(data)
+}
+
+// Hex
+func (this MLDSA) FromHexString(data string) MLDSA {
+    newData, err := tool.HexDecode(data)
+
+    this.data = newData
+
+    return this.AppendError(err)
+}
+
+// Hex
+func FromHexString(data string) MLDSA {
+    return defaultMLDSA.FromHexString(data)
+}
@@ -0,0 +1,55 @@
+package mldsa
+
+import (
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+// 获取 PrivateKey
+func (this MLDSA) GetPrivateKey() *mldsa.PrivateKey {
+    return this.privateKey
+}
+
+// 获取 PrivateKeySeed
+func (this MLDSA) GetPrivateKeySeed() []byte {
+    return this.privateKey.Seed()
+}
+
+// 获取 PublicKey
+func (this MLDSA) GetPublicKey() *mldsa.PublicKey {
+    return this.publicKey
+}
+
+// 获取 Params
+func (this MLDSA) GetParams() *Params {
+    return this.params
+}
+
+// 获取 Options
+func (this MLDSA) GetOptions() *Options {
+    return this.options
+}
+
+// 获取 keyData
+func (this MLDSA) GetKeyData() []byte {
+    return this.keyData
+}
+
+// 获取 data
+func (this MLDSA) GetData() []byte {
+    return this.data
+}
+
+// 获取 parsedData
+func (this MLDSA) GetParsedData() []byte {
+    return this.parsedData
+}
+
+// 获取验证后情况
+func (this MLDSA) GetVerify() bool {
+    return this.verify
+}
+
+// 获取错误
+func (this MLDSA) GetErrors() []error {
+    return this.Errors
+}
@@ -0,0 +1,34 @@
+package mldsa
+
+import (
+    "errors"
+    "encoding/pem"
+)
+
+// 生成公钥
+func (this MLDSA) MakePublicKey() MLDSA {
+    this.publicKey = nil
+
+    if this.privateKey == nil {
+        err := errors.New("privateKey empty.")
+        return this.AppendError(err)
+    }
+
+    // 导出公钥
+    this.publicKey = &this.privateKey.PublicKey
+
+    return this
+}
+
+// 生成密钥 der 数据
+func (this MLDSA) MakeKeyDer() MLDSA {
+    var block *pem.Block
+    if block, _ = pem.Decode(this.keyData); block == nil {
+        err := errors.New("keyData error.")
+        return this.AppendError(err)
+    }
+
+    this.keyData = block.Bytes
+
+    return this
+}
@@ -0,0 +1,73 @@
+package mldsa
+
+import (
+    "crypto"
+
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+type (
+    // 设置
+    Options = mldsa.Options
+
+    // 参数
+    Params = mldsa.Params
+)
+
+/**
+ * ML-DSA
+ *
+ * @create 2026-10-19
+ * @author deatil
+ */
+type MLDSA struct {
+    // 私钥
+    privateKey *mldsa.PrivateKey
+
+    // 公钥
+    publicKey *mldsa.PublicKey
+
+    // 参数
+    params *Params
+
+    // 设置
+    options *Options
+
+    // [私钥/公钥]数据
+    keyData []byte
+
+    // 传入数据
+    data []byte
+
+    // 解析后的数据
+    parsedData []byte
+
+    // 验证结果
+    verify bool
+
+    // 错误
+    Errors []error
+}
+
+// 构造函数
+func NewMLDSA() MLDSA {
+    return MLDSA{
+        params:  mldsa.MLDSA65(),
+        options: &Options{
+            Hash:    crypto.Hash(0),
+            Context: "",
+        },
+        verify:  false,
+        Errors:  make([]error, 0),
+    }
+}
+
+// 构造函数
+func New() MLDSA {
+    return NewMLDSA()
+}
+
+var (
+    // 默认
+    defaultMLDSA = NewMLDSA()
+)
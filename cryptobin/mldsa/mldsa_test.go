@@ -0,0 +1,127 @@
+package mldsa
+
+import (
+    "testing"
+
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+var testParams = []string{
+    "ML-DSA-44",
+    "ML-DSA-65",
+    "ML-DSA-87",
+}
+
+func Test_SignVerify(t *testing.T) {
+    for _, name := range testParams {
+        t.Run(name, func(t *testing.T) {
+            test_SignVerify(t, name, "", "")
+            test_SignVerify(t, name, "", "ase3ertygfa1")
+            test_SignVerify(t, name, "SHA256", "")
+            test_SignVerify(t, name, "SHA512", "ase3ertygfa1")
+            test_SignVerify(t, name, "SHA3_256", "ase3ertygfa1")
+        })
+    }
+}
+
+func test_SignVerify(t *testing.T, params, hash, ctx string) {
+    assertBool := cryptobin_test.AssertBoolT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertNotEmpty := cryptobin_test.AssertNotEmptyT(t)
+
+    data := "test-pass"
+
+    obj := GenerateKey(params)
+    assertError(obj.Error(), "Test_SignVerify-GenerateKey")
+
+    prikey := obj.CreatePrivateKey().ToKeyString()
+    pubkey := obj.CreatePublicKey().ToKeyString()
+
+    assertNotEmpty(prikey, "Test_SignVerify-prikey")
+    assertNotEmpty(pubkey, "Test_SignVerify-pubkey")
+
+    sign := FromString(data).
+        FromPrivateKey([]byte(prikey)).
+        SetOptions(hash, ctx).
+        Sign()
+    signed := sign.ToBase64String()
+
+    assertError(sign.Error(), "Test_SignVerify-Sign")
+    assertNotEmpty(signed, "Test_SignVerify-Sign")
+
+    verify := FromBase64String(signed).
+        FromPublicKey([]byte(pubkey)).
+        SetOptions(hash, ctx).
+        Verify([]byte(data))
+
+    assertError(verify.Error(), "Test_SignVerify-Verify")
+    assertBool(verify.ToVerify(), "Test_SignVerify-Verify")
+
+    // 错误的 context
+    verify2 := FromBase64String(signed).
+        FromPublicKey([]byte(pubkey)).
+        SetOptions(hash, ctx + "1").
+        Verify([]byte(data))
+
+    if verify2.ToVerify() {
+        t.Error("Test_SignVerify-Verify should fail with other context")
+    }
+}
+
+func Test_SetParamsError(t *testing.T) {
+    obj := New().SetParams("ML-DSA-11")
+    if obj.Error() == nil {
+        t.Error("SetParams should return error")
+    }
+
+    obj = New().SetOptions("MD5")
+    if obj.Error() == nil {
+        t.Error("SetOptions should return error")
+    }
+}
+
+func Test_CreateKeyWithPassword(t *testing.T) {
+    assertBool := cryptobin_test.AssertBoolT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertNotEmpty := cryptobin_test.AssertNotEmptyT(t)
+
+    obj := New().SetParams("ML-DSA-44").GenerateKey()
+    assertError(obj.Error(), "Test_CreateKeyWithPassword-GenerateKey")
+
+    prikey := obj.CreatePrivateKeyWithPassword("123", "AES256CBC", "SHA256").ToKeyString()
+    pubkey := obj.CreatePublicKey().ToKeyString()
+
+    assertNotEmpty(prikey, "Test_CreateKeyWithPassword-prikey")
+
+    newObj := New().
+        FromPrivateKeyWithPassword([]byte(prikey), "123").
+        FromPublicKey([]byte(pubkey))
+
+    assertError(newObj.Error(), "Test_CreateKeyWithPassword-parse")
+    assertBool(newObj.CheckKeyPair(), "Test_CreateKeyWithPassword-CheckKeyPair")
+    assertBool(newObj.GetParams().Name == "ML-DSA-44", "Test_CreateKeyWithPassword-params")
+    assertBool(newObj.GetPrivateKey().Equal(obj.GetPrivateKey()), "Test_CreateKeyWithPassword-Equal")
+}
+
+func Test_FromPrivateKeySeed(t *testing.T) {
+    assertBool := cryptobin_test.AssertBoolT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    obj := GenerateKey("ML-DSA-87")
+    assertError(obj.Error(), "Test_FromPrivateKeySeed-GenerateKey")
+
+    seed := obj.GetPrivateKeySeed()
+    assertEqual(len(seed), 32, "Test_FromPrivateKeySeed-seed")
+
+    newObj := FromPrivateKeySeed(seed, "ML-DSA-87").MakePublicKey()
+    assertError(newObj.Error(), "Test_FromPrivateKeySeed-FromPrivateKeySeed")
+
+    assertBool(newObj.GetPublicKey().Equal(obj.GetPublicKey()), "Test_FromPrivateKeySeed-Equal")
+    assertBool(newObj.WithPublicKey(obj.GetPublicKey()).CheckKeyPair(), "Test_FromPrivateKeySeed-CheckKeyPair")
+
+    other := GenerateKey("ML-DSA-87")
+    if newObj.WithPublicKey(other.GetPublicKey()).CheckKeyPair() {
+        t.Error("CheckKeyPair should fail")
+    }
+}
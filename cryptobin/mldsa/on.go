@@ -0,0 +1,14 @@
+package mldsa
+
+type (
+    // 错误方法
+    MLDSAErrorFunc = func([]error)
+)
+
+// 引出错误信息
+func (this MLDSA) OnError(fn MLDSAErrorFunc) MLDSA {
+    fn(this.Errors)
+
+    return this
+}
+
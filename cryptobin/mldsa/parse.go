@@ -0,0 +1,76 @@
+package mldsa
+
+import (
+    "errors"
+    "encoding/pem"
+
+    "github.com/deatil/go-cryptobin/pkcs8"
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+var (
+    ErrKeyMustBePEMEncoded = errors.New("invalid key: Key must be a PEM encoded PKCS8 key")
+    ErrNotMLDSAPrivateKey  = errors.New("key is not a valid ML-DSA private key")
+    ErrNotMLDSAPublicKey   = errors.New("key is not a valid ML-DSA public key")
+)
+
+// 解析私钥
+func (this MLDSA) ParsePrivateKeyFromPEM(key []byte) (*mldsa.PrivateKey, error) {
+    var err error
+
+    // Parse PEM block
+    var block *pem.Block
+    if block, _ = pem.Decode(key); block == nil {
+        return nil, ErrKeyMustBePEMEncoded
+    }
+
+    // Parse the key
+    var pkey *mldsa.PrivateKey
+    if pkey, err = mldsa.ParsePrivateKey(block.Bytes); err != nil {
+        return nil, ErrNotMLDSAPrivateKey
+    }
+
+    return pkey, nil
+}
+
+// 解析私钥带密码
+func (this MLDSA) ParsePrivateKeyFromPEMWithPassword(key []byte, password string) (*mldsa.PrivateKey, error) {
+    var err error
+
+    // Parse PEM block
+    var block *pem.Block
+    if block, _ = pem.Decode(key); block == nil {
+        return nil, ErrKeyMustBePEMEncoded
+    }
+
+    var blockDecrypted []byte
+    if blockDecrypted, err = pkcs8.DecryptPEMBlock(block, []byte(password)); err != nil {
+        return nil, err
+    }
+
+    var pkey *mldsa.PrivateKey
+    if pkey, err = mldsa.ParsePrivateKey(blockDecrypted); err != nil {
+        return nil, ErrNotMLDSAPrivateKey
+    }
+
+    return pkey, nil
+}
+
+// 解析公钥
+func (this MLDSA) ParsePublicKeyFromPEM(key []byte) (*mldsa.PublicKey, error) {
+    var err error
+
+    // Parse PEM block
+    var block *pem.Block
+    if block, _ = pem.Decode(key); block == nil {
+        return nil, ErrKeyMustBePEMEncoded
+    }
+
+    // Parse the key
+    var pkey *mldsa.PublicKey
+    if pkey, err = mldsa.ParsePublicKey(block.Bytes); err != nil {
+        return nil, ErrNotMLDSAPublicKey
+    }
+
+    return pkey, nil
+}
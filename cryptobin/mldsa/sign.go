@@ -0,0 +1,73 @@
+package mldsa
+
+import (
+    "errors"
+    "crypto"
+    "crypto/rand"
+    _ "crypto/sha256"
+    _ "crypto/sha512"
+
+    _ "golang.org/x/crypto/sha3"
+
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+// 私钥签名
+func (this MLDSA) Sign() MLDSA {
+    if this.privateKey == nil {
+        err := errors.New("privateKey empty.")
+        return this.AppendError(err)
+    }
+
+    data, err := this.dataHash(this.data)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    sig, err := this.privateKey.Sign(rand.Reader, data, this.options)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.parsedData = sig
+
+    return this
+}
+
+// 公钥验证
+func (this MLDSA) Verify(data []byte) MLDSA {
+    if this.publicKey == nil {
+        err := errors.New("publicKey empty.")
+        return this.AppendError(err)
+    }
+
+    data, err := this.dataHash(data)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    err = mldsa.VerifyWithOptions(this.publicKey, data, this.data, this.options)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.verify = true
+
+    return this
+}
+
+// HashML-DSA 时签名数据为摘要
+func (this MLDSA) dataHash(data []byte) ([]byte, error) {
+    if this.options == nil || this.options.Hash == crypto.Hash(0) {
+        return data, nil
+    }
+
+    if !this.options.Hash.Available() {
+        return nil, mldsa.ErrInvalidHash
+    }
+
+    h := this.options.Hash.New()
+    h.Write(data)
+
+    return h.Sum(nil), nil
+}
@@ -0,0 +1,53 @@
+package mldsa
+
+import (
+    "github.com/deatil/go-cryptobin/tool"
+)
+
+// 私钥/公钥
+func (this MLDSA) ToKeyBytes() []byte {
+    return this.keyData
+}
+
+// 私钥/公钥
+func (this MLDSA) ToKeyString() string {
+    return string(this.keyData)
+}
+
+// ==========
+
+// 输出字节
+func (this MLDSA) ToBytes() []byte {
+    return this.parsedData
+}
+
+// 输出字符
+func (this MLDSA) ToString() string {
+    return string(this.parsedData)
+}
+
+// 输出Base64
+func (this MLDSA) ToBase64String() string {
+    return tool.Base64Encode(this.parsedData)
+}
+
+// 输出Hex
+func (this MLDSA) ToHexString() string {
+    return tool.HexEncode(this.parsedData)
+}
+
+// ==========
+
+// 验证结果
+func (this MLDSA) ToVerify() bool {
+    return this.verify
+}
+
+// 验证结果，返回 int 类型
+func (this MLDSA) ToVerifyInt() int {
+    if this.verify {
+        return 1
+    }
+
+    return 0
+}
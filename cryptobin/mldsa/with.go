@@ -0,0 +1,116 @@
+package mldsa
+
+import (
+    "crypto"
+
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+// HashML-DSA 可用 hash
+var hashes = map[string]crypto.Hash{
+    "SHA224":     crypto.SHA224,
+    "SHA256":     crypto.SHA256,
+    "SHA384":     crypto.SHA384,
+    "SHA512":     crypto.SHA512,
+    "SHA512_224": crypto.SHA512_224,
+    "SHA512_256": crypto.SHA512_256,
+    "SHA3_224":   crypto.SHA3_224,
+    "SHA3_256":   crypto.SHA3_256,
+    "SHA3_384":   crypto.SHA3_384,
+    "SHA3_512":   crypto.SHA3_512,
+}
+
+// 设置 PrivateKey
+func (this MLDSA) WithPrivateKey(data *mldsa.PrivateKey) MLDSA {
+    this.privateKey = data
+
+    return this
+}
+
+// 设置 PublicKey
+func (this MLDSA) WithPublicKey(data *mldsa.PublicKey) MLDSA {
+    this.publicKey = data
+
+    return this
+}
+
+// 设置 params
+func (this MLDSA) WithParams(params *Params) MLDSA {
+    this.params = params
+
+    return this
+}
+
+// 设置 params
+// 可选参数 [ML-DSA-44 | ML-DSA-65 | ML-DSA-87]
+func (this MLDSA) SetParams(name string) MLDSA {
+    params, err := mldsa.GetParams(name)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    this.params = params
+
+    return this
+}
+
+// 设置 options
+func (this MLDSA) WithOptions(op *Options) MLDSA {
+    this.options = op
+
+    return this
+}
+
+// 设置 options
+// hash 为空时为 ML-DSA, 否则为 HashML-DSA
+// 可用 hash [SHA224 | SHA256 | SHA384 | SHA512 | SHA512_224 | SHA512_256
+// | SHA3_224 | SHA3_256 | SHA3_384 | SHA3_512]
+func (this MLDSA) SetOptions(hash string, context ...string) MLDSA {
+    ctx := ""
+    if len(context) > 0 {
+        ctx = context[0]
+    }
+
+    h := crypto.Hash(0)
+    if hash != "" {
+        var ok bool
+        if h, ok = hashes[hash]; !ok {
+            return this.AppendError(mldsa.ErrInvalidHash)
+        }
+    }
+
+    this.options = &Options{
+        Hash:    h,
+        Context: ctx,
+    }
+
+    return this
+}
+
+// 设置 data
+func (this MLDSA) WithData(data []byte) MLDSA {
+    this.data = data
+
+    return this
+}
+
+// 设置 parsedData
+func (this MLDSA) WithParsedData(data []byte) MLDSA {
+    this.parsedData = data
+
+    return this
+}
+
+// 设置 verify
+func (this MLDSA) WithVerify(data bool) MLDSA {
+    this.verify = data
+
+    return this
+}
+
+// 设置错误
+func (this MLDSA) WithErrors(errs []error) MLDSA {
+    this.Errors = errs
+
+    return this
+}
@@ -0,0 +1,40 @@
+package rsa
+
+import (
+    "errors"
+    "crypto/x509"
+
+    "github.com/deatil/go-cryptobin/secret/shamir"
+)
+
+// 使用 Shamir 门限方案拆分私钥, 需要 threshold 份才能恢复
+// 拆分的数据为 PKCS8 编码的私钥
+// SplitPrivateKey(5, 3)
+func (this RSA) SplitPrivateKey(parts, threshold int) ([][]byte, error) {
+    if this.privateKey == nil {
+        err := errors.New("privateKey empty.")
+        return nil, err
+    }
+
+    privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(this.privateKey)
+    if err != nil {
+        return nil, err
+    }
+
+    return shamir.Split(privateKeyBytes, parts, threshold)
+}
+
+// 从 Shamir 拆分的私钥份额恢复私钥
+func (this RSA) FromPrivateKeyShares(shares [][]byte) RSA {
+    privateKeyBytes, err := shamir.Combine(shares)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    return this.FromPKCS8PrivateKeyDer(privateKeyBytes)
+}
+
+// 从 Shamir 拆分的私钥份额恢复私钥
+func FromPrivateKeyShares(shares [][]byte) RSA {
+    return defaultRSA.FromPrivateKeyShares(shares)
+}
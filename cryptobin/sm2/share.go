@@ -0,0 +1,40 @@
+package sm2
+
+import (
+    "errors"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/secret/shamir"
+)
+
+// 使用 Shamir 门限方案拆分私钥, 需要 threshold 份才能恢复
+// 拆分的数据为 PKCS8 编码的私钥
+// SplitPrivateKey(5, 3)
+func (this SM2) SplitPrivateKey(parts, threshold int) ([][]byte, error) {
+    if this.privateKey == nil {
+        err := errors.New("privateKey empty.")
+        return nil, err
+    }
+
+    privateKeyBytes, err := sm2.MarshalPrivateKey(this.privateKey)
+    if err != nil {
+        return nil, err
+    }
+
+    return shamir.Split(privateKeyBytes, parts, threshold)
+}
+
+// 从 Shamir 拆分的私钥份额恢复私钥
+func (this SM2) FromPrivateKeyShares(shares [][]byte) SM2 {
+    privateKeyBytes, err := shamir.Combine(shares)
+    if err != nil {
+        return this.AppendError(err)
+    }
+
+    return this.FromPKCS8PrivateKeyDer(privateKeyBytes)
+}
+
+// 从 Shamir 拆分的私钥份额恢复私钥
+func FromPrivateKeyShares(shares [][]byte) SM2 {
+    return defaultSM2.FromPrivateKeyShares(shares)
+}
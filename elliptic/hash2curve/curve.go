@@ -0,0 +1,105 @@
+package hash2curve
+
+import (
+    "math/big"
+)
+
+// edwardsCurve is a twisted Edwards curve a*x^2 + y^2 = 1 + d*x^2*y^2
+// in affine coordinates, the identity is (0, 1).
+type edwardsCurve struct {
+    f    field
+    a, d *big.Int
+}
+
+func (c *edwardsCurve) add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+    f := c.f
+
+    x1x2 := f.mul(x1, x2)
+    y1y2 := f.mul(y1, y2)
+    dxy := f.mul(c.d, f.mul(x1x2, y1y2))
+
+    // x3 = (x1*y2 + y1*x2) / (1 + d*x1*x2*y1*y2)
+    x = f.add(f.mul(x1, y2), f.mul(y1, x2))
+    x = f.mul(x, f.inv0(f.add(one, dxy)))
+
+    // y3 = (y1*y2 - a*x1*x2) / (1 - d*x1*x2*y1*y2)
+    y = f.sub(y1y2, f.mul(c.a, x1x2))
+    y = f.mul(y, f.inv0(f.sub(one, dxy)))
+
+    return
+}
+
+func (c *edwardsCurve) isOnCurve(x, y *big.Int) bool {
+    f := c.f
+
+    x2 := f.square(x)
+    y2 := f.square(y)
+
+    lhs := f.add(f.mul(c.a, x2), y2)
+    rhs := f.add(one, f.mul(c.d, f.mul(x2, y2)))
+
+    return lhs.Cmp(rhs) == 0
+}
+
+// montgomeryCurve is y^2 = x^3 + A*x^2 + x in affine coordinates,
+// the point at infinity is represented by a nil x.
+type montgomeryCurve struct {
+    f field
+    a *big.Int
+}
+
+func (c *montgomeryCurve) add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+    f := c.f
+
+    if x1 == nil {
+        return x2, y2
+    }
+    if x2 == nil {
+        return x1, y1
+    }
+
+    var lambda *big.Int
+    if f.equal(x1, x2) {
+        if f.add(y1, y2).Sign() == 0 {
+            return nil, nil
+        }
+
+        // lambda = (3*x1^2 + 2*A*x1 + 1) / (2*y1)
+        num := f.mul(three, f.square(x1))
+        num = f.add(num, f.mul(f.mul(two, c.a), x1))
+        num = f.add(num, one)
+        lambda = f.mul(num, f.inv0(f.mul(two, y1)))
+    } else {
+        lambda = f.mul(f.sub(y2, y1), f.inv0(f.sub(x2, x1)))
+    }
+
+    // x3 = lambda^2 - A - x1 - x2, y3 = lambda*(x1 - x3) - y1
+    x = f.sub(f.square(lambda), c.a)
+    x = f.sub(f.sub(x, x1), x2)
+    y = f.sub(f.mul(lambda, f.sub(x1, x)), y1)
+
+    return
+}
+
+func (c *montgomeryCurve) isOnCurve(x, y *big.Int) bool {
+    f := c.f
+
+    rhs := f.add(x, c.a)
+    rhs = f.add(f.mul(rhs, x), one)
+    rhs = f.mul(rhs, x)
+
+    return f.square(y).Cmp(rhs) == 0
+}
+
+// scalarMult computes k*P with double-and-add, used to clear small cofactors.
+func scalarMult(add func(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int), idX, idY, x, y *big.Int, k uint) (*big.Int, *big.Int) {
+    rx, ry := idX, idY
+    for i := 63; i >= 0; i-- {
+        rx, ry = add(rx, ry, rx, ry)
+        if (k >> uint(i)) & 1 == 1 {
+            rx, ry = add(rx, ry, x, y)
+        }
+    }
+
+    return rx, ry
+}
@@ -0,0 +1,117 @@
+package hash2curve
+
+import (
+    "math/big"
+)
+
+// Elligator2 is the Elligator 2 method for the Montgomery curve
+// y^2 = x^3 + J*x^2 + x, RFC 9380 section 6.7.1 with K = 1.
+type Elligator2 struct {
+    f field
+    j *big.Int
+    z *big.Int
+}
+
+// NewElligator2 returns the Elligator 2 map for y^2 = x^3 + j*x^2 + x
+// over GF(p) with the non-square z.
+func NewElligator2(p, j, z *big.Int) *Elligator2 {
+    f := field{p}
+
+    return &Elligator2{
+        f: f,
+        j: f.mod(new(big.Int).Set(j)),
+        z: f.mod(new(big.Int).Set(z)),
+    }
+}
+
+// Map maps u to a point (s, t) of the Montgomery curve.
+func (e *Elligator2) Map(u *big.Int) (s, t *big.Int) {
+    f := e.f
+
+    // x1 = -J * inv0(1 + Z * u^2), x1 = -J when x1 = 0
+    x1 := f.add(one, f.mul(e.z, f.square(u)))
+    x1 = f.mul(f.neg(e.j), f.inv0(x1))
+    if x1.Sign() == 0 {
+        x1 = f.neg(e.j)
+    }
+
+    // x2 = -x1 - J
+    x2 := f.sub(f.neg(x1), e.j)
+
+    gx1 := e.g(x1)
+
+    var y *big.Int
+    isSquare := f.isSquare(gx1)
+    if isSquare {
+        s = x1
+        y = f.sqrt(gx1)
+    } else {
+        s = x2
+        y = f.sqrt(e.g(x2))
+    }
+
+    // sgn0(t) is 1 for x1 and 0 for x2
+    if (sgn0(y) == 1) != isSquare {
+        y = f.neg(y)
+    }
+
+    return s, y
+}
+
+// g returns x^3 + J*x^2 + x.
+func (e *Elligator2) g(x *big.Int) *big.Int {
+    f := e.f
+
+    t := f.add(x, e.j)
+    t = f.mul(t, x)
+    t = f.add(t, one)
+
+    return f.mul(t, x)
+}
+
+// curve25519ToEdwards25519 is the birational map of RFC 9380 appendix D.1,
+// c1 = sqrt(-486664) with sgn0(c1) = 0.
+func curve25519ToEdwards25519(f field, c1, s, t *big.Int) (x, y *big.Int) {
+    sp1 := f.add(s, one)
+    if t.Sign() == 0 || sp1.Sign() == 0 {
+        return new(big.Int), big.NewInt(1)
+    }
+
+    x = f.mul(f.mul(c1, s), f.inv0(t))
+    y = f.mul(f.sub(s, one), f.inv0(sp1))
+
+    return
+}
+
+// curve448ToEdwards448 is the 4-isogeny of RFC 7748 section 4.2.
+func curve448ToEdwards448(f field, u, v *big.Int) (x, y *big.Int) {
+    u2 := f.square(u)
+    u3 := f.mul(u2, u)
+    u5 := f.mul(u3, u2)
+    v2 := f.square(v)
+
+    // xn = 4*v*(u^2 - 1), xd = (u^2 - 1)^2 + 4*v^2
+    u2m1 := f.sub(u2, one)
+    xn := f.mul(f.mul(big.NewInt(4), v), u2m1)
+    xd := f.add(f.square(u2m1), f.mul(big.NewInt(4), v2))
+
+    // yn = -(u^5 - 2*u^3 - 4*u*v^2 + u)
+    yn := f.sub(u5, f.mul(two, u3))
+    yn = f.sub(yn, f.mul(big.NewInt(4), f.mul(u, v2)))
+    yn = f.neg(f.add(yn, u))
+
+    // yd = u^5 - 2*u^2*v^2 - 2*u^3 - 2*v^2 + u
+    yd := f.sub(u5, f.mul(two, f.mul(u2, v2)))
+    yd = f.sub(yd, f.mul(two, u3))
+    yd = f.sub(yd, f.mul(two, v2))
+    yd = f.add(yd, u)
+
+    if xd.Sign() == 0 || yd.Sign() == 0 {
+        return new(big.Int), big.NewInt(1)
+    }
+
+    x = f.mul(xn, f.inv0(xd))
+    y = f.mul(yn, f.inv0(yd))
+
+    return
+}
@@ -0,0 +1,153 @@
+package hash2curve
+
+import (
+    "hash"
+    "errors"
+
+    "golang.org/x/crypto/sha3"
+)
+
+var (
+    ErrLengthTooLarge = errors.New("go-cryptobin/hash2curve: requested length is too large")
+    ErrEmptyDST       = errors.New("go-cryptobin/hash2curve: dst is empty")
+)
+
+// oversizeDSTPrefix is used to hash DSTs longer than 255 bytes.
+const oversizeDSTPrefix = "H2C-OVERSIZE-DST-"
+
+// Expander produces uniformly random bytes from a message,
+// as expand_message in RFC 9380 section 5.3.
+type Expander interface {
+    // Expand returns length bytes derived from msg.
+    Expand(msg []byte, length int) ([]byte, error)
+}
+
+// ExpanderXMD is expand_message_xmd with a Merkle-Damgard hash.
+type ExpanderXMD struct {
+    hash func() hash.Hash
+    dst  []byte
+}
+
+// NewExpanderXMD returns an expand_message_xmd expander.
+func NewExpanderXMD(h func() hash.Hash, dst []byte) *ExpanderXMD {
+    if len(dst) > 255 {
+        hh := h()
+        hh.Write([]byte(oversizeDSTPrefix))
+        hh.Write(dst)
+        dst = hh.Sum(nil)
+    }
+
+    return &ExpanderXMD{
+        hash: h,
+        dst:  append([]byte{}, dst...),
+    }
+}
+
+// Expand implements expand_message_xmd, RFC 9380 section 5.3.1.
+func (e *ExpanderXMD) Expand(msg []byte, length int) ([]byte, error) {
+    h := e.hash()
+
+    bInBytes := h.Size()
+    rInBytes := h.BlockSize()
+
+    ell := (length + bInBytes - 1) / bInBytes
+    if ell > 255 || length > 65535 || length < 0 {
+        return nil, ErrLengthTooLarge
+    }
+    if len(e.dst) == 0 {
+        return nil, ErrEmptyDST
+    }
+
+    dstPrime := append(append([]byte{}, e.dst...), byte(len(e.dst)))
+
+    // b_0 = H(Z_pad || msg || l_i_b_str || I2OSP(0, 1) || DST_prime)
+    h.Write(make([]byte, rInBytes))
+    h.Write(msg)
+    h.Write([]byte{byte(length >> 8), byte(length), 0})
+    h.Write(dstPrime)
+    b0 := h.Sum(nil)
+
+    // b_1 = H(b_0 || I2OSP(1, 1) || DST_prime)
+    h.Reset()
+    h.Write(b0)
+    h.Write([]byte{1})
+    h.Write(dstPrime)
+    bi := h.Sum(nil)
+
+    out := make([]byte, 0, ell*bInBytes)
+    out = append(out, bi...)
+
+    t := make([]byte, bInBytes)
+    for i := 2; i <= ell; i++ {
+        // b_i = H(strxor(b_0, b_(i - 1)) || I2OSP(i, 1) || DST_prime)
+        for j := range t {
+            t[j] = b0[j] ^ bi[j]
+        }
+
+        h.Reset()
+        h.Write(t)
+        h.Write([]byte{byte(i)})
+        h.Write(dstPrime)
+        bi = h.Sum(nil)
+
+        out = append(out, bi...)
+    }
+
+    return out[:length], nil
+}
+
+// ExpanderXOF is expand_message_xof with an extendable-output function.
+type ExpanderXOF struct {
+    xof func() sha3.ShakeHash
+    dst []byte
+}
+
+// NewExpanderXOF returns an expand_message_xof expander, k is the
+// target security level in bits, used to hash DSTs longer than 255 bytes.
+func NewExpanderXOF(xof func() sha3.ShakeHash, k int, dst []byte) *ExpanderXOF {
+    if len(dst) > 255 {
+        h := xof()
+        h.Write([]byte(oversizeDSTPrefix))
+        h.Write(dst)
+
+        d := make([]byte, (2*k + 7) / 8)
+        h.Read(d)
+        dst = d
+    }
+
+    return &ExpanderXOF{
+        xof: xof,
+        dst: append([]byte{}, dst...),
+    }
+}
+
+// Expand implements expand_message_xof, RFC 9380 section 5.3.2.
+func (e *ExpanderXOF) Expand(msg []byte, length int) ([]byte, error) {
+    if length > 65535 || length < 0 {
+        return nil, ErrLengthTooLarge
+    }
+    if len(e.dst) == 0 {
+        return nil, ErrEmptyDST
+    }
+
+    h := e.xof()
+    h.Write(msg)
+    h.Write([]byte{byte(length >> 8), byte(length)})
+    h.Write(e.dst)
+    h.Write([]byte{byte(len(e.dst))})
+
+    out := make([]byte, length)
+    h.Read(out)
+
+    return out, nil
+}
+
+// ExpandMessageXMD is expand_message_xmd(msg, DST, len_in_bytes).
+func ExpandMessageXMD(h func() hash.Hash, msg, dst []byte, length int) ([]byte, error) {
+    return NewExpanderXMD(h, dst).Expand(msg, length)
+}
+
+// ExpandMessageXOF is expand_message_xof(msg, DST, len_in_bytes).
+func ExpandMessageXOF(xof func() sha3.ShakeHash, k int, msg, dst []byte, length int) ([]byte, error) {
+    return NewExpanderXOF(xof, k, dst).Expand(msg, length)
+}
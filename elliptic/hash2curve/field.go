@@ -0,0 +1,94 @@
+package hash2curve
+
+import (
+    "math/big"
+)
+
+var (
+    zero  = big.NewInt(0)
+    one   = big.NewInt(1)
+    two   = big.NewInt(2)
+    three = big.NewInt(3)
+)
+
+// HashToField implements hash_to_field for m = 1, RFC 9380 section 5.2,
+// returning count elements of GF(p), each from L uniform bytes.
+func HashToField(exp Expander, msg []byte, p *big.Int, L, count int) ([]*big.Int, error) {
+    uniform, err := exp.Expand(msg, count*L)
+    if err != nil {
+        return nil, err
+    }
+
+    out := make([]*big.Int, count)
+    for i := range out {
+        e := new(big.Int).SetBytes(uniform[i*L:(i+1)*L])
+        out[i] = e.Mod(e, p)
+    }
+
+    return out, nil
+}
+
+// field holds helpers for arithmetic in GF(p).
+type field struct {
+    p *big.Int
+}
+
+func (f field) mod(x *big.Int) *big.Int {
+    return x.Mod(x, f.p)
+}
+
+func (f field) add(x, y *big.Int) *big.Int {
+    return f.mod(new(big.Int).Add(x, y))
+}
+
+func (f field) sub(x, y *big.Int) *big.Int {
+    return f.mod(new(big.Int).Sub(x, y))
+}
+
+func (f field) mul(x, y *big.Int) *big.Int {
+    return f.mod(new(big.Int).Mul(x, y))
+}
+
+func (f field) square(x *big.Int) *big.Int {
+    return f.mul(x, x)
+}
+
+func (f field) neg(x *big.Int) *big.Int {
+    return f.mod(new(big.Int).Neg(x))
+}
+
+// inv0 returns 1/x, with inv0(0) = 0.
+func (f field) inv0(x *big.Int) *big.Int {
+    if x.Sign() == 0 {
+        return new(big.Int)
+    }
+
+    return new(big.Int).ModInverse(x, f.p)
+}
+
+func (f field) isSquare(x *big.Int) bool {
+    return x.Sign() == 0 || big.Jacobi(x, f.p) == 1
+}
+
+// sqrt returns a square root of x, or nil when there is none.
+func (f field) sqrt(x *big.Int) *big.Int {
+    return new(big.Int).ModSqrt(x, f.p)
+}
+
+// sgn0 is the parity of x, RFC 9380 section 4.1.
+func sgn0(x *big.Int) uint {
+    return x.Bit(0)
+}
+
+func (f field) equal(x, y *big.Int) bool {
+    return f.mod(new(big.Int).Set(x)).Cmp(f.mod(new(big.Int).Set(y))) == 0
+}
+
+func bigFromHex(s string) *big.Int {
+    b, ok := new(big.Int).SetString(s, 0)
+    if !ok {
+        panic("go-cryptobin/hash2curve: invalid constant " + s)
+    }
+
+    return b
+}
@@ -0,0 +1,103 @@
+// Package hash2curve implements hashing to elliptic curves as
+// defined in RFC 9380.
+//
+// Suites are provided for P-256, P-384, P-521, secp256k1, the SM2 curve,
+// curve25519, edwards25519, curve448 and edwards448. Points are returned
+// as affine coordinates; the point at infinity is (0, 0) for Weierstrass
+// and Montgomery curves and (0, 1) for Edwards curves.
+package hash2curve
+
+import (
+    "hash"
+    "math/big"
+
+    "golang.org/x/crypto/sha3"
+)
+
+// Suite is a hash-to-curve suite without its RO_ / NU_ suffix.
+type Suite struct {
+    name string
+
+    p *big.Int
+    l int
+    k int
+
+    hash func() hash.Hash
+    xof  func() sha3.ShakeHash
+
+    mapToCurve    func(u *big.Int) (*big.Int, *big.Int)
+    add           func(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int)
+    clearCofactor func(x, y *big.Int) (*big.Int, *big.Int)
+    finish        func(x, y *big.Int) (*big.Int, *big.Int)
+}
+
+// Name returns the suite name without the RO_ or NU_ suffix,
+// such as "P256_XMD:SHA-256_SSWU_".
+func (s *Suite) Name() string {
+    return s.name
+}
+
+// HashID returns the suite ID of HashToCurve.
+func (s *Suite) HashID() string {
+    return s.name + "RO_"
+}
+
+// EncodeID returns the suite ID of EncodeToCurve.
+func (s *Suite) EncodeID() string {
+    return s.name + "NU_"
+}
+
+// Field returns the characteristic p of the base field.
+func (s *Suite) Field() *big.Int {
+    return new(big.Int).Set(s.p)
+}
+
+// Expander returns the expand_message function of the suite with dst.
+func (s *Suite) Expander(dst []byte) Expander {
+    if s.xof != nil {
+        return NewExpanderXOF(s.xof, s.k, dst)
+    }
+
+    return NewExpanderXMD(s.hash, dst)
+}
+
+// HashToField returns count elements of GF(p) derived from msg.
+func (s *Suite) HashToField(msg, dst []byte, count int) ([]*big.Int, error) {
+    return HashToField(s.Expander(dst), msg, s.p, s.l, count)
+}
+
+// MapToCurve maps a field element to the curve, without clearing the cofactor.
+func (s *Suite) MapToCurve(u *big.Int) (x, y *big.Int) {
+    return s.finish(s.mapToCurve(u))
+}
+
+// HashToCurve implements hash_to_curve, a random oracle encoding.
+func (s *Suite) HashToCurve(msg, dst []byte) (x, y *big.Int, err error) {
+    u, err := s.HashToField(msg, dst, 2)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    x0, y0 := s.mapToCurve(u[0])
+    x1, y1 := s.mapToCurve(u[1])
+
+    x, y = s.add(x0, y0, x1, y1)
+    x, y = s.clearCofactor(x, y)
+
+    x, y = s.finish(x, y)
+    return x, y, nil
+}
+
+// EncodeToCurve implements encode_to_curve, a nonuniform encoding.
+func (s *Suite) EncodeToCurve(msg, dst []byte) (x, y *big.Int, err error) {
+    u, err := s.HashToField(msg, dst, 1)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    x, y = s.mapToCurve(u[0])
+    x, y = s.clearCofactor(x, y)
+
+    x, y = s.finish(x, y)
+    return x, y, nil
+}
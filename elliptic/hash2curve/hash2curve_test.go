@@ -0,0 +1,370 @@
+package hash2curve
+
+import (
+    "os"
+    "bytes"
+    "testing"
+    "math/big"
+    "crypto/sha256"
+    "crypto/sha512"
+    "compress/gzip"
+    "encoding/hex"
+    "encoding/json"
+
+    "golang.org/x/crypto/sha3"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+func readJSON(t *testing.T, name string, v any) {
+    f, err := os.Open("testdata/" + name + ".json.gz")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if err := json.NewDecoder(r).Decode(v); err != nil {
+        t.Fatal(err)
+    }
+}
+
+type expanderVectors struct {
+    DST   string `json:"DST"`
+    K     int    `json:"k"`
+    Tests []struct {
+        Msg          string `json:"msg"`
+        LenInBytes   string `json:"len_in_bytes"`
+        UniformBytes string `json:"uniform_bytes"`
+    } `json:"tests"`
+}
+
+func Test_Expander(t *testing.T) {
+    cases := []struct {
+        name string
+        exp  func(dst []byte, k int) Expander
+    }{
+        {"expand_message_xmd_SHA256_38", func(dst []byte, k int) Expander { return NewExpanderXMD(sha256.New, dst) }},
+        {"expand_message_xmd_SHA256_256", func(dst []byte, k int) Expander { return NewExpanderXMD(sha256.New, dst) }},
+        {"expand_message_xmd_SHA512_38", func(dst []byte, k int) Expander { return NewExpanderXMD(sha512.New, dst) }},
+        {"expand_message_xof_SHAKE128_36", func(dst []byte, k int) Expander { return NewExpanderXOF(sha3.NewShake128, k, dst) }},
+        {"expand_message_xof_SHAKE128_256", func(dst []byte, k int) Expander { return NewExpanderXOF(sha3.NewShake128, k, dst) }},
+        {"expand_message_xof_SHAKE256_36", func(dst []byte, k int) Expander { return NewExpanderXOF(sha3.NewShake256, k, dst) }},
+    }
+
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            var v expanderVectors
+            readJSON(t, c.name, &v)
+
+            exp := c.exp([]byte(v.DST), v.K)
+            for i, tt := range v.Tests {
+                length, _ := new(big.Int).SetString(tt.LenInBytes, 0)
+                want, _ := hex.DecodeString(tt.UniformBytes)
+
+                got, err := exp.Expand([]byte(tt.Msg), int(length.Int64()))
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                if !bytes.Equal(got, want) {
+                    t.Errorf("[%d] got %x, want %x", i, got, want)
+                }
+            }
+        })
+    }
+}
+
+type suiteVectors struct {
+    Ciphersuite string `json:"ciphersuite"`
+    Dst         string `json:"dst"`
+    Vectors     []struct {
+        Msg string   `json:"msg"`
+        U   []string `json:"u"`
+        P   struct {
+            X string `json:"x"`
+            Y string `json:"y"`
+        } `json:"P"`
+    } `json:"vectors"`
+}
+
+func Test_Suites(t *testing.T) {
+    cases := []struct {
+        file  string
+        suite *Suite
+    }{
+        {"P256_XMD-SHA-256_SSWU", P256()},
+        {"P384_XMD-SHA-384_SSWU", P384()},
+        {"P521_XMD-SHA-512_SSWU", P521()},
+        {"secp256k1_XMD-SHA-256_SSWU", Secp256k1()},
+        {"edwards25519_XMD-SHA-512_ELL2", Edwards25519()},
+    }
+
+    for _, c := range cases {
+        for _, ro := range []bool{true, false} {
+            name := c.file + "_NU_"
+            id := c.suite.EncodeID()
+            if ro {
+                name = c.file + "_RO_"
+                id = c.suite.HashID()
+            }
+
+            t.Run(name, func(t *testing.T) {
+                var v suiteVectors
+                readJSON(t, name, &v)
+
+                if v.Ciphersuite != id {
+                    t.Fatalf("suite ID got %s, want %s", id, v.Ciphersuite)
+                }
+
+                for i, tt := range v.Vectors {
+                    var x, y *big.Int
+                    var err error
+                    if ro {
+                        x, y, err = c.suite.HashToCurve([]byte(tt.Msg), []byte(v.Dst))
+                    } else {
+                        x, y, err = c.suite.EncodeToCurve([]byte(tt.Msg), []byte(v.Dst))
+                    }
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    wantX, _ := new(big.Int).SetString(tt.P.X, 0)
+                    wantY, _ := new(big.Int).SetString(tt.P.Y, 0)
+                    if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+                        t.Errorf("[%d] got (%x, %x), want (%x, %x)", i, x, y, wantX, wantY)
+                    }
+
+                    u, err := c.suite.HashToField([]byte(tt.Msg), []byte(v.Dst), len(tt.U))
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+                    for j := range tt.U {
+                        want, _ := new(big.Int).SetString(tt.U[j], 0)
+                        if u[j].Cmp(want) != 0 {
+                            t.Errorf("[%d] u[%d] got %x, want %x", i, j, u[j], want)
+                        }
+                    }
+                }
+            })
+        }
+    }
+}
+
+func bigScalarMult(add func(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int), idX, idY, x, y, k *big.Int) (*big.Int, *big.Int) {
+    rx, ry := idX, idY
+    for i := k.BitLen() - 1; i >= 0; i-- {
+        rx, ry = add(rx, ry, rx, ry)
+        if k.Bit(i) == 1 {
+            rx, ry = add(rx, ry, x, y)
+        }
+    }
+
+    return rx, ry
+}
+
+func Test_SM2(t *testing.T) {
+    s := SM2()
+    curve := sm2.P256()
+
+    for _, msg := range []string{"", "abc", "abcdef0123456789"} {
+        x, y, err := s.HashToCurve([]byte(msg), []byte("QUUX-V01-CS02-with-" + s.HashID()))
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !curve.IsOnCurve(x, y) {
+            t.Errorf("HashToCurve(%q) is not on the curve", msg)
+        }
+
+        x, y, err = s.EncodeToCurve([]byte(msg), []byte("QUUX-V01-CS02-with-" + s.EncodeID()))
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !curve.IsOnCurve(x, y) {
+            t.Errorf("EncodeToCurve(%q) is not on the curve", msg)
+        }
+    }
+}
+
+func Test_Curve25519(t *testing.T) {
+    mont := Curve25519()
+    ed := Edwards25519()
+
+    f := field{mont.p}
+    c1 := f.sqrt(f.neg(big.NewInt(486664)))
+    if sgn0(c1) == 1 {
+        c1 = f.neg(c1)
+    }
+
+    m := &montgomeryCurve{f: f, a: big.NewInt(486662)}
+
+    dst := []byte("QUUX-V01-CS02-with-curve25519_XMD:SHA-512_ELL2_RO_")
+    for _, msg := range []string{"", "abc", "abcdef0123456789"} {
+        u, v, err := mont.HashToCurve([]byte(msg), dst)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !m.isOnCurve(u, v) {
+            t.Fatalf("HashToCurve(%q) is not on the curve", msg)
+        }
+
+        // the birational map commutes with the group law
+        x, y, _ := ed.HashToCurve([]byte(msg), dst)
+        ex, ey := curve25519ToEdwards25519(f, c1, u, v)
+        if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+            t.Errorf("HashToCurve(%q) mismatch with edwards25519", msg)
+        }
+
+        u, v, err = mont.EncodeToCurve([]byte(msg), dst)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        x, y, _ = ed.EncodeToCurve([]byte(msg), dst)
+        ex, ey = curve25519ToEdwards25519(f, c1, u, v)
+        if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+            t.Errorf("EncodeToCurve(%q) mismatch with edwards25519", msg)
+        }
+    }
+}
+
+// RFC 9380 appendix J.7 is not vendored, curve25519 is checked
+// against the appendix J.5 outputs through the birational map.
+func Test_Curve25519Vectors(t *testing.T) {
+    mont := Curve25519()
+
+    f := field{mont.p}
+    c1 := f.sqrt(f.neg(big.NewInt(486664)))
+    if sgn0(c1) == 1 {
+        c1 = f.neg(c1)
+    }
+
+    for _, ro := range []bool{true, false} {
+        name := "edwards25519_XMD-SHA-512_ELL2_NU_"
+        if ro {
+            name = "edwards25519_XMD-SHA-512_ELL2_RO_"
+        }
+
+        var v suiteVectors
+        readJSON(t, name, &v)
+
+        for i, tt := range v.Vectors {
+            var u, w *big.Int
+            var err error
+            if ro {
+                u, w, err = mont.HashToCurve([]byte(tt.Msg), []byte(v.Dst))
+            } else {
+                u, w, err = mont.EncodeToCurve([]byte(tt.Msg), []byte(v.Dst))
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            x, y := curve25519ToEdwards25519(f, c1, u, w)
+
+            wantX, _ := new(big.Int).SetString(tt.P.X, 0)
+            wantY, _ := new(big.Int).SetString(tt.P.Y, 0)
+            if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+                t.Errorf("%s [%d] got (%x, %x), want (%x, %x)", name, i, x, y, wantX, wantY)
+            }
+        }
+    }
+}
+
+func Test_Edwards448(t *testing.T) {
+    ed := Edwards448()
+    mont := Curve448()
+
+    p := ed.p
+    f := field{p}
+    curve := &edwardsCurve{f: f, a: big.NewInt(1), d: f.neg(big.NewInt(39081))}
+    m := &montgomeryCurve{f: f, a: big.NewInt(156326)}
+
+    // q = 2^446 - 13818066809895115352007386748515426880336692474882178609894547503885
+    q := new(big.Int).Lsh(one, 446)
+    q.Sub(q, bigFromHex("13818066809895115352007386748515426880336692474882178609894547503885"))
+
+    dst := []byte("QUUX-V01-CS02-with-edwards448_XOF:SHAKE256_ELL2_RO_")
+    for _, msg := range []string{"", "abc", "abcdef0123456789"} {
+        x, y, err := ed.HashToCurve([]byte(msg), dst)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !curve.isOnCurve(x, y) {
+            t.Fatalf("HashToCurve(%q) is not on the curve", msg)
+        }
+
+        // the result is in the prime order subgroup
+        ox, oy := bigScalarMult(curve.add, new(big.Int), big.NewInt(1), x, y, q)
+        if ox.Sign() != 0 || oy.Cmp(one) != 0 {
+            t.Errorf("HashToCurve(%q) is not in the subgroup", msg)
+        }
+
+        // the 4-isogeny commutes with the group law
+        u, v, err := mont.HashToCurve([]byte(msg), dst)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !m.isOnCurve(u, v) {
+            t.Fatalf("curve448 HashToCurve(%q) is not on the curve", msg)
+        }
+
+        ex, ey := curve448ToEdwards448(f, u, v)
+        if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+            t.Errorf("HashToCurve(%q) mismatch with curve448", msg)
+        }
+
+        x, y, err = ed.EncodeToCurve([]byte(msg), dst)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !curve.isOnCurve(x, y) {
+            t.Fatalf("EncodeToCurve(%q) is not on the curve", msg)
+        }
+
+        u, v, err = mont.EncodeToCurve([]byte(msg), dst)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        ex, ey = curve448ToEdwards448(f, u, v)
+        if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+            t.Errorf("EncodeToCurve(%q) mismatch with curve448", msg)
+        }
+    }
+}
+
+func Test_LongDST(t *testing.T) {
+    dst := bytes.Repeat([]byte{'a'}, 300)
+
+    h := sha256.New()
+    h.Write([]byte(oversizeDSTPrefix))
+    h.Write(dst)
+
+    a, err := ExpandMessageXMD(sha256.New, []byte("msg"), dst, 32)
+    if err != nil {
+        t.Fatal(err)
+    }
+    b, err := ExpandMessageXMD(sha256.New, []byte("msg"), h.Sum(nil), 32)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if !bytes.Equal(a, b) {
+        t.Error("oversize DST is not hashed")
+    }
+
+    if _, err := ExpandMessageXMD(sha256.New, []byte("msg"), nil, 32); err == nil {
+        t.Error("empty DST should fail")
+    }
+}
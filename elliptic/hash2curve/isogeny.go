@@ -0,0 +1,78 @@
+package hash2curve
+
+import (
+    "math/big"
+)
+
+// Isogeny is a rational map (x, y) -> (xNum/xDen, y * yNum/yDen),
+// coefficients are listed from the constant term upwards.
+type Isogeny struct {
+    f                      field
+    xNum, xDen, yNum, yDen []*big.Int
+}
+
+// Map applies the isogeny, exceptional inputs map to the identity,
+// reported as ok = false.
+func (iso *Isogeny) Map(x, y *big.Int) (xo, yo *big.Int, ok bool) {
+    f := iso.f
+
+    xn := iso.horner(iso.xNum, x)
+    xd := iso.horner(iso.xDen, x)
+    yn := iso.horner(iso.yNum, x)
+    yd := iso.horner(iso.yDen, x)
+
+    if xd.Sign() == 0 || yd.Sign() == 0 {
+        return nil, nil, false
+    }
+
+    xo = f.mul(xn, f.inv0(xd))
+    yo = f.mul(f.mul(y, yn), f.inv0(yd))
+
+    return xo, yo, true
+}
+
+func (iso *Isogeny) horner(coeffs []*big.Int, x *big.Int) *big.Int {
+    f := iso.f
+
+    z := new(big.Int).Set(coeffs[len(coeffs)-1])
+    for i := len(coeffs) - 2; i >= 0; i-- {
+        z = f.add(f.mul(z, x), coeffs[i])
+    }
+
+    return z
+}
+
+// secp256k1 3-isogeny from E': y^2 = x^3 + A'*x + B', RFC 9380 appendix E.1.
+var (
+    secp256k1IsoA = bigFromHex("0x3f8731abdd661adca08a5558f0f5d272e953d363cb6f0e5d405447c01a444533")
+    secp256k1IsoB = big.NewInt(1771)
+)
+
+func secp256k1Isogeny(p *big.Int) *Isogeny {
+    return &Isogeny{
+        f: field{p},
+        xNum: []*big.Int{
+            bigFromHex("0x8e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38daaaaa8c7"),
+            bigFromHex("0x07d3d4c80bc321d5b9f315cea7fd44c5d595d2fc0bf63b92dfff1044f17c6581"),
+            bigFromHex("0x534c328d23f234e6e2a413deca25caece4506144037c40314ecbd0b53d9dd262"),
+            bigFromHex("0x8e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38e38daaaaa88c"),
+        },
+        xDen: []*big.Int{
+            bigFromHex("0xd35771193d94918a9ca34ccbb7b640dd86cd409542f8487d9fe6b745781eb49b"),
+            bigFromHex("0xedadc6f64383dc1df7c4b2d51b54225406d36b641f5e41bbc52a56612a8c6d14"),
+            big.NewInt(1),
+        },
+        yNum: []*big.Int{
+            bigFromHex("0x4bda12f684bda12f684bda12f684bda12f684bda12f684bda12f684b8e38e23c"),
+            bigFromHex("0xc75e0c32d5cb7c0fa9d0a54b12a0a6d5647ab046d686da6fdffc90fc201d71a3"),
+            bigFromHex("0x29a6194691f91a73715209ef6512e576722830a201be2018a765e85a9ecee931"),
+            bigFromHex("0x2f684bda12f684bda12f684bda12f684bda12f684bda12f684bda12f38e38d84"),
+        },
+        yDen: []*big.Int{
+            bigFromHex("0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffefffff93b"),
+            bigFromHex("0x7a06534bb8bdb49fd5e9e6632722c2989467c1bfc8e8d978dfb425d2685c2573"),
+            bigFromHex("0x6484aa716545ca2cf3a70c3fa8fe337e0a3d21162f0d6299a7bf8192bfd2a76f"),
+            big.NewInt(1),
+        },
+    }
+}
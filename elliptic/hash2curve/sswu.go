@@ -0,0 +1,71 @@
+package hash2curve
+
+import (
+    "math/big"
+)
+
+// SSWU is the simplified Shallue-van de Woestijne-Ulas method for
+// y^2 = x^3 + A*x + B with A != 0 and B != 0, RFC 9380 section 6.6.2.
+type SSWU struct {
+    f    field
+    a, b *big.Int
+    z    *big.Int
+}
+
+// NewSSWU returns the simplified SWU map for the curve y^2 = x^3 + a*x + b
+// over GF(p), with the non-square z chosen as in RFC 9380 appendix H.2.
+func NewSSWU(p, a, b, z *big.Int) *SSWU {
+    f := field{p}
+
+    return &SSWU{
+        f: f,
+        a: f.mod(new(big.Int).Set(a)),
+        b: f.mod(new(big.Int).Set(b)),
+        z: f.mod(new(big.Int).Set(z)),
+    }
+}
+
+// Map maps u to a point of the curve.
+func (s *SSWU) Map(u *big.Int) (x, y *big.Int) {
+    f := s.f
+
+    // tv1 = inv0(Z^2 * u^4 + Z * u^2)
+    zu2 := f.mul(s.z, f.square(u))
+    tv1 := f.add(f.square(zu2), zu2)
+    tv1 = f.inv0(tv1)
+
+    // x1 = (-B / A) * (1 + tv1), or B / (Z * A) when tv1 = 0
+    var x1 *big.Int
+    if tv1.Sign() == 0 {
+        x1 = f.mul(s.b, f.inv0(f.mul(s.z, s.a)))
+    } else {
+        x1 = f.mul(f.neg(s.b), f.inv0(s.a))
+        x1 = f.mul(x1, f.add(one, tv1))
+    }
+
+    gx1 := s.g(x1)
+    if f.isSquare(gx1) {
+        x = x1
+        y = f.sqrt(gx1)
+    } else {
+        // x2 = Z * u^2 * x1
+        x = f.mul(zu2, x1)
+        y = f.sqrt(s.g(x))
+    }
+
+    if sgn0(u) != sgn0(y) {
+        y = f.neg(y)
+    }
+
+    return
+}
+
+// g returns x^3 + A*x + B.
+func (s *SSWU) g(x *big.Int) *big.Int {
+    f := s.f
+
+    t := f.add(f.square(x), s.a)
+    t = f.mul(t, x)
+
+    return f.add(t, s.b)
+}
@@ -0,0 +1,269 @@
+package hash2curve
+
+import (
+    "sync"
+    "math/big"
+    "crypto/sha256"
+    "crypto/sha512"
+    "crypto/elliptic"
+
+    "golang.org/x/crypto/sha3"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/hash/sm3"
+    "github.com/deatil/go-cryptobin/elliptic/secp256k1"
+)
+
+var (
+    once sync.Once
+
+    p256Suite         *Suite
+    p384Suite         *Suite
+    p521Suite         *Suite
+    secp256k1Suite    *Suite
+    sm2Suite          *Suite
+    curve25519Suite   *Suite
+    edwards25519Suite *Suite
+    curve448Suite     *Suite
+    edwards448Suite   *Suite
+)
+
+func initAll() {
+    p256Suite = newWeierstrassSuite("P256_XMD:SHA-256_SSWU_", elliptic.P256(), -10, 48)
+    p256Suite.hash = sha256.New
+
+    p384Suite = newWeierstrassSuite("P384_XMD:SHA-384_SSWU_", elliptic.P384(), -12, 72)
+    p384Suite.hash = sha512.New384
+
+    p521Suite = newWeierstrassSuite("P521_XMD:SHA-512_SSWU_", elliptic.P521(), -4, 98)
+    p521Suite.hash = sha512.New
+
+    // Z for the SM2 curve is chosen with the algorithm of RFC 9380 appendix H.2
+    sm2Suite = newWeierstrassSuite("SM2_XMD:SM3_SSWU_", sm2.P256(), -9, 48)
+    sm2Suite.hash = sm3.New
+
+    initSecp256k1()
+    init25519()
+    init448()
+}
+
+// newWeierstrassSuite returns a suite for a curve with a = -3 and cofactor 1.
+func newWeierstrassSuite(name string, curve elliptic.Curve, z int64, l int) *Suite {
+    params := curve.Params()
+
+    a := new(big.Int).Sub(params.P, three)
+    sswu := NewSSWU(params.P, a, params.B, big.NewInt(z))
+
+    return &Suite{
+        name: name,
+        p:    params.P,
+        l:    l,
+        k:    128,
+
+        mapToCurve:    sswu.Map,
+        add:           curve.Add,
+        clearCofactor: identityMap,
+        finish:        identityMap,
+    }
+}
+
+func initSecp256k1() {
+    curve := secp256k1.Curve()
+    p := curve.Params().P
+
+    sswu := NewSSWU(p, secp256k1IsoA, secp256k1IsoB, big.NewInt(-11))
+    iso := secp256k1Isogeny(p)
+
+    secp256k1Suite = &Suite{
+        name: "secp256k1_XMD:SHA-256_SSWU_",
+        p:    p,
+        l:    48,
+        k:    128,
+        hash: sha256.New,
+
+        mapToCurve: func(u *big.Int) (x, y *big.Int) {
+            x, y = sswu.Map(u)
+
+            x, y, ok := iso.Map(x, y)
+            if !ok {
+                return new(big.Int), new(big.Int)
+            }
+
+            return x, y
+        },
+        add:           curve.Add,
+        clearCofactor: identityMap,
+        finish:        identityMap,
+    }
+}
+
+func init25519() {
+    // p = 2^255 - 19
+    p := new(big.Int).Lsh(one, 255)
+    p.Sub(p, big.NewInt(19))
+
+    f := field{p}
+
+    ell2 := NewElligator2(p, big.NewInt(486662), two)
+    mont := &montgomeryCurve{f: f, a: big.NewInt(486662)}
+
+    // d = -121665 / 121666
+    d := f.mul(f.neg(big.NewInt(121665)), f.inv0(big.NewInt(121666)))
+    ed := &edwardsCurve{f: f, a: f.neg(one), d: d}
+
+    // c1 = sqrt(-486664) with sgn0(c1) = 0
+    c1 := f.sqrt(f.neg(big.NewInt(486664)))
+    if sgn0(c1) == 1 {
+        c1 = f.neg(c1)
+    }
+
+    curve25519Suite = &Suite{
+        name: "curve25519_XMD:SHA-512_ELL2_",
+        p:    p,
+        l:    48,
+        k:    128,
+        hash: sha512.New,
+
+        mapToCurve: ell2.Map,
+        add:        mont.add,
+        clearCofactor: func(x, y *big.Int) (*big.Int, *big.Int) {
+            return scalarMult(mont.add, nil, nil, x, y, 8)
+        },
+        finish: montgomeryFinish,
+    }
+
+    edwards25519Suite = &Suite{
+        name: "edwards25519_XMD:SHA-512_ELL2_",
+        p:    p,
+        l:    48,
+        k:    128,
+        hash: sha512.New,
+
+        mapToCurve: func(u *big.Int) (x, y *big.Int) {
+            s, t := ell2.Map(u)
+            return curve25519ToEdwards25519(f, c1, s, t)
+        },
+        add: ed.add,
+        clearCofactor: func(x, y *big.Int) (*big.Int, *big.Int) {
+            return scalarMult(ed.add, new(big.Int), big.NewInt(1), x, y, 8)
+        },
+        finish: identityMap,
+    }
+}
+
+func init448() {
+    // p = 2^448 - 2^224 - 1
+    p := new(big.Int).Lsh(one, 448)
+    p.Sub(p, new(big.Int).Lsh(one, 224))
+    p.Sub(p, one)
+
+    f := field{p}
+
+    ell2 := NewElligator2(p, big.NewInt(156326), big.NewInt(-1))
+    mont := &montgomeryCurve{f: f, a: big.NewInt(156326)}
+    ed := &edwardsCurve{f: f, a: big.NewInt(1), d: f.neg(big.NewInt(39081))}
+
+    curve448Suite = &Suite{
+        name: "curve448_XOF:SHAKE256_ELL2_",
+        p:    p,
+        l:    84,
+        k:    224,
+        xof:  sha3.NewShake256,
+
+        mapToCurve: ell2.Map,
+        add:        mont.add,
+        clearCofactor: func(x, y *big.Int) (*big.Int, *big.Int) {
+            return scalarMult(mont.add, nil, nil, x, y, 4)
+        },
+        finish: montgomeryFinish,
+    }
+
+    edwards448Suite = &Suite{
+        name: "edwards448_XOF:SHAKE256_ELL2_",
+        p:    p,
+        l:    84,
+        k:    224,
+        xof:  sha3.NewShake256,
+
+        mapToCurve: func(u *big.Int) (x, y *big.Int) {
+            s, t := ell2.Map(u)
+            return curve448ToEdwards448(f, s, t)
+        },
+        add: ed.add,
+        clearCofactor: func(x, y *big.Int) (*big.Int, *big.Int) {
+            return scalarMult(ed.add, new(big.Int), big.NewInt(1), x, y, 4)
+        },
+        finish: identityMap,
+    }
+}
+
+func identityMap(x, y *big.Int) (*big.Int, *big.Int) {
+    return x, y
+}
+
+// montgomeryFinish reports the point at infinity as (0, 0).
+func montgomeryFinish(x, y *big.Int) (*big.Int, *big.Int) {
+    if x == nil {
+        return new(big.Int), new(big.Int)
+    }
+
+    return x, y
+}
+
+// P256 returns the P256_XMD:SHA-256_SSWU_ suite.
+func P256() *Suite {
+    once.Do(initAll)
+    return p256Suite
+}
+
+// P384 returns the P384_XMD:SHA-384_SSWU_ suite.
+func P384() *Suite {
+    once.Do(initAll)
+    return p384Suite
+}
+
+// P521 returns the P521_XMD:SHA-512_SSWU_ suite.
+func P521() *Suite {
+    once.Do(initAll)
+    return p521Suite
+}
+
+// Secp256k1 returns the secp256k1_XMD:SHA-256_SSWU_ suite,
+// which maps to an isogenous curve as secp256k1 has A = 0.
+func Secp256k1() *Suite {
+    once.Do(initAll)
+    return secp256k1Suite
+}
+
+// SM2 returns the SM2_XMD:SM3_SSWU_ suite for the curve of GM/T 0003,
+// it follows RFC 9380 section 8.10 with Z = -9 and L = 48.
+func SM2() *Suite {
+    once.Do(initAll)
+    return sm2Suite
+}
+
+// Curve25519 returns the curve25519_XMD:SHA-512_ELL2_ suite,
+// points are (u, v) on the Montgomery curve.
+func Curve25519() *Suite {
+    once.Do(initAll)
+    return curve25519Suite
+}
+
+// Edwards25519 returns the edwards25519_XMD:SHA-512_ELL2_ suite.
+func Edwards25519() *Suite {
+    once.Do(initAll)
+    return edwards25519Suite
+}
+
+// Curve448 returns the curve448_XOF:SHAKE256_ELL2_ suite,
+// points are (u, v) on the Montgomery curve.
+func Curve448() *Suite {
+    once.Do(initAll)
+    return curve448Suite
+}
+
+// Edwards448 returns the edwards448_XOF:SHAKE256_ELL2_ suite.
+func Edwards448() *Suite {
+    once.Do(initAll)
+    return edwards448Suite
+}
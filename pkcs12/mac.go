@@ -160,6 +160,23 @@ type MacData struct {
 }
 
 func (this MacData) Verify(message []byte, password []byte) (err error) {
+    expectedMAC, err := this.Sum(message, password)
+    if err != nil {
+        return err
+    }
+
+    if !hmac.Equal(this.Mac.Digest, expectedMAC) {
+        return ErrIncorrectPassword
+    }
+
+    return
+}
+
+// Sum 使用 MacData 的算法及参数计算 message 的 MAC
+// Sum returns the MAC of message with the algorithm and the parameters
+// of the MacData, for protocols such as CMP that protect their messages
+// with the PBMAC1 parameters before the MAC is known.
+func (this MacData) Sum(message []byte, password []byte) (sum []byte, err error) {
     var h func() hash.Hash
     var key []byte
 
@@ -167,24 +184,19 @@ func (this MacData) Verify(message []byte, password []byte) (err error) {
         case this.Mac.Algorithm.Algorithm.Equal(oidPBMAC1):
             h, key, err = parsePBMAC1Param(this.Mac.Algorithm.Parameters.FullBytes, password)
             if err != nil {
-                return err
+                return nil, err
             }
         default:
             h, key, err = this.parseMacParam(password)
             if err != nil {
-                return err
+                return nil, err
             }
     }
 
     mac := hmac.New(h, key)
     mac.Write(message)
-    expectedMAC := mac.Sum(nil)
-
-    if !hmac.Equal(this.Mac.Digest, expectedMAC) {
-        return ErrIncorrectPassword
-    }
 
-    return
+    return mac.Sum(nil), nil
 }
 
 func (this MacData) parseMacParam(password []byte) (h func() hash.Hash, key []byte, err error) {
@@ -0,0 +1,291 @@
+package pkcs7
+
+import (
+    "io"
+    "errors"
+    "crypto"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+var (
+    // RFC 5083 AuthEnvelopedData OID
+    oidAuthEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 23}
+)
+
+// AuthEnvelopedData, RFC 5083
+// AuthEnvelopedData ::= SEQUENCE {
+//     version CMSVersion,
+//     originatorInfo [0] IMPLICIT OriginatorInfo OPTIONAL,
+//     recipientInfos RecipientInfos,
+//     authEncryptedContentInfo EncryptedContentInfo,
+//     authAttrs [1] IMPLICIT AuthAttributes OPTIONAL,
+//     mac MessageAuthenticationCode,
+//     unauthAttrs [2] IMPLICIT UnauthAttributes OPTIONAL }
+type authEnvelopedData struct {
+    Version                  int
+    OriginatorInfo           asn1.RawValue   `asn1:"optional,tag:0"`
+    RecipientInfos           []asn1.RawValue `asn1:"set"`
+    AuthEncryptedContentInfo encryptedContentInfo
+    AuthAttributes           asn1.RawValue   `asn1:"optional,tag:1"`
+    MAC                      []byte
+    UnauthAttributes         asn1.RawValue   `asn1:"optional,tag:2"`
+}
+
+// 认证加密配置
+type AuthOpts struct {
+    Cipher     AuthCipher
+    KeyEncrypt KeyEncrypt
+    KeyAgree   KeyAgree
+
+    // 认证属性, 参与认证码计算
+    AuthAttributes []Attribute
+
+    // 非认证属性
+    UnauthAttributes []Attribute
+}
+
+// 默认认证加密配置
+var DefaultAuthOpts = AuthOpts{
+    Cipher:     AuthAES256GCM,
+    KeyEncrypt: KeyEncryptRSA,
+}
+
+// SM2 认证加密配置
+var SM2AuthOpts = AuthOpts{
+    Cipher:     AuthSM4GCM,
+    KeyEncrypt: KeyEncryptSM2,
+}
+
+// 认证加密, 生成 AuthEnvelopedData
+func EncryptAuth(rand io.Reader, content []byte, recipients []*x509.Certificate, opts ...AuthOpts) ([]byte, error) {
+    opt := &DefaultAuthOpts
+    if len(opts) > 0 {
+        opt = &opts[0]
+    }
+
+    cipher := opt.Cipher
+    if cipher == nil {
+        return nil, errors.New("pkcs7: unknown opts cipher")
+    }
+
+    if opt.KeyEncrypt == nil && opt.KeyAgree == nil {
+        return nil, errors.New("pkcs7: unknown opts keyEncrypt")
+    }
+
+    // 生成密钥
+    key := make([]byte, cipher.KeySize())
+    if _, err := io.ReadFull(rand, key); err != nil {
+        return nil, errors.New("pkcs7: cannot generate key: " + err.Error())
+    }
+
+    recipientInfos, err := makeRecipientInfos(rand, recipients, opt.KeyEncrypt, opt.KeyAgree, key)
+    if err != nil {
+        return nil, err
+    }
+
+    var authAttrs, additional []byte
+    if len(opt.AuthAttributes) > 0 {
+        attrs := &attributes{}
+        attrs.Add(oidAttributeContentType, oidData)
+        for _, attr := range opt.AuthAttributes {
+            attrs.Add(attr.Type, attr.Value)
+        }
+
+        authAttrs, err = marshalAttributeSet(attrs)
+        if err != nil {
+            return nil, err
+        }
+
+        // 认证数据为 SET OF 标签的 authAttrs
+        additional, err = asn1.Marshal(asn1.RawValue{
+            Tag:        asn1.TagSet,
+            IsCompound: true,
+            Bytes:      authAttrs,
+        })
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    var unauthAttrs []byte
+    if len(opt.UnauthAttributes) > 0 {
+        attrs := &attributes{}
+        for _, attr := range opt.UnauthAttributes {
+            attrs.Add(attr.Type, attr.Value)
+        }
+
+        unauthAttrs, err = marshalAttributeSet(attrs)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    encrypted, mac, paramBytes, err := cipher.Encrypt(rand, key, content, additional)
+    if err != nil {
+        return nil, err
+    }
+
+    envelope := authEnvelopedData{
+        Version:        0,
+        RecipientInfos: recipientInfos,
+        AuthEncryptedContentInfo: encryptedContentInfo{
+            ContentType: oidData,
+            ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+                Algorithm: cipher.OID(),
+                Parameters: asn1.RawValue{
+                    FullBytes: paramBytes,
+                },
+            },
+            EncryptedContent: marshalEncryptedContent(encrypted),
+        },
+        AuthAttributes:   implicitAttributes(1, authAttrs),
+        MAC:              mac,
+        UnauthAttributes: implicitAttributes(2, unauthAttrs),
+    }
+
+    innerContent, err := asn1.Marshal(envelope)
+    if err != nil {
+        return nil, err
+    }
+
+    wrapper := contentInfo{
+        ContentType: oidAuthEnvelopedData,
+        Content:     asn1.RawValue{
+            Class: 2,
+            Tag: 0,
+            IsCompound: true,
+            Bytes: innerContent,
+        },
+    }
+
+    return asn1.Marshal(wrapper)
+}
+
+// 解密 AuthEnvelopedData
+func DecryptAuth(data []byte, cert *x509.Certificate, pkey crypto.PrivateKey) ([]byte, error) {
+    content, _, err := DecryptAuthWithAttributes(data, cert, pkey)
+    return content, err
+}
+
+// 解密 AuthEnvelopedData, 同时返回已认证的认证属性
+// 属性值为 asn1.RawValue
+func DecryptAuthWithAttributes(data []byte, cert *x509.Certificate, pkey crypto.PrivateKey) ([]byte, []Attribute, error) {
+    info, contentType, err := parseData(data)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if !oidAuthEnvelopedData.Equal(contentType) {
+        return nil, nil, errors.New("pkcs7: contentType error")
+    }
+
+    var endata authEnvelopedData
+    if _, err := asn1.Unmarshal(info, &endata); err != nil {
+        return nil, nil, err
+    }
+
+    contentKey, err := decryptRecipientKey(endata.RecipientInfos, cert, pkey)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    var additional []byte
+    var attrs []Attribute
+    if len(endata.AuthAttributes.FullBytes) > 0 {
+        additional, err = asn1.Marshal(asn1.RawValue{
+            Tag:        asn1.TagSet,
+            IsCompound: true,
+            Bytes:      endata.AuthAttributes.Bytes,
+        })
+        if err != nil {
+            return nil, nil, err
+        }
+
+        attrs, err = parseAttributeSet(endata.AuthAttributes.Bytes)
+        if err != nil {
+            return nil, nil, err
+        }
+    }
+
+    eci := endata.AuthEncryptedContentInfo
+
+    cipher, err := GetAuthCipher(eci.ContentEncryptionAlgorithm.Algorithm)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    content, err := cipher.Decrypt(contentKey, eci.ContentEncryptionAlgorithm.Parameters.FullBytes,
+        encryptedContentBytes(eci), endata.MAC, additional)
+    if err != nil {
+        return nil, nil, errors.New("pkcs7: message authentication failed")
+    }
+
+    return content, attrs, nil
+}
+
+// marshalAttributeSet returns the contents of the DER SET OF attrs
+func marshalAttributeSet(attrs *attributes) ([]byte, error) {
+    finalAttrs, err := attrs.ForMarshalling()
+    if err != nil {
+        return nil, err
+    }
+
+    encoded, err := marshalAttributes(finalAttrs)
+    if err != nil {
+        return nil, err
+    }
+
+    var set asn1.RawValue
+    if _, err := asn1.Unmarshal(encoded, &set); err != nil {
+        return nil, err
+    }
+
+    return set.Bytes, nil
+}
+
+func implicitAttributes(tag int, attrs []byte) asn1.RawValue {
+    if len(attrs) == 0 {
+        return asn1.RawValue{}
+    }
+
+    return asn1.RawValue{
+        Class:      asn1.ClassContextSpecific,
+        Tag:        tag,
+        IsCompound: true,
+        Bytes:      attrs,
+    }
+}
+
+func parseAttributeSet(data []byte) ([]Attribute, error) {
+    seq, err := asn1.Marshal(asn1.RawValue{
+        Tag:        asn1.TagSequence,
+        IsCompound: true,
+        Bytes:      data,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var attrs []attribute
+    if _, err := asn1.Unmarshal(seq, &attrs); err != nil {
+        return nil, err
+    }
+
+    res := make([]Attribute, 0, len(attrs))
+    for _, attr := range attrs {
+        var value asn1.RawValue
+        if _, err := asn1.Unmarshal(attr.Value.Bytes, &value); err != nil {
+            return nil, err
+        }
+
+        res = append(res, Attribute{
+            Type:  attr.Type,
+            Value: value,
+        })
+    }
+
+    return res, nil
+}
@@ -0,0 +1,166 @@
+package pkcs7
+
+import (
+    "testing"
+    "crypto/rand"
+    "encoding/asn1"
+
+    cryptobin_x509 "github.com/deatil/go-cryptobin/x509"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func Test_EncryptAuth(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    cert, err := createTestCertificate(cryptobin_x509.SHA256WithRSA)
+    assertError(err, "createTestCertificate")
+
+    content := []byte("test data test data test data test data")
+
+    ciphers := map[string]AuthCipher{
+        "AES128GCM":        AuthAES128GCM,
+        "AES192GCM":        AuthAES192GCM,
+        "AES256GCM":        AuthAES256GCM,
+        "AES128CCM":        AuthAES128CCM,
+        "AES192CCM":        AuthAES192CCM,
+        "AES256CCM":        AuthAES256CCM,
+        "SM4GCM":           AuthSM4GCM,
+        "ChaCha20Poly1305": AuthChaCha20Poly1305,
+        "AES128GCM-12":     AuthAES128GCM.WithTagSize(12),
+    }
+
+    for name, cipher := range ciphers {
+        t.Run(name, func(t *testing.T) {
+            enData, err := EncryptAuth(rand.Reader, content, []*cryptobin_x509.Certificate{cert.Certificate}, AuthOpts{
+                Cipher:     cipher,
+                KeyEncrypt: KeyEncryptRSAESOAEP,
+            })
+            assertError(err, "EncryptAuth")
+
+            deData, err := DecryptAuth(enData, cert.Certificate, *cert.PrivateKey)
+            assertError(err, "DecryptAuth")
+            assertEqual(deData, content, "DecryptAuth")
+        })
+    }
+}
+
+func Test_EncryptAuthAttributes(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertNotEmpty := cryptobin_test.AssertNotEmptyT(t)
+
+    cert, err := createTestCertificate(cryptobin_x509.SHA256WithRSA)
+    assertError(err, "createTestCertificate")
+
+    content := []byte("test data test data test data test data")
+    oidTest := asn1.ObjectIdentifier{2, 3, 4, 5, 6, 7}
+
+    enData, err := EncryptAuth(rand.Reader, content, []*cryptobin_x509.Certificate{cert.Certificate}, AuthOpts{
+        Cipher:     AuthAES128GCM,
+        KeyEncrypt: KeyEncryptRSA,
+        AuthAttributes: []Attribute{
+            {Type: oidTest, Value: "authenticated"},
+        },
+        UnauthAttributes: []Attribute{
+            {Type: oidTest, Value: "unauthenticated"},
+        },
+    })
+    assertError(err, "EncryptAuth")
+
+    deData, attrs, err := DecryptAuthWithAttributes(enData, cert.Certificate, *cert.PrivateKey)
+    assertError(err, "DecryptAuthWithAttributes")
+    assertEqual(deData, content, "DecryptAuthWithAttributes")
+
+    // content type and the test attribute
+    assertEqual(len(attrs), 2, "attrs")
+
+    var value string
+    for _, attr := range attrs {
+        if attr.Type.Equal(oidTest) {
+            _, err = asn1.Unmarshal(attr.Value.(asn1.RawValue).FullBytes, &value)
+            assertError(err, "Unmarshal attribute")
+        }
+    }
+    assertEqual(value, "authenticated", "attrs")
+
+    // the authenticated attributes are protected by the mac
+    var info contentInfo
+    _, err = asn1.Unmarshal(enData, &info)
+    assertError(err, "Unmarshal")
+
+    var envelope authEnvelopedData
+    _, err = asn1.Unmarshal(info.Content.Bytes, &envelope)
+    assertError(err, "Unmarshal")
+    assertNotEmpty(envelope.UnauthAttributes.Bytes, "UnauthAttributes")
+
+    tamper := func(fn func(e *authEnvelopedData)) []byte {
+        e := envelope
+        fn(&e)
+
+        inner, err := asn1.Marshal(e)
+        assertError(err, "Marshal")
+
+        data, err := asn1.Marshal(contentInfo{
+            ContentType: oidAuthEnvelopedData,
+            Content:     asn1.RawValue{Class: 2, Tag: 0, IsCompound: true, Bytes: inner},
+        })
+        assertError(err, "Marshal")
+
+        return data
+    }
+
+    attrsBytes := append([]byte(nil), envelope.AuthAttributes.Bytes...)
+    attrsBytes[len(attrsBytes) - 1] ^= 1
+
+    _, err = DecryptAuth(tamper(func(e *authEnvelopedData) {
+        e.AuthAttributes = implicitAttributes(1, attrsBytes)
+    }), cert.Certificate, *cert.PrivateKey)
+    if err == nil {
+        t.Error("DecryptAuth should fail with changed authAttrs")
+    }
+
+    mac := append([]byte(nil), envelope.MAC...)
+    mac[0] ^= 1
+
+    _, err = DecryptAuth(tamper(func(e *authEnvelopedData) {
+        e.MAC = mac
+    }), cert.Certificate, *cert.PrivateKey)
+    if err == nil {
+        t.Error("DecryptAuth should fail with changed mac")
+    }
+
+    // the unauthenticated attributes are not protected
+    _, err = DecryptAuth(tamper(func(e *authEnvelopedData) {
+        e.UnauthAttributes = asn1.RawValue{}
+    }), cert.Certificate, *cert.PrivateKey)
+    assertError(err, "DecryptAuth without unauthAttrs")
+}
+
+func Test_EncryptAuthWithSM2(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    cert := decodeCert(encCert)
+    privkey := decodeSM2PrivateKey(expectedEncKey)
+
+    content := []byte("test data test data test data test data")
+
+    enData, err := EncryptAuth(rand.Reader, content, []*cryptobin_x509.Certificate{cert}, SM2AuthOpts)
+    assertError(err, "EncryptAuth")
+
+    enDataw := EncodePkcs7ToPem(enData, "PKCS7")
+
+    deData, err := ParsePkcs7Pem(enDataw)
+    assertError(err, "ParsePkcs7Pem")
+
+    deDataw, err := DecryptAuth(deData, cert, privkey)
+    assertError(err, "DecryptAuth")
+    assertEqual(deDataw, content, "DecryptAuth")
+
+    // not an EnvelopedData
+    _, err = Decrypt(deData, cert, privkey)
+    if err == nil {
+        t.Error("Decrypt should fail")
+    }
+}
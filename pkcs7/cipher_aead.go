@@ -0,0 +1,328 @@
+package pkcs7
+
+import (
+    "io"
+    "fmt"
+    "errors"
+    "crypto/aes"
+    "crypto/cipher"
+    "encoding/asn1"
+
+    "golang.org/x/crypto/chacha20poly1305"
+
+    "github.com/deatil/go-cryptobin/cipher/sm4"
+    "github.com/deatil/go-cryptobin/mode/ccm"
+)
+
+var (
+    // RFC 5084
+    oidAES128GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}
+    oidAES192GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 26}
+    oidAES256GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+    oidAES128CCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 7}
+    oidAES192CCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 27}
+    oidAES256CCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 47}
+
+    // RFC 8103
+    oidChaCha20Poly1305 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 18}
+
+    // GM/T 0006-2012
+    oidSM4GCM = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 8}
+)
+
+// 认证加密接口, 用于 AuthEnvelopedData
+type AuthCipher interface {
+    // oid
+    OID() asn1.ObjectIdentifier
+
+    // 密钥大小
+    KeySize() int
+
+    // 加密, 返回: [加密后数据, 认证码, 参数, error]
+    Encrypt(rand io.Reader, key, plaintext, additional []byte) ([]byte, []byte, []byte, error)
+
+    // 解密
+    Decrypt(key, params, ciphertext, mac, additional []byte) ([]byte, error)
+}
+
+var authCiphers = make(map[string]func() AuthCipher)
+
+// 添加认证加密方式
+func AddAuthCipher(oid asn1.ObjectIdentifier, fn func() AuthCipher) {
+    authCiphers[oid.String()] = fn
+}
+
+// 获取认证加密方式
+func GetAuthCipher(oid asn1.ObjectIdentifier) (AuthCipher, error) {
+    fn, ok := authCiphers[oid.String()]
+    if !ok {
+        return nil, fmt.Errorf("pkcs7: unsupported auth cipher (OID: %s)", oid.String())
+    }
+
+    return fn(), nil
+}
+
+// 认证加密参数, GCM 及 CCM 模式通用
+// GCMParameters ::= SEQUENCE {
+//     aes-nonce        OCTET STRING,
+//     aes-ICVlen       AES-GCM-ICVlen DEFAULT 12 }
+type aeadParams struct {
+    Nonce  []byte
+    ICVLen int `asn1:"default:12,optional"`
+}
+
+// 分组加密的认证加密模式
+type AuthCipherBlock struct {
+    cipherFunc func(key []byte) (cipher.Block, error)
+    aeadFunc   func(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error)
+    keySize    int
+    nonceSize  int
+    tagSize    int
+    identifier asn1.ObjectIdentifier
+}
+
+// oid
+func (this AuthCipherBlock) OID() asn1.ObjectIdentifier {
+    return this.identifier
+}
+
+// 密钥大小
+func (this AuthCipherBlock) KeySize() int {
+    return this.keySize
+}
+
+// 加密
+func (this AuthCipherBlock) Encrypt(rand io.Reader, key, plaintext, additional []byte) ([]byte, []byte, []byte, error) {
+    block, err := this.cipherFunc(key)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    aead, err := this.aeadFunc(block, this.nonceSize, this.tagSize)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    nonce := make([]byte, this.nonceSize)
+    if _, err := io.ReadFull(rand, nonce); err != nil {
+        return nil, nil, nil, errors.New("pkcs7: failed to generate nonce: " + err.Error())
+    }
+
+    sealed := aead.Seal(nil, nonce, plaintext, additional)
+    split := len(sealed) - this.tagSize
+
+    paramBytes, err := asn1.Marshal(aeadParams{
+        Nonce:  nonce,
+        ICVLen: this.tagSize,
+    })
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    return sealed[:split], sealed[split:], paramBytes, nil
+}
+
+// 解密
+func (this AuthCipherBlock) Decrypt(key, params, ciphertext, mac, additional []byte) ([]byte, error) {
+    var param aeadParams
+    if _, err := asn1.Unmarshal(params, &param); err != nil {
+        return nil, errors.New("pkcs7: invalid auth cipher params")
+    }
+
+    if param.ICVLen != len(mac) {
+        return nil, errors.New("pkcs7: invalid mac size")
+    }
+
+    block, err := this.cipherFunc(key)
+    if err != nil {
+        return nil, err
+    }
+
+    aead, err := this.aeadFunc(block, len(param.Nonce), param.ICVLen)
+    if err != nil {
+        return nil, err
+    }
+
+    sealed := make([]byte, 0, len(ciphertext) + len(mac))
+    sealed = append(sealed, ciphertext...)
+    sealed = append(sealed, mac...)
+
+    return aead.Open(nil, param.Nonce, sealed, additional)
+}
+
+// 设置认证码大小
+func (this AuthCipherBlock) WithTagSize(tagSize int) AuthCipherBlock {
+    this.tagSize = tagSize
+
+    return this
+}
+
+// ChaCha20-Poly1305, RFC 8103
+// AEADChaCha20Poly1305Nonce ::= OCTET STRING (SIZE(12))
+type AuthCipherChaCha20Poly1305 struct {}
+
+// oid
+func (this AuthCipherChaCha20Poly1305) OID() asn1.ObjectIdentifier {
+    return oidChaCha20Poly1305
+}
+
+// 密钥大小
+func (this AuthCipherChaCha20Poly1305) KeySize() int {
+    return chacha20poly1305.KeySize
+}
+
+// 加密
+func (this AuthCipherChaCha20Poly1305) Encrypt(rand io.Reader, key, plaintext, additional []byte) ([]byte, []byte, []byte, error) {
+    aead, err := chacha20poly1305.New(key)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    nonce := make([]byte, chacha20poly1305.NonceSize)
+    if _, err := io.ReadFull(rand, nonce); err != nil {
+        return nil, nil, nil, errors.New("pkcs7: failed to generate nonce: " + err.Error())
+    }
+
+    sealed := aead.Seal(nil, nonce, plaintext, additional)
+    split := len(sealed) - aead.Overhead()
+
+    paramBytes, err := asn1.Marshal(nonce)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    return sealed[:split], sealed[split:], paramBytes, nil
+}
+
+// 解密
+func (this AuthCipherChaCha20Poly1305) Decrypt(key, params, ciphertext, mac, additional []byte) ([]byte, error) {
+    var nonce []byte
+    if _, err := asn1.Unmarshal(params, &nonce); err != nil {
+        return nil, errors.New("pkcs7: invalid auth cipher params")
+    }
+
+    if len(nonce) != chacha20poly1305.NonceSize {
+        return nil, errors.New("pkcs7: invalid nonce size")
+    }
+
+    aead, err := chacha20poly1305.New(key)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(mac) != aead.Overhead() {
+        return nil, errors.New("pkcs7: invalid mac size")
+    }
+
+    sealed := make([]byte, 0, len(ciphertext) + len(mac))
+    sealed = append(sealed, ciphertext...)
+    sealed = append(sealed, mac...)
+
+    return aead.Open(nil, nonce, sealed, additional)
+}
+
+func newGCM(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+    if nonceSize != 12 {
+        if tagSize != 16 {
+            return nil, errors.New("pkcs7: unsupported gcm nonce and tag size")
+        }
+
+        return cipher.NewGCMWithNonceSize(block, nonceSize)
+    }
+
+    return cipher.NewGCMWithTagSize(block, tagSize)
+}
+
+// 认证加密方式
+var (
+    AuthAES128GCM = AuthCipherBlock{
+        cipherFunc: aes.NewCipher,
+        aeadFunc:   newGCM,
+        keySize:    16,
+        nonceSize:  12,
+        tagSize:    16,
+        identifier: oidAES128GCM,
+    }
+    AuthAES192GCM = AuthCipherBlock{
+        cipherFunc: aes.NewCipher,
+        aeadFunc:   newGCM,
+        keySize:    24,
+        nonceSize:  12,
+        tagSize:    16,
+        identifier: oidAES192GCM,
+    }
+    AuthAES256GCM = AuthCipherBlock{
+        cipherFunc: aes.NewCipher,
+        aeadFunc:   newGCM,
+        keySize:    32,
+        nonceSize:  12,
+        tagSize:    16,
+        identifier: oidAES256GCM,
+    }
+
+    AuthAES128CCM = AuthCipherBlock{
+        cipherFunc: aes.NewCipher,
+        aeadFunc:   ccm.NewCCMWithNonceAndTagSize,
+        keySize:    16,
+        nonceSize:  12,
+        tagSize:    16,
+        identifier: oidAES128CCM,
+    }
+    AuthAES192CCM = AuthCipherBlock{
+        cipherFunc: aes.NewCipher,
+        aeadFunc:   ccm.NewCCMWithNonceAndTagSize,
+        keySize:    24,
+        nonceSize:  12,
+        tagSize:    16,
+        identifier: oidAES192CCM,
+    }
+    AuthAES256CCM = AuthCipherBlock{
+        cipherFunc: aes.NewCipher,
+        aeadFunc:   ccm.NewCCMWithNonceAndTagSize,
+        keySize:    32,
+        nonceSize:  12,
+        tagSize:    16,
+        identifier: oidAES256CCM,
+    }
+
+    AuthSM4GCM = AuthCipherBlock{
+        cipherFunc: sm4.NewCipher,
+        aeadFunc:   newGCM,
+        keySize:    16,
+        nonceSize:  12,
+        tagSize:    16,
+        identifier: oidSM4GCM,
+    }
+
+    AuthChaCha20Poly1305 = AuthCipherChaCha20Poly1305{}
+)
+
+func init() {
+    AddAuthCipher(oidAES128GCM, func() AuthCipher {
+        return AuthAES128GCM
+    })
+    AddAuthCipher(oidAES192GCM, func() AuthCipher {
+        return AuthAES192GCM
+    })
+    AddAuthCipher(oidAES256GCM, func() AuthCipher {
+        return AuthAES256GCM
+    })
+
+    AddAuthCipher(oidAES128CCM, func() AuthCipher {
+        return AuthAES128CCM
+    })
+    AddAuthCipher(oidAES192CCM, func() AuthCipher {
+        return AuthAES192CCM
+    })
+    AddAuthCipher(oidAES256CCM, func() AuthCipher {
+        return AuthAES256CCM
+    })
+
+    AddAuthCipher(oidSM4GCM, func() AuthCipher {
+        return AuthSM4GCM
+    })
+
+    AddAuthCipher(oidChaCha20Poly1305, func() AuthCipher {
+        return AuthChaCha20Poly1305
+    })
+}
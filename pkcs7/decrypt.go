@@ -14,23 +14,114 @@ import (
 
 // 解析
 func Decrypt(data []byte, cert *x509.Certificate, pkey crypto.PrivateKey) ([]byte, error) {
-    info, contentType, err := parseData(data)
+    endata, err := parseEnvelopedData(data)
+    if err != nil {
+        return nil, err
+    }
+
+    contentKey, err := decryptRecipientKey(endata.RecipientInfos, cert, pkey)
     if err != nil {
         return nil, err
     }
 
+    return encryptedContentInfoDecrypt(endata.EncryptedContentInfo, contentKey)
+}
+
+// 使用密码解密 PasswordRecipientInfo 接收方
+func DecryptWithPassword(data []byte, password []byte) ([]byte, error) {
+    endata, err := parseEnvelopedData(data)
+    if err != nil {
+        return nil, err
+    }
+
+    // a wrong password fails the check of the key wrap
+    for _, raw := range endata.RecipientInfos {
+        if raw.Class != asn1.ClassContextSpecific || raw.Tag != 3 {
+            continue
+        }
+
+        contentKey, err := decryptPasswordRecipient(raw, password)
+        if err != nil {
+            continue
+        }
+
+        return encryptedContentInfoDecrypt(endata.EncryptedContentInfo, contentKey)
+    }
+
+    return nil, errors.New("pkcs7: no password recipient for provided password")
+}
+
+// 使用密钥加密密钥解密 KEKRecipientInfo 接收方
+func DecryptWithKEK(data []byte, keyID, kek []byte) ([]byte, error) {
+    endata, err := parseEnvelopedData(data)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, raw := range endata.RecipientInfos {
+        if raw.Class != asn1.ClassContextSpecific || raw.Tag != 2 {
+            continue
+        }
+
+        contentKey, err := decryptKEKRecipient(raw, keyID, kek)
+        if err != nil {
+            return nil, err
+        }
+
+        if contentKey != nil {
+            return encryptedContentInfoDecrypt(endata.EncryptedContentInfo, contentKey)
+        }
+    }
+
+    return nil, errors.New("pkcs7: no KEK recipient for provided key identifier")
+}
+
+func parseEnvelopedData(data []byte) (envelopedData, error) {
+    info, contentType, err := parseData(data)
+    if err != nil {
+        return envelopedData{}, err
+    }
+
     if !DefaultMode.IsEnvelopedData(contentType) &&
         !SM2Mode.IsEnvelopedData(contentType) &&
         !SM9Mode.IsEnvelopedData(contentType) {
-        return nil, errors.New("pkcs7: contentType error")
+        return envelopedData{}, errors.New("pkcs7: contentType error")
     }
 
     var endata envelopedData
     if _, err := asn1.Unmarshal(info, &endata); err != nil {
-        return nil, err
+        return envelopedData{}, err
+    }
+
+    return endata, nil
+}
+
+// decryptRecipientKey decrypts the content key of the recipient of cert
+// from the KeyTransRecipientInfo or KeyAgreeRecipientInfo recipients
+func decryptRecipientKey(recipients []asn1.RawValue, cert *x509.Certificate, pkey crypto.PrivateKey) ([]byte, error) {
+    var keyTrans []recipientInfo
+    for _, raw := range recipients {
+        switch {
+            case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagSequence:
+                var info recipientInfo
+                if _, err := asn1.Unmarshal(raw.FullBytes, &info); err != nil {
+                    return nil, err
+                }
+
+                keyTrans = append(keyTrans, info)
+            case raw.Class == asn1.ClassContextSpecific && raw.Tag == 1:
+                key, err := decryptKeyAgreeRecipient(raw, cert, pkey)
+                if err != nil {
+                    return nil, err
+                }
+
+                if key != nil {
+                    return key, nil
+                }
+        }
     }
 
-    recipient := selectRecipientForCertificate(endata.RecipientInfos, cert)
+    recipient := selectRecipientForCertificate(keyTrans, cert)
     if recipient.EncryptedKey == nil {
         return nil, errors.New("pkcs7: no enveloped recipient for provided certificate")
     }
@@ -40,12 +131,7 @@ func Decrypt(data []byte, cert *x509.Certificate, pkey crypto.PrivateKey) ([]byt
         return nil, err
     }
 
-    contentKey, err := keyEncrypt.Decrypt(recipient.EncryptedKey, pkey)
-    if err != nil {
-        return nil, err
-    }
-
-    return encryptedContentInfoDecrypt(endata.EncryptedContentInfo, contentKey)
+    return keyEncrypt.Decrypt(recipient.EncryptedKey, pkey)
 }
 
 // DecryptUsingPSK decrypts encrypted data using caller provided
@@ -71,6 +157,22 @@ func DecryptUsingPSK(data []byte, key []byte) ([]byte, error) {
 }
 
 func encryptedContentInfoDecrypt(eci encryptedContentInfo, key []byte) ([]byte, error) {
+    cyphertext := encryptedContentBytes(eci)
+
+    cipher, cipherParams, err := parseEncryptionScheme(eci.ContentEncryptionAlgorithm)
+    if err != nil {
+        return nil, err
+    }
+
+    decryptedKey, err := cipher.Decrypt(key, cipherParams, cyphertext)
+    if err != nil {
+        return nil, err
+    }
+
+    return decryptedKey, nil
+}
+
+func encryptedContentBytes(eci encryptedContentInfo) []byte {
     // EncryptedContent can either be constructed of multple OCTET STRINGs
     // or _be_ a tagged OCTET STRING
     var cyphertext []byte
@@ -92,17 +194,7 @@ func encryptedContentInfoDecrypt(eci encryptedContentInfo, key []byte) ([]byte,
         cyphertext = eci.EncryptedContent.Bytes
     }
 
-    cipher, cipherParams, err := parseEncryptionScheme(eci.ContentEncryptionAlgorithm)
-    if err != nil {
-        return nil, err
-    }
-
-    decryptedKey, err := cipher.Decrypt(key, cipherParams, cyphertext)
-    if err != nil {
-        return nil, err
-    }
-
-    return decryptedKey, nil
+    return cyphertext
 }
 
 func parseKeyEncrypt(keyEncrypt pkix.AlgorithmIdentifier) (KeyEncrypt, error) {
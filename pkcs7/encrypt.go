@@ -11,7 +11,7 @@ import (
 
 type envelopedData struct {
     Version              int
-    RecipientInfos       []recipientInfo `asn1:"set"`
+    RecipientInfos       []asn1.RawValue `asn1:"set"`
     EncryptedContentInfo encryptedContentInfo
 }
 
@@ -41,7 +41,14 @@ var ErrPSKNotProvided = errors.New("pkcs7: cannot encrypt content: PSK not provi
 type Opts struct {
     Cipher     Cipher
     KeyEncrypt KeyEncrypt
+    KeyAgree   KeyAgree
     Mode       Mode
+
+    // 密码接收方
+    PasswordRecipients []PasswordRecipient
+
+    // 密钥加密密钥接收方
+    KEKRecipients []KEKRecipient
 }
 
 // 默认配置
@@ -74,11 +81,14 @@ func Encrypt(rand io.Reader, content []byte, recipients []*x509.Certificate, opt
         return nil, errors.New("pkcs7: failed to encrypt PEM: unknown opts cipher")
     }
 
-    keyEncrypt := opt.KeyEncrypt
-    if keyEncrypt == nil {
+    if len(recipients) > 0 && opt.KeyEncrypt == nil && opt.KeyAgree == nil {
         return nil, errors.New("pkcs7: unknown opts keyEncrypt")
     }
 
+    if len(recipients) == 0 && len(opt.PasswordRecipients) == 0 && len(opt.KEKRecipients) == 0 {
+        return nil, errors.New("pkcs7: no recipients")
+    }
+
     useMode := opt.Mode
 
     // 生成密钥
@@ -104,33 +114,33 @@ func Encrypt(rand io.Reader, content []byte, recipients []*x509.Certificate, opt
     }
 
     // Prepare each recipient's encrypted cipher key
-    recipientInfos := make([]recipientInfo, len(recipients))
-    for i, recipient := range recipients {
-        encrypted, err := keyEncrypt.Encrypt(key, recipient.PublicKey)
+    recipientInfos, err := makeRecipientInfos(rand, recipients, opt.KeyEncrypt, opt.KeyAgree, key)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, recipient := range opt.KEKRecipients {
+        info, err := kekRecipientInfoFor(rand, recipient, key)
         if err != nil {
             return nil, err
         }
 
-        ias, err := cert2issuerAndSerial(recipient)
+        recipientInfos = append(recipientInfos, info)
+    }
+
+    for _, recipient := range opt.PasswordRecipients {
+        info, err := passwordRecipientInfoFor(rand, recipient, key)
         if err != nil {
             return nil, err
         }
 
-        info := recipientInfo{
-            Version:               0,
-            IssuerAndSerialNumber: ias,
-            KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
-                Algorithm: keyEncrypt.OID(),
-            },
-            EncryptedKey: encrypted,
-        }
-        recipientInfos[i] = info
+        recipientInfos = append(recipientInfos, info)
     }
 
     // Prepare envelope content
     envelope := envelopedData{
         EncryptedContentInfo: *eci,
-        Version:              0,
+        Version:              envelopedDataVersion(recipientInfos),
         RecipientInfos:       recipientInfos,
     }
     innerContent, err := asn1.Marshal(envelope)
@@ -207,6 +217,91 @@ func EncryptUsingPSK(rand io.Reader, content []byte, key []byte, cipher Cipher,
     return asn1.Marshal(wrapper)
 }
 
+// makeRecipientInfos encrypts the content key for each recipient, with
+// KeyTransRecipientInfo when keyEncrypt supports the recipient key and
+// with KeyAgreeRecipientInfo when keyAgree does
+func makeRecipientInfos(rand io.Reader, recipients []*x509.Certificate, keyEncrypt KeyEncrypt, keyAgree KeyAgree, key []byte) ([]asn1.RawValue, error) {
+    recipientInfos := make([]asn1.RawValue, len(recipients))
+    for i, recipient := range recipients {
+        pub, err := recipientPublicKey(recipient)
+        if err != nil {
+            return nil, err
+        }
+
+        switch {
+            case keyEncrypt != nil && keyEncrypt.Check(pub):
+                recipientInfos[i], err = keyTransRecipientInfoFor(recipient, keyEncrypt, key)
+            case keyAgree != nil && keyAgree.Check(pub):
+                recipientInfos[i], err = keyAgreeRecipientInfoFor(rand, recipient, pub, keyAgree, key)
+            default:
+                err = errors.New("pkcs7: unsupported recipient public key")
+        }
+
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return recipientInfos, nil
+}
+
+// keyTransRecipientInfoFor returns the KeyTransRecipientInfo of the
+// content key for the recipient
+func keyTransRecipientInfoFor(recipient *x509.Certificate, keyEncrypt KeyEncrypt, key []byte) (asn1.RawValue, error) {
+    encrypted, err := keyEncrypt.Encrypt(key, recipient.PublicKey)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    ias, err := cert2issuerAndSerial(recipient)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    info := recipientInfo{
+        Version:               0,
+        IssuerAndSerialNumber: ias,
+        KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+            Algorithm: keyEncrypt.OID(),
+        },
+        EncryptedKey: encrypted,
+    }
+
+    encoded, err := asn1.Marshal(info)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    return asn1.RawValue{FullBytes: encoded}, nil
+}
+
+// envelopedDataVersion returns the EnvelopedData version of RFC 5652
+// for the recipient infos
+func envelopedDataVersion(recipientInfos []asn1.RawValue) int {
+    version := 0
+    for _, info := range recipientInfos {
+        if !isContextSpecificTag(info.FullBytes) {
+            continue
+        }
+
+        // pwri [3] and ori [4]
+        switch info.FullBytes[0] & 0x1f {
+            case 3, 4:
+                return 3
+        }
+
+        version = 2
+    }
+
+    return version
+}
+
+// isContextSpecificTag checks the class of the tag of the DER value,
+// the RecipientInfo CHOICEs other than KeyTransRecipientInfo are tagged
+func isContextSpecificTag(der []byte) bool {
+    return len(der) > 0 && der[0] & 0xc0 == 0x80
+}
+
 func marshalEncryptedContent(content []byte) asn1.RawValue {
     asn1Content, _ := asn1.Marshal(content)
     return asn1.RawValue{
@@ -0,0 +1,218 @@
+package est
+
+import (
+    "io"
+    "fmt"
+    "time"
+    "bytes"
+    "errors"
+    "context"
+    "strconv"
+    "strings"
+    "net/http"
+    "mime"
+    "mime/multipart"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// maxResponseSize limits the size of the response bodies.
+const maxResponseSize = 1 << 20
+
+// Client is an EST client.
+type Client struct {
+    // URL is the scheme and authority of the server, as
+    // "https://est.example.com".
+    URL string
+
+    // Label is the optional CA label.
+    Label string
+
+    // Username and Password, if set, are sent as HTTP basic
+    // credentials.
+    Username string
+    Password string
+
+    // HTTPClient sends the requests, http.DefaultClient if nil. Its
+    // TLS configuration holds the client certificate used by
+    // SimpleReenroll.
+    HTTPClient *http.Client
+}
+
+// CACerts returns the current CA certificates.
+func (c *Client) CACerts(ctx context.Context) ([]*x509.Certificate, error) {
+    resp, err := c.do(ctx, http.MethodGet, OpCACerts, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    return decodeCerts(resp)
+}
+
+// CSRAttrs returns the attributes the CA wants in the requests, nil if
+// it has none.
+func (c *Client) CSRAttrs(ctx context.Context) (*CSRAttrs, error) {
+    resp, err := c.do(ctx, http.MethodGet, OpCSRAttrs, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(resp) == 0 {
+        return nil, nil
+    }
+
+    der, err := decodeBase64(resp)
+    if err != nil {
+        return nil, err
+    }
+
+    return ParseCSRAttrs(der)
+}
+
+// SimpleEnroll asks a certificate for csr.
+func (c *Client) SimpleEnroll(ctx context.Context, csr *x509.CertificateRequest) (*x509.Certificate, error) {
+    return c.enroll(ctx, OpSimpleEnroll, csr)
+}
+
+// SimpleReenroll asks a new certificate for csr, that has the subject
+// and names of the client certificate.
+func (c *Client) SimpleReenroll(ctx context.Context, csr *x509.CertificateRequest) (*x509.Certificate, error) {
+    return c.enroll(ctx, OpSimpleReenroll, csr)
+}
+
+// ServerKeyGen asks a certificate with a key generated by the server,
+// of the type of the key of csr. It returns the certificate and the
+// PKCS #8 encoded private key.
+func (c *Client) ServerKeyGen(ctx context.Context, csr *x509.CertificateRequest) (*x509.Certificate, []byte, error) {
+    body, header, err := c.doHeader(ctx, http.MethodPost, OpServerKeyGen, csr.Raw)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+    if err != nil || mediaType != "multipart/mixed" {
+        return nil, nil, errors.New("est: invalid serverkeygen response")
+    }
+
+    var cert *x509.Certificate
+    var key []byte
+
+    mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+    for {
+        part, err := mr.NextPart()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, nil, err
+        }
+
+        data, err := io.ReadAll(part)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+        switch partType {
+            case mimePKCS8:
+                if key, err = decodeBase64(data); err != nil {
+                    return nil, nil, err
+                }
+            case mimePKCS7:
+                certs, err := decodeCerts(data)
+                if err != nil {
+                    return nil, nil, err
+                }
+
+                cert = certs[0]
+        }
+    }
+
+    if cert == nil || key == nil {
+        return nil, nil, errors.New("est: incomplete serverkeygen response")
+    }
+
+    return cert, key, nil
+}
+
+func (c *Client) enroll(ctx context.Context, op string, csr *x509.CertificateRequest) (*x509.Certificate, error) {
+    resp, err := c.do(ctx, http.MethodPost, op, csr.Raw)
+    if err != nil {
+        return nil, err
+    }
+
+    certs, err := decodeCerts(resp)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, cert := range certs {
+        if samePublicKey(cert.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo) {
+            return cert, nil
+        }
+    }
+
+    return nil, errors.New("est: no certificate for the request key")
+}
+
+func (c *Client) do(ctx context.Context, method, op string, csr []byte) ([]byte, error) {
+    body, _, err := c.doHeader(ctx, method, op, csr)
+    return body, err
+}
+
+func (c *Client) doHeader(ctx context.Context, method, op string, csr []byte) ([]byte, http.Header, error) {
+    url := strings.TrimSuffix(c.URL, "/") + WellKnownPrefix
+    if c.Label != "" {
+        url += c.Label + "/"
+    }
+    url += op
+
+    var reqBody io.Reader
+    if csr != nil {
+        reqBody = bytes.NewReader(encodeBase64(csr))
+    }
+
+    req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if csr != nil {
+        req.Header.Set("Content-Type", mimePKCS10)
+        req.Header.Set("Content-Transfer-Encoding", "base64")
+    }
+
+    if c.Username != "" || c.Password != "" {
+        req.SetBasicAuth(c.Username, c.Password)
+    }
+
+    client := c.HTTPClient
+    if client == nil {
+        client = http.DefaultClient
+    }
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+    if err != nil {
+        return nil, nil, err
+    }
+
+    switch resp.StatusCode {
+        case http.StatusOK:
+            return body, resp.Header, nil
+        case http.StatusNoContent:
+            return nil, resp.Header, nil
+        case http.StatusAccepted:
+            seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+            return nil, nil, &PendingError{
+                RetryAfter: time.Duration(seconds) * time.Second,
+            }
+    }
+
+    return nil, nil, fmt.Errorf("est: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
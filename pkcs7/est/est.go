@@ -0,0 +1,301 @@
+// Package est implements Enrollment over Secure Transport, RFC 7030:
+// an http.Handler serving the EST operations from a pluggable CA, and
+// a client.
+package est
+
+import (
+    "fmt"
+    "time"
+    "bytes"
+    "errors"
+    "context"
+    "crypto"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "encoding/asn1"
+    "encoding/base64"
+    std_x509 "crypto/x509"
+
+    "github.com/deatil/go-cryptobin/pkcs7"
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+)
+
+// WellKnownPrefix is the path prefix of the EST operations.
+const WellKnownPrefix = "/.well-known/est/"
+
+// The EST operations, RFC 7030 section 3.2.2.
+const (
+    OpCACerts        = "cacerts"
+    OpSimpleEnroll   = "simpleenroll"
+    OpSimpleReenroll = "simplereenroll"
+    OpServerKeyGen   = "serverkeygen"
+    OpCSRAttrs       = "csrattrs"
+)
+
+const (
+    mimeCerts    = "application/pkcs7-mime; smime-type=certs-only"
+    mimePKCS7    = "application/pkcs7-mime"
+    mimePKCS8    = "application/pkcs8"
+    mimePKCS10   = "application/pkcs10"
+    mimeCSRAttrs = "application/csrattrs"
+)
+
+// CA issues the certificates of an EST server. The label is the
+// optional CA label of the request path, RFC 7030 section 3.2.2, empty
+// when the request has none.
+type CA interface {
+    // CACerts returns the current CA certificates, the issuing CA
+    // first.
+    CACerts(ctx context.Context, label string) ([]*x509.Certificate, error)
+
+    // Enroll issues a certificate for csr.
+    Enroll(ctx context.Context, csr *x509.CertificateRequest, label string) (*x509.Certificate, error)
+
+    // Reenroll issues a certificate for csr renewing or re-keying cert,
+    // the certificate the client authenticated with. csr has the
+    // subject and the subject alternative names of cert.
+    Reenroll(ctx context.Context, cert *x509.Certificate, csr *x509.CertificateRequest, label string) (*x509.Certificate, error)
+}
+
+// CSRAttrsCA is implemented by the CAs asking for attributes in the
+// certificate requests. The csrattrs operation answers with no content
+// for the other CAs.
+type CSRAttrsCA interface {
+    CSRAttrs(ctx context.Context, label string) (*CSRAttrs, error)
+}
+
+// PendingError is returned by a CA whose enrollment is not complete
+// yet. The server answers with status 202 and the client returns it.
+type PendingError struct {
+    RetryAfter time.Duration
+}
+
+func (e *PendingError) Error() string {
+    return fmt.Sprintf("est: enrollment pending, retry after %s", e.RetryAfter)
+}
+
+// Attribute is an attribute of CSRAttrs.
+type Attribute struct {
+    Type   asn1.ObjectIdentifier
+    Values []asn1.RawValue `asn1:"set"`
+}
+
+// CSRAttrs are the attributes a CA wants in the certificate requests,
+// RFC 7030 section 4.5.2.
+type CSRAttrs struct {
+    // OIDs ask for attributes or signature algorithms.
+    OIDs []asn1.ObjectIdentifier
+
+    // Attributes ask for attributes with the values.
+    Attributes []Attribute
+}
+
+// Marshal returns the DER encoding of the CsrAttrs.
+func (a *CSRAttrs) Marshal() ([]byte, error) {
+    var attrs []asn1.RawValue
+
+    for _, oid := range a.OIDs {
+        der, err := asn1.Marshal(oid)
+        if err != nil {
+            return nil, err
+        }
+
+        attrs = append(attrs, asn1.RawValue{FullBytes: der})
+    }
+
+    for _, attr := range a.Attributes {
+        der, err := asn1.Marshal(attr)
+        if err != nil {
+            return nil, err
+        }
+
+        attrs = append(attrs, asn1.RawValue{FullBytes: der})
+    }
+
+    if attrs == nil {
+        attrs = []asn1.RawValue{}
+    }
+
+    return asn1.Marshal(attrs)
+}
+
+// ParseCSRAttrs parses a DER-encoded CsrAttrs.
+func ParseCSRAttrs(der []byte) (*CSRAttrs, error) {
+    var raws []asn1.RawValue
+
+    rest, err := asn1.Unmarshal(der, &raws)
+    if err != nil {
+        return nil, err
+    }
+    if len(rest) > 0 {
+        return nil, errors.New("est: trailing data after CsrAttrs")
+    }
+
+    attrs := new(CSRAttrs)
+    for _, raw := range raws {
+        switch {
+            case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagOID:
+                var oid asn1.ObjectIdentifier
+                if _, err := asn1.Unmarshal(raw.FullBytes, &oid); err != nil {
+                    return nil, err
+                }
+
+                attrs.OIDs = append(attrs.OIDs, oid)
+            case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagSequence:
+                var attr Attribute
+                if _, err := asn1.Unmarshal(raw.FullBytes, &attr); err != nil {
+                    return nil, err
+                }
+
+                attrs.Attributes = append(attrs.Attributes, attr)
+            default:
+                return nil, errors.New("est: invalid CsrAttrs element")
+        }
+    }
+
+    return attrs, nil
+}
+
+// encodeCerts returns the base64 encoding of the certs-only pkcs7 of
+// certs.
+func encodeCerts(certs []*x509.Certificate) ([]byte, error) {
+    var buf bytes.Buffer
+    for _, cert := range certs {
+        buf.Write(cert.Raw)
+    }
+
+    der, err := pkcs7.DegenerateCertificate(buf.Bytes())
+    if err != nil {
+        return nil, err
+    }
+
+    return encodeBase64(der), nil
+}
+
+// decodeCerts parses the base64 encoded certs-only pkcs7 body.
+func decodeCerts(body []byte) ([]*x509.Certificate, error) {
+    der, err := decodeBase64(body)
+    if err != nil {
+        return nil, err
+    }
+
+    p7, err := pkcs7.Parse(der)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(p7.Certificates) == 0 {
+        return nil, errors.New("est: no certificate in response")
+    }
+
+    return p7.Certificates, nil
+}
+
+func encodeBase64(der []byte) []byte {
+    out := make([]byte, base64.StdEncoding.EncodedLen(len(der)))
+    base64.StdEncoding.Encode(out, der)
+
+    return out
+}
+
+// decodeBase64 decodes a base64 body, ignoring line breaks.
+func decodeBase64(body []byte) ([]byte, error) {
+    body = bytes.Map(func(r rune) rune {
+        switch r {
+            case ' ', '\t', '\r', '\n':
+                return -1
+        }
+
+        return r
+    }, body)
+
+    out := make([]byte, base64.StdEncoding.DecodedLen(len(body)))
+    n, err := base64.StdEncoding.Decode(out, body)
+    if err != nil {
+        return nil, errors.New("est: invalid base64 content")
+    }
+
+    return out[:n], nil
+}
+
+// generateKey returns a new private key of the type of pub and its
+// PKCS #8 encoding.
+func generateKey(pub crypto.PublicKey) (crypto.Signer, []byte, error) {
+    var key crypto.Signer
+    var der []byte
+    var err error
+
+    switch pub := pub.(type) {
+        case *rsa.PublicKey:
+            var k *rsa.PrivateKey
+            if k, err = rsa.GenerateKey(rand.Reader, pub.N.BitLen()); err == nil {
+                key = k
+                der, err = std_x509.MarshalPKCS8PrivateKey(k)
+            }
+        case *ecdsa.PublicKey:
+            var k *ecdsa.PrivateKey
+            if k, err = ecdsa.GenerateKey(pub.Curve, rand.Reader); err == nil {
+                key = k
+                der, err = std_x509.MarshalPKCS8PrivateKey(k)
+            }
+        case ed25519.PublicKey:
+            var k ed25519.PrivateKey
+            if _, k, err = ed25519.GenerateKey(rand.Reader); err == nil {
+                key = k
+                der, err = std_x509.MarshalPKCS8PrivateKey(k)
+            }
+        case *sm2.PublicKey:
+            var k *sm2.PrivateKey
+            if k, err = sm2.GenerateKey(rand.Reader); err == nil {
+                key = k
+                der, err = sm2.MarshalPrivateKey(k)
+            }
+        case *gost.PublicKey:
+            var k *gost.PrivateKey
+            if k, err = gost.GenerateKey(rand.Reader, pub.Curve); err == nil {
+                key = k
+                der, err = gost.MarshalPrivateKey(k)
+            }
+        default:
+            return nil, nil, fmt.Errorf("est: unsupported key type %T", pub)
+    }
+
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return key, der, nil
+}
+
+// signatureAlgorithm returns the algorithm of the certificate requests
+// signed by key.
+func signatureAlgorithm(key crypto.Signer) x509.SignatureAlgorithm {
+    switch k := key.(type) {
+        case *rsa.PrivateKey:
+            return x509.SHA256WithRSA
+        case *ecdsa.PrivateKey:
+            return x509.ECDSAWithSHA256
+        case ed25519.PrivateKey:
+            return x509.PureEd25519
+        case *sm2.PrivateKey:
+            return x509.SM2WithSM3
+        case *gost.PrivateKey:
+            if k.Curve.PointSize() == 64 {
+                return x509.GOST3410WithGOST34112012512
+            }
+
+            return x509.GOST3410WithGOST34112012256
+    }
+
+    return x509.UnknownSignatureAlgorithm
+}
+
+// samePublicKey reports whether a and b have the same subject public
+// key info.
+func samePublicKey(a, b []byte) bool {
+    return len(a) > 0 && bytes.Equal(a, b)
+}
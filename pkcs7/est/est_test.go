@@ -0,0 +1,356 @@
+package est
+
+import (
+    "time"
+    "errors"
+    "context"
+    "testing"
+    "net/http"
+    "net/http/httptest"
+    "crypto"
+    "crypto/tls"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+    "github.com/deatil/go-cryptobin/cryptobin/ca/authority"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func newCSR(t *testing.T, key crypto.Signer, cn string, dnsNames ...string) *x509.CertificateRequest {
+    der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+        Subject:            pkix.Name{
+            CommonName: cn,
+        },
+        DNSNames:           dnsNames,
+        SignatureAlgorithm: signatureAlgorithm(key),
+    }, key)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    csr, err := x509.ParseCertificateRequest(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return csr
+}
+
+func newAuthority(t *testing.T) *authority.Authority {
+    key, err := sm2.GenerateKey(rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    ca, err := authority.NewRoot(pkix.Name{CommonName: "EST Root"}, key, 24 * time.Hour, authority.NewMemoryStore())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return ca
+}
+
+func allowAll(r *http.Request, csr *x509.CertificateRequest) error {
+    return nil
+}
+
+type attrsCA struct {
+    *authority.Service
+    attrs *CSRAttrs
+}
+
+func (ca attrsCA) CSRAttrs(ctx context.Context, label string) (*CSRAttrs, error) {
+    return ca.attrs, nil
+}
+
+type pendingCA struct {
+    *authority.Service
+}
+
+func (ca pendingCA) Enroll(ctx context.Context, csr *x509.CertificateRequest, label string) (*x509.Certificate, error) {
+    return nil, &PendingError{RetryAfter: time.Minute}
+}
+
+func Test_EST(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    ca := newAuthority(t)
+
+    var current *x509.Certificate
+
+    srv := httptest.NewServer(&Server{
+        CA:        authority.NewService(ca),
+        Authorize: func(r *http.Request, csr *x509.CertificateRequest) error {
+            user, pass, ok := r.BasicAuth()
+            if !ok || user != "device" || pass != "secret" {
+                return errors.New("bad credentials")
+            }
+
+            return nil
+        },
+        ClientCertificate: func(r *http.Request) (*x509.Certificate, error) {
+            return current, nil
+        },
+    })
+    defer srv.Close()
+
+    client := &Client{
+        URL:      srv.URL,
+        Username: "device",
+        Password: "secret",
+    }
+
+    certs, err := client.CACerts(ctx)
+    assertError(err, "CACerts")
+    assertEqual(len(certs), 1, "CACerts")
+    assertEqual(certs[0].Raw, ca.Certificate().Raw, "CACerts")
+
+    // GOST and SM2 devices
+    gostKey, err := gost.GenerateKey(rand.Reader, gost.CurveIdGostR34102001CryptoProAParamSet())
+    assertError(err, "GenerateKey")
+
+    sm2Key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    for _, key := range []crypto.Signer{gostKey, sm2Key} {
+        csr := newCSR(t, key, "device.example.com", "device.example.com")
+
+        cert, err := client.SimpleEnroll(ctx, csr)
+        assertError(err, "SimpleEnroll")
+        assertEqual(cert.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo, "SimpleEnroll")
+        assertError(cert.CheckSignatureFrom(ca.Certificate()), "SimpleEnroll")
+
+        current = cert
+    }
+
+    // the client profile with the label
+    labelClient := *client
+    labelClient.Label = "client"
+
+    cert, err := labelClient.SimpleEnroll(ctx, newCSR(t, sm2Key, "device"))
+    assertError(err, "SimpleEnroll label")
+    assertEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, "SimpleEnroll label")
+
+    // the labels out of the allowlist and the CA profiles
+    ca.AddProfile(authority.SubCAProfile(0))
+
+    for _, label := range []string{"sub-ca", "code-signing", "unknown"} {
+        labelClient.Label = label
+
+        _, err = labelClient.SimpleEnroll(ctx, newCSR(t, sm2Key, "device"))
+        assertBool(err != nil, "SimpleEnroll label " + label)
+    }
+
+    // rejected credentials and requests
+    badClient := &Client{URL: srv.URL}
+    _, err = badClient.SimpleEnroll(ctx, newCSR(t, sm2Key, "device.example.com", "device.example.com"))
+    assertBool(err != nil, "SimpleEnroll without credentials")
+
+    _, err = client.SimpleEnroll(ctx, newCSR(t, sm2Key, "device.example.com"))
+    assertBool(err != nil, "SimpleEnroll rejected by policy")
+
+    // renewal with the same key, re-key with a new one
+    renewed, err := client.SimpleReenroll(ctx, newCSR(t, sm2Key, "device.example.com", "device.example.com"))
+    assertError(err, "SimpleReenroll")
+    assertEqual(renewed.RawSubjectPublicKeyInfo, current.RawSubjectPublicKeyInfo, "SimpleReenroll")
+
+    current = renewed
+
+    newKey, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    rekeyed, err := client.SimpleReenroll(ctx, newCSR(t, newKey, "device.example.com", "device.example.com"))
+    assertError(err, "SimpleReenroll rekey")
+    assertEqual(rekeyed.PublicKey.(*sm2.PublicKey).Equal(&newKey.PublicKey), true, "SimpleReenroll rekey")
+
+    rec, err := ca.Lookup(renewed.SerialNumber)
+    assertError(err, "Lookup")
+    assertBool(rec.Revoked, "Lookup")
+
+    current = rekeyed
+
+    _, err = client.SimpleReenroll(ctx, newCSR(t, newKey, "other.example.com", "other.example.com"))
+    assertBool(err != nil, "SimpleReenroll other subject")
+
+    // server generated GOST key
+    cert, keyDer, err := client.ServerKeyGen(ctx, newCSR(t, gostKey, "device.example.com", "device.example.com"))
+    assertError(err, "ServerKeyGen")
+
+    genKey, err := gost.ParsePrivateKey(keyDer)
+    assertError(err, "ServerKeyGen key")
+    assertBool(genKey.PublicKey.Equal(cert.PublicKey), "ServerKeyGen key")
+    assertBool(!genKey.PublicKey.Equal(&gostKey.PublicKey), "ServerKeyGen new key")
+    assertEqual(cert.DNSNames, []string{"device.example.com"}, "ServerKeyGen")
+
+    attrs, err := client.CSRAttrs(ctx)
+    assertError(err, "CSRAttrs")
+    assertBool(attrs == nil, "CSRAttrs")
+}
+
+func Test_CSRAttrsAndPending(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    ca := newAuthority(t)
+
+    want := &CSRAttrs{
+        OIDs:       []asn1.ObjectIdentifier{
+            {1, 2, 156, 10197, 1, 501},
+        },
+        Attributes: []Attribute{
+            {
+                Type:   asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14},
+                Values: []asn1.RawValue{
+                    {FullBytes: []byte{0x06, 0x03, 0x55, 0x1d, 0x11}},
+                },
+            },
+        },
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle("/attrs" + WellKnownPrefix, http.StripPrefix("/attrs", &Server{
+        CA:        attrsCA{authority.NewService(ca), want},
+        Authorize: allowAll,
+    }))
+    mux.Handle("/pending" + WellKnownPrefix, http.StripPrefix("/pending", &Server{
+        CA:        pendingCA{authority.NewService(ca)},
+        Authorize: allowAll,
+    }))
+
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+
+    client := &Client{URL: srv.URL + "/attrs"}
+
+    attrs, err := client.CSRAttrs(ctx)
+    assertError(err, "CSRAttrs")
+    assertEqual(len(attrs.OIDs), 1, "CSRAttrs")
+    assertBool(attrs.OIDs[0].Equal(want.OIDs[0]), "CSRAttrs")
+    assertEqual(len(attrs.Attributes), 1, "CSRAttrs")
+    assertBool(attrs.Attributes[0].Type.Equal(want.Attributes[0].Type), "CSRAttrs")
+    assertEqual(attrs.Attributes[0].Values[0].FullBytes, want.Attributes[0].Values[0].FullBytes, "CSRAttrs")
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    client = &Client{URL: srv.URL + "/pending"}
+
+    _, err = client.SimpleEnroll(ctx, newCSR(t, key, "device", "device.example.com"))
+
+    var pending *PendingError
+    assertBool(errors.As(err, &pending), "SimpleEnroll pending")
+    assertEqual(pending.RetryAfter, time.Minute, "RetryAfter")
+
+    // methods and operations
+    resp, err := http.Get(srv.URL + "/attrs" + WellKnownPrefix + "simpleenroll")
+    assertError(err, "Get")
+    resp.Body.Close()
+    assertEqual(resp.StatusCode, http.StatusMethodNotAllowed, "Get simpleenroll")
+
+    resp, err = http.Get(srv.URL + "/attrs" + WellKnownPrefix + "unknown")
+    assertError(err, "Get")
+    resp.Body.Close()
+    assertEqual(resp.StatusCode, http.StatusNotFound, "Get unknown")
+}
+
+func Test_ReenrollTLS(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    ca, err := authority.NewRoot(pkix.Name{CommonName: "EST Root"}, caKey, 24 * time.Hour, authority.NewMemoryStore())
+    assertError(err, "NewRoot")
+
+    srv := httptest.NewUnstartedServer(&Server{
+        CA:        authority.NewService(ca),
+        Authorize: allowAll,
+    })
+    srv.TLS = &tls.Config{
+        ClientAuth: tls.RequestClientCert,
+    }
+    srv.StartTLS()
+    defer srv.Close()
+
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    client := &Client{
+        URL:        srv.URL,
+        HTTPClient: srv.Client(),
+    }
+
+    cert, err := client.SimpleEnroll(ctx, newCSR(t, key, "device.example.com", "device.example.com"))
+    assertError(err, "SimpleEnroll")
+
+    // no client certificate
+    _, err = client.SimpleReenroll(ctx, newCSR(t, key, "device.example.com", "device.example.com"))
+    assertBool(err != nil, "SimpleReenroll without certificate")
+
+    httpClient := srv.Client()
+    httpClient.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{
+        {
+            Certificate: [][]byte{cert.Raw},
+            PrivateKey:  key,
+        },
+    }
+    httpClient.CloseIdleConnections()
+    client.HTTPClient = httpClient
+
+    renewed, err := client.SimpleReenroll(ctx, newCSR(t, key, "device.example.com", "device.example.com"))
+    assertError(err, "SimpleReenroll")
+    assertEqual(renewed.RawSubjectPublicKeyInfo, cert.RawSubjectPublicKeyInfo, "SimpleReenroll")
+    assertBool(renewed.SerialNumber.Cmp(cert.SerialNumber) != 0, "SimpleReenroll")
+}
+
+func Test_CSRAttrsMarshal(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    der, err := (&CSRAttrs{}).Marshal()
+    assertError(err, "Marshal")
+    assertEqual(der, []byte{0x30, 0x00}, "Marshal")
+
+    attrs, err := ParseCSRAttrs(der)
+    assertError(err, "ParseCSRAttrs")
+    assertEqual(len(attrs.OIDs) + len(attrs.Attributes), 0, "ParseCSRAttrs")
+
+    _, err = ParseCSRAttrs([]byte{0x30, 0x02, 0x02, 0x00})
+    assertEqual(err != nil, true, "ParseCSRAttrs")
+}
+
+func Test_NoAuthorize(t *testing.T) {
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    srv := httptest.NewServer(&Server{
+        CA: authority.NewService(newAuthority(t)),
+    })
+    defer srv.Close()
+
+    client := &Client{URL: srv.URL}
+
+    _, err = client.SimpleEnroll(context.Background(), newCSR(t, key, "device.example.com", "device.example.com"))
+    assertBool(err != nil, "SimpleEnroll without Authorize")
+}
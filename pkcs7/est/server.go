@@ -0,0 +1,387 @@
+package est
+
+import (
+    "io"
+    "bytes"
+    "errors"
+    "strings"
+    "strconv"
+    "net/http"
+    "net/textproto"
+    "crypto/rand"
+    "mime/multipart"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// maxRequestSize limits the size of the request bodies.
+const maxRequestSize = 1 << 16
+
+// Server is an EST server, an http.Handler for the requests to
+// WellKnownPrefix, with or without a CA label.
+type Server struct {
+    // CA issues the certificates.
+    CA CA
+
+    // Authorize is called before an enrollment or a server key
+    // generation, for instance to check HTTP basic credentials. An
+    // error rejects the request with status 401. If nil, the requests
+    // are rejected with status 403.
+    Authorize func(r *http.Request, csr *x509.CertificateRequest) error
+
+    // ClientCertificate returns the certificate the client
+    // authenticated with, needed by simplereenroll. If nil, it is the
+    // first TLS peer certificate.
+    ClientCertificate func(r *http.Request) (*x509.Certificate, error)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    label, op, ok := splitPath(r.URL.Path)
+    if !ok {
+        http.NotFound(w, r)
+        return
+    }
+
+    switch op {
+        case OpCACerts, OpCSRAttrs:
+            if r.Method != http.MethodGet {
+                w.Header().Set("Allow", http.MethodGet)
+                w.WriteHeader(http.StatusMethodNotAllowed)
+                return
+            }
+        case OpSimpleEnroll, OpSimpleReenroll, OpServerKeyGen:
+            if r.Method != http.MethodPost {
+                w.Header().Set("Allow", http.MethodPost)
+                w.WriteHeader(http.StatusMethodNotAllowed)
+                return
+            }
+        default:
+            http.NotFound(w, r)
+            return
+    }
+
+    switch op {
+        case OpCACerts:
+            s.caCerts(w, r, label)
+        case OpCSRAttrs:
+            s.csrAttrs(w, r, label)
+        case OpSimpleEnroll:
+            s.enroll(w, r, label, false)
+        case OpSimpleReenroll:
+            s.enroll(w, r, label, true)
+        case OpServerKeyGen:
+            s.serverKeyGen(w, r, label)
+    }
+}
+
+func (s *Server) caCerts(w http.ResponseWriter, r *http.Request, label string) {
+    certs, err := s.CA.CACerts(r.Context(), label)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+
+    body, err := encodeCerts(certs)
+    if err != nil {
+        internalError(w)
+        return
+    }
+
+    writeBase64(w, mimeCerts, body)
+}
+
+func (s *Server) csrAttrs(w http.ResponseWriter, r *http.Request, label string) {
+    ca, ok := s.CA.(CSRAttrsCA)
+    if !ok {
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+
+    attrs, err := ca.CSRAttrs(r.Context(), label)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+
+    if attrs == nil || len(attrs.OIDs) == 0 && len(attrs.Attributes) == 0 {
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+
+    der, err := attrs.Marshal()
+    if err != nil {
+        internalError(w)
+        return
+    }
+
+    writeBase64(w, mimeCSRAttrs, encodeBase64(der))
+}
+
+func (s *Server) enroll(w http.ResponseWriter, r *http.Request, label string, renew bool) {
+    csr, ok := s.readCSR(w, r)
+    if !ok {
+        return
+    }
+
+    var cert *x509.Certificate
+    var err error
+
+    if renew {
+        current, err := s.clientCertificate(r)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusUnauthorized)
+            return
+        }
+
+        // RFC 7030 section 4.2.2
+        if !bytes.Equal(csr.RawSubject, current.RawSubject) || !sameNames(csr, current) {
+            http.Error(w, "est: request does not match the current certificate", http.StatusBadRequest)
+            return
+        }
+
+        cert, err = s.CA.Reenroll(r.Context(), current, csr, label)
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+    } else {
+        if !s.authorize(w, r, csr) {
+            return
+        }
+
+        cert, err = s.CA.Enroll(r.Context(), csr, label)
+        if err != nil {
+            writeError(w, err)
+            return
+        }
+    }
+
+    body, err := encodeCerts([]*x509.Certificate{cert})
+    if err != nil {
+        internalError(w)
+        return
+    }
+
+    writeBase64(w, mimeCerts, body)
+}
+
+func (s *Server) serverKeyGen(w http.ResponseWriter, r *http.Request, label string) {
+    csr, ok := s.readCSR(w, r)
+    if !ok || !s.authorize(w, r, csr) {
+        return
+    }
+
+    key, keyDer, err := generateKey(csr.PublicKey)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    // the request of the generated key, with the subject and names
+    // asked by the client
+    der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+        RawSubject:         csr.RawSubject,
+        DNSNames:           csr.DNSNames,
+        EmailAddresses:     csr.EmailAddresses,
+        IPAddresses:        csr.IPAddresses,
+        URIs:               csr.URIs,
+        SignatureAlgorithm: signatureAlgorithm(key),
+    }, key)
+    if err != nil {
+        internalError(w)
+        return
+    }
+
+    keyCSR, err := x509.ParseCertificateRequest(der)
+    if err != nil {
+        internalError(w)
+        return
+    }
+
+    cert, err := s.CA.Enroll(r.Context(), keyCSR, label)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+
+    certs, err := encodeCerts([]*x509.Certificate{cert})
+    if err != nil {
+        internalError(w)
+        return
+    }
+
+    var buf bytes.Buffer
+    mw := multipart.NewWriter(&buf)
+
+    for _, part := range []struct {
+        contentType string
+        body        []byte
+    }{
+        {mimePKCS8, encodeBase64(keyDer)},
+        {mimeCerts, certs},
+    } {
+        header := textproto.MIMEHeader{}
+        header.Set("Content-Type", part.contentType)
+        header.Set("Content-Transfer-Encoding", "base64")
+
+        pw, err := mw.CreatePart(header)
+        if err != nil {
+            internalError(w)
+            return
+        }
+
+        pw.Write(part.body)
+    }
+
+    if err = mw.Close(); err != nil {
+        internalError(w)
+        return
+    }
+
+    w.Header().Set("Content-Type", "multipart/mixed; boundary=" + mw.Boundary())
+    w.WriteHeader(http.StatusOK)
+    w.Write(buf.Bytes())
+}
+
+// readCSR reads the base64 encoded PKCS #10 request of the body.
+func (s *Server) readCSR(w http.ResponseWriter, r *http.Request) (*x509.CertificateRequest, bool) {
+    contentType := r.Header.Get("Content-Type")
+    if !strings.HasPrefix(contentType, mimePKCS10) {
+        w.WriteHeader(http.StatusUnsupportedMediaType)
+        return nil, false
+    }
+
+    body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        return nil, false
+    }
+
+    der, err := decodeBase64(body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return nil, false
+    }
+
+    csr, err := x509.ParseCertificateRequest(der)
+    if err != nil {
+        http.Error(w, "est: invalid certificate request", http.StatusBadRequest)
+        return nil, false
+    }
+
+    if err = csr.CheckSignature(); err != nil {
+        http.Error(w, "est: invalid certificate request signature", http.StatusBadRequest)
+        return nil, false
+    }
+
+    return csr, true
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, csr *x509.CertificateRequest) bool {
+    if s.Authorize == nil {
+        http.Error(w, "est: enrollment is not authorized", http.StatusForbidden)
+        return false
+    }
+
+    if err := s.Authorize(r, csr); err != nil {
+        w.Header().Set("WWW-Authenticate", `Basic realm="est"`)
+        http.Error(w, err.Error(), http.StatusUnauthorized)
+        return false
+    }
+
+    return true
+}
+
+func (s *Server) clientCertificate(r *http.Request) (*x509.Certificate, error) {
+    if s.ClientCertificate != nil {
+        return s.ClientCertificate(r)
+    }
+
+    if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+        return nil, errors.New("est: no client certificate")
+    }
+
+    return x509.ParseCertificate(r.TLS.PeerCertificates[0].Raw)
+}
+
+// splitPath returns the CA label and the operation of path.
+func splitPath(path string) (label, op string, ok bool) {
+    i := strings.Index(path, WellKnownPrefix)
+    if i < 0 {
+        return "", "", false
+    }
+
+    parts := strings.Split(path[i+len(WellKnownPrefix):], "/")
+    switch len(parts) {
+        case 1:
+            return "", parts[0], true
+        case 2:
+            return parts[0], parts[1], parts[0] != ""
+    }
+
+    return "", "", false
+}
+
+// sameNames reports whether csr has the subject alternative names of
+// cert.
+func sameNames(csr *x509.CertificateRequest, cert *x509.Certificate) bool {
+    if !equalStrings(csr.DNSNames, cert.DNSNames) ||
+        !equalStrings(csr.EmailAddresses, cert.EmailAddresses) ||
+        len(csr.IPAddresses) != len(cert.IPAddresses) ||
+        len(csr.URIs) != len(cert.URIs) {
+        return false
+    }
+
+    for i := range csr.IPAddresses {
+        if !csr.IPAddresses[i].Equal(cert.IPAddresses[i]) {
+            return false
+        }
+    }
+
+    for i := range csr.URIs {
+        if csr.URIs[i].String() != cert.URIs[i].String() {
+            return false
+        }
+    }
+
+    return true
+}
+
+func equalStrings(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+
+    return true
+}
+
+func writeBase64(w http.ResponseWriter, contentType string, body []byte) {
+    w.Header().Set("Content-Type", contentType)
+    w.Header().Set("Content-Transfer-Encoding", "base64")
+    w.WriteHeader(http.StatusOK)
+    w.Write(body)
+}
+
+func internalError(w http.ResponseWriter) {
+    http.Error(w, "est: internal error", http.StatusInternalServerError)
+}
+
+// writeError answers a CA error: 202 for pending enrollments, 400 for
+// the others.
+func writeError(w http.ResponseWriter, err error) {
+    var pending *PendingError
+    if errors.As(err, &pending) {
+        w.Header().Set("Retry-After", strconv.Itoa(int(pending.RetryAfter.Seconds())))
+        w.WriteHeader(http.StatusAccepted)
+        return
+    }
+
+    http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,255 @@
+package pkcs7
+
+import (
+    "io"
+    "fmt"
+    "time"
+    "bytes"
+    "errors"
+    "crypto"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/pubkey/x448"
+    "github.com/deatil/go-cryptobin/pubkey/x25519"
+)
+
+var (
+    // RFC 8410
+    oidPublicKeyX25519 = asn1.ObjectIdentifier{1, 3, 101, 110}
+    oidPublicKeyX448   = asn1.ObjectIdentifier{1, 3, 101, 111}
+)
+
+// 密钥协商数据
+type KeyAgreeData struct {
+    // 发送方公钥
+    OriginatorKeyAlgorithm pkix.AlgorithmIdentifier
+    OriginatorKey          []byte
+
+    // UserKeyingMaterial
+    UKM []byte
+
+    // 密钥加密算法参数
+    Parameters []byte
+
+    // 加密后的内容密钥
+    EncryptedKey []byte
+}
+
+// 密钥协商接口, 用于 KeyAgreeRecipientInfo
+type KeyAgree interface {
+    // oid
+    OID() asn1.ObjectIdentifier
+
+    // 使用临时密钥与接收方公钥协商密钥, 并加密内容密钥
+    Encrypt(rand io.Reader, key []byte, pkey crypto.PublicKey) (KeyAgreeData, error)
+
+    // 解密内容密钥
+    Decrypt(data KeyAgreeData, pkey crypto.PrivateKey) ([]byte, error)
+
+    // 检测证书
+    Check(pkey any) bool
+}
+
+var keyAgrees = make(map[string]func() KeyAgree)
+
+// 添加密钥协商方式
+func AddKeyAgree(oid asn1.ObjectIdentifier, fn func() KeyAgree) {
+    keyAgrees[oid.String()] = fn
+}
+
+// KeyAgreeRecipientInfo ::= SEQUENCE {
+//     version CMSVersion,  -- always set to 3
+//     originator [0] EXPLICIT OriginatorIdentifierOrKey,
+//     ukm [1] EXPLICIT UserKeyingMaterial OPTIONAL,
+//     keyEncryptionAlgorithm KeyEncryptionAlgorithmIdentifier,
+//     recipientEncryptedKeys RecipientEncryptedKeys }
+type keyAgreeRecipientInfo struct {
+    Version                int
+    Originator             asn1.RawValue `asn1:"explicit,tag:0"`
+    UKM                    []byte        `asn1:"explicit,optional,tag:1"`
+    KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+    RecipientEncryptedKeys []recipientEncryptedKey
+}
+
+// OriginatorPublicKey ::= SEQUENCE {
+//     algorithm AlgorithmIdentifier,
+//     publicKey BIT STRING }
+type originatorPublicKey struct {
+    Algorithm pkix.AlgorithmIdentifier
+    PublicKey asn1.BitString
+}
+
+// RecipientEncryptedKey ::= SEQUENCE {
+//     rid KeyAgreeRecipientIdentifier,
+//     encryptedKey EncryptedKey }
+type recipientEncryptedKey struct {
+    RID          asn1.RawValue
+    EncryptedKey []byte
+}
+
+// RecipientKeyIdentifier ::= SEQUENCE {
+//     subjectKeyIdentifier SubjectKeyIdentifier,
+//     date GeneralizedTime OPTIONAL,
+//     other OtherKeyAttribute OPTIONAL }
+type recipientKeyIdentifier struct {
+    SubjectKeyIdentifier []byte
+    Date                 time.Time     `asn1:"optional,generalized"`
+    Other                asn1.RawValue `asn1:"optional"`
+}
+
+// keyAgreeRecipientInfoFor returns the [1] IMPLICIT KeyAgreeRecipientInfo
+// of the content key for the recipient
+func keyAgreeRecipientInfoFor(rand io.Reader, recipient *x509.Certificate, pub crypto.PublicKey, keyAgree KeyAgree, key []byte) (asn1.RawValue, error) {
+    data, err := keyAgree.Encrypt(rand, key, pub)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    originator, err := asn1.MarshalWithParams(originatorPublicKey{
+        Algorithm: data.OriginatorKeyAlgorithm,
+        PublicKey: asn1.BitString{
+            Bytes:     data.OriginatorKey,
+            BitLength: 8 * len(data.OriginatorKey),
+        },
+    }, "tag:1")
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    ias, err := cert2issuerAndSerial(recipient)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    rid, err := asn1.Marshal(ias)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    info := keyAgreeRecipientInfo{
+        Version:    3,
+        Originator: asn1.RawValue{
+            Class:      asn1.ClassContextSpecific,
+            Tag:        0,
+            IsCompound: true,
+            Bytes:      originator,
+        },
+        UKM: data.UKM,
+        KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+            Algorithm: keyAgree.OID(),
+            Parameters: asn1.RawValue{
+                FullBytes: data.Parameters,
+            },
+        },
+        RecipientEncryptedKeys: []recipientEncryptedKey{
+            {
+                RID:          asn1.RawValue{FullBytes: rid},
+                EncryptedKey: data.EncryptedKey,
+            },
+        },
+    }
+
+    encoded, err := asn1.MarshalWithParams(info, "tag:1")
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    return asn1.RawValue{FullBytes: encoded}, nil
+}
+
+// decryptKeyAgreeRecipient decrypts the content key of the recipient of
+// cert in the [1] IMPLICIT KeyAgreeRecipientInfo. It returns nil if cert
+// is not a recipient.
+func decryptKeyAgreeRecipient(raw asn1.RawValue, cert *x509.Certificate, pkey crypto.PrivateKey) ([]byte, error) {
+    var info keyAgreeRecipientInfo
+    if _, err := asn1.UnmarshalWithParams(raw.FullBytes, &info, "tag:1"); err != nil {
+        return nil, err
+    }
+
+    var encryptedKey []byte
+    for _, rek := range info.RecipientEncryptedKeys {
+        if isCertMatchForRecipientIdentifier(cert, rek.RID) {
+            encryptedKey = rek.EncryptedKey
+            break
+        }
+    }
+
+    if encryptedKey == nil {
+        return nil, nil
+    }
+
+    var originator originatorPublicKey
+    if _, err := asn1.UnmarshalWithParams(info.Originator.Bytes, &originator, "tag:1"); err != nil {
+        return nil, errors.New("pkcs7: unsupported originator, only originatorKey supported")
+    }
+
+    oid := info.KeyEncryptionAlgorithm.Algorithm.String()
+
+    fn, ok := keyAgrees[oid]
+    if !ok {
+        return nil, fmt.Errorf("pkcs7: unsupported key agreement (OID: %s)", oid)
+    }
+
+    key, err := fn().Decrypt(KeyAgreeData{
+        OriginatorKeyAlgorithm: originator.Algorithm,
+        OriginatorKey:          originator.PublicKey.RightAlign(),
+        UKM:                    info.UKM,
+        Parameters:             info.KeyEncryptionAlgorithm.Parameters.FullBytes,
+        EncryptedKey:           encryptedKey,
+    }, pkey)
+    if err != nil {
+        return nil, err
+    }
+
+    if key == nil {
+        return nil, errors.New("pkcs7: failed to decrypt content key")
+    }
+
+    return key, nil
+}
+
+// isCertMatchForRecipientIdentifier checks the issuerAndSerialNumber or
+// the [0] IMPLICIT RecipientKeyIdentifier rid with cert
+func isCertMatchForRecipientIdentifier(cert *x509.Certificate, rid asn1.RawValue) bool {
+    if rid.Class == asn1.ClassContextSpecific && rid.Tag == 0 {
+        var rkid recipientKeyIdentifier
+        if _, err := asn1.UnmarshalWithParams(rid.FullBytes, &rkid, "tag:0"); err != nil {
+            return false
+        }
+
+        return len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, rkid.SubjectKeyIdentifier)
+    }
+
+    var ias issuerAndSerial
+    if _, err := asn1.Unmarshal(rid.FullBytes, &ias); err != nil {
+        return false
+    }
+
+    return isCertMatchForIssuerAndSerial(cert, ias)
+}
+
+// recipientPublicKey returns the public key of the recipient, with the
+// X25519 and X448 keys x509 does not parse
+func recipientPublicKey(cert *x509.Certificate) (crypto.PublicKey, error) {
+    if cert.PublicKey != nil {
+        return cert.PublicKey, nil
+    }
+
+    var spki originatorPublicKey
+    if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+        return nil, err
+    }
+
+    keyBytes := spki.PublicKey.RightAlign()
+
+    switch {
+        case spki.Algorithm.Algorithm.Equal(oidPublicKeyX25519) && len(keyBytes) == x25519.PublicKeySize:
+            return x25519.PublicKey(keyBytes), nil
+        case spki.Algorithm.Algorithm.Equal(oidPublicKeyX448) && len(keyBytes) == x448.PublicKeySize:
+            return x448.PublicKey(keyBytes), nil
+    }
+
+    return nil, errors.New("pkcs7: unsupported recipient public key")
+}
@@ -0,0 +1,471 @@
+package pkcs7
+
+import (
+    "io"
+    "hash"
+    "errors"
+    "math/big"
+    "crypto"
+    "crypto/aes"
+    "crypto/ecdsa"
+    "crypto/sha1"
+    "crypto/sha256"
+    "crypto/sha512"
+    "crypto/cipher"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+    "encoding/binary"
+
+    "golang.org/x/crypto/hkdf"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/cipher/sm4"
+    "github.com/deatil/go-cryptobin/kdf/smkdf"
+    "github.com/deatil/go-cryptobin/pubkey/x448"
+    "github.com/deatil/go-cryptobin/pubkey/x25519"
+    cryptobin_mode "github.com/deatil/go-cryptobin/mode"
+)
+
+var (
+    // RFC 5753 and RFC 8418 key agreement schemes
+    oidDHSinglePassStdDHSHA1KDF   = asn1.ObjectIdentifier{1, 3, 133, 16, 840, 63, 0, 2}
+    oidDHSinglePassStdDHSHA224KDF = asn1.ObjectIdentifier{1, 3, 132, 1, 11, 0}
+    oidDHSinglePassStdDHSHA256KDF = asn1.ObjectIdentifier{1, 3, 132, 1, 11, 1}
+    oidDHSinglePassStdDHSHA384KDF = asn1.ObjectIdentifier{1, 3, 132, 1, 11, 2}
+    oidDHSinglePassStdDHSHA512KDF = asn1.ObjectIdentifier{1, 3, 132, 1, 11, 3}
+
+    oidDHSinglePassStdDHHKDFSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 19}
+    oidDHSinglePassStdDHHKDFSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 20}
+    oidDHSinglePassStdDHHKDFSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 21}
+
+    // RFC 3565 AES key wrap
+    oidAES128Wrap = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 5}
+    oidAES192Wrap = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 25}
+    oidAES256Wrap = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 45}
+
+    // GmSSL SMS4-WRAP
+    oidSM4Wrap = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 11}
+
+    oidPublicKeyEC = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// 密钥包装, RFC 3394, 支持 AES 及 SM4
+type KeyWrap struct {
+    cipherFunc func(key []byte) (cipher.Block, error)
+    identifier asn1.ObjectIdentifier
+    keySize    int
+}
+
+// oid
+func (this KeyWrap) OID() asn1.ObjectIdentifier {
+    return this.identifier
+}
+
+// 密钥大小
+func (this KeyWrap) KeySize() int {
+    return this.keySize
+}
+
+// 包装密钥
+func (this KeyWrap) Wrap(kek, key []byte) ([]byte, error) {
+    if len(kek) != this.keySize {
+        return nil, errors.New("pkcs7: invalid key wrap key size")
+    }
+
+    if len(key) < 16 || len(key) % 8 != 0 {
+        return nil, errors.New("pkcs7: invalid wrapped key size")
+    }
+
+    block, err := this.cipherFunc(kek)
+    if err != nil {
+        return nil, err
+    }
+
+    dst := make([]byte, len(key) + 8)
+    cryptobin_mode.NewWrapEncrypter(block, nil).CryptBlocks(dst, key)
+
+    return dst, nil
+}
+
+// 解包装密钥
+func (this KeyWrap) Unwrap(kek, wrapped []byte) ([]byte, error) {
+    if len(kek) != this.keySize {
+        return nil, errors.New("pkcs7: invalid key wrap key size")
+    }
+
+    if len(wrapped) < 24 || len(wrapped) % 8 != 0 {
+        return nil, errors.New("pkcs7: invalid wrapped key size")
+    }
+
+    block, err := this.cipherFunc(kek)
+    if err != nil {
+        return nil, err
+    }
+
+    dst := make([]byte, len(wrapped) - 8)
+    cryptobin_mode.NewWrapDecrypter(block, nil).CryptBlocks(dst, wrapped)
+
+    // the unwrapped key is zero when the integrity check fails
+    if isZeroBytes(dst) {
+        return nil, errors.New("pkcs7: key unwrap failed")
+    }
+
+    return dst, nil
+}
+
+// 密钥包装方式
+var (
+    KeyWrapAES128 = KeyWrap{aes.NewCipher, oidAES128Wrap, 16}
+    KeyWrapAES192 = KeyWrap{aes.NewCipher, oidAES192Wrap, 24}
+    KeyWrapAES256 = KeyWrap{aes.NewCipher, oidAES256Wrap, 32}
+    KeyWrapSM4    = KeyWrap{sm4.NewCipher, oidSM4Wrap, 16}
+)
+
+var keyWraps = map[string]KeyWrap{
+    oidAES128Wrap.String(): KeyWrapAES128,
+    oidAES192Wrap.String(): KeyWrapAES192,
+    oidAES256Wrap.String(): KeyWrapAES256,
+    oidSM4Wrap.String():    KeyWrapSM4,
+}
+
+// ECC-CMS-SharedInfo ::= SEQUENCE {
+//     keyInfo AlgorithmIdentifier,
+//     entityUInfo [0] EXPLICIT OCTET STRING OPTIONAL,
+//     suppPubInfo [2] EXPLICIT OCTET STRING }
+type eccCMSSharedInfo struct {
+    KeyInfo     pkix.AlgorithmIdentifier
+    EntityUInfo []byte `asn1:"explicit,optional,tag:0"`
+    SuppPubInfo []byte `asn1:"explicit,tag:2"`
+}
+
+// 临时-静态 ECDH 密钥协商, RFC 5753 及 RFC 8418
+// 支持 ECDSA, SM2, X25519 及 X448 接收方
+type KeyAgreeECDH struct {
+    identifier asn1.ObjectIdentifier
+    hashFunc   func() hash.Hash
+    hkdf       bool
+    keyWrap    KeyWrap
+}
+
+// oid
+func (this KeyAgreeECDH) OID() asn1.ObjectIdentifier {
+    return this.identifier
+}
+
+// 设置密钥包装方式
+func (this KeyAgreeECDH) WithKeyWrap(keyWrap KeyWrap) KeyAgreeECDH {
+    this.keyWrap = keyWrap
+
+    return this
+}
+
+// 加密
+func (this KeyAgreeECDH) Encrypt(rand io.Reader, key []byte, pkey crypto.PublicKey) (KeyAgreeData, error) {
+    z, originatorAlgo, originatorKey, err := ecdhEphemeral(rand, pkey)
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    wrapAlgo := pkix.AlgorithmIdentifier{
+        Algorithm: this.keyWrap.OID(),
+    }
+
+    kek, err := this.deriveKey(z, wrapAlgo, nil, this.keyWrap.KeySize())
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    encryptedKey, err := this.keyWrap.Wrap(kek, key)
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    params, err := asn1.Marshal(wrapAlgo)
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    return KeyAgreeData{
+        OriginatorKeyAlgorithm: originatorAlgo,
+        OriginatorKey:          originatorKey,
+        Parameters:             params,
+        EncryptedKey:           encryptedKey,
+    }, nil
+}
+
+// 解密
+func (this KeyAgreeECDH) Decrypt(data KeyAgreeData, pkey crypto.PrivateKey) ([]byte, error) {
+    var wrapAlgo pkix.AlgorithmIdentifier
+    if _, err := asn1.Unmarshal(data.Parameters, &wrapAlgo); err != nil {
+        return nil, errors.New("pkcs7: invalid key wrap algorithm")
+    }
+
+    keyWrap, ok := keyWraps[wrapAlgo.Algorithm.String()]
+    if !ok {
+        return nil, errors.New("pkcs7: unsupported key wrap algorithm (OID: " + wrapAlgo.Algorithm.String() + ")")
+    }
+
+    z, err := ecdhStatic(pkey, data.OriginatorKeyAlgorithm, data.OriginatorKey)
+    if err != nil {
+        return nil, err
+    }
+
+    kek, err := this.deriveKey(z, wrapAlgo, data.UKM, keyWrap.KeySize())
+    if err != nil {
+        return nil, err
+    }
+
+    return keyWrap.Unwrap(kek, data.EncryptedKey)
+}
+
+// 检测证书
+func (this KeyAgreeECDH) Check(pkey any) bool {
+    switch pkey.(type) {
+        case *ecdsa.PublicKey, *ecdsa.PrivateKey,
+            *sm2.PublicKey, *sm2.PrivateKey,
+            x25519.PublicKey, x25519.PrivateKey,
+            x448.PublicKey, x448.PrivateKey:
+            return true
+    }
+
+    return false
+}
+
+// deriveKey derives the key encryption key from the shared secret z
+// with the ECC-CMS-SharedInfo as the shared info
+func (this KeyAgreeECDH) deriveKey(z []byte, wrapAlgo pkix.AlgorithmIdentifier, ukm []byte, size int) ([]byte, error) {
+    suppPubInfo := make([]byte, 4)
+    binary.BigEndian.PutUint32(suppPubInfo, uint32(size * 8))
+
+    sharedInfo, err := asn1.Marshal(eccCMSSharedInfo{
+        KeyInfo:     wrapAlgo,
+        EntityUInfo: ukm,
+        SuppPubInfo: suppPubInfo,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if this.hkdf {
+        kek := make([]byte, size)
+        if _, err := io.ReadFull(hkdf.New(this.hashFunc, z, nil, sharedInfo), kek); err != nil {
+            return nil, err
+        }
+
+        return kek, nil
+    }
+
+    // ANSI X9.63 KDF
+    input := make([]byte, 0, len(z) + len(sharedInfo))
+    input = append(input, z...)
+    input = append(input, sharedInfo...)
+
+    return smkdf.Key(this.hashFunc, input, size), nil
+}
+
+// ecdhEphemeral generates an ephemeral key on the curve of the recipient
+// key and returns the shared secret with the ephemeral public key
+func ecdhEphemeral(rand io.Reader, pkey crypto.PublicKey) ([]byte, pkix.AlgorithmIdentifier, []byte, error) {
+    ecAlgo := pkix.AlgorithmIdentifier{
+        Algorithm: oidPublicKeyEC,
+    }
+
+    switch pub := pkey.(type) {
+        case *ecdsa.PublicKey:
+            priv, err := ecdsa.GenerateKey(pub.Curve, rand)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            z, err := ecSharedSecret(pub.Curve, priv.D, pub.X, pub.Y)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            return z, ecAlgo, elliptic.Marshal(pub.Curve, priv.X, priv.Y), nil
+        case *sm2.PublicKey:
+            priv, err := sm2.GenerateKey(rand)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            z, err := ecSharedSecret(pub.Curve, priv.D, pub.X, pub.Y)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            return z, ecAlgo, elliptic.Marshal(pub.Curve, priv.X, priv.Y), nil
+        case x25519.PublicKey:
+            ephPub, priv, err := x25519.GenerateKey(rand)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            z, err := x25519.X25519(priv.Seed(), pub)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            return z, pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyX25519}, ephPub, nil
+        case x448.PublicKey:
+            ephPub, priv, err := x448.GenerateKey(rand)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            z, err := x448.X448(priv.Seed(), pub)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            return z, pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyX448}, ephPub, nil
+    }
+
+    return nil, pkix.AlgorithmIdentifier{}, nil, errors.New("pkcs7: unsupported key agreement public key")
+}
+
+// ecdhStatic returns the shared secret of the recipient private key with
+// the originator public key
+func ecdhStatic(pkey crypto.PrivateKey, algo pkix.AlgorithmIdentifier, originator []byte) ([]byte, error) {
+    switch priv := pkey.(type) {
+        case *ecdsa.PrivateKey:
+            if !algo.Algorithm.Equal(oidPublicKeyEC) {
+                return nil, errors.New("pkcs7: originator key is not an EC key")
+            }
+
+            x, y := elliptic.Unmarshal(priv.Curve, originator)
+            if x == nil {
+                return nil, errors.New("pkcs7: invalid originator key")
+            }
+
+            return ecSharedSecret(priv.Curve, priv.D, x, y)
+        case *sm2.PrivateKey:
+            if !algo.Algorithm.Equal(oidPublicKeyEC) {
+                return nil, errors.New("pkcs7: originator key is not an EC key")
+            }
+
+            x, y := elliptic.Unmarshal(priv.Curve, originator)
+            if x == nil {
+                return nil, errors.New("pkcs7: invalid originator key")
+            }
+
+            return ecSharedSecret(priv.Curve, priv.D, x, y)
+        case x25519.PrivateKey:
+            if !algo.Algorithm.Equal(oidPublicKeyX25519) || len(originator) != x25519.PublicKeySize {
+                return nil, errors.New("pkcs7: invalid originator key")
+            }
+
+            return x25519.X25519(priv.Seed(), originator)
+        case x448.PrivateKey:
+            if !algo.Algorithm.Equal(oidPublicKeyX448) || len(originator) != x448.PublicKeySize {
+                return nil, errors.New("pkcs7: invalid originator key")
+            }
+
+            return x448.X448(priv.Seed(), originator)
+    }
+
+    return nil, errors.New("pkcs7: unsupported key agreement private key")
+}
+
+// ecSharedSecret returns the x-coordinate of d * (x, y)
+func ecSharedSecret(curve elliptic.Curve, d, x, y *big.Int) ([]byte, error) {
+    if !curve.IsOnCurve(x, y) {
+        return nil, errors.New("pkcs7: point is not on curve")
+    }
+
+    zx, _ := curve.ScalarMult(x, y, d.Bytes())
+    if zx.Sign() == 0 {
+        return nil, errors.New("pkcs7: invalid shared secret")
+    }
+
+    z := make([]byte, (curve.Params().BitSize + 7) / 8)
+    zx.FillBytes(z)
+
+    return z, nil
+}
+
+func isZeroBytes(b []byte) bool {
+    var v byte
+    for _, c := range b {
+        v |= c
+    }
+
+    return v == 0
+}
+
+// 密钥协商方式
+var (
+    KeyAgreeECDHSHA1 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHSHA1KDF,
+        hashFunc:   sha1.New,
+        keyWrap:    KeyWrapAES128,
+    }
+    KeyAgreeECDHSHA224 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHSHA224KDF,
+        hashFunc:   sha256.New224,
+        keyWrap:    KeyWrapAES128,
+    }
+    KeyAgreeECDHSHA256 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHSHA256KDF,
+        hashFunc:   sha256.New,
+        keyWrap:    KeyWrapAES128,
+    }
+    KeyAgreeECDHSHA384 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHSHA384KDF,
+        hashFunc:   sha512.New384,
+        keyWrap:    KeyWrapAES256,
+    }
+    KeyAgreeECDHSHA512 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHSHA512KDF,
+        hashFunc:   sha512.New,
+        keyWrap:    KeyWrapAES256,
+    }
+
+    KeyAgreeECDHHKDFSHA256 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHHKDFSHA256,
+        hashFunc:   sha256.New,
+        hkdf:       true,
+        keyWrap:    KeyWrapAES128,
+    }
+    KeyAgreeECDHHKDFSHA384 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHHKDFSHA384,
+        hashFunc:   sha512.New384,
+        hkdf:       true,
+        keyWrap:    KeyWrapAES256,
+    }
+    KeyAgreeECDHHKDFSHA512 = KeyAgreeECDH{
+        identifier: oidDHSinglePassStdDHHKDFSHA512,
+        hashFunc:   sha512.New,
+        hkdf:       true,
+        keyWrap:    KeyWrapAES256,
+    }
+)
+
+func init() {
+    AddKeyAgree(oidDHSinglePassStdDHSHA1KDF, func() KeyAgree {
+        return KeyAgreeECDHSHA1
+    })
+    AddKeyAgree(oidDHSinglePassStdDHSHA224KDF, func() KeyAgree {
+        return KeyAgreeECDHSHA224
+    })
+    AddKeyAgree(oidDHSinglePassStdDHSHA256KDF, func() KeyAgree {
+        return KeyAgreeECDHSHA256
+    })
+    AddKeyAgree(oidDHSinglePassStdDHSHA384KDF, func() KeyAgree {
+        return KeyAgreeECDHSHA384
+    })
+    AddKeyAgree(oidDHSinglePassStdDHSHA512KDF, func() KeyAgree {
+        return KeyAgreeECDHSHA512
+    })
+
+    AddKeyAgree(oidDHSinglePassStdDHHKDFSHA256, func() KeyAgree {
+        return KeyAgreeECDHHKDFSHA256
+    })
+    AddKeyAgree(oidDHSinglePassStdDHHKDFSHA384, func() KeyAgree {
+        return KeyAgreeECDHHKDFSHA384
+    })
+    AddKeyAgree(oidDHSinglePassStdDHHKDFSHA512, func() KeyAgree {
+        return KeyAgreeECDHHKDFSHA512
+    })
+}
@@ -0,0 +1,255 @@
+package pkcs7
+
+import (
+    "io"
+    "bytes"
+    "errors"
+    "crypto"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+    cipher_gost "github.com/deatil/go-cryptobin/cipher/gost"
+)
+
+var (
+    // RFC 4357 and RFC 4490
+    oidGostR34102001CryptoProESDH = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 98}
+    oidGost2814789NoneKeyWrap     = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 13, 0}
+    oidGost2814789CryptoProA      = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 31, 1}
+)
+
+// Gost28147-89-KeyWrapParameters ::= SEQUENCE {
+//     encryptionParamSet Gost28147-89-ParamSet,
+//     ukm OCTET STRING (SIZE (8)) OPTIONAL }
+type gostKeyWrapParameters struct {
+    EncryptionParamSet asn1.ObjectIdentifier
+    UKM                []byte `asn1:"optional"`
+}
+
+// Gost28147-89-EncryptedKey ::= SEQUENCE {
+//     encryptedKey Gost28147-89-Key,
+//     maskKey [0] IMPLICIT Gost28147-89-Key OPTIONAL,
+//     macKey Gost28147-89-MAC }
+type gostEncryptedKey struct {
+    EncryptedKey []byte
+    MaskKey      []byte `asn1:"optional,tag:0"`
+    MacKey       []byte
+}
+
+// GOST R 34.10-2001 VKO 密钥协商, RFC 4490
+// 使用 GOST 28147-89 None KeyWrap 包装内容密钥
+type KeyAgreeGost struct {
+    identifier asn1.ObjectIdentifier
+}
+
+// oid
+func (this KeyAgreeGost) OID() asn1.ObjectIdentifier {
+    return this.identifier
+}
+
+// 加密
+func (this KeyAgreeGost) Encrypt(rand io.Reader, key []byte, pkey crypto.PublicKey) (KeyAgreeData, error) {
+    pub, ok := pkey.(*gost.PublicKey)
+    if !ok {
+        return KeyAgreeData{}, errors.New("pkcs7: PublicKey is not a GOST key")
+    }
+
+    if len(key) != 32 {
+        return KeyAgreeData{}, errors.New("pkcs7: GOST key wrap needs a 32 byte content key")
+    }
+
+    priv, err := gost.GenerateKey(rand, pub.Curve)
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    ukm := make([]byte, 8)
+    if _, err := io.ReadFull(rand, ukm); err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    kek, err := gost.KEK2001(priv, pub, gost.NewUKM(ukm))
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    encryptedKey, err := gostKeyWrap(kek, ukm, key)
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    ephPub, err := gost.MarshalPublicKey(&priv.PublicKey)
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    var spki originatorPublicKey
+    if _, err := asn1.Unmarshal(ephPub, &spki); err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    wrapParams, err := asn1.Marshal(gostKeyWrapParameters{
+        EncryptionParamSet: oidGost2814789CryptoProA,
+    })
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    params, err := asn1.Marshal(pkix.AlgorithmIdentifier{
+        Algorithm: oidGost2814789NoneKeyWrap,
+        Parameters: asn1.RawValue{
+            FullBytes: wrapParams,
+        },
+    })
+    if err != nil {
+        return KeyAgreeData{}, err
+    }
+
+    return KeyAgreeData{
+        OriginatorKeyAlgorithm: spki.Algorithm,
+        OriginatorKey:          spki.PublicKey.RightAlign(),
+        UKM:                    ukm,
+        Parameters:             params,
+        EncryptedKey:           encryptedKey,
+    }, nil
+}
+
+// 解密
+func (this KeyAgreeGost) Decrypt(data KeyAgreeData, pkey crypto.PrivateKey) ([]byte, error) {
+    priv, ok := pkey.(*gost.PrivateKey)
+    if !ok {
+        return nil, errors.New("pkcs7: PrivateKey is not a GOST key")
+    }
+
+    var wrapAlgo pkix.AlgorithmIdentifier
+    if _, err := asn1.Unmarshal(data.Parameters, &wrapAlgo); err != nil {
+        return nil, errors.New("pkcs7: invalid key wrap algorithm")
+    }
+
+    if !wrapAlgo.Algorithm.Equal(oidGost2814789NoneKeyWrap) {
+        return nil, errors.New("pkcs7: unsupported key wrap algorithm (OID: " + wrapAlgo.Algorithm.String() + ")")
+    }
+
+    var params gostKeyWrapParameters
+    if _, err := asn1.Unmarshal(wrapAlgo.Parameters.FullBytes, &params); err != nil {
+        return nil, errors.New("pkcs7: invalid GOST key wrap parameters")
+    }
+
+    if !params.EncryptionParamSet.Equal(oidGost2814789CryptoProA) {
+        return nil, errors.New("pkcs7: unsupported GOST 28147-89 parameter set")
+    }
+
+    ukm := data.UKM
+    if ukm == nil {
+        ukm = params.UKM
+    }
+
+    if len(ukm) != 8 {
+        return nil, errors.New("pkcs7: invalid GOST ukm")
+    }
+
+    spki, err := asn1.Marshal(originatorPublicKey{
+        Algorithm: data.OriginatorKeyAlgorithm,
+        PublicKey: asn1.BitString{
+            Bytes:     data.OriginatorKey,
+            BitLength: 8 * len(data.OriginatorKey),
+        },
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    pub, err := gost.ParsePublicKey(spki)
+    if err != nil {
+        return nil, err
+    }
+
+    kek, err := gost.KEK2001(priv, pub, gost.NewUKM(ukm))
+    if err != nil {
+        return nil, err
+    }
+
+    return gostKeyUnwrap(kek, ukm, data.EncryptedKey)
+}
+
+// 检测证书
+func (this KeyAgreeGost) Check(pkey any) bool {
+    switch pkey.(type) {
+        case *gost.PublicKey, *gost.PrivateKey:
+            return true
+    }
+
+    return false
+}
+
+// gostKeyWrap wraps the key with the GOST 28147-89 None KeyWrap, RFC 4357
+func gostKeyWrap(kek, ukm, key []byte) ([]byte, error) {
+    block, err := cipher_gost.NewCipher(kek, cipher_gost.SboxIdGost2814789CryptoProAParamSet)
+    if err != nil {
+        return nil, err
+    }
+
+    mac, err := cipher_gost.NewMAC(kek, cipher_gost.SboxIdGost2814789CryptoProAParamSet, 4, ukm)
+    if err != nil {
+        return nil, err
+    }
+
+    mac.Write(key)
+
+    encrypted := make([]byte, len(key))
+    for i := 0; i < len(key); i += block.BlockSize() {
+        block.Encrypt(encrypted[i:], key[i:])
+    }
+
+    return asn1.Marshal(gostEncryptedKey{
+        EncryptedKey: encrypted,
+        MacKey:       mac.Sum(nil),
+    })
+}
+
+// gostKeyUnwrap unwraps the key with the GOST 28147-89 None KeyWrap
+func gostKeyUnwrap(kek, ukm, wrapped []byte) ([]byte, error) {
+    var ek gostEncryptedKey
+    if _, err := asn1.Unmarshal(wrapped, &ek); err != nil {
+        return nil, errors.New("pkcs7: invalid GOST encrypted key")
+    }
+
+    if len(ek.EncryptedKey) != 32 || len(ek.MacKey) != 4 {
+        return nil, errors.New("pkcs7: invalid GOST encrypted key")
+    }
+
+    block, err := cipher_gost.NewCipher(kek, cipher_gost.SboxIdGost2814789CryptoProAParamSet)
+    if err != nil {
+        return nil, err
+    }
+
+    key := make([]byte, len(ek.EncryptedKey))
+    for i := 0; i < len(key); i += block.BlockSize() {
+        block.Decrypt(key[i:], ek.EncryptedKey[i:])
+    }
+
+    mac, err := cipher_gost.NewMAC(kek, cipher_gost.SboxIdGost2814789CryptoProAParamSet, 4, ukm)
+    if err != nil {
+        return nil, err
+    }
+
+    mac.Write(key)
+
+    if !bytes.Equal(mac.Sum(nil), ek.MacKey) {
+        return nil, errors.New("pkcs7: GOST key unwrap failed")
+    }
+
+    return key, nil
+}
+
+// 密钥协商方式
+var KeyAgreeGostR34102001 = KeyAgreeGost{
+    identifier: oidGostR34102001CryptoProESDH,
+}
+
+func init() {
+    AddKeyAgree(oidGostR34102001CryptoProESDH, func() KeyAgree {
+        return KeyAgreeGostR34102001
+    })
+}
@@ -0,0 +1,393 @@
+package pkcs7
+
+import (
+    "testing"
+    "math/big"
+    "crypto"
+    "crypto/rand"
+    "crypto/x509/pkix"
+    "encoding/hex"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+    "github.com/deatil/go-cryptobin/pubkey/x448"
+    "github.com/deatil/go-cryptobin/pubkey/x25519"
+    cryptobin_x509 "github.com/deatil/go-cryptobin/x509"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+// createKeyAgreeTestCertificate returns a certificate with only the
+// fields the recipient infos use, for the keys x509 can not issue
+func createKeyAgreeTestCertificate(algo asn1.ObjectIdentifier, pub []byte) *cryptobin_x509.Certificate {
+    spki, _ := asn1.Marshal(originatorPublicKey{
+        Algorithm: pkix.AlgorithmIdentifier{
+            Algorithm: algo,
+        },
+        PublicKey: asn1.BitString{
+            Bytes:     pub,
+            BitLength: 8 * len(pub),
+        },
+    })
+
+    issuer, _ := asn1.Marshal(pkix.Name{CommonName: "Key Agree"}.ToRDNSequence())
+
+    return &cryptobin_x509.Certificate{
+        RawSubjectPublicKeyInfo: spki,
+        RawIssuer:               issuer,
+        SerialNumber:            big.NewInt(1),
+    }
+}
+
+func getEnvelopedVersion(t *testing.T, data []byte) int {
+    var info contentInfo
+    if _, err := asn1.Unmarshal(data, &info); err != nil {
+        t.Fatal(err)
+    }
+
+    var envelope envelopedData
+    if _, err := asn1.Unmarshal(info.Content.Bytes, &envelope); err != nil {
+        t.Fatal(err)
+    }
+
+    return envelope.Version
+}
+
+func Test_EncryptWithKeyAgree(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    content := []byte("test data test data test data test data")
+
+    certs := map[string]cryptobin_x509.SignatureAlgorithm{
+        "P256": cryptobin_x509.ECDSAWithSHA256,
+        "P384": cryptobin_x509.ECDSAWithSHA384,
+        "P521": cryptobin_x509.ECDSAWithSHA512,
+    }
+
+    keyAgrees := map[string]KeyAgree{
+        "SHA1":       KeyAgreeECDHSHA1,
+        "SHA224":     KeyAgreeECDHSHA224,
+        "SHA256":     KeyAgreeECDHSHA256,
+        "SHA384":     KeyAgreeECDHSHA384,
+        "SHA512":     KeyAgreeECDHSHA512,
+        "HKDFSHA256": KeyAgreeECDHHKDFSHA256,
+        "HKDFSHA384": KeyAgreeECDHHKDFSHA384,
+        "HKDFSHA512": KeyAgreeECDHHKDFSHA512,
+        "AES192Wrap": KeyAgreeECDHSHA256.WithKeyWrap(KeyWrapAES192),
+        "SM4Wrap":    KeyAgreeECDHSHA256.WithKeyWrap(KeyWrapSM4),
+    }
+
+    for certName, sigAlg := range certs {
+        cert, err := createTestCertificate(sigAlg)
+        assertError(err, "createTestCertificate")
+
+        for name, keyAgree := range keyAgrees {
+            t.Run(certName + "-" + name, func(t *testing.T) {
+                enData, err := Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{cert.Certificate}, Opts{
+                    Cipher:   AES256CBC,
+                    KeyAgree: keyAgree,
+                    Mode:     DefaultMode,
+                })
+                assertError(err, "Encrypt")
+                assertEqual(getEnvelopedVersion(t, enData), 2, "Version")
+
+                deData, err := Decrypt(enData, cert.Certificate, *cert.PrivateKey)
+                assertError(err, "Decrypt")
+                assertEqual(deData, content, "Decrypt")
+            })
+        }
+    }
+}
+
+func Test_EncryptWithKeyAgreeMixed(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    rsaCert, err := createTestCertificate(cryptobin_x509.SHA256WithRSA)
+    assertError(err, "createTestCertificate")
+
+    ecCert, err := createTestCertificate(cryptobin_x509.ECDSAWithSHA256)
+    assertError(err, "createTestCertificate")
+
+    otherCert, err := createTestCertificate(cryptobin_x509.ECDSAWithSHA256)
+    assertError(err, "createTestCertificate")
+
+    content := []byte("test data test data test data test data")
+
+    opts := DefaultOpts
+    opts.KeyAgree = KeyAgreeECDHSHA256
+
+    enData, err := Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{
+        rsaCert.Certificate,
+        ecCert.Certificate,
+    }, opts)
+    assertError(err, "Encrypt")
+    assertEqual(getEnvelopedVersion(t, enData), 2, "Version")
+
+    for _, cert := range []certKeyPair{rsaCert, ecCert} {
+        deData, err := Decrypt(enData, cert.Certificate, *cert.PrivateKey)
+        assertError(err, "Decrypt")
+        assertEqual(deData, content, "Decrypt")
+    }
+
+    _, err = Decrypt(enData, otherCert.Certificate, *otherCert.PrivateKey)
+    if err == nil {
+        t.Error("Decrypt should fail with a certificate not in the recipients")
+    }
+
+    // the recipient key is the key of the certificate
+    _, err = Decrypt(enData, ecCert.Certificate, *otherCert.PrivateKey)
+    if err == nil {
+        t.Error("Decrypt should fail with another private key")
+    }
+
+    // only key transport recipients are version 0
+    enData, err = Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{rsaCert.Certificate}, opts)
+    assertError(err, "Encrypt")
+    assertEqual(getEnvelopedVersion(t, enData), 0, "Version")
+}
+
+func Test_EncryptDefaultOptsKeyAgree(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    rsaCert, err := createTestCertificate(cryptobin_x509.SHA256WithRSA)
+    assertError(err, "createTestCertificate")
+
+    ecCert, err := createTestCertificate(cryptobin_x509.ECDSAWithSHA256)
+    assertError(err, "createTestCertificate")
+
+    content := []byte("test data test data test data test data")
+
+    // the default opts only use key transport
+    enData, err := Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{rsaCert.Certificate})
+    assertError(err, "Encrypt")
+    assertEqual(getEnvelopedVersion(t, enData), 0, "Version")
+
+    deData, err := Decrypt(enData, rsaCert.Certificate, *rsaCert.PrivateKey)
+    assertError(err, "Decrypt")
+    assertEqual(deData, content, "Decrypt")
+
+    _, err = Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{ecCert.Certificate})
+    if err == nil {
+        t.Error("Encrypt should fail without a key agreement")
+    }
+
+    _, err = Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{
+        rsaCert.Certificate,
+        ecCert.Certificate,
+    })
+    if err == nil {
+        t.Error("Encrypt should fail without a key agreement")
+    }
+
+    _, err = EncryptAuth(rand.Reader, content, []*cryptobin_x509.Certificate{ecCert.Certificate})
+    if err == nil {
+        t.Error("EncryptAuth should fail without a key agreement")
+    }
+}
+
+func Test_EncryptWithKeyAgreeSM2(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    cert := decodeCert(encCert)
+    privkey := decodeSM2PrivateKey(expectedEncKey)
+
+    content := []byte("test data test data test data test data")
+
+    enData, err := Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{cert}, Opts{
+        Cipher:   SM4CBC,
+        KeyAgree: KeyAgreeECDHSHA256,
+        Mode:     SM2Mode,
+    })
+    assertError(err, "Encrypt")
+
+    deData, err := Decrypt(enData, cert, privkey)
+    assertError(err, "Decrypt")
+    assertEqual(deData, content, "Decrypt")
+}
+
+func Test_EncryptWithKeyAgreeX25519AndX448(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    pub25519, priv25519, err := x25519.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    pub448, priv448, err := x448.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    tests := []struct {
+        name string
+        cert *cryptobin_x509.Certificate
+        priv crypto.PrivateKey
+    }{
+        {"X25519", createKeyAgreeTestCertificate(oidPublicKeyX25519, pub25519), priv25519},
+        {"X448", createKeyAgreeTestCertificate(oidPublicKeyX448, pub448), priv448},
+    }
+
+    content := []byte("test data test data test data test data")
+
+    for _, td := range tests {
+        t.Run(td.name, func(t *testing.T) {
+            enData, err := Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{td.cert}, Opts{
+                Cipher:   AES256CBC,
+                KeyAgree: KeyAgreeECDHHKDFSHA256,
+                Mode:     DefaultMode,
+            })
+            assertError(err, "Encrypt")
+
+            deData, err := Decrypt(enData, td.cert, td.priv)
+            assertError(err, "Decrypt")
+            assertEqual(deData, content, "Decrypt")
+
+            enData, err = EncryptAuth(rand.Reader, content, []*cryptobin_x509.Certificate{td.cert}, AuthOpts{
+                Cipher:   AuthAES256GCM,
+                KeyAgree: KeyAgreeECDHSHA512,
+            })
+            assertError(err, "EncryptAuth")
+
+            deData, err = DecryptAuth(enData, td.cert, td.priv)
+            assertError(err, "DecryptAuth")
+            assertEqual(deData, content, "DecryptAuth")
+        })
+    }
+}
+
+func Test_EncryptWithKeyAgreeGost(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    priv, err := gost.GenerateKey(rand.Reader, gost.CurveIdGostR34102001CryptoProAParamSet())
+    assertError(err, "GenerateKey")
+
+    template := &cryptobin_x509.Certificate{
+        SerialNumber:       big.NewInt(2),
+        Subject:            pkix.Name{CommonName: "GOST"},
+        SignatureAlgorithm: cryptobin_x509.GOST3410WithGOST34112001,
+    }
+
+    der, err := cryptobin_x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+    assertError(err, "CreateCertificate")
+
+    cert, err := cryptobin_x509.ParseCertificate(der)
+    assertError(err, "ParseCertificate")
+
+    content := []byte("test data test data test data test data")
+
+    enData, err := Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{cert}, Opts{
+        Cipher:   AES256CBC,
+        KeyAgree: KeyAgreeGostR34102001,
+        Mode:     DefaultMode,
+    })
+    assertError(err, "Encrypt")
+
+    deData, err := Decrypt(enData, cert, priv)
+    assertError(err, "Decrypt")
+    assertEqual(deData, content, "Decrypt")
+
+    other, err := gost.GenerateKey(rand.Reader, gost.CurveIdGostR34102001CryptoProAParamSet())
+    assertError(err, "GenerateKey")
+
+    _, err = Decrypt(enData, cert, other)
+    if err == nil {
+        t.Error("Decrypt should fail with another private key")
+    }
+
+    // the GOST key wrap needs a 256 bit content key
+    _, err = Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{cert}, Opts{
+        Cipher:   AES128CBC,
+        KeyAgree: KeyAgreeGostR34102001,
+        Mode:     DefaultMode,
+    })
+    if err == nil {
+        t.Error("Encrypt should fail with a 128 bit content key")
+    }
+}
+
+// Decrypt on the VKO GOST R 34.10-2001 vector of pubkey/gost, the
+// encrypted key is wrapped with the reference KEK and not the derived one
+func Test_KeyAgreeGost_Check(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    c := gost.CurveIdGostR34102001TestParamSet()
+
+    prv1, _ := hex.DecodeString("1df129e43dab345b68f6a852f4162dc69f36b2f84717d08755cc5c44150bf928")
+    prv2, _ := hex.DecodeString("5b9356c6474f913f1e83885ea0edd5df1a43fd9d799d219093241157ac9ed473")
+    ukm, _ := hex.DecodeString("5172be25f852a233")
+    kek, _ := hex.DecodeString("ee4618a0dbb10cb31777b4b86a53d9e7ef6cb3e400101410f0c0f2af46c494a6")
+
+    originator, err := gost.NewPrivateKey(c, prv1)
+    assertError(err, "NewPrivateKey")
+
+    recipient, err := gost.NewPrivateKey(c, prv2)
+    assertError(err, "NewPrivateKey")
+
+    pub, err := gost.MarshalPublicKey(&originator.PublicKey)
+    assertError(err, "MarshalPublicKey")
+
+    var spki originatorPublicKey
+    _, err = asn1.Unmarshal(pub, &spki)
+    assertError(err, "Unmarshal")
+
+    key := make([]byte, 32)
+    for i := range key {
+        key[i] = byte(i)
+    }
+
+    encryptedKey, err := gostKeyWrap(kek, ukm, key)
+    assertError(err, "gostKeyWrap")
+
+    wrapParams, _ := asn1.Marshal(gostKeyWrapParameters{
+        EncryptionParamSet: oidGost2814789CryptoProA,
+    })
+    params, _ := asn1.Marshal(pkix.AlgorithmIdentifier{
+        Algorithm:  oidGost2814789NoneKeyWrap,
+        Parameters: asn1.RawValue{FullBytes: wrapParams},
+    })
+
+    data := KeyAgreeData{
+        OriginatorKeyAlgorithm: spki.Algorithm,
+        OriginatorKey:          spki.PublicKey.RightAlign(),
+        UKM:                    ukm,
+        Parameters:             params,
+        EncryptedKey:           encryptedKey,
+    }
+
+    res, err := KeyAgreeGostR34102001.Decrypt(data, recipient)
+    assertError(err, "Decrypt")
+    assertEqual(res, key, "Decrypt")
+
+    // the MAC of the key wrap covers the key
+    data.EncryptedKey[len(data.EncryptedKey) - 1] ^= 1
+    _, err = KeyAgreeGostR34102001.Decrypt(data, recipient)
+    if err == nil {
+        t.Error("Decrypt should fail with a changed MAC")
+    }
+}
+
+func Test_KeyWrap(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    // RFC 3394 4.6
+    kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F")
+    key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F")
+    wrapped, _ := hex.DecodeString("28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21")
+
+    res, err := KeyWrapAES256.Wrap(kek, key)
+    assertError(err, "Wrap")
+    assertEqual(res, wrapped, "Wrap")
+
+    res, err = KeyWrapAES256.Unwrap(kek, wrapped)
+    assertError(err, "Unwrap")
+    assertEqual(res, key, "Unwrap")
+
+    wrapped[0] ^= 1
+    _, err = KeyWrapAES256.Unwrap(kek, wrapped)
+    if err == nil {
+        t.Error("Unwrap should fail with a changed key")
+    }
+}
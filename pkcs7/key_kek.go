@@ -0,0 +1,106 @@
+package pkcs7
+
+import (
+    "io"
+    "time"
+    "bytes"
+    "errors"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+)
+
+// 密钥加密密钥接收方
+type KEKRecipient struct {
+    // 密钥标识
+    KeyID []byte
+
+    // 密钥加密密钥
+    KEK []byte
+
+    // 密钥包装方式
+    KeyWrap KEKWrap
+}
+
+// KEKRecipientInfo ::= SEQUENCE {
+//     version CMSVersion,  -- always set to 4
+//     kekid KEKIdentifier,
+//     keyEncryptionAlgorithm KeyEncryptionAlgorithmIdentifier,
+//     encryptedKey EncryptedKey }
+type kekRecipientInfo struct {
+    Version                int
+    KEKID                  kekIdentifier
+    KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+    EncryptedKey           []byte
+}
+
+// KEKIdentifier ::= SEQUENCE {
+//     keyIdentifier OCTET STRING,
+//     date GeneralizedTime OPTIONAL,
+//     other OtherKeyAttribute OPTIONAL }
+type kekIdentifier struct {
+    KeyIdentifier []byte
+    Date          time.Time     `asn1:"optional,generalized"`
+    Other         asn1.RawValue `asn1:"optional"`
+}
+
+// kekRecipientInfoFor returns the [2] IMPLICIT KEKRecipientInfo of the
+// content key for the key encryption key
+func kekRecipientInfoFor(rand io.Reader, recipient KEKRecipient, key []byte) (asn1.RawValue, error) {
+    if len(recipient.KeyID) == 0 {
+        return asn1.RawValue{}, errors.New("pkcs7: KEK recipient without key identifier")
+    }
+
+    keyWrap := recipient.KeyWrap
+    if keyWrap == nil {
+        return asn1.RawValue{}, errors.New("pkcs7: KEK recipient without key wrap")
+    }
+
+    encryptedKey, params, err := keyWrap.Encrypt(rand, recipient.KEK, key)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    info := kekRecipientInfo{
+        Version: 4,
+        KEKID: kekIdentifier{
+            KeyIdentifier: recipient.KeyID,
+        },
+        KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+            Algorithm: keyWrap.OID(),
+            Parameters: asn1.RawValue{
+                FullBytes: params,
+            },
+        },
+        EncryptedKey: encryptedKey,
+    }
+
+    encoded, err := asn1.MarshalWithParams(info, "tag:2")
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    return asn1.RawValue{FullBytes: encoded}, nil
+}
+
+// decryptKEKRecipient decrypts the content key in the [2] IMPLICIT
+// KEKRecipientInfo with the key encryption key. It returns nil if the
+// key identifier does not match.
+func decryptKEKRecipient(raw asn1.RawValue, keyID, kek []byte) ([]byte, error) {
+    var info kekRecipientInfo
+    if _, err := asn1.UnmarshalWithParams(raw.FullBytes, &info, "tag:2"); err != nil {
+        return nil, err
+    }
+
+    if !bytes.Equal(info.KEKID.KeyIdentifier, keyID) {
+        return nil, nil
+    }
+
+    oid := info.KeyEncryptionAlgorithm.Algorithm.String()
+
+    fn, ok := kekWraps[oid]
+    if !ok {
+        return nil, errors.New("pkcs7: unsupported key wrap algorithm (OID: " + oid + ")")
+    }
+
+    return fn().Decrypt(kek, info.KeyEncryptionAlgorithm.Parameters.FullBytes, info.EncryptedKey)
+}
@@ -0,0 +1,227 @@
+package pkcs7
+
+import (
+    "io"
+    "hash"
+    "errors"
+    "crypto/sha1"
+    "crypto/sha256"
+    "crypto/sha512"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/hash/sm3"
+    "github.com/deatil/go-cryptobin/kdf/pbkdf2"
+)
+
+var (
+    // PKCS #5 PBKDF2
+    oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+    oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+    oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+    oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+    oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+    oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+    oidHMACWithSM3    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401, 2}
+)
+
+// PBKDF2 使用的 HMAC 方式
+type PBKDF2PRF struct {
+    identifier asn1.ObjectIdentifier
+    hashFunc   func() hash.Hash
+}
+
+// oid
+func (this PBKDF2PRF) OID() asn1.ObjectIdentifier {
+    return this.identifier
+}
+
+// HMAC 方式
+var (
+    PBKDF2HMACSHA1   = PBKDF2PRF{oidHMACWithSHA1, sha1.New}
+    PBKDF2HMACSHA224 = PBKDF2PRF{oidHMACWithSHA224, sha256.New224}
+    PBKDF2HMACSHA256 = PBKDF2PRF{oidHMACWithSHA256, sha256.New}
+    PBKDF2HMACSHA384 = PBKDF2PRF{oidHMACWithSHA384, sha512.New384}
+    PBKDF2HMACSHA512 = PBKDF2PRF{oidHMACWithSHA512, sha512.New}
+    PBKDF2HMACSM3    = PBKDF2PRF{oidHMACWithSM3, sm3.New}
+)
+
+var pbkdf2PRFs = map[string]PBKDF2PRF{
+    oidHMACWithSHA1.String():   PBKDF2HMACSHA1,
+    oidHMACWithSHA224.String(): PBKDF2HMACSHA224,
+    oidHMACWithSHA256.String(): PBKDF2HMACSHA256,
+    oidHMACWithSHA384.String(): PBKDF2HMACSHA384,
+    oidHMACWithSHA512.String(): PBKDF2HMACSHA512,
+    oidHMACWithSM3.String():    PBKDF2HMACSM3,
+}
+
+// 密码接收方
+type PasswordRecipient struct {
+    Password []byte
+
+    // PBKDF2 设置
+    SaltSize       int
+    IterationCount int
+    PRF            PBKDF2PRF
+
+    // 密钥包装方式, 默认为 PWRIKeyWrapAES256CBC
+    KeyWrap PWRIKeyWrap
+}
+
+// PBKDF2-params ::= SEQUENCE {
+//     salt OCTET STRING,
+//     iterationCount INTEGER (1..MAX),
+//     keyLength INTEGER (1..MAX) OPTIONAL,
+//     prf AlgorithmIdentifier DEFAULT algid-hmacWithSHA1 }
+type pbkdf2Params struct {
+    Salt           []byte
+    IterationCount int
+    KeyLength      int                      `asn1:"optional"`
+    PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// PasswordRecipientInfo ::= SEQUENCE {
+//     version CMSVersion,   -- always set to 0
+//     keyDerivationAlgorithm [0] KeyDerivationAlgorithmIdentifier OPTIONAL,
+//     keyEncryptionAlgorithm KeyEncryptionAlgorithmIdentifier,
+//     encryptedKey EncryptedKey }
+type passwordRecipientInfo struct {
+    Version                int
+    KeyDerivationAlgorithm pkix.AlgorithmIdentifier `asn1:"optional,tag:0"`
+    KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+    EncryptedKey           []byte
+}
+
+// passwordRecipientInfoFor returns the [3] IMPLICIT PasswordRecipientInfo
+// of the content key for the password
+func passwordRecipientInfoFor(rand io.Reader, recipient PasswordRecipient, key []byte) (asn1.RawValue, error) {
+    if len(recipient.Password) == 0 {
+        return asn1.RawValue{}, errors.New("pkcs7: password recipient without password")
+    }
+
+    keyWrap := recipient.KeyWrap
+    if keyWrap.cipherFunc == nil {
+        keyWrap = PWRIKeyWrapAES256CBC
+    }
+
+    prf := recipient.PRF
+    if prf.hashFunc == nil {
+        prf = PBKDF2HMACSHA256
+    }
+
+    saltSize := recipient.SaltSize
+    if saltSize <= 0 {
+        saltSize = 16
+    }
+
+    iterationCount := recipient.IterationCount
+    if iterationCount <= 0 {
+        iterationCount = 10000
+    }
+
+    salt := make([]byte, saltSize)
+    if _, err := io.ReadFull(rand, salt); err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    kek := pbkdf2.Key(recipient.Password, salt, iterationCount, keyWrap.KeySize(), pbkdf2.NewHmacPRF(prf.hashFunc))
+
+    encryptedKey, params, err := keyWrap.Encrypt(rand, kek, key)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    kdfParams := pbkdf2Params{
+        Salt:           salt,
+        IterationCount: iterationCount,
+    }
+
+    // hmacWithSHA1 is the DEFAULT
+    if !prf.identifier.Equal(oidHMACWithSHA1) {
+        kdfParams.PRF = pkix.AlgorithmIdentifier{
+            Algorithm:  prf.identifier,
+            Parameters: asn1.NullRawValue,
+        }
+    }
+
+    kdfParamBytes, err := asn1.Marshal(kdfParams)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    info := passwordRecipientInfo{
+        Version: 0,
+        KeyDerivationAlgorithm: pkix.AlgorithmIdentifier{
+            Algorithm: oidPBKDF2,
+            Parameters: asn1.RawValue{
+                FullBytes: kdfParamBytes,
+            },
+        },
+        KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+            Algorithm: keyWrap.OID(),
+            Parameters: asn1.RawValue{
+                FullBytes: params,
+            },
+        },
+        EncryptedKey: encryptedKey,
+    }
+
+    encoded, err := asn1.MarshalWithParams(info, "tag:3")
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    return asn1.RawValue{FullBytes: encoded}, nil
+}
+
+// decryptPasswordRecipient decrypts the content key in the [3] IMPLICIT
+// PasswordRecipientInfo with the password
+func decryptPasswordRecipient(raw asn1.RawValue, password []byte) ([]byte, error) {
+    var info passwordRecipientInfo
+    if _, err := asn1.UnmarshalWithParams(raw.FullBytes, &info, "tag:3"); err != nil {
+        return nil, err
+    }
+
+    if !info.KeyDerivationAlgorithm.Algorithm.Equal(oidPBKDF2) {
+        return nil, errors.New("pkcs7: unsupported key derivation algorithm (OID: " + info.KeyDerivationAlgorithm.Algorithm.String() + ")")
+    }
+
+    if !info.KeyEncryptionAlgorithm.Algorithm.Equal(oidPWRIKEK) {
+        return nil, errors.New("pkcs7: unsupported password key encryption algorithm (OID: " + info.KeyEncryptionAlgorithm.Algorithm.String() + ")")
+    }
+
+    var kdfParams pbkdf2Params
+    if _, err := asn1.Unmarshal(info.KeyDerivationAlgorithm.Parameters.FullBytes, &kdfParams); err != nil {
+        return nil, errors.New("pkcs7: invalid PBKDF2 parameters")
+    }
+
+    prf := PBKDF2HMACSHA1
+    if len(kdfParams.PRF.Algorithm) > 0 {
+        var ok bool
+        prf, ok = pbkdf2PRFs[kdfParams.PRF.Algorithm.String()]
+        if !ok {
+            return nil, errors.New("pkcs7: unsupported PBKDF2 prf (OID: " + kdfParams.PRF.Algorithm.String() + ")")
+        }
+    }
+
+    if kdfParams.IterationCount <= 0 {
+        return nil, errors.New("pkcs7: invalid PBKDF2 iteration count")
+    }
+
+    params := info.KeyEncryptionAlgorithm.Parameters.FullBytes
+
+    keyWrap, _, err := parsePWRIKeyWrap(params)
+    if err != nil {
+        return nil, err
+    }
+
+    keySize := keyWrap.KeySize()
+    if kdfParams.KeyLength > 0 && kdfParams.KeyLength != keySize {
+        return nil, errors.New("pkcs7: invalid PBKDF2 key length")
+    }
+
+    kek := pbkdf2.Key(password, kdfParams.Salt, kdfParams.IterationCount, keySize, pbkdf2.NewHmacPRF(prf.hashFunc))
+
+    return keyWrap.Decrypt(kek, params, info.EncryptedKey)
+}
@@ -0,0 +1,214 @@
+package pkcs7
+
+import (
+    "testing"
+    "crypto/rand"
+    "encoding/hex"
+
+    cryptobin_x509 "github.com/deatil/go-cryptobin/x509"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+// openssl cms -encrypt -aes256 -pwri_password "cryptobin"
+var testPWRIData = `-----BEGIN CMS-----
+MIHoBgkqhkiG9w0BBwOggdowgdcCAQMxgYOjgYACAQCgGwYJKoZIhvcNAQUMMA4E
+CIYnYU6d0yNpAgIIADAsBgsqhkiG9w0BCRADCTAdBglghkgBZQMEASoEEPIFgOLN
+0hqSt5xJ1Q0/zFEEMNEaRpVBdYuxScxDTqtvT5biwyFjwVIuH3/8QGvJqqAKp8AJ
+PmW6ODJMGIZly8EHDDBMBgkqhkiG9w0BBwEwHQYJYIZIAWUDBAEqBBB0n4XYmJZd
+XuddxyCVFZ0ygCA6l0aBb4ZsHSQGTpvpW0dK+KC9JfZgSJsphTHFMQdsag==
+-----END CMS-----
+`
+
+// openssl cms -encrypt -aes128 -secretkey 000102030405060708090A0B0C0D0E0F -secretkeyid 0102030405
+var testKEKData = `-----BEGIN CMS-----
+MIGZBgkqhkiG9w0BBwOggYswgYgCAQIxNaIzAgEEMAcEBQECAwQFMAsGCWCGSAFl
+AwQBBQQY/DRYCrRU7K4D4fPsCf8dxeOSJQJAr20fMEwGCSqGSIb3DQEHATAdBglg
+hkgBZQMEAQIEEH20L12HWtilpAh0VvhzYkuAIHACWnnjGzjhDuWOVU475UfrkIK4
+gxvuEm/gs3GUQ7YU
+-----END CMS-----
+`
+
+func Test_DecryptWithPasswordAndKEKOpenSSL(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    content := []byte("test data test data")
+
+    data, err := ParsePkcs7Pem([]byte(testPWRIData))
+    assertError(err, "ParsePkcs7Pem")
+
+    deData, err := DecryptWithPassword(data, []byte("cryptobin"))
+    assertError(err, "DecryptWithPassword")
+    assertEqual(deData, content, "DecryptWithPassword")
+
+    _, err = DecryptWithPassword(data, []byte("cryptobin2"))
+    if err == nil {
+        t.Error("DecryptWithPassword should fail with a wrong password")
+    }
+
+    data, err = ParsePkcs7Pem([]byte(testKEKData))
+    assertError(err, "ParsePkcs7Pem")
+
+    keyID, _ := hex.DecodeString("0102030405")
+    kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+
+    deData, err = DecryptWithKEK(data, keyID, kek)
+    assertError(err, "DecryptWithKEK")
+    assertEqual(deData, content, "DecryptWithKEK")
+
+    _, err = DecryptWithKEK(data, []byte("other"), kek)
+    if err == nil {
+        t.Error("DecryptWithKEK should fail with another key identifier")
+    }
+}
+
+func Test_EncryptWithPassword(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    content := []byte("test data test data test data test data")
+    password := []byte("cryptobin")
+
+    tests := map[string]PasswordRecipient{
+        "Default": {
+            Password: password,
+        },
+        "AES128CBC-SHA1": {
+            Password:       password,
+            IterationCount: 1000,
+            PRF:            PBKDF2HMACSHA1,
+            KeyWrap:        PWRIKeyWrapAES128CBC,
+        },
+        "AES192CBC-SHA512": {
+            Password: password,
+            SaltSize: 8,
+            PRF:      PBKDF2HMACSHA512,
+            KeyWrap:  PWRIKeyWrapAES192CBC,
+        },
+        "SM4CBC-SM3": {
+            Password: password,
+            PRF:      PBKDF2HMACSM3,
+            KeyWrap:  PWRIKeyWrapSM4CBC,
+        },
+    }
+
+    for name, recipient := range tests {
+        t.Run(name, func(t *testing.T) {
+            enData, err := Encrypt(rand.Reader, content, nil, Opts{
+                Cipher:             AES256CBC,
+                Mode:               DefaultMode,
+                PasswordRecipients: []PasswordRecipient{recipient},
+            })
+            assertError(err, "Encrypt")
+            assertEqual(getEnvelopedVersion(t, enData), 3, "Version")
+
+            deData, err := DecryptWithPassword(enData, password)
+            assertError(err, "DecryptWithPassword")
+            assertEqual(deData, content, "DecryptWithPassword")
+
+            _, err = DecryptWithPassword(enData, []byte("cryptobin2"))
+            if err == nil {
+                t.Error("DecryptWithPassword should fail with a wrong password")
+            }
+        })
+    }
+}
+
+func Test_EncryptWithKEK(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    content := []byte("test data test data test data test data")
+    keyID := []byte("kek id")
+
+    tests := map[string]KEKWrap{
+        "AES128":         KeyWrapAES128,
+        "AES192":         KeyWrapAES192,
+        "AES256":         KeyWrapAES256,
+        "SM4":            KeyWrapSM4,
+        "PWRI-AES256CBC": PWRIKeyWrapAES256CBC,
+        "PWRI-SM4CBC":    PWRIKeyWrapSM4CBC,
+    }
+
+    for name, keyWrap := range tests {
+        t.Run(name, func(t *testing.T) {
+            kek := make([]byte, keyWrap.KeySize())
+            _, err := rand.Read(kek)
+            assertError(err, "rand")
+
+            enData, err := Encrypt(rand.Reader, content, nil, Opts{
+                Cipher: AES256CBC,
+                Mode:   DefaultMode,
+                KEKRecipients: []KEKRecipient{
+                    {KeyID: keyID, KEK: kek, KeyWrap: keyWrap},
+                },
+            })
+            assertError(err, "Encrypt")
+            assertEqual(getEnvelopedVersion(t, enData), 2, "Version")
+
+            deData, err := DecryptWithKEK(enData, keyID, kek)
+            assertError(err, "DecryptWithKEK")
+            assertEqual(deData, content, "DecryptWithKEK")
+
+            kek[0] ^= 1
+            _, err = DecryptWithKEK(enData, keyID, kek)
+            if err == nil {
+                t.Error("DecryptWithKEK should fail with another kek")
+            }
+        })
+    }
+}
+
+func Test_EncryptWithMixedRecipients(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    cert, err := createTestCertificate(cryptobin_x509.SHA256WithRSA)
+    assertError(err, "createTestCertificate")
+
+    ecCert, err := createTestCertificate(cryptobin_x509.ECDSAWithSHA256)
+    assertError(err, "createTestCertificate")
+
+    content := []byte("test data test data test data test data")
+    password := []byte("cryptobin")
+    keyID := []byte("kek id")
+    kek := make([]byte, 32)
+    rand.Read(kek)
+
+    opts := DefaultOpts
+    opts.KeyAgree = KeyAgreeECDHSHA256
+    opts.PasswordRecipients = []PasswordRecipient{
+        {Password: password},
+    }
+    opts.KEKRecipients = []KEKRecipient{
+        {KeyID: keyID, KEK: kek, KeyWrap: KeyWrapAES256},
+    }
+
+    enData, err := Encrypt(rand.Reader, content, []*cryptobin_x509.Certificate{
+        cert.Certificate,
+        ecCert.Certificate,
+    }, opts)
+    assertError(err, "Encrypt")
+    assertEqual(getEnvelopedVersion(t, enData), 3, "Version")
+
+    deData, err := Decrypt(enData, cert.Certificate, *cert.PrivateKey)
+    assertError(err, "Decrypt")
+    assertEqual(deData, content, "Decrypt")
+
+    deData, err = Decrypt(enData, ecCert.Certificate, *ecCert.PrivateKey)
+    assertError(err, "Decrypt")
+    assertEqual(deData, content, "Decrypt")
+
+    deData, err = DecryptWithPassword(enData, password)
+    assertError(err, "DecryptWithPassword")
+    assertEqual(deData, content, "DecryptWithPassword")
+
+    deData, err = DecryptWithKEK(enData, keyID, kek)
+    assertError(err, "DecryptWithKEK")
+    assertEqual(deData, content, "DecryptWithKEK")
+
+    _, err = Encrypt(rand.Reader, content, nil)
+    if err == nil {
+        t.Error("Encrypt should fail without recipients")
+    }
+}
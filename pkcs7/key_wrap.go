@@ -0,0 +1,238 @@
+package pkcs7
+
+import (
+    "io"
+    "errors"
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/cipher/sm4"
+)
+
+var (
+    // RFC 3211 PWRI-KEK
+    oidPWRIKEK = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 9}
+
+    oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+    oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+    oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+    oidSM4CBC    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 2}
+)
+
+// 密钥加密密钥包装接口, 用于 KEKRecipientInfo
+type KEKWrap interface {
+    // oid
+    OID() asn1.ObjectIdentifier
+
+    // 密钥大小
+    KeySize() int
+
+    // 包装内容密钥, 返回包装数据和算法参数
+    Encrypt(rand io.Reader, kek, key []byte) ([]byte, []byte, error)
+
+    // 解包装内容密钥
+    Decrypt(kek, params, encrypted []byte) ([]byte, error)
+}
+
+var kekWraps = make(map[string]func() KEKWrap)
+
+// 添加密钥包装方式
+func AddKEKWrap(oid asn1.ObjectIdentifier, fn func() KEKWrap) {
+    kekWraps[oid.String()] = fn
+}
+
+// 包装内容密钥, 没有算法参数
+func (this KeyWrap) Encrypt(rand io.Reader, kek, key []byte) ([]byte, []byte, error) {
+    wrapped, err := this.Wrap(kek, key)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return wrapped, nil, nil
+}
+
+// 解包装内容密钥
+func (this KeyWrap) Decrypt(kek, params, encrypted []byte) ([]byte, error) {
+    return this.Unwrap(kek, encrypted)
+}
+
+// PWRI-KEK 密钥包装, RFC 3211
+// 使用 CBC 模式两次加密带长度和校验值的内容密钥
+type PWRIKeyWrap struct {
+    cipherFunc func(key []byte) (cipher.Block, error)
+    cipherOID  asn1.ObjectIdentifier
+    keySize    int
+}
+
+// oid
+func (this PWRIKeyWrap) OID() asn1.ObjectIdentifier {
+    return oidPWRIKEK
+}
+
+// 密钥大小
+func (this PWRIKeyWrap) KeySize() int {
+    return this.keySize
+}
+
+// 包装内容密钥, 算法参数为 CBC 加密算法及 iv
+func (this PWRIKeyWrap) Encrypt(rand io.Reader, kek, key []byte) ([]byte, []byte, error) {
+    if len(kek) != this.keySize {
+        return nil, nil, errors.New("pkcs7: invalid key wrap key size")
+    }
+
+    if len(key) < 3 || len(key) > 255 {
+        return nil, nil, errors.New("pkcs7: invalid wrapped key size")
+    }
+
+    block, err := this.cipherFunc(kek)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    bs := block.BlockSize()
+
+    // at least two blocks of length, check value, key and padding
+    size := (4 + len(key) + bs - 1) / bs * bs
+    if size < 2 * bs {
+        size = 2 * bs
+    }
+
+    buf := make([]byte, size)
+    buf[0] = byte(len(key))
+    buf[1] = key[0] ^ 0xff
+    buf[2] = key[1] ^ 0xff
+    buf[3] = key[2] ^ 0xff
+    copy(buf[4:], key)
+
+    if _, err := io.ReadFull(rand, buf[4 + len(key):]); err != nil {
+        return nil, nil, err
+    }
+
+    iv := make([]byte, bs)
+    if _, err := io.ReadFull(rand, iv); err != nil {
+        return nil, nil, err
+    }
+
+    // the last block of the first pass is the iv of the second pass
+    cipher.NewCBCEncrypter(block, iv).CryptBlocks(buf, buf)
+    cipher.NewCBCEncrypter(block, buf[size - bs:]).CryptBlocks(buf, buf)
+
+    params, err := asn1.Marshal(pkix.AlgorithmIdentifier{
+        Algorithm: this.cipherOID,
+        Parameters: asn1.RawValue{
+            FullBytes: marshalOctetString(iv),
+        },
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return buf, params, nil
+}
+
+// 解包装内容密钥, 加密算法由算法参数确定
+func (this PWRIKeyWrap) Decrypt(kek, params, encrypted []byte) ([]byte, error) {
+    keyWrap, iv, err := parsePWRIKeyWrap(params)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(kek) != keyWrap.keySize {
+        return nil, errors.New("pkcs7: invalid key wrap key size")
+    }
+
+    block, err := keyWrap.cipherFunc(kek)
+    if err != nil {
+        return nil, err
+    }
+
+    bs := block.BlockSize()
+    n := len(encrypted)
+
+    if len(iv) != bs || n < 2 * bs || n % bs != 0 {
+        return nil, errors.New("pkcs7: invalid wrapped key size")
+    }
+
+    tmp := make([]byte, n)
+
+    // decrypt the last block with the previous block as iv, then the
+    // other blocks of the second pass with the last block as iv
+    cipher.NewCBCDecrypter(block, encrypted[n - 2 * bs:n - bs]).CryptBlocks(tmp[n - bs:], encrypted[n - bs:])
+    cipher.NewCBCDecrypter(block, tmp[n - bs:]).CryptBlocks(tmp[:n - bs], encrypted[:n - bs])
+
+    // decrypt the first pass
+    cipher.NewCBCDecrypter(block, iv).CryptBlocks(tmp, tmp)
+
+    size := int(tmp[0])
+    if size < 3 || size > n - 4 ||
+        tmp[1] ^ tmp[4] != 0xff ||
+        tmp[2] ^ tmp[5] != 0xff ||
+        tmp[3] ^ tmp[6] != 0xff {
+        return nil, errors.New("pkcs7: key unwrap failed")
+    }
+
+    return tmp[4:4 + size], nil
+}
+
+// parsePWRIKeyWrap returns the PWRI key wrap and the iv of the
+// PWRI-KEK parameters
+func parsePWRIKeyWrap(params []byte) (PWRIKeyWrap, []byte, error) {
+    var algo pkix.AlgorithmIdentifier
+    if _, err := asn1.Unmarshal(params, &algo); err != nil {
+        return PWRIKeyWrap{}, nil, errors.New("pkcs7: invalid PWRI-KEK parameters")
+    }
+
+    var iv []byte
+    if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &iv); err != nil {
+        return PWRIKeyWrap{}, nil, errors.New("pkcs7: invalid PWRI-KEK iv")
+    }
+
+    keyWrap, ok := pwriKeyWraps[algo.Algorithm.String()]
+    if !ok {
+        return PWRIKeyWrap{}, nil, errors.New("pkcs7: unsupported PWRI-KEK cipher (OID: " + algo.Algorithm.String() + ")")
+    }
+
+    return keyWrap, iv, nil
+}
+
+func marshalOctetString(b []byte) []byte {
+    encoded, _ := asn1.Marshal(b)
+    return encoded
+}
+
+// PWRI-KEK 密钥包装方式
+var (
+    PWRIKeyWrapAES128CBC = PWRIKeyWrap{aes.NewCipher, oidAES128CBC, 16}
+    PWRIKeyWrapAES192CBC = PWRIKeyWrap{aes.NewCipher, oidAES192CBC, 24}
+    PWRIKeyWrapAES256CBC = PWRIKeyWrap{aes.NewCipher, oidAES256CBC, 32}
+    PWRIKeyWrapSM4CBC    = PWRIKeyWrap{sm4.NewCipher, oidSM4CBC, 16}
+)
+
+var pwriKeyWraps = map[string]PWRIKeyWrap{
+    oidAES128CBC.String(): PWRIKeyWrapAES128CBC,
+    oidAES192CBC.String(): PWRIKeyWrapAES192CBC,
+    oidAES256CBC.String(): PWRIKeyWrapAES256CBC,
+    oidSM4CBC.String():    PWRIKeyWrapSM4CBC,
+}
+
+func init() {
+    AddKEKWrap(oidAES128Wrap, func() KEKWrap {
+        return KeyWrapAES128
+    })
+    AddKEKWrap(oidAES192Wrap, func() KEKWrap {
+        return KeyWrapAES192
+    })
+    AddKEKWrap(oidAES256Wrap, func() KEKWrap {
+        return KeyWrapAES256
+    })
+    AddKEKWrap(oidSM4Wrap, func() KEKWrap {
+        return KeyWrapSM4
+    })
+
+    // the cipher of PWRI-KEK is in the parameters
+    AddKEKWrap(oidPWRIKEK, func() KEKWrap {
+        return PWRIKeyWrapAES256CBC
+    })
+}
@@ -337,6 +337,14 @@ func getSignatureFunc(digestEncryption, digest asn1.ObjectIdentifier) (KeySign,
                         digest.String(), digestEncryption.String())
             }
 
+        // ML-DSA
+        case digestEncryption.Equal(OidEncryptionAlgorithmMLDSA44):
+            return KeySignWithMLDSA44, nil
+        case digestEncryption.Equal(OidEncryptionAlgorithmMLDSA65):
+            return KeySignWithMLDSA65, nil
+        case digestEncryption.Equal(OidEncryptionAlgorithmMLDSA87):
+            return KeySignWithMLDSA87, nil
+
         default:
             return nil, fmt.Errorf("pkcs7: unsupported algorithm %q",
                 digestEncryption.String())
@@ -353,7 +361,8 @@ func getDigestOIDForSignatureAlgorithm(digestAlg x509.SignatureAlgorithm) (asn1.
             return OidDigestAlgorithmSHA256, nil
         case x509.SHA384WithRSA, x509.ECDSAWithSHA384:
             return OidDigestAlgorithmSHA384, nil
-        case x509.SHA512WithRSA, x509.ECDSAWithSHA512:
+        case x509.SHA512WithRSA, x509.ECDSAWithSHA512,
+            x509.MLDSA44, x509.MLDSA65, x509.MLDSA87:
             return OidDigestAlgorithmSHA512, nil
         case x509.SM2WithSM3:
             return OidDigestAlgorithmSM3, nil
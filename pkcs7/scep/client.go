@@ -0,0 +1,284 @@
+package scep
+
+import (
+    "io"
+    "fmt"
+    "time"
+    "bytes"
+    "errors"
+    "context"
+    "strings"
+    "math/big"
+    "net/url"
+    "net/http"
+    "crypto"
+    "crypto/rand"
+
+    "github.com/deatil/go-cryptobin/pkcs7"
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// maxResponseSize limits the size of the response bodies.
+const maxResponseSize = 1 << 20
+
+// PendingError is returned for a pending request, the client polls
+// with the same request.
+type PendingError struct {
+    TransactionID string
+}
+
+func (e *PendingError) Error() string {
+    return "scep: request pending, transaction " + e.TransactionID
+}
+
+// FailError is returned for a failed request.
+type FailError struct {
+    FailInfo FailInfo
+    Text     string
+}
+
+func (e *FailError) Error() string {
+    if e.Text != "" {
+        return "scep: request failed: " + e.FailInfo.String() + ": " + e.Text
+    }
+
+    return "scep: request failed: " + e.FailInfo.String()
+}
+
+// Client is a SCEP client.
+type Client struct {
+    // URL is the URL of the server, as
+    // "http://scep.example.com/scep".
+    URL string
+
+    // HTTPClient sends the requests, http.DefaultClient if nil.
+    HTTPClient *http.Client
+}
+
+// GetCACaps returns the capabilities of the server.
+func (c *Client) GetCACaps(ctx context.Context) ([]string, error) {
+    body, _, err := c.get(ctx, "GetCACaps")
+    if err != nil {
+        return nil, err
+    }
+
+    var caps []string
+    for _, line := range strings.Split(string(body), "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            caps = append(caps, line)
+        }
+    }
+
+    return caps, nil
+}
+
+// GetCACert returns the CA certificates, the certificate the requests
+// are encrypted for first.
+func (c *Client) GetCACert(ctx context.Context) ([]*x509.Certificate, error) {
+    body, contentType, err := c.get(ctx, "GetCACert")
+    if err != nil {
+        return nil, err
+    }
+
+    switch contentType {
+        case "application/x-x509-ca-cert":
+            cert, err := x509.ParseCertificate(body)
+            if err != nil {
+                return nil, err
+            }
+
+            return []*x509.Certificate{cert}, nil
+        case "application/x-x509-ca-ra-cert":
+            p7, err := pkcs7.Parse(body)
+            if err != nil {
+                return nil, err
+            }
+
+            if len(p7.Certificates) == 0 {
+                return nil, errors.New("scep: no CA certificate")
+            }
+
+            return p7.Certificates, nil
+    }
+
+    return nil, errors.New("scep: invalid GetCACert content type " + contentType)
+}
+
+// Enroll sends a PKCSReq for csr. signer is a self-signed certificate
+// of the key of csr, see NewSelfSignedSigner, and recipient is the
+// first certificate returned by GetCACert.
+func (c *Client) Enroll(ctx context.Context, csr *x509.CertificateRequest, signer Signer, recipient *x509.Certificate) (*x509.Certificate, error) {
+    msg, err := NewPKCSReq(csr, signer, recipient)
+    if err != nil {
+        return nil, err
+    }
+
+    return c.certificate(ctx, msg, signer, recipient)
+}
+
+// Renew sends a RenewalReq for csr, signed with the current
+// certificate and key.
+func (c *Client) Renew(ctx context.Context, csr *x509.CertificateRequest, signer Signer, recipient *x509.Certificate) (*x509.Certificate, error) {
+    msg, err := NewRenewalReq(csr, signer, recipient)
+    if err != nil {
+        return nil, err
+    }
+
+    return c.certificate(ctx, msg, signer, recipient)
+}
+
+// Poll sends a CertPoll for the pending request csr.
+func (c *Client) Poll(ctx context.Context, csr *x509.CertificateRequest, signer Signer, recipient *x509.Certificate) (*x509.Certificate, error) {
+    msg, err := NewCertPoll(csr, signer, recipient)
+    if err != nil {
+        return nil, err
+    }
+
+    return c.certificate(ctx, msg, signer, recipient)
+}
+
+// GetCert returns the certificate with the serial number issued by
+// issuer.
+func (c *Client) GetCert(ctx context.Context, issuer *x509.Certificate, serial *big.Int, signer Signer, recipient *x509.Certificate) (*x509.Certificate, error) {
+    msg, err := NewGetCert(issuer, serial, signer, recipient)
+    if err != nil {
+        return nil, err
+    }
+
+    return c.certificate(ctx, msg, signer, recipient)
+}
+
+// PKIOperation sends msg and returns the CertRep, which must be signed
+// by recipient or by a certificate it issued. The CertRep is not
+// decrypted.
+func (c *Client) PKIOperation(ctx context.Context, msg *PKIMessage, recipient *x509.Certificate) (*PKIMessage, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.operationURL("PKIOperation"), bytes.NewReader(msg.Raw))
+    if err != nil {
+        return nil, err
+    }
+
+    req.Header.Set("Content-Type", "application/x-pki-message")
+
+    body, _, err := c.do(req)
+    if err != nil {
+        return nil, err
+    }
+
+    rep, err := ParsePKIMessage(body)
+    if err != nil {
+        return nil, err
+    }
+
+    if rep.MessageType != CertRep ||
+        rep.TransactionID != msg.TransactionID ||
+        !bytes.Equal(rep.RecipientNonce, msg.SenderNonce) {
+        return nil, errors.New("scep: response does not match the request")
+    }
+
+    if !bytes.Equal(rep.Signer.Raw, recipient.Raw) && rep.Signer.CheckSignatureFrom(recipient) != nil {
+        return nil, errors.New("scep: response not signed by the CA")
+    }
+
+    return rep, nil
+}
+
+func (c *Client) certificate(ctx context.Context, msg *PKIMessage, signer Signer, recipient *x509.Certificate) (*x509.Certificate, error) {
+    rep, err := c.PKIOperation(ctx, msg, recipient)
+    if err != nil {
+        return nil, err
+    }
+
+    switch rep.PKIStatus {
+        case Pending:
+            return nil, &PendingError{TransactionID: rep.TransactionID}
+        case Failure:
+            return nil, &FailError{FailInfo: rep.FailInfo, Text: rep.FailInfoText}
+    }
+
+    if err = rep.Decrypt(signer.Certificate, signer.Key); err != nil {
+        return nil, err
+    }
+
+    if len(rep.Certificates) == 0 {
+        return nil, errors.New("scep: no certificate in response")
+    }
+
+    return rep.Certificates[0], nil
+}
+
+func (c *Client) get(ctx context.Context, operation string) ([]byte, string, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.operationURL(operation), nil)
+    if err != nil {
+        return nil, "", err
+    }
+
+    return c.do(req)
+}
+
+func (c *Client) do(req *http.Request) ([]byte, string, error) {
+    client := c.HTTPClient
+    if client == nil {
+        client = http.DefaultClient
+    }
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, "", err
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+    if err != nil {
+        return nil, "", err
+    }
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, "", fmt.Errorf("scep: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+    }
+
+    return body, resp.Header.Get("Content-Type"), nil
+}
+
+func (c *Client) operationURL(operation string) string {
+    sep := "?"
+    if strings.Contains(c.URL, "?") {
+        sep = "&"
+    }
+
+    return c.URL + sep + "operation=" + url.QueryEscape(operation)
+}
+
+// NewSelfSignedSigner returns the signer of the PKCSReq for csr: a
+// certificate of the key of csr self-signed with key, valid for a day.
+func NewSelfSignedSigner(csr *x509.CertificateRequest, key crypto.Signer) (Signer, error) {
+    serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+    if err != nil {
+        return Signer{}, err
+    }
+
+    now := time.Now()
+
+    tmpl := &x509.Certificate{
+        SerialNumber:       serial,
+        RawSubject:         csr.RawSubject,
+        NotBefore:          now.Add(-time.Hour),
+        NotAfter:           now.Add(24 * time.Hour),
+        KeyUsage:           x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+        SignatureAlgorithm: signatureAlgorithm(key),
+    }
+
+    der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+    if err != nil {
+        return Signer{}, err
+    }
+
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        return Signer{}, err
+    }
+
+    return Signer{
+        Certificate: cert,
+        Key:         key,
+    }, nil
+}
@@ -0,0 +1,619 @@
+// Package scep implements the Simple Certificate Enrolment Protocol,
+// RFC 8894: the pkiMessage signed and enveloped with the pkcs7
+// package, an http.Handler server and a client.
+package scep
+
+import (
+    "io"
+    "fmt"
+    "errors"
+    "math/big"
+    "crypto"
+    "crypto/rsa"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/sha256"
+    "crypto/x509/pkix"
+    "encoding/hex"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/pkcs7"
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+// MessageType is the messageType attribute.
+type MessageType string
+
+const (
+    CertRep    MessageType = "3"
+    RenewalReq MessageType = "17"
+    PKCSReq    MessageType = "19"
+    CertPoll   MessageType = "20"
+    GetCert    MessageType = "21"
+    GetCRL     MessageType = "22"
+)
+
+func (t MessageType) String() string {
+    switch t {
+        case CertRep:
+            return "CertRep"
+        case RenewalReq:
+            return "RenewalReq"
+        case PKCSReq:
+            return "PKCSReq"
+        case CertPoll:
+            return "CertPoll"
+        case GetCert:
+            return "GetCert"
+        case GetCRL:
+            return "GetCRL"
+    }
+
+    return "unknown message type " + string(t)
+}
+
+// PKIStatus is the pkiStatus attribute of a CertRep.
+type PKIStatus string
+
+const (
+    Success PKIStatus = "0"
+    Failure PKIStatus = "2"
+    Pending PKIStatus = "3"
+)
+
+// FailInfo is the failInfo attribute of a failed CertRep.
+type FailInfo string
+
+const (
+    BadAlg          FailInfo = "0"
+    BadMessageCheck FailInfo = "1"
+    BadRequest      FailInfo = "2"
+    BadTime         FailInfo = "3"
+    BadCertID       FailInfo = "4"
+)
+
+func (f FailInfo) String() string {
+    switch f {
+        case BadAlg:
+            return "badAlg"
+        case BadMessageCheck:
+            return "badMessageCheck"
+        case BadRequest:
+            return "badRequest"
+        case BadTime:
+            return "badTime"
+        case BadCertID:
+            return "badCertID"
+    }
+
+    return "unknown failInfo " + string(f)
+}
+
+var (
+    oidTransactionID  = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+    oidMessageType    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+    oidPKIStatus      = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+    oidFailInfo       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+    oidSenderNonce    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+    oidRecipientNonce = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+    oidFailInfoText   = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 24, 1}
+
+    oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+)
+
+const nonceSize = 16
+
+// IssuerAndSubject is the content of a CertPoll.
+type IssuerAndSubject struct {
+    Issuer  asn1.RawValue
+    Subject asn1.RawValue
+}
+
+// IssuerAndSerial is the content of a GetCert.
+type IssuerAndSerial struct {
+    Issuer       asn1.RawValue
+    SerialNumber *big.Int
+}
+
+// PKIMessage is a SCEP pkiMessage.
+type PKIMessage struct {
+    // Raw is the DER-encoded pkiMessage.
+    Raw []byte
+
+    TransactionID  string
+    MessageType    MessageType
+    SenderNonce    []byte
+    RecipientNonce []byte
+
+    // PKIStatus, FailInfo and FailInfoText are set in CertRep.
+    PKIStatus    PKIStatus
+    FailInfo     FailInfo
+    FailInfoText string
+
+    // Signer is the certificate that signed the message.
+    Signer *x509.Certificate
+
+    // The decrypted content, depending on MessageType: the request of
+    // PKCSReq and RenewalReq, the names of CertPoll, the issuer and
+    // serial number of GetCert and the certificates of a successful
+    // CertRep.
+    CSR              *x509.CertificateRequest
+    IssuerAndSubject *IssuerAndSubject
+    IssuerAndSerial  *IssuerAndSerial
+    Certificates     []*x509.Certificate
+
+    // envelope is the encrypted pkcsPKIEnvelope.
+    envelope []byte
+}
+
+// Signer signs and decrypts the messages: the requester certificate,
+// self-signed for a PKCSReq, or the CA or RA certificate.
+type Signer struct {
+    Certificate *x509.Certificate
+    Key         crypto.PrivateKey
+}
+
+// NewPKCSReq returns a PKCSReq for csr, signed by signer and encrypted
+// for recipient, the CA or RA certificate.
+func NewPKCSReq(csr *x509.CertificateRequest, signer Signer, recipient *x509.Certificate) (*PKIMessage, error) {
+    return newRequest(PKCSReq, TransactionID(csr), csr.Raw, signer, recipient)
+}
+
+// NewRenewalReq returns a RenewalReq for csr, signed with the current
+// certificate and key of the requester.
+func NewRenewalReq(csr *x509.CertificateRequest, signer Signer, recipient *x509.Certificate) (*PKIMessage, error) {
+    return newRequest(RenewalReq, TransactionID(csr), csr.Raw, signer, recipient)
+}
+
+// NewCertPoll returns a CertPoll for the pending request csr sent with
+// the transaction of csr.
+func NewCertPoll(csr *x509.CertificateRequest, signer Signer, recipient *x509.Certificate) (*PKIMessage, error) {
+    content, err := asn1.Marshal(IssuerAndSubject{
+        Issuer:  asn1.RawValue{FullBytes: recipient.RawSubject},
+        Subject: asn1.RawValue{FullBytes: csr.RawSubject},
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return newRequest(CertPoll, TransactionID(csr), content, signer, recipient)
+}
+
+// NewGetCert returns a GetCert for the certificate with the serial
+// number issued by issuer.
+func NewGetCert(issuer *x509.Certificate, serial *big.Int, signer Signer, recipient *x509.Certificate) (*PKIMessage, error) {
+    content, err := asn1.Marshal(IssuerAndSerial{
+        Issuer:       asn1.RawValue{FullBytes: issuer.RawSubject},
+        SerialNumber: serial,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    tid, err := randomTransactionID()
+    if err != nil {
+        return nil, err
+    }
+
+    return newRequest(GetCert, tid, content, signer, recipient)
+}
+
+func newRequest(typ MessageType, tid string, content []byte, signer Signer, recipient *x509.Certificate) (*PKIMessage, error) {
+    nonce, err := newNonce()
+    if err != nil {
+        return nil, err
+    }
+
+    envelope, err := encrypt(content, recipient)
+    if err != nil {
+        return nil, err
+    }
+
+    msg := &PKIMessage{
+        TransactionID: tid,
+        MessageType:   typ,
+        SenderNonce:   nonce,
+        Signer:        signer.Certificate,
+        envelope:      envelope,
+    }
+
+    if msg.Raw, err = msg.sign(signer); err != nil {
+        return nil, err
+    }
+
+    return msg, nil
+}
+
+// Success returns the successful CertRep of the request m with the
+// certificates, encrypted for the signer of m.
+func (m *PKIMessage) Success(certs []*x509.Certificate, signer Signer) (*PKIMessage, error) {
+    var raw []byte
+    for _, cert := range certs {
+        raw = append(raw, cert.Raw...)
+    }
+
+    degenerate, err := pkcs7.DegenerateCertificate(raw)
+    if err != nil {
+        return nil, err
+    }
+
+    envelope, err := encrypt(degenerate, m.Signer)
+    if err != nil {
+        return nil, err
+    }
+
+    return m.reply(Success, "", "", envelope, signer)
+}
+
+// Fail returns the failed CertRep of the request m.
+func (m *PKIMessage) Fail(info FailInfo, text string, signer Signer) (*PKIMessage, error) {
+    return m.reply(Failure, info, text, nil, signer)
+}
+
+// Pending returns the pending CertRep of the request m.
+func (m *PKIMessage) Pending(signer Signer) (*PKIMessage, error) {
+    return m.reply(Pending, "", "", nil, signer)
+}
+
+func (m *PKIMessage) reply(status PKIStatus, info FailInfo, text string, envelope []byte, signer Signer) (*PKIMessage, error) {
+    nonce, err := newNonce()
+    if err != nil {
+        return nil, err
+    }
+
+    rep := &PKIMessage{
+        TransactionID:  m.TransactionID,
+        MessageType:    CertRep,
+        SenderNonce:    nonce,
+        RecipientNonce: m.SenderNonce,
+        PKIStatus:      status,
+        FailInfo:       info,
+        FailInfoText:   text,
+        Signer:         signer.Certificate,
+        envelope:       envelope,
+    }
+
+    if rep.Raw, err = rep.sign(signer); err != nil {
+        return nil, err
+    }
+
+    return rep, nil
+}
+
+// sign returns the pkiMessage, the envelope signed with the SCEP
+// attributes.
+func (m *PKIMessage) sign(signer Signer) ([]byte, error) {
+    attrs := []pkcs7.Attribute{
+        {Type: oidTransactionID, Value: m.TransactionID},
+        {Type: oidMessageType, Value: string(m.MessageType)},
+        {Type: oidSenderNonce, Value: m.SenderNonce},
+    }
+
+    if m.RecipientNonce != nil {
+        attrs = append(attrs, pkcs7.Attribute{Type: oidRecipientNonce, Value: m.RecipientNonce})
+    }
+
+    if m.PKIStatus != "" {
+        attrs = append(attrs, pkcs7.Attribute{Type: oidPKIStatus, Value: string(m.PKIStatus)})
+    }
+
+    if m.FailInfo != "" {
+        attrs = append(attrs, pkcs7.Attribute{Type: oidFailInfo, Value: string(m.FailInfo)})
+    }
+
+    if m.FailInfoText != "" {
+        attrs = append(attrs, pkcs7.Attribute{
+            Type:  oidFailInfoText,
+            Value: asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(m.FailInfoText)},
+        })
+    }
+
+    sd, err := pkcs7.NewSignedData(m.envelope)
+    if err != nil {
+        return nil, err
+    }
+
+    digestOid, encryptionOid, err := signingParams(signer.Key)
+    if err != nil {
+        return nil, err
+    }
+
+    sd.SetDigestAlgorithm(digestOid)
+    sd.SetEncryptionAlgorithm(encryptionOid)
+
+    err = sd.AddSigner(signer.Certificate, signer.Key, pkcs7.SignerInfoConfig{
+        ExtraSignedAttributes: attrs,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return sd.Finish()
+}
+
+// ParsePKIMessage parses a pkiMessage and verifies its signature. The
+// content is still encrypted, see Decrypt.
+func ParsePKIMessage(der []byte) (*PKIMessage, error) {
+    p7, err := pkcs7.Parse(der)
+    if err != nil {
+        return nil, err
+    }
+
+    signer := p7.GetOnlySigner()
+    if signer == nil {
+        return nil, errors.New("scep: message must have one signer with its certificate")
+    }
+
+    if err = p7.Verify(); err != nil {
+        return nil, err
+    }
+
+    msg := &PKIMessage{
+        Raw:      der,
+        Signer:   signer,
+        envelope: p7.Content,
+    }
+
+    var typ, status, info string
+    if err = p7.UnmarshalSignedAttribute(oidTransactionID, &msg.TransactionID); err != nil {
+        return nil, errors.New("scep: message has no transactionID")
+    }
+    if err = p7.UnmarshalSignedAttribute(oidMessageType, &typ); err != nil {
+        return nil, errors.New("scep: message has no messageType")
+    }
+    if err = p7.UnmarshalSignedAttribute(oidSenderNonce, &msg.SenderNonce); err != nil {
+        return nil, errors.New("scep: message has no senderNonce")
+    }
+
+    msg.MessageType = MessageType(typ)
+
+    switch msg.MessageType {
+        case CertRep:
+            if err = p7.UnmarshalSignedAttribute(oidRecipientNonce, &msg.RecipientNonce); err != nil {
+                return nil, errors.New("scep: CertRep has no recipientNonce")
+            }
+            if err = p7.UnmarshalSignedAttribute(oidPKIStatus, &status); err != nil {
+                return nil, errors.New("scep: CertRep has no pkiStatus")
+            }
+
+            msg.PKIStatus = PKIStatus(status)
+
+            switch msg.PKIStatus {
+                case Failure:
+                    if err = p7.UnmarshalSignedAttribute(oidFailInfo, &info); err != nil {
+                        return nil, errors.New("scep: failed CertRep has no failInfo")
+                    }
+
+                    msg.FailInfo = FailInfo(info)
+
+                    // optional
+                    p7.UnmarshalSignedAttribute(oidFailInfoText, &msg.FailInfoText)
+                case Success, Pending:
+                default:
+                    return nil, errors.New("scep: invalid pkiStatus " + status)
+            }
+        case PKCSReq, RenewalReq, CertPoll, GetCert, GetCRL:
+        default:
+            return nil, errors.New("scep: " + msg.MessageType.String())
+    }
+
+    return msg, nil
+}
+
+// Decrypt decrypts the pkcsPKIEnvelope with the certificate and the
+// key it is encrypted for, and parses the content.
+func (m *PKIMessage) Decrypt(cert *x509.Certificate, key crypto.PrivateKey) error {
+    if m.MessageType == CertRep && m.PKIStatus != Success {
+        return nil
+    }
+
+    content, err := pkcs7.Decrypt(m.envelope, cert, key)
+    if err != nil {
+        return err
+    }
+
+    switch m.MessageType {
+        case PKCSReq, RenewalReq:
+            csr, err := x509.ParseCertificateRequest(content)
+            if err != nil {
+                return err
+            }
+
+            if err = csr.CheckSignature(); err != nil {
+                return err
+            }
+
+            m.CSR = csr
+        case CertPoll:
+            m.IssuerAndSubject = new(IssuerAndSubject)
+            if _, err = asn1.Unmarshal(content, m.IssuerAndSubject); err != nil {
+                return err
+            }
+        case GetCert:
+            m.IssuerAndSerial = new(IssuerAndSerial)
+            if _, err = asn1.Unmarshal(content, m.IssuerAndSerial); err != nil {
+                return err
+            }
+        case CertRep:
+            p7, err := pkcs7.Parse(content)
+            if err != nil {
+                return err
+            }
+
+            m.Certificates = p7.Certificates
+        default:
+            return errors.New("scep: unsupported " + m.MessageType.String())
+    }
+
+    return nil
+}
+
+// TransactionID returns the transaction ID of the requests for csr,
+// the hexadecimal SHA-256 hash of its public key.
+func TransactionID(csr *x509.CertificateRequest) string {
+    h := sha256.Sum256(csr.RawSubjectPublicKeyInfo)
+    return hex.EncodeToString(h[:])
+}
+
+func randomTransactionID() (string, error) {
+    nonce, err := newNonce()
+    if err != nil {
+        return "", err
+    }
+
+    return hex.EncodeToString(nonce), nil
+}
+
+func newNonce() ([]byte, error) {
+    nonce := make([]byte, nonceSize)
+    if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+        return nil, err
+    }
+
+    return nonce, nil
+}
+
+// encrypt returns the pkcsPKIEnvelope of content for recipient, which
+// must have a RSA or SM2 key.
+func encrypt(content []byte, recipient *x509.Certificate) ([]byte, error) {
+    var opts pkcs7.Opts
+
+    switch recipient.PublicKey.(type) {
+        case *rsa.PublicKey:
+            opts = pkcs7.DefaultOpts
+        case *sm2.PublicKey:
+            opts = pkcs7.SM2Opts
+        default:
+            return nil, fmt.Errorf("scep: can not encrypt for a %T key", recipient.PublicKey)
+    }
+
+    return pkcs7.Encrypt(rand.Reader, content, []*x509.Certificate{recipient}, opts)
+}
+
+// signingParams returns the pkcs7 algorithms of the signatures of key.
+func signingParams(key crypto.PrivateKey) (digestOid, encryptionOid asn1.ObjectIdentifier, err error) {
+    switch key.(type) {
+        case *sm2.PrivateKey:
+            return pkcs7.OidDigestAlgorithmSM3, pkcs7.OidDigestEncryptionAlgorithmSM2, nil
+        case *rsa.PrivateKey:
+            return pkcs7.OidDigestAlgorithmSHA256, pkcs7.OidEncryptionAlgorithmRSA, nil
+        case *ecdsa.PrivateKey:
+            return pkcs7.OidDigestAlgorithmSHA256, pkcs7.OidEncryptionAlgorithmECDSASHA256, nil
+    }
+
+    return nil, nil, fmt.Errorf("scep: unsupported signer key %T", key)
+}
+
+type tbsCertificateRequest struct {
+    Version       int
+    Subject       asn1.RawValue
+    PublicKey     asn1.RawValue
+    RawAttributes []asn1.RawValue `asn1:"tag:0"`
+}
+
+type certificateRequest struct {
+    TBSCSR             asn1.RawValue
+    SignatureAlgorithm pkix.AlgorithmIdentifier
+    SignatureValue     asn1.BitString
+}
+
+type csrAttribute struct {
+    Type   asn1.ObjectIdentifier
+    Values []asn1.RawValue `asn1:"set"`
+}
+
+// CreateCertificateRequest creates a certificate request as
+// x509.CreateCertificateRequest with the challengePassword attribute,
+// RFC 2985, used to authorize a PKCSReq.
+func CreateCertificateRequest(random io.Reader, template *x509.CertificateRequest, challengePassword string, key crypto.Signer) ([]byte, error) {
+    tmpl := *template
+    if tmpl.SignatureAlgorithm == x509.UnknownSignatureAlgorithm {
+        tmpl.SignatureAlgorithm = signatureAlgorithm(key)
+    }
+
+    der, err := x509.CreateCertificateRequest(random, &tmpl, key)
+    if err != nil || challengePassword == "" {
+        return der, err
+    }
+
+    csr, err := x509.ParseCertificateRequest(der)
+    if err != nil {
+        return nil, err
+    }
+
+    var tbs tbsCertificateRequest
+    if _, err = asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+        return nil, err
+    }
+
+    attr, err := asn1.Marshal(csrAttribute{
+        Type:   oidChallengePassword,
+        Values: []asn1.RawValue{
+            {Tag: asn1.TagUTF8String, Bytes: []byte(challengePassword)},
+        },
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    tbs.RawAttributes = append(tbs.RawAttributes, asn1.RawValue{FullBytes: attr})
+
+    tbsDer, err := asn1.Marshal(tbs)
+    if err != nil {
+        return nil, err
+    }
+
+    sigAlgo, signature, err := x509.CreateSignature(random, key, tmpl.SignatureAlgorithm, tbsDer)
+    if err != nil {
+        return nil, err
+    }
+
+    return asn1.Marshal(certificateRequest{
+        TBSCSR:             asn1.RawValue{FullBytes: tbsDer},
+        SignatureAlgorithm: sigAlgo,
+        SignatureValue:     asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+    })
+}
+
+// ChallengePassword returns the challengePassword attribute of csr,
+// empty if it has none.
+func ChallengePassword(csr *x509.CertificateRequest) string {
+    var tbs tbsCertificateRequest
+    if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+        return ""
+    }
+
+    for _, raw := range tbs.RawAttributes {
+        var attr csrAttribute
+        if _, err := asn1.Unmarshal(raw.FullBytes, &attr); err != nil {
+            continue
+        }
+
+        if !attr.Type.Equal(oidChallengePassword) || len(attr.Values) != 1 {
+            continue
+        }
+
+        var password string
+        if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &password); err == nil {
+            return password
+        }
+    }
+
+    return ""
+}
+
+// signatureAlgorithm returns the default algorithm of the certificate
+// requests signed by key.
+func signatureAlgorithm(key crypto.Signer) x509.SignatureAlgorithm {
+    switch key.(type) {
+        case *rsa.PrivateKey:
+            return x509.SHA256WithRSA
+        case *ecdsa.PrivateKey:
+            return x509.ECDSAWithSHA256
+        case *sm2.PrivateKey:
+            return x509.SM2WithSM3
+    }
+
+    return x509.UnknownSignatureAlgorithm
+}
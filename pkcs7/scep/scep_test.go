@@ -0,0 +1,395 @@
+package scep
+
+import (
+    "time"
+    "errors"
+    "context"
+    "testing"
+    "net/http"
+    "net/http/httptest"
+    "crypto"
+    "crypto/rsa"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/cryptobin/ca/authority"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func newCSR(t *testing.T, key crypto.Signer, cn, password string) *x509.CertificateRequest {
+    der, err := CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+        Subject:  pkix.Name{
+            CommonName: cn,
+        },
+        DNSNames: []string{cn},
+    }, password, key)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    csr, err := x509.ParseCertificateRequest(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return csr
+}
+
+func newAuthority(t *testing.T) (*authority.Authority, Signer) {
+    key, err := sm2.GenerateKey(rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    ca, err := authority.NewRoot(pkix.Name{CommonName: "SCEP Root"}, key, 24 * time.Hour, authority.NewMemoryStore())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return ca, Signer{
+        Certificate: ca.Certificate(),
+        Key:         key,
+    }
+}
+
+type pollingCA struct {
+    *authority.Service
+    pending map[string]*x509.CertificateRequest
+    approve bool
+}
+
+func (ca *pollingCA) Enroll(ctx context.Context, csr *x509.CertificateRequest, label string) (*x509.Certificate, error) {
+    ca.pending[TransactionID(csr)] = csr
+    return nil, ErrPending
+}
+
+func (ca *pollingCA) Poll(ctx context.Context, transactionID string, names *IssuerAndSubject) (*x509.Certificate, error) {
+    csr, ok := ca.pending[transactionID]
+    if !ok {
+        return nil, errors.New("unknown transaction")
+    }
+
+    if !ca.approve {
+        return nil, ErrPending
+    }
+
+    return ca.Service.Enroll(ctx, csr, "")
+}
+
+func allowAll(r *http.Request, csr *x509.CertificateRequest, password string) error {
+    return nil
+}
+
+func Test_SCEP(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    ca, signer := newAuthority(t)
+
+    srv := httptest.NewServer(&Server{
+        CA:        authority.NewService(ca),
+        Signer:    signer,
+        Authorize: func(r *http.Request, csr *x509.CertificateRequest, password string) error {
+            if password != "secret" {
+                return errors.New("bad challenge password")
+            }
+
+            return nil
+        },
+    })
+    defer srv.Close()
+
+    client := &Client{
+        URL: srv.URL,
+    }
+
+    caps, err := client.GetCACaps(ctx)
+    assertError(err, "GetCACaps")
+    assertEqual(caps, DefaultCapabilities, "GetCACaps")
+
+    certs, err := client.GetCACert(ctx)
+    assertError(err, "GetCACert")
+    assertEqual(len(certs), 1, "GetCACert")
+    assertEqual(certs[0].Raw, ca.Certificate().Raw, "GetCACert")
+
+    recipient := certs[0]
+
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    assertError(err, "GenerateKey")
+
+    sm2Key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    for _, key := range []crypto.Signer{rsaKey, sm2Key} {
+        csr := newCSR(t, key, "device.example.com", "secret")
+        assertEqual(ChallengePassword(csr), "secret", "ChallengePassword")
+
+        self, err := NewSelfSignedSigner(csr, key)
+        assertError(err, "NewSelfSignedSigner")
+
+        cert, err := client.Enroll(ctx, csr, self, recipient)
+        assertError(err, "Enroll")
+        assertEqual(cert.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo, "Enroll")
+        assertError(cert.CheckSignatureFrom(ca.Certificate()), "Enroll")
+
+        // the renewal is signed with the issued certificate
+        current := Signer{Certificate: cert, Key: key}
+
+        renewed, err := client.Renew(ctx, newCSR(t, key, "device.example.com", ""), current, recipient)
+        assertError(err, "Renew")
+        assertEqual(renewed.RawSubjectPublicKeyInfo, cert.RawSubjectPublicKeyInfo, "Renew")
+        assertBool(renewed.SerialNumber.Cmp(cert.SerialNumber) != 0, "Renew")
+
+        got, err := client.GetCert(ctx, ca.Certificate(), renewed.SerialNumber, current, recipient)
+        assertError(err, "GetCert")
+        assertEqual(got.Raw, renewed.Raw, "GetCert")
+    }
+
+    // rejected challenge password
+    csr := newCSR(t, sm2Key, "device.example.com", "wrong")
+
+    self, err := NewSelfSignedSigner(csr, sm2Key)
+    assertError(err, "NewSelfSignedSigner")
+
+    _, err = client.Enroll(ctx, csr, self, recipient)
+
+    var fail *FailError
+    assertBool(errors.As(err, &fail), "Enroll password")
+    assertEqual(fail.FailInfo, BadRequest, "Enroll password")
+
+    // signed with another key
+    other, err := NewSelfSignedSigner(newCSR(t, rsaKey, "other", ""), rsaKey)
+    assertError(err, "NewSelfSignedSigner")
+
+    _, err = client.Enroll(ctx, newCSR(t, sm2Key, "device.example.com", "secret"), other, recipient)
+    assertBool(errors.As(err, &fail), "Enroll signer")
+    assertEqual(fail.FailInfo, BadMessageCheck, "Enroll signer")
+
+    // unknown certificate
+    _, err = client.GetCert(ctx, ca.Certificate(), self.Certificate.SerialNumber, self, recipient)
+    assertBool(errors.As(err, &fail), "GetCert unknown")
+    assertEqual(fail.FailInfo, BadCertID, "GetCert unknown")
+
+    // ECDSA requesters can not be answered with an envelope
+    ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    ecCSR := newCSR(t, ecKey, "device.example.com", "secret")
+
+    ecSelf, err := NewSelfSignedSigner(ecCSR, ecKey)
+    assertError(err, "NewSelfSignedSigner")
+
+    _, err = client.Enroll(ctx, ecCSR, ecSelf, recipient)
+    assertBool(errors.As(err, &fail), "Enroll ECDSA")
+    assertEqual(fail.FailInfo, BadAlg, "Enroll ECDSA")
+}
+
+func Test_Pending(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    ca, signer := newAuthority(t)
+
+    pca := &pollingCA{
+        Service: authority.NewService(ca),
+        pending: make(map[string]*x509.CertificateRequest),
+    }
+
+    srv := httptest.NewServer(&Server{
+        CA:           pca,
+        Signer:       signer,
+        Capabilities: []string{"POSTPKIOperation", "SHA-256"},
+        Authorize:    allowAll,
+    })
+    defer srv.Close()
+
+    client := &Client{
+        URL: srv.URL + "/scep?profile=server",
+    }
+
+    caps, err := client.GetCACaps(ctx)
+    assertError(err, "GetCACaps")
+    assertEqual(caps, []string{"POSTPKIOperation", "SHA-256"}, "GetCACaps")
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    csr := newCSR(t, key, "device.example.com", "")
+
+    self, err := NewSelfSignedSigner(csr, key)
+    assertError(err, "NewSelfSignedSigner")
+
+    _, err = client.Enroll(ctx, csr, self, signer.Certificate)
+
+    var pending *PendingError
+    assertBool(errors.As(err, &pending), "Enroll pending")
+    assertEqual(pending.TransactionID, TransactionID(csr), "Enroll pending")
+
+    _, err = client.Poll(ctx, csr, self, signer.Certificate)
+    assertBool(errors.As(err, &pending), "Poll pending")
+
+    pca.approve = true
+
+    cert, err := client.Poll(ctx, csr, self, signer.Certificate)
+    assertError(err, "Poll")
+    assertEqual(cert.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo, "Poll")
+
+    got, err := client.GetCert(ctx, ca.Certificate(), cert.SerialNumber, self, signer.Certificate)
+    assertError(err, "GetCert")
+    assertEqual(got.Raw, cert.Raw, "GetCert")
+
+    // unknown transaction
+    otherKey, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    _, err = client.Poll(ctx, newCSR(t, otherKey, "device.example.com", ""), self, signer.Certificate)
+
+    var fail *FailError
+    assertBool(errors.As(err, &fail), "Poll unknown")
+}
+
+func Test_RASigner(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    ctx := context.Background()
+
+    ca, _ := newAuthority(t)
+
+    // a RA certificate issued by the CA
+    raKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    assertError(err, "GenerateKey")
+
+    raCert, err := ca.Issue(newCSR(t, raKey, "ra.example.com", ""), "server")
+    assertError(err, "Issue")
+
+    srv := httptest.NewServer(&Server{
+        CA:        authority.NewService(ca),
+        Signer:    Signer{Certificate: raCert, Key: raKey},
+        Authorize: allowAll,
+    })
+    defer srv.Close()
+
+    client := &Client{
+        URL: srv.URL,
+    }
+
+    certs, err := client.GetCACert(ctx)
+    assertError(err, "GetCACert")
+    assertEqual(len(certs), 2, "GetCACert")
+    assertEqual(certs[0].Raw, raCert.Raw, "GetCACert")
+    assertEqual(certs[1].Raw, ca.Certificate().Raw, "GetCACert")
+
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    assertError(err, "GenerateKey")
+
+    csr := newCSR(t, key, "device.example.com", "")
+
+    self, err := NewSelfSignedSigner(csr, key)
+    assertError(err, "NewSelfSignedSigner")
+
+    cert, err := client.Enroll(ctx, csr, self, certs[0])
+    assertError(err, "Enroll")
+    assertError(cert.CheckSignatureFrom(ca.Certificate()), "Enroll")
+}
+
+func Test_NoAuthorize(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ca, signer := newAuthority(t)
+
+    srv := httptest.NewServer(&Server{
+        CA:     authority.NewService(ca),
+        Signer: signer,
+    })
+    defer srv.Close()
+
+    client := &Client{
+        URL: srv.URL,
+    }
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    csr := newCSR(t, key, "device.example.com", "secret")
+
+    self, err := NewSelfSignedSigner(csr, key)
+    assertError(err, "NewSelfSignedSigner")
+
+    _, err = client.Enroll(context.Background(), csr, self, signer.Certificate)
+
+    var fail *FailError
+    assertBool(errors.As(err, &fail), "Enroll without Authorize")
+    assertEqual(fail.FailInfo, BadRequest, "Enroll without Authorize")
+}
+
+func Test_ParsePKIMessage(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    _, signer := newAuthority(t)
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    csr := newCSR(t, key, "device.example.com", "secret")
+
+    self, err := NewSelfSignedSigner(csr, key)
+    assertError(err, "NewSelfSignedSigner")
+
+    msg, err := NewPKCSReq(csr, self, signer.Certificate)
+    assertError(err, "NewPKCSReq")
+
+    parsed, err := ParsePKIMessage(msg.Raw)
+    assertError(err, "ParsePKIMessage")
+    assertEqual(parsed.MessageType, PKCSReq, "ParsePKIMessage")
+    assertEqual(parsed.TransactionID, TransactionID(csr), "ParsePKIMessage")
+    assertEqual(parsed.SenderNonce, msg.SenderNonce, "ParsePKIMessage")
+    assertEqual(parsed.Signer.Raw, self.Certificate.Raw, "ParsePKIMessage")
+
+    // only the recipient decrypts
+    assertBool(parsed.Decrypt(self.Certificate, key) != nil, "Decrypt")
+
+    assertError(parsed.Decrypt(signer.Certificate, signer.Key), "Decrypt")
+    assertEqual(parsed.CSR.Raw, csr.Raw, "Decrypt")
+
+    rep, err := parsed.Pending(signer)
+    assertError(err, "Pending")
+
+    parsedRep, err := ParsePKIMessage(rep.Raw)
+    assertError(err, "ParsePKIMessage")
+    assertEqual(parsedRep.MessageType, CertRep, "ParsePKIMessage")
+    assertEqual(parsedRep.PKIStatus, Pending, "ParsePKIMessage")
+    assertEqual(parsedRep.RecipientNonce, msg.SenderNonce, "ParsePKIMessage")
+
+    rep, err = parsed.Fail(BadTime, "clock skew", signer)
+    assertError(err, "Fail")
+
+    parsedRep, err = ParsePKIMessage(rep.Raw)
+    assertError(err, "ParsePKIMessage")
+    assertEqual(parsedRep.FailInfo, BadTime, "ParsePKIMessage")
+    assertEqual(parsedRep.FailInfoText, "clock skew", "ParsePKIMessage")
+
+    // tampered messages fail the signature check
+    tampered := append([]byte{}, msg.Raw...)
+    tampered[len(tampered) - 10] ^= 0xff
+
+    _, err = ParsePKIMessage(tampered)
+    assertBool(err != nil, "ParsePKIMessage tampered")
+
+    _, err = ParsePKIMessage([]byte("scep"))
+    assertBool(err != nil, "ParsePKIMessage invalid")
+}
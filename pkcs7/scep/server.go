@@ -0,0 +1,247 @@
+package scep
+
+import (
+    "io"
+    "bytes"
+    "errors"
+    "context"
+    "strings"
+    "math/big"
+    "net/http"
+    "encoding/base64"
+
+    "github.com/deatil/go-cryptobin/pkcs7"
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// ErrPending is returned by a CA that can not issue a certificate
+// right away. The request is answered with a pending CertRep and the
+// client polls with CertPoll.
+var ErrPending = errors.New("scep: request pending")
+
+// DefaultCapabilities are the capabilities of a Server without
+// Capabilities.
+var DefaultCapabilities = []string{
+    "POSTPKIOperation",
+    "Renewal",
+    "SHA-256",
+    "AES",
+    "SCEPStandard",
+}
+
+// maxMessageSize limits the size of the request messages.
+const maxMessageSize = 1 << 20
+
+// CA issues the certificates of a SCEP server. The label is the label
+// of the Server.
+type CA interface {
+    // CACerts returns the current CA certificates, the issuing CA
+    // first.
+    CACerts(ctx context.Context, label string) ([]*x509.Certificate, error)
+
+    // Enroll issues a certificate for csr, or returns ErrPending.
+    Enroll(ctx context.Context, csr *x509.CertificateRequest, label string) (*x509.Certificate, error)
+
+    // Reenroll issues a certificate for csr renewing cert, the
+    // certificate that signed the RenewalReq.
+    Reenroll(ctx context.Context, cert *x509.Certificate, csr *x509.CertificateRequest, label string) (*x509.Certificate, error)
+}
+
+// CertificateCA is implemented by the CAs answering GetCert.
+type CertificateCA interface {
+    // Certificate returns the issued certificate with the serial
+    // number.
+    Certificate(ctx context.Context, serial *big.Int) (*x509.Certificate, error)
+}
+
+// PollingCA is implemented by the CAs returning ErrPending.
+type PollingCA interface {
+    // Poll returns the certificate of the pending transaction, or
+    // ErrPending.
+    Poll(ctx context.Context, transactionID string, names *IssuerAndSubject) (*x509.Certificate, error)
+}
+
+// Server is a SCEP server, an http.Handler for the GetCACaps,
+// GetCACert and PKIOperation operations.
+type Server struct {
+    // CA issues the certificates.
+    CA CA
+
+    // Signer is the CA or RA certificate and key, the requests are
+    // encrypted for it and the responses are signed with it. It must
+    // have a RSA or SM2 key.
+    Signer Signer
+
+    // Label is passed to the CA.
+    Label string
+
+    // Capabilities are returned by GetCACaps, DefaultCapabilities if
+    // nil.
+    Capabilities []string
+
+    // Authorize authorizes the PKCSReq requests, with the challenge
+    // password of the request. An error fails the request. If nil, the
+    // PKCSReq requests fail with BadRequest.
+    Authorize func(r *http.Request, csr *x509.CertificateRequest, challengePassword string) error
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    switch r.URL.Query().Get("operation") {
+        case "GetCACaps":
+            caps := s.Capabilities
+            if caps == nil {
+                caps = DefaultCapabilities
+            }
+
+            w.Header().Set("Content-Type", "text/plain")
+            w.WriteHeader(http.StatusOK)
+            io.WriteString(w, strings.Join(caps, "\n"))
+        case "GetCACert":
+            s.getCACert(w, r)
+        case "PKIOperation":
+            s.pkiOperation(w, r)
+        default:
+            http.Error(w, "scep: unknown operation", http.StatusBadRequest)
+    }
+}
+
+func (s *Server) getCACert(w http.ResponseWriter, r *http.Request) {
+    certs, err := s.CA.CACerts(r.Context(), s.Label)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    // the RA certificate goes first
+    if len(certs) == 0 || !bytes.Equal(certs[0].Raw, s.Signer.Certificate.Raw) {
+        certs = append([]*x509.Certificate{s.Signer.Certificate}, certs...)
+    }
+
+    if len(certs) == 1 {
+        w.Header().Set("Content-Type", "application/x-x509-ca-cert")
+        w.WriteHeader(http.StatusOK)
+        w.Write(certs[0].Raw)
+        return
+    }
+
+    var raw []byte
+    for _, cert := range certs {
+        raw = append(raw, cert.Raw...)
+    }
+
+    der, err := pkcs7.DegenerateCertificate(raw)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/x-x509-ca-ra-cert")
+    w.WriteHeader(http.StatusOK)
+    w.Write(der)
+}
+
+func (s *Server) pkiOperation(w http.ResponseWriter, r *http.Request) {
+    var der []byte
+    var err error
+
+    switch r.Method {
+        case http.MethodPost:
+            der, err = io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+        case http.MethodGet:
+            der, err = base64.StdEncoding.DecodeString(r.URL.Query().Get("message"))
+        default:
+            w.WriteHeader(http.StatusMethodNotAllowed)
+            return
+    }
+
+    if err != nil {
+        http.Error(w, "scep: invalid message", http.StatusBadRequest)
+        return
+    }
+
+    msg, err := ParsePKIMessage(der)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    rep, err := s.respond(r, msg)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/x-pki-message")
+    w.WriteHeader(http.StatusOK)
+    w.Write(rep.Raw)
+}
+
+// respond returns the CertRep of msg.
+func (s *Server) respond(r *http.Request, msg *PKIMessage) (*PKIMessage, error) {
+    if err := msg.Decrypt(s.Signer.Certificate, s.Signer.Key); err != nil {
+        return msg.Fail(BadMessageCheck, "can not decrypt the message", s.Signer)
+    }
+
+    ctx := r.Context()
+
+    var cert *x509.Certificate
+    var err error
+
+    switch msg.MessageType {
+        case PKCSReq:
+            // signed with the key of the request
+            if !bytes.Equal(msg.Signer.RawSubjectPublicKeyInfo, msg.CSR.RawSubjectPublicKeyInfo) {
+                return msg.Fail(BadMessageCheck, "request not signed by its key", s.Signer)
+            }
+
+            if s.Authorize == nil {
+                return msg.Fail(BadRequest, "enrollment is not authorized", s.Signer)
+            }
+
+            if err = s.Authorize(r, msg.CSR, ChallengePassword(msg.CSR)); err != nil {
+                return msg.Fail(BadRequest, err.Error(), s.Signer)
+            }
+
+            cert, err = s.CA.Enroll(ctx, msg.CSR, s.Label)
+        case RenewalReq:
+            if !bytes.Equal(msg.Signer.RawSubject, msg.CSR.RawSubject) {
+                return msg.Fail(BadRequest, "subject does not match the certificate", s.Signer)
+            }
+
+            cert, err = s.CA.Reenroll(ctx, msg.Signer, msg.CSR, s.Label)
+        case CertPoll:
+            ca, ok := s.CA.(PollingCA)
+            if !ok {
+                return msg.Fail(BadRequest, "no pending request", s.Signer)
+            }
+
+            cert, err = ca.Poll(ctx, msg.TransactionID, msg.IssuerAndSubject)
+        case GetCert:
+            ca, ok := s.CA.(CertificateCA)
+            if !ok {
+                return msg.Fail(BadRequest, "GetCert is not supported", s.Signer)
+            }
+
+            cert, err = ca.Certificate(ctx, msg.IssuerAndSerial.SerialNumber)
+            if err != nil || !bytes.Equal(cert.RawIssuer, msg.IssuerAndSerial.Issuer.FullBytes) {
+                return msg.Fail(BadCertID, "unknown certificate", s.Signer)
+            }
+        default:
+            return msg.Fail(BadRequest, msg.MessageType.String() + " is not supported", s.Signer)
+    }
+
+    switch {
+        case errors.Is(err, ErrPending):
+            return msg.Pending(s.Signer)
+        case err != nil:
+            return msg.Fail(BadRequest, err.Error(), s.Signer)
+    }
+
+    rep, err := msg.Success([]*x509.Certificate{cert}, s.Signer)
+    if err != nil {
+        return msg.Fail(BadAlg, err.Error(), s.Signer)
+    }
+
+    return rep, nil
+}
@@ -0,0 +1,125 @@
+package pkcs7
+
+import (
+    "errors"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/ber"
+)
+
+// id-aa-timeStampToken, RFC 3161 Appendix A
+var oidAttributeTimeStampToken = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+
+// AddTimestampToken adds an RFC 3161 time-stamp token as unsigned attribute
+// to the signer at signerIndex. The token must be a time-stamp over the
+// signature value of that signer, see SignerSignature.
+//
+// This must be called after the signer is added and before Finish()
+func (this *SignedData) AddTimestampToken(signerIndex int, token []byte) error {
+    if signerIndex < 0 || signerIndex >= len(this.sd.SignerInfos) {
+        return errors.New("pkcs7: signer index out of range")
+    }
+
+    return this.sd.SignerInfos[signerIndex].addTimestampToken(token)
+}
+
+// SignerSignature returns the signature value of the signer at signerIndex,
+// the data a time-stamp token for that signer is requested for.
+func (this *SignedData) SignerSignature(signerIndex int) ([]byte, error) {
+    if signerIndex < 0 || signerIndex >= len(this.sd.SignerInfos) {
+        return nil, errors.New("pkcs7: signer index out of range")
+    }
+
+    return this.sd.SignerInfos[signerIndex].EncryptedDigest, nil
+}
+
+// AttachTimestampToken adds an RFC 3161 time-stamp token as unsigned
+// attribute to the signer at signerIndex of the DER encoded SignedData
+// and returns the new encoding. The signatures are left untouched.
+func AttachTimestampToken(data []byte, signerIndex int, token []byte) ([]byte, error) {
+    der, err := ber.Ber2der(data)
+    if err != nil {
+        return nil, err
+    }
+
+    var info contentInfo
+    rest, err := asn1.Unmarshal(der, &info)
+    if err != nil {
+        return nil, err
+    }
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    if !info.ContentType.Equal(oidSignedData) &&
+        !info.ContentType.Equal(oidSM2SignedData) {
+        return nil, ErrUnsupportedContentType
+    }
+
+    var sd signedData
+    if _, err = asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+        return nil, err
+    }
+
+    if signerIndex < 0 || signerIndex >= len(sd.SignerInfos) {
+        return nil, errors.New("pkcs7: signer index out of range")
+    }
+
+    err = sd.SignerInfos[signerIndex].addTimestampToken(token)
+    if err != nil {
+        return nil, err
+    }
+
+    inner, err := asn1.Marshal(sd)
+    if err != nil {
+        return nil, err
+    }
+
+    outer := contentInfo{
+        ContentType: info.ContentType,
+        Content:     asn1.RawValue{
+            Class: 2,
+            Tag: 0,
+            Bytes: inner,
+            IsCompound: true,
+        },
+    }
+
+    return asn1.Marshal(outer)
+}
+
+// GetTimestampToken returns the RFC 3161 time-stamp token of the signer
+// at signerIndex, and the signature value the token is over.
+func (this *PKCS7) GetTimestampToken(signerIndex int) (token []byte, signature []byte, err error) {
+    if signerIndex < 0 || signerIndex >= len(this.Signers) {
+        return nil, nil, errors.New("pkcs7: signer index out of range")
+    }
+
+    signer := this.Signers[signerIndex]
+
+    var raw asn1.RawValue
+    err = unmarshalAttribute(signer.UnauthenticatedAttributes, oidAttributeTimeStampToken, &raw)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return raw.FullBytes, signer.EncryptedDigest, nil
+}
+
+func (this *signerInfo) addTimestampToken(token []byte) error {
+    var raw asn1.RawValue
+    rest, err := asn1.Unmarshal(token, &raw)
+    if err != nil {
+        return err
+    }
+    if len(rest) > 0 {
+        return errors.New("pkcs7: trailing data after time-stamp token")
+    }
+
+    this.UnauthenticatedAttributes = append(this.UnauthenticatedAttributes, attribute{
+        Type:  oidAttributeTimeStampToken,
+        Value: asn1.RawValue{Tag: 17, IsCompound: true, Bytes: token}, // 17 == SET tag
+    })
+
+    return nil
+}
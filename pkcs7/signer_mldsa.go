@@ -0,0 +1,90 @@
+package pkcs7
+
+import (
+    "errors"
+    "crypto"
+    "crypto/rand"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+// ML-DSA 签名, RFC 9882
+// 使用 pure ML-DSA 直接签名数据, context 为空
+type KeySignWithMLDSA struct {
+    hashFunc   crypto.Hash
+    hashId     asn1.ObjectIdentifier
+    identifier asn1.ObjectIdentifier
+}
+
+// oid
+func (this KeySignWithMLDSA) HashOID() asn1.ObjectIdentifier {
+    return this.hashId
+}
+
+// oid
+func (this KeySignWithMLDSA) OID() asn1.ObjectIdentifier {
+    return this.identifier
+}
+
+// 签名
+func (this KeySignWithMLDSA) Sign(pkey crypto.PrivateKey, data []byte) ([]byte, []byte, error) {
+    var priv *mldsa.PrivateKey
+    var ok bool
+
+    if priv, ok = pkey.(*mldsa.PrivateKey); !ok {
+        return nil, nil, errors.New("pkcs7: PrivateKey is not mldsa PrivateKey")
+    }
+
+    if !this.checkParams(priv.Params) {
+        return nil, nil, errors.New("pkcs7: mldsa PrivateKey params not match")
+    }
+
+    signData, err := priv.Sign(rand.Reader, data, crypto.Hash(0))
+    if err != nil {
+        return nil, nil, err
+    }
+
+    hashData := hashSignData(this.hashFunc, data)
+
+    return hashData, signData, nil
+}
+
+// 验证
+func (this KeySignWithMLDSA) Verify(pkey crypto.PublicKey, signed []byte, signature []byte) (bool, error) {
+    var pub *mldsa.PublicKey
+    var ok bool
+
+    if pub, ok = pkey.(*mldsa.PublicKey); !ok {
+        return false, errors.New("pkcs7: PublicKey is not mldsa PublicKey")
+    }
+
+    if !this.checkParams(pub.Params) {
+        return false, errors.New("pkcs7: mldsa PublicKey params not match")
+    }
+
+    return mldsa.Verify(pub, signed, signature), nil
+}
+
+// 检测证书
+func (this KeySignWithMLDSA) Check(pkey any) bool {
+    if priv, ok := pkey.(*mldsa.PrivateKey); ok {
+        return this.checkParams(priv.Params)
+    }
+
+    if pub, ok := pkey.(*mldsa.PublicKey); ok {
+        return this.checkParams(pub.Params)
+    }
+
+    return false
+}
+
+// 检测参数与 oid 是否匹配
+func (this KeySignWithMLDSA) checkParams(params *mldsa.Params) bool {
+    oid, err := mldsa.OIDFromParams(params)
+    if err != nil {
+        return false
+    }
+
+    return oid.Equal(this.identifier)
+}
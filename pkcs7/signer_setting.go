@@ -49,6 +49,11 @@ var (
     // sm9 签名
     OidDigestAlgorithmSM9SM3 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 502}
     OidDigestEncryptionAlgorithmSM9 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302, 1}
+
+    // ml-dsa 签名
+    OidEncryptionAlgorithmMLDSA44 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 17}
+    OidEncryptionAlgorithmMLDSA65 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 18}
+    OidEncryptionAlgorithmMLDSA87 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 19}
 )
 
 var KeySignWithDSASHA1 = KeySignWithDSA{
@@ -141,6 +146,22 @@ var KeySignWithSM2WithSM3 = KeySignWithSM2{
     identifier: OidDigestEncryptionAlgorithmSM2,
 }
 
+var KeySignWithMLDSA44 = KeySignWithMLDSA{
+    hashFunc:   crypto.SHA512,
+    hashId:     OidDigestAlgorithmSHA512,
+    identifier: OidEncryptionAlgorithmMLDSA44,
+}
+var KeySignWithMLDSA65 = KeySignWithMLDSA{
+    hashFunc:   crypto.SHA512,
+    hashId:     OidDigestAlgorithmSHA512,
+    identifier: OidEncryptionAlgorithmMLDSA65,
+}
+var KeySignWithMLDSA87 = KeySignWithMLDSA{
+    hashFunc:   crypto.SHA512,
+    hashId:     OidDigestAlgorithmSHA512,
+    identifier: OidEncryptionAlgorithmMLDSA87,
+}
+
 func init() {
     // DSA
     AddKeySign(OidEncryptionAlgorithmDSASHA1, func() KeySign {
@@ -202,5 +223,16 @@ func init() {
     AddKeySign(oidDigestEncryptionAlgorithmSM2, func() KeySign {
         return KeySignWithSM2WithSM3
     })
+
+    // ML-DSA
+    AddKeySign(OidEncryptionAlgorithmMLDSA44, func() KeySign {
+        return KeySignWithMLDSA44
+    })
+    AddKeySign(OidEncryptionAlgorithmMLDSA65, func() KeySign {
+        return KeySignWithMLDSA65
+    })
+    AddKeySign(OidEncryptionAlgorithmMLDSA87, func() KeySign {
+        return KeySignWithMLDSA87
+    })
 }
 
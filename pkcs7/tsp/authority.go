@@ -0,0 +1,359 @@
+package tsp
+
+import (
+    "io"
+    "time"
+    "errors"
+    "math/big"
+    "net/http"
+    "crypto"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/pkcs7"
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+// Authority is an in-process time-stamping authority. It issues
+// time-stamp tokens as pkcs7 SignedData with TSTInfo content, and is an
+// http.Handler for time-stamp requests sent by POST, RFC 3161 section
+// 3.4.
+type Authority struct {
+    // Certificate is the TSA certificate, it must have the time
+    // stamping extended key usage.
+    Certificate *x509.Certificate
+
+    // Certificates are the intermediates included in the tokens when
+    // the certificate is requested.
+    Certificates []*x509.Certificate
+
+    // Signer is the private key of Certificate.
+    Signer crypto.PrivateKey
+
+    // Policy is the TSA policy of the tokens.
+    Policy asn1.ObjectIdentifier
+
+    // Policies are other policies the requests may ask for.
+    Policies []asn1.ObjectIdentifier
+
+    // Accuracy of the time-stamps, if not zero.
+    Accuracy time.Duration
+
+    // Ordering is set in the tokens.
+    Ordering bool
+
+    // Hashes are the accepted message imprint hashes. All the hashes
+    // known to the package are accepted if empty.
+    Hashes []x509.Hash
+
+    // DigestAlgorithm and EncryptionAlgorithm are the pkcs7 signing
+    // algorithms. If nil, they are chosen from the type of Signer: RSA
+    // and ECDSA use SHA-256, SM2 uses SM3.
+    DigestAlgorithm     asn1.ObjectIdentifier
+    EncryptionAlgorithm asn1.ObjectIdentifier
+
+    // SerialNumber returns the serial number of a new token, a random
+    // 128 bits number if nil.
+    SerialNumber func() (*big.Int, error)
+
+    // Now returns the current time, time.Now if nil.
+    Now func() time.Time
+}
+
+// ServeHTTP implements http.Handler.
+func (a *Authority) ServeHTTP(w http.ResponseWriter, hreq *http.Request) {
+    if hreq.Method != http.MethodPost {
+        w.WriteHeader(http.StatusMethodNotAllowed)
+        return
+    }
+
+    if hreq.Header.Get("Content-Type") != "application/timestamp-query" {
+        w.WriteHeader(http.StatusUnsupportedMediaType)
+        return
+    }
+
+    der, err := io.ReadAll(io.LimitReader(hreq.Body, 1 << 16))
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        return
+    }
+
+    var resp []byte
+
+    req, err := ParseRequest(der)
+    if err != nil {
+        resp = rejection(BadDataFormat)
+    } else {
+        resp = a.Respond(req)
+    }
+
+    w.Header().Set("Content-Type", "application/timestamp-reply")
+    w.WriteHeader(http.StatusOK)
+    w.Write(resp)
+}
+
+// Respond returns the DER-encoded time-stamp response to req.
+func (a *Authority) Respond(req *Request) []byte {
+    token, err := a.CreateToken(req)
+    if err != nil {
+        var failErr failureError
+        if errors.As(err, &failErr) {
+            return rejection(failErr.info)
+        }
+
+        return rejection(SystemFailure)
+    }
+
+    resp, err := asn1.Marshal(timeStampResp{
+        Status:         pkiStatusInfo{
+            Status: int(Granted),
+        },
+        TimeStampToken: asn1.RawValue{FullBytes: token},
+    })
+    if err != nil {
+        return rejection(SystemFailure)
+    }
+
+    return resp
+}
+
+// CreateToken returns a time-stamp token for req.
+func (a *Authority) CreateToken(req *Request) ([]byte, error) {
+    if !a.acceptsHash(req.HashAlgorithm) ||
+        len(req.HashedMessage) != req.HashAlgorithm.Size() {
+        return nil, failureError{BadAlgorithm}
+    }
+
+    policy := a.Policy
+    if req.Policy != nil {
+        if !a.acceptsPolicy(req.Policy) {
+            return nil, failureError{UnacceptedPolicy}
+        }
+
+        policy = req.Policy
+    }
+
+    if len(policy) == 0 {
+        return nil, errors.New("tsp: authority has no policy")
+    }
+
+    if len(req.Extensions) > 0 {
+        return nil, failureError{UnacceptedExtension}
+    }
+
+    serial, err := a.serialNumber()
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now
+    if a.Now != nil {
+        now = a.Now
+    }
+
+    info := tstInfo{
+        Version:        1,
+        Policy:         policy,
+        MessageImprint: messageImprint{
+            HashAlgorithm: pkix.AlgorithmIdentifier{
+                Algorithm:  getOIDFromHashAlgorithm(req.HashAlgorithm),
+                Parameters: asn1.NullRawValue,
+            },
+            HashedMessage: req.HashedMessage,
+        },
+        SerialNumber:   serial,
+        GenTime:        now().UTC().Truncate(time.Second),
+        Accuracy:       newAccuracy(a.Accuracy),
+        Ordering:       a.Ordering,
+        Nonce:          req.Nonce,
+    }
+
+    content, err := asn1.Marshal(info)
+    if err != nil {
+        return nil, err
+    }
+
+    digestOid, encryptionOid, hashFunc, err := a.signingParams()
+    if err != nil {
+        return nil, err
+    }
+
+    signCert, err := a.signingCertificate(hashFunc, digestOid)
+    if err != nil {
+        return nil, err
+    }
+
+    sd, err := pkcs7.NewSignedData(content)
+    if err != nil {
+        return nil, err
+    }
+
+    sd.SetContentType(oidTSTInfo)
+    sd.SetDigestAlgorithm(digestOid)
+    sd.SetEncryptionAlgorithm(encryptionOid)
+
+    err = sd.AddSigner(a.Certificate, a.Signer, pkcs7.SignerInfoConfig{
+        ExtraSignedAttributes: []pkcs7.Attribute{
+            {
+                Type:  oidAttributeSigningCertificateV2,
+                Value: signCert,
+            },
+        },
+        SkipCertificates: !req.CertReq,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if req.CertReq {
+        for _, cert := range a.Certificates {
+            sd.AddCertificate(cert)
+        }
+    }
+
+    return sd.Finish()
+}
+
+// signingParams returns the pkcs7 algorithms used to sign the tokens.
+func (a *Authority) signingParams() (digestOid, encryptionOid asn1.ObjectIdentifier, hashFunc x509.Hash, err error) {
+    switch a.Signer.(type) {
+        case *sm2.PrivateKey:
+            digestOid = pkcs7.OidDigestAlgorithmSM3
+            encryptionOid = pkcs7.OidDigestEncryptionAlgorithmSM2
+        case *rsa.PrivateKey:
+            digestOid = pkcs7.OidDigestAlgorithmSHA256
+            encryptionOid = pkcs7.OidEncryptionAlgorithmRSA
+        case *ecdsa.PrivateKey:
+            digestOid = pkcs7.OidDigestAlgorithmSHA256
+            encryptionOid = pkcs7.OidEncryptionAlgorithmECDSASHA256
+    }
+
+    if a.DigestAlgorithm != nil {
+        digestOid = a.DigestAlgorithm
+    }
+    if a.EncryptionAlgorithm != nil {
+        encryptionOid = a.EncryptionAlgorithm
+    }
+
+    if digestOid == nil || encryptionOid == nil {
+        return nil, nil, 0, errors.New("tsp: unsupported signer key")
+    }
+
+    hashFunc = getHashAlgorithmFromOID(digestOid)
+    if hashFunc == 0 || !hashFunc.Available() {
+        return nil, nil, 0, errors.New("tsp: unsupported digest algorithm")
+    }
+
+    return
+}
+
+// signingCertificate returns the SigningCertificateV2 attribute of the
+// TSA certificate, RFC 5816.
+func (a *Authority) signingCertificate(hashFunc x509.Hash, hashOid asn1.ObjectIdentifier) (signingCertificateV2, error) {
+    if a.Certificate == nil {
+        return signingCertificateV2{}, errors.New("tsp: authority has no certificate")
+    }
+
+    h := hashFunc.New()
+    h.Write(a.Certificate.Raw)
+
+    certID := essCertIDv2{
+        CertHash:     h.Sum(nil),
+        IssuerSerial: issuerSerial{
+            Issuer:       []asn1.RawValue{
+                {
+                    Class:      asn1.ClassContextSpecific,
+                    Tag:        4,
+                    IsCompound: true,
+                    Bytes:      a.Certificate.RawIssuer,
+                },
+            },
+            SerialNumber: a.Certificate.SerialNumber,
+        },
+    }
+
+    // SHA-256 is the DEFAULT and is not encoded
+    if hashFunc != x509.SHA256 {
+        certID.HashAlgorithm = pkix.AlgorithmIdentifier{
+            Algorithm: hashOid,
+        }
+    }
+
+    return signingCertificateV2{
+        Certs: []essCertIDv2{certID},
+    }, nil
+}
+
+func (a *Authority) acceptsHash(hashFunc x509.Hash) bool {
+    if getOIDFromHashAlgorithm(hashFunc) == nil || !hashFunc.Available() {
+        return false
+    }
+
+    if len(a.Hashes) == 0 {
+        return true
+    }
+
+    for _, h := range a.Hashes {
+        if h == hashFunc {
+            return true
+        }
+    }
+
+    return false
+}
+
+func (a *Authority) acceptsPolicy(policy asn1.ObjectIdentifier) bool {
+    if policy.Equal(a.Policy) {
+        return true
+    }
+
+    for _, p := range a.Policies {
+        if policy.Equal(p) {
+            return true
+        }
+    }
+
+    return false
+}
+
+func (a *Authority) serialNumber() (*big.Int, error) {
+    if a.SerialNumber != nil {
+        return a.SerialNumber()
+    }
+
+    return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func newAccuracy(d time.Duration) accuracy {
+    return accuracy{
+        Seconds: int(d / time.Second),
+        Millis:  int(d % time.Second / time.Millisecond),
+        Micros:  int(d % time.Millisecond / time.Microsecond),
+    }
+}
+
+// failureError is returned by CreateToken for requests the authority
+// rejects.
+type failureError struct {
+    info FailureInfo
+}
+
+func (e failureError) Error() string {
+    return "tsp: request rejected: " + e.info.String()
+}
+
+// rejection returns the DER-encoded rejection response.
+func rejection(info FailureInfo) []byte {
+    resp, _ := asn1.Marshal(timeStampResp{
+        Status: pkiStatusInfo{
+            Status:   int(Rejection),
+            FailInfo: marshalFailInfo(info),
+        },
+    })
+
+    return resp
+}
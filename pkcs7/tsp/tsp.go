@@ -0,0 +1,761 @@
+package tsp
+
+import (
+    "time"
+    "bytes"
+    "errors"
+    "strconv"
+    "math/big"
+    "crypto/rand"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/pkcs7"
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+var (
+    // id-ct-TSTInfo
+    oidTSTInfo = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+
+    oidAttributeContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+
+    // id-aa-signingCertificate and id-aa-signingCertificateV2
+    oidAttributeSigningCertificate   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 12}
+    oidAttributeSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+)
+
+// PKIStatus is the status of a time-stamp response. See
+// https://tools.ietf.org/html/rfc3161#section-2.4.2
+type PKIStatus int
+
+const (
+    Granted                PKIStatus = 0
+    GrantedWithMods        PKIStatus = 1
+    Rejection              PKIStatus = 2
+    Waiting                PKIStatus = 3
+    RevocationWarning      PKIStatus = 4
+    RevocationNotification PKIStatus = 5
+)
+
+func (s PKIStatus) String() string {
+    switch s {
+        case Granted:
+            return "granted"
+        case GrantedWithMods:
+            return "granted with modifications"
+        case Rejection:
+            return "rejection"
+        case Waiting:
+            return "waiting"
+        case RevocationWarning:
+            return "revocation warning"
+        case RevocationNotification:
+            return "revocation notification"
+        default:
+            return "unknown PKIStatus: " + strconv.Itoa(int(s))
+    }
+}
+
+// FailureInfo is the reason a time-stamp request is rejected, the bit
+// number of the PKIFailureInfo.
+type FailureInfo int
+
+const (
+    BadAlgorithm        FailureInfo = 0
+    BadRequest          FailureInfo = 2
+    BadDataFormat       FailureInfo = 5
+    TimeNotAvailable    FailureInfo = 14
+    UnacceptedPolicy    FailureInfo = 15
+    UnacceptedExtension FailureInfo = 16
+    AddInfoNotAvailable FailureInfo = 17
+    SystemFailure       FailureInfo = 25
+)
+
+func (f FailureInfo) String() string {
+    switch f {
+        case BadAlgorithm:
+            return "unrecognized or unsupported algorithm"
+        case BadRequest:
+            return "transaction not permitted or supported"
+        case BadDataFormat:
+            return "data submitted has the wrong format"
+        case TimeNotAvailable:
+            return "time source not available"
+        case UnacceptedPolicy:
+            return "requested policy not supported"
+        case UnacceptedExtension:
+            return "requested extension not supported"
+        case AddInfoNotAvailable:
+            return "additional information not available"
+        case SystemFailure:
+            return "system failure"
+        default:
+            return "unknown failure: " + strconv.Itoa(int(f))
+    }
+}
+
+// ResponseError is returned by ParseResponse when the response does
+// not grant a time-stamp.
+type ResponseError struct {
+    Status       PKIStatus
+    StatusString []string
+    FailInfo     FailureInfo
+}
+
+func (r ResponseError) Error() string {
+    s := "tsp: error from server: " + r.Status.String()
+    if r.FailInfo >= 0 {
+        s += ", " + r.FailInfo.String()
+    }
+
+    for _, text := range r.StatusString {
+        s += ", " + text
+    }
+
+    return s
+}
+
+// These are internal structures that reflect the ASN.1 structure of a
+// time-stamp request and response. See RFC 3161, section 2.4.
+
+type messageImprint struct {
+    HashAlgorithm pkix.AlgorithmIdentifier
+    HashedMessage []byte
+}
+
+type timeStampReq struct {
+    Version        int
+    MessageImprint messageImprint
+    ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+    Nonce          *big.Int              `asn1:"optional"`
+    CertReq        bool                  `asn1:"optional,default:false"`
+    Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+type pkiStatusInfo struct {
+    Status       int
+    StatusString []string       `asn1:"optional,omitempty"`
+    FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+    Status         pkiStatusInfo
+    TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type tstInfo struct {
+    Version        int
+    Policy         asn1.ObjectIdentifier
+    MessageImprint messageImprint
+    SerialNumber   *big.Int
+    GenTime        time.Time        `asn1:"generalized"`
+    Accuracy       accuracy         `asn1:"optional"`
+    Ordering       bool             `asn1:"optional,default:false"`
+    Nonce          *big.Int         `asn1:"optional"`
+    TSA            asn1.RawValue    `asn1:"optional,explicit,tag:0"`
+    Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+type accuracy struct {
+    Seconds int `asn1:"optional"`
+    Millis  int `asn1:"optional,tag:0"`
+    Micros  int `asn1:"optional,tag:1"`
+}
+
+// SigningCertificateV2, RFC 5035
+type signingCertificateV2 struct {
+    Certs []essCertIDv2
+}
+
+type essCertIDv2 struct {
+    HashAlgorithm pkix.AlgorithmIdentifier `asn1:"optional"`
+    CertHash      []byte
+    IssuerSerial  issuerSerial `asn1:"optional"`
+}
+
+// SigningCertificate, RFC 2634
+type signingCertificate struct {
+    Certs []essCertID
+}
+
+type essCertID struct {
+    CertHash     []byte
+    IssuerSerial issuerSerial `asn1:"optional"`
+}
+
+type issuerSerial struct {
+    Issuer       []asn1.RawValue
+    SerialNumber *big.Int
+}
+
+// Message imprint hash algorithms
+var hashOIDs = map[x509.Hash]asn1.ObjectIdentifier{
+    x509.SHA1:            asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26},
+    x509.SHA224:          asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4},
+    x509.SHA256:          asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1},
+    x509.SHA384:          asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2},
+    x509.SHA512:          asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3},
+    x509.SM3:             asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401},
+    x509.GOST34112012256: asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2},
+    x509.GOST34112012512: asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 3},
+}
+
+func getHashAlgorithmFromOID(target asn1.ObjectIdentifier) x509.Hash {
+    for hash, oid := range hashOIDs {
+        if oid.Equal(target) {
+            return hash
+        }
+    }
+
+    return x509.Hash(0)
+}
+
+func getOIDFromHashAlgorithm(target x509.Hash) asn1.ObjectIdentifier {
+    for hash, oid := range hashOIDs {
+        if hash == target {
+            return oid
+        }
+    }
+
+    return nil
+}
+
+// Request represents a time-stamp request. See RFC 3161.
+type Request struct {
+    HashAlgorithm x509.Hash
+    HashedMessage []byte
+    Policy        asn1.ObjectIdentifier
+    Nonce         *big.Int
+    CertReq       bool
+    Extensions    []pkix.Extension
+}
+
+// Marshal marshals the time-stamp request to ASN.1 DER encoded form.
+func (req *Request) Marshal() ([]byte, error) {
+    hashOID := getOIDFromHashAlgorithm(req.HashAlgorithm)
+    if hashOID == nil {
+        return nil, errors.New("tsp: unknown hash function")
+    }
+
+    return asn1.Marshal(timeStampReq{
+        Version:        1,
+        MessageImprint: messageImprint{
+            HashAlgorithm: pkix.AlgorithmIdentifier{
+                Algorithm:  hashOID,
+                Parameters: asn1.NullRawValue,
+            },
+            HashedMessage: req.HashedMessage,
+        },
+        ReqPolicy:  req.Policy,
+        Nonce:      req.Nonce,
+        CertReq:    req.CertReq,
+        Extensions: req.Extensions,
+    })
+}
+
+// ParseRequest parses a time-stamp request in DER form.
+func ParseRequest(der []byte) (*Request, error) {
+    var req timeStampReq
+    rest, err := asn1.Unmarshal(der, &req)
+    if err != nil {
+        return nil, err
+    }
+    if len(rest) > 0 {
+        return nil, errors.New("tsp: trailing data in request")
+    }
+
+    if req.Version != 1 {
+        return nil, errors.New("tsp: unsupported request version " + strconv.Itoa(req.Version))
+    }
+
+    return &Request{
+        HashAlgorithm: getHashAlgorithmFromOID(req.MessageImprint.HashAlgorithm.Algorithm),
+        HashedMessage: req.MessageImprint.HashedMessage,
+        Policy:        req.ReqPolicy,
+        Nonce:         req.Nonce,
+        CertReq:       req.CertReq,
+        Extensions:    req.Extensions,
+    }, nil
+}
+
+// RequestOptions contains options for constructing time-stamp requests.
+type RequestOptions struct {
+    // Hash is the hash of the message imprint, SHA-256 if zero.
+    Hash x509.Hash
+
+    // Policy is the requested TSA policy, the TSA chooses if nil.
+    Policy asn1.ObjectIdentifier
+
+    // Nonce is sent in the request, a random 64 bits nonce is used
+    // if nil.
+    Nonce *big.Int
+
+    // CertReq asks the TSA to include its certificate in the token.
+    CertReq bool
+}
+
+func (opts *RequestOptions) hash() x509.Hash {
+    if opts == nil || opts.Hash == 0 {
+        return x509.SHA256
+    }
+
+    return opts.Hash
+}
+
+// CreateRequest returns a DER-encoded time-stamp request for message.
+// If opts is nil, default values are used.
+func CreateRequest(message []byte, opts *RequestOptions) ([]byte, error) {
+    req, err := NewRequest(message, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    return req.Marshal()
+}
+
+// NewRequest returns a time-stamp request for message. If opts is nil,
+// default values are used.
+func NewRequest(message []byte, opts *RequestOptions) (*Request, error) {
+    hashFunc := opts.hash()
+    if getOIDFromHashAlgorithm(hashFunc) == nil || !hashFunc.Available() {
+        return nil, errors.New("tsp: hash function is not available")
+    }
+
+    h := hashFunc.New()
+    h.Write(message)
+
+    req := &Request{
+        HashAlgorithm: hashFunc,
+        HashedMessage: h.Sum(nil),
+    }
+
+    if opts != nil {
+        req.Policy = opts.Policy
+        req.Nonce = opts.Nonce
+        req.CertReq = opts.CertReq
+    }
+
+    if req.Nonce == nil {
+        nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+        if err != nil {
+            return nil, err
+        }
+
+        req.Nonce = nonce
+    }
+
+    return req, nil
+}
+
+// Timestamp represents the TSTInfo of a time-stamp token. See RFC 3161.
+type Timestamp struct {
+    HashAlgorithm x509.Hash
+    HashedMessage []byte
+
+    Time         time.Time
+    Accuracy     time.Duration
+    SerialNumber *big.Int
+    Policy       asn1.ObjectIdentifier
+    Ordering     bool
+    Nonce        *big.Int
+    Extensions   []pkix.Extension
+
+    // Certificate is the TSA certificate that signed the token.
+    Certificate *x509.Certificate
+
+    // Certificates are the certificates included in the token.
+    Certificates []*x509.Certificate
+
+    // RawToken is the time-stamp token, a SignedData ContentInfo.
+    RawToken []byte
+}
+
+// Response represents a time-stamp response. See RFC 3161.
+type Response struct {
+    Status       PKIStatus
+    StatusString []string
+
+    // Token is the time-stamp token of a granted response.
+    Token []byte
+
+    // Timestamp is the parsed Token.
+    Timestamp *Timestamp
+}
+
+// ParseResponse parses a time-stamp response in DER form. If the
+// response does not grant a time-stamp, a ResponseError is returned.
+// The token signature and its signing certificate binding are checked,
+// see Parse.
+func ParseResponse(der []byte, cert *x509.Certificate) (*Response, error) {
+    var resp timeStampResp
+    rest, err := asn1.Unmarshal(der, &resp)
+    if err != nil {
+        return nil, err
+    }
+    if len(rest) > 0 {
+        return nil, errors.New("tsp: trailing data in response")
+    }
+
+    status := PKIStatus(resp.Status.Status)
+    if status != Granted && status != GrantedWithMods {
+        return nil, ResponseError{
+            Status:       status,
+            StatusString: resp.Status.StatusString,
+            FailInfo:     parseFailInfo(resp.Status.FailInfo),
+        }
+    }
+
+    if len(resp.TimeStampToken.FullBytes) == 0 {
+        return nil, errors.New("tsp: response has no time-stamp token")
+    }
+
+    ts, err := Parse(resp.TimeStampToken.FullBytes, cert)
+    if err != nil {
+        return nil, err
+    }
+
+    return &Response{
+        Status:       status,
+        StatusString: resp.Status.StatusString,
+        Token:        resp.TimeStampToken.FullBytes,
+        Timestamp:    ts,
+    }, nil
+}
+
+// Parse parses a time-stamp token and checks its signature, that it is
+// bound to the signer certificate with an ESSCertIDv2 or ESSCertID
+// signed attribute, and that the certificate may be used for time
+// stamping. cert is the TSA certificate, used when the token does not
+// include it, and may be nil. The chain of the certificate is not
+// checked, see Verify.
+func Parse(token []byte, cert *x509.Certificate) (*Timestamp, error) {
+    p7, err := pkcs7.Parse(token)
+    if err != nil {
+        return nil, err
+    }
+
+    ts, err := parseToken(p7, cert)
+    if err != nil {
+        return nil, err
+    }
+
+    ts.RawToken = token
+
+    return ts, nil
+}
+
+func parseToken(p7 *pkcs7.PKCS7, tsaCert *x509.Certificate) (*Timestamp, error) {
+    if len(p7.Signers) != 1 {
+        return nil, errors.New("tsp: token must have exactly one signer")
+    }
+
+    var contentType asn1.ObjectIdentifier
+    err := p7.UnmarshalSignedAttribute(oidAttributeContentType, &contentType)
+    if err != nil || !contentType.Equal(oidTSTInfo) {
+        return nil, errors.New("tsp: token content is not TSTInfo")
+    }
+
+    var info tstInfo
+    rest, err := asn1.Unmarshal(p7.Content, &info)
+    if err != nil {
+        return nil, err
+    }
+    if len(rest) > 0 {
+        return nil, errors.New("tsp: trailing data in TSTInfo")
+    }
+
+    if info.Version != 1 {
+        return nil, errors.New("tsp: unsupported TSTInfo version " + strconv.Itoa(info.Version))
+    }
+
+    certs := p7.Certificates
+    if tsaCert != nil {
+        p7.Certificates = append(p7.Certificates, tsaCert)
+    }
+
+    cert := p7.GetOnlySigner()
+    if cert == nil {
+        return nil, errors.New("tsp: token has no signer certificate")
+    }
+
+    if err = p7.Verify(); err != nil {
+        return nil, err
+    }
+
+    if err = checkSigningCertificate(p7, cert); err != nil {
+        return nil, err
+    }
+
+    if err = checkTSACertificate(cert); err != nil {
+        return nil, err
+    }
+
+    return &Timestamp{
+        HashAlgorithm: getHashAlgorithmFromOID(info.MessageImprint.HashAlgorithm.Algorithm),
+        HashedMessage: info.MessageImprint.HashedMessage,
+        Time:          info.GenTime,
+        Accuracy:      time.Duration(info.Accuracy.Seconds) * time.Second +
+            time.Duration(info.Accuracy.Millis) * time.Millisecond +
+            time.Duration(info.Accuracy.Micros) * time.Microsecond,
+        SerialNumber:  info.SerialNumber,
+        Policy:        info.Policy,
+        Ordering:      info.Ordering,
+        Nonce:         info.Nonce,
+        Extensions:    info.Extensions,
+        Certificate:   cert,
+        Certificates:  certs,
+    }, nil
+}
+
+// checkSigningCertificate checks that the first certificate identifier
+// of the signing certificate attribute is cert.
+func checkSigningCertificate(p7 *pkcs7.PKCS7, cert *x509.Certificate) error {
+    var (
+        hashFunc x509.Hash
+        certHash []byte
+        issuer   issuerSerial
+    )
+
+    var v2 signingCertificateV2
+    var v1 signingCertificate
+
+    if err := p7.UnmarshalSignedAttribute(oidAttributeSigningCertificateV2, &v2); err == nil {
+        if len(v2.Certs) == 0 {
+            return errors.New("tsp: empty signing certificate attribute")
+        }
+
+        hashFunc = x509.SHA256
+        if len(v2.Certs[0].HashAlgorithm.Algorithm) > 0 {
+            hashFunc = getHashAlgorithmFromOID(v2.Certs[0].HashAlgorithm.Algorithm)
+        }
+
+        certHash = v2.Certs[0].CertHash
+        issuer = v2.Certs[0].IssuerSerial
+    } else if err := p7.UnmarshalSignedAttribute(oidAttributeSigningCertificate, &v1); err == nil {
+        if len(v1.Certs) == 0 {
+            return errors.New("tsp: empty signing certificate attribute")
+        }
+
+        hashFunc = x509.SHA1
+        certHash = v1.Certs[0].CertHash
+        issuer = v1.Certs[0].IssuerSerial
+    } else {
+        return errors.New("tsp: token has no signing certificate attribute")
+    }
+
+    if hashFunc == 0 || !hashFunc.Available() {
+        return errors.New("tsp: unsupported signing certificate hash")
+    }
+
+    h := hashFunc.New()
+    h.Write(cert.Raw)
+    if !bytes.Equal(h.Sum(nil), certHash) {
+        return errors.New("tsp: signing certificate hash mismatch")
+    }
+
+    if issuer.SerialNumber != nil {
+        if issuer.SerialNumber.Cmp(cert.SerialNumber) != 0 ||
+            !issuerNamesContain(issuer.Issuer, cert.RawIssuer) {
+            return errors.New("tsp: signing certificate issuer serial mismatch")
+        }
+    }
+
+    return nil
+}
+
+// issuerNamesContain reports whether the GeneralNames contain the
+// directoryName rawIssuer.
+func issuerNamesContain(names []asn1.RawValue, rawIssuer []byte) bool {
+    for _, name := range names {
+        if name.Class == asn1.ClassContextSpecific && name.Tag == 4 &&
+            bytes.Equal(name.Bytes, rawIssuer) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// checkTSACertificate checks the extended key usage of a TSA
+// certificate, RFC 3161 section 2.3.
+func checkTSACertificate(cert *x509.Certificate) error {
+    for _, usage := range cert.ExtKeyUsage {
+        if usage == x509.ExtKeyUsageTimeStamping {
+            return nil
+        }
+    }
+
+    return errors.New("tsp: certificate is not valid for time stamping")
+}
+
+// VerifyOptions contains the values checked by Verify. Roots or
+// Certificate must be set, the TSA is not trusted otherwise.
+type VerifyOptions struct {
+    // Roots is used to verify the chain of the TSA certificate at the
+    // time of the time-stamp. If nil, the chain is not checked and
+    // Certificate must be set.
+    Roots *x509.CertPool
+
+    // Message is checked against the message imprint, if not nil.
+    Message []byte
+
+    // HashedMessage is checked against the message imprint, if not
+    // nil.
+    HashedMessage []byte
+
+    // Nonce is checked against the nonce of the token, if not nil.
+    Nonce *big.Int
+
+    // Certificate, if not nil, must be the TSA certificate, which pins
+    // the TSA. It is used when the token does not include the
+    // certificate.
+    Certificate *x509.Certificate
+}
+
+// Verify parses a time-stamp token like Parse and checks it against
+// opts.
+func Verify(token []byte, opts VerifyOptions) (*Timestamp, error) {
+    ts, err := Parse(token, opts.Certificate)
+    if err != nil {
+        return nil, err
+    }
+
+    if err = ts.Verify(opts); err != nil {
+        return nil, err
+    }
+
+    return ts, nil
+}
+
+// Verify checks the time-stamp against opts. It fails if neither
+// opts.Roots nor opts.Certificate is set.
+func (ts *Timestamp) Verify(opts VerifyOptions) error {
+    if opts.Roots == nil && opts.Certificate == nil {
+        return errors.New("tsp: no roots or TSA certificate to trust")
+    }
+
+    if err := ts.check(opts); err != nil {
+        return err
+    }
+
+    if opts.Certificate != nil && !opts.Certificate.Equal(ts.Certificate) {
+        return errors.New("tsp: unexpected TSA certificate")
+    }
+
+    if opts.Roots != nil {
+        intermediates := x509.NewCertPool()
+        for _, cert := range ts.Certificates {
+            intermediates.AddCert(cert)
+        }
+
+        _, err := ts.Certificate.Verify(x509.VerifyOptions{
+            Roots:         opts.Roots,
+            Intermediates: intermediates,
+            CurrentTime:   ts.Time,
+            KeyUsages:     []x509.ExtKeyUsage{
+                x509.ExtKeyUsageTimeStamping,
+            },
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// check checks the message imprint and the nonce of the time-stamp.
+func (ts *Timestamp) check(opts VerifyOptions) error {
+    if opts.Message != nil {
+        if !ts.HashAlgorithm.Available() {
+            return errors.New("tsp: unsupported message imprint hash")
+        }
+
+        h := ts.HashAlgorithm.New()
+        h.Write(opts.Message)
+        if !bytes.Equal(h.Sum(nil), ts.HashedMessage) {
+            return errors.New("tsp: message imprint mismatch")
+        }
+    }
+
+    if opts.HashedMessage != nil && !bytes.Equal(opts.HashedMessage, ts.HashedMessage) {
+        return errors.New("tsp: message imprint mismatch")
+    }
+
+    if opts.Nonce != nil {
+        if ts.Nonce == nil || ts.Nonce.Cmp(opts.Nonce) != 0 {
+            return errors.New("tsp: nonce mismatch")
+        }
+    }
+
+    return nil
+}
+
+// VerifyRequest checks that the time-stamp answers req. It does not
+// check the trust in the TSA, see Verify.
+func (ts *Timestamp) VerifyRequest(req *Request) error {
+    if ts.HashAlgorithm != req.HashAlgorithm {
+        return errors.New("tsp: message imprint hash mismatch")
+    }
+
+    if req.Policy != nil && !req.Policy.Equal(ts.Policy) {
+        return errors.New("tsp: policy mismatch")
+    }
+
+    if req.CertReq && !containsCertificate(ts.Certificates, ts.Certificate) {
+        return errors.New("tsp: TSA certificate is missing")
+    }
+
+    return ts.check(VerifyOptions{
+        HashedMessage: req.HashedMessage,
+        Nonce:         req.Nonce,
+    })
+}
+
+// VerifySigner verifies the time-stamp token attached to the signer at
+// signerIndex of p7 with pkcs7 AttachTimestampToken, whose message
+// imprint is over the signature value of the signer.
+func VerifySigner(p7 *pkcs7.PKCS7, signerIndex int, opts VerifyOptions) (*Timestamp, error) {
+    token, signature, err := p7.GetTimestampToken(signerIndex)
+    if err != nil {
+        return nil, err
+    }
+
+    opts.Message = signature
+    opts.HashedMessage = nil
+
+    return Verify(token, opts)
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+    for _, c := range certs {
+        if c.Equal(cert) {
+            return true
+        }
+    }
+
+    return false
+}
+
+func parseFailInfo(bits asn1.BitString) FailureInfo {
+    for i := 0; i < bits.BitLength; i++ {
+        if bits.At(i) == 1 {
+            return FailureInfo(i)
+        }
+    }
+
+    return -1
+}
+
+func marshalFailInfo(info FailureInfo) asn1.BitString {
+    n := int(info)
+
+    b := make([]byte, n/8+1)
+    b[n/8] |= 0x80 >> uint(n%8)
+
+    return asn1.BitString{
+        Bytes:     b,
+        BitLength: n + 1,
+    }
+}
@@ -0,0 +1,428 @@
+package tsp
+
+import (
+    "io"
+    "time"
+    "bytes"
+    "testing"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "crypto"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/pkcs7"
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+var testPolicy = asn1.ObjectIdentifier{1, 2, 3, 4, 1}
+
+type testPKI struct {
+    ca, tsa    *x509.Certificate
+    caKey      crypto.Signer
+    tsaKey     crypto.Signer
+    roots      *x509.CertPool
+}
+
+func newTestPKI(t *testing.T, newKey func() crypto.Signer, eku []x509.ExtKeyUsage) *testPKI {
+    now := time.Now()
+
+    caKey := newKey()
+    caTemplate := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: "Test CA"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              x509.KeyUsageCertSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+
+    ca := createCert(t, caTemplate, caTemplate, caKey.Public(), caKey)
+
+    tsaKey := newKey()
+    tsa := createCert(t, &x509.Certificate{
+        SerialNumber: big.NewInt(2),
+        Subject:      pkix.Name{CommonName: "Test TSA"},
+        NotBefore:    now.Add(-time.Hour),
+        NotAfter:     now.Add(time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  eku,
+    }, ca, tsaKey.Public(), caKey)
+
+    roots := x509.NewCertPool()
+    roots.AddCert(ca)
+
+    return &testPKI{
+        ca:     ca,
+        tsa:    tsa,
+        caKey:  caKey,
+        tsaKey: tsaKey,
+        roots:  roots,
+    }
+}
+
+func createCert(t *testing.T, template, parent *x509.Certificate, pub any, priv crypto.Signer) *x509.Certificate {
+    der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return cert
+}
+
+var testKeys = []struct {
+    name string
+    hash x509.Hash
+    key  func() crypto.Signer
+}{
+    {
+        name: "RSA",
+        hash: x509.SHA256,
+        key: func() crypto.Signer {
+            k, _ := rsa.GenerateKey(rand.Reader, 2048)
+            return k
+        },
+    },
+    {
+        name: "ECDSA",
+        hash: x509.SHA384,
+        key: func() crypto.Signer {
+            k, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+            return k
+        },
+    },
+    {
+        name: "SM2",
+        hash: x509.SM3,
+        key: func() crypto.Signer {
+            k, _ := sm2.GenerateKey(rand.Reader)
+            return k
+        },
+    },
+}
+
+func Test_Timestamp(t *testing.T) {
+    for _, td := range testKeys {
+        t.Run(td.name, func(t *testing.T) {
+            assertEqual := cryptobin_test.AssertEqualT(t)
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            pki := newTestPKI(t, td.key, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping})
+
+            tsa := &Authority{
+                Certificate: pki.tsa,
+                Signer:      pki.tsaKey,
+                Policy:      testPolicy,
+                Accuracy:    1500 * time.Millisecond,
+            }
+
+            message := []byte("test message")
+
+            reqDer, err := CreateRequest(message, &RequestOptions{
+                Hash:    td.hash,
+                CertReq: true,
+            })
+            assertError(err, "CreateRequest")
+
+            req, err := ParseRequest(reqDer)
+            assertError(err, "ParseRequest")
+            assertEqual(req.HashAlgorithm, td.hash, "HashAlgorithm")
+            assertBool(req.Nonce != nil, "Nonce")
+            assertBool(req.CertReq, "CertReq")
+
+            resp, err := ParseResponse(tsa.Respond(req), nil)
+            assertError(err, "ParseResponse")
+            assertEqual(resp.Status, Granted, "Status")
+
+            ts := resp.Timestamp
+            assertError(ts.VerifyRequest(req), "VerifyRequest")
+            assertEqual(ts.Policy, testPolicy, "Policy")
+            assertEqual(ts.Accuracy, 1500 * time.Millisecond, "Accuracy")
+            assertBool(ts.Certificate.Equal(pki.tsa), "Certificate")
+
+            _, err = Verify(resp.Token, VerifyOptions{
+                Roots:   pki.roots,
+                Message: message,
+                Nonce:   req.Nonce,
+            })
+            assertError(err, "Verify")
+
+            _, err = Verify(resp.Token, VerifyOptions{
+                Roots:   pki.roots,
+                Message: []byte("other message"),
+            })
+            assertBool(err != nil, "Verify other message")
+
+            _, err = Verify(resp.Token, VerifyOptions{
+                Roots: pki.roots,
+                Nonce: big.NewInt(1),
+            })
+            assertBool(err != nil, "Verify other nonce")
+
+            // the TSA is not trusted without roots or certificate
+            _, err = Verify(resp.Token, VerifyOptions{
+                Message: message,
+            })
+            assertBool(err != nil, "Verify without trust")
+
+            _, err = Verify(resp.Token, VerifyOptions{
+                Certificate: pki.tsa,
+                Message:     message,
+            })
+            assertError(err, "Verify with TSA certificate")
+        })
+    }
+}
+
+func Test_TokenWithoutCertificate(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    pki := newTestPKI(t, testKeys[2].key, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping})
+
+    tsa := &Authority{
+        Certificate: pki.tsa,
+        Signer:      pki.tsaKey,
+        Policy:      testPolicy,
+    }
+
+    req, err := NewRequest([]byte("test message"), nil)
+    assertError(err, "NewRequest")
+
+    token, err := tsa.CreateToken(req)
+    assertError(err, "CreateToken")
+
+    _, err = Parse(token, nil)
+    assertBool(err != nil, "Parse without certificate")
+
+    ts, err := Verify(token, VerifyOptions{
+        Roots:       pki.roots,
+        Certificate: pki.tsa,
+    })
+    assertError(err, "Verify")
+    assertError(ts.VerifyRequest(req), "VerifyRequest")
+
+    // the token is bound to the TSA certificate
+    other := newTestPKI(t, testKeys[2].key, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping})
+    _, err = Parse(token, other.tsa)
+    assertBool(err != nil, "Parse with other certificate")
+}
+
+func Test_CertificateWithoutEKU(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    pki := newTestPKI(t, testKeys[1].key, nil)
+
+    tsa := &Authority{
+        Certificate: pki.tsa,
+        Signer:      pki.tsaKey,
+        Policy:      testPolicy,
+    }
+
+    req, err := NewRequest([]byte("test message"), &RequestOptions{CertReq: true})
+    assertError(err, "NewRequest")
+
+    token, err := tsa.CreateToken(req)
+    assertError(err, "CreateToken")
+
+    _, err = Parse(token, nil)
+    assertBool(err != nil, "Parse should fail")
+}
+
+func Test_Rejection(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    pki := newTestPKI(t, testKeys[1].key, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping})
+
+    tsa := &Authority{
+        Certificate: pki.tsa,
+        Signer:      pki.tsaKey,
+        Policy:      testPolicy,
+        Hashes:      []x509.Hash{x509.SHA256},
+    }
+
+    tests := []struct {
+        name string
+        opts *RequestOptions
+        info FailureInfo
+    }{
+        {"hash", &RequestOptions{Hash: x509.SM3}, BadAlgorithm},
+        {"policy", &RequestOptions{Policy: asn1.ObjectIdentifier{1, 2, 3}}, UnacceptedPolicy},
+    }
+
+    for _, test := range tests {
+        req, err := NewRequest([]byte("test message"), test.opts)
+        assertError(err, "NewRequest")
+
+        _, err = ParseResponse(tsa.Respond(req), nil)
+
+        respErr, ok := err.(ResponseError)
+        if !ok {
+            t.Fatalf("%s: got %v, want ResponseError", test.name, err)
+        }
+
+        assertEqual(respErr.Status, Rejection, test.name)
+        assertEqual(respErr.FailInfo, test.info, test.name)
+    }
+}
+
+func Test_Authority(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    pki := newTestPKI(t, testKeys[2].key, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping})
+
+    server := httptest.NewServer(&Authority{
+        Certificate: pki.tsa,
+        Signer:      pki.tsaKey,
+        Policy:      testPolicy,
+    })
+    defer server.Close()
+
+    message := []byte("test message")
+
+    req, err := NewRequest(message, &RequestOptions{
+        Hash:    x509.SM3,
+        CertReq: true,
+    })
+    assertError(err, "NewRequest")
+
+    reqDer, err := req.Marshal()
+    assertError(err, "Marshal")
+
+    httpResp, err := http.Post(server.URL, "application/timestamp-query", bytes.NewReader(reqDer))
+    assertError(err, "Post")
+
+    respDer, err := io.ReadAll(httpResp.Body)
+    httpResp.Body.Close()
+    assertError(err, "ReadAll")
+    assertEqual(httpResp.Header.Get("Content-Type"), "application/timestamp-reply", "Content-Type")
+
+    resp, err := ParseResponse(respDer, nil)
+    assertError(err, "ParseResponse")
+    assertError(resp.Timestamp.VerifyRequest(req), "VerifyRequest")
+
+    // malformed request
+    httpResp, err = http.Post(server.URL, "application/timestamp-query", bytes.NewReader([]byte("bad")))
+    assertError(err, "Post")
+
+    respDer, err = io.ReadAll(httpResp.Body)
+    httpResp.Body.Close()
+    assertError(err, "ReadAll")
+
+    _, err = ParseResponse(respDer, nil)
+    respErr, ok := err.(ResponseError)
+    if !ok || respErr.FailInfo != BadDataFormat {
+        t.Errorf("got %v, want bad data format", err)
+    }
+}
+
+func Test_SignerTimestamp(t *testing.T) {
+    for _, td := range testKeys[1:] {
+        t.Run(td.name, func(t *testing.T) {
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            pki := newTestPKI(t, td.key, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping})
+            signer := newTestPKI(t, td.key, nil)
+
+            tsa := &Authority{
+                Certificate: pki.tsa,
+                Signer:      pki.tsaKey,
+                Policy:      testPolicy,
+            }
+
+            var sd *pkcs7.SignedData
+            var err error
+            if td.name == "SM2" {
+                sd, err = pkcs7.NewSMSignedData([]byte("document"))
+            } else {
+                sd, err = pkcs7.NewSignedData([]byte("document"))
+                sd.SetDigestAlgorithm(pkcs7.OidDigestAlgorithmSHA256)
+                sd.SetEncryptionAlgorithm(pkcs7.OidEncryptionAlgorithmECDSASHA256)
+            }
+            assertError(err, "NewSignedData")
+
+            err = sd.AddSigner(signer.tsa, signer.tsaKey, pkcs7.SignerInfoConfig{})
+            assertError(err, "AddSigner")
+
+            signed, err := sd.Finish()
+            assertError(err, "Finish")
+
+            // timestamp the signature of an existing signed document
+            p7, err := pkcs7.Parse(signed)
+            assertError(err, "Parse")
+
+            req, err := NewRequest(p7.Signers[0].EncryptedDigest, &RequestOptions{
+                Hash:    td.hash,
+                CertReq: true,
+            })
+            assertError(err, "NewRequest")
+
+            token, err := tsa.CreateToken(req)
+            assertError(err, "CreateToken")
+
+            signed, err = pkcs7.AttachTimestampToken(signed, 0, token)
+            assertError(err, "AttachTimestampToken")
+
+            p7, err = pkcs7.Parse(signed)
+            assertError(err, "Parse")
+            assertError(p7.Verify(), "Verify")
+
+            _, err = VerifySigner(p7, 0, VerifyOptions{
+                Roots: pki.roots,
+            })
+            assertError(err, "VerifySigner")
+
+            _, err = VerifySigner(p7, 0, VerifyOptions{})
+            assertBool(err != nil, "VerifySigner without trust")
+
+            // a token over other data
+            req, err = NewRequest([]byte("other"), &RequestOptions{CertReq: true})
+            assertError(err, "NewRequest")
+
+            token, err = tsa.CreateToken(req)
+            assertError(err, "CreateToken")
+
+            sd2, err := pkcs7.NewSignedData([]byte("document"))
+            assertError(err, "NewSignedData")
+            sd2.SetDigestAlgorithm(pkcs7.OidDigestAlgorithmSHA256)
+            sd2.SetEncryptionAlgorithm(pkcs7.OidEncryptionAlgorithmECDSASHA256)
+            if td.name == "SM2" {
+                sd2.SetDigestAlgorithm(pkcs7.OidDigestAlgorithmSM3)
+                sd2.SetEncryptionAlgorithm(pkcs7.OidDigestEncryptionAlgorithmSM2)
+            }
+
+            err = sd2.AddSigner(signer.tsa, signer.tsaKey, pkcs7.SignerInfoConfig{})
+            assertError(err, "AddSigner")
+            assertError(sd2.AddTimestampToken(0, token), "AddTimestampToken")
+
+            signed, err = sd2.Finish()
+            assertError(err, "Finish")
+
+            p7, err = pkcs7.Parse(signed)
+            assertError(err, "Parse")
+
+            _, err = VerifySigner(p7, 0, VerifyOptions{
+                Roots: pki.roots,
+            })
+            assertBool(err != nil, "VerifySigner should fail")
+        })
+    }
+}
@@ -126,6 +126,11 @@ func verifySignatureAtTime(p7 *PKCS7, signer signerInfo, truststore *x509.CertPo
 
     checkStatus, err := signFunc.Verify(pkey, signedData, signer.EncryptedDigest)
     if !checkStatus {
+        // SM2, EdDSA and DSA report a bad signature without an error
+        if err == nil {
+            err = errors.New("pkcs7: signature verification failure")
+        }
+
         return err
     }
 
@@ -196,6 +201,11 @@ func verifySignature(p7 *PKCS7, signer signerInfo, truststore *x509.CertPool) (e
 
     checkStatus, err := signFunc.Verify(pkey, signedData, signer.EncryptedDigest)
     if !checkStatus {
+        // SM2, EdDSA and DSA report a bad signature without an error
+        if err == nil {
+            err = errors.New("pkcs7: signature verification failure")
+        }
+
         return err
     }
 
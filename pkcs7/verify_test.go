@@ -607,3 +607,54 @@ but that's not what ships are built for.
     }
     os.Remove(tmpContentFile.Name()) // clean up
 }
+
+func TestVerifyBadSignature(t *testing.T) {
+    content := []byte("Hello World")
+
+    cert, err := createTestCertificate(cryptobin_x509.SM2WithSM3)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for _, withoutAttr := range []bool{false, true} {
+        toBeSigned, err := NewSMSignedData(content)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if withoutAttr {
+            err = toBeSigned.SignWithoutAttr(cert.Certificate, *cert.PrivateKey, SignerInfoConfig{})
+        } else {
+            err = toBeSigned.AddSigner(cert.Certificate, *cert.PrivateKey, SignerInfoConfig{})
+        }
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        signed, err := toBeSigned.Finish()
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        p7, err := Parse(signed)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if err = p7.Verify(); err != nil {
+            t.Fatal(err)
+        }
+
+        // the SM2 signer returns false without an error
+        sig := p7.Signers[0].EncryptedDigest
+        sig[len(sig) - 1] ^= 0xff
+
+        if err = p7.Verify(); err == nil {
+            t.Errorf("withoutAttr %v: Verify should fail with a bad signature", withoutAttr)
+        }
+
+        if err = p7.VerifyWithChainAtTime(nil, time.Now()); err == nil {
+            t.Errorf("withoutAttr %v: VerifyWithChainAtTime should fail with a bad signature", withoutAttr)
+        }
+    }
+}
@@ -0,0 +1,557 @@
+// Package bls implements BLS signatures over the BLS12-381 curve
+// as defined in draft-irtf-cfrg-bls-signature-05.
+//
+// Both variants are supported: MinPk places public keys in G1 and
+// signatures in G2, MinSig places public keys in G2 and signatures in G1.
+// Each variant provides the Basic, MessageAugmentation and
+// ProofOfPossession schemes.
+package bls
+
+import (
+    "io"
+    "errors"
+    "math/big"
+    "crypto"
+    "crypto/sha256"
+    "crypto/subtle"
+
+    "golang.org/x/crypto/hkdf"
+
+    "github.com/deatil/go-cryptobin/pubkey/bls/bls12381"
+)
+
+var (
+    ErrInvalidVariant    = errors.New("go-cryptobin/bls: invalid variant")
+    ErrInvalidScheme     = errors.New("go-cryptobin/bls: invalid scheme")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/bls: invalid private key")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/bls: invalid public key")
+    ErrInvalidSignature  = errors.New("go-cryptobin/bls: invalid signature")
+    ErrShortIKM          = errors.New("go-cryptobin/bls: IKM must be at least 32 bytes")
+    ErrEmptyAggregate    = errors.New("go-cryptobin/bls: nothing to aggregate")
+    ErrMixedVariants     = errors.New("go-cryptobin/bls: keys of different variants")
+)
+
+// PrivateKeySize is the size of a serialized private key.
+const PrivateKeySize = bls12381.ScalarSize
+
+// Variant selects the groups of the public keys and signatures.
+type Variant uint
+
+const (
+    // MinPk has public keys in G1 and signatures in G2.
+    MinPk Variant = iota
+    // MinSig has public keys in G2 and signatures in G1.
+    MinSig
+)
+
+// PublicKeySize returns the size of a serialized public key.
+func (v Variant) PublicKeySize() int {
+    if v == MinSig {
+        return bls12381.G2CompressedSize
+    }
+
+    return bls12381.G1CompressedSize
+}
+
+// SignatureSize returns the size of a serialized signature.
+func (v Variant) SignatureSize() int {
+    if v == MinSig {
+        return bls12381.G1CompressedSize
+    }
+
+    return bls12381.G2CompressedSize
+}
+
+func (v Variant) valid() bool {
+    return v == MinPk || v == MinSig
+}
+
+// Scheme selects how the rogue key attack is prevented.
+type Scheme uint
+
+const (
+    // Basic requires distinct messages in aggregate verification.
+    Basic Scheme = iota
+    // MessageAugmentation prepends the signer's public key to the message.
+    MessageAugmentation
+    // ProofOfPossession requires a proof of the private key for each public key.
+    ProofOfPossession
+)
+
+// Options implements crypto.SignerOpts.
+type Options struct {
+    Scheme Scheme
+}
+
+// HashFunc returns crypto.Hash(0), messages are hashed to the curve directly.
+func (opts *Options) HashFunc() crypto.Hash {
+    return crypto.Hash(0)
+}
+
+// dst returns the ciphersuite ID of the variant and scheme.
+func dst(v Variant, s Scheme) ([]byte, error) {
+    var tag string
+    switch s {
+        case Basic:
+            tag = "NUL_"
+        case MessageAugmentation:
+            tag = "AUG_"
+        case ProofOfPossession:
+            tag = "POP_"
+        default:
+            return nil, ErrInvalidScheme
+    }
+
+    return []byte("BLS_SIG_" + sigGroup(v) + "_XMD:SHA-256_SSWU_RO_" + tag), nil
+}
+
+func popDST(v Variant) []byte {
+    return []byte("BLS_POP_" + sigGroup(v) + "_XMD:SHA-256_SSWU_RO_POP_")
+}
+
+func sigGroup(v Variant) string {
+    if v == MinSig {
+        return "BLS12381G1"
+    }
+
+    return "BLS12381G2"
+}
+
+// PublicKey is a BLS public key.
+type PublicKey struct {
+    Variant Variant
+
+    g1 *bls12381.G1
+    g2 *bls12381.G2
+}
+
+// NewPublicKey decodes a compressed public key and runs KeyValidate.
+func NewPublicKey(v Variant, b []byte) (*PublicKey, error) {
+    pub := &PublicKey{
+        Variant: v,
+    }
+
+    switch v {
+        case MinPk:
+            if len(b) != bls12381.G1CompressedSize {
+                return nil, ErrInvalidPublicKey
+            }
+
+            p, err := new(bls12381.G1).SetBytes(b)
+            if err != nil || p.IsIdentity() {
+                return nil, ErrInvalidPublicKey
+            }
+
+            pub.g1 = p
+        case MinSig:
+            if len(b) != bls12381.G2CompressedSize {
+                return nil, ErrInvalidPublicKey
+            }
+
+            p, err := new(bls12381.G2).SetBytes(b)
+            if err != nil || p.IsIdentity() {
+                return nil, ErrInvalidPublicKey
+            }
+
+            pub.g2 = p
+        default:
+            return nil, ErrInvalidVariant
+    }
+
+    return pub, nil
+}
+
+// Bytes returns the compressed encoding of pub.
+func (pub *PublicKey) Bytes() []byte {
+    if pub.Variant == MinSig {
+        return pub.g2.Bytes()
+    }
+
+    return pub.g1.Bytes()
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok || pub.Variant != xx.Variant {
+        return false
+    }
+
+    if pub.Variant == MinSig {
+        return pub.g2.Equal(xx.g2)
+    }
+
+    return pub.g1.Equal(xx.g1)
+}
+
+// PrivateKey is a BLS private key.
+type PrivateKey struct {
+    PublicKey
+
+    D *big.Int
+}
+
+// NewPrivateKey decodes a 32-byte big-endian private key.
+func NewPrivateKey(v Variant, b []byte) (*PrivateKey, error) {
+    if len(b) != PrivateKeySize {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    d := new(big.Int).SetBytes(b)
+    if d.Sign() == 0 || d.Cmp(bls12381.Order()) >= 0 {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    return newPrivateKey(v, d)
+}
+
+func newPrivateKey(v Variant, d *big.Int) (*PrivateKey, error) {
+    priv := &PrivateKey{
+        D: d,
+    }
+    priv.PublicKey.Variant = v
+
+    switch v {
+        case MinPk:
+            priv.PublicKey.g1 = new(bls12381.G1).ScalarBaseMult(d)
+        case MinSig:
+            priv.PublicKey.g2 = new(bls12381.G2).ScalarBaseMult(d)
+        default:
+            return nil, ErrInvalidVariant
+    }
+
+    return priv, nil
+}
+
+// GenerateKey generates a private key from 32 bytes of rand.
+func GenerateKey(rand io.Reader, v Variant) (*PrivateKey, error) {
+    ikm := make([]byte, 32)
+    if _, err := io.ReadFull(rand, ikm); err != nil {
+        return nil, err
+    }
+
+    return KeyGen(v, ikm, nil)
+}
+
+// KeyGen derives a private key from ikm as in section 2.3 of the draft.
+func KeyGen(v Variant, ikm, keyInfo []byte) (*PrivateKey, error) {
+    if len(ikm) < 32 {
+        return nil, ErrShortIKM
+    }
+
+    const L = 48
+
+    ikmZero := append(append([]byte{}, ikm...), 0)
+    info := append(append([]byte{}, keyInfo...), 0, L)
+
+    order := bls12381.Order()
+
+    salt := []byte("BLS-SIG-KEYGEN-SALT-")
+    okm := make([]byte, L)
+
+    for {
+        h := sha256.Sum256(salt)
+        salt = h[:]
+
+        r := hkdf.New(sha256.New, ikmZero, salt, info)
+        if _, err := io.ReadFull(r, okm); err != nil {
+            return nil, err
+        }
+
+        d := new(big.Int).SetBytes(okm)
+        d.Mod(d, order)
+        if d.Sign() != 0 {
+            return newPrivateKey(v, d)
+        }
+    }
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// Bytes returns the 32-byte big-endian encoding of priv.
+func (priv *PrivateKey) Bytes() []byte {
+    return priv.D.FillBytes(make([]byte, PrivateKeySize))
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok || priv.Variant != xx.Variant {
+        return false
+    }
+
+    return subtle.ConstantTimeCompare(priv.Bytes(), xx.Bytes()) == 1
+}
+
+// Sign signs msg with the scheme in opts, Basic is used when opts
+// is not an *Options.
+func (priv *PrivateKey) Sign(rand io.Reader, msg []byte, opts crypto.SignerOpts) ([]byte, error) {
+    scheme := Basic
+    if o, ok := opts.(*Options); ok {
+        scheme = o.Scheme
+    }
+
+    return Sign(priv, scheme, msg)
+}
+
+// Sign signs msg with priv.
+func Sign(priv *PrivateKey, scheme Scheme, msg []byte) ([]byte, error) {
+    tag, err := dst(priv.Variant, scheme)
+    if err != nil {
+        return nil, err
+    }
+
+    if scheme == MessageAugmentation {
+        msg = augment(&priv.PublicKey, msg)
+    }
+
+    return coreSign(priv, msg, tag), nil
+}
+
+// Verify reports whether sig is a valid signature of msg by pub.
+func Verify(pub *PublicKey, scheme Scheme, msg, sig []byte) bool {
+    tag, err := dst(pub.Variant, scheme)
+    if err != nil {
+        return false
+    }
+
+    if scheme == MessageAugmentation {
+        msg = augment(pub, msg)
+    }
+
+    return coreVerify(pub, msg, sig, tag)
+}
+
+// Aggregate combines signatures of the same variant into one.
+func Aggregate(v Variant, sigs [][]byte) ([]byte, error) {
+    if len(sigs) == 0 {
+        return nil, ErrEmptyAggregate
+    }
+
+    switch v {
+        case MinPk:
+            agg := bls12381.NewG2()
+            for _, sig := range sigs {
+                p, err := decodeG2Signature(sig)
+                if err != nil {
+                    return nil, err
+                }
+
+                agg.Add(agg, p)
+            }
+
+            return agg.Bytes(), nil
+        case MinSig:
+            agg := bls12381.NewG1()
+            for _, sig := range sigs {
+                p, err := decodeG1Signature(sig)
+                if err != nil {
+                    return nil, err
+                }
+
+                agg.Add(agg, p)
+            }
+
+            return agg.Bytes(), nil
+    }
+
+    return nil, ErrInvalidVariant
+}
+
+// AggregatePublicKeys adds public keys, the result is used to verify
+// an aggregate signature of one message under ProofOfPossession.
+func AggregatePublicKeys(pubs []*PublicKey) (*PublicKey, error) {
+    if len(pubs) == 0 {
+        return nil, ErrEmptyAggregate
+    }
+
+    v := pubs[0].Variant
+    agg := &PublicKey{
+        Variant: v,
+    }
+
+    switch v {
+        case MinPk:
+            agg.g1 = bls12381.NewG1()
+        case MinSig:
+            agg.g2 = bls12381.NewG2()
+        default:
+            return nil, ErrInvalidVariant
+    }
+
+    for _, pub := range pubs {
+        if pub.Variant != v {
+            return nil, ErrMixedVariants
+        }
+
+        if v == MinSig {
+            agg.g2.Add(agg.g2, pub.g2)
+        } else {
+            agg.g1.Add(agg.g1, pub.g1)
+        }
+    }
+
+    return agg, nil
+}
+
+// AggregateVerify reports whether sig is an aggregate of signatures of
+// msgs[i] by pubs[i]. The Basic scheme rejects repeated messages.
+func AggregateVerify(pubs []*PublicKey, scheme Scheme, msgs [][]byte, sig []byte) bool {
+    if len(pubs) == 0 || len(pubs) != len(msgs) {
+        return false
+    }
+
+    v := pubs[0].Variant
+    for _, pub := range pubs {
+        if pub.Variant != v {
+            return false
+        }
+    }
+
+    tag, err := dst(v, scheme)
+    if err != nil {
+        return false
+    }
+
+    switch scheme {
+        case Basic:
+            seen := make(map[string]bool, len(msgs))
+            for _, msg := range msgs {
+                if seen[string(msg)] {
+                    return false
+                }
+
+                seen[string(msg)] = true
+            }
+        case MessageAugmentation:
+            augmented := make([][]byte, len(msgs))
+            for i := range msgs {
+                augmented[i] = augment(pubs[i], msgs[i])
+            }
+
+            msgs = augmented
+    }
+
+    return coreAggregateVerify(pubs, msgs, sig, tag)
+}
+
+// FastAggregateVerify reports whether sig is an aggregate of signatures
+// of msg by all of pubs, using the ProofOfPossession scheme. Each public
+// key must have been checked with PopVerify beforehand.
+func FastAggregateVerify(pubs []*PublicKey, msg, sig []byte) bool {
+    agg, err := AggregatePublicKeys(pubs)
+    if err != nil {
+        return false
+    }
+
+    tag, _ := dst(agg.Variant, ProofOfPossession)
+
+    return coreVerify(agg, msg, sig, tag)
+}
+
+// PopProve returns a proof of possession of priv.
+func PopProve(priv *PrivateKey) []byte {
+    return coreSign(priv, priv.PublicKey.Bytes(), popDST(priv.Variant))
+}
+
+// PopVerify reports whether proof is a valid proof of possession for pub.
+func PopVerify(pub *PublicKey, proof []byte) bool {
+    return coreVerify(pub, pub.Bytes(), proof, popDST(pub.Variant))
+}
+
+func augment(pub *PublicKey, msg []byte) []byte {
+    return append(pub.Bytes(), msg...)
+}
+
+func coreSign(priv *PrivateKey, msg, tag []byte) []byte {
+    if priv.Variant == MinSig {
+        q := new(bls12381.G1).Hash(msg, tag)
+        return q.ScalarMult(priv.D, q).Bytes()
+    }
+
+    q := new(bls12381.G2).Hash(msg, tag)
+    return q.ScalarMult(priv.D, q).Bytes()
+}
+
+func coreVerify(pub *PublicKey, msg, sig, tag []byte) bool {
+    return coreAggregateVerify([]*PublicKey{pub}, [][]byte{msg}, sig, tag)
+}
+
+// coreAggregateVerify checks that the product of e(PK_i, H(m_i)) equals
+// e(G, sig), with the arguments swapped for MinSig.
+func coreAggregateVerify(pubs []*PublicKey, msgs [][]byte, sig, tag []byte) bool {
+    n := len(pubs)
+
+    g1s := make([]*bls12381.G1, 0, n+1)
+    g2s := make([]*bls12381.G2, 0, n+1)
+
+    switch pubs[0].Variant {
+        case MinPk:
+            s, err := decodeG2Signature(sig)
+            if err != nil {
+                return false
+            }
+
+            for i := range pubs {
+                if pubs[i].g1 == nil || pubs[i].g1.IsIdentity() {
+                    return false
+                }
+
+                g1s = append(g1s, pubs[i].g1)
+                g2s = append(g2s, new(bls12381.G2).Hash(msgs[i], tag))
+            }
+
+            g1s = append(g1s, new(bls12381.G1).Neg(bls12381.G1Generator()))
+            g2s = append(g2s, s)
+        case MinSig:
+            s, err := decodeG1Signature(sig)
+            if err != nil {
+                return false
+            }
+
+            for i := range pubs {
+                if pubs[i].g2 == nil || pubs[i].g2.IsIdentity() {
+                    return false
+                }
+
+                g1s = append(g1s, new(bls12381.G1).Hash(msgs[i], tag))
+                g2s = append(g2s, pubs[i].g2)
+            }
+
+            g1s = append(g1s, s)
+            g2s = append(g2s, new(bls12381.G2).Neg(bls12381.G2Generator()))
+        default:
+            return false
+    }
+
+    return bls12381.PairingCheck(g1s, g2s)
+}
+
+func decodeG1Signature(sig []byte) (*bls12381.G1, error) {
+    if len(sig) != bls12381.G1CompressedSize {
+        return nil, ErrInvalidSignature
+    }
+
+    p, err := new(bls12381.G1).SetBytes(sig)
+    if err != nil {
+        return nil, ErrInvalidSignature
+    }
+
+    return p, nil
+}
+
+func decodeG2Signature(sig []byte) (*bls12381.G2, error) {
+    if len(sig) != bls12381.G2CompressedSize {
+        return nil, ErrInvalidSignature
+    }
+
+    p, err := new(bls12381.G2).SetBytes(sig)
+    if err != nil {
+        return nil, ErrInvalidSignature
+    }
+
+    return p, nil
+}
@@ -0,0 +1,44 @@
+package bls12381
+
+import (
+    "math/big"
+)
+
+// Serialization flags of the ZCash encoding, stored in the
+// three most significant bits of the first byte.
+const (
+    flagCompressed = 0x80
+    flagInfinity   = 0x40
+    flagSign       = 0x20
+)
+
+// ScalarSize is the size of a serialized scalar.
+const ScalarSize = 32
+
+var (
+    // r, the order of G1, G2 and Gt
+    orderBig = bigFromHex("0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+
+    // |x|, the curve is generated by x = -0xd201000000010000
+    xAbs = bigFromHex("0xd201000000010000")
+
+    // h_eff for G1 = 1 - x
+    g1HEff = bigFromHex("0xd201000000010001")
+
+    // h_eff for G2, from RFC 9380 section 8.8.2
+    g2HEff = bigFromHex("0xbc69f08f2ee75b3584c6a0ea91b352888e2a8e9145ad7689986ff031508ffe1329c2f178731db956d82bf015d1212b02ec0ec69d7477c1ae954cbc06689f6a359894c0adebbf6b4e8020005aaa95551")
+)
+
+// Order returns the order r of the groups.
+func Order() *big.Int {
+    return new(big.Int).Set(orderBig)
+}
+
+func bigFromHex(s string) *big.Int {
+    b, ok := new(big.Int).SetString(s, 0)
+    if !ok {
+        panic("bls12381: invalid hex constant " + s)
+    }
+
+    return b
+}
@@ -0,0 +1,88 @@
+package bls12381
+
+import (
+    "os"
+    "bytes"
+    "testing"
+)
+
+// the files hold the compressed encodings of i*G for i in [0, 1000)
+func Test_G1_CompressedVectors(t *testing.T) {
+    data, err := os.ReadFile("testdata/g1_compressed_valid_test_vectors.dat")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    p := NewG1()
+    for i := 0; i*G1CompressedSize < len(data); i++ {
+        enc := data[i*G1CompressedSize:(i+1)*G1CompressedSize]
+
+        if got := p.Bytes(); !bytes.Equal(got, enc) {
+            t.Fatalf("[%d] got %x, want %x", i, got, enc)
+        }
+
+        q, err := new(G1).SetBytes(enc)
+        if err != nil {
+            t.Fatalf("[%d] %v", i, err)
+        }
+        if !q.Equal(p) {
+            t.Fatalf("[%d] decoded point mismatch", i)
+        }
+
+        u, err := new(G1).SetBytes(p.BytesUncompressed())
+        if err != nil || !u.Equal(p) {
+            t.Fatalf("[%d] uncompressed round trip fail", i)
+        }
+
+        p.Add(p, G1Generator())
+    }
+}
+
+func Test_G2_CompressedVectors(t *testing.T) {
+    data, err := os.ReadFile("testdata/g2_compressed_valid_test_vectors.dat")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    p := NewG2()
+    for i := 0; i*G2CompressedSize < len(data); i++ {
+        enc := data[i*G2CompressedSize:(i+1)*G2CompressedSize]
+
+        if got := p.Bytes(); !bytes.Equal(got, enc) {
+            t.Fatalf("[%d] got %x, want %x", i, got, enc)
+        }
+
+        q, err := new(G2).SetBytes(enc)
+        if err != nil {
+            t.Fatalf("[%d] %v", i, err)
+        }
+        if !q.Equal(p) {
+            t.Fatalf("[%d] decoded point mismatch", i)
+        }
+
+        p.Add(p, G2Generator())
+    }
+}
+
+func Test_G1_SetBytes_Invalid(t *testing.T) {
+    enc := G1Generator().Bytes()
+
+    // a point not in the subgroup: x = 0 gives y^2 = 4
+    bad := make([]byte, G1CompressedSize)
+    bad[0] = flagCompressed
+    if _, err := new(G1).SetBytes(bad); err == nil {
+        t.Error("should reject point outside the subgroup")
+    }
+
+    // wrong length
+    if _, err := new(G1).SetBytes(enc[1:]); err == nil {
+        t.Error("should reject short input")
+    }
+
+    // infinity with extra bits
+    inf := NewG1().Bytes()
+    inf[1] = 1
+    if _, err := new(G1).SetBytes(inf); err == nil {
+        t.Error("should reject non-zero infinity")
+    }
+}
@@ -0,0 +1,327 @@
+package bls12381
+
+import (
+    "errors"
+    "math/big"
+    "math/bits"
+    "encoding/binary"
+)
+
+// fe is an element of GF(p) in Montgomery form,
+// stored as six little-endian 64-bit limbs.
+type fe [6]uint64
+
+// FpSize is the size of a serialized field element.
+const FpSize = 48
+
+// p = 0x1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab
+var modulus = fe{
+    0xb9feffffffffaaab, 0x1eabfffeb153ffff, 0x6730d2a0f6b0f624,
+    0x64774b84f38512bf, 0x4b1ba7b6434bacd7, 0x1a0111ea397fe69a,
+}
+
+// r1 = 2^384 mod p, the Montgomery form of one
+var r1 = fe{
+    0x760900000002fffd, 0xebf4000bc40c0002, 0x5f48985753c758ba,
+    0x77ce585370525745, 0x5c071a97a256ec6d, 0x15f65ec3fa80e493,
+}
+
+// r2 = 2^768 mod p
+var r2 = fe{
+    0xf4df1f341c341746, 0x0a76e6a609d104f1, 0x8de5476c4c95b6d5,
+    0x67eb88a9939d83c0, 0x9a793e85b519952d, 0x11988fe592cae3aa,
+}
+
+// inp = -p^-1 mod 2^64
+const inp = 0x89f3fffcfffcfffd
+
+var (
+    pBig = new(big.Int).SetBytes(modulus.rawBytes())
+
+    // (p - 3) / 4
+    pMinus3Over4 = new(big.Int).Rsh(pBig, 2)
+    // (p + 1) / 4
+    pPlus1Over4 = new(big.Int).Add(pMinus3Over4, big.NewInt(1))
+    // (p - 1) / 2
+    pMinus1Over2 = new(big.Int).Rsh(pBig, 1)
+    // p - 2
+    pMinus2 = new(big.Int).Sub(pBig, big.NewInt(2))
+)
+
+var errFpEncoding = errors.New("bls12381: invalid field element encoding")
+
+func (z *fe) setZero() *fe {
+    *z = fe{}
+    return z
+}
+
+func (z *fe) setOne() *fe {
+    *z = r1
+    return z
+}
+
+func (z *fe) set(x *fe) *fe {
+    *z = *x
+    return z
+}
+
+// setUint64 sets z = x.
+func (z *fe) setUint64(x uint64) *fe {
+    *z = fe{x}
+    return z.mul(z, &r2)
+}
+
+// setBig sets z = x mod p.
+func (z *fe) setBig(x *big.Int) *fe {
+    t := new(big.Int).Mod(x, pBig)
+
+    var buf [FpSize]byte
+    t.FillBytes(buf[:])
+
+    z.setRawBytes(buf[:])
+    return z.mul(z, &r2)
+}
+
+// setHex sets z from a hex string, it panics on error
+// as it is only used with constants.
+func (z *fe) setHex(s string) *fe {
+    x, ok := new(big.Int).SetString(s, 0)
+    if !ok {
+        panic("bls12381: invalid hex constant " + s)
+    }
+
+    return z.setBig(x)
+}
+
+// setBytes sets z from a 48-byte big-endian canonical encoding.
+func (z *fe) setBytes(b []byte) (*fe, error) {
+    if len(b) != FpSize {
+        return nil, errFpEncoding
+    }
+
+    var t fe
+    t.setRawBytes(b)
+
+    // reject non-canonical values
+    var borrow uint64
+    for i := 0; i < 6; i++ {
+        _, borrow = bits.Sub64(t[i], modulus[i], borrow)
+    }
+    if borrow == 0 {
+        return nil, errFpEncoding
+    }
+
+    *z = t
+    z.mul(z, &r2)
+
+    return z, nil
+}
+
+func (z *fe) setRawBytes(b []byte) {
+    for i := 0; i < 6; i++ {
+        z[i] = binary.BigEndian.Uint64(b[FpSize - 8*(i+1):])
+    }
+}
+
+func (z *fe) rawBytes() []byte {
+    var out [FpSize]byte
+    for i := 0; i < 6; i++ {
+        binary.BigEndian.PutUint64(out[FpSize - 8*(i+1):], z[i])
+    }
+
+    return out[:]
+}
+
+// bytes returns the 48-byte big-endian encoding of z.
+func (z *fe) bytes() []byte {
+    var t fe
+    t.fromMont(z)
+
+    return t.rawBytes()
+}
+
+// big returns z as a big.Int.
+func (z *fe) big() *big.Int {
+    return new(big.Int).SetBytes(z.bytes())
+}
+
+// fromMont sets z = x * R^-1.
+func (z *fe) fromMont(x *fe) *fe {
+    return z.mul(x, &fe{1})
+}
+
+func (z *fe) isZero() bool {
+    return *z == fe{}
+}
+
+func (z *fe) isOne() bool {
+    return *z == r1
+}
+
+func (z *fe) equal(x *fe) bool {
+    var acc uint64
+    for i := 0; i < 6; i++ {
+        acc |= z[i] ^ x[i]
+    }
+
+    return acc == 0
+}
+
+// cmov sets z = x if c == 1, leaves z unchanged if c == 0.
+func (z *fe) cmov(x *fe, c int) *fe {
+    mask := -uint64(c & 1)
+    for i := 0; i < 6; i++ {
+        z[i] ^= mask & (z[i] ^ x[i])
+    }
+
+    return z
+}
+
+// subP subtracts p from z if z >= p.
+func (z *fe) subP(carry uint64) {
+    var t fe
+    var borrow uint64
+    for i := 0; i < 6; i++ {
+        t[i], borrow = bits.Sub64(z[i], modulus[i], borrow)
+    }
+
+    // keep z if z < p and there is no carry
+    _, keep := bits.Sub64(carry, 0, borrow)
+    mask := keep - 1
+    for i := 0; i < 6; i++ {
+        z[i] = (z[i] &^ mask) | (t[i] & mask)
+    }
+}
+
+func (z *fe) add(x, y *fe) *fe {
+    var carry uint64
+    for i := 0; i < 6; i++ {
+        z[i], carry = bits.Add64(x[i], y[i], carry)
+    }
+
+    z.subP(carry)
+    return z
+}
+
+func (z *fe) double(x *fe) *fe {
+    return z.add(x, x)
+}
+
+func (z *fe) sub(x, y *fe) *fe {
+    var borrow uint64
+    for i := 0; i < 6; i++ {
+        z[i], borrow = bits.Sub64(x[i], y[i], borrow)
+    }
+
+    // add p back on underflow
+    mask := -borrow
+    var carry uint64
+    for i := 0; i < 6; i++ {
+        z[i], carry = bits.Add64(z[i], modulus[i] & mask, carry)
+    }
+
+    return z
+}
+
+func (z *fe) neg(x *fe) *fe {
+    var zero fe
+    return z.sub(&zero, x)
+}
+
+// mul sets z = x * y * R^-1 using word-by-word Montgomery multiplication.
+func (z *fe) mul(x, y *fe) *fe {
+    var t [12]uint64
+    var carry uint64
+
+    for i := 0; i < 6; i++ {
+        c1 := addMulVVW(t[i:6+i], x[:], y[i])
+
+        m := t[i] * inp
+        c2 := addMulVVW(t[i:6+i], modulus[:], m)
+
+        t[6+i], carry = bits.Add64(c1, c2, carry)
+    }
+
+    copy(z[:], t[6:12])
+
+    z.subP(carry)
+    return z
+}
+
+func (z *fe) square(x *fe) *fe {
+    return z.mul(x, x)
+}
+
+// exp sets z = x^e.
+func (z *fe) exp(x *fe, e *big.Int) *fe {
+    var res fe
+    res.setOne()
+
+    base := *x
+    for i := e.BitLen() - 1; i >= 0; i-- {
+        res.square(&res)
+        if e.Bit(i) == 1 {
+            res.mul(&res, &base)
+        }
+    }
+
+    *z = res
+    return z
+}
+
+// inverse sets z = x^-1, with 0^-1 = 0.
+func (z *fe) inverse(x *fe) *fe {
+    return z.exp(x, pMinus2)
+}
+
+// isSquare reports whether x is a square in GF(p), zero included.
+func (z *fe) isSquare() bool {
+    var t fe
+    t.exp(z, pMinus1Over2)
+
+    return t.isZero() || t.isOne()
+}
+
+// sqrt sets z to a square root of x and reports whether it exists.
+func (z *fe) sqrt(x *fe) bool {
+    var t, c fe
+    t.exp(x, pPlus1Over4)
+
+    c.square(&t)
+    if !c.equal(x) {
+        return false
+    }
+
+    *z = t
+    return true
+}
+
+// sgn0 returns the parity of z as in RFC 9380.
+func (z *fe) sgn0() int {
+    var t fe
+    t.fromMont(z)
+
+    return int(t[0] & 1)
+}
+
+// isLexLargest reports whether z > (p-1)/2.
+func (z *fe) isLexLargest() bool {
+    return z.big().Cmp(pMinus1Over2) > 0
+}
+
+// addMulVVW multiplies the multi-word value x by the single-word value y,
+// adding the result to the multi-word value z and returning the final carry.
+func addMulVVW(z, x []uint64, y uint64) (carry uint64) {
+    _ = x[len(z)-1]
+    for i := range z {
+        hi, lo := bits.Mul64(x[i], y)
+        lo, c := bits.Add64(lo, z[i], 0)
+        hi, _ = bits.Add64(hi, 0, c)
+        lo, c = bits.Add64(lo, carry, 0)
+        hi, _ = bits.Add64(hi, 0, c)
+        carry = hi
+        z[i] = lo
+    }
+
+    return carry
+}
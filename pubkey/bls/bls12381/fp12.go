@@ -0,0 +1,188 @@
+package bls12381
+
+import (
+    "math/big"
+)
+
+// fe12 is an element of GF(p^12) = GF(p^6)[w] / (w^2 - v),
+// the value is c0 + c1*w.
+type fe12 struct {
+    c0, c1 fe6
+}
+
+// frobCoeffs[k] = xi^(k*(p-1)/6), used to map w^k to (w^k)^p.
+var frobCoeffs [6]fe2
+
+func init() {
+    var xi fe2
+    xi.c0.setOne()
+    xi.c1.setOne()
+
+    pMinus1Over6 := new(big.Int).Sub(pBig, big.NewInt(1))
+    pMinus1Over6.Div(pMinus1Over6, big.NewInt(6))
+
+    for k := 0; k < 6; k++ {
+        e := new(big.Int).Mul(pMinus1Over6, big.NewInt(int64(k)))
+        frobCoeffs[k].exp(&xi, e)
+    }
+}
+
+func (z *fe12) setOne() *fe12 {
+    z.c0.setOne()
+    z.c1.setZero()
+    return z
+}
+
+func (z *fe12) set(x *fe12) *fe12 {
+    *z = *x
+    return z
+}
+
+func (z *fe12) isOne() bool {
+    return z.c0.isOne() && z.c1.isZero()
+}
+
+func (z *fe12) equal(x *fe12) bool {
+    return z.c0.equal(&x.c0) && z.c1.equal(&x.c1)
+}
+
+func (z *fe12) mul(x, y *fe12) *fe12 {
+    var v0, v1, t0, t1 fe6
+
+    v0.mul(&x.c0, &y.c0)
+    v1.mul(&x.c1, &y.c1)
+
+    // c1 = (x0 + x1)(y0 + y1) - v0 - v1
+    t0.add(&x.c0, &x.c1)
+    t1.add(&y.c0, &y.c1)
+    t0.mul(&t0, &t1)
+    t0.sub(&t0, &v0)
+    z.c1.sub(&t0, &v1)
+
+    // c0 = v0 + v*v1
+    v1.mulByNonResidue(&v1)
+    z.c0.add(&v0, &v1)
+
+    return z
+}
+
+// square uses the complex squaring method.
+func (z *fe12) square(x *fe12) *fe12 {
+    var t0, t1, t2 fe6
+
+    // t0 = x0*x1
+    t0.mul(&x.c0, &x.c1)
+
+    // c0 = (x0 + x1)(x0 + v*x1) - t0 - v*t0
+    t1.mulByNonResidue(&x.c1)
+    t1.add(&t1, &x.c0)
+    t2.add(&x.c0, &x.c1)
+    t1.mul(&t1, &t2)
+    t1.sub(&t1, &t0)
+    t2.mulByNonResidue(&t0)
+
+    z.c0.sub(&t1, &t2)
+    z.c1.double(&t0)
+
+    return z
+}
+
+// conjugate sets z = x0 - x1*w, which equals x^(p^6).
+func (z *fe12) conjugate(x *fe12) *fe12 {
+    z.c0 = x.c0
+    z.c1.neg(&x.c1)
+    return z
+}
+
+// inverse sets z = x^-1.
+func (z *fe12) inverse(x *fe12) *fe12 {
+    var t0, t1 fe6
+
+    // t = x0^2 - v*x1^2
+    t0.square(&x.c0)
+    t1.square(&x.c1)
+    t1.mulByNonResidue(&t1)
+    t0.sub(&t0, &t1)
+    t0.inverse(&t0)
+
+    z.c0.mul(&x.c0, &t0)
+    t0.neg(&t0)
+    z.c1.mul(&x.c1, &t0)
+
+    return z
+}
+
+// frobenius sets z = x^p.
+func (z *fe12) frobenius(x *fe12) *fe12 {
+    // coefficients of w^0, w^2, w^4 and w^1, w^3, w^5
+    z.c0.c0.conjugate(&x.c0.c0)
+    z.c0.c1.conjugate(&x.c0.c1)
+    z.c0.c2.conjugate(&x.c0.c2)
+    z.c1.c0.conjugate(&x.c1.c0)
+    z.c1.c1.conjugate(&x.c1.c1)
+    z.c1.c2.conjugate(&x.c1.c2)
+
+    z.c0.c1.mul(&z.c0.c1, &frobCoeffs[2])
+    z.c0.c2.mul(&z.c0.c2, &frobCoeffs[4])
+    z.c1.c0.mul(&z.c1.c0, &frobCoeffs[1])
+    z.c1.c1.mul(&z.c1.c1, &frobCoeffs[3])
+    z.c1.c2.mul(&z.c1.c2, &frobCoeffs[5])
+
+    return z
+}
+
+// exp sets z = x^e.
+func (z *fe12) exp(x *fe12, e *big.Int) *fe12 {
+    var res fe12
+    res.setOne()
+
+    base := *x
+    for i := e.BitLen() - 1; i >= 0; i-- {
+        res.square(&res)
+        if e.Bit(i) == 1 {
+            res.mul(&res, &base)
+        }
+    }
+
+    *z = res
+    return z
+}
+
+// bytes returns the 576-byte encoding, most significant coefficient first.
+func (z *fe12) bytes() []byte {
+    coeffs := []*fe2{
+        &z.c1.c2, &z.c1.c1, &z.c1.c0,
+        &z.c0.c2, &z.c0.c1, &z.c0.c0,
+    }
+
+    out := make([]byte, 0, 12*FpSize)
+    for _, c := range coeffs {
+        out = append(out, c.c1.bytes()...)
+        out = append(out, c.c0.bytes()...)
+    }
+
+    return out
+}
+
+// setBytes decodes the encoding produced by bytes.
+func (z *fe12) setBytes(b []byte) (*fe12, error) {
+    if len(b) != 12*FpSize {
+        return nil, errFpEncoding
+    }
+
+    coeffs := []*fe2{
+        &z.c1.c2, &z.c1.c1, &z.c1.c0,
+        &z.c0.c2, &z.c0.c1, &z.c0.c0,
+    }
+
+    for i, c := range coeffs {
+        if _, err := c.c1.setBytes(b[(2*i)*FpSize:(2*i+1)*FpSize]); err != nil {
+            return nil, err
+        }
+        if _, err := c.c0.setBytes(b[(2*i+1)*FpSize:(2*i+2)*FpSize]); err != nil {
+            return nil, err
+        }
+    }
+
+    return z, nil
+}
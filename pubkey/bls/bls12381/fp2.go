@@ -0,0 +1,266 @@
+package bls12381
+
+import (
+    "math/big"
+)
+
+// fe2 is an element of GF(p^2) = GF(p)[u] / (u^2 + 1),
+// the value is c0 + c1*u.
+type fe2 struct {
+    c0, c1 fe
+}
+
+// Fp2Size is the size of a serialized GF(p^2) element.
+const Fp2Size = 2 * FpSize
+
+func (z *fe2) setZero() *fe2 {
+    z.c0.setZero()
+    z.c1.setZero()
+    return z
+}
+
+func (z *fe2) setOne() *fe2 {
+    z.c0.setOne()
+    z.c1.setZero()
+    return z
+}
+
+func (z *fe2) set(x *fe2) *fe2 {
+    *z = *x
+    return z
+}
+
+// setHex sets z = a + b*u from hex constants.
+func (z *fe2) setHex(a, b string) *fe2 {
+    z.c0.setHex(a)
+    z.c1.setHex(b)
+    return z
+}
+
+// bytes returns c1 || c0, the encoding used by ZCash.
+func (z *fe2) bytes() []byte {
+    out := make([]byte, 0, Fp2Size)
+    out = append(out, z.c1.bytes()...)
+    out = append(out, z.c0.bytes()...)
+    return out
+}
+
+// setBytes decodes c1 || c0.
+func (z *fe2) setBytes(b []byte) (*fe2, error) {
+    if len(b) != Fp2Size {
+        return nil, errFpEncoding
+    }
+
+    if _, err := z.c1.setBytes(b[:FpSize]); err != nil {
+        return nil, err
+    }
+    if _, err := z.c0.setBytes(b[FpSize:]); err != nil {
+        return nil, err
+    }
+
+    return z, nil
+}
+
+func (z *fe2) isZero() bool {
+    return z.c0.isZero() && z.c1.isZero()
+}
+
+func (z *fe2) isOne() bool {
+    return z.c0.isOne() && z.c1.isZero()
+}
+
+func (z *fe2) equal(x *fe2) bool {
+    return z.c0.equal(&x.c0) && z.c1.equal(&x.c1)
+}
+
+func (z *fe2) cmov(x *fe2, c int) *fe2 {
+    z.c0.cmov(&x.c0, c)
+    z.c1.cmov(&x.c1, c)
+    return z
+}
+
+func (z *fe2) add(x, y *fe2) *fe2 {
+    z.c0.add(&x.c0, &y.c0)
+    z.c1.add(&x.c1, &y.c1)
+    return z
+}
+
+func (z *fe2) double(x *fe2) *fe2 {
+    z.c0.double(&x.c0)
+    z.c1.double(&x.c1)
+    return z
+}
+
+func (z *fe2) sub(x, y *fe2) *fe2 {
+    z.c0.sub(&x.c0, &y.c0)
+    z.c1.sub(&x.c1, &y.c1)
+    return z
+}
+
+func (z *fe2) neg(x *fe2) *fe2 {
+    z.c0.neg(&x.c0)
+    z.c1.neg(&x.c1)
+    return z
+}
+
+// conjugate sets z = c0 - c1*u, which is also x^p.
+func (z *fe2) conjugate(x *fe2) *fe2 {
+    z.c0.set(&x.c0)
+    z.c1.neg(&x.c1)
+    return z
+}
+
+// mul uses Karatsuba:
+// (a0 + a1*u)(b0 + b1*u) = (a0*b0 - a1*b1) + ((a0 + a1)(b0 + b1) - a0*b0 - a1*b1)*u
+func (z *fe2) mul(x, y *fe2) *fe2 {
+    var v0, v1, t0, t1 fe
+
+    v0.mul(&x.c0, &y.c0)
+    v1.mul(&x.c1, &y.c1)
+
+    t0.add(&x.c0, &x.c1)
+    t1.add(&y.c0, &y.c1)
+    t0.mul(&t0, &t1)
+    t0.sub(&t0, &v0)
+
+    z.c1.sub(&t0, &v1)
+    z.c0.sub(&v0, &v1)
+
+    return z
+}
+
+// square uses (a0 + a1*u)^2 = (a0 + a1)(a0 - a1) + 2*a0*a1*u
+func (z *fe2) square(x *fe2) *fe2 {
+    var t0, t1, t2 fe
+
+    t0.add(&x.c0, &x.c1)
+    t1.sub(&x.c0, &x.c1)
+    t2.double(&x.c0)
+
+    z.c1.mul(&t2, &x.c1)
+    z.c0.mul(&t0, &t1)
+
+    return z
+}
+
+// mulByFp sets z = x * c for c in GF(p).
+func (z *fe2) mulByFp(x *fe2, c *fe) *fe2 {
+    z.c0.mul(&x.c0, c)
+    z.c1.mul(&x.c1, c)
+    return z
+}
+
+// mulByNonResidue sets z = x * (1 + u).
+func (z *fe2) mulByNonResidue(x *fe2) *fe2 {
+    var t fe
+    t.sub(&x.c0, &x.c1)
+    z.c1.add(&x.c0, &x.c1)
+    z.c0.set(&t)
+    return z
+}
+
+// norm returns c0^2 + c1^2.
+func (z *fe2) norm() *fe {
+    var t0, t1 fe
+    t0.square(&z.c0)
+    t1.square(&z.c1)
+
+    return t0.add(&t0, &t1)
+}
+
+// inverse sets z = x^-1 = conj(x) / norm(x).
+func (z *fe2) inverse(x *fe2) *fe2 {
+    t := x.norm()
+    t.inverse(t)
+
+    z.c0.mul(&x.c0, t)
+    var c1 fe
+    c1.mul(&x.c1, t)
+    z.c1.neg(&c1)
+
+    return z
+}
+
+// exp sets z = x^e.
+func (z *fe2) exp(x *fe2, e *big.Int) *fe2 {
+    var res fe2
+    res.setOne()
+
+    base := *x
+    for i := e.BitLen() - 1; i >= 0; i-- {
+        res.square(&res)
+        if e.Bit(i) == 1 {
+            res.mul(&res, &base)
+        }
+    }
+
+    *z = res
+    return z
+}
+
+// isSquare reports whether x is a square in GF(p^2),
+// which is true when its norm is a square in GF(p).
+func (z *fe2) isSquare() bool {
+    return z.norm().isSquare()
+}
+
+// sqrt sets z to a square root of x and reports whether it exists.
+// It uses Algorithm 9 of https://eprint.iacr.org/2012/685.pdf.
+func (z *fe2) sqrt(x *fe2) bool {
+    if x.isZero() {
+        z.setZero()
+        return true
+    }
+
+    var a1, alpha, x0, minusOne, t fe2
+
+    a1.exp(x, pMinus3Over4)
+    alpha.square(&a1)
+    alpha.mul(&alpha, x)
+    x0.mul(&a1, x)
+
+    minusOne.setOne()
+    minusOne.neg(&minusOne)
+
+    if alpha.equal(&minusOne) {
+        // x0 * u
+        t.c0.neg(&x0.c1)
+        t.c1.set(&x0.c0)
+    } else {
+        var b fe2
+        b.setOne()
+        b.add(&b, &alpha)
+        b.exp(&b, pMinus1Over2)
+        t.mul(&b, &x0)
+    }
+
+    var c fe2
+    c.square(&t)
+    if !c.equal(x) {
+        return false
+    }
+
+    *z = t
+    return true
+}
+
+// sgn0 returns the sign of z as in RFC 9380.
+func (z *fe2) sgn0() int {
+    sign0 := z.c0.sgn0()
+    zero0 := 0
+    if z.c0.isZero() {
+        zero0 = 1
+    }
+    sign1 := z.c1.sgn0()
+
+    return sign0 | (zero0 & sign1)
+}
+
+// isLexLargest compares c1 first, then c0 when c1 is zero.
+func (z *fe2) isLexLargest() bool {
+    if !z.c1.isZero() {
+        return z.c1.isLexLargest()
+    }
+
+    return z.c0.isLexLargest()
+}
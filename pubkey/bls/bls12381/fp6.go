@@ -0,0 +1,161 @@
+package bls12381
+
+// fe6 is an element of GF(p^6) = GF(p^2)[v] / (v^3 - (1 + u)),
+// the value is c0 + c1*v + c2*v^2.
+type fe6 struct {
+    c0, c1, c2 fe2
+}
+
+func (z *fe6) setZero() *fe6 {
+    z.c0.setZero()
+    z.c1.setZero()
+    z.c2.setZero()
+    return z
+}
+
+func (z *fe6) setOne() *fe6 {
+    z.c0.setOne()
+    z.c1.setZero()
+    z.c2.setZero()
+    return z
+}
+
+func (z *fe6) isZero() bool {
+    return z.c0.isZero() && z.c1.isZero() && z.c2.isZero()
+}
+
+func (z *fe6) isOne() bool {
+    return z.c0.isOne() && z.c1.isZero() && z.c2.isZero()
+}
+
+func (z *fe6) equal(x *fe6) bool {
+    return z.c0.equal(&x.c0) && z.c1.equal(&x.c1) && z.c2.equal(&x.c2)
+}
+
+func (z *fe6) add(x, y *fe6) *fe6 {
+    z.c0.add(&x.c0, &y.c0)
+    z.c1.add(&x.c1, &y.c1)
+    z.c2.add(&x.c2, &y.c2)
+    return z
+}
+
+func (z *fe6) double(x *fe6) *fe6 {
+    z.c0.double(&x.c0)
+    z.c1.double(&x.c1)
+    z.c2.double(&x.c2)
+    return z
+}
+
+func (z *fe6) sub(x, y *fe6) *fe6 {
+    z.c0.sub(&x.c0, &y.c0)
+    z.c1.sub(&x.c1, &y.c1)
+    z.c2.sub(&x.c2, &y.c2)
+    return z
+}
+
+func (z *fe6) neg(x *fe6) *fe6 {
+    z.c0.neg(&x.c0)
+    z.c1.neg(&x.c1)
+    z.c2.neg(&x.c2)
+    return z
+}
+
+// mul uses the Karatsuba method from
+// "Multiplication and Squaring on Pairing-Friendly Fields",
+// https://eprint.iacr.org/2006/471.pdf
+func (z *fe6) mul(x, y *fe6) *fe6 {
+    var v0, v1, v2, t0, t1, c0, c1, c2 fe2
+
+    v0.mul(&x.c0, &y.c0)
+    v1.mul(&x.c1, &y.c1)
+    v2.mul(&x.c2, &y.c2)
+
+    // c0 = v0 + xi*((x1 + x2)(y1 + y2) - v1 - v2)
+    t0.add(&x.c1, &x.c2)
+    t1.add(&y.c1, &y.c2)
+    c0.mul(&t0, &t1)
+    c0.sub(&c0, &v1)
+    c0.sub(&c0, &v2)
+    c0.mulByNonResidue(&c0)
+    c0.add(&c0, &v0)
+
+    // c1 = (x0 + x1)(y0 + y1) - v0 - v1 + xi*v2
+    t0.add(&x.c0, &x.c1)
+    t1.add(&y.c0, &y.c1)
+    c1.mul(&t0, &t1)
+    c1.sub(&c1, &v0)
+    c1.sub(&c1, &v1)
+    t0.mulByNonResidue(&v2)
+    c1.add(&c1, &t0)
+
+    // c2 = (x0 + x2)(y0 + y2) - v0 - v2 + v1
+    t0.add(&x.c0, &x.c2)
+    t1.add(&y.c0, &y.c2)
+    c2.mul(&t0, &t1)
+    c2.sub(&c2, &v0)
+    c2.sub(&c2, &v2)
+    c2.add(&c2, &v1)
+
+    z.c0, z.c1, z.c2 = c0, c1, c2
+    return z
+}
+
+func (z *fe6) square(x *fe6) *fe6 {
+    return z.mul(x, x)
+}
+
+// mulByFp2 sets z = x * c for c in GF(p^2).
+func (z *fe6) mulByFp2(x *fe6, c *fe2) *fe6 {
+    z.c0.mul(&x.c0, c)
+    z.c1.mul(&x.c1, c)
+    z.c2.mul(&x.c2, c)
+    return z
+}
+
+// mulByNonResidue sets z = x * v.
+func (z *fe6) mulByNonResidue(x *fe6) *fe6 {
+    var t fe2
+    t.mulByNonResidue(&x.c2)
+
+    z.c2.set(&x.c1)
+    z.c1.set(&x.c0)
+    z.c0.set(&t)
+    return z
+}
+
+// inverse sets z = x^-1.
+func (z *fe6) inverse(x *fe6) *fe6 {
+    var a, b, c, t, f fe2
+
+    // a = x0^2 - xi*x1*x2
+    a.square(&x.c0)
+    t.mul(&x.c1, &x.c2)
+    t.mulByNonResidue(&t)
+    a.sub(&a, &t)
+
+    // b = xi*x2^2 - x0*x1
+    b.square(&x.c2)
+    b.mulByNonResidue(&b)
+    t.mul(&x.c0, &x.c1)
+    b.sub(&b, &t)
+
+    // c = x1^2 - x0*x2
+    c.square(&x.c1)
+    t.mul(&x.c0, &x.c2)
+    c.sub(&c, &t)
+
+    // f = x0*a + xi*(x2*b + x1*c)
+    f.mul(&x.c2, &b)
+    t.mul(&x.c1, &c)
+    f.add(&f, &t)
+    f.mulByNonResidue(&f)
+    t.mul(&x.c0, &a)
+    f.add(&f, &t)
+
+    f.inverse(&f)
+
+    z.c0.mul(&a, &f)
+    z.c1.mul(&b, &f)
+    z.c2.mul(&c, &f)
+    return z
+}
@@ -0,0 +1,402 @@
+package bls12381
+
+import (
+    "errors"
+    "math/big"
+)
+
+const (
+    // G1CompressedSize is the size of a compressed G1 point.
+    G1CompressedSize = FpSize
+    // G1UncompressedSize is the size of an uncompressed G1 point.
+    G1UncompressedSize = 2 * FpSize
+)
+
+var (
+    errG1Encoding  = errors.New("bls12381: invalid G1 point encoding")
+    errG1NotOnCurve = errors.New("bls12381: G1 point is not on the curve")
+    errG1Subgroup  = errors.New("bls12381: G1 point is not in the subgroup")
+)
+
+// b = 4
+var g1B = new(fe).setUint64(4)
+
+var g1Gen = func() *G1 {
+    p := &G1{}
+    p.x.setHex("0x17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb")
+    p.y.setHex("0x08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1")
+    p.z.setOne()
+    return p
+}()
+
+// G1 is a point of E(Fp): y^2 = x^3 + 4 in Jacobian coordinates,
+// (X, Y, Z) represents (X/Z^2, Y/Z^3), Z = 0 is the point at infinity.
+type G1 struct {
+    x, y, z fe
+}
+
+// NewG1 returns the point at infinity.
+func NewG1() *G1 {
+    return new(G1).SetIdentity()
+}
+
+// G1Generator returns the generator of G1.
+func G1Generator() *G1 {
+    return new(G1).Set(g1Gen)
+}
+
+func (p *G1) Set(q *G1) *G1 {
+    *p = *q
+    return p
+}
+
+// SetIdentity sets p to the point at infinity.
+func (p *G1) SetIdentity() *G1 {
+    p.x.setZero()
+    p.y.setOne()
+    p.z.setZero()
+    return p
+}
+
+// IsIdentity reports whether p is the point at infinity.
+func (p *G1) IsIdentity() bool {
+    return p.z.isZero()
+}
+
+// Equal reports whether p and q are the same point.
+func (p *G1) Equal(q *G1) bool {
+    if p.IsIdentity() || q.IsIdentity() {
+        return p.IsIdentity() && q.IsIdentity()
+    }
+
+    // X1*Z2^2 == X2*Z1^2 and Y1*Z2^3 == Y2*Z1^3
+    var z1z1, z2z2, u1, u2, s1, s2 fe
+    z1z1.square(&p.z)
+    z2z2.square(&q.z)
+    u1.mul(&p.x, &z2z2)
+    u2.mul(&q.x, &z1z1)
+    s1.mul(&p.y, &q.z)
+    s1.mul(&s1, &z2z2)
+    s2.mul(&q.y, &p.z)
+    s2.mul(&s2, &z1z1)
+
+    return u1.equal(&u2) && s1.equal(&s2)
+}
+
+// affine returns the affine coordinates of p, p must not be the identity.
+func (p *G1) affine() (x, y fe) {
+    var zinv, zinv2 fe
+    zinv.inverse(&p.z)
+    zinv2.square(&zinv)
+
+    x.mul(&p.x, &zinv2)
+    zinv2.mul(&zinv2, &zinv)
+    y.mul(&p.y, &zinv2)
+
+    return
+}
+
+// setAffine sets p = (x, y).
+func (p *G1) setAffine(x, y *fe) *G1 {
+    p.x.set(x)
+    p.y.set(y)
+    p.z.setOne()
+    return p
+}
+
+// IsOnCurve reports whether p is on the curve.
+func (p *G1) IsOnCurve() bool {
+    if p.IsIdentity() {
+        return true
+    }
+
+    // Y^2 = X^3 + b*Z^6
+    var y2, x3, z6 fe
+    y2.square(&p.y)
+    x3.square(&p.x)
+    x3.mul(&x3, &p.x)
+    z6.square(&p.z)
+    z6.mul(&z6, &p.z)
+    z6.square(&z6)
+    z6.mul(&z6, g1B)
+    x3.add(&x3, &z6)
+
+    return y2.equal(&x3)
+}
+
+// IsInSubgroup reports whether p is in the prime order subgroup.
+func (p *G1) IsInSubgroup() bool {
+    var t G1
+    t.ScalarMult(orderBig, p)
+    return t.IsIdentity()
+}
+
+// Neg sets p = -q.
+func (p *G1) Neg(q *G1) *G1 {
+    p.x.set(&q.x)
+    p.y.neg(&q.y)
+    p.z.set(&q.z)
+    return p
+}
+
+// Double sets p = 2q.
+func (p *G1) Double(q *G1) *G1 {
+    if q.IsIdentity() {
+        return p.Set(q)
+    }
+
+    // http://www.hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+    var a, b, c, d, e, f, t fe
+
+    a.square(&q.x)
+    b.square(&q.y)
+    c.square(&b)
+
+    d.add(&q.x, &b)
+    d.square(&d)
+    d.sub(&d, &a)
+    d.sub(&d, &c)
+    d.double(&d)
+
+    e.double(&a)
+    e.add(&e, &a)
+    f.square(&e)
+
+    var z3 fe
+    z3.mul(&q.y, &q.z)
+    z3.double(&z3)
+
+    var x3 fe
+    t.double(&d)
+    x3.sub(&f, &t)
+
+    var y3 fe
+    y3.sub(&d, &x3)
+    y3.mul(&y3, &e)
+    c.double(&c)
+    c.double(&c)
+    c.double(&c)
+    y3.sub(&y3, &c)
+
+    p.x, p.y, p.z = x3, y3, z3
+    return p
+}
+
+// Add sets p = a + b.
+func (p *G1) Add(a, b *G1) *G1 {
+    if a.IsIdentity() {
+        return p.Set(b)
+    }
+    if b.IsIdentity() {
+        return p.Set(a)
+    }
+
+    // http://www.hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+    var z1z1, z2z2, u1, u2, s1, s2, h, i, j, r, v, t fe
+
+    z1z1.square(&a.z)
+    z2z2.square(&b.z)
+    u1.mul(&a.x, &z2z2)
+    u2.mul(&b.x, &z1z1)
+    s1.mul(&a.y, &b.z)
+    s1.mul(&s1, &z2z2)
+    s2.mul(&b.y, &a.z)
+    s2.mul(&s2, &z1z1)
+
+    h.sub(&u2, &u1)
+    r.sub(&s2, &s1)
+
+    if h.isZero() {
+        if r.isZero() {
+            return p.Double(a)
+        }
+
+        return p.SetIdentity()
+    }
+
+    i.double(&h)
+    i.square(&i)
+    j.mul(&h, &i)
+    r.double(&r)
+    v.mul(&u1, &i)
+
+    var x3, y3, z3 fe
+
+    x3.square(&r)
+    x3.sub(&x3, &j)
+    t.double(&v)
+    x3.sub(&x3, &t)
+
+    y3.sub(&v, &x3)
+    y3.mul(&y3, &r)
+    t.mul(&s1, &j)
+    t.double(&t)
+    y3.sub(&y3, &t)
+
+    z3.add(&a.z, &b.z)
+    z3.square(&z3)
+    z3.sub(&z3, &z1z1)
+    z3.sub(&z3, &z2z2)
+    z3.mul(&z3, &h)
+
+    p.x, p.y, p.z = x3, y3, z3
+    return p
+}
+
+// Sub sets p = a - b.
+func (p *G1) Sub(a, b *G1) *G1 {
+    var t G1
+    t.Neg(b)
+    return p.Add(a, &t)
+}
+
+// ScalarMult sets p = k*q.
+func (p *G1) ScalarMult(k *big.Int, q *G1) *G1 {
+    var r G1
+    r.SetIdentity()
+
+    base := *q
+
+    e := k
+    if k.Sign() < 0 {
+        e = new(big.Int).Neg(k)
+        base.Neg(&base)
+    }
+
+    for i := e.BitLen() - 1; i >= 0; i-- {
+        r.Double(&r)
+        if e.Bit(i) == 1 {
+            r.Add(&r, &base)
+        }
+    }
+
+    *p = r
+    return p
+}
+
+// ScalarBaseMult sets p = k*G.
+func (p *G1) ScalarBaseMult(k *big.Int) *G1 {
+    return p.ScalarMult(k, g1Gen)
+}
+
+// ClearCofactor sets p = h_eff * q, mapping q into G1.
+func (p *G1) ClearCofactor(q *G1) *G1 {
+    return p.ScalarMult(g1HEff, q)
+}
+
+// Bytes returns the compressed encoding of p.
+func (p *G1) Bytes() []byte {
+    out := make([]byte, G1CompressedSize)
+    if p.IsIdentity() {
+        out[0] = flagCompressed | flagInfinity
+        return out
+    }
+
+    x, y := p.affine()
+    copy(out, x.bytes())
+
+    out[0] |= flagCompressed
+    if y.isLexLargest() {
+        out[0] |= flagSign
+    }
+
+    return out
+}
+
+// BytesUncompressed returns the uncompressed encoding of p.
+func (p *G1) BytesUncompressed() []byte {
+    out := make([]byte, G1UncompressedSize)
+    if p.IsIdentity() {
+        out[0] = flagInfinity
+        return out
+    }
+
+    x, y := p.affine()
+    copy(out, x.bytes())
+    copy(out[FpSize:], y.bytes())
+
+    return out
+}
+
+// SetBytes decodes a compressed or uncompressed point and
+// checks that it is on the curve and in the subgroup.
+func (p *G1) SetBytes(b []byte) (*G1, error) {
+    if _, err := p.setBytes(b); err != nil {
+        return nil, err
+    }
+
+    if !p.IsInSubgroup() {
+        return nil, errG1Subgroup
+    }
+
+    return p, nil
+}
+
+func (p *G1) setBytes(b []byte) (*G1, error) {
+    if len(b) == 0 {
+        return nil, errG1Encoding
+    }
+
+    compressed := b[0] & flagCompressed != 0
+    infinity := b[0] & flagInfinity != 0
+    sign := b[0] & flagSign != 0
+
+    if (compressed && len(b) != G1CompressedSize) ||
+        (!compressed && len(b) != G1UncompressedSize) {
+        return nil, errG1Encoding
+    }
+
+    buf := make([]byte, len(b))
+    copy(buf, b)
+    buf[0] &= 0x1f
+
+    if infinity {
+        if sign && compressed {
+            return nil, errG1Encoding
+        }
+
+        for _, v := range buf {
+            if v != 0 {
+                return nil, errG1Encoding
+            }
+        }
+
+        return p.SetIdentity(), nil
+    }
+
+    var x, y fe
+    if _, err := x.setBytes(buf[:FpSize]); err != nil {
+        return nil, errG1Encoding
+    }
+
+    if compressed {
+        // y^2 = x^3 + 4
+        var y2 fe
+        y2.square(&x)
+        y2.mul(&y2, &x)
+        y2.add(&y2, g1B)
+
+        if !y.sqrt(&y2) {
+            return nil, errG1NotOnCurve
+        }
+
+        if y.isLexLargest() != sign {
+            y.neg(&y)
+        }
+    } else {
+        if sign {
+            return nil, errG1Encoding
+        }
+
+        if _, err := y.setBytes(buf[FpSize:]); err != nil {
+            return nil, errG1Encoding
+        }
+    }
+
+    p.setAffine(&x, &y)
+    if !p.IsOnCurve() {
+        return nil, errG1NotOnCurve
+    }
+
+    return p, nil
+}
@@ -0,0 +1,408 @@
+package bls12381
+
+import (
+    "errors"
+    "math/big"
+)
+
+const (
+    // G2CompressedSize is the size of a compressed G2 point.
+    G2CompressedSize = Fp2Size
+    // G2UncompressedSize is the size of an uncompressed G2 point.
+    G2UncompressedSize = 2 * Fp2Size
+)
+
+var (
+    errG2Encoding  = errors.New("bls12381: invalid G2 point encoding")
+    errG2NotOnCurve = errors.New("bls12381: G2 point is not on the curve")
+    errG2Subgroup  = errors.New("bls12381: G2 point is not in the subgroup")
+)
+
+// b = 4(1 + u)
+var g2B = new(fe2).setHex("4", "4")
+
+var g2Gen = func() *G2 {
+    p := &G2{}
+    p.x.setHex(
+        "0x024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8",
+        "0x13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e",
+    )
+    p.y.setHex(
+        "0x0ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801",
+        "0x0606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+    )
+    p.z.setOne()
+    return p
+}()
+
+// G2 is a point of the twist E'(Fp2): y^2 = x^3 + 4(1 + u) in Jacobian coordinates,
+// (X, Y, Z) represents (X/Z^2, Y/Z^3), Z = 0 is the point at infinity.
+type G2 struct {
+    x, y, z fe2
+}
+
+// NewG2 returns the point at infinity.
+func NewG2() *G2 {
+    return new(G2).SetIdentity()
+}
+
+// G2Generator returns the generator of G2.
+func G2Generator() *G2 {
+    return new(G2).Set(g2Gen)
+}
+
+func (p *G2) Set(q *G2) *G2 {
+    *p = *q
+    return p
+}
+
+// SetIdentity sets p to the point at infinity.
+func (p *G2) SetIdentity() *G2 {
+    p.x.setZero()
+    p.y.setOne()
+    p.z.setZero()
+    return p
+}
+
+// IsIdentity reports whether p is the point at infinity.
+func (p *G2) IsIdentity() bool {
+    return p.z.isZero()
+}
+
+// Equal reports whether p and q are the same point.
+func (p *G2) Equal(q *G2) bool {
+    if p.IsIdentity() || q.IsIdentity() {
+        return p.IsIdentity() && q.IsIdentity()
+    }
+
+    // X1*Z2^2 == X2*Z1^2 and Y1*Z2^3 == Y2*Z1^3
+    var z1z1, z2z2, u1, u2, s1, s2 fe2
+    z1z1.square(&p.z)
+    z2z2.square(&q.z)
+    u1.mul(&p.x, &z2z2)
+    u2.mul(&q.x, &z1z1)
+    s1.mul(&p.y, &q.z)
+    s1.mul(&s1, &z2z2)
+    s2.mul(&q.y, &p.z)
+    s2.mul(&s2, &z1z1)
+
+    return u1.equal(&u2) && s1.equal(&s2)
+}
+
+// affine returns the affine coordinates of p, p must not be the identity.
+func (p *G2) affine() (x, y fe2) {
+    var zinv, zinv2 fe2
+    zinv.inverse(&p.z)
+    zinv2.square(&zinv)
+
+    x.mul(&p.x, &zinv2)
+    zinv2.mul(&zinv2, &zinv)
+    y.mul(&p.y, &zinv2)
+
+    return
+}
+
+// setAffine sets p = (x, y).
+func (p *G2) setAffine(x, y *fe2) *G2 {
+    p.x.set(x)
+    p.y.set(y)
+    p.z.setOne()
+    return p
+}
+
+// IsOnCurve reports whether p is on the curve.
+func (p *G2) IsOnCurve() bool {
+    if p.IsIdentity() {
+        return true
+    }
+
+    // Y^2 = X^3 + b*Z^6
+    var y2, x3, z6 fe2
+    y2.square(&p.y)
+    x3.square(&p.x)
+    x3.mul(&x3, &p.x)
+    z6.square(&p.z)
+    z6.mul(&z6, &p.z)
+    z6.square(&z6)
+    z6.mul(&z6, g2B)
+    x3.add(&x3, &z6)
+
+    return y2.equal(&x3)
+}
+
+// IsInSubgroup reports whether p is in the prime order subgroup.
+func (p *G2) IsInSubgroup() bool {
+    var t G2
+    t.ScalarMult(orderBig, p)
+    return t.IsIdentity()
+}
+
+// Neg sets p = -q.
+func (p *G2) Neg(q *G2) *G2 {
+    p.x.set(&q.x)
+    p.y.neg(&q.y)
+    p.z.set(&q.z)
+    return p
+}
+
+// Double sets p = 2q.
+func (p *G2) Double(q *G2) *G2 {
+    if q.IsIdentity() {
+        return p.Set(q)
+    }
+
+    // http://www.hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+    var a, b, c, d, e, f, t fe2
+
+    a.square(&q.x)
+    b.square(&q.y)
+    c.square(&b)
+
+    d.add(&q.x, &b)
+    d.square(&d)
+    d.sub(&d, &a)
+    d.sub(&d, &c)
+    d.double(&d)
+
+    e.double(&a)
+    e.add(&e, &a)
+    f.square(&e)
+
+    var z3 fe2
+    z3.mul(&q.y, &q.z)
+    z3.double(&z3)
+
+    var x3 fe2
+    t.double(&d)
+    x3.sub(&f, &t)
+
+    var y3 fe2
+    y3.sub(&d, &x3)
+    y3.mul(&y3, &e)
+    c.double(&c)
+    c.double(&c)
+    c.double(&c)
+    y3.sub(&y3, &c)
+
+    p.x, p.y, p.z = x3, y3, z3
+    return p
+}
+
+// Add sets p = a + b.
+func (p *G2) Add(a, b *G2) *G2 {
+    if a.IsIdentity() {
+        return p.Set(b)
+    }
+    if b.IsIdentity() {
+        return p.Set(a)
+    }
+
+    // http://www.hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+    var z1z1, z2z2, u1, u2, s1, s2, h, i, j, r, v, t fe2
+
+    z1z1.square(&a.z)
+    z2z2.square(&b.z)
+    u1.mul(&a.x, &z2z2)
+    u2.mul(&b.x, &z1z1)
+    s1.mul(&a.y, &b.z)
+    s1.mul(&s1, &z2z2)
+    s2.mul(&b.y, &a.z)
+    s2.mul(&s2, &z1z1)
+
+    h.sub(&u2, &u1)
+    r.sub(&s2, &s1)
+
+    if h.isZero() {
+        if r.isZero() {
+            return p.Double(a)
+        }
+
+        return p.SetIdentity()
+    }
+
+    i.double(&h)
+    i.square(&i)
+    j.mul(&h, &i)
+    r.double(&r)
+    v.mul(&u1, &i)
+
+    var x3, y3, z3 fe2
+
+    x3.square(&r)
+    x3.sub(&x3, &j)
+    t.double(&v)
+    x3.sub(&x3, &t)
+
+    y3.sub(&v, &x3)
+    y3.mul(&y3, &r)
+    t.mul(&s1, &j)
+    t.double(&t)
+    y3.sub(&y3, &t)
+
+    z3.add(&a.z, &b.z)
+    z3.square(&z3)
+    z3.sub(&z3, &z1z1)
+    z3.sub(&z3, &z2z2)
+    z3.mul(&z3, &h)
+
+    p.x, p.y, p.z = x3, y3, z3
+    return p
+}
+
+// Sub sets p = a - b.
+func (p *G2) Sub(a, b *G2) *G2 {
+    var t G2
+    t.Neg(b)
+    return p.Add(a, &t)
+}
+
+// ScalarMult sets p = k*q.
+func (p *G2) ScalarMult(k *big.Int, q *G2) *G2 {
+    var r G2
+    r.SetIdentity()
+
+    base := *q
+
+    e := k
+    if k.Sign() < 0 {
+        e = new(big.Int).Neg(k)
+        base.Neg(&base)
+    }
+
+    for i := e.BitLen() - 1; i >= 0; i-- {
+        r.Double(&r)
+        if e.Bit(i) == 1 {
+            r.Add(&r, &base)
+        }
+    }
+
+    *p = r
+    return p
+}
+
+// ScalarBaseMult sets p = k*G.
+func (p *G2) ScalarBaseMult(k *big.Int) *G2 {
+    return p.ScalarMult(k, g2Gen)
+}
+
+// ClearCofactor sets p = h_eff * q, mapping q into G2.
+func (p *G2) ClearCofactor(q *G2) *G2 {
+    return p.ScalarMult(g2HEff, q)
+}
+
+// Bytes returns the compressed encoding of p.
+func (p *G2) Bytes() []byte {
+    out := make([]byte, G2CompressedSize)
+    if p.IsIdentity() {
+        out[0] = flagCompressed | flagInfinity
+        return out
+    }
+
+    x, y := p.affine()
+    copy(out, x.bytes())
+
+    out[0] |= flagCompressed
+    if y.isLexLargest() {
+        out[0] |= flagSign
+    }
+
+    return out
+}
+
+// BytesUncompressed returns the uncompressed encoding of p.
+func (p *G2) BytesUncompressed() []byte {
+    out := make([]byte, G2UncompressedSize)
+    if p.IsIdentity() {
+        out[0] = flagInfinity
+        return out
+    }
+
+    x, y := p.affine()
+    copy(out, x.bytes())
+    copy(out[Fp2Size:], y.bytes())
+
+    return out
+}
+
+// SetBytes decodes a compressed or uncompressed point and
+// checks that it is on the curve and in the subgroup.
+func (p *G2) SetBytes(b []byte) (*G2, error) {
+    if _, err := p.setBytes(b); err != nil {
+        return nil, err
+    }
+
+    if !p.IsInSubgroup() {
+        return nil, errG2Subgroup
+    }
+
+    return p, nil
+}
+
+func (p *G2) setBytes(b []byte) (*G2, error) {
+    if len(b) == 0 {
+        return nil, errG2Encoding
+    }
+
+    compressed := b[0] & flagCompressed != 0
+    infinity := b[0] & flagInfinity != 0
+    sign := b[0] & flagSign != 0
+
+    if (compressed && len(b) != G2CompressedSize) ||
+        (!compressed && len(b) != G2UncompressedSize) {
+        return nil, errG2Encoding
+    }
+
+    buf := make([]byte, len(b))
+    copy(buf, b)
+    buf[0] &= 0x1f
+
+    if infinity {
+        if sign && compressed {
+            return nil, errG2Encoding
+        }
+
+        for _, v := range buf {
+            if v != 0 {
+                return nil, errG2Encoding
+            }
+        }
+
+        return p.SetIdentity(), nil
+    }
+
+    var x, y fe2
+    if _, err := x.setBytes(buf[:Fp2Size]); err != nil {
+        return nil, errG2Encoding
+    }
+
+    if compressed {
+        // y^2 = x^3 + 4(1 + u)
+        var y2 fe2
+        y2.square(&x)
+        y2.mul(&y2, &x)
+        y2.add(&y2, g2B)
+
+        if !y.sqrt(&y2) {
+            return nil, errG2NotOnCurve
+        }
+
+        if y.isLexLargest() != sign {
+            y.neg(&y)
+        }
+    } else {
+        if sign {
+            return nil, errG2Encoding
+        }
+
+        if _, err := y.setBytes(buf[Fp2Size:]); err != nil {
+            return nil, errG2Encoding
+        }
+    }
+
+    p.setAffine(&x, &y)
+    if !p.IsOnCurve() {
+        return nil, errG2NotOnCurve
+    }
+
+    return p, nil
+}
@@ -0,0 +1,84 @@
+package bls12381
+
+import (
+    "errors"
+    "math/big"
+)
+
+// GtSize is the size of a serialized Gt element.
+const GtSize = 12 * FpSize
+
+var errGtEncoding = errors.New("bls12381: invalid Gt element encoding")
+
+// Gt is an element of the order r subgroup of GF(p^12)^*,
+// the target group of the pairing.
+type Gt struct {
+    v fe12
+}
+
+// NewGt returns the identity of Gt.
+func NewGt() *Gt {
+    return new(Gt).SetOne()
+}
+
+func (z *Gt) Set(x *Gt) *Gt {
+    z.v.set(&x.v)
+    return z
+}
+
+// SetOne sets z to the identity.
+func (z *Gt) SetOne() *Gt {
+    z.v.setOne()
+    return z
+}
+
+// IsOne reports whether z is the identity.
+func (z *Gt) IsOne() bool {
+    return z.v.isOne()
+}
+
+// Equal reports whether z and x are equal.
+func (z *Gt) Equal(x *Gt) bool {
+    return z.v.equal(&x.v)
+}
+
+// Mul sets z = x * y.
+func (z *Gt) Mul(x, y *Gt) *Gt {
+    z.v.mul(&x.v, &y.v)
+    return z
+}
+
+// Inverse sets z = x^-1, elements of Gt are unitary so this is a conjugation.
+func (z *Gt) Inverse(x *Gt) *Gt {
+    z.v.conjugate(&x.v)
+    return z
+}
+
+// Exp sets z = x^k.
+func (z *Gt) Exp(x *Gt, k *big.Int) *Gt {
+    e := new(big.Int).Mod(k, orderBig)
+    z.v.exp(&x.v, e)
+    return z
+}
+
+// Bytes returns the encoding of z.
+func (z *Gt) Bytes() []byte {
+    return z.v.bytes()
+}
+
+// SetBytes decodes b and checks that it is in Gt.
+func (z *Gt) SetBytes(b []byte) (*Gt, error) {
+    var t fe12
+    if _, err := t.setBytes(b); err != nil {
+        return nil, errGtEncoding
+    }
+
+    var c fe12
+    c.exp(&t, orderBig)
+    if !c.isOne() {
+        return nil, errGtEncoding
+    }
+
+    z.v = t
+    return z, nil
+}
@@ -0,0 +1,207 @@
+package bls12381
+
+import (
+    "crypto/sha256"
+
+    "github.com/deatil/go-cryptobin/elliptic/hash2curve"
+)
+
+// Hash-to-curve suites of RFC 9380, section 8.8:
+// BLS12381G1_XMD:SHA-256_SSWU_RO_ / _NU_ and
+// BLS12381G2_XMD:SHA-256_SSWU_RO_ / _NU_.
+
+// hashToFieldL is ceil((ceil(log2(p)) + k) / 8) with k = 128.
+const hashToFieldL = 64
+
+var (
+    // Z = 11
+    g1SSWUZ = new(fe).setUint64(11)
+    // Z = -(2 + u)
+    g2SSWUZ = new(fe2).neg(new(fe2).setHex("2", "1"))
+)
+
+// hashToFp returns count elements of GF(p) using expand_message_xmd
+// with SHA-256. It panics if dst is empty.
+func hashToFp(msg, dst []byte, count int) []fe {
+    exp := hash2curve.NewExpanderXMD(sha256.New, dst)
+
+    u, err := hash2curve.HashToField(exp, msg, pBig, hashToFieldL, count)
+    if err != nil {
+        panic("bls12381: " + err.Error())
+    }
+
+    out := make([]fe, count)
+    for i := range out {
+        out[i].setBig(u[i])
+    }
+
+    return out
+}
+
+// hashToFp2 returns count elements of GF(p^2).
+func hashToFp2(msg, dst []byte, count int) []fe2 {
+    u := hashToFp(msg, dst, 2*count)
+
+    out := make([]fe2, count)
+    for i := range out {
+        out[i].c0 = u[2*i]
+        out[i].c1 = u[2*i+1]
+    }
+
+    return out
+}
+
+// g1SSWU maps u to E1' with the simplified SWU method, RFC 9380 section 6.6.2.
+func g1SSWU(u *fe) (x, y fe) {
+    var tv1, tv2, x1, gx, t fe
+
+    // tv1 = 1 / (Z^2 * u^4 + Z * u^2)
+    tv2.square(u)
+    tv2.mul(&tv2, g1SSWUZ)
+    tv1.square(&tv2)
+    tv1.add(&tv1, &tv2)
+    tv1.inverse(&tv1)
+
+    // x1 = (-B / A) * (1 + tv1), or B / (Z * A) when tv1 = 0
+    if tv1.isZero() {
+        t.mul(g1SSWUZ, g1IsoA)
+        t.inverse(&t)
+        x1.mul(g1IsoB, &t)
+    } else {
+        t.inverse(g1IsoA)
+        t.mul(&t, g1IsoB)
+        t.neg(&t)
+        x1.setOne()
+        x1.add(&x1, &tv1)
+        x1.mul(&x1, &t)
+    }
+
+    g1IsoCurve(&gx, &x1)
+    if y.sqrt(&gx) {
+        x = x1
+    } else {
+        // x2 = Z * u^2 * x1
+        x.mul(&tv2, &x1)
+        g1IsoCurve(&gx, &x)
+        y.sqrt(&gx)
+    }
+
+    if u.sgn0() != y.sgn0() {
+        y.neg(&y)
+    }
+
+    return
+}
+
+// g2SSWU maps u to E2' with the simplified SWU method.
+func g2SSWU(u *fe2) (x, y fe2) {
+    var tv1, tv2, x1, gx, t fe2
+
+    tv2.square(u)
+    tv2.mul(&tv2, g2SSWUZ)
+    tv1.square(&tv2)
+    tv1.add(&tv1, &tv2)
+    tv1.inverse(&tv1)
+
+    if tv1.isZero() {
+        t.mul(g2SSWUZ, g2IsoA)
+        t.inverse(&t)
+        x1.mul(g2IsoB, &t)
+    } else {
+        t.inverse(g2IsoA)
+        t.mul(&t, g2IsoB)
+        t.neg(&t)
+        x1.setOne()
+        x1.add(&x1, &tv1)
+        x1.mul(&x1, &t)
+    }
+
+    g2IsoCurve(&gx, &x1)
+    if y.sqrt(&gx) {
+        x = x1
+    } else {
+        x.mul(&tv2, &x1)
+        g2IsoCurve(&gx, &x)
+        y.sqrt(&gx)
+    }
+
+    if u.sgn0() != y.sgn0() {
+        y.neg(&y)
+    }
+
+    return
+}
+
+// g1IsoCurve sets z = x^3 + A'*x + B'.
+func g1IsoCurve(z, x *fe) {
+    var t fe
+    t.square(x)
+    t.add(&t, g1IsoA)
+    t.mul(&t, x)
+    z.add(&t, g1IsoB)
+}
+
+// g2IsoCurve sets z = x^3 + A'*x + B'.
+func g2IsoCurve(z, x *fe2) {
+    var t fe2
+    t.square(x)
+    t.add(&t, g2IsoA)
+    t.mul(&t, x)
+    z.add(&t, g2IsoB)
+}
+
+func g1MapToCurve(u *fe) *G1 {
+    x, y := g1SSWU(u)
+    x, y = g1Isogeny(&x, &y)
+
+    return new(G1).setAffine(&x, &y)
+}
+
+func g2MapToCurve(u *fe2) *G2 {
+    x, y := g2SSWU(u)
+    x, y = g2Isogeny(&x, &y)
+
+    return new(G2).setAffine(&x, &y)
+}
+
+// Hash sets p to hash_to_curve(msg) with the
+// BLS12381G1_XMD:SHA-256_SSWU_RO_ suite and the given dst.
+func (p *G1) Hash(msg, dst []byte) *G1 {
+    u := hashToFp(msg, dst, 2)
+
+    q0 := g1MapToCurve(&u[0])
+    q1 := g1MapToCurve(&u[1])
+
+    q0.Add(q0, q1)
+    return p.ClearCofactor(q0)
+}
+
+// Encode sets p to encode_to_curve(msg) with the
+// BLS12381G1_XMD:SHA-256_SSWU_NU_ suite and the given dst.
+func (p *G1) Encode(msg, dst []byte) *G1 {
+    u := hashToFp(msg, dst, 1)
+
+    q := g1MapToCurve(&u[0])
+    return p.ClearCofactor(q)
+}
+
+// Hash sets p to hash_to_curve(msg) with the
+// BLS12381G2_XMD:SHA-256_SSWU_RO_ suite and the given dst.
+func (p *G2) Hash(msg, dst []byte) *G2 {
+    u := hashToFp2(msg, dst, 2)
+
+    q0 := g2MapToCurve(&u[0])
+    q1 := g2MapToCurve(&u[1])
+
+    q0.Add(q0, q1)
+    return p.ClearCofactor(q0)
+}
+
+// Encode sets p to encode_to_curve(msg) with the
+// BLS12381G2_XMD:SHA-256_SSWU_NU_ suite and the given dst.
+func (p *G2) Encode(msg, dst []byte) *G2 {
+    u := hashToFp2(msg, dst, 1)
+
+    q := g2MapToCurve(&u[0])
+    return p.ClearCofactor(q)
+}
@@ -0,0 +1,119 @@
+package bls12381
+
+import (
+    "os"
+    "bytes"
+    "strings"
+    "testing"
+    "math/big"
+    "compress/gzip"
+    "encoding/json"
+)
+
+type hashToCurvePoint struct {
+    X string `json:"x"`
+    Y string `json:"y"`
+}
+
+type hashToCurveVector struct {
+    Msg string           `json:"msg"`
+    P   hashToCurvePoint `json:"P"`
+    U   []string         `json:"u"`
+}
+
+type hashToCurveSuite struct {
+    Ciphersuite string              `json:"ciphersuite"`
+    Dst         string              `json:"dst"`
+    Vectors     []hashToCurveVector `json:"vectors"`
+}
+
+func readHashToCurveSuite(t *testing.T, name string) hashToCurveSuite {
+    f, err := os.Open("testdata/" + name + ".json.gz")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var suite hashToCurveSuite
+    if err := json.NewDecoder(r).Decode(&suite); err != nil {
+        t.Fatal(err)
+    }
+
+    return suite
+}
+
+func fromHex(t *testing.T, s string) []byte {
+    b, ok := new(big.Int).SetString(s, 0)
+    if !ok {
+        t.Fatalf("invalid hex %s", s)
+    }
+
+    return b.FillBytes(make([]byte, FpSize))
+}
+
+// fe2FromHex parses "c0,c1" into the c1 || c0 encoding.
+func fe2FromHex(t *testing.T, s string) []byte {
+    parts := strings.Split(s, ",")
+    if len(parts) != 2 {
+        t.Fatalf("invalid fe2 %s", s)
+    }
+
+    return append(fromHex(t, parts[1]), fromHex(t, parts[0])...)
+}
+
+func Test_G1_HashToCurve(t *testing.T) {
+    for _, name := range []string{
+        "BLS12381G1_XMD-SHA-256_SSWU_RO_",
+        "BLS12381G1_XMD-SHA-256_SSWU_NU_",
+    } {
+        suite := readHashToCurveSuite(t, name)
+        ro := strings.HasSuffix(name, "RO_")
+
+        t.Run(name, func(t *testing.T) {
+            for i, v := range suite.Vectors {
+                var p G1
+                if ro {
+                    p.Hash([]byte(v.Msg), []byte(suite.Dst))
+                } else {
+                    p.Encode([]byte(v.Msg), []byte(suite.Dst))
+                }
+
+                want := append(fromHex(t, v.P.X), fromHex(t, v.P.Y)...)
+                if got := p.BytesUncompressed(); !bytes.Equal(got, want) {
+                    t.Errorf("[%d] got %x, want %x", i, got, want)
+                }
+            }
+        })
+    }
+}
+
+func Test_G2_HashToCurve(t *testing.T) {
+    for _, name := range []string{
+        "BLS12381G2_XMD-SHA-256_SSWU_RO_",
+        "BLS12381G2_XMD-SHA-256_SSWU_NU_",
+    } {
+        suite := readHashToCurveSuite(t, name)
+        ro := strings.HasSuffix(name, "RO_")
+
+        t.Run(name, func(t *testing.T) {
+            for i, v := range suite.Vectors {
+                var p G2
+                if ro {
+                    p.Hash([]byte(v.Msg), []byte(suite.Dst))
+                } else {
+                    p.Encode([]byte(v.Msg), []byte(suite.Dst))
+                }
+
+                want := append(fe2FromHex(t, v.P.X), fe2FromHex(t, v.P.Y)...)
+                if got := p.BytesUncompressed(); !bytes.Equal(got, want) {
+                    t.Errorf("[%d] got %x, want %x", i, got, want)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,143 @@
+package bls12381
+
+import (
+    "math/big"
+)
+
+// finalExpHard = (p^4 - p^2 + 1) / r
+var finalExpHard = func() *big.Int {
+    p2 := new(big.Int).Mul(pBig, pBig)
+    p4 := new(big.Int).Mul(p2, p2)
+
+    e := new(big.Int).Sub(p4, p2)
+    e.Add(e, big.NewInt(1))
+
+    return e.Div(e, orderBig)
+}()
+
+// lineEval returns the line with slope lambda through t, evaluated at
+// (xP, yP) and multiplied by w^3, which is removed by the final exponentiation:
+//
+//     l = (lambda*xT - yT) - lambda*xP*w^2 + yP*w^3
+func lineEval(lambda, xT, yT *fe2, xP, yP *fe) *fe12 {
+    var l fe12
+
+    l.c0.c0.mul(lambda, xT)
+    l.c0.c0.sub(&l.c0.c0, yT)
+
+    l.c0.c1.mulByFp(lambda, xP)
+    l.c0.c1.neg(&l.c0.c1)
+
+    l.c1.c1.c0.set(yP)
+
+    return &l
+}
+
+// millerLoop computes f_{|x|,Q}(P) with affine coordinates on the twist,
+// conjugated at the end because x is negative.
+func millerLoop(p *G1, q *G2) *fe12 {
+    var f fe12
+    f.setOne()
+
+    if p.IsIdentity() || q.IsIdentity() {
+        return &f
+    }
+
+    xP, yP := p.affine()
+    xQ, yQ := q.affine()
+
+    xT, yT := xQ, yQ
+
+    var lambda, t, x3 fe2
+    for i := xAbs.BitLen() - 2; i >= 0; i-- {
+        // doubling: lambda = 3*xT^2 / (2*yT)
+        lambda.square(&xT)
+        t.double(&lambda)
+        lambda.add(&lambda, &t)
+        t.double(&yT)
+        t.inverse(&t)
+        lambda.mul(&lambda, &t)
+
+        f.square(&f)
+        f.mul(&f, lineEval(&lambda, &xT, &yT, &xP, &yP))
+
+        x3.square(&lambda)
+        x3.sub(&x3, &xT)
+        x3.sub(&x3, &xT)
+        t.sub(&xT, &x3)
+        t.mul(&t, &lambda)
+        yT.sub(&t, &yT)
+        xT = x3
+
+        if xAbs.Bit(i) == 1 {
+            // addition: lambda = (yQ - yT) / (xQ - xT)
+            t.sub(&xQ, &xT)
+            t.inverse(&t)
+            lambda.sub(&yQ, &yT)
+            lambda.mul(&lambda, &t)
+
+            f.mul(&f, lineEval(&lambda, &xT, &yT, &xP, &yP))
+
+            x3.square(&lambda)
+            x3.sub(&x3, &xT)
+            x3.sub(&x3, &xQ)
+            t.sub(&xT, &x3)
+            t.mul(&t, &lambda)
+            yT.sub(&t, &yT)
+            xT = x3
+        }
+    }
+
+    return f.conjugate(&f)
+}
+
+// finalExponentiation computes f^((p^12 - 1) / r).
+func finalExponentiation(f *fe12) *fe12 {
+    var t0, t1 fe12
+
+    // easy part: f^((p^6 - 1)(p^2 + 1))
+    t0.conjugate(f)
+    t1.inverse(f)
+    t0.mul(&t0, &t1)
+
+    t1.frobenius(&t0)
+    t1.frobenius(&t1)
+    t0.mul(&t0, &t1)
+
+    // hard part: f^((p^4 - p^2 + 1) / r)
+    return t0.exp(&t0, finalExpHard)
+}
+
+// Pair computes the optimal ate pairing e(p, q).
+func Pair(p *G1, q *G2) *Gt {
+    f := millerLoop(p, q)
+
+    out := &Gt{}
+    out.v = *finalExponentiation(f)
+
+    return out
+}
+
+// MultiPair computes the product of e(ps[i], qs[i]) sharing
+// one final exponentiation.
+func MultiPair(ps []*G1, qs []*G2) *Gt {
+    if len(ps) != len(qs) {
+        panic("bls12381: mismatched number of G1 and G2 points")
+    }
+
+    var f fe12
+    f.setOne()
+    for i := range ps {
+        f.mul(&f, millerLoop(ps[i], qs[i]))
+    }
+
+    out := &Gt{}
+    out.v = *finalExponentiation(&f)
+
+    return out
+}
+
+// PairingCheck reports whether the product of e(ps[i], qs[i]) is one.
+func PairingCheck(ps []*G1, qs []*G2) bool {
+    return MultiPair(ps, qs).IsOne()
+}
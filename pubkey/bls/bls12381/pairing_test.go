@@ -0,0 +1,86 @@
+package bls12381
+
+import (
+    "testing"
+    "math/big"
+    "crypto/rand"
+)
+
+func randScalar(t *testing.T) *big.Int {
+    k, err := rand.Int(rand.Reader, orderBig)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return k
+}
+
+func Test_Pair_Bilinearity(t *testing.T) {
+    a := randScalar(t)
+    b := randScalar(t)
+
+    p := new(G1).ScalarBaseMult(a)
+    q := new(G2).ScalarBaseMult(b)
+
+    e1 := Pair(p, q)
+
+    ab := new(big.Int).Mul(a, b)
+    e2 := NewGt().Exp(Pair(G1Generator(), G2Generator()), ab)
+
+    if !e1.Equal(e2) {
+        t.Error("e(aP, bQ) != e(P, Q)^ab")
+    }
+
+    e3 := Pair(new(G1).ScalarBaseMult(ab), G2Generator())
+    if !e1.Equal(e3) {
+        t.Error("e(aP, bQ) != e(abP, Q)")
+    }
+}
+
+func Test_Pair_NonDegenerate(t *testing.T) {
+    e := Pair(G1Generator(), G2Generator())
+    if e.IsOne() {
+        t.Fatal("pairing is degenerate")
+    }
+
+    var c Gt
+    c.v.exp(&e.v, orderBig)
+    if !c.IsOne() {
+        t.Error("e(P, Q)^r != 1")
+    }
+
+    if !Pair(NewG1(), G2Generator()).IsOne() {
+        t.Error("e(O, Q) != 1")
+    }
+}
+
+func Test_PairingCheck(t *testing.T) {
+    a := randScalar(t)
+
+    p := new(G1).ScalarBaseMult(a)
+    q := new(G2).ScalarBaseMult(a)
+
+    negG1 := new(G1).Neg(G1Generator())
+
+    // e(aP, Q) * e(-P, aQ) == 1
+    if !PairingCheck([]*G1{p, negG1}, []*G2{G2Generator(), q}) {
+        t.Error("PairingCheck fail")
+    }
+
+    if PairingCheck([]*G1{p, G1Generator()}, []*G2{G2Generator(), q}) {
+        t.Error("PairingCheck should fail")
+    }
+}
+
+func Test_Gt_Bytes(t *testing.T) {
+    e := Pair(G1Generator(), G2Generator())
+
+    var e2 Gt
+    if _, err := e2.SetBytes(e.Bytes()); err != nil {
+        t.Fatal(err)
+    }
+
+    if !e.Equal(&e2) {
+        t.Error("Gt encoding mismatch")
+    }
+}
@@ -0,0 +1,335 @@
+package bls
+
+import (
+    "io"
+    "os"
+    "fmt"
+    "bufio"
+    "bytes"
+    "strings"
+    "testing"
+    "math/big"
+    "crypto/rand"
+    "crypto/sha256"
+    "compress/gzip"
+    "encoding/hex"
+
+    "golang.org/x/crypto/hkdf"
+
+    "github.com/deatil/go-cryptobin/pubkey/bls/bls12381"
+)
+
+var testVariants = []Variant{MinPk, MinSig}
+var testSchemes = []Scheme{Basic, MessageAugmentation, ProofOfPossession}
+
+func Test_SignVerify(t *testing.T) {
+    for _, v := range testVariants {
+        for _, s := range testSchemes {
+            t.Run(fmt.Sprintf("%d-%d", v, s), func(t *testing.T) {
+                priv, err := GenerateKey(rand.Reader, v)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                msg := []byte("test-data")
+
+                sig, err := Sign(priv, s, msg)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                if len(sig) != v.SignatureSize() {
+                    t.Errorf("signature size got %d", len(sig))
+                }
+
+                if !Verify(&priv.PublicKey, s, msg, sig) {
+                    t.Error("Verify fail")
+                }
+
+                if Verify(&priv.PublicKey, s, []byte("test-data2"), sig) {
+                    t.Error("Verify should fail with other message")
+                }
+
+                other := (s + 1) % 3
+                if Verify(&priv.PublicKey, other, msg, sig) {
+                    t.Error("Verify should fail with other scheme")
+                }
+            })
+        }
+    }
+}
+
+func Test_Signer(t *testing.T) {
+    priv, err := GenerateKey(rand.Reader, MinPk)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    msg := []byte("test-data")
+
+    sig, err := priv.Sign(rand.Reader, msg, &Options{Scheme: MessageAugmentation})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    pub := priv.Public().(*PublicKey)
+    if !Verify(pub, MessageAugmentation, msg, sig) {
+        t.Error("Verify fail")
+    }
+}
+
+func Test_KeyEncoding(t *testing.T) {
+    for _, v := range testVariants {
+        priv, err := GenerateKey(rand.Reader, v)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        priv2, err := NewPrivateKey(v, priv.Bytes())
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !priv.Equal(priv2) {
+            t.Error("PrivateKey mismatch")
+        }
+
+        pubBytes := priv.PublicKey.Bytes()
+        if len(pubBytes) != v.PublicKeySize() {
+            t.Errorf("public key size got %d", len(pubBytes))
+        }
+
+        pub, err := NewPublicKey(v, pubBytes)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !pub.Equal(&priv.PublicKey) {
+            t.Error("PublicKey mismatch")
+        }
+    }
+
+    // the identity is not a valid public key
+    if _, err := NewPublicKey(MinPk, bls12381.NewG1().Bytes()); err == nil {
+        t.Error("identity public key should fail")
+    }
+
+    if _, err := NewPrivateKey(MinPk, make([]byte, PrivateKeySize)); err == nil {
+        t.Error("zero private key should fail")
+    }
+}
+
+func Test_KeyGen(t *testing.T) {
+    ikm := bytes.Repeat([]byte{0x01}, 32)
+
+    k1, err := KeyGen(MinPk, ikm, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    k2, err := KeyGen(MinPk, ikm, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !k1.Equal(k2) {
+        t.Error("KeyGen is not deterministic")
+    }
+
+    k3, err := KeyGen(MinPk, ikm, []byte("info"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if k1.Equal(k3) {
+        t.Error("key_info is ignored")
+    }
+
+    if _, err := KeyGen(MinPk, ikm[:31], nil); err != ErrShortIKM {
+        t.Error("short IKM should fail")
+    }
+}
+
+func Test_AggregateVerify(t *testing.T) {
+    for _, v := range testVariants {
+        for _, s := range testSchemes {
+            t.Run(fmt.Sprintf("%d-%d", v, s), func(t *testing.T) {
+                n := 3
+
+                pubs := make([]*PublicKey, n)
+                msgs := make([][]byte, n)
+                sigs := make([][]byte, n)
+                for i := 0; i < n; i++ {
+                    priv, err := GenerateKey(rand.Reader, v)
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    pubs[i] = &priv.PublicKey
+                    msgs[i] = []byte(fmt.Sprintf("message %d", i))
+
+                    sigs[i], err = Sign(priv, s, msgs[i])
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+                }
+
+                agg, err := Aggregate(v, sigs)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                if !AggregateVerify(pubs, s, msgs, agg) {
+                    t.Error("AggregateVerify fail")
+                }
+
+                msgs[1] = []byte("other")
+                if AggregateVerify(pubs, s, msgs, agg) {
+                    t.Error("AggregateVerify should fail")
+                }
+            })
+        }
+    }
+}
+
+func Test_AggregateVerify_BasicDistinct(t *testing.T) {
+    msg := []byte("same message")
+
+    var pubs []*PublicKey
+    var sigs [][]byte
+    for i := 0; i < 2; i++ {
+        priv, err := GenerateKey(rand.Reader, MinPk)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        sig, _ := Sign(priv, Basic, msg)
+        pubs = append(pubs, &priv.PublicKey)
+        sigs = append(sigs, sig)
+    }
+
+    agg, err := Aggregate(MinPk, sigs)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if AggregateVerify(pubs, Basic, [][]byte{msg, msg}, agg) {
+        t.Error("Basic scheme must reject repeated messages")
+    }
+
+}
+
+func Test_FastAggregateVerify(t *testing.T) {
+    for _, v := range testVariants {
+        msg := []byte("test-data")
+
+        var privs []*PrivateKey
+        var pubs []*PublicKey
+        var sigs [][]byte
+        for i := 0; i < 4; i++ {
+            priv, err := GenerateKey(rand.Reader, v)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            proof := PopProve(priv)
+            if !PopVerify(&priv.PublicKey, proof) {
+                t.Fatal("PopVerify fail")
+            }
+
+            sig, err := Sign(priv, ProofOfPossession, msg)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            privs = append(privs, priv)
+            pubs = append(pubs, &priv.PublicKey)
+            sigs = append(sigs, sig)
+        }
+
+        agg, err := Aggregate(v, sigs)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !FastAggregateVerify(pubs, msg, agg) {
+            t.Error("FastAggregateVerify fail")
+        }
+
+        if FastAggregateVerify(pubs[1:], msg, agg) {
+            t.Error("FastAggregateVerify should fail with missing key")
+        }
+
+        // proofs use their own domain separation tag
+        if Verify(pubs[0], ProofOfPossession, pubs[0].Bytes(), PopProve(privs[0])) {
+            t.Error("proof should not verify as a signature")
+        }
+    }
+}
+
+// testKey derives the key the same way as the vector generator,
+// which uses the unhashed salt of earlier drafts.
+func testKey(t *testing.T, v Variant, ikm []byte) *PrivateKey {
+    info := []byte{0, 48}
+
+    r := hkdf.New(sha256.New, append(ikm, 0), []byte("BLS-SIG-KEYGEN-SALT-"), info)
+
+    okm := make([]byte, 48)
+    if _, err := io.ReadFull(r, okm); err != nil {
+        t.Fatal(err)
+    }
+
+    d := new(big.Int).SetBytes(okm)
+    d.Mod(d, bls12381.Order())
+
+    priv, err := newPrivateKey(v, d)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return priv
+}
+
+func Test_Vectors(t *testing.T) {
+    for _, name := range []string{"P256", "P521"} {
+        t.Run(name+"/G2", func(t *testing.T) { testVector(t, MinPk, "g2", name) })
+        t.Run(name+"/G1", func(t *testing.T) { testVector(t, MinSig, "g1", name) })
+    }
+}
+
+func testVector(t *testing.T, v Variant, group, name string) {
+    f, err := os.Open("testdata/sig_" + group + "_basic_" + name + ".txt.gz")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 0, 1 << 16), 1 << 20)
+
+    for scanner.Scan() {
+        fields := strings.Fields(scanner.Text())
+        if len(fields) != 3 {
+            t.Fatal("bad vector line")
+        }
+
+        msg, _ := hex.DecodeString(fields[0])
+        ikm, _ := hex.DecodeString(fields[1])
+        want, _ := hex.DecodeString(fields[2])
+
+        priv := testKey(t, v, ikm)
+
+        sig, err := Sign(priv, Basic, msg)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !bytes.Equal(sig, want) {
+            t.Errorf("got %x, want %x", sig, want)
+        }
+
+        if !Verify(&priv.PublicKey, Basic, msg, want) {
+            t.Error("Verify fail")
+        }
+    }
+}
@@ -0,0 +1,711 @@
+package composite
+
+import (
+    "io"
+    "hash"
+    "bytes"
+    "errors"
+    "crypto"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/sha256"
+    "crypto/sha512"
+    "crypto/x509"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/hash/sm3"
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+)
+
+var (
+    ErrInvalidParams     = errors.New("go-cryptobin/composite: invalid params")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/composite: invalid public key")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/composite: invalid private key")
+    ErrInvalidSignature  = errors.New("go-cryptobin/composite: invalid signature")
+    ErrContextTooLong    = errors.New("go-cryptobin/composite: context too long")
+    ErrInvalidHash       = errors.New("go-cryptobin/composite: message must not be prehashed")
+)
+
+// prefix of the message representative
+var signaturePrefix = []byte("CompositeAlgorithmSignatures2025")
+
+type tradType int
+
+const (
+    tradRSAPSS tradType = iota
+    tradECDSA
+    tradEd25519
+    tradSM2
+)
+
+// Params is a composite ML-DSA algorithm,
+// draft-ietf-lamps-pq-composite-sigs.
+type Params struct {
+    Name string
+
+    label    string
+    mldsa    *mldsa.Params
+    prehash  func() hash.Hash
+    trad     tradType
+    tradHash crypto.Hash
+    rsaBits  int
+    curve    elliptic.Curve
+}
+
+// String returns the name of the algorithm.
+func (p *Params) String() string {
+    return p.Name
+}
+
+// MLDSA returns the ML-DSA parameter set of the algorithm.
+func (p *Params) MLDSA() *mldsa.Params {
+    return p.mldsa
+}
+
+var (
+    paramsMLDSA44_RSA2048_PSS_SHA256 = &Params{
+        Name:     "MLDSA44-RSA2048-PSS-SHA256",
+        label:    "COMPSIG-MLDSA44-RSA2048-PSS-SHA256",
+        mldsa:    mldsa.MLDSA44(),
+        prehash:  sha256.New,
+        trad:     tradRSAPSS,
+        tradHash: crypto.SHA256,
+        rsaBits:  2048,
+    }
+    paramsMLDSA44_Ed25519_SHA512 = &Params{
+        Name:    "MLDSA44-Ed25519-SHA512",
+        label:   "COMPSIG-MLDSA44-Ed25519-SHA512",
+        mldsa:   mldsa.MLDSA44(),
+        prehash: sha512.New,
+        trad:    tradEd25519,
+    }
+    paramsMLDSA44_ECDSA_P256_SHA256 = &Params{
+        Name:     "MLDSA44-ECDSA-P256-SHA256",
+        label:    "COMPSIG-MLDSA44-ECDSA-P256-SHA256",
+        mldsa:    mldsa.MLDSA44(),
+        prehash:  sha256.New,
+        trad:     tradECDSA,
+        tradHash: crypto.SHA256,
+        curve:    elliptic.P256(),
+    }
+    paramsMLDSA65_RSA3072_PSS_SHA512 = &Params{
+        Name:     "MLDSA65-RSA3072-PSS-SHA512",
+        label:    "COMPSIG-MLDSA65-RSA3072-PSS-SHA512",
+        mldsa:    mldsa.MLDSA65(),
+        prehash:  sha512.New,
+        trad:     tradRSAPSS,
+        tradHash: crypto.SHA256,
+        rsaBits:  3072,
+    }
+    paramsMLDSA65_RSA4096_PSS_SHA512 = &Params{
+        Name:     "MLDSA65-RSA4096-PSS-SHA512",
+        label:    "COMPSIG-MLDSA65-RSA4096-PSS-SHA512",
+        mldsa:    mldsa.MLDSA65(),
+        prehash:  sha512.New,
+        trad:     tradRSAPSS,
+        tradHash: crypto.SHA384,
+        rsaBits:  4096,
+    }
+    paramsMLDSA65_ECDSA_P256_SHA512 = &Params{
+        Name:     "MLDSA65-ECDSA-P256-SHA512",
+        label:    "COMPSIG-MLDSA65-ECDSA-P256-SHA512",
+        mldsa:    mldsa.MLDSA65(),
+        prehash:  sha512.New,
+        trad:     tradECDSA,
+        tradHash: crypto.SHA256,
+        curve:    elliptic.P256(),
+    }
+    paramsMLDSA65_ECDSA_P384_SHA512 = &Params{
+        Name:     "MLDSA65-ECDSA-P384-SHA512",
+        label:    "COMPSIG-MLDSA65-ECDSA-P384-SHA512",
+        mldsa:    mldsa.MLDSA65(),
+        prehash:  sha512.New,
+        trad:     tradECDSA,
+        tradHash: crypto.SHA384,
+        curve:    elliptic.P384(),
+    }
+    paramsMLDSA65_Ed25519_SHA512 = &Params{
+        Name:    "MLDSA65-Ed25519-SHA512",
+        label:   "COMPSIG-MLDSA65-Ed25519-SHA512",
+        mldsa:   mldsa.MLDSA65(),
+        prehash: sha512.New,
+        trad:    tradEd25519,
+    }
+    paramsMLDSA87_ECDSA_P384_SHA512 = &Params{
+        Name:     "MLDSA87-ECDSA-P384-SHA512",
+        label:    "COMPSIG-MLDSA87-ECDSA-P384-SHA512",
+        mldsa:    mldsa.MLDSA87(),
+        prehash:  sha512.New,
+        trad:     tradECDSA,
+        tradHash: crypto.SHA384,
+        curve:    elliptic.P384(),
+    }
+    paramsMLDSA87_RSA3072_PSS_SHA512 = &Params{
+        Name:     "MLDSA87-RSA3072-PSS-SHA512",
+        label:    "COMPSIG-MLDSA87-RSA3072-PSS-SHA512",
+        mldsa:    mldsa.MLDSA87(),
+        prehash:  sha512.New,
+        trad:     tradRSAPSS,
+        tradHash: crypto.SHA256,
+        rsaBits:  3072,
+    }
+    paramsMLDSA87_RSA4096_PSS_SHA512 = &Params{
+        Name:     "MLDSA87-RSA4096-PSS-SHA512",
+        label:    "COMPSIG-MLDSA87-RSA4096-PSS-SHA512",
+        mldsa:    mldsa.MLDSA87(),
+        prehash:  sha512.New,
+        trad:     tradRSAPSS,
+        tradHash: crypto.SHA384,
+        rsaBits:  4096,
+    }
+    paramsMLDSA87_ECDSA_P521_SHA512 = &Params{
+        Name:     "MLDSA87-ECDSA-P521-SHA512",
+        label:    "COMPSIG-MLDSA87-ECDSA-P521-SHA512",
+        mldsa:    mldsa.MLDSA87(),
+        prehash:  sha512.New,
+        trad:     tradECDSA,
+        tradHash: crypto.SHA512,
+        curve:    elliptic.P521(),
+    }
+
+    // SM2 combination, the SM2 signature uses the default uid
+    paramsMLDSA65_SM2_SM3 = &Params{
+        Name:    "MLDSA65-SM2-SM3",
+        label:   "COMPSIG-MLDSA65-SM2-SM3",
+        mldsa:   mldsa.MLDSA65(),
+        prehash: sm3.New,
+        trad:    tradSM2,
+        curve:   sm2.P256(),
+    }
+)
+
+var allParams = []*Params{
+    paramsMLDSA44_RSA2048_PSS_SHA256,
+    paramsMLDSA44_Ed25519_SHA512,
+    paramsMLDSA44_ECDSA_P256_SHA256,
+    paramsMLDSA65_RSA3072_PSS_SHA512,
+    paramsMLDSA65_RSA4096_PSS_SHA512,
+    paramsMLDSA65_ECDSA_P256_SHA512,
+    paramsMLDSA65_ECDSA_P384_SHA512,
+    paramsMLDSA65_Ed25519_SHA512,
+    paramsMLDSA87_ECDSA_P384_SHA512,
+    paramsMLDSA87_RSA3072_PSS_SHA512,
+    paramsMLDSA87_RSA4096_PSS_SHA512,
+    paramsMLDSA87_ECDSA_P521_SHA512,
+    paramsMLDSA65_SM2_SM3,
+}
+
+// MLDSA44_RSA2048_PSS_SHA256 returns the MLDSA44-RSA2048-PSS-SHA256 composite algorithm.
+func MLDSA44_RSA2048_PSS_SHA256() *Params {
+    return paramsMLDSA44_RSA2048_PSS_SHA256
+}
+
+// MLDSA44_Ed25519_SHA512 returns the MLDSA44-Ed25519-SHA512 composite algorithm.
+func MLDSA44_Ed25519_SHA512() *Params {
+    return paramsMLDSA44_Ed25519_SHA512
+}
+
+// MLDSA44_ECDSA_P256_SHA256 returns the MLDSA44-ECDSA-P256-SHA256 composite algorithm.
+func MLDSA44_ECDSA_P256_SHA256() *Params {
+    return paramsMLDSA44_ECDSA_P256_SHA256
+}
+
+// MLDSA65_RSA3072_PSS_SHA512 returns the MLDSA65-RSA3072-PSS-SHA512 composite algorithm.
+func MLDSA65_RSA3072_PSS_SHA512() *Params {
+    return paramsMLDSA65_RSA3072_PSS_SHA512
+}
+
+// MLDSA65_RSA4096_PSS_SHA512 returns the MLDSA65-RSA4096-PSS-SHA512 composite algorithm.
+func MLDSA65_RSA4096_PSS_SHA512() *Params {
+    return paramsMLDSA65_RSA4096_PSS_SHA512
+}
+
+// MLDSA65_ECDSA_P256_SHA512 returns the MLDSA65-ECDSA-P256-SHA512 composite algorithm.
+func MLDSA65_ECDSA_P256_SHA512() *Params {
+    return paramsMLDSA65_ECDSA_P256_SHA512
+}
+
+// MLDSA65_ECDSA_P384_SHA512 returns the MLDSA65-ECDSA-P384-SHA512 composite algorithm.
+func MLDSA65_ECDSA_P384_SHA512() *Params {
+    return paramsMLDSA65_ECDSA_P384_SHA512
+}
+
+// MLDSA65_Ed25519_SHA512 returns the MLDSA65-Ed25519-SHA512 composite algorithm.
+func MLDSA65_Ed25519_SHA512() *Params {
+    return paramsMLDSA65_Ed25519_SHA512
+}
+
+// MLDSA87_ECDSA_P384_SHA512 returns the MLDSA87-ECDSA-P384-SHA512 composite algorithm.
+func MLDSA87_ECDSA_P384_SHA512() *Params {
+    return paramsMLDSA87_ECDSA_P384_SHA512
+}
+
+// MLDSA87_RSA3072_PSS_SHA512 returns the MLDSA87-RSA3072-PSS-SHA512 composite algorithm.
+func MLDSA87_RSA3072_PSS_SHA512() *Params {
+    return paramsMLDSA87_RSA3072_PSS_SHA512
+}
+
+// MLDSA87_RSA4096_PSS_SHA512 returns the MLDSA87-RSA4096-PSS-SHA512 composite algorithm.
+func MLDSA87_RSA4096_PSS_SHA512() *Params {
+    return paramsMLDSA87_RSA4096_PSS_SHA512
+}
+
+// MLDSA87_ECDSA_P521_SHA512 returns the MLDSA87-ECDSA-P521-SHA512 composite algorithm.
+func MLDSA87_ECDSA_P521_SHA512() *Params {
+    return paramsMLDSA87_ECDSA_P521_SHA512
+}
+
+// MLDSA65_SM2_SM3 returns the MLDSA65-SM2-SM3 composite algorithm.
+func MLDSA65_SM2_SM3() *Params {
+    return paramsMLDSA65_SM2_SM3
+}
+
+// GetParams returns the algorithm with the name.
+func GetParams(name string) (*Params, error) {
+    for _, p := range allParams {
+        if p.Name == name {
+            return p, nil
+        }
+    }
+
+    return nil, ErrInvalidParams
+}
+
+// Options implements crypto.SignerOpts and holds the application
+// context of the composite signature.
+type Options struct {
+    // Context is an optional domain separation string for signing.
+    // Its length must be less or equal than 255 bytes.
+    Context string
+}
+
+// HashFunc returns zero, composite signatures hash the message internally.
+func (o *Options) HashFunc() crypto.Hash {
+    return crypto.Hash(0)
+}
+
+// PublicKey is a composite public key, an ML-DSA public key
+// and a traditional public key.
+type PublicKey struct {
+    Params *Params
+
+    MLDSA       *mldsa.PublicKey
+    Traditional crypto.PublicKey
+}
+
+// NewPublicKey parses the raw encoding mldsaPK || tradPK. The
+// traditional key is a PKCS#1 RSAPublicKey, an uncompressed EC point
+// or a raw Ed25519 key.
+func NewPublicKey(params *Params, b []byte) (*PublicKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    size := params.mldsa.PublicKeySize()
+    if len(b) <= size {
+        return nil, ErrInvalidPublicKey
+    }
+
+    mldsaKey, err := mldsa.NewPublicKey(params.mldsa, b[:size])
+    if err != nil {
+        return nil, ErrInvalidPublicKey
+    }
+
+    tradKey, err := parseTradPublicKey(params, b[size:])
+    if err != nil {
+        return nil, ErrInvalidPublicKey
+    }
+
+    pub := &PublicKey{
+        Params:      params,
+        MLDSA:       mldsaKey,
+        Traditional: tradKey,
+    }
+
+    return pub, nil
+}
+
+// Bytes returns the raw encoding mldsaPK || tradPK.
+func (pub *PublicKey) Bytes() []byte {
+    var b []byte
+    b = append(b, pub.MLDSA.Bytes()...)
+    b = append(b, marshalTradPublicKey(pub.Traditional)...)
+
+    return b
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok {
+        return false
+    }
+
+    return pub.Params == xx.Params &&
+        bytes.Equal(pub.Bytes(), xx.Bytes())
+}
+
+// PrivateKey is a composite private key, an ML-DSA private key
+// and a traditional private key.
+type PrivateKey struct {
+    PublicKey
+
+    MLDSA       *mldsa.PrivateKey
+    Traditional crypto.Signer
+}
+
+// GenerateKey generates a composite private key.
+func GenerateKey(rand io.Reader, params *Params) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    mldsaKey, err := mldsa.GenerateKey(rand, params.mldsa)
+    if err != nil {
+        return nil, err
+    }
+
+    var tradKey crypto.Signer
+
+    switch params.trad {
+        case tradRSAPSS:
+            tradKey, err = rsa.GenerateKey(rand, params.rsaBits)
+        case tradECDSA:
+            tradKey, err = ecdsa.GenerateKey(params.curve, rand)
+        case tradEd25519:
+            _, tradKey, err = ed25519.GenerateKey(rand)
+        case tradSM2:
+            tradKey, err = sm2.GenerateKey(rand)
+    }
+
+    if err != nil {
+        return nil, err
+    }
+
+    return newPrivateKey(params, mldsaKey, tradKey), nil
+}
+
+// NewPrivateKey parses the raw encoding mldsaSeed || tradSK. The
+// traditional key is a PKCS#1 RSAPrivateKey, a SEC 1 ECPrivateKey
+// or a raw Ed25519 seed.
+func NewPrivateKey(params *Params, b []byte) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(b) <= mldsa.SeedSize {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    mldsaKey, err := mldsa.NewKeyFromSeed(params.mldsa, b[:mldsa.SeedSize])
+    if err != nil {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    tradKey, err := parseTradPrivateKey(params, b[mldsa.SeedSize:])
+    if err != nil {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    return newPrivateKey(params, mldsaKey, tradKey), nil
+}
+
+func newPrivateKey(params *Params, mldsaKey *mldsa.PrivateKey, tradKey crypto.Signer) *PrivateKey {
+    priv := &PrivateKey{
+        MLDSA:       mldsaKey,
+        Traditional: tradKey,
+    }
+
+    priv.PublicKey = PublicKey{
+        Params:      params,
+        MLDSA:       &mldsaKey.PublicKey,
+        Traditional: tradKey.Public(),
+    }
+
+    return priv
+}
+
+// Public returns the public key of priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// Bytes returns the raw encoding mldsaSeed || tradSK.
+func (priv *PrivateKey) Bytes() ([]byte, error) {
+    seed := priv.MLDSA.Seed()
+    if seed == nil {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    tradKey, err := marshalTradPrivateKey(priv.Traditional)
+    if err != nil {
+        return nil, err
+    }
+
+    var b []byte
+    b = append(b, seed...)
+    b = append(b, tradKey...)
+
+    return b, nil
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok {
+        return false
+    }
+
+    return priv.Params == xx.Params &&
+        priv.MLDSA.Equal(xx.MLDSA) &&
+        priv.PublicKey.Equal(&xx.PublicKey)
+}
+
+// Sign signs the message with priv. The message must not be hashed,
+// the context can be set with *Options.
+func (priv *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+    var context string
+
+    if opts != nil && opts.HashFunc() != 0 {
+        return nil, ErrInvalidHash
+    }
+
+    if o, ok := opts.(*Options); ok {
+        context = o.Context
+    }
+
+    params := priv.Params
+
+    m, err := computeMessage(params, message, context)
+    if err != nil {
+        return nil, err
+    }
+
+    mldsaSig, err := priv.MLDSA.Sign(rand, m, &mldsa.Options{
+        Context: params.label,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var tradSig []byte
+
+    switch params.trad {
+        case tradRSAPSS:
+            tradSig, err = rsa.SignPSS(rand, priv.Traditional.(*rsa.PrivateKey), params.tradHash, hashSum(params.tradHash, m), &rsa.PSSOptions{
+                SaltLength: rsa.PSSSaltLengthEqualsHash,
+            })
+        case tradECDSA:
+            tradSig, err = ecdsa.SignASN1(rand, priv.Traditional.(*ecdsa.PrivateKey), hashSum(params.tradHash, m))
+        case tradEd25519:
+            tradSig = ed25519.Sign(priv.Traditional.(ed25519.PrivateKey), m)
+        case tradSM2:
+            tradSig, err = sm2.Sign(rand, priv.Traditional.(*sm2.PrivateKey), m, sm2.SignerOpts{
+                Encoding: sm2.EncodingASN1,
+            })
+    }
+
+    if err != nil {
+        return nil, err
+    }
+
+    sig := make([]byte, 0, len(mldsaSig)+len(tradSig))
+    sig = append(sig, mldsaSig...)
+    sig = append(sig, tradSig...)
+
+    return sig, nil
+}
+
+// Sign signs the message with priv and an empty context.
+func Sign(rand io.Reader, priv *PrivateKey, message []byte) ([]byte, error) {
+    return priv.Sign(rand, message, nil)
+}
+
+// Verify reports whether sig is a valid composite signature of
+// message by pub with an empty context.
+func Verify(pub *PublicKey, message, sig []byte) bool {
+    return VerifyWithOptions(pub, message, sig, nil) == nil
+}
+
+// VerifyWithOptions reports whether sig is a valid signature of message
+// by pub. Both component signatures must be valid. A valid signature is
+// indicated by returning a nil error.
+func VerifyWithOptions(pub *PublicKey, message, sig []byte, opts crypto.SignerOpts) error {
+    var context string
+
+    if o, ok := opts.(*Options); ok {
+        context = o.Context
+    }
+
+    params := pub.Params
+
+    size := params.mldsa.SignatureSize()
+    if len(sig) <= size {
+        return ErrInvalidSignature
+    }
+
+    mldsaSig, tradSig := sig[:size], sig[size:]
+
+    m, err := computeMessage(params, message, context)
+    if err != nil {
+        return err
+    }
+
+    err = mldsa.VerifyWithOptions(pub.MLDSA, m, mldsaSig, &mldsa.Options{
+        Context: params.label,
+    })
+    if err != nil {
+        return ErrInvalidSignature
+    }
+
+    var ok bool
+
+    switch params.trad {
+        case tradRSAPSS:
+            ok = rsa.VerifyPSS(pub.Traditional.(*rsa.PublicKey), params.tradHash, hashSum(params.tradHash, m), tradSig, &rsa.PSSOptions{
+                SaltLength: rsa.PSSSaltLengthEqualsHash,
+            }) == nil
+        case tradECDSA:
+            ok = ecdsa.VerifyASN1(pub.Traditional.(*ecdsa.PublicKey), hashSum(params.tradHash, m), tradSig)
+        case tradEd25519:
+            ok = ed25519.Verify(pub.Traditional.(ed25519.PublicKey), m, tradSig)
+        case tradSM2:
+            ok = sm2.Verify(pub.Traditional.(*sm2.PublicKey), m, tradSig, sm2.SignerOpts{
+                Encoding: sm2.EncodingASN1,
+            })
+    }
+
+    if !ok {
+        return ErrInvalidSignature
+    }
+
+    return nil
+}
+
+// computeMessage returns the message representative
+// M' = Prefix || Label || len(ctx) || ctx || PH(M).
+func computeMessage(params *Params, message []byte, context string) ([]byte, error) {
+    if len(context) > 255 {
+        return nil, ErrContextTooLong
+    }
+
+    h := params.prehash()
+    h.Write(message)
+
+    var m []byte
+    m = append(m, signaturePrefix...)
+    m = append(m, params.label...)
+    m = append(m, byte(len(context)))
+    m = append(m, context...)
+    m = h.Sum(m)
+
+    return m, nil
+}
+
+func hashSum(h crypto.Hash, m []byte) []byte {
+    hh := h.New()
+    hh.Write(m)
+    return hh.Sum(nil)
+}
+
+func parseTradPublicKey(params *Params, b []byte) (crypto.PublicKey, error) {
+    switch params.trad {
+        case tradRSAPSS:
+            pub, err := x509.ParsePKCS1PublicKey(b)
+            if err != nil {
+                return nil, err
+            }
+
+            if pub.N.BitLen() != params.rsaBits {
+                return nil, ErrInvalidPublicKey
+            }
+
+            return pub, nil
+        case tradECDSA:
+            x, y := elliptic.Unmarshal(params.curve, b)
+            if x == nil {
+                return nil, ErrInvalidPublicKey
+            }
+
+            return &ecdsa.PublicKey{Curve: params.curve, X: x, Y: y}, nil
+        case tradEd25519:
+            if len(b) != ed25519.PublicKeySize {
+                return nil, ErrInvalidPublicKey
+            }
+
+            return ed25519.PublicKey(append([]byte(nil), b...)), nil
+        case tradSM2:
+            return sm2.NewPublicKey(b)
+    }
+
+    return nil, ErrInvalidParams
+}
+
+func marshalTradPublicKey(pub crypto.PublicKey) []byte {
+    switch k := pub.(type) {
+        case *rsa.PublicKey:
+            return x509.MarshalPKCS1PublicKey(k)
+        case *ecdsa.PublicKey:
+            return elliptic.Marshal(k.Curve, k.X, k.Y)
+        case ed25519.PublicKey:
+            return []byte(k)
+        case *sm2.PublicKey:
+            return sm2.PublicKeyTo(k)
+    }
+
+    return nil
+}
+
+func parseTradPrivateKey(params *Params, b []byte) (crypto.Signer, error) {
+    switch params.trad {
+        case tradRSAPSS:
+            priv, err := x509.ParsePKCS1PrivateKey(b)
+            if err != nil {
+                return nil, err
+            }
+
+            if priv.N.BitLen() != params.rsaBits {
+                return nil, ErrInvalidPrivateKey
+            }
+
+            return priv, nil
+        case tradECDSA:
+            priv, err := x509.ParseECPrivateKey(b)
+            if err != nil {
+                return nil, err
+            }
+
+            if priv.Curve != params.curve {
+                return nil, ErrInvalidPrivateKey
+            }
+
+            return priv, nil
+        case tradEd25519:
+            if len(b) != ed25519.SeedSize {
+                return nil, ErrInvalidPrivateKey
+            }
+
+            return ed25519.NewKeyFromSeed(b), nil
+        case tradSM2:
+            return sm2.ParseSM2PrivateKey(b)
+    }
+
+    return nil, ErrInvalidParams
+}
+
+func marshalTradPrivateKey(priv crypto.Signer) ([]byte, error) {
+    switch k := priv.(type) {
+        case *rsa.PrivateKey:
+            return x509.MarshalPKCS1PrivateKey(k), nil
+        case *ecdsa.PrivateKey:
+            return x509.MarshalECPrivateKey(k)
+        case ed25519.PrivateKey:
+            return k.Seed(), nil
+        case *sm2.PrivateKey:
+            return sm2.MarshalSM2PrivateKey(k)
+    }
+
+    return nil, ErrInvalidPrivateKey
+}
@@ -0,0 +1,195 @@
+package composite
+
+import (
+    "testing"
+    "crypto/rand"
+    "encoding/asn1"
+
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func testParams(t *testing.T) []*Params {
+    if !testing.Short() {
+        return allParams
+    }
+
+    // RSA 3072 and 4096 key generation is slow
+    var ps []*Params
+    for _, p := range allParams {
+        if p.trad != tradRSAPSS || p.rsaBits == 2048 {
+            ps = append(ps, p)
+        }
+    }
+
+    return ps
+}
+
+func Test_SignVerify(t *testing.T) {
+    for _, params := range testParams(t) {
+        t.Run(params.Name, func(t *testing.T) {
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            priv, err := GenerateKey(rand.Reader, params)
+            assertError(err, "GenerateKey")
+
+            msg := []byte("test data")
+
+            sig, err := Sign(rand.Reader, priv, msg)
+            assertError(err, "Sign")
+
+            assertBool(Verify(&priv.PublicKey, msg, sig), "Verify")
+            assertBool(!Verify(&priv.PublicKey, []byte("test data 2"), sig), "Verify wrong message")
+
+            // both component signatures must be valid
+            size := params.mldsa.SignatureSize()
+
+            sig2 := append([]byte(nil), sig...)
+            sig2[0] ^= 1
+            assertBool(!Verify(&priv.PublicKey, msg, sig2), "Verify wrong ML-DSA signature")
+
+            sig3 := append([]byte(nil), sig...)
+            sig3[size+len(sig3[size:])/2] ^= 1
+            assertBool(!Verify(&priv.PublicKey, msg, sig3), "Verify wrong traditional signature")
+
+            // context
+            opts := &Options{Context: "ctx"}
+
+            sig4, err := priv.Sign(rand.Reader, msg, opts)
+            assertError(err, "Sign with context")
+
+            assertBool(VerifyWithOptions(&priv.PublicKey, msg, sig4, opts) == nil, "VerifyWithOptions")
+            assertBool(!Verify(&priv.PublicKey, msg, sig4), "Verify without context")
+        })
+    }
+}
+
+func Test_KeyBytes(t *testing.T) {
+    for _, params := range testParams(t) {
+        t.Run(params.Name, func(t *testing.T) {
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            priv, err := GenerateKey(rand.Reader, params)
+            assertError(err, "GenerateKey")
+
+            pub, err := NewPublicKey(params, priv.PublicKey.Bytes())
+            assertError(err, "NewPublicKey")
+            assertBool(pub.Equal(&priv.PublicKey), "NewPublicKey")
+
+            privBytes, err := priv.Bytes()
+            assertError(err, "Bytes")
+
+            priv2, err := NewPrivateKey(params, privBytes)
+            assertError(err, "NewPrivateKey")
+            assertBool(priv2.Equal(priv), "NewPrivateKey")
+
+            msg := []byte("test data")
+
+            sig, err := Sign(rand.Reader, priv2, msg)
+            assertError(err, "Sign")
+            assertBool(Verify(pub, msg, sig), "Verify")
+        })
+    }
+}
+
+// a test OID for MLDSA65-SM2-SM3, which has no registered one
+var testOIDMLDSA65_SM2_SM3 = asn1.ObjectIdentifier{1, 2, 3, 4}
+
+func Test_MarshalPKCS8(t *testing.T) {
+    oidMLDSA65_SM2_SM3 = testOIDMLDSA65_SM2_SM3
+    defer func() {
+        oidMLDSA65_SM2_SM3 = nil
+    }()
+
+    for _, params := range testParams(t) {
+        t.Run(params.Name, func(t *testing.T) {
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            priv, err := GenerateKey(rand.Reader, params)
+            assertError(err, "GenerateKey")
+
+            pubDer, err := MarshalPublicKey(&priv.PublicKey)
+            assertError(err, "MarshalPublicKey")
+
+            pub, err := ParsePublicKey(pubDer)
+            assertError(err, "ParsePublicKey")
+            assertBool(pub.Equal(&priv.PublicKey), "ParsePublicKey")
+
+            privDer, err := MarshalPrivateKey(priv)
+            assertError(err, "MarshalPrivateKey")
+
+            priv2, err := ParsePrivateKey(privDer)
+            assertError(err, "ParsePrivateKey")
+            assertBool(priv2.Equal(priv), "ParsePrivateKey")
+        })
+    }
+}
+
+func Test_MLDSA65_SM2_SM3OID(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    defer func() {
+        oidMLDSA65_SM2_SM3 = nil
+    }()
+
+    priv, err := GenerateKey(rand.Reader, MLDSA65_SM2_SM3())
+    assertError(err, "GenerateKey")
+
+    // no OID by default
+    _, err = MarshalPublicKey(&priv.PublicKey)
+    assertBool(err != nil, "MarshalPublicKey without OID")
+
+    _, err = MarshalPrivateKey(priv)
+    assertBool(err != nil, "MarshalPrivateKey without OID")
+
+    _, err = ParamsFromOID(nil)
+    assertBool(err != nil, "ParamsFromOID nil")
+
+    // the OIDs of the other combinations can not be taken
+    err = SetMLDSA65_SM2_SM3OID(oidMLDSA65_ECDSA_P256_SHA512)
+    assertBool(err != nil, "SetMLDSA65_SM2_SM3OID registered OID")
+
+    err = SetMLDSA65_SM2_SM3OID(asn1.ObjectIdentifier{1})
+    assertBool(err != nil, "SetMLDSA65_SM2_SM3OID invalid OID")
+
+    err = SetMLDSA65_SM2_SM3OID(testOIDMLDSA65_SM2_SM3)
+    assertError(err, "SetMLDSA65_SM2_SM3OID")
+
+    oid, err := OIDFromParams(MLDSA65_SM2_SM3())
+    assertError(err, "OIDFromParams")
+    assertBool(oid.Equal(testOIDMLDSA65_SM2_SM3), "OIDFromParams")
+
+    params, err := ParamsFromOID(testOIDMLDSA65_SM2_SM3)
+    assertError(err, "ParamsFromOID")
+    assertEqual(params, MLDSA65_SM2_SM3(), "ParamsFromOID")
+
+    pubDer, err := MarshalPublicKey(&priv.PublicKey)
+    assertError(err, "MarshalPublicKey")
+
+    pub, err := ParsePublicKey(pubDer)
+    assertError(err, "ParsePublicKey")
+    assertBool(pub.Equal(&priv.PublicKey), "ParsePublicKey")
+}
+
+func Test_WrongParams(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    priv, err := GenerateKey(rand.Reader, MLDSA44_ECDSA_P256_SHA256())
+    assertError(err, "GenerateKey")
+
+    // same components, another label
+    pub, err := NewPublicKey(MLDSA65_ECDSA_P256_SHA512(), priv.PublicKey.Bytes())
+    assertBool(err != nil, "NewPublicKey wrong params")
+    assertBool(pub == nil, "NewPublicKey wrong params")
+
+    _, err = GetParams("MLDSA44-ECDSA-P256-SHA256")
+    assertError(err, "GetParams")
+
+    _, err = GetParams("unknown")
+    assertBool(err != nil, "GetParams unknown")
+}
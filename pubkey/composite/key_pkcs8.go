@@ -0,0 +1,212 @@
+package composite
+
+import (
+    "errors"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+)
+
+var (
+    // draft-ietf-lamps-pq-composite-sigs
+    oidMLDSA44_RSA2048_PSS_SHA256 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 37}
+    oidMLDSA44_Ed25519_SHA512     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 39}
+    oidMLDSA44_ECDSA_P256_SHA256  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 40}
+    oidMLDSA65_RSA3072_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 41}
+    oidMLDSA65_RSA4096_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 43}
+    oidMLDSA65_ECDSA_P256_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 45}
+    oidMLDSA65_ECDSA_P384_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 46}
+    oidMLDSA65_Ed25519_SHA512     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 48}
+    oidMLDSA87_ECDSA_P384_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 49}
+    oidMLDSA87_RSA3072_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 52}
+    oidMLDSA87_RSA4096_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 53}
+    oidMLDSA87_ECDSA_P521_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 54}
+
+    // MLDSA65-SM2-SM3 has no registered OID, it is unset
+    // until SetMLDSA65_SM2_SM3OID is called
+    oidMLDSA65_SM2_SM3 asn1.ObjectIdentifier
+)
+
+// 参数及 OID 对应
+var paramsOIDs = []struct {
+    params *Params
+    oid    asn1.ObjectIdentifier
+}{
+    {paramsMLDSA44_RSA2048_PSS_SHA256, oidMLDSA44_RSA2048_PSS_SHA256},
+    {paramsMLDSA44_Ed25519_SHA512, oidMLDSA44_Ed25519_SHA512},
+    {paramsMLDSA44_ECDSA_P256_SHA256, oidMLDSA44_ECDSA_P256_SHA256},
+    {paramsMLDSA65_RSA3072_PSS_SHA512, oidMLDSA65_RSA3072_PSS_SHA512},
+    {paramsMLDSA65_RSA4096_PSS_SHA512, oidMLDSA65_RSA4096_PSS_SHA512},
+    {paramsMLDSA65_ECDSA_P256_SHA512, oidMLDSA65_ECDSA_P256_SHA512},
+    {paramsMLDSA65_ECDSA_P384_SHA512, oidMLDSA65_ECDSA_P384_SHA512},
+    {paramsMLDSA65_Ed25519_SHA512, oidMLDSA65_Ed25519_SHA512},
+    {paramsMLDSA87_ECDSA_P384_SHA512, oidMLDSA87_ECDSA_P384_SHA512},
+    {paramsMLDSA87_RSA3072_PSS_SHA512, oidMLDSA87_RSA3072_PSS_SHA512},
+    {paramsMLDSA87_RSA4096_PSS_SHA512, oidMLDSA87_RSA4096_PSS_SHA512},
+    {paramsMLDSA87_ECDSA_P521_SHA512, oidMLDSA87_ECDSA_P521_SHA512},
+}
+
+// SetMLDSA65_SM2_SM3OID sets the OID of MLDSA65-SM2-SM3.
+// The combination is not standardized and has no registered OID, so
+// its keys and signatures can not be encoded until an OID of the
+// caller's own arc is set. It is not safe to call concurrently with
+// the encoding functions.
+func SetMLDSA65_SM2_SM3OID(oid asn1.ObjectIdentifier) error {
+    if len(oid) < 2 {
+        return errors.New("go-cryptobin/composite: invalid OID")
+    }
+
+    for _, po := range paramsOIDs {
+        if po.oid.Equal(oid) {
+            return errors.New("go-cryptobin/composite: OID " + oid.String() + " is already used by " + po.params.Name)
+        }
+    }
+
+    oidMLDSA65_SM2_SM3 = append(asn1.ObjectIdentifier(nil), oid...)
+
+    return nil
+}
+
+// 私钥 - 包装
+type pkcs8 struct {
+    Version    int
+    Algo       pkix.AlgorithmIdentifier
+    PrivateKey []byte
+    Attributes []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// 公钥 - 包装
+type pkixPublicKey struct {
+    Algo      pkix.AlgorithmIdentifier
+    BitString asn1.BitString
+}
+
+// 公钥信息 - 解析
+type publicKeyInfo struct {
+    Raw       asn1.RawContent
+    Algorithm pkix.AlgorithmIdentifier
+    PublicKey asn1.BitString
+}
+
+// OID 获取参数
+func ParamsFromOID(oid asn1.ObjectIdentifier) (*Params, error) {
+    if len(oidMLDSA65_SM2_SM3) > 0 && oidMLDSA65_SM2_SM3.Equal(oid) {
+        return paramsMLDSA65_SM2_SM3, nil
+    }
+
+    for _, po := range paramsOIDs {
+        if po.oid.Equal(oid) {
+            return po.params, nil
+        }
+    }
+
+    return nil, errors.New("go-cryptobin/composite: unknown public key algorithm")
+}
+
+// 参数获取 OID
+func OIDFromParams(params *Params) (asn1.ObjectIdentifier, error) {
+    if params == paramsMLDSA65_SM2_SM3 {
+        if len(oidMLDSA65_SM2_SM3) == 0 {
+            return nil, errors.New("go-cryptobin/composite: MLDSA65-SM2-SM3 has no OID, set it with SetMLDSA65_SM2_SM3OID")
+        }
+
+        return oidMLDSA65_SM2_SM3, nil
+    }
+
+    for _, po := range paramsOIDs {
+        if po.params == params {
+            return po.oid, nil
+        }
+    }
+
+    return nil, ErrInvalidParams
+}
+
+// 包装公钥
+func MarshalPublicKey(key *PublicKey) ([]byte, error) {
+    oid, err := OIDFromParams(key.Params)
+    if err != nil {
+        return nil, err
+    }
+
+    publicKeyBytes := key.Bytes()
+
+    pkix := pkixPublicKey{
+        Algo: pkix.AlgorithmIdentifier{
+            Algorithm: oid,
+        },
+        BitString: asn1.BitString{
+            Bytes:     publicKeyBytes,
+            BitLength: 8 * len(publicKeyBytes),
+        },
+    }
+
+    return asn1.Marshal(pkix)
+}
+
+// 解析公钥
+func ParsePublicKey(derBytes []byte) (*PublicKey, error) {
+    var pki publicKeyInfo
+    rest, err := asn1.Unmarshal(derBytes, &pki)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    params, err := ParamsFromOID(pki.Algorithm.Algorithm)
+    if err != nil {
+        return nil, err
+    }
+
+    // the parameters field must be absent
+    if len(pki.Algorithm.Parameters.FullBytes) != 0 {
+        return nil, errors.New("go-cryptobin/composite: invalid public key algorithm parameters")
+    }
+
+    return NewPublicKey(params, pki.PublicKey.RightAlign())
+}
+
+// ====================
+
+// 包装私钥, privateKey 直接保存 mldsaSeed || tradSK
+func MarshalPrivateKey(key *PrivateKey) ([]byte, error) {
+    oid, err := OIDFromParams(key.Params)
+    if err != nil {
+        return nil, err
+    }
+
+    keyBytes, err := key.Bytes()
+    if err != nil {
+        return nil, err
+    }
+
+    var privKey pkcs8
+    privKey.Algo = pkix.AlgorithmIdentifier{
+        Algorithm: oid,
+    }
+    privKey.PrivateKey = keyBytes
+
+    return asn1.Marshal(privKey)
+}
+
+// 解析私钥
+func ParsePrivateKey(derBytes []byte) (*PrivateKey, error) {
+    var privKey pkcs8
+    _, err := asn1.Unmarshal(derBytes, &privKey)
+    if err != nil {
+        return nil, err
+    }
+
+    params, err := ParamsFromOID(privKey.Algo.Algorithm)
+    if err != nil {
+        return nil, errors.New("go-cryptobin/composite: unknown private key algorithm")
+    }
+
+    if len(privKey.Algo.Parameters.FullBytes) != 0 {
+        return nil, errors.New("go-cryptobin/composite: invalid private key algorithm parameters")
+    }
+
+    return NewPrivateKey(params, privKey.PrivateKey)
+}
@@ -0,0 +1,328 @@
+// Package ecvrf implements the elliptic curve verifiable random functions
+// defined in RFC 9381.
+//
+// The suites ECVRF-P256-SHA256-TAI, ECVRF-P256-SHA256-SSWU,
+// ECVRF-EDWARDS25519-SHA512-TAI and ECVRF-EDWARDS25519-SHA512-ELL2 follow
+// RFC 9381. ECVRF-SM2-SM3-TAI and ECVRF-SM2-SM3-SSWU apply the same
+// construction to the SM2 curve with SM3.
+package ecvrf
+
+import (
+    "io"
+    "errors"
+    "math/big"
+    "crypto"
+    "crypto/subtle"
+)
+
+var (
+    ErrInvalidSuite      = errors.New("go-cryptobin/ecvrf: invalid suite")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/ecvrf: invalid public key")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/ecvrf: invalid private key")
+    ErrInvalidProof      = errors.New("go-cryptobin/ecvrf: invalid proof")
+)
+
+// PublicKey is an ECVRF public key.
+type PublicKey struct {
+    Suite *Suite
+
+    X, Y *big.Int
+}
+
+// NewPublicKey decodes a public key of the suite.
+// The key is checked as ECVRF_validate_key.
+func NewPublicKey(suite *Suite, data []byte) (*PublicKey, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    x, y := suite.group.Unmarshal(data)
+    if x == nil {
+        return nil, ErrInvalidPublicKey
+    }
+
+    // reject points of small order
+    cx, cy := suite.cofactorMult(x, y)
+    if suite.group.IsIdentity(cx, cy) {
+        return nil, ErrInvalidPublicKey
+    }
+
+    return &PublicKey{
+        Suite: suite,
+        X:     x,
+        Y:     y,
+    }, nil
+}
+
+// Bytes returns the encoded public key.
+func (pub *PublicKey) Bytes() []byte {
+    return pub.Suite.group.Marshal(pub.X, pub.Y)
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok {
+        return false
+    }
+
+    return pub.Suite == xx.Suite &&
+        pub.X.Cmp(xx.X) == 0 &&
+        pub.Y.Cmp(xx.Y) == 0
+}
+
+// Verify checks the proof pi for alpha and returns the VRF hash beta.
+func (pub *PublicKey) Verify(alpha, pi []byte) ([]byte, error) {
+    return Verify(pub, alpha, pi)
+}
+
+// PrivateKey is an ECVRF private key.
+type PrivateKey struct {
+    PublicKey
+
+    D *big.Int
+
+    // seed is the RFC 8032 private key of the edwards25519 suites.
+    seed []byte
+}
+
+// NewPrivateKey returns a private key from its encoding. For the
+// edwards25519 suites this is the 32 bytes RFC 8032 private key,
+// otherwise it is the big-endian scalar.
+func NewPrivateKey(suite *Suite, data []byte) (*PrivateKey, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    if len(data) != suite.qLen {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    priv := &PrivateKey{}
+    priv.Suite = suite
+
+    if suite.edwards {
+        h := suite.hash()
+        h.Write(data)
+        digest := h.Sum(nil)
+
+        // clamp the scalar as RFC 8032 section 5.1.5
+        digest[0] &= 248
+        digest[31] &= 127
+        digest[31] |= 64
+
+        priv.D = suite.group.StringToInt(digest[:32])
+        priv.seed = append([]byte(nil), data...)
+    } else {
+        priv.D = new(big.Int).SetBytes(data)
+        if priv.D.Sign() == 0 || priv.D.Cmp(suite.group.Order()) >= 0 {
+            return nil, ErrInvalidPrivateKey
+        }
+    }
+
+    priv.X, priv.Y = suite.group.ScalarBaseMult(priv.D.Bytes())
+
+    return priv, nil
+}
+
+// GenerateKey generates a private key of the suite.
+func GenerateKey(rand io.Reader, suite *Suite) (*PrivateKey, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    buf := make([]byte, suite.qLen)
+
+    for {
+        if _, err := io.ReadFull(rand, buf); err != nil {
+            return nil, err
+        }
+
+        priv, err := NewPrivateKey(suite, buf)
+        if err == nil {
+            return priv, nil
+        }
+    }
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// Bytes returns the encoded private key.
+func (priv *PrivateKey) Bytes() []byte {
+    if priv.Suite.edwards {
+        return append([]byte(nil), priv.seed...)
+    }
+
+    return priv.D.FillBytes(make([]byte, priv.Suite.qLen))
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok {
+        return false
+    }
+
+    return priv.PublicKey.Equal(&xx.PublicKey) &&
+        priv.D.Cmp(xx.D) == 0
+}
+
+// Prove returns the proof pi for alpha.
+func (priv *PrivateKey) Prove(alpha []byte) ([]byte, error) {
+    return Prove(priv, alpha)
+}
+
+// Prove implements ECVRF_prove of RFC 9381 section 5.1.
+func Prove(priv *PrivateKey, alpha []byte) ([]byte, error) {
+    if priv == nil || priv.Suite == nil || priv.D == nil {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    s := priv.Suite
+    g := s.group
+    q := g.Order()
+
+    pkString := priv.PublicKey.Bytes()
+
+    hx, hy, err := s.encodeToCurve(pkString, alpha)
+    if err != nil {
+        return nil, err
+    }
+
+    hString := g.Marshal(hx, hy)
+
+    gx, gy := g.ScalarMult(hx, hy, priv.D.Bytes())
+
+    k := s.generateNonce(priv, hString)
+    ux, uy := g.ScalarBaseMult(k.Bytes())
+    vx, vy := g.ScalarMult(hx, hy, k.Bytes())
+
+    c := s.generateChallenge(
+        pkString,
+        hString,
+        g.Marshal(gx, gy),
+        g.Marshal(ux, uy),
+        g.Marshal(vx, vy),
+    )
+
+    // s = (k + c*x) mod q
+    sc := new(big.Int).Mul(c, priv.D)
+    sc.Add(sc, k)
+    sc.Mod(sc, q)
+
+    pi := make([]byte, 0, s.ProofSize())
+    pi = append(pi, g.Marshal(gx, gy)...)
+    pi = append(pi, g.IntToString(c, s.cLen)...)
+    pi = append(pi, g.IntToString(sc, s.qLen)...)
+
+    return pi, nil
+}
+
+// Verify implements ECVRF_verify of RFC 9381 section 5.3.
+// It returns the VRF hash beta if the proof is valid.
+func Verify(pub *PublicKey, alpha, pi []byte) ([]byte, error) {
+    if pub == nil || pub.Suite == nil {
+        return nil, ErrInvalidPublicKey
+    }
+
+    s := pub.Suite
+    g := s.group
+    q := g.Order()
+
+    pkString := pub.Bytes()
+
+    // validate the key
+    cx, cy := s.cofactorMult(pub.X, pub.Y)
+    if g.IsIdentity(cx, cy) {
+        return nil, ErrInvalidPublicKey
+    }
+
+    gx, gy, c, sc, err := decodeProof(s, pi)
+    if err != nil {
+        return nil, err
+    }
+
+    hx, hy, err := s.encodeToCurve(pkString, alpha)
+    if err != nil {
+        return nil, err
+    }
+
+    // U = s*B - c*Y, V = s*H - c*Gamma
+    negC := new(big.Int).Sub(q, c)
+    negC.Mod(negC, q)
+
+    ux, uy := g.ScalarBaseMult(sc.Bytes())
+    tx, ty := g.ScalarMult(pub.X, pub.Y, negC.Bytes())
+    ux, uy = g.Add(ux, uy, tx, ty)
+
+    vx, vy := g.ScalarMult(hx, hy, sc.Bytes())
+    tx, ty = g.ScalarMult(gx, gy, negC.Bytes())
+    vx, vy = g.Add(vx, vy, tx, ty)
+
+    c2 := s.generateChallenge(
+        pkString,
+        g.Marshal(hx, hy),
+        g.Marshal(gx, gy),
+        g.Marshal(ux, uy),
+        g.Marshal(vx, vy),
+    )
+
+    cb := g.IntToString(c, s.cLen)
+    c2b := g.IntToString(c2, s.cLen)
+    if subtle.ConstantTimeCompare(cb, c2b) != 1 {
+        return nil, ErrInvalidProof
+    }
+
+    return proofToHash(s, gx, gy), nil
+}
+
+// ProofToHash implements ECVRF_proof_to_hash of RFC 9381 section 5.2.
+// It does not verify the proof, use Verify for untrusted proofs.
+func ProofToHash(suite *Suite, pi []byte) ([]byte, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    gx, gy, _, _, err := decodeProof(suite, pi)
+    if err != nil {
+        return nil, err
+    }
+
+    return proofToHash(suite, gx, gy), nil
+}
+
+func proofToHash(s *Suite, gx, gy *big.Int) []byte {
+    x, y := s.cofactorMult(gx, gy)
+
+    h := s.hash()
+    h.Write([]byte{s.suiteString, 0x03})
+    h.Write(s.group.Marshal(x, y))
+    h.Write([]byte{0x00})
+
+    return h.Sum(nil)
+}
+
+// decodeProof implements ECVRF_decode_proof of RFC 9381 section 5.4.4.
+func decodeProof(s *Suite, pi []byte) (gx, gy, c, sc *big.Int, err error) {
+    if len(pi) != s.ProofSize() {
+        return nil, nil, nil, nil, ErrInvalidProof
+    }
+
+    ptLen := s.group.PointLen()
+
+    gx, gy = s.group.Unmarshal(pi[:ptLen])
+    if gx == nil {
+        return nil, nil, nil, nil, ErrInvalidProof
+    }
+
+    c = s.group.StringToInt(pi[ptLen:ptLen+s.cLen])
+    sc = s.group.StringToInt(pi[ptLen+s.cLen:])
+    if sc.Cmp(s.group.Order()) >= 0 {
+        return nil, nil, nil, nil, ErrInvalidProof
+    }
+
+    return gx, gy, c, sc, nil
+}
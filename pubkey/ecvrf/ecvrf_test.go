@@ -0,0 +1,282 @@
+package ecvrf
+
+import (
+    "bytes"
+    "testing"
+    "math/big"
+    "crypto/rand"
+    "crypto/sha512"
+    "crypto/ed25519"
+    "encoding/hex"
+
+    "github.com/deatil/go-cryptobin/elliptic/hash2curve"
+)
+
+func fromHex(s string) []byte {
+    h, _ := hex.DecodeString(s)
+    return h
+}
+
+type testVector struct {
+    suite *Suite
+    sk    string
+    pk    string
+    alpha string
+    pi    string
+    beta  string
+}
+
+// RFC 9381 appendix B
+var testVectors = []testVector{
+    // ECVRF-P256-SHA256-TAI
+    {
+        suite: P256SHA256TAI(),
+        sk:    "c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721",
+        pk:    "0360fed4ba255a9d31c961eb74c6356d68c049b8923b61fa6ce669622e60f29fb6",
+        alpha: "73616d706c65",
+        pi:    "035b5c726e8c0e2c488a107c600578ee75cb702343c153cb1eb8dec77f4b5071b4a53f0a46f018bc2c56e58d383f2305e0975972c26feea0eb122fe7893c15af376b33edf7de17c6ea056d4d82de6bc02f",
+        beta:  "a3ad7b0ef73d8fc6655053ea22f9bede8c743f08bbed3d38821f0e16474b505e",
+    },
+    // ECVRF-EDWARDS25519-SHA512-ELL2
+    {
+        suite: Edwards25519SHA512ELL2(),
+        sk:    "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+        pk:    "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+        alpha: "",
+        pi:    "7d9c633ffeee27349264cf5c667579fc583b4bda63ab71d001f89c10003ab46f14adf9a3cd8b8412d9038531e865c341cafa73589b023d14311c331a9ad15ff2fb37831e00f0acaa6d73bc9997b06501",
+        beta:  "9d574bf9b8302ec0fc1e21c3ec5368269527b87b462ce36dab2d14ccf80c53cccf6758f058c5b1c856b116388152bbe509ee3b9ecfe63d93c3b4346c1fbc6c54",
+    },
+    {
+        suite: Edwards25519SHA512ELL2(),
+        sk:    "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+        pk:    "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c",
+        alpha: "72",
+        pi:    "47b327393ff2dd81336f8a2ef10339112401253b3c714eeda879f12c509072ef055b48372bb82efbdce8e10c8cb9a2f9d60e93908f93df1623ad78a86a028d6bc064dbfc75a6a57379ef855dc6733801",
+        beta:  "38561d6b77b71d30eb97a062168ae12b667ce5c28caccdf76bc88e093e4635987cd96814ce55b4689b3dd2947f80e59aac7b7675f8083865b46c89b2ce9cc735",
+    },
+    {
+        suite: Edwards25519SHA512ELL2(),
+        sk:    "c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+        pk:    "fc51cd8e6218a1a38da47ed00230f0580816ed13ba3303ac5deb911548908025",
+        alpha: "af82",
+        pi:    "926e895d308f5e328e7aa159c06eddbe56d06846abf5d98c2512235eaa57fdce35b46edfc655bc828d44ad09d1150f31374e7ef73027e14760d42e77341fe05467bb286cc2c9d7fde29120a0b2320d04",
+        beta:  "121b7f9b9aaaa29099fc04a94ba52784d44eac976dd1a3cca458733be5cd090a7b5fbd148444f17f8daf1fb55cb04b1ae85a626e30a54b4b0f8abf4a43314a58",
+    },
+}
+
+func Test_Vectors(t *testing.T) {
+    for i, v := range testVectors {
+        priv, err := NewPrivateKey(v.suite, fromHex(v.sk))
+        if err != nil {
+            t.Fatalf("%d: %v", i, err)
+        }
+
+        if got := priv.PublicKey.Bytes(); !bytes.Equal(got, fromHex(v.pk)) {
+            t.Errorf("%d: public key got %x, want %s", i, got, v.pk)
+        }
+
+        pi, err := Prove(priv, fromHex(v.alpha))
+        if err != nil {
+            t.Fatalf("%d: %v", i, err)
+        }
+
+        if !bytes.Equal(pi, fromHex(v.pi)) {
+            t.Errorf("%d: pi got %x, want %s", i, pi, v.pi)
+        }
+
+        pub, err := NewPublicKey(v.suite, fromHex(v.pk))
+        if err != nil {
+            t.Fatalf("%d: %v", i, err)
+        }
+
+        beta, err := Verify(pub, fromHex(v.alpha), fromHex(v.pi))
+        if err != nil {
+            t.Fatalf("%d: %v", i, err)
+        }
+
+        if !bytes.Equal(beta, fromHex(v.beta)) {
+            t.Errorf("%d: beta got %x, want %s", i, beta, v.beta)
+        }
+
+        beta, err = ProofToHash(v.suite, fromHex(v.pi))
+        if err != nil {
+            t.Fatalf("%d: %v", i, err)
+        }
+
+        if !bytes.Equal(beta, fromHex(v.beta)) {
+            t.Errorf("%d: ProofToHash got %x, want %s", i, beta, v.beta)
+        }
+    }
+}
+
+// RFC 9381 appendix B.2 and B.3 are not vendored, the encode_to_curve
+// step that makes these suites differ from the B.1 and B.4 ones is
+// checked against the steps of section 5.4.1 instead.
+func Test_EncodeToCurve(t *testing.T) {
+    pk := fromHex("0360fed4ba255a9d31c961eb74c6356d68c049b8923b61fa6ce669622e60f29fb6")
+
+    for _, alpha := range []string{"sample", "test"} {
+        // ECVRF-P256-SHA256-SSWU uses P256_XMD:SHA-256_SSWU_NU_ of RFC 9380
+        x, y, err := P256SHA256SSWU().encodeToCurve(pk, []byte(alpha))
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        dst := []byte("ECVRF_P256_XMD:SHA-256_SSWU_NU_\x02")
+        wantX, wantY, err := hash2curve.P256().EncodeToCurve(append(pk, alpha...), dst)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+            t.Errorf("SSWU encodeToCurve(%q) got (%x, %x), want (%x, %x)", alpha, x, y, wantX, wantY)
+        }
+    }
+
+    suite := Edwards25519SHA512TAI()
+    g := edwards25519Group{}
+
+    pk = fromHex("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+    for _, alpha := range []string{"", "r", "\xaf\x82"} {
+        x, y, err := suite.encodeToCurve(pk, []byte(alpha))
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        var wantX, wantY *big.Int
+        for ctr := 0; ctr < 256 && wantX == nil; ctr++ {
+            h := sha512.New()
+            h.Write([]byte{0x03, 0x01})
+            h.Write(pk)
+            h.Write([]byte(alpha))
+            h.Write([]byte{byte(ctr), 0x00})
+
+            wantX, wantY = g.Unmarshal(h.Sum(nil)[:32])
+        }
+
+        wantX, wantY = g.ScalarMult(wantX, wantY, []byte{8})
+        if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+            t.Errorf("TAI encodeToCurve(%q) got (%x, %x), want (%x, %x)", alpha, x, y, wantX, wantY)
+        }
+
+        if g.IsIdentity(x, y) {
+            t.Errorf("TAI encodeToCurve(%q) is the identity", alpha)
+        }
+
+        // H is in the prime order subgroup
+        if ox, oy := g.ScalarMult(x, y, g.Order().Bytes()); !g.IsIdentity(ox, oy) {
+            t.Errorf("TAI encodeToCurve(%q) is not in the subgroup", alpha)
+        }
+    }
+}
+
+func Test_Edwards25519PublicKey(t *testing.T) {
+    seed := fromHex("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+
+    priv, err := NewPrivateKey(Edwards25519SHA512TAI(), seed)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    want := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+    if !bytes.Equal(priv.PublicKey.Bytes(), want) {
+        t.Errorf("got %x, want %x", priv.PublicKey.Bytes(), []byte(want))
+    }
+
+    if !bytes.Equal(priv.Bytes(), seed) {
+        t.Error("Bytes fail")
+    }
+}
+
+func Test_ProveVerify(t *testing.T) {
+    suites := []*Suite{
+        P256SHA256TAI(),
+        P256SHA256SSWU(),
+        Edwards25519SHA512TAI(),
+        Edwards25519SHA512ELL2(),
+        SM2SM3TAI(),
+        SM2SM3SSWU(),
+    }
+
+    alpha := []byte("test-data")
+
+    for _, suite := range suites {
+        t.Run(suite.Name(), func(t *testing.T) {
+            priv, err := GenerateKey(rand.Reader, suite)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            pi, err := priv.Prove(alpha)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if len(pi) != suite.ProofSize() {
+                t.Fatalf("proof size got %d, want %d", len(pi), suite.ProofSize())
+            }
+
+            pub, err := NewPublicKey(suite, priv.PublicKey.Bytes())
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !pub.Equal(&priv.PublicKey) {
+                t.Error("public key Equal fail")
+            }
+
+            beta, err := pub.Verify(alpha, pi)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if len(beta) != suite.HashSize() {
+                t.Errorf("hash size got %d, want %d", len(beta), suite.HashSize())
+            }
+
+            beta2, _ := ProofToHash(suite, pi)
+            if !bytes.Equal(beta, beta2) {
+                t.Error("ProofToHash fail")
+            }
+
+            // proofs are deterministic
+            pi2, _ := priv.Prove(alpha)
+            if !bytes.Equal(pi, pi2) {
+                t.Error("Prove is not deterministic")
+            }
+
+            if _, err := pub.Verify([]byte("test-data2"), pi); err != ErrInvalidProof {
+                t.Error("Verify should fail with other alpha")
+            }
+
+            bad := append([]byte(nil), pi...)
+            bad[len(bad) - suite.qLen - 1] ^= 0x01
+            if _, err := pub.Verify(alpha, bad); err == nil {
+                t.Error("Verify should fail with bad proof")
+            }
+
+            if _, err := pub.Verify(alpha, pi[1:]); err != ErrInvalidProof {
+                t.Error("Verify should fail with short proof")
+            }
+
+            priv2, err := NewPrivateKey(suite, priv.Bytes())
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !priv2.Equal(priv) {
+                t.Error("private key Equal fail")
+            }
+        })
+    }
+}
+
+func Test_NewPublicKeySmallOrder(t *testing.T) {
+    // the identity of edwards25519
+    identity := make([]byte, 32)
+    identity[0] = 1
+
+    if _, err := NewPublicKey(Edwards25519SHA512ELL2(), identity); err != ErrInvalidPublicKey {
+        t.Error("small order public key should be rejected")
+    }
+}
@@ -0,0 +1,277 @@
+package ecvrf
+
+import (
+    "math/big"
+    "crypto/elliptic"
+)
+
+// group is the prime order group used by a suite.
+type group interface {
+    // Order returns the prime order q of the group.
+    Order() *big.Int
+
+    // Cofactor returns the cofactor of the curve.
+    Cofactor() int
+
+    // PointLen returns ptLen, the size of an encoded point.
+    PointLen() int
+
+    Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int)
+    ScalarMult(x, y *big.Int, k []byte) (rx, ry *big.Int)
+    ScalarBaseMult(k []byte) (x, y *big.Int)
+
+    // IsIdentity reports whether (x, y) is the identity element.
+    IsIdentity(x, y *big.Int) bool
+
+    // Marshal implements point_to_string.
+    Marshal(x, y *big.Int) []byte
+
+    // Unmarshal implements string_to_point, returns nil if data is invalid.
+    Unmarshal(data []byte) (x, y *big.Int)
+
+    // IntToString implements int_to_string.
+    IntToString(k *big.Int, size int) []byte
+
+    // StringToInt implements string_to_int.
+    StringToInt(data []byte) *big.Int
+}
+
+// weierstrassGroup is a prime order short Weierstrass curve,
+// points are encoded in SEC1 compressed form.
+type weierstrassGroup struct {
+    curve elliptic.Curve
+}
+
+func (g weierstrassGroup) Order() *big.Int {
+    return g.curve.Params().N
+}
+
+func (g weierstrassGroup) Cofactor() int {
+    return 1
+}
+
+func (g weierstrassGroup) PointLen() int {
+    return 1 + (g.curve.Params().BitSize + 7) / 8
+}
+
+func (g weierstrassGroup) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+    return g.curve.Add(x1, y1, x2, y2)
+}
+
+func (g weierstrassGroup) ScalarMult(x, y *big.Int, k []byte) (rx, ry *big.Int) {
+    return g.curve.ScalarMult(x, y, k)
+}
+
+func (g weierstrassGroup) ScalarBaseMult(k []byte) (x, y *big.Int) {
+    return g.curve.ScalarBaseMult(k)
+}
+
+func (g weierstrassGroup) IsIdentity(x, y *big.Int) bool {
+    return x.Sign() == 0 && y.Sign() == 0
+}
+
+func (g weierstrassGroup) Marshal(x, y *big.Int) []byte {
+    return elliptic.MarshalCompressed(g.curve, x, y)
+}
+
+func (g weierstrassGroup) Unmarshal(data []byte) (x, y *big.Int) {
+    if len(data) != g.PointLen() {
+        return nil, nil
+    }
+
+    return elliptic.UnmarshalCompressed(g.curve, data)
+}
+
+func (g weierstrassGroup) IntToString(k *big.Int, size int) []byte {
+    return k.FillBytes(make([]byte, size))
+}
+
+func (g weierstrassGroup) StringToInt(data []byte) *big.Int {
+    return new(big.Int).SetBytes(data)
+}
+
+// edwards25519Group is the prime order subgroup of edwards25519,
+// points are encoded as in RFC 8032.
+type edwards25519Group struct{}
+
+var (
+    one = big.NewInt(1)
+
+    // p = 2^255 - 19
+    ed25519P = bigFromHex("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed")
+    // q = 2^252 + 27742317777372353535851937790883648493
+    ed25519Q = bigFromHex("1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed")
+    // d = -121665 / 121666
+    ed25519D = bigFromHex("52036cee2b6ffe738cc740797779e89800700a4d4141d8ab75eb4dca135978a3")
+    // sqrt(-1) = 2^((p-1)/4)
+    ed25519SqrtM1 = bigFromHex("2b8324804fc1df0b2b4d00993dfbd7a72f431806ad2fe478c4ee1b274a0ea0b0")
+
+    ed25519Bx = bigFromHex("216936d3cd6e53fec0a4e231fdd6dc5c692cc7609525a7b2c9562d608f25d51a")
+    ed25519By = bigFromHex("6666666666666666666666666666666666666666666666666666666666666658")
+)
+
+func (g edwards25519Group) Order() *big.Int {
+    return ed25519Q
+}
+
+func (g edwards25519Group) Cofactor() int {
+    return 8
+}
+
+func (g edwards25519Group) PointLen() int {
+    return 32
+}
+
+// Add uses the complete twisted Edwards addition law with a = -1.
+func (g edwards25519Group) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+    p := ed25519P
+
+    x1x2 := new(big.Int).Mul(x1, x2)
+    y1y2 := new(big.Int).Mul(y1, y2)
+    x1y2 := new(big.Int).Mul(x1, y2)
+    y1x2 := new(big.Int).Mul(y1, x2)
+
+    dxy := new(big.Int).Mul(x1x2, y1y2)
+    dxy.Mul(dxy, ed25519D)
+    dxy.Mod(dxy, p)
+
+    // x = (x1*y2 + y1*x2) / (1 + d*x1*x2*y1*y2)
+    num := x1y2.Add(x1y2, y1x2)
+    den := new(big.Int).Add(one, dxy)
+    den.ModInverse(den, p)
+    x = num.Mul(num, den)
+    x.Mod(x, p)
+
+    // y = (y1*y2 + x1*x2) / (1 - d*x1*x2*y1*y2)
+    num = y1y2.Add(y1y2, x1x2)
+    den = new(big.Int).Sub(one, dxy)
+    den.Mod(den, p)
+    den.ModInverse(den, p)
+    y = num.Mul(num, den)
+    y.Mod(y, p)
+
+    return x, y
+}
+
+func (g edwards25519Group) ScalarMult(x, y *big.Int, k []byte) (rx, ry *big.Int) {
+    rx, ry = new(big.Int), new(big.Int).Set(one)
+
+    for _, b := range k {
+        for i := 7; i >= 0; i-- {
+            rx, ry = g.Add(rx, ry, rx, ry)
+            if (b >> uint(i)) & 1 == 1 {
+                rx, ry = g.Add(rx, ry, x, y)
+            }
+        }
+    }
+
+    return rx, ry
+}
+
+func (g edwards25519Group) ScalarBaseMult(k []byte) (x, y *big.Int) {
+    return g.ScalarMult(ed25519Bx, ed25519By, k)
+}
+
+func (g edwards25519Group) IsIdentity(x, y *big.Int) bool {
+    return x.Sign() == 0 && y.Cmp(one) == 0
+}
+
+func (g edwards25519Group) Marshal(x, y *big.Int) []byte {
+    out := g.IntToString(y, 32)
+    out[31] |= byte(x.Bit(0) << 7)
+
+    return out
+}
+
+// Unmarshal decodes a point as RFC 8032 section 5.1.3.
+func (g edwards25519Group) Unmarshal(data []byte) (x, y *big.Int) {
+    if len(data) != 32 {
+        return nil, nil
+    }
+
+    buf := make([]byte, 32)
+    copy(buf, data)
+
+    sign := uint(buf[31] >> 7)
+    buf[31] &= 0x7f
+
+    y = g.StringToInt(buf)
+    if y.Cmp(ed25519P) >= 0 {
+        return nil, nil
+    }
+
+    p := ed25519P
+
+    // x^2 = (y^2 - 1) / (d*y^2 + 1)
+    yy := new(big.Int).Mul(y, y)
+    yy.Mod(yy, p)
+
+    u := new(big.Int).Sub(yy, one)
+    u.Mod(u, p)
+
+    v := new(big.Int).Mul(ed25519D, yy)
+    v.Add(v, one)
+    v.Mod(v, p)
+
+    // candidate root x = (u/v)^((p+3)/8)
+    xx := new(big.Int).ModInverse(v, p)
+    xx.Mul(xx, u)
+    xx.Mod(xx, p)
+
+    e := new(big.Int).Add(p, big.NewInt(3))
+    e.Rsh(e, 3)
+    x = new(big.Int).Exp(xx, e, p)
+
+    check := new(big.Int).Mul(x, x)
+    check.Mod(check, p)
+    if check.Cmp(xx) != 0 {
+        x.Mul(x, ed25519SqrtM1)
+        x.Mod(x, p)
+
+        check.Mul(x, x)
+        check.Mod(check, p)
+        if check.Cmp(xx) != 0 {
+            return nil, nil
+        }
+    }
+
+    if x.Sign() == 0 && sign == 1 {
+        return nil, nil
+    }
+
+    if x.Bit(0) != sign {
+        x.Sub(p, x)
+    }
+
+    return x, y
+}
+
+func (g edwards25519Group) IntToString(k *big.Int, size int) []byte {
+    out := k.FillBytes(make([]byte, size))
+    reverse(out)
+
+    return out
+}
+
+func (g edwards25519Group) StringToInt(data []byte) *big.Int {
+    buf := make([]byte, len(data))
+    copy(buf, data)
+    reverse(buf)
+
+    return new(big.Int).SetBytes(buf)
+}
+
+func reverse(b []byte) {
+    for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+        b[i], b[j] = b[j], b[i]
+    }
+}
+
+func bigFromHex(s string) *big.Int {
+    b, ok := new(big.Int).SetString(s, 16)
+    if !ok {
+        panic("go-cryptobin/ecvrf: internal error: invalid encoding")
+    }
+
+    return b
+}
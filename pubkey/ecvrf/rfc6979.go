@@ -0,0 +1,77 @@
+package ecvrf
+
+import (
+    "hash"
+    "math/big"
+    "crypto/hmac"
+)
+
+// generateNonceRFC6979 returns the deterministic nonce k of
+// RFC 6979 section 3.2 for private key x and hashed message h1.
+func generateNonceRFC6979(h func() hash.Hash, q, x *big.Int, h1 []byte) *big.Int {
+    qlen := q.BitLen()
+    rolen := (qlen + 7) / 8
+    hlen := h().Size()
+
+    bits2int := func(b []byte) *big.Int {
+        v := new(big.Int).SetBytes(b)
+        if blen := len(b) * 8; blen > qlen {
+            v.Rsh(v, uint(blen - qlen))
+        }
+
+        return v
+    }
+
+    int2octets := func(v *big.Int) []byte {
+        return v.FillBytes(make([]byte, rolen))
+    }
+
+    bits2octets := func(b []byte) []byte {
+        z := bits2int(b)
+        if z.Cmp(q) >= 0 {
+            z.Sub(z, q)
+        }
+
+        return int2octets(z)
+    }
+
+    mac := func(key []byte, data ...[]byte) []byte {
+        m := hmac.New(h, key)
+        for _, d := range data {
+            m.Write(d)
+        }
+
+        return m.Sum(nil)
+    }
+
+    xb := int2octets(x)
+    hb := bits2octets(h1)
+
+    v := make([]byte, hlen)
+    for i := range v {
+        v[i] = 0x01
+    }
+
+    k := make([]byte, hlen)
+
+    k = mac(k, v, []byte{0x00}, xb, hb)
+    v = mac(k, v)
+    k = mac(k, v, []byte{0x01}, xb, hb)
+    v = mac(k, v)
+
+    for {
+        var t []byte
+        for len(t) < rolen {
+            v = mac(k, v)
+            t = append(t, v...)
+        }
+
+        nonce := bits2int(t)
+        if nonce.Sign() > 0 && nonce.Cmp(q) < 0 {
+            return nonce
+        }
+
+        k = mac(k, v, []byte{0x00})
+        v = mac(k, v)
+    }
+}
@@ -0,0 +1,250 @@
+package ecvrf
+
+import (
+    "hash"
+    "errors"
+    "math/big"
+    "crypto/sha256"
+    "crypto/sha512"
+    "crypto/elliptic"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/hash/sm3"
+    "github.com/deatil/go-cryptobin/elliptic/hash2curve"
+)
+
+// Suite is an ECVRF ciphersuite.
+type Suite struct {
+    name        string
+    suiteString byte
+
+    group group
+    hash  func() hash.Hash
+
+    // cLen is the size of the challenge in bytes.
+    cLen int
+    // qLen is the size of a scalar in bytes.
+    qLen int
+
+    // h2c is the hash-to-curve suite used by encode_to_curve,
+    // nil means try-and-increment.
+    h2c *hash2curve.Suite
+
+    // edwards marks suites which derive keys and nonces as RFC 8032.
+    edwards bool
+}
+
+// Name returns the name of the suite, such as "ECVRF-P256-SHA256-TAI".
+func (s *Suite) Name() string {
+    return s.name
+}
+
+// ID returns the suite_string of the suite.
+func (s *Suite) ID() byte {
+    return s.suiteString
+}
+
+// PublicKeySize returns the size of an encoded public key.
+func (s *Suite) PublicKeySize() int {
+    return s.group.PointLen()
+}
+
+// PrivateKeySize returns the size of an encoded private key.
+func (s *Suite) PrivateKeySize() int {
+    return s.qLen
+}
+
+// ProofSize returns the size of a proof pi.
+func (s *Suite) ProofSize() int {
+    return s.group.PointLen() + s.cLen + s.qLen
+}
+
+// HashSize returns the size of the VRF hash output beta.
+func (s *Suite) HashSize() int {
+    return s.hash().Size()
+}
+
+var (
+    p256SHA256TAI = &Suite{
+        name:        "ECVRF-P256-SHA256-TAI",
+        suiteString: 0x01,
+        group:       weierstrassGroup{elliptic.P256()},
+        hash:        sha256.New,
+        cLen:        16,
+        qLen:        32,
+    }
+
+    p256SHA256SSWU = &Suite{
+        name:        "ECVRF-P256-SHA256-SSWU",
+        suiteString: 0x02,
+        group:       weierstrassGroup{elliptic.P256()},
+        hash:        sha256.New,
+        cLen:        16,
+        qLen:        32,
+        h2c:         hash2curve.P256(),
+    }
+
+    edwards25519SHA512TAI = &Suite{
+        name:        "ECVRF-EDWARDS25519-SHA512-TAI",
+        suiteString: 0x03,
+        group:       edwards25519Group{},
+        hash:        sha512.New,
+        cLen:        16,
+        qLen:        32,
+        edwards:     true,
+    }
+
+    edwards25519SHA512ELL2 = &Suite{
+        name:        "ECVRF-EDWARDS25519-SHA512-ELL2",
+        suiteString: 0x04,
+        group:       edwards25519Group{},
+        hash:        sha512.New,
+        cLen:        16,
+        qLen:        32,
+        h2c:         hash2curve.Edwards25519(),
+        edwards:     true,
+    }
+
+    // The SM2 suites are not defined by RFC 9381, their suite_string
+    // values are taken from the private use range.
+    sm2SM3TAI = &Suite{
+        name:        "ECVRF-SM2-SM3-TAI",
+        suiteString: 0xF1,
+        group:       weierstrassGroup{sm2.P256()},
+        hash:        sm3.New,
+        cLen:        16,
+        qLen:        32,
+    }
+
+    sm2SM3SSWU = &Suite{
+        name:        "ECVRF-SM2-SM3-SSWU",
+        suiteString: 0xF2,
+        group:       weierstrassGroup{sm2.P256()},
+        hash:        sm3.New,
+        cLen:        16,
+        qLen:        32,
+        h2c:         hash2curve.SM2(),
+    }
+)
+
+// P256SHA256TAI returns the ECVRF-P256-SHA256-TAI suite.
+func P256SHA256TAI() *Suite {
+    return p256SHA256TAI
+}
+
+// P256SHA256SSWU returns the ECVRF-P256-SHA256-SSWU suite.
+func P256SHA256SSWU() *Suite {
+    return p256SHA256SSWU
+}
+
+// Edwards25519SHA512TAI returns the ECVRF-EDWARDS25519-SHA512-TAI suite.
+func Edwards25519SHA512TAI() *Suite {
+    return edwards25519SHA512TAI
+}
+
+// Edwards25519SHA512ELL2 returns the ECVRF-EDWARDS25519-SHA512-ELL2 suite.
+func Edwards25519SHA512ELL2() *Suite {
+    return edwards25519SHA512ELL2
+}
+
+// SM2SM3TAI returns the ECVRF-SM2-SM3-TAI suite,
+// the SM2 curve with SM3 and try-and-increment.
+func SM2SM3TAI() *Suite {
+    return sm2SM3TAI
+}
+
+// SM2SM3SSWU returns the ECVRF-SM2-SM3-SSWU suite,
+// the SM2 curve with SM3 and the SM2_XMD:SM3_SSWU_NU_ encoding.
+func SM2SM3SSWU() *Suite {
+    return sm2SM3SSWU
+}
+
+// encodeToCurve implements ECVRF_encode_to_curve of RFC 9381 section 5.4.1.
+func (s *Suite) encodeToCurve(salt, alpha []byte) (x, y *big.Int, err error) {
+    if s.h2c != nil {
+        dst := []byte("ECVRF_" + s.h2c.EncodeID())
+        dst = append(dst, s.suiteString)
+
+        msg := make([]byte, 0, len(salt) + len(alpha))
+        msg = append(msg, salt...)
+        msg = append(msg, alpha...)
+
+        return s.h2c.EncodeToCurve(msg, dst)
+    }
+
+    return s.encodeToCurveTAI(salt, alpha)
+}
+
+// encodeToCurveTAI implements ECVRF_encode_to_curve_try_and_increment.
+func (s *Suite) encodeToCurveTAI(salt, alpha []byte) (x, y *big.Int, err error) {
+    for ctr := 0; ctr < 256; ctr++ {
+        h := s.hash()
+        h.Write([]byte{s.suiteString, 0x01})
+        h.Write(salt)
+        h.Write(alpha)
+        h.Write([]byte{byte(ctr), 0x00})
+        hashString := h.Sum(nil)
+
+        x, y = s.interpretHashAsPoint(hashString)
+        if x != nil {
+            x, y = s.cofactorMult(x, y)
+            return x, y, nil
+        }
+    }
+
+    return nil, nil, errors.New("go-cryptobin/ecvrf: encode to curve failed")
+}
+
+// interpretHashAsPoint implements interpret_hash_value_as_a_point.
+func (s *Suite) interpretHashAsPoint(hashString []byte) (x, y *big.Int) {
+    if s.edwards {
+        return s.group.Unmarshal(hashString[:32])
+    }
+
+    data := make([]byte, 0, s.group.PointLen())
+    data = append(data, 0x02)
+    data = append(data, hashString[:s.group.PointLen() - 1]...)
+
+    return s.group.Unmarshal(data)
+}
+
+func (s *Suite) cofactorMult(x, y *big.Int) (*big.Int, *big.Int) {
+    cofactor := s.group.Cofactor()
+    if cofactor == 1 {
+        return x, y
+    }
+
+    return s.group.ScalarMult(x, y, []byte{byte(cofactor)})
+}
+
+// generateNonce implements ECVRF_nonce_generation of RFC 9381 section 5.4.2.
+func (s *Suite) generateNonce(priv *PrivateKey, hString []byte) *big.Int {
+    if s.edwards {
+        hashedSK := s.hash()
+        hashedSK.Write(priv.seed)
+
+        h := s.hash()
+        h.Write(hashedSK.Sum(nil)[32:64])
+        h.Write(hString)
+
+        k := s.group.StringToInt(h.Sum(nil))
+        return k.Mod(k, s.group.Order())
+    }
+
+    h := s.hash()
+    h.Write(hString)
+
+    return generateNonceRFC6979(s.hash, s.group.Order(), priv.D, h.Sum(nil))
+}
+
+// generateChallenge implements ECVRF_challenge_generation of RFC 9381 section 5.4.3.
+func (s *Suite) generateChallenge(points ...[]byte) *big.Int {
+    h := s.hash()
+    h.Write([]byte{s.suiteString, 0x02})
+    for _, p := range points {
+        h.Write(p)
+    }
+    h.Write([]byte{0x00})
+
+    return s.group.StringToInt(h.Sum(nil)[:s.cLen])
+}
@@ -0,0 +1,503 @@
+package hybrid
+
+import (
+    "io"
+    "errors"
+    "crypto"
+    "crypto/subtle"
+
+    "golang.org/x/crypto/sha3"
+
+    "github.com/deatil/go-cryptobin/ecdh"
+    "github.com/deatil/go-cryptobin/hash/sm3"
+    "github.com/deatil/go-cryptobin/kdf/smkdf"
+    "github.com/deatil/go-cryptobin/pubkey/mlkem"
+)
+
+var (
+    ErrInvalidScheme     = errors.New("go-cryptobin/hybrid: invalid scheme")
+    ErrInvalidSeed       = errors.New("go-cryptobin/hybrid: invalid seed length")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/hybrid: invalid public key")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/hybrid: invalid private key")
+    ErrInvalidCiphertext = errors.New("go-cryptobin/hybrid: invalid ciphertext")
+)
+
+// Encapsulator is implemented by KEM public keys, including the
+// public keys of this package and of the mlkem package.
+type Encapsulator interface {
+    Encapsulate(rand io.Reader) (sharedKey, ciphertext []byte, err error)
+}
+
+// Decapsulator is implemented by KEM private keys, including the
+// private keys of this package and of the mlkem package.
+type Decapsulator interface {
+    Decapsulate(ciphertext []byte) ([]byte, error)
+}
+
+// Combiner derives the hybrid shared key from the ML-KEM shared key,
+// the ECDH shared secret, the ECDH ciphertext (the ephemeral public key)
+// and the ECDH public key of the recipient.
+type Combiner func(kemKey, ecdhKey, ecdhCiphertext, ecdhPublicKey []byte) []byte
+
+// Scheme is a hybrid KEM made of ML-KEM and an ECDH curve.
+type Scheme struct {
+    Name string
+
+    kem       *mlkem.Params
+    curve     ecdh.Curve
+    ecdhFirst bool
+    combiner  Combiner
+
+    // size of the private key seed expanded with SHAKE256,
+    // zero if the private key is stored as its two parts
+    seedSize int
+}
+
+// NewScheme returns a hybrid KEM. If ecdhFirst is true the ECDH part is
+// placed before the ML-KEM part in keys and ciphertexts. A nil combiner
+// concatenates the two shared keys in the same order, as TLS does.
+func NewScheme(name string, params *mlkem.Params, curve ecdh.Curve, ecdhFirst bool, combiner Combiner) *Scheme {
+    return &Scheme{
+        Name:      name,
+        kem:       params,
+        curve:     curve,
+        ecdhFirst: ecdhFirst,
+        combiner:  combiner,
+    }
+}
+
+// String returns the name of the scheme.
+func (s *Scheme) String() string {
+    return s.Name
+}
+
+// KEM returns the ML-KEM parameter set of the scheme.
+func (s *Scheme) KEM() *mlkem.Params {
+    return s.kem
+}
+
+// Curve returns the ECDH curve of the scheme.
+func (s *Scheme) Curve() ecdh.Curve {
+    return s.curve
+}
+
+// split splits b into its ML-KEM and ECDH parts, the ML-KEM part
+// having kemSize bytes.
+func (s *Scheme) split(b []byte, kemSize int) (kemPart, ecdhPart []byte, ok bool) {
+    if len(b) <= kemSize {
+        return nil, nil, false
+    }
+
+    if s.ecdhFirst {
+        return b[len(b)-kemSize:], b[:len(b)-kemSize], true
+    }
+
+    return b[:kemSize], b[kemSize:], true
+}
+
+// join joins the ML-KEM and ECDH parts in the order of the scheme.
+func (s *Scheme) join(kemPart, ecdhPart []byte) []byte {
+    b := make([]byte, 0, len(kemPart)+len(ecdhPart))
+    if s.ecdhFirst {
+        b = append(b, ecdhPart...)
+        return append(b, kemPart...)
+    }
+
+    b = append(b, kemPart...)
+    return append(b, ecdhPart...)
+}
+
+// combine derives the hybrid shared key.
+func (s *Scheme) combine(kemKey, ecdhKey, ecdhCiphertext, ecdhPublicKey []byte) []byte {
+    if s.combiner == nil {
+        return s.join(kemKey, ecdhKey)
+    }
+
+    return s.combiner(kemKey, ecdhKey, ecdhCiphertext, ecdhPublicKey)
+}
+
+// XWingCombiner is the combiner of the X-Wing KEM,
+// SHA3-256(ss_M || ss_X || ct_X || pk_X || XWingLabel).
+func XWingCombiner(kemKey, ecdhKey, ecdhCiphertext, ecdhPublicKey []byte) []byte {
+    h := sha3.New256()
+    h.Write(kemKey)
+    h.Write(ecdhKey)
+    h.Write(ecdhCiphertext)
+    h.Write(ecdhPublicKey)
+
+    //   \./
+    //   /^\
+    h.Write([]byte(`\.//^\`))
+
+    return h.Sum(nil)
+}
+
+// SM3Combiner derives a 32 bytes key with the SM3 KDF of GB/T 32918.4,
+// KDF(ss_M || ss_E || ct_E || pk_E, 256).
+func SM3Combiner(kemKey, ecdhKey, ecdhCiphertext, ecdhPublicKey []byte) []byte {
+    z := make([]byte, 0, len(kemKey)+len(ecdhKey)+len(ecdhCiphertext)+len(ecdhPublicKey))
+    z = append(z, kemKey...)
+    z = append(z, ecdhKey...)
+    z = append(z, ecdhCiphertext...)
+    z = append(z, ecdhPublicKey...)
+
+    return smkdf.Key(sm3.New, z, 32)
+}
+
+var (
+    // draft-ietf-tls-ecdhe-mlkem
+    x25519MLKEM768     = NewScheme("X25519MLKEM768", mlkem.MLKEM768(), ecdh.X25519(), false, nil)
+    secP256r1MLKEM768  = NewScheme("SecP256r1MLKEM768", mlkem.MLKEM768(), ecdh.P256(), true, nil)
+    secP384r1MLKEM1024 = NewScheme("SecP384r1MLKEM1024", mlkem.MLKEM1024(), ecdh.P384(), true, nil)
+
+    // draft-yang-tls-hybrid-sm2-mlkem
+    curveSM2MLKEM768 = NewScheme("curveSM2MLKEM768", mlkem.MLKEM768(), ecdh.GmSM2(), true, nil)
+
+    // draft-connolly-cfrg-xwing-kem
+    xwing = &Scheme{
+        Name:     "X-Wing",
+        kem:      mlkem.MLKEM768(),
+        curve:    ecdh.X25519(),
+        combiner: XWingCombiner,
+        seedSize: 32,
+    }
+
+    // ECDH over the SM2 curve and ML-KEM-768 with the SM3 KDF combiner
+    sm2MLKEM768 = NewScheme("SM2-MLKEM768", mlkem.MLKEM768(), ecdh.GmSM2(), false, SM3Combiner)
+)
+
+// X25519MLKEM768 returns the TLS hybrid of ML-KEM-768 and X25519.
+func X25519MLKEM768() *Scheme {
+    return x25519MLKEM768
+}
+
+// SecP256r1MLKEM768 returns the TLS hybrid of P-256 and ML-KEM-768.
+func SecP256r1MLKEM768() *Scheme {
+    return secP256r1MLKEM768
+}
+
+// SecP384r1MLKEM1024 returns the TLS hybrid of P-384 and ML-KEM-1024.
+func SecP384r1MLKEM1024() *Scheme {
+    return secP384r1MLKEM1024
+}
+
+// CurveSM2MLKEM768 returns the TLS hybrid of SM2 and ML-KEM-768.
+// As curveSM2 of RFC 8998, the SM2 part is ECDH over the SM2 curve,
+// not the key exchange protocol of GB/T 32918.3.
+func CurveSM2MLKEM768() *Scheme {
+    return curveSM2MLKEM768
+}
+
+// XWing returns the X-Wing KEM.
+func XWing() *Scheme {
+    return xwing
+}
+
+// SM2MLKEM768 returns the hybrid of ML-KEM-768 and SM2 with the SM3
+// KDF combiner. The SM2 part is ECDH of an ephemeral key with the
+// static key of the recipient over the SM2 curve, not the key exchange
+// protocol of GB/T 32918.3, which needs both parties to have a static
+// key and an identity.
+func SM2MLKEM768() *Scheme {
+    return sm2MLKEM768
+}
+
+// GetScheme returns the scheme with the name.
+func GetScheme(name string) (*Scheme, error) {
+    switch name {
+        case x25519MLKEM768.Name:
+            return x25519MLKEM768, nil
+        case secP256r1MLKEM768.Name:
+            return secP256r1MLKEM768, nil
+        case secP384r1MLKEM1024.Name:
+            return secP384r1MLKEM1024, nil
+        case curveSM2MLKEM768.Name:
+            return curveSM2MLKEM768, nil
+        case xwing.Name:
+            return xwing, nil
+        case sm2MLKEM768.Name:
+            return sm2MLKEM768, nil
+    }
+
+    return nil, ErrInvalidScheme
+}
+
+// PublicKey is a hybrid encapsulation key.
+type PublicKey struct {
+    Scheme *Scheme
+
+    kem  *mlkem.PublicKey
+    ecdh *ecdh.PublicKey
+}
+
+// NewPublicKey parses an encoded encapsulation key.
+func NewPublicKey(scheme *Scheme, b []byte) (*PublicKey, error) {
+    if scheme == nil {
+        return nil, ErrInvalidScheme
+    }
+
+    kemPart, ecdhPart, ok := scheme.split(b, scheme.kem.PublicKeySize())
+    if !ok {
+        return nil, ErrInvalidPublicKey
+    }
+
+    kemKey, err := mlkem.NewPublicKey(scheme.kem, kemPart)
+    if err != nil {
+        return nil, ErrInvalidPublicKey
+    }
+
+    ecdhKey, err := scheme.curve.NewPublicKey(ecdhPart)
+    if err != nil {
+        return nil, ErrInvalidPublicKey
+    }
+
+    pub := &PublicKey{
+        Scheme: scheme,
+        kem:    kemKey,
+        ecdh:   ecdhKey,
+    }
+
+    return pub, nil
+}
+
+// KEMPublicKey returns the ML-KEM part of the key.
+func (pub *PublicKey) KEMPublicKey() *mlkem.PublicKey {
+    return pub.kem
+}
+
+// ECDHPublicKey returns the ECDH part of the key.
+func (pub *PublicKey) ECDHPublicKey() *ecdh.PublicKey {
+    return pub.ecdh
+}
+
+// Bytes returns the encoded encapsulation key.
+func (pub *PublicKey) Bytes() []byte {
+    return pub.Scheme.join(pub.kem.Bytes(), pub.ecdh.Bytes())
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok {
+        return false
+    }
+
+    return pub.Scheme == xx.Scheme &&
+        subtle.ConstantTimeCompare(pub.Bytes(), xx.Bytes()) == 1
+}
+
+// Encapsulate generates a shared key and an associated ciphertext,
+// drawing random bytes from rand.
+func (pub *PublicKey) Encapsulate(rand io.Reader) (sharedKey, ciphertext []byte, err error) {
+    m := make([]byte, 32)
+    if _, err = io.ReadFull(rand, m); err != nil {
+        return nil, nil, err
+    }
+
+    ephemeral, err := pub.Scheme.curve.GenerateKey(rand)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return pub.encapsulate(m, ephemeral)
+}
+
+// EncapsulateDeterministically is like Encapsulate, but takes the 32
+// bytes ML-KEM randomness followed by the ephemeral ECDH private key.
+// It must only be used for testing.
+func (pub *PublicKey) EncapsulateDeterministically(seed []byte) (sharedKey, ciphertext []byte, err error) {
+    if len(seed) <= 32 {
+        return nil, nil, ErrInvalidSeed
+    }
+
+    ephemeral, err := pub.Scheme.curve.NewPrivateKey(seed[32:])
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return pub.encapsulate(seed[:32], ephemeral)
+}
+
+func (pub *PublicKey) encapsulate(m []byte, ephemeral *ecdh.PrivateKey) (sharedKey, ciphertext []byte, err error) {
+    kemKey, kemCiphertext, err := pub.kem.EncapsulateDeterministically(m)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    ecdhKey, err := ephemeral.ECDH(pub.ecdh)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    ecdhCiphertext := ephemeral.PublicKey().Bytes()
+
+    sharedKey = pub.Scheme.combine(kemKey, ecdhKey, ecdhCiphertext, pub.ecdh.Bytes())
+    ciphertext = pub.Scheme.join(kemCiphertext, ecdhCiphertext)
+
+    return sharedKey, ciphertext, nil
+}
+
+// PrivateKey is a hybrid decapsulation key.
+type PrivateKey struct {
+    PublicKey
+
+    // the private key seed, empty if the scheme stores the two parts
+    seed []byte
+
+    kem  *mlkem.PrivateKey
+    ecdh *ecdh.PrivateKey
+}
+
+// GenerateKey generates a new decapsulation key, drawing random
+// bytes from rand.
+func GenerateKey(rand io.Reader, scheme *Scheme) (*PrivateKey, error) {
+    if scheme == nil {
+        return nil, ErrInvalidScheme
+    }
+
+    if scheme.seedSize > 0 {
+        seed := make([]byte, scheme.seedSize)
+        if _, err := io.ReadFull(rand, seed); err != nil {
+            return nil, err
+        }
+
+        return NewPrivateKey(scheme, seed)
+    }
+
+    kemKey, err := mlkem.GenerateKey(rand, scheme.kem)
+    if err != nil {
+        return nil, err
+    }
+
+    ecdhKey, err := scheme.curve.GenerateKey(rand)
+    if err != nil {
+        return nil, err
+    }
+
+    return newPrivateKey(scheme, kemKey, ecdhKey), nil
+}
+
+// NewPrivateKey parses an encoded decapsulation key. For X-Wing it is
+// the 32 bytes seed, for the other schemes it is the 64 bytes ML-KEM
+// seed and the ECDH private key, in the order of the scheme.
+func NewPrivateKey(scheme *Scheme, b []byte) (*PrivateKey, error) {
+    if scheme == nil {
+        return nil, ErrInvalidScheme
+    }
+
+    var kemSeed, ecdhPart []byte
+
+    if scheme.seedSize > 0 {
+        if len(b) != scheme.seedSize {
+            return nil, ErrInvalidSeed
+        }
+
+        expanded := make([]byte, mlkem.SeedSize+32)
+
+        h := sha3.NewShake256()
+        h.Write(b)
+        h.Read(expanded)
+
+        kemSeed, ecdhPart = expanded[:mlkem.SeedSize], expanded[mlkem.SeedSize:]
+    } else {
+        var ok bool
+        kemSeed, ecdhPart, ok = scheme.split(b, mlkem.SeedSize)
+        if !ok {
+            return nil, ErrInvalidPrivateKey
+        }
+    }
+
+    kemKey, err := mlkem.NewKeyFromSeed(scheme.kem, kemSeed)
+    if err != nil {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    ecdhKey, err := scheme.curve.NewPrivateKey(ecdhPart)
+    if err != nil {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    priv := newPrivateKey(scheme, kemKey, ecdhKey)
+    if scheme.seedSize > 0 {
+        priv.seed = append([]byte(nil), b...)
+    }
+
+    return priv, nil
+}
+
+func newPrivateKey(scheme *Scheme, kemKey *mlkem.PrivateKey, ecdhKey *ecdh.PrivateKey) *PrivateKey {
+    priv := &PrivateKey{
+        kem:  kemKey,
+        ecdh: ecdhKey,
+    }
+
+    priv.PublicKey = PublicKey{
+        Scheme: scheme,
+        kem:    &kemKey.PublicKey,
+        ecdh:   ecdhKey.PublicKey(),
+    }
+
+    return priv
+}
+
+// Public returns the encapsulation key of priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// KEMPrivateKey returns the ML-KEM part of the key.
+func (priv *PrivateKey) KEMPrivateKey() *mlkem.PrivateKey {
+    return priv.kem
+}
+
+// ECDHPrivateKey returns the ECDH part of the key.
+func (priv *PrivateKey) ECDHPrivateKey() *ecdh.PrivateKey {
+    return priv.ecdh
+}
+
+// Bytes returns the encoded decapsulation key.
+func (priv *PrivateKey) Bytes() []byte {
+    if priv.Scheme.seedSize > 0 {
+        return append([]byte(nil), priv.seed...)
+    }
+
+    return priv.Scheme.join(priv.kem.Seed(), priv.ecdh.Bytes())
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok {
+        return false
+    }
+
+    return priv.Scheme == xx.Scheme &&
+        subtle.ConstantTimeCompare(priv.Bytes(), xx.Bytes()) == 1
+}
+
+// Decapsulate generates a shared key from a ciphertext.
+func (priv *PrivateKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+    scheme := priv.Scheme
+
+    kemCiphertext, ecdhCiphertext, ok := scheme.split(ciphertext, scheme.kem.CiphertextSize())
+    if !ok {
+        return nil, ErrInvalidCiphertext
+    }
+
+    kemKey, err := priv.kem.Decapsulate(kemCiphertext)
+    if err != nil {
+        return nil, ErrInvalidCiphertext
+    }
+
+    ephemeral, err := scheme.curve.NewPublicKey(ecdhCiphertext)
+    if err != nil {
+        return nil, ErrInvalidCiphertext
+    }
+
+    ecdhKey, err := priv.ecdh.ECDH(ephemeral)
+    if err != nil {
+        return nil, err
+    }
+
+    return scheme.combine(kemKey, ecdhKey, ecdhCiphertext, priv.ecdh.PublicKey().Bytes()), nil
+}
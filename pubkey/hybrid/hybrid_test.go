@@ -0,0 +1,265 @@
+package hybrid
+
+import (
+    "bytes"
+    "testing"
+    "crypto/rand"
+    "encoding/hex"
+
+    "golang.org/x/crypto/sha3"
+
+    "github.com/deatil/go-cryptobin/ecdh"
+    "github.com/deatil/go-cryptobin/pubkey/mlkem"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+var allSchemes = []*Scheme{
+    X25519MLKEM768(),
+    SecP256r1MLKEM768(),
+    SecP384r1MLKEM1024(),
+    CurveSM2MLKEM768(),
+    XWing(),
+    SM2MLKEM768(),
+}
+
+func fromHex(s string) []byte {
+    b, err := hex.DecodeString(s)
+    if err != nil {
+        panic(err)
+    }
+
+    return b
+}
+
+func Test_EncapsulateDecapsulate(t *testing.T) {
+    for _, scheme := range allSchemes {
+        t.Run(scheme.String(), func(t *testing.T) {
+            assertEqual := cryptobin_test.AssertEqualT(t)
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            priv, err := GenerateKey(rand.Reader, scheme)
+            assertError(err, "GenerateKey")
+
+            var enc Encapsulator = &priv.PublicKey
+            var dec Decapsulator = priv
+
+            sharedKey, ciphertext, err := enc.Encapsulate(rand.Reader)
+            assertError(err, "Encapsulate")
+
+            sharedKey2, err := dec.Decapsulate(ciphertext)
+            assertError(err, "Decapsulate")
+
+            assertEqual(sharedKey2, sharedKey, "Decapsulate")
+
+            pub, err := NewPublicKey(scheme, priv.PublicKey.Bytes())
+            assertError(err, "NewPublicKey")
+            assertBool(pub.Equal(&priv.PublicKey), "NewPublicKey")
+
+            priv2, err := NewPrivateKey(scheme, priv.Bytes())
+            assertError(err, "NewPrivateKey")
+            assertBool(priv2.Equal(priv), "NewPrivateKey")
+
+            sharedKey3, err := priv2.Decapsulate(ciphertext)
+            assertError(err, "Decapsulate")
+
+            assertEqual(sharedKey3, sharedKey, "Decapsulate 2")
+
+            priv3, err := GenerateKey(rand.Reader, scheme)
+            assertError(err, "GenerateKey")
+
+            sharedKey4, err := priv3.Decapsulate(ciphertext)
+            if err == nil {
+                assertBool(!bytes.Equal(sharedKey4, sharedKey), "wrong key")
+            }
+
+            if _, err = priv.Decapsulate(ciphertext[:len(ciphertext)-1]); err == nil {
+                t.Error("should fail with short ciphertext")
+            }
+        })
+    }
+}
+
+func Test_X25519MLKEM768(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    priv, err := GenerateKey(rand.Reader, X25519MLKEM768())
+    assertError(err, "GenerateKey")
+
+    pub := priv.PublicKey.Bytes()
+    assertEqual(len(pub), 1184+32, "public key size")
+    assertEqual(pub[:1184], priv.KEMPrivateKey().PublicKey.Bytes(), "public key order")
+
+    seed := make([]byte, 64)
+    rand.Read(seed)
+
+    sharedKey, ciphertext, err := priv.PublicKey.EncapsulateDeterministically(seed)
+    assertError(err, "EncapsulateDeterministically")
+
+    assertEqual(len(ciphertext), 1088+32, "ciphertext size")
+    assertEqual(len(sharedKey), 64, "shared key size")
+
+    // the shared key is the ML-KEM shared key followed by the X25519 one
+    kemKey, err := priv.KEMPrivateKey().Decapsulate(ciphertext[:1088])
+    assertError(err, "Decapsulate")
+
+    assertEqual(sharedKey[:32], kemKey, "ML-KEM shared key")
+}
+
+func Test_SecP256r1MLKEM768(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    priv, err := GenerateKey(rand.Reader, SecP256r1MLKEM768())
+    assertError(err, "GenerateKey")
+
+    pub := priv.PublicKey.Bytes()
+    assertEqual(len(pub), 65+1184, "public key size")
+    assertEqual(pub[:65], priv.ECDHPrivateKey().PublicKey().Bytes(), "public key order")
+
+    sharedKey, ciphertext, err := priv.PublicKey.Encapsulate(rand.Reader)
+    assertError(err, "Encapsulate")
+
+    assertEqual(len(ciphertext), 65+1088, "ciphertext size")
+    assertEqual(len(sharedKey), 64, "shared key size")
+
+    kemKey, err := priv.KEMPrivateKey().Decapsulate(ciphertext[65:])
+    assertError(err, "Decapsulate")
+
+    assertEqual(sharedKey[32:], kemKey, "ML-KEM shared key")
+}
+
+// test vectors of draft-connolly-cfrg-xwing-kem, with the public keys
+// and ciphertexts given as their SHA3-256 digests
+func Test_XWing_Vectors(t *testing.T) {
+    tests := []struct {
+        seed  string
+        pk    string
+        eseed string
+        ct    string
+        ss    string
+    }{
+        {
+            seed:  "7f9c2ba4e88f827d616045507605853ed73b8093f6efbc88eb1a6eacfa66ef26",
+            pk:    "5121745904643ad9dfacca7869292c19a8a69533b53e60666b7db910b4ad6367",
+            eseed: "3cb1eea988004b93103cfb0aeefd2a686e01fa4a58e8a3639ca8a1e3f9ae57e235b8cc873c23dc62b8d260169afa2f75ab916a58d974918835d25e6a435085b2",
+            ct:    "c0abd149f83f45324ac3a7ddc7606c71f257e5ea86113522834a0ee1bcb34e3e",
+            ss:    "d2df0522128f09dd8e2c92b1e905c793d8f57a54c3da25861f10bf4ca613e384",
+        },
+        {
+            seed:  "badfd6dfaac359a5efbb7bcc4b59d538df9a04302e10c8bc1cbf1a0b3a5120ea",
+            pk:    "799b6016e5daa56ffa1b5e79f7caf73413ceecd6df428642404cac41ddee4853",
+            eseed: "17cda7cfad765f5623474d368ccca8af0007cd9f5e4c849f167a580b14aabdefaee7eef47cb0fca9767be1fda69419dfb927e9df07348b196691abaeb580b32d",
+            ct:    "7680b7ba47ae09bac4b43001edcef9d98e50df20026e70ba6a424447e1f2b961",
+            ss:    "f2e86241c64d60f6649fbc6c5b7d17180b780a3f34355e64a85749949c45f150",
+        },
+        {
+            seed:  "ef58538b8d23f87732ea63b02b4fa0f4873360e2841928cd60dd4cee8cc0d4c9",
+            pk:    "1ef0c99a06026450564957a5402a788feffbbefdcce55d25de254d0a49eb095a",
+            eseed: "22a96188d032675c8ac850933c7aff1533b94c834adbb69c6115bad4692d8619f90b0cdf8a7b9c264029ac185b70b83f2801f2f4b3f70c593ea3aeeb613a7f1b",
+            ct:    "3088688d63201d5d844170b79f148b2791c15f346ff6f8bd559807fbd442f91f",
+            ss:    "953f7f4e8c5b5049bdc771d1dffada0dd961477d1a2ae0988baa7ea6898d893f",
+        },
+    }
+
+    for i, td := range tests {
+        priv, err := NewPrivateKey(XWing(), fromHex(td.seed))
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        pk := priv.PublicKey.Bytes()
+        if len(pk) != 1216 {
+            t.Errorf("[%d] public key size got %d", i, len(pk))
+        }
+
+        pkSum := sha3.Sum256(pk)
+        if !bytes.Equal(pkSum[:], fromHex(td.pk)) {
+            t.Errorf("[%d] public key mismatch", i)
+        }
+
+        pub, err := NewPublicKey(XWing(), pk)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        ss, ct, err := pub.EncapsulateDeterministically(fromHex(td.eseed))
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        ctSum := sha3.Sum256(ct)
+        if !bytes.Equal(ctSum[:], fromHex(td.ct)) {
+            t.Errorf("[%d] ciphertext mismatch", i)
+        }
+
+        if !bytes.Equal(ss, fromHex(td.ss)) {
+            t.Errorf("[%d] shared key got %x, want %s", i, ss, td.ss)
+        }
+
+        ss2, err := priv.Decapsulate(ct)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !bytes.Equal(ss2, fromHex(td.ss)) {
+            t.Errorf("[%d] Decapsulate got %x, want %s", i, ss2, td.ss)
+        }
+    }
+}
+
+func Test_MLKEMInterface(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    priv, err := mlkem.GenerateKey(rand.Reader, mlkem.MLKEM768())
+    assertError(err, "GenerateKey")
+
+    var enc Encapsulator = &priv.PublicKey
+    var dec Decapsulator = priv
+
+    sharedKey, ciphertext, err := enc.Encapsulate(rand.Reader)
+    assertError(err, "Encapsulate")
+
+    sharedKey2, err := dec.Decapsulate(ciphertext)
+    assertError(err, "Decapsulate")
+
+    assertEqual(sharedKey2, sharedKey, "Decapsulate")
+}
+
+func Test_NewScheme(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    scheme := NewScheme("X448-MLKEM1024", mlkem.MLKEM1024(), ecdh.X448(), false, XWingCombiner)
+
+    priv, err := GenerateKey(rand.Reader, scheme)
+    assertError(err, "GenerateKey")
+
+    sharedKey, ciphertext, err := priv.PublicKey.Encapsulate(rand.Reader)
+    assertError(err, "Encapsulate")
+
+    sharedKey2, err := priv.Decapsulate(ciphertext)
+    assertError(err, "Decapsulate")
+
+    assertEqual(sharedKey2, sharedKey, "Decapsulate")
+    assertEqual(len(sharedKey), 32, "shared key size")
+}
+
+func Test_GetScheme(t *testing.T) {
+    for _, scheme := range allSchemes {
+        s, err := GetScheme(scheme.Name)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if s != scheme {
+            t.Errorf("GetScheme(%s) fail", scheme.Name)
+        }
+    }
+
+    if _, err := GetScheme("unknown"); err == nil {
+        t.Error("should fail")
+    }
+}
@@ -0,0 +1,122 @@
+package lms
+
+import (
+    "fmt"
+    "errors"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+)
+
+var (
+    // id-alg-hss-lms-hashsig, RFC 8708
+    oidHSSLMSHashSig = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 17}
+)
+
+// 私钥 - 包装
+type pkcs8 struct {
+    Version    int
+    Algo       pkix.AlgorithmIdentifier
+    PrivateKey []byte
+    Attributes []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// 公钥 - 包装
+type pkixPublicKey struct {
+    Algo      pkix.AlgorithmIdentifier
+    BitString asn1.BitString
+}
+
+// 公钥信息 - 解析
+type publicKeyInfo struct {
+    Raw       asn1.RawContent
+    Algorithm pkix.AlgorithmIdentifier
+    PublicKey asn1.BitString
+}
+
+// 包装公钥, 公钥数据直接放入 BIT STRING
+func MarshalPublicKey(key *HSSPublicKey) ([]byte, error) {
+    publicKeyBytes := key.ToBytes()
+
+    pkix := pkixPublicKey{
+        Algo: pkix.AlgorithmIdentifier{
+            Algorithm: oidHSSLMSHashSig,
+        },
+        BitString: asn1.BitString{
+            Bytes:     publicKeyBytes,
+            BitLength: 8 * len(publicKeyBytes),
+        },
+    }
+
+    return asn1.Marshal(pkix)
+}
+
+// 解析公钥
+func ParsePublicKey(derBytes []byte) (*HSSPublicKey, error) {
+    var pki publicKeyInfo
+    rest, err := asn1.Unmarshal(derBytes, &pki)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    if !pki.Algorithm.Algorithm.Equal(oidHSSLMSHashSig) {
+        return nil, errors.New("lms: unknown public key algorithm")
+    }
+
+    // the parameters field must be absent
+    if len(pki.Algorithm.Parameters.FullBytes) != 0 {
+        return nil, errors.New("lms: invalid public key algorithm parameters")
+    }
+
+    return NewHSSPublicKeyFromBytes(pki.PublicKey.RightAlign())
+}
+
+// ====================
+
+// 包装私钥. 私钥包含计数器 q, 导出后的私钥不能和原私钥同时使用
+func MarshalPrivateKey(key *HSSPrivateKey) ([]byte, error) {
+    keyBytes, err := key.ToBytes()
+    if err != nil {
+        return nil, err
+    }
+
+    var privKey pkcs8
+    privKey.Algo = pkix.AlgorithmIdentifier{
+        Algorithm: oidHSSLMSHashSig,
+    }
+
+    privKey.PrivateKey, err = asn1.Marshal(keyBytes)
+    if err != nil {
+        return nil, fmt.Errorf("lms: failed to marshal private key: %v", err)
+    }
+
+    return asn1.Marshal(privKey)
+}
+
+// 解析私钥
+func ParsePrivateKey(derBytes []byte) (*HSSPrivateKey, error) {
+    var privKey pkcs8
+    _, err := asn1.Unmarshal(derBytes, &privKey)
+    if err != nil {
+        return nil, err
+    }
+
+    if !privKey.Algo.Algorithm.Equal(oidHSSLMSHashSig) {
+        return nil, errors.New("lms: unknown private key algorithm")
+    }
+
+    var keyBytes []byte
+    rest, err := asn1.Unmarshal(privKey.PrivateKey, &keyBytes)
+    if err != nil {
+        return nil, fmt.Errorf("lms: invalid private key: %v", err)
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    return NewHSSPrivateKeyFromBytes(keyBytes)
+}
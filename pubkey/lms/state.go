@@ -0,0 +1,129 @@
+package lms
+
+import (
+    "io"
+    "sync"
+    "errors"
+    "crypto"
+
+    "github.com/deatil/go-cryptobin/pubkey/stateful"
+)
+
+// HSSSigner signs with a HSSPrivateKey whose state is kept in a
+// stateful.Store. Indices are reserved in batches: before the first
+// index of a batch is used, the key is saved with its counter moved
+// past the whole batch. After a crash the reloaded key continues
+// after the reserved batch, so an index is never used twice at the
+// cost of skipping the unused rest of the batch.
+type HSSSigner struct {
+    mu    sync.Mutex
+    key   *HSSPrivateKey
+    store stateful.Store
+    batch uint64
+    res   stateful.Reservation
+}
+
+// NewHSSSigner loads the private key from store and returns a signer
+// reserving batch indices at a time. The store must be initialized
+// with the bytes of the private key, see HSSPrivateKey.ToBytes.
+func NewHSSSigner(store stateful.Store, batch uint64) (*HSSSigner, error) {
+    data, err := store.Load()
+    if err != nil {
+        return nil, err
+    }
+
+    key, err := NewHSSPrivateKeyFromBytes(data)
+    if err != nil {
+        return nil, err
+    }
+
+    s := &HSSSigner{
+        key:   key,
+        store: store,
+        batch: batch,
+    }
+
+    // nothing is reserved yet
+    s.res.Next = uint64(s.signingKey().q)
+    s.res.Limit = s.res.Next
+
+    return s, nil
+}
+
+// Public returns the public key of the signer.
+func (s *HSSSigner) Public() crypto.PublicKey {
+    return s.key.Public()
+}
+
+// PublicKey returns the HSSPublicKey of the signer.
+func (s *HSSSigner) PublicKey() HSSPublicKey {
+    return s.key.PublicKey()
+}
+
+// Remaining returns the number of signatures left.
+func (s *HSSSigner) Remaining() uint64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    return s.max() - s.res.Next
+}
+
+// Sign signs msg like HSSPrivateKey.Sign, after the index used has
+// been reserved in the store.
+func (s *HSSSigner) Sign(rng io.Reader, msg []byte, opts crypto.SignerOpts) ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if s.res.NeedsReserve() {
+        if err := s.reserve(); err != nil {
+            return nil, err
+        }
+    }
+
+    sig, err := s.key.Sign(rng, msg, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    s.res.Next = uint64(s.signingKey().q)
+
+    return sig, nil
+}
+
+// reserve saves the key with the counter of the signing key moved to
+// the end of a new batch.
+func (s *HSSSigner) reserve() error {
+    limit, err := s.res.Reserve(s.batch, s.max())
+    if err != nil {
+        return err
+    }
+
+    key := s.signingKey()
+
+    q := key.q
+    key.q = uint32(limit)
+    data, err := s.key.ToBytes()
+    key.q = q
+
+    if err != nil {
+        return err
+    }
+
+    if err = s.store.Save(data); err != nil {
+        return errors.New("lms: save state fail: " + err.Error())
+    }
+
+    s.res.Limit = limit
+
+    return nil
+}
+
+// signingKey returns the bottom level LMS key, which signs messages.
+func (s *HSSSigner) signingKey() *PrivateKey {
+    return &s.key.LmsKey[s.key.Levels-1]
+}
+
+// max returns the number of signatures of the signing key.
+func (s *HSSSigner) max() uint64 {
+    return uint64(1) << s.signingKey().typ.Params().H
+}
@@ -0,0 +1,132 @@
+package lms
+
+import (
+    "testing"
+    "crypto/rand"
+    "path/filepath"
+
+    "github.com/deatil/go-cryptobin/tool/test"
+    "github.com/deatil/go-cryptobin/pubkey/stateful"
+)
+
+func newTestHSSStore(t *testing.T) (*HSSPrivateKey, stateful.Store) {
+    priv, err := GenerateHSSKey(rand.Reader, []HSSOpts{
+        HSSOpts{
+            Type:    LMS_SHA256_M32_H5,
+            OtsType: LMOTS_SHA256_N32_W8,
+        },
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    data, err := priv.ToBytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    store := stateful.NewFileStore(filepath.Join(t.TempDir(), "hss.state"))
+    if err = store.Save(data); err != nil {
+        t.Fatal(err)
+    }
+
+    return priv, store
+}
+
+func Test_HSSSigner(t *testing.T) {
+    assertEqual := test.AssertEqualT(t)
+    assertError := test.AssertErrorT(t)
+    assertBool := test.AssertBoolT(t)
+
+    priv, store := newTestHSSStore(t)
+
+    signer, err := NewHSSSigner(store, 4)
+    assertError(err, "NewHSSSigner")
+    assertEqual(signer.Remaining(), uint64(32), "Remaining")
+
+    pub := signer.PublicKey()
+
+    msg := []byte("example")
+
+    sig, err := signer.Sign(rand.Reader, msg, nil)
+    assertError(err, "Sign")
+    assertBool(pub.Verify(msg, sig), "Verify")
+    assertBool(priv.HSSPublicKey.Verify(msg, sig), "Verify")
+    assertEqual(signer.Remaining(), uint64(31), "Remaining")
+
+    // the whole batch is reserved before signing
+    data, err := store.Load()
+    assertError(err, "Load")
+
+    saved, err := NewHSSPrivateKeyFromBytes(data)
+    assertError(err, "NewHSSPrivateKeyFromBytes")
+    assertEqual(saved.LmsKey[0].Q(), uint32(4), "reserved q")
+
+    for i := 0; i < 3; i++ {
+        _, err = signer.Sign(rand.Reader, msg, nil)
+        assertError(err, "Sign")
+    }
+
+    data2, err := store.Load()
+    assertError(err, "Load")
+    assertEqual(data2, data, "no save inside a batch")
+
+    // a reloaded signer never reuses a reserved index
+    signer.Sign(rand.Reader, msg, nil)
+
+    signer2, err := NewHSSSigner(store, 4)
+    assertError(err, "NewHSSSigner")
+    assertEqual(signer2.Remaining(), uint64(24), "Remaining after reload")
+}
+
+func Test_HSSSigner_Exhausted(t *testing.T) {
+    assertEqual := test.AssertEqualT(t)
+    assertError := test.AssertErrorT(t)
+
+    _, store := newTestHSSStore(t)
+
+    signer, err := NewHSSSigner(store, 10)
+    assertError(err, "NewHSSSigner")
+
+    msg := []byte("example")
+
+    for i := 0; i < 32; i++ {
+        _, err = signer.Sign(rand.Reader, msg, nil)
+        assertError(err, "Sign")
+    }
+
+    assertEqual(signer.Remaining(), uint64(0), "Remaining")
+
+    _, err = signer.Sign(rand.Reader, msg, nil)
+    assertEqual(err, stateful.ErrExhausted, "Sign exhausted")
+}
+
+func Test_MarshalPKCS8(t *testing.T) {
+    assertError := test.AssertErrorT(t)
+    assertBool := test.AssertBoolT(t)
+
+    priv, err := GenerateHSSKey(rand.Reader, DefaultOpts)
+    assertError(err, "GenerateHSSKey")
+
+    pub := priv.PublicKey()
+
+    pubDer, err := MarshalPublicKey(&pub)
+    assertError(err, "MarshalPublicKey")
+
+    pub2, err := ParsePublicKey(pubDer)
+    assertError(err, "ParsePublicKey")
+    assertBool(pub2.Equal(&pub), "ParsePublicKey")
+
+    privDer, err := MarshalPrivateKey(priv)
+    assertError(err, "MarshalPrivateKey")
+
+    priv2, err := ParsePrivateKey(privDer)
+    assertError(err, "ParsePrivateKey")
+    assertBool(priv2.Equal(priv), "ParsePrivateKey")
+
+    msg := []byte("example")
+
+    sig, err := priv2.Sign(rand.Reader, msg, nil)
+    assertError(err, "Sign")
+    assertBool(pub2.Verify(msg, sig), "Verify")
+}
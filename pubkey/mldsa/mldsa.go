@@ -0,0 +1,843 @@
+// based on https://github.com/golang/go/blob/master/src/crypto/internal/fips140/mldsa/mldsa.go
+//
+// original copyright:
+// Copyright 2025 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mldsa
+
+import (
+    "io"
+    "bytes"
+    "errors"
+    "crypto"
+    "crypto/subtle"
+    "encoding/binary"
+
+    "golang.org/x/crypto/sha3"
+)
+
+const (
+    // ContextMaxSize is the maximum length (in bytes) allowed for context.
+    ContextMaxSize = 255
+
+    // SeedSize is the size of a seed used to generate a private key.
+    SeedSize = 32
+)
+
+var (
+    ErrInvalidParams     = errors.New("go-cryptobin/mldsa: invalid params")
+    ErrInvalidSeed       = errors.New("go-cryptobin/mldsa: invalid seed length")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/mldsa: invalid public key")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/mldsa: invalid private key")
+    ErrInvalidSignature  = errors.New("go-cryptobin/mldsa: invalid signature")
+    ErrContextTooLong    = errors.New("go-cryptobin/mldsa: context too long")
+    ErrInvalidHash       = errors.New("go-cryptobin/mldsa: unsupported hash algorithm")
+    ErrInvalidDigest     = errors.New("go-cryptobin/mldsa: invalid digest length")
+)
+
+// Params is a ML-DSA parameter set, FIPS 204 section 4.
+type Params struct {
+    Name string
+
+    k, l   int // dimensions of A
+    eta    int // bound for secret coefficients
+    gamma1 int // log₂(γ₁), where [-γ₁+1, γ₁] is the bound of y
+    gamma2 int // denominator of γ₂ = (q - 1) / gamma2
+    lambda int // collison strength
+    tau    int // number of non-zero coefficients in challenge
+    omega  int // max number of hints in MakeHint
+}
+
+const maxK, maxL, maxLambda, maxGamma1 = 8, 7, 256, 19
+
+// String returns the name of the parameter set.
+func (p *Params) String() string {
+    return p.Name
+}
+
+// PublicKeySize returns the size of a public key.
+func (p *Params) PublicKeySize() int {
+    // ρ + k × n × 10-bit coefficients of t₁
+    return 32 + p.k*n*10/8
+}
+
+// PrivateKeySize returns the size of a semi-expanded private key.
+func (p *Params) PrivateKeySize() int {
+    return semiExpandedPrivKeySize(p)
+}
+
+// SignatureSize returns the size of a signature.
+func (p *Params) SignatureSize() int {
+    // challenge + l × n × (γ₁+1)-bit coefficients of z + hint
+    return (p.lambda / 4) + p.l*n*(p.gamma1+1)/8 + p.omega + p.k
+}
+
+var (
+    params44 = &Params{Name: "ML-DSA-44", k: 4, l: 4, eta: 2, gamma1: 17, gamma2: 88, lambda: 128, tau: 39, omega: 80}
+    params65 = &Params{Name: "ML-DSA-65", k: 6, l: 5, eta: 4, gamma1: 19, gamma2: 32, lambda: 192, tau: 49, omega: 55}
+    params87 = &Params{Name: "ML-DSA-87", k: 8, l: 7, eta: 2, gamma1: 19, gamma2: 32, lambda: 256, tau: 60, omega: 75}
+)
+
+// MLDSA44 returns the ML-DSA-44 parameter set.
+func MLDSA44() *Params {
+    return params44
+}
+
+// MLDSA65 returns the ML-DSA-65 parameter set.
+func MLDSA65() *Params {
+    return params65
+}
+
+// MLDSA87 returns the ML-DSA-87 parameter set.
+func MLDSA87() *Params {
+    return params87
+}
+
+// GetParams returns the parameter set with the name.
+func GetParams(name string) (*Params, error) {
+    switch name {
+        case params44.Name:
+            return params44, nil
+        case params65.Name:
+            return params65, nil
+        case params87.Name:
+            return params87, nil
+    }
+
+    return nil, ErrInvalidParams
+}
+
+// Options implements crypto.SignerOpts and augments with parameters
+// that are specific to the ML-DSA signature schemes.
+type Options struct {
+    // Hash is crypto.Hash(0) for ML-DSA. Otherwise HashML-DSA is used,
+    // and the message passed to Sign must be the digest of the hash.
+    Hash crypto.Hash
+
+    // Context is an optional domain separation string for signing.
+    // Its length must be less or equal than 255 bytes.
+    Context string
+
+    // Deterministic makes Sign use the deterministic variant,
+    // which does not read any randomness.
+    Deterministic bool
+}
+
+// HashFunc returns o.Hash.
+func (o *Options) HashFunc() crypto.Hash {
+    return o.Hash
+}
+
+// PublicKey is the type of ML-DSA public keys.
+type PublicKey struct {
+    Params *Params
+
+    raw []byte
+    tr  [64]byte // public key hash
+}
+
+// NewPublicKey parses an encoded public key.
+func NewPublicKey(params *Params, b []byte) (*PublicKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(b) != params.PublicKeySize() {
+        return nil, ErrInvalidPublicKey
+    }
+
+    // A and NTT(t₁ ⋅ 2ᵈ) are computed on demand in Verify, which
+    // keeps public keys small.
+    pub := &PublicKey{}
+    pub.Params = params
+    pub.raw = append([]byte(nil), b...)
+    pub.tr = computePublicKeyHash(b)
+
+    return pub, nil
+}
+
+// Bytes returns the encoded public key.
+func (pub *PublicKey) Bytes() []byte {
+    return append([]byte(nil), pub.raw...)
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok {
+        return false
+    }
+
+    return pub.Params == xx.Params &&
+        subtle.ConstantTimeCompare(pub.raw, xx.raw) == 1
+}
+
+// PrivateKey is the type of ML-DSA private keys.
+type PrivateKey struct {
+    PublicKey
+
+    seed []byte
+    k    [32]byte
+    a    []nttElement // k*l matrix in row major order
+    s1   []nttElement
+    s2   []nttElement
+    t0   []nttElement
+}
+
+// GenerateKey generates a new private key, drawing random bytes from rand.
+func GenerateKey(rand io.Reader, params *Params) (*PrivateKey, error) {
+    seed := make([]byte, SeedSize)
+    if _, err := io.ReadFull(rand, seed); err != nil {
+        return nil, err
+    }
+
+    return NewKeyFromSeed(params, seed)
+}
+
+// NewKeyFromSeed derives a private key from the 32 bytes seed ξ,
+// implementing ML-DSA.KeyGen_internal, FIPS 204 Algorithm 6.
+func NewKeyFromSeed(params *Params, seed []byte) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(seed) != SeedSize {
+        return nil, ErrInvalidSeed
+    }
+
+    p, k, l := params, params.k, params.l
+
+    priv := &PrivateKey{}
+    priv.Params = params
+    priv.seed = append([]byte(nil), seed...)
+
+    H := sha3.NewShake256()
+    H.Write(seed)
+    H.Write([]byte{byte(k), byte(l)})
+
+    rho, rhos := make([]byte, 32), make([]byte, 64)
+    H.Read(rho)
+    H.Read(rhos)
+    H.Read(priv.k[:])
+
+    priv.a = computeMatrixA(rho, p)
+
+    priv.s1 = make([]nttElement, l)
+    for r := 0; r < l; r++ {
+        priv.s1[r] = ntt(sampleBoundedPoly(rhos, byte(r), p))
+    }
+
+    priv.s2 = make([]nttElement, k)
+    for r := 0; r < k; r++ {
+        priv.s2[r] = ntt(sampleBoundedPoly(rhos, byte(l+r), p))
+    }
+
+    // t = NTT⁻¹(Â ∘ ŝ₁ + ŝ₂)
+    // (t₁, t₀) = Power2Round(t)
+    t1 := make([][n]uint16, k)
+    priv.t0 = make([]nttElement, k)
+    for i := 0; i < k; i++ {
+        tHat := priv.s2[i]
+        for j := 0; j < l; j++ {
+            tHat = polyAdd(tHat, nttMul(priv.a[i*l+j], priv.s1[j]))
+        }
+
+        t := inverseNTT(tHat)
+
+        var w ringElement
+        for j := 0; j < n; j++ {
+            t1[i][j], w[j] = power2Round(t[j])
+        }
+
+        priv.t0[i] = ntt(w)
+    }
+
+    priv.raw = pkEncode(nil, rho, t1, p)
+    priv.tr = computePublicKeyHash(priv.raw)
+
+    return priv, nil
+}
+
+// Public returns the public key of priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// Seed returns the 32 bytes seed of the key, or nil if the key
+// was parsed from the semi-expanded form.
+func (priv *PrivateKey) Seed() []byte {
+    if len(priv.seed) == 0 {
+        return nil
+    }
+
+    return append([]byte(nil), priv.seed...)
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok {
+        return false
+    }
+
+    return priv.Params == xx.Params &&
+        subtle.ConstantTimeCompare(priv.Bytes(), xx.Bytes()) == 1
+}
+
+// Sign signs the message with priv. If opts.HashFunc() is zero the
+// message is signed with ML-DSA, otherwise the message must be the
+// digest of the hash and it is signed with HashML-DSA.
+// The context and the deterministic variant can be set with *Options.
+func (priv *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+    var context string
+    var deterministic bool
+    var hash crypto.Hash
+
+    if opts != nil {
+        hash = opts.HashFunc()
+    }
+
+    if o, ok := opts.(*Options); ok {
+        context = o.Context
+        deterministic = o.Deterministic
+    }
+
+    mu, err := computeMessageHash(priv.tr[:], message, context, hash)
+    if err != nil {
+        return nil, err
+    }
+
+    var rnd [32]byte
+    if !deterministic {
+        if _, err := io.ReadFull(rand, rnd[:]); err != nil {
+            return nil, err
+        }
+    }
+
+    return priv.signInternal(&mu, &rnd), nil
+}
+
+// Sign signs the message with priv, using ML-DSA and an empty context.
+func Sign(rand io.Reader, priv *PrivateKey, message []byte) ([]byte, error) {
+    return priv.Sign(rand, message, crypto.Hash(0))
+}
+
+// Verify reports whether sig is a valid ML-DSA signature of message
+// by pub with an empty context.
+func Verify(pub *PublicKey, message, sig []byte) bool {
+    return VerifyWithOptions(pub, message, sig, crypto.Hash(0)) == nil
+}
+
+// VerifyWithOptions reports whether sig is a valid signature of message
+// by pub. A valid signature is indicated by returning a nil error.
+func VerifyWithOptions(pub *PublicKey, message, sig []byte, opts crypto.SignerOpts) error {
+    var context string
+    var hash crypto.Hash
+
+    if opts != nil {
+        hash = opts.HashFunc()
+    }
+
+    if o, ok := opts.(*Options); ok {
+        context = o.Context
+    }
+
+    mu, err := computeMessageHash(pub.tr[:], message, context, hash)
+    if err != nil {
+        return err
+    }
+
+    if !pub.verifyInternal(&mu, sig) {
+        return ErrInvalidSignature
+    }
+
+    return nil
+}
+
+// DER encoded OIDs of the hash functions for HashML-DSA,
+// FIPS 204 Algorithm 4.
+var hashOIDs = map[crypto.Hash][]byte{
+    crypto.SHA256:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01},
+    crypto.SHA384:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02},
+    crypto.SHA512:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03},
+    crypto.SHA224:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x04},
+    crypto.SHA512_224: {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x05},
+    crypto.SHA512_256: {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x06},
+    crypto.SHA3_224:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x07},
+    crypto.SHA3_256:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x08},
+    crypto.SHA3_384:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x09},
+    crypto.SHA3_512:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x0a},
+}
+
+// computeMessageHash computes μ for ML-DSA when hash is zero, and for
+// HashML-DSA with the digest otherwise, FIPS 204 Algorithms 2 to 5.
+func computeMessageHash(tr []byte, msg []byte, context string, hash crypto.Hash) ([64]byte, error) {
+    var mu [64]byte
+
+    if len(context) > ContextMaxSize {
+        return mu, ErrContextTooLong
+    }
+
+    H := sha3.NewShake256()
+    H.Write(tr)
+
+    if hash == crypto.Hash(0) {
+        H.Write([]byte{0, byte(len(context))})
+        H.Write([]byte(context))
+    } else {
+        oid, ok := hashOIDs[hash]
+        if !ok {
+            return mu, ErrInvalidHash
+        }
+
+        if len(msg) != hash.Size() {
+            return mu, ErrInvalidDigest
+        }
+
+        H.Write([]byte{1, byte(len(context))})
+        H.Write([]byte(context))
+        H.Write(oid)
+    }
+
+    H.Write(msg)
+    H.Read(mu[:])
+
+    return mu, nil
+}
+
+// computeInternalMessageHash computes μ for the internal interface,
+// which is used by the ACVP tests.
+func computeInternalMessageHash(tr []byte, msg []byte) [64]byte {
+    var mu [64]byte
+
+    H := sha3.NewShake256()
+    H.Write(tr)
+    H.Write(msg)
+    H.Read(mu[:])
+
+    return mu
+}
+
+func computeMatrixA(rho []byte, p *Params) []nttElement {
+    k, l := p.k, p.l
+
+    A := make([]nttElement, k*l)
+    for r := 0; r < k; r++ {
+        for s := 0; s < l; s++ {
+            A[r*l+s] = sampleNTT(rho, byte(s), byte(r))
+        }
+    }
+
+    return A
+}
+
+func computePublicKeyHash(pk []byte) [64]byte {
+    var tr [64]byte
+
+    H := sha3.NewShake256()
+    H.Write(pk)
+    H.Read(tr[:])
+
+    return tr
+}
+
+// computeT1Hat returns NTT(t₁ ⋅ 2ᵈ).
+func computeT1Hat(t1 [][n]uint16) []nttElement {
+    t1Hat := make([]nttElement, len(t1))
+    for i := range t1 {
+        var w ringElement
+        for j := range t1[i] {
+            // t₁ <= 2¹⁰ - 1
+            // t₁ ⋅ 2ᵈ <= 2ᵈ(2¹⁰ - 1) = 2²³ - 2¹³ < q = 2²³ - 2¹³ + 1
+            z, _ := fieldToMontgomery(uint32(t1[i][j]) << 13)
+            w[j] = z
+        }
+
+        t1Hat[i] = ntt(w)
+    }
+
+    return t1Hat
+}
+
+func pkEncode(buf []byte, rho []byte, t1 [][n]uint16, p *Params) []byte {
+    pk := append(buf, rho...)
+    for _, w := range t1[:p.k] {
+        // Encode four at a time into 4 * 10 bits = 5 bytes.
+        for i := 0; i < n; i += 4 {
+            c0 := w[i]
+            c1 := w[i+1]
+            c2 := w[i+2]
+            c3 := w[i+3]
+            b0 := byte(c0 >> 0)
+            b1 := byte((c0 >> 8) | (c1 << 2))
+            b2 := byte((c1 >> 6) | (c2 << 4))
+            b3 := byte((c2 >> 4) | (c3 << 6))
+            b4 := byte(c3 >> 2)
+            pk = append(pk, b0, b1, b2, b3, b4)
+        }
+    }
+
+    return pk
+}
+
+func pkDecode(pk []byte, t1 [][n]uint16, p *Params) (rho []byte, err error) {
+    if len(pk) != p.PublicKeySize() {
+        return nil, ErrInvalidPublicKey
+    }
+
+    rho, pk = pk[:32], pk[32:]
+    for r := range t1 {
+        // Decode four at a time from 4 * 10 bits = 5 bytes.
+        for i := 0; i < n; i += 4 {
+            b0, b1, b2, b3, b4 := pk[0], pk[1], pk[2], pk[3], pk[4]
+            t1[r][i+0] = uint16(b0>>0) | uint16(b1&0b0000_0011)<<8
+            t1[r][i+1] = uint16(b1>>2) | uint16(b2&0b0000_1111)<<6
+            t1[r][i+2] = uint16(b2>>4) | uint16(b3&0b0011_1111)<<4
+            t1[r][i+3] = uint16(b3>>6) | uint16(b4&0b1111_1111)<<2
+            pk = pk[5:]
+        }
+    }
+
+    return rho, nil
+}
+
+// signInternal implements ML-DSA.Sign_internal, FIPS 204 Algorithm 7.
+func (priv *PrivateKey) signInternal(mu *[64]byte, rnd *[32]byte) []byte {
+    p, k, l := priv.Params, priv.Params.k, priv.Params.l
+    A, s1, s2, t0 := priv.a, priv.s1, priv.s2, priv.t0
+
+    beta := p.tau * p.eta
+    gamma1 := uint32(1 << p.gamma1)
+    gamma1Beta := gamma1 - uint32(beta)
+    gamma2 := (q - 1) / uint32(p.gamma2)
+    gamma2Beta := gamma2 - uint32(beta)
+
+    H := sha3.NewShake256()
+    H.Write(priv.k[:])
+    H.Write(rnd[:])
+    H.Write(mu[:])
+    nonce := make([]byte, 64)
+    H.Read(nonce)
+
+    kappa := 0
+
+sign:
+    for {
+        // Main rejection sampling loop. Leaking rejected ch values, which
+        // check causes the rejection and which coefficient failed the check
+        // (but not the value or sign of the coefficient) is safe, see the
+        // Dilithium round 3 specification section 5.5.
+
+        y := make([]ringElement, l, maxL)
+        for r := range y {
+            counter := make([]byte, 2)
+            binary.LittleEndian.PutUint16(counter, uint16(kappa))
+            kappa++
+
+            H.Reset()
+            H.Write(nonce)
+            H.Write(counter)
+            v := make([]byte, (p.gamma1+1)*n/8, (maxGamma1+1)*n/8)
+            H.Read(v)
+
+            y[r] = bitUnpack(v, p)
+        }
+
+        // w = NTT⁻¹(Â ∘ NTT(y))
+        yHat := make([]nttElement, l, maxL)
+        for i := range y {
+            yHat[i] = ntt(y[i])
+        }
+
+        w := make([]ringElement, k, maxK)
+        for i := range w {
+            var wHat nttElement
+            for j := 0; j < l; j++ {
+                wHat = polyAdd(wHat, nttMul(A[i*l+j], yHat[j]))
+            }
+
+            w[i] = inverseNTT(wHat)
+        }
+
+        H.Reset()
+        H.Write(mu[:])
+        for i := range w {
+            w1Encode(H, highBits(w[i], p), p)
+        }
+
+        ch := make([]byte, p.lambda/4, maxLambda/4)
+        H.Read(ch)
+
+        // sampleInBall is not constant time, but leaking
+        // rejected ch values is acceptable.
+        c := ntt(sampleInBall(ch, p))
+
+        cs1 := make([]ringElement, l, maxL)
+        for i := range cs1 {
+            cs1[i] = inverseNTT(nttMul(c, s1[i]))
+        }
+
+        cs2 := make([]ringElement, k, maxK)
+        for i := range cs2 {
+            cs2[i] = inverseNTT(nttMul(c, s2[i]))
+        }
+
+        z := make([]ringElement, l, maxL)
+        for i := range y {
+            z[i] = polyAdd(y[i], cs1[i])
+
+            // Reject if ||z||∞ ≥ γ1 − β
+            if coefficientsExceedBound(z[i], gamma1Beta) {
+                continue sign
+            }
+        }
+
+        for i := range w {
+            r0 := polySub(w[i], cs2[i])
+
+            // Reject if ||LowBits(r0)||∞ ≥ γ2 − β
+            if lowBitsExceedBound(r0, gamma2Beta, p) {
+                continue sign
+            }
+        }
+
+        ct0 := make([]ringElement, k, maxK)
+        for i := range ct0 {
+            ct0[i] = inverseNTT(nttMul(c, t0[i]))
+
+            // Reject if ||ct0||∞ ≥ γ2
+            if coefficientsExceedBound(ct0[i], gamma2) {
+                continue sign
+            }
+        }
+
+        count1s := 0
+        h := make([][n]byte, k, maxK)
+        for i := range w {
+            var count int
+            h[i], count = makeHint(ct0[i], w[i], cs2[i], p)
+            count1s += count
+        }
+
+        // Reject if number of hints > ω
+        if count1s > p.omega {
+            continue sign
+        }
+
+        return sigEncode(ch, z, h, p)
+    }
+}
+
+// verifyInternal implements ML-DSA.Verify_internal, FIPS 204 Algorithm 8.
+func (pub *PublicKey) verifyInternal(mu *[64]byte, sig []byte) bool {
+    p, k, l := pub.Params, pub.Params.k, pub.Params.l
+
+    beta := p.tau * p.eta
+    gamma1 := uint32(1 << p.gamma1)
+    gamma1Beta := gamma1 - uint32(beta)
+
+    t1 := make([][n]uint16, k, maxK)
+    rho, err := pkDecode(pub.raw, t1, p)
+    if err != nil {
+        return false
+    }
+
+    A := computeMatrixA(rho, p)
+    t1Hat := computeT1Hat(t1)
+
+    z := make([]ringElement, l, maxL)
+    h := make([][n]byte, k, maxK)
+    ch, err := sigDecode(sig, z, h, p)
+    if err != nil {
+        return false
+    }
+
+    c := ntt(sampleInBall(ch, p))
+
+    // w = Â ∘ NTT(z) − NTT(c) ∘ NTT(t₁ ⋅ 2ᵈ)
+    zHat := make([]nttElement, l, maxL)
+    for i := range zHat {
+        zHat[i] = ntt(z[i])
+    }
+
+    w := make([]ringElement, k, maxK)
+    for i := range w {
+        var wHat nttElement
+        for j := 0; j < l; j++ {
+            wHat = polyAdd(wHat, nttMul(A[i*l+j], zHat[j]))
+        }
+
+        wHat = polySub(wHat, nttMul(c, t1Hat[i]))
+        w[i] = inverseNTT(wHat)
+    }
+
+    // Use hints h to compute w₁ from w(approx).
+    H := sha3.NewShake256()
+    H.Write(mu[:])
+    for i := range w {
+        w1Encode(H, useHint(w[i], h[i], p), p)
+    }
+
+    computedCH := make([]byte, p.lambda/4, maxLambda/4)
+    H.Read(computedCH)
+
+    for i := range z {
+        if coefficientsExceedBound(z[i], gamma1Beta) {
+            return false
+        }
+    }
+
+    return bytes.Equal(ch, computedCH)
+}
+
+// w1Encode implements w1Encode from FIPS 204, writing directly into H.
+func w1Encode(H sha3.ShakeHash, w [n]byte, p *Params) {
+    switch p.gamma2 {
+        case 32:
+            // Coefficients are <= (q − 1)/(2γ2) − 1 = 15, four bits each.
+            buf := make([]byte, 4*n/8)
+            for i := 0; i < n; i += 2 {
+                b0 := w[i]
+                b1 := w[i+1]
+                buf[i/2] = b0 | b1<<4
+            }
+
+            H.Write(buf)
+        case 88:
+            // Coefficients are <= (q − 1)/(2γ2) − 1 = 43, six bits each.
+            buf := make([]byte, 6*n/8)
+            for i := 0; i < n; i += 4 {
+                b0 := w[i]
+                b1 := w[i+1]
+                b2 := w[i+2]
+                b3 := w[i+3]
+                buf[3*i/4+0] = (b0 >> 0) | (b1 << 6)
+                buf[3*i/4+1] = (b1 >> 2) | (b2 << 4)
+                buf[3*i/4+2] = (b2 >> 4) | (b3 << 2)
+            }
+
+            H.Write(buf)
+        default:
+            panic("go-cryptobin/mldsa: internal error: unsupported γ2")
+    }
+}
+
+func coefficientsExceedBound(w ringElement, bound uint32) bool {
+    for i := range w {
+        if fieldInfinityNorm(w[i]) >= bound {
+            return true
+        }
+    }
+
+    return false
+}
+
+func lowBitsExceedBound(w ringElement, bound uint32, p *Params) bool {
+    switch p.gamma2 {
+        case 32:
+            for i := range w {
+                _, r0 := decompose32(w[i])
+                if constantTimeAbs(r0) >= bound {
+                    return true
+                }
+            }
+        case 88:
+            for i := range w {
+                _, r0 := decompose88(w[i])
+                if constantTimeAbs(r0) >= bound {
+                    return true
+                }
+            }
+        default:
+            panic("go-cryptobin/mldsa: internal error: unsupported γ2")
+    }
+
+    return false
+}
+
+func sigEncode(ch []byte, z []ringElement, h [][n]byte, p *Params) []byte {
+    sig := make([]byte, 0, p.SignatureSize())
+    sig = append(sig, ch...)
+    for i := range z {
+        sig = bitPack(sig, z[i], p)
+    }
+
+    sig = hintEncode(sig, h, p)
+
+    return sig
+}
+
+func sigDecode(sig []byte, z []ringElement, h [][n]byte, p *Params) (ch []byte, err error) {
+    if len(sig) != p.SignatureSize() {
+        return nil, ErrInvalidSignature
+    }
+
+    ch, sig = sig[:p.lambda/4], sig[p.lambda/4:]
+    for i := range z {
+        length := (p.gamma1 + 1) * n / 8
+        z[i] = bitUnpack(sig[:length], p)
+        sig = sig[length:]
+    }
+
+    if err := hintDecode(sig, h, p); err != nil {
+        return nil, err
+    }
+
+    return ch, nil
+}
+
+func hintEncode(buf []byte, h [][n]byte, p *Params) []byte {
+    omega, k := p.omega, p.k
+
+    out, y := sliceForAppend(buf, omega+k)
+
+    var idx byte
+    for i := 0; i < k; i++ {
+        for j := 0; j < n; j++ {
+            if h[i][j] != 0 {
+                y[idx] = byte(j)
+                idx++
+            }
+        }
+
+        y[omega+i] = idx
+    }
+
+    return out
+}
+
+func hintDecode(y []byte, h [][n]byte, p *Params) error {
+    omega, k := p.omega, p.k
+
+    if len(y) != omega+k {
+        return ErrInvalidSignature
+    }
+
+    var idx byte
+    for i := 0; i < k; i++ {
+        limit := y[omega+i]
+        if limit < idx || limit > byte(omega) {
+            return ErrInvalidSignature
+        }
+
+        first := idx
+        for idx < limit {
+            if idx > first && y[idx-1] >= y[idx] {
+                return ErrInvalidSignature
+            }
+
+            h[i][y[idx]] = 1
+            idx++
+        }
+    }
+
+    for i := idx; i < byte(omega); i++ {
+        if y[i] != 0 {
+            return ErrInvalidSignature
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,636 @@
+package mldsa
+
+import (
+    "os"
+    "bytes"
+    "testing"
+    "crypto"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/asn1"
+    "encoding/json"
+    "compress/gzip"
+    "path/filepath"
+)
+
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) (err error) {
+    var s string
+    if err = json.Unmarshal(data, &s); err != nil {
+        return err
+    }
+
+    *b, err = hex.DecodeString(s)
+    return err
+}
+
+type acvp struct {
+    groups  []json.RawMessage
+    results map[int]json.RawMessage
+}
+
+func readACVPGroups(t *testing.T, path string) []json.RawMessage {
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var file struct {
+        TestGroups []json.RawMessage `json:"testGroups"`
+    }
+    if err := json.NewDecoder(r).Decode(&file); err != nil {
+        t.Fatal(err)
+    }
+
+    return file.TestGroups
+}
+
+func readACVP(t *testing.T, name string) *acvp {
+    dir := filepath.Join("testdata", name)
+
+    a := &acvp{
+        groups:  readACVPGroups(t, filepath.Join(dir, "prompt.json.gz")),
+        results: make(map[int]json.RawMessage),
+    }
+
+    for _, rawGroup := range readACVPGroups(t, filepath.Join(dir, "expectedResults.json.gz")) {
+        var group struct {
+            Tests []json.RawMessage `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        for _, rawTest := range group.Tests {
+            var tc struct {
+                TcID int `json:"tcId"`
+            }
+            if err := json.Unmarshal(rawTest, &tc); err != nil {
+                t.Fatal(err)
+            }
+
+            a.results[tc.TcID] = rawTest
+        }
+    }
+
+    return a
+}
+
+func (a *acvp) result(t *testing.T, tcID int, result any) {
+    raw, ok := a.results[tcID]
+    if !ok {
+        t.Fatalf("missing result: %d", tcID)
+    }
+
+    if err := json.Unmarshal(raw, result); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// NIST ACVP ML-DSA-keyGen-FIPS204
+func Test_ACVP_KeyGen(t *testing.T) {
+    vectors := readACVP(t, "ML-DSA-keyGen-FIPS204")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet string `json:"parameterSet"`
+            Tests        []struct {
+                TcID int      `json:"tcId"`
+                Seed hexBytes `json:"seed"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        for _, tc := range group.Tests {
+            var result struct {
+                Pk hexBytes `json:"pk"`
+                Sk hexBytes `json:"sk"`
+            }
+            vectors.result(t, tc.TcID, &result)
+
+            priv, err := NewKeyFromSeed(params, tc.Seed)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !bytes.Equal(priv.PublicKey.Bytes(), result.Pk) {
+                t.Fatalf("tc=%d: pk got %x, want %x", tc.TcID, priv.PublicKey.Bytes(), result.Pk)
+            }
+
+            if !bytes.Equal(priv.Bytes(), result.Sk) {
+                t.Fatalf("tc=%d: sk got %x, want %x", tc.TcID, priv.Bytes(), result.Sk)
+            }
+
+            priv2, err := NewPrivateKey(params, result.Sk)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !priv.Equal(priv2) {
+                t.Fatalf("tc=%d: NewPrivateKey fail", tc.TcID)
+            }
+        }
+    }
+}
+
+// NIST ACVP ML-DSA-sigGen-FIPS204, internal interface
+func Test_ACVP_SigGen(t *testing.T) {
+    vectors := readACVP(t, "ML-DSA-sigGen-FIPS204")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet  string `json:"parameterSet"`
+            Deterministic bool   `json:"deterministic"`
+            Tests         []struct {
+                TcID    int      `json:"tcId"`
+                Sk      hexBytes `json:"sk"`
+                Message hexBytes `json:"message"`
+                Rnd     hexBytes `json:"rnd"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        for _, tc := range group.Tests {
+            var result struct {
+                Signature hexBytes `json:"signature"`
+            }
+            vectors.result(t, tc.TcID, &result)
+
+            priv, err := NewPrivateKey(params, tc.Sk)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            var rnd [32]byte
+            if !group.Deterministic {
+                copy(rnd[:], tc.Rnd)
+            }
+
+            mu := computeInternalMessageHash(priv.tr[:], tc.Message)
+            sig := priv.signInternal(&mu, &rnd)
+
+            if !bytes.Equal(sig, result.Signature) {
+                t.Fatalf("tc=%d: signature got %x, want %x", tc.TcID, sig, result.Signature)
+            }
+        }
+    }
+}
+
+// NIST ACVP ML-DSA-sigVer-FIPS204, internal interface
+func Test_ACVP_SigVer(t *testing.T) {
+    vectors := readACVP(t, "ML-DSA-sigVer-FIPS204")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet string   `json:"parameterSet"`
+            Pk           hexBytes `json:"pk"`
+            Tests        []struct {
+                TcID      int      `json:"tcId"`
+                Message   hexBytes `json:"message"`
+                Signature hexBytes `json:"signature"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        pub, err := NewPublicKey(params, group.Pk)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        for _, tc := range group.Tests {
+            var result struct {
+                TestPassed bool `json:"testPassed"`
+            }
+            vectors.result(t, tc.TcID, &result)
+
+            mu := computeInternalMessageHash(pub.tr[:], tc.Message)
+            if pub.verifyInternal(&mu, tc.Signature) != result.TestPassed {
+                t.Fatalf("tc=%d: verify want %v", tc.TcID, result.TestPassed)
+            }
+        }
+    }
+}
+
+// NIST ACVP ML-DSA-sigGen-FIPS204, external interface, pure ML-DSA
+// with a context
+func Test_ACVP_SigGen_External(t *testing.T) {
+    vectors := readACVP(t, "ML-DSA-sigGen-FIPS204-external")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet  string `json:"parameterSet"`
+            Deterministic bool   `json:"deterministic"`
+            Tests         []struct {
+                TcID    int      `json:"tcId"`
+                Sk      hexBytes `json:"sk"`
+                Message hexBytes `json:"message"`
+                Context hexBytes `json:"context"`
+                Rnd     hexBytes `json:"rnd"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        for _, tc := range group.Tests {
+            var result struct {
+                Signature hexBytes `json:"signature"`
+            }
+            vectors.result(t, tc.TcID, &result)
+
+            priv, err := NewPrivateKey(params, tc.Sk)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            opts := &Options{
+                Context:       string(tc.Context),
+                Deterministic: group.Deterministic,
+            }
+
+            sig, err := priv.Sign(bytes.NewReader(tc.Rnd), tc.Message, opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !bytes.Equal(sig, result.Signature) {
+                t.Fatalf("tc=%d: signature got %x, want %x", tc.TcID, sig, result.Signature)
+            }
+
+            pub := priv.Public().(*PublicKey)
+            if err := VerifyWithOptions(pub, tc.Message, sig, opts); err != nil {
+                t.Fatalf("tc=%d: VerifyWithOptions fail: %v", tc.TcID, err)
+            }
+        }
+    }
+}
+
+// NIST ACVP ML-DSA-sigVer-FIPS204, external interface, pure ML-DSA
+// with a context
+func Test_ACVP_SigVer_External(t *testing.T) {
+    vectors := readACVP(t, "ML-DSA-sigVer-FIPS204-external")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet string `json:"parameterSet"`
+            Tests        []struct {
+                TcID      int      `json:"tcId"`
+                Pk        hexBytes `json:"pk"`
+                Message   hexBytes `json:"message"`
+                Context   hexBytes `json:"context"`
+                Signature hexBytes `json:"signature"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        for _, tc := range group.Tests {
+            var result struct {
+                TestPassed bool `json:"testPassed"`
+            }
+            vectors.result(t, tc.TcID, &result)
+
+            pub, err := NewPublicKey(params, tc.Pk)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            err = VerifyWithOptions(pub, tc.Message, tc.Signature, &Options{Context: string(tc.Context)})
+            if (err == nil) != result.TestPassed {
+                t.Fatalf("tc=%d: verify want %v", tc.TcID, result.TestPassed)
+            }
+        }
+    }
+}
+
+type wycheproofTest struct {
+    TcID   int      `json:"tcId"`
+    Msg    hexBytes `json:"msg"`
+    Ctx    hexBytes `json:"ctx"`
+    Sig    hexBytes `json:"sig"`
+    Result string   `json:"result"`
+}
+
+func readWycheproof(t *testing.T, name string, groups any) {
+    f, err := os.Open(filepath.Join("testdata", "wycheproof", name))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    file := struct {
+        TestGroups any `json:"testGroups"`
+    }{groups}
+    if err := json.NewDecoder(r).Decode(&file); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// Wycheproof ML-DSA deterministic signing from a seed, with and
+// without a context
+func Test_Wycheproof_Sign(t *testing.T) {
+    files := []struct {
+        params *Params
+        name   string
+    }{
+        {MLDSA44(), "mldsa_44_sign_seed_test.json.gz"},
+        {MLDSA65(), "mldsa_65_sign_seed_test.json.gz"},
+        {MLDSA87(), "mldsa_87_sign_seed_test.json.gz"},
+    }
+
+    for _, file := range files {
+        var groups []struct {
+            PrivateSeed hexBytes         `json:"privateSeed"`
+            Tests       []wycheproofTest `json:"tests"`
+        }
+        readWycheproof(t, file.name, &groups)
+
+        for _, group := range groups {
+            priv, err := NewKeyFromSeed(file.params, group.PrivateSeed)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            for _, tc := range group.Tests {
+                opts := &Options{
+                    Context:       string(tc.Ctx),
+                    Deterministic: true,
+                }
+
+                sig, err := priv.Sign(nil, tc.Msg, opts)
+                if tc.Result == "invalid" {
+                    if err == nil {
+                        t.Fatalf("%s tc=%d: Sign should fail", file.name, tc.TcID)
+                    }
+
+                    continue
+                }
+
+                if err != nil {
+                    t.Fatalf("%s tc=%d: %v", file.name, tc.TcID, err)
+                }
+
+                if !bytes.Equal(sig, tc.Sig) {
+                    t.Fatalf("%s tc=%d: signature got %x, want %x", file.name, tc.TcID, sig, tc.Sig)
+                }
+
+                // ML-DSA with an empty context, as the x509 and pkcs7
+                // signers use it
+                if len(tc.Ctx) == 0 {
+                    sig, err = priv.Sign(bytes.NewReader(make([]byte, 32)), tc.Msg, crypto.Hash(0))
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    if !bytes.Equal(sig, tc.Sig) {
+                        t.Fatalf("%s tc=%d: signature with a zero rnd got %x, want %x", file.name, tc.TcID, sig, tc.Sig)
+                    }
+                }
+            }
+        }
+    }
+}
+
+// Wycheproof ML-DSA verification
+func Test_Wycheproof_Verify(t *testing.T) {
+    var groups []struct {
+        PublicKey hexBytes         `json:"publicKey"`
+        Tests     []wycheproofTest `json:"tests"`
+    }
+    readWycheproof(t, "mldsa_44_verify_test.json.gz", &groups)
+
+    for _, group := range groups {
+        pub, err := NewPublicKey(MLDSA44(), group.PublicKey)
+
+        for _, tc := range group.Tests {
+            valid := tc.Result == "valid"
+
+            if err != nil {
+                if valid {
+                    t.Fatalf("tc=%d: NewPublicKey fail: %v", tc.TcID, err)
+                }
+
+                continue
+            }
+
+            verr := VerifyWithOptions(pub, tc.Msg, tc.Sig, &Options{Context: string(tc.Ctx)})
+            if (verr == nil) != valid {
+                t.Fatalf("tc=%d: verify want %v, got %v", tc.TcID, valid, verr)
+            }
+
+            if len(tc.Ctx) == 0 && Verify(pub, tc.Msg, tc.Sig) != valid {
+                t.Fatalf("tc=%d: Verify want %v", tc.TcID, valid)
+            }
+        }
+    }
+}
+
+// HashML-DSA against the message M' of FIPS 204 Algorithm 4, built
+// with the hash OIDs of NIST CSOR
+func Test_HashMLDSA(t *testing.T) {
+    hashes := []struct {
+        hash crypto.Hash
+        arc  int
+    }{
+        {crypto.SHA256, 1},
+        {crypto.SHA384, 2},
+        {crypto.SHA512, 3},
+        {crypto.SHA224, 4},
+        {crypto.SHA512_224, 5},
+        {crypto.SHA512_256, 6},
+        {crypto.SHA3_224, 7},
+        {crypto.SHA3_256, 8},
+        {crypto.SHA3_384, 9},
+        {crypto.SHA3_512, 10},
+    }
+
+    priv, err := NewKeyFromSeed(MLDSA44(), bytes.Repeat([]byte{0x2a}, 32))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    pub := priv.Public().(*PublicKey)
+
+    context := "test-context"
+
+    for _, h := range hashes {
+        digest := bytes.Repeat([]byte{0x5a}, h.hash.Size())
+
+        oid, err := asn1.Marshal(asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, h.arc})
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        // M' = 1 || len(ctx) || ctx || OID || PH(M)
+        m := append([]byte{1, byte(len(context))}, context...)
+        m = append(m, oid...)
+        m = append(m, digest...)
+
+        var rnd [32]byte
+        mu := computeInternalMessageHash(priv.tr[:], m)
+        want := priv.signInternal(&mu, &rnd)
+
+        opts := &Options{Hash: h.hash, Context: context, Deterministic: true}
+
+        sig, err := priv.Sign(nil, digest, opts)
+        if err != nil {
+            t.Fatalf("%s: %v", h.hash, err)
+        }
+
+        if !bytes.Equal(sig, want) {
+            t.Fatalf("%s: signature got %x, want %x", h.hash, sig, want)
+        }
+
+        if err := VerifyWithOptions(pub, digest, sig, opts); err != nil {
+            t.Fatalf("%s: VerifyWithOptions fail: %v", h.hash, err)
+        }
+    }
+}
+
+func Test_SignVerify(t *testing.T) {
+    msg := []byte("test-pass")
+    digest := sha256.Sum256(msg)
+
+    for _, params := range []*Params{MLDSA44(), MLDSA65(), MLDSA87()} {
+        priv, err := GenerateKey(rand.Reader, params)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        pub := priv.Public().(*PublicKey)
+
+        if len(pub.Bytes()) != params.PublicKeySize() {
+            t.Errorf("%s: public key size fail", params)
+        }
+
+        if len(priv.Bytes()) != params.PrivateKeySize() {
+            t.Errorf("%s: private key size fail", params)
+        }
+
+        // ML-DSA
+        sig, err := Sign(rand.Reader, priv, msg)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if len(sig) != params.SignatureSize() {
+            t.Errorf("%s: signature size fail", params)
+        }
+
+        if !Verify(pub, msg, sig) {
+            t.Errorf("%s: Verify fail", params)
+        }
+
+        if Verify(pub, []byte("test-pass2"), sig) {
+            t.Errorf("%s: Verify should fail with other message", params)
+        }
+
+        // ML-DSA with context
+        opts := &Options{Context: "test-context"}
+
+        sig, err = priv.Sign(rand.Reader, msg, opts)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if err := VerifyWithOptions(pub, msg, sig, opts); err != nil {
+            t.Errorf("%s: VerifyWithOptions fail: %v", params, err)
+        }
+
+        if Verify(pub, msg, sig) {
+            t.Errorf("%s: Verify should fail with other context", params)
+        }
+
+        // HashML-DSA
+        opts = &Options{Hash: crypto.SHA256, Context: "test-context"}
+
+        sig, err = priv.Sign(rand.Reader, digest[:], opts)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if err := VerifyWithOptions(pub, digest[:], sig, opts); err != nil {
+            t.Errorf("%s: HashML-DSA VerifyWithOptions fail: %v", params, err)
+        }
+
+        if err := VerifyWithOptions(pub, digest[:], sig, &Options{Context: "test-context"}); err == nil {
+            t.Errorf("%s: HashML-DSA signature should not verify as ML-DSA", params)
+        }
+
+        if _, err := priv.Sign(rand.Reader, msg, opts); err != ErrInvalidDigest {
+            t.Errorf("%s: HashML-DSA should check digest length", params)
+        }
+
+        // deterministic
+        opts = &Options{Deterministic: true}
+
+        sig1, _ := priv.Sign(nil, msg, opts)
+        sig2, _ := priv.Sign(nil, msg, opts)
+        if !bytes.Equal(sig1, sig2) {
+            t.Errorf("%s: deterministic signatures mismatch", params)
+        }
+
+        priv2, err := NewKeyFromSeed(params, priv.Seed())
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !priv.Equal(priv2) {
+            t.Errorf("%s: NewKeyFromSeed fail", params)
+        }
+    }
+}
+
+func Test_ContextTooLong(t *testing.T) {
+    priv, _ := GenerateKey(rand.Reader, MLDSA44())
+
+    opts := &Options{Context: string(make([]byte, ContextMaxSize+1))}
+    if _, err := priv.Sign(rand.Reader, []byte("test-pass"), opts); err != ErrContextTooLong {
+        t.Error("long context should be rejected")
+    }
+}
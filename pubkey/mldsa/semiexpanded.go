@@ -0,0 +1,240 @@
+// based on https://github.com/golang/go/blob/master/src/crypto/internal/fips140/mldsa/semiexpanded.go
+//
+// original copyright:
+// Copyright 2025 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mldsa
+
+import (
+    "math/bits"
+    "crypto/subtle"
+)
+
+// FIPS 204 defines a semi-expanded format for private keys,
+// ρ || K || tr || s₁ || s₂ || t₀. The seed is a better format for key
+// storage, but the semi-expanded format is used by the ACVP tests
+// and by the expandedKey choice of the PKCS#8 encoding.
+
+func semiExpandedPrivKeySize(p *Params) int {
+    k, l := p.k, p.l
+    etaBitlen := bits.Len(uint(p.eta)) + 1
+
+    // ρ + K + tr + l × n × η-bit coefficients of s₁ +
+    // k × n × η-bit coefficients of s₂ + k × n × 13-bit coefficients of t₀
+    return 32 + 32 + 64 + l*n*etaBitlen/8 + k*n*etaBitlen/8 + k*n*13/8
+}
+
+// NewPrivateKey parses a semi-expanded private key, implementing
+// skDecode, FIPS 204 Algorithm 25. Inconsistent keys are rejected.
+func NewPrivateKey(params *Params, sk []byte) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    p, k, l := params, params.k, params.l
+
+    rho, K, tr, s1, s2, t0, err := skDecode(sk, p)
+    if err != nil {
+        return nil, err
+    }
+
+    priv := &PrivateKey{}
+    priv.Params = params
+    priv.k = K
+
+    priv.a = computeMatrixA(rho[:], p)
+
+    priv.s1 = make([]nttElement, l)
+    for r := 0; r < l; r++ {
+        priv.s1[r] = ntt(s1[r])
+    }
+
+    priv.s2 = make([]nttElement, k)
+    for r := 0; r < k; r++ {
+        priv.s2[r] = ntt(s2[r])
+    }
+
+    priv.t0 = make([]nttElement, k)
+    for r := 0; r < k; r++ {
+        priv.t0[r] = ntt(t0[r])
+    }
+
+    // The public key is not stored, so recompute t₁ from ρ, s₁ and s₂,
+    // and check that t₀ and tr are consistent with it.
+    t1 := make([][n]uint16, k)
+    for i := 0; i < k; i++ {
+        tHat := priv.s2[i]
+        for j := 0; j < l; j++ {
+            tHat = polyAdd(tHat, nttMul(priv.a[i*l+j], priv.s1[j]))
+        }
+
+        t := inverseNTT(tHat)
+        for j := 0; j < n; j++ {
+            r1, r0 := power2Round(t[j])
+            t1[i][j] = r1
+            if r0 != t0[i][j] {
+                return nil, ErrInvalidPrivateKey
+            }
+        }
+    }
+
+    priv.raw = pkEncode(nil, rho[:], t1, p)
+    priv.tr = computePublicKeyHash(priv.raw)
+
+    if subtle.ConstantTimeCompare(priv.tr[:], tr[:]) != 1 {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    return priv, nil
+}
+
+// Bytes returns the semi-expanded private key, implementing
+// skEncode, FIPS 204 Algorithm 24.
+func (priv *PrivateKey) Bytes() []byte {
+    k, l, eta := priv.Params.k, priv.Params.l, priv.Params.eta
+
+    sk := make([]byte, 0, semiExpandedPrivKeySize(priv.Params))
+    sk = append(sk, priv.raw[:32]...) // ρ
+    sk = append(sk, priv.k[:]...)     // K
+    sk = append(sk, priv.tr[:]...)    // tr
+
+    for i := 0; i < l; i++ {
+        sk = bitPackSlow(sk, inverseNTT(priv.s1[i]), eta, eta)
+    }
+
+    for i := 0; i < k; i++ {
+        sk = bitPackSlow(sk, inverseNTT(priv.s2[i]), eta, eta)
+    }
+
+    const bound = 1 << (13 - 1) // 2^(d-1)
+    for i := 0; i < k; i++ {
+        sk = bitPackSlow(sk, inverseNTT(priv.t0[i]), bound-1, bound)
+    }
+
+    return sk
+}
+
+func skDecode(sk []byte, p *Params) (rho, K [32]byte, tr [64]byte, s1, s2, t0 []ringElement, err error) {
+    k, l, eta := p.k, p.l, p.eta
+
+    if len(sk) != semiExpandedPrivKeySize(p) {
+        err = ErrInvalidPrivateKey
+        return
+    }
+
+    copy(rho[:], sk[:32])
+    sk = sk[32:]
+    copy(K[:], sk[:32])
+    sk = sk[32:]
+    copy(tr[:], sk[:64])
+    sk = sk[64:]
+
+    length := n * bits.Len(uint(eta)*2) / 8
+
+    s1 = make([]ringElement, l)
+    for i := 0; i < l; i++ {
+        s1[i], err = bitUnpackSlow(sk[:length], eta, eta)
+        if err != nil {
+            return
+        }
+
+        sk = sk[length:]
+    }
+
+    s2 = make([]ringElement, k)
+    for i := 0; i < k; i++ {
+        s2[i], err = bitUnpackSlow(sk[:length], eta, eta)
+        if err != nil {
+            return
+        }
+
+        sk = sk[length:]
+    }
+
+    const bound = 1 << (13 - 1) // 2^(d-1)
+
+    t0 = make([]ringElement, k)
+    for i := 0; i < k; i++ {
+        length := n * 13 / 8
+        t0[i], err = bitUnpackSlow(sk[:length], bound-1, bound)
+        if err != nil {
+            return
+        }
+
+        sk = sk[length:]
+    }
+
+    return
+}
+
+// bitPackSlow implements BitPack(r, a, b), FIPS 204 Algorithm 17.
+func bitPackSlow(buf []byte, r ringElement, a, b int) []byte {
+    bitlen := bits.Len(uint(a + b))
+    if bitlen <= 0 || bitlen > 16 {
+        panic("go-cryptobin/mldsa: internal error: invalid bitlen")
+    }
+
+    out, v := sliceForAppend(buf, n*bitlen/8)
+
+    var acc uint32
+    var accBits uint
+    for i := range r {
+        w := int32(b) - fieldCenteredMod(r[i])
+        acc |= uint32(w) << accBits
+        accBits += uint(bitlen)
+        for accBits >= 8 {
+            v[0] = byte(acc)
+            v = v[1:]
+            acc >>= 8
+            accBits -= 8
+        }
+    }
+
+    if accBits > 0 {
+        v[0] = byte(acc)
+    }
+
+    return out
+}
+
+// bitUnpackSlow implements BitUnpack(v, a, b), FIPS 204 Algorithm 19,
+// rejecting coefficients out of range.
+func bitUnpackSlow(v []byte, a, b int) (ringElement, error) {
+    bitlen := bits.Len(uint(a + b))
+    if bitlen <= 0 || bitlen > 16 {
+        panic("go-cryptobin/mldsa: internal error: invalid bitlen")
+    }
+
+    if len(v) != n*bitlen/8 {
+        return ringElement{}, ErrInvalidPrivateKey
+    }
+
+    mask := uint32((1 << bitlen) - 1)
+    maxValue := uint32(a + b)
+
+    var r ringElement
+    var acc uint32
+    var accBits uint
+
+    vIdx := 0
+    for i := range r {
+        for accBits < uint(bitlen) {
+            acc |= uint32(v[vIdx]) << accBits
+            vIdx++
+            accBits += 8
+        }
+
+        w := acc & mask
+        if w > maxValue {
+            return ringElement{}, ErrInvalidPrivateKey
+        }
+
+        r[i] = fieldSubToMontgomery(uint32(b), w)
+        acc >>= bitlen
+        accBits -= uint(bitlen)
+    }
+
+    return r, nil
+}
@@ -0,0 +1,427 @@
+// based on https://github.com/golang/go/blob/master/src/crypto/internal/fips140/mlkem/field.go
+//
+// original copyright:
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mlkem
+
+import (
+    "errors"
+    "encoding/binary"
+
+    "golang.org/x/crypto/sha3"
+)
+
+const (
+    n = 256
+    q = 3329
+
+    encodingSize1  = n / 8
+    encodingSize12 = n * 12 / 8
+)
+
+// fieldElement is an integer modulo q, an element of ℤ_q. It is always reduced.
+type fieldElement uint16
+
+// fieldCheckReduced checks that a value a is < q.
+func fieldCheckReduced(a uint16) (fieldElement, error) {
+    if a >= q {
+        return 0, errors.New("go-cryptobin/mlkem: unreduced field element")
+    }
+
+    return fieldElement(a), nil
+}
+
+// fieldReduceOnce reduces a value a < 2q.
+func fieldReduceOnce(a uint16) fieldElement {
+    x := a - q
+    // If x underflowed, then x >= 2¹⁶ - q > 2¹⁵, so the top bit is set.
+    x += (x >> 15) * q
+    return fieldElement(x)
+}
+
+func fieldAdd(a, b fieldElement) fieldElement {
+    x := uint16(a + b)
+    return fieldReduceOnce(x)
+}
+
+func fieldSub(a, b fieldElement) fieldElement {
+    x := uint16(a - b + q)
+    return fieldReduceOnce(x)
+}
+
+const (
+    barrettMultiplier = 5039 // 2¹² * 2¹² / q
+    barrettShift      = 24   // log₂(2¹² * 2¹²)
+)
+
+// fieldReduce reduces a value a < 2q² using Barrett reduction, to avoid
+// potentially variable-time division.
+func fieldReduce(a uint32) fieldElement {
+    quotient := uint32((uint64(a) * barrettMultiplier) >> barrettShift)
+    return fieldReduceOnce(uint16(a - quotient*q))
+}
+
+func fieldMul(a, b fieldElement) fieldElement {
+    x := uint32(a) * uint32(b)
+    return fieldReduce(x)
+}
+
+// fieldMulSub returns a * (b - c).
+func fieldMulSub(a, b, c fieldElement) fieldElement {
+    x := uint32(a) * uint32(b-c+q)
+    return fieldReduce(x)
+}
+
+// fieldAddMul returns a * b + c * d.
+func fieldAddMul(a, b, c, d fieldElement) fieldElement {
+    x := uint32(a) * uint32(b)
+    x += uint32(c) * uint32(d)
+    return fieldReduce(x)
+}
+
+// compress maps a field element uniformly to the range 0 to 2ᵈ-1,
+// according to FIPS 203, Definition 4.7.
+func compress(x fieldElement, d uint8) uint16 {
+    // Barrett reduction produces a quotient and a remainder in the
+    // range [0, 2q), such that dividend = quotient * q + remainder.
+    dividend := uint32(x) << d
+    quotient := uint32(uint64(dividend) * barrettMultiplier >> barrettShift)
+    remainder := dividend - quotient*q
+
+    // round to nearest, add 1 if remainder > q/2,
+    // then add 1 again if remainder > q + q/2.
+    quotient += (q/2 - remainder) >> 31 & 1
+    quotient += (q + q/2 - remainder) >> 31 & 1
+
+    var mask uint32 = (1 << d) - 1
+    return uint16(quotient & mask)
+}
+
+// decompress maps a number y between 0 and 2ᵈ-1 uniformly to the full
+// range of field elements, according to FIPS 203, Definition 4.8.
+func decompress(y uint16, d uint8) fieldElement {
+    dividend := uint32(y) * q
+    quotient := dividend >> d
+
+    // The d'th least-significant bit of the dividend is 1 for the
+    // top half of the values that divide to the same quotient.
+    quotient += dividend >> (d - 1) & 1
+
+    return fieldElement(quotient)
+}
+
+// ringElement is a polynomial, an element of R_q.
+type ringElement [n]fieldElement
+
+// nttElement is an NTT representation, an element of T_q.
+type nttElement [n]fieldElement
+
+// polyAdd adds two ringElements or nttElements.
+func polyAdd[T ~[n]fieldElement](a, b T) (s T) {
+    for i := range s {
+        s[i] = fieldAdd(a[i], b[i])
+    }
+
+    return s
+}
+
+// polySub subtracts two ringElements or nttElements.
+func polySub[T ~[n]fieldElement](a, b T) (s T) {
+    for i := range s {
+        s[i] = fieldSub(a[i], b[i])
+    }
+
+    return s
+}
+
+// polyByteEncode appends the 384 bytes encoding of f to b.
+// It implements ByteEncode₁₂, according to FIPS 203, Algorithm 5.
+func polyByteEncode[T ~[n]fieldElement](b []byte, f T) []byte {
+    out, B := sliceForAppend(b, encodingSize12)
+    for i := 0; i < n; i += 2 {
+        x := uint32(f[i]) | uint32(f[i+1])<<12
+        B[0] = uint8(x)
+        B[1] = uint8(x >> 8)
+        B[2] = uint8(x >> 16)
+        B = B[3:]
+    }
+
+    return out
+}
+
+// polyByteDecode decodes the 384 bytes encoding of a polynomial,
+// checking that all the coefficients are properly reduced.
+// It implements ByteDecode₁₂, according to FIPS 203, Algorithm 6.
+func polyByteDecode[T ~[n]fieldElement](b []byte) (T, error) {
+    if len(b) != encodingSize12 {
+        return T{}, errors.New("go-cryptobin/mlkem: invalid encoding length")
+    }
+
+    var f T
+    for i := 0; i < n; i += 2 {
+        d := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+
+        var err error
+        if f[i], err = fieldCheckReduced(uint16(d & 0xfff)); err != nil {
+            return T{}, errors.New("go-cryptobin/mlkem: invalid polynomial encoding")
+        }
+        if f[i+1], err = fieldCheckReduced(uint16(d >> 12)); err != nil {
+            return T{}, errors.New("go-cryptobin/mlkem: invalid polynomial encoding")
+        }
+
+        b = b[3:]
+    }
+
+    return f, nil
+}
+
+// sliceForAppend returns a slice with the contents of in followed by
+// n bytes, and a second slice that aliases the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+    if total := len(in) + n; cap(in) >= total {
+        head = in[:total]
+    } else {
+        head = make([]byte, total)
+        copy(head, in)
+    }
+
+    tail = head[len(in):]
+    return
+}
+
+func minUint8(a, b uint8) uint8 {
+    if a < b {
+        return a
+    }
+
+    return b
+}
+
+// ringCompressAndEncode appends an encoding of a ring element to s,
+// compressing each coefficient to d bits.
+// It implements Compress followed by ByteEncode.
+func ringCompressAndEncode(s []byte, f ringElement, d uint8) []byte {
+    var b byte
+    var bIdx uint8
+    for i := 0; i < n; i++ {
+        c := compress(f[i], d)
+
+        var cIdx uint8
+        for cIdx < d {
+            b |= byte(c>>cIdx) << bIdx
+            bits := minUint8(8-bIdx, d-cIdx)
+            bIdx += bits
+            cIdx += bits
+            if bIdx == 8 {
+                s = append(s, b)
+                b = 0
+                bIdx = 0
+            }
+        }
+    }
+
+    return s
+}
+
+// ringDecodeAndDecompress decodes an encoding of a ring element where
+// each d bits are mapped to an equidistant distribution.
+// It implements ByteDecode followed by Decompress.
+func ringDecodeAndDecompress(b []byte, d uint8) ringElement {
+    var f ringElement
+    var bIdx uint8
+    for i := 0; i < n; i++ {
+        var c uint16
+        var cIdx uint8
+        for cIdx < d {
+            c |= uint16(b[0]>>bIdx) << cIdx
+            c &= (1 << d) - 1
+            bits := minUint8(8-bIdx, d-cIdx)
+            bIdx += bits
+            cIdx += bits
+            if bIdx == 8 {
+                b = b[1:]
+                bIdx = 0
+            }
+        }
+
+        f[i] = decompress(c, d)
+    }
+
+    return f
+}
+
+// ringCompressAndEncode1 appends a 32 bytes encoding of a ring element
+// to s, compressing one coefficient per bit.
+func ringCompressAndEncode1(s []byte, f ringElement) []byte {
+    s, b := sliceForAppend(s, encodingSize1)
+    for i := range b {
+        b[i] = 0
+    }
+
+    for i := range f {
+        b[i/8] |= uint8(compress(f[i], 1) << (i % 8))
+    }
+
+    return s
+}
+
+// ringDecodeAndDecompress1 decodes a 32 bytes slice to a ring element
+// where each bit is mapped to 0 or ⌈q/2⌋.
+func ringDecodeAndDecompress1(b []byte) ringElement {
+    const halfQ = (q + 1) / 2
+
+    var f ringElement
+    for i := range f {
+        bi := b[i/8] >> (i % 8) & 1
+        f[i] = fieldElement(bi) * halfQ
+    }
+
+    return f
+}
+
+// samplePolyCBD draws a ringElement from the special Dη distribution
+// given a stream of random bytes generated by the PRF function,
+// according to FIPS 203, Algorithm 8 and Definition 4.3.
+func samplePolyCBD(s []byte, b byte, eta int) ringElement {
+    prf := sha3.NewShake256()
+    prf.Write(s)
+    prf.Write([]byte{b})
+
+    B := make([]byte, 64*eta)
+    prf.Read(B)
+
+    // SamplePolyCBD draws 2η bits for each coefficient, and adds
+    // the first η bits and subtracts the last η bits.
+    var f ringElement
+    var bit int
+    for i := 0; i < n; i++ {
+        var x, y fieldElement
+        for j := 0; j < eta; j++ {
+            x += fieldElement(B[bit/8] >> (bit % 8) & 1)
+            bit++
+        }
+        for j := 0; j < eta; j++ {
+            y += fieldElement(B[bit/8] >> (bit % 8) & 1)
+            bit++
+        }
+
+        f[i] = fieldSub(x, y)
+    }
+
+    return f
+}
+
+// gammas are the values ζ^2BitRev7(i)+1 mod q for each index i,
+// according to FIPS 203, Appendix A.
+var gammas = [128]fieldElement{17, 3312, 2761, 568, 583, 2746, 2649, 680, 1637, 1692, 723, 2606, 2288, 1041, 1100, 2229, 1409, 1920, 2662, 667, 3281, 48, 233, 3096, 756, 2573, 2156, 1173, 3015, 314, 3050, 279, 1703, 1626, 1651, 1678, 2789, 540, 1789, 1540, 1847, 1482, 952, 2377, 1461, 1868, 2687, 642, 939, 2390, 2308, 1021, 2437, 892, 2388, 941, 733, 2596, 2337, 992, 268, 3061, 641, 2688, 1584, 1745, 2298, 1031, 2037, 1292, 3220, 109, 375, 2954, 2549, 780, 2090, 1239, 1645, 1684, 1063, 2266, 319, 3010, 2773, 556, 757, 2572, 2099, 1230, 561, 2768, 2466, 863, 2594, 735, 2804, 525, 1092, 2237, 403, 2926, 1026, 2303, 1143, 2186, 2150, 1179, 2775, 554, 886, 2443, 1722, 1607, 1212, 2117, 1874, 1455, 1029, 2300, 2110, 1219, 2935, 394, 885, 2444, 2154, 1175}
+
+// nttMul multiplies two nttElements.
+// It implements MultiplyNTTs, according to FIPS 203, Algorithm 11.
+func nttMul(f, g nttElement) nttElement {
+    var h nttElement
+    for i := 0; i < 256; i += 2 {
+        a0, a1 := f[i], f[i+1]
+        b0, b1 := g[i], g[i+1]
+        h[i] = fieldAddMul(a0, b0, fieldMul(a1, b1), gammas[i/2])
+        h[i+1] = fieldAddMul(a0, b1, a1, b0)
+    }
+
+    return h
+}
+
+// zetas are the values ζ^BitRev7(k) mod q for each index k,
+// according to FIPS 203, Appendix A.
+var zetas = [128]fieldElement{1, 1729, 2580, 3289, 2642, 630, 1897, 848, 1062, 1919, 193, 797, 2786, 3260, 569, 1746, 296, 2447, 1339, 1476, 3046, 56, 2240, 1333, 1426, 2094, 535, 2882, 2393, 2879, 1974, 821, 289, 331, 3253, 1756, 1197, 2304, 2277, 2055, 650, 1977, 2513, 632, 2865, 33, 1320, 1915, 2319, 1435, 807, 452, 1438, 2868, 1534, 2402, 2647, 2617, 1481, 648, 2474, 3110, 1227, 910, 17, 2761, 583, 2649, 1637, 723, 2288, 1100, 1409, 2662, 3281, 233, 756, 2156, 3015, 3050, 1703, 1651, 2789, 1789, 1847, 952, 1461, 2687, 939, 2308, 2437, 2388, 733, 2337, 268, 641, 1584, 2298, 2037, 3220, 375, 2549, 2090, 1645, 1063, 319, 2773, 757, 2099, 561, 2466, 2594, 2804, 1092, 403, 1026, 1143, 2150, 2775, 886, 1722, 1212, 1874, 1029, 2110, 2935, 885, 2154}
+
+// ntt maps a ringElement to its nttElement representation.
+// It implements NTT, according to FIPS 203, Algorithm 9.
+func ntt(f ringElement) nttElement {
+    k := 1
+    for l := 128; l >= 2; l /= 2 {
+        for start := 0; start < 256; start += 2 * l {
+            zeta := zetas[k]
+            k++
+
+            lo, hi := f[start:start+l], f[start+l:start+l+l]
+            for j := 0; j < l; j++ {
+                t := fieldMul(zeta, hi[j])
+                hi[j] = fieldSub(lo[j], t)
+                lo[j] = fieldAdd(lo[j], t)
+            }
+        }
+    }
+
+    return nttElement(f)
+}
+
+// inverseNTT maps a nttElement back to the ringElement it represents.
+// It implements NTT⁻¹, according to FIPS 203, Algorithm 10.
+func inverseNTT(f nttElement) ringElement {
+    k := 127
+    for l := 2; l <= 128; l *= 2 {
+        for start := 0; start < 256; start += 2 * l {
+            zeta := zetas[k]
+            k--
+
+            lo, hi := f[start:start+l], f[start+l:start+l+l]
+            for j := 0; j < l; j++ {
+                t := lo[j]
+                lo[j] = fieldAdd(t, hi[j])
+                hi[j] = fieldMulSub(zeta, hi[j], t)
+            }
+        }
+    }
+
+    for i := range f {
+        f[i] = fieldMul(f[i], 3303) // 3303 = 128⁻¹ mod q
+    }
+
+    return ringElement(f)
+}
+
+// sampleNTT draws a uniformly random nttElement from a stream of
+// uniformly random bytes generated by the XOF function, according
+// to FIPS 203, Algorithm 7.
+func sampleNTT(rho []byte, ii, jj byte) nttElement {
+    B := sha3.NewShake128()
+    B.Write(rho)
+    B.Write([]byte{ii, jj})
+
+    // draws 12 bits at a time and rejects values higher than q,
+    // three bytes give two candidates.
+    var a nttElement
+    var j int
+    var buf [168]byte
+    off := len(buf)
+    for {
+        if off >= len(buf) {
+            B.Read(buf[:])
+            off = 0
+        }
+
+        d1 := binary.LittleEndian.Uint16(buf[off:]) & 0xfff
+        d2 := binary.LittleEndian.Uint16(buf[off+1:]) >> 4
+        off += 3
+
+        if d1 < q {
+            a[j] = fieldElement(d1)
+            j++
+        }
+        if j >= len(a) {
+            break
+        }
+
+        if d2 < q {
+            a[j] = fieldElement(d2)
+            j++
+        }
+        if j >= len(a) {
+            break
+        }
+    }
+
+    return a
+}
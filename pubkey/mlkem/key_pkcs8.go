@@ -0,0 +1,207 @@
+package mlkem
+
+import (
+    "fmt"
+    "errors"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+)
+
+var (
+    // NIST OIDs, FIPS 203
+    oidMLKEM512  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 1}
+    oidMLKEM768  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 2}
+    oidMLKEM1024 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 3}
+)
+
+// 私钥 - 包装
+type pkcs8 struct {
+    Version    int
+    Algo       pkix.AlgorithmIdentifier
+    PrivateKey []byte
+    Attributes []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// 公钥 - 包装
+type pkixPublicKey struct {
+    Algo      pkix.AlgorithmIdentifier
+    BitString asn1.BitString
+}
+
+// 公钥信息 - 解析
+type publicKeyInfo struct {
+    Raw       asn1.RawContent
+    Algorithm pkix.AlgorithmIdentifier
+    PublicKey asn1.BitString
+}
+
+// 私钥 both 格式
+type privateKeyBoth struct {
+    Seed        []byte
+    ExpandedKey []byte
+}
+
+// OID 获取参数
+func ParamsFromOID(oid asn1.ObjectIdentifier) (*Params, error) {
+    switch {
+        case oid.Equal(oidMLKEM512):
+            return params512, nil
+        case oid.Equal(oidMLKEM768):
+            return params768, nil
+        case oid.Equal(oidMLKEM1024):
+            return params1024, nil
+    }
+
+    return nil, errors.New("go-cryptobin/mlkem: unknown public key algorithm")
+}
+
+// 参数获取 OID
+func OIDFromParams(params *Params) (asn1.ObjectIdentifier, error) {
+    switch params {
+        case params512:
+            return oidMLKEM512, nil
+        case params768:
+            return oidMLKEM768, nil
+        case params1024:
+            return oidMLKEM1024, nil
+    }
+
+    return nil, ErrInvalidParams
+}
+
+// 包装公钥
+func MarshalPublicKey(key *PublicKey) ([]byte, error) {
+    oid, err := OIDFromParams(key.Params)
+    if err != nil {
+        return nil, err
+    }
+
+    publicKeyBytes := key.Bytes()
+
+    pkix := pkixPublicKey{
+        Algo: pkix.AlgorithmIdentifier{
+            Algorithm: oid,
+        },
+        BitString: asn1.BitString{
+            Bytes:     publicKeyBytes,
+            BitLength: 8 * len(publicKeyBytes),
+        },
+    }
+
+    return asn1.Marshal(pkix)
+}
+
+// 解析公钥
+func ParsePublicKey(derBytes []byte) (*PublicKey, error) {
+    var pki publicKeyInfo
+    rest, err := asn1.Unmarshal(derBytes, &pki)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    params, err := ParamsFromOID(pki.Algorithm.Algorithm)
+    if err != nil {
+        return nil, err
+    }
+
+    // the parameters field must be absent
+    if len(pki.Algorithm.Parameters.FullBytes) != 0 {
+        return nil, errors.New("go-cryptobin/mlkem: invalid public key algorithm parameters")
+    }
+
+    return NewPublicKey(params, pki.PublicKey.RightAlign())
+}
+
+// ====================
+
+// 包装私钥, 有种子时只保存种子, 否则保存扩展私钥
+func MarshalPrivateKey(key *PrivateKey) ([]byte, error) {
+    oid, err := OIDFromParams(key.Params)
+    if err != nil {
+        return nil, err
+    }
+
+    var privKey pkcs8
+    privKey.Algo = pkix.AlgorithmIdentifier{
+        Algorithm: oid,
+    }
+
+    var keyBytes []byte
+    if seed := key.Seed(); seed != nil {
+        keyBytes, err = asn1.Marshal(asn1.RawValue{
+            Class: asn1.ClassContextSpecific,
+            Tag:   0,
+            Bytes: seed,
+        })
+    } else {
+        keyBytes, err = asn1.Marshal(key.Bytes())
+    }
+
+    if err != nil {
+        return nil, fmt.Errorf("go-cryptobin/mlkem: failed to marshal private key: %v", err)
+    }
+
+    privKey.PrivateKey = keyBytes
+
+    return asn1.Marshal(privKey)
+}
+
+// 解析私钥, 支持 seed, expandedKey 和 both 三种格式
+func ParsePrivateKey(derBytes []byte) (*PrivateKey, error) {
+    var privKey pkcs8
+    _, err := asn1.Unmarshal(derBytes, &privKey)
+    if err != nil {
+        return nil, err
+    }
+
+    params, err := ParamsFromOID(privKey.Algo.Algorithm)
+    if err != nil {
+        return nil, errors.New("go-cryptobin/mlkem: unknown private key algorithm")
+    }
+
+    var raw asn1.RawValue
+    rest, err := asn1.Unmarshal(privKey.PrivateKey, &raw)
+    if err != nil {
+        return nil, fmt.Errorf("go-cryptobin/mlkem: invalid private key: %v", err)
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    switch {
+        case raw.Class == asn1.ClassContextSpecific && raw.Tag == 0 && !raw.IsCompound:
+            return NewKeyFromSeed(params, raw.Bytes)
+
+        case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagOctetString:
+            return NewPrivateKey(params, raw.Bytes)
+
+        case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagSequence:
+            var both privateKeyBoth
+            if _, err := asn1.Unmarshal(raw.FullBytes, &both); err != nil {
+                return nil, fmt.Errorf("go-cryptobin/mlkem: invalid private key: %v", err)
+            }
+
+            priv, err := NewKeyFromSeed(params, both.Seed)
+            if err != nil {
+                return nil, err
+            }
+
+            expanded, err := NewPrivateKey(params, both.ExpandedKey)
+            if err != nil {
+                return nil, err
+            }
+
+            if !priv.Equal(expanded) {
+                return nil, errors.New("go-cryptobin/mlkem: seed and expanded key mismatch")
+            }
+
+            return priv, nil
+    }
+
+    return nil, ErrInvalidPrivateKey
+}
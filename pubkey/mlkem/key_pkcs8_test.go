@@ -0,0 +1,131 @@
+package mlkem
+
+import (
+    "bytes"
+    "testing"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/pem"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+
+    cryptobin_pkcs8 "github.com/deatil/go-cryptobin/pkcs8"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func Test_Marshal(t *testing.T) {
+    for _, params := range []*Params{MLKEM512(), MLKEM768(), MLKEM1024()} {
+        t.Run(params.Name, func(t *testing.T) {
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+            assertEqual := cryptobin_test.AssertEqualT(t)
+
+            priv, err := GenerateKey(rand.Reader, params)
+            assertError(err, "GenerateKey")
+
+            pubkey, err := MarshalPublicKey(&priv.PublicKey)
+            assertError(err, "MarshalPublicKey")
+
+            parsedPub, err := ParsePublicKey(pubkey)
+            assertError(err, "ParsePublicKey")
+
+            prikey, err := MarshalPrivateKey(priv)
+            assertError(err, "MarshalPrivateKey")
+
+            parsedPri, err := ParsePrivateKey(prikey)
+            assertError(err, "ParsePrivateKey")
+
+            assertBool(priv.PublicKey.Equal(parsedPub), "Equal")
+            assertBool(priv.Equal(parsedPri), "Equal")
+            assertEqual(parsedPri.Seed(), priv.Seed(), "Seed")
+        })
+    }
+}
+
+func Test_MarshalPrivateKey_Seed(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    seed := make([]byte, SeedSize)
+    for i := range seed {
+        seed[i] = byte(i)
+    }
+
+    priv, err := NewKeyFromSeed(MLKEM512(), seed)
+    assertError(err, "NewKeyFromSeed")
+
+    der, err := MarshalPrivateKey(priv)
+    assertError(err, "MarshalPrivateKey")
+
+    // PrivateKeyInfo with the seed [0] choice
+    prefix, _ := hex.DecodeString("3054020100300b0609608648016503040401044280400001")
+    if !bytes.HasPrefix(der, prefix) {
+        t.Errorf("MarshalPrivateKey got %x", der)
+    }
+}
+
+func Test_ParsePrivateKey_Expanded(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    params := MLKEM1024()
+
+    priv, err := GenerateKey(rand.Reader, params)
+    assertError(err, "GenerateKey")
+
+    // expandedKey choice
+    expanded, err := NewPrivateKey(params, priv.Bytes())
+    assertError(err, "NewPrivateKey")
+
+    der, err := MarshalPrivateKey(expanded)
+    assertError(err, "MarshalPrivateKey")
+
+    parsed, err := ParsePrivateKey(der)
+    assertError(err, "ParsePrivateKey")
+
+    assertBool(priv.Equal(parsed), "Equal")
+    assertBool(parsed.Seed() == nil, "Equal")
+
+    // both choice
+    bothBytes, err := asn1.Marshal(privateKeyBoth{
+        Seed:        priv.Seed(),
+        ExpandedKey: priv.Bytes(),
+    })
+    assertError(err, "Marshal")
+
+    der, err = asn1.Marshal(pkcs8{
+        Algo:       pkix.AlgorithmIdentifier{Algorithm: oidMLKEM1024},
+        PrivateKey: bothBytes,
+    })
+    assertError(err, "Marshal")
+
+    parsed, err = ParsePrivateKey(der)
+    assertError(err, "ParsePrivateKey")
+
+    assertBool(priv.Equal(parsed), "Equal")
+}
+
+func Test_EncryptPKCS8(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    priv, err := GenerateKey(rand.Reader, MLKEM768())
+    assertError(err, "GenerateKey")
+
+    der, err := MarshalPrivateKey(priv)
+    assertError(err, "MarshalPrivateKey")
+
+    password := []byte("test-pass")
+
+    block, err := cryptobin_pkcs8.EncryptPEMBlock(rand.Reader, "ENCRYPTED PRIVATE KEY", der, password, cryptobin_pkcs8.DefaultOpts)
+    assertError(err, "EncryptPEMBlock")
+
+    block, _ = pem.Decode(pem.EncodeToMemory(block))
+
+    decrypted, err := cryptobin_pkcs8.DecryptPEMBlock(block, password)
+    assertError(err, "DecryptPEMBlock")
+
+    parsed, err := ParsePrivateKey(decrypted)
+    assertError(err, "ParsePrivateKey")
+
+    assertBool(priv.Equal(parsed), "Equal")
+}
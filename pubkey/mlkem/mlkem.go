@@ -0,0 +1,481 @@
+// based on https://github.com/golang/go/blob/master/src/crypto/internal/fips140/mlkem/mlkem768.go
+//
+// original copyright:
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mlkem
+
+import (
+    "io"
+    "errors"
+    "crypto"
+    "crypto/subtle"
+
+    "golang.org/x/crypto/sha3"
+)
+
+const (
+    // SharedKeySize is the size of a shared key produced by ML-KEM.
+    SharedKeySize = 32
+
+    // SeedSize is the size of a seed used to generate a decapsulation key.
+    SeedSize = 64
+)
+
+var (
+    ErrInvalidParams     = errors.New("go-cryptobin/mlkem: invalid params")
+    ErrInvalidSeed       = errors.New("go-cryptobin/mlkem: invalid seed length")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/mlkem: invalid public key")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/mlkem: invalid private key")
+    ErrInvalidCiphertext = errors.New("go-cryptobin/mlkem: invalid ciphertext length")
+)
+
+// Params is a ML-KEM parameter set, FIPS 203 section 8.
+type Params struct {
+    Name string
+
+    k    int
+    eta1 int
+    du   uint8
+    dv   uint8
+}
+
+const eta2 = 2
+
+// String returns the name of the parameter set.
+func (p *Params) String() string {
+    return p.Name
+}
+
+// PublicKeySize returns the size of an encapsulation key.
+func (p *Params) PublicKeySize() int {
+    return encodingSize12*p.k + 32
+}
+
+// PrivateKeySize returns the size of an expanded decapsulation key.
+func (p *Params) PrivateKeySize() int {
+    return 2*encodingSize12*p.k + 96
+}
+
+// CiphertextSize returns the size of a ciphertext.
+func (p *Params) CiphertextSize() int {
+    return 32 * (int(p.du)*p.k + int(p.dv))
+}
+
+var (
+    params512  = &Params{Name: "ML-KEM-512", k: 2, eta1: 3, du: 10, dv: 4}
+    params768  = &Params{Name: "ML-KEM-768", k: 3, eta1: 2, du: 10, dv: 4}
+    params1024 = &Params{Name: "ML-KEM-1024", k: 4, eta1: 2, du: 11, dv: 5}
+)
+
+// MLKEM512 returns the ML-KEM-512 parameter set.
+func MLKEM512() *Params {
+    return params512
+}
+
+// MLKEM768 returns the ML-KEM-768 parameter set.
+func MLKEM768() *Params {
+    return params768
+}
+
+// MLKEM1024 returns the ML-KEM-1024 parameter set.
+func MLKEM1024() *Params {
+    return params1024
+}
+
+// GetParams returns the parameter set with the name.
+func GetParams(name string) (*Params, error) {
+    switch name {
+        case params512.Name:
+            return params512, nil
+        case params768.Name:
+            return params768, nil
+        case params1024.Name:
+            return params1024, nil
+    }
+
+    return nil, ErrInvalidParams
+}
+
+// PublicKey is the encapsulation key of ML-KEM.
+type PublicKey struct {
+    Params *Params
+
+    rho [32]byte
+    h   [32]byte
+    t   []nttElement
+    a   []nttElement // k*k matrix in row major order
+}
+
+// NewPublicKey parses an encoded encapsulation key.
+func NewPublicKey(params *Params, b []byte) (*PublicKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    pub := &PublicKey{}
+    if err := parsePublicKey(pub, params, b); err != nil {
+        return nil, err
+    }
+
+    return pub, nil
+}
+
+func parsePublicKey(pub *PublicKey, params *Params, b []byte) error {
+    if len(b) != params.PublicKeySize() {
+        return ErrInvalidPublicKey
+    }
+
+    k := params.k
+
+    pub.Params = params
+    pub.h = sha3.Sum256(b)
+    pub.t = make([]nttElement, k)
+
+    for i := range pub.t {
+        var err error
+        pub.t[i], err = polyByteDecode[nttElement](b[:encodingSize12])
+        if err != nil {
+            return ErrInvalidPublicKey
+        }
+
+        b = b[encodingSize12:]
+    }
+
+    copy(pub.rho[:], b)
+    pub.expandA()
+
+    return nil
+}
+
+// expandA samples the matrix A from rho.
+func (pub *PublicKey) expandA() {
+    k := pub.Params.k
+
+    pub.a = make([]nttElement, k*k)
+    for i := 0; i < k; i++ {
+        for j := 0; j < k; j++ {
+            pub.a[i*k+j] = sampleNTT(pub.rho[:], byte(j), byte(i))
+        }
+    }
+}
+
+// Bytes returns the encoded encapsulation key.
+func (pub *PublicKey) Bytes() []byte {
+    b := make([]byte, 0, pub.Params.PublicKeySize())
+    for i := range pub.t {
+        b = polyByteEncode(b, pub.t[i])
+    }
+
+    return append(b, pub.rho[:]...)
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok {
+        return false
+    }
+
+    return pub.Params == xx.Params &&
+        subtle.ConstantTimeCompare(pub.Bytes(), xx.Bytes()) == 1
+}
+
+// Encapsulate generates a shared key and an associated ciphertext
+// from the encapsulation key, drawing random bytes from rand.
+func (pub *PublicKey) Encapsulate(rand io.Reader) (sharedKey, ciphertext []byte, err error) {
+    var m [32]byte
+    if _, err = io.ReadFull(rand, m[:]); err != nil {
+        return nil, nil, err
+    }
+
+    sharedKey, ciphertext = pub.encapsulate(m[:])
+    return
+}
+
+// EncapsulateDeterministically is Encapsulate with the explicit
+// 32 bytes randomness m. It is only meant for tests.
+func (pub *PublicKey) EncapsulateDeterministically(m []byte) (sharedKey, ciphertext []byte, err error) {
+    if len(m) != 32 {
+        return nil, nil, errors.New("go-cryptobin/mlkem: invalid randomness length")
+    }
+
+    sharedKey, ciphertext = pub.encapsulate(m)
+    return
+}
+
+// encapsulate implements ML-KEM.Encaps_internal, FIPS 203 Algorithm 17.
+func (pub *PublicKey) encapsulate(m []byte) (sharedKey, ciphertext []byte) {
+    g := sha3.New512()
+    g.Write(m)
+    g.Write(pub.h[:])
+    G := g.Sum(nil)
+
+    K, r := G[:SharedKeySize], G[SharedKeySize:]
+
+    return K, pub.encrypt(m, r)
+}
+
+// encrypt implements K-PKE.Encrypt, FIPS 203 Algorithm 14.
+func (pub *PublicKey) encrypt(m, rnd []byte) []byte {
+    params := pub.Params
+    k := params.k
+
+    var N byte
+    y := make([]nttElement, k)
+    for i := range y {
+        y[i] = ntt(samplePolyCBD(rnd, N, params.eta1))
+        N++
+    }
+
+    e1 := make([]ringElement, k)
+    for i := range e1 {
+        e1[i] = samplePolyCBD(rnd, N, eta2)
+        N++
+    }
+
+    e2 := samplePolyCBD(rnd, N, eta2)
+
+    // u = NTT⁻¹(Aᵀ ∘ y) + e1
+    u := make([]ringElement, k)
+    for i := range u {
+        var uHat nttElement
+        for j := range y {
+            uHat = polyAdd(uHat, nttMul(pub.a[j*k+i], y[j]))
+        }
+
+        u[i] = polyAdd(e1[i], inverseNTT(uHat))
+    }
+
+    mu := ringDecodeAndDecompress1(m)
+
+    // v = NTT⁻¹(tᵀ ∘ y) + e2 + μ
+    var vHat nttElement
+    for i := range pub.t {
+        vHat = polyAdd(vHat, nttMul(pub.t[i], y[i]))
+    }
+
+    v := polyAdd(polyAdd(inverseNTT(vHat), e2), mu)
+
+    c := make([]byte, 0, params.CiphertextSize())
+    for i := range u {
+        c = ringCompressAndEncode(c, u[i], params.du)
+    }
+
+    return ringCompressAndEncode(c, v, params.dv)
+}
+
+// PrivateKey is the decapsulation key of ML-KEM.
+type PrivateKey struct {
+    PublicKey
+
+    // seed d || z, empty if the key was parsed from the expanded form
+    seed []byte
+
+    s []nttElement
+    z [32]byte
+}
+
+// GenerateKey generates a new decapsulation key, drawing random
+// bytes from rand.
+func GenerateKey(rand io.Reader, params *Params) (*PrivateKey, error) {
+    seed := make([]byte, SeedSize)
+    if _, err := io.ReadFull(rand, seed); err != nil {
+        return nil, err
+    }
+
+    return NewKeyFromSeed(params, seed)
+}
+
+// NewKeyFromSeed derives a decapsulation key from the 64 bytes seed
+// d || z, implementing ML-KEM.KeyGen_internal, FIPS 203 Algorithm 16.
+func NewKeyFromSeed(params *Params, seed []byte) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(seed) != SeedSize {
+        return nil, ErrInvalidSeed
+    }
+
+    k := params.k
+
+    priv := &PrivateKey{}
+    priv.Params = params
+    priv.seed = append([]byte(nil), seed...)
+    copy(priv.z[:], seed[32:])
+
+    // K-PKE.KeyGen, FIPS 203 Algorithm 13
+    g := sha3.New512()
+    g.Write(seed[:32])
+    g.Write([]byte{byte(k)})
+    G := g.Sum(nil)
+
+    rho, sigma := G[:32], G[32:]
+    copy(priv.rho[:], rho)
+    priv.expandA()
+
+    var N byte
+    priv.s = make([]nttElement, k)
+    for i := range priv.s {
+        priv.s[i] = ntt(samplePolyCBD(sigma, N, params.eta1))
+        N++
+    }
+
+    e := make([]nttElement, k)
+    for i := range e {
+        e[i] = ntt(samplePolyCBD(sigma, N, params.eta1))
+        N++
+    }
+
+    // t = A ∘ s + e
+    priv.t = make([]nttElement, k)
+    for i := range priv.t {
+        priv.t[i] = e[i]
+        for j := range priv.s {
+            priv.t[i] = polyAdd(priv.t[i], nttMul(priv.a[i*k+j], priv.s[j]))
+        }
+    }
+
+    priv.h = sha3.Sum256(priv.PublicKey.Bytes())
+
+    return priv, nil
+}
+
+// NewPrivateKey parses an expanded decapsulation key
+// dk_pke || ek || H(ek) || z.
+func NewPrivateKey(params *Params, b []byte) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(b) != params.PrivateKeySize() {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    k := params.k
+
+    priv := &PrivateKey{}
+
+    dkPKE := b[:encodingSize12*k]
+    ek := b[encodingSize12*k : params.PrivateKeySize()-64]
+    h := b[params.PrivateKeySize()-64 : params.PrivateKeySize()-32]
+    z := b[params.PrivateKeySize()-32:]
+
+    if err := parsePublicKey(&priv.PublicKey, params, ek); err != nil {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    // hash check, FIPS 203 section 7.3
+    if subtle.ConstantTimeCompare(priv.h[:], h) != 1 {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    priv.s = make([]nttElement, k)
+    for i := range priv.s {
+        var err error
+        priv.s[i], err = polyByteDecode[nttElement](dkPKE[:encodingSize12])
+        if err != nil {
+            return nil, ErrInvalidPrivateKey
+        }
+
+        dkPKE = dkPKE[encodingSize12:]
+    }
+
+    copy(priv.z[:], z)
+
+    return priv, nil
+}
+
+// Public returns the encapsulation key of priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// Seed returns the 64 bytes seed d || z of the key, or nil if the key
+// was parsed from the expanded form.
+func (priv *PrivateKey) Seed() []byte {
+    if len(priv.seed) == 0 {
+        return nil
+    }
+
+    return append([]byte(nil), priv.seed...)
+}
+
+// Bytes returns the expanded decapsulation key
+// dk_pke || ek || H(ek) || z.
+func (priv *PrivateKey) Bytes() []byte {
+    b := make([]byte, 0, priv.Params.PrivateKeySize())
+    for i := range priv.s {
+        b = polyByteEncode(b, priv.s[i])
+    }
+
+    b = append(b, priv.PublicKey.Bytes()...)
+    b = append(b, priv.h[:]...)
+    b = append(b, priv.z[:]...)
+
+    return b
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok {
+        return false
+    }
+
+    return priv.Params == xx.Params &&
+        subtle.ConstantTimeCompare(priv.Bytes(), xx.Bytes()) == 1
+}
+
+// Decapsulate generates a shared key from a ciphertext, implementing
+// ML-KEM.Decaps_internal, FIPS 203 Algorithm 18. An invalid ciphertext
+// of the right length gives a pseudorandom shared key.
+func (priv *PrivateKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+    if len(ciphertext) != priv.Params.CiphertextSize() {
+        return nil, ErrInvalidCiphertext
+    }
+
+    m := priv.decrypt(ciphertext)
+
+    g := sha3.New512()
+    g.Write(m)
+    g.Write(priv.h[:])
+    G := g.Sum(nil)
+
+    Kprime, r := G[:SharedKeySize], G[SharedKeySize:]
+
+    Kout := make([]byte, SharedKeySize)
+    J := sha3.NewShake256()
+    J.Write(priv.z[:])
+    J.Write(ciphertext)
+    J.Read(Kout)
+
+    c := priv.encrypt(m, r)
+
+    subtle.ConstantTimeCopy(subtle.ConstantTimeCompare(ciphertext, c), Kout, Kprime)
+
+    return Kout, nil
+}
+
+// decrypt implements K-PKE.Decrypt, FIPS 203 Algorithm 15.
+func (priv *PrivateKey) decrypt(c []byte) []byte {
+    params := priv.Params
+
+    uSize := 32 * int(params.du)
+
+    var mask nttElement
+    for i := range priv.s {
+        u := ringDecodeAndDecompress(c[:uSize], params.du)
+        c = c[uSize:]
+
+        mask = polyAdd(mask, nttMul(priv.s[i], ntt(u)))
+    }
+
+    v := ringDecodeAndDecompress(c, params.dv)
+    w := polySub(v, inverseNTT(mask))
+
+    return ringCompressAndEncode1(nil, w)
+}
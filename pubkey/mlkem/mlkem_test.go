@@ -0,0 +1,314 @@
+package mlkem
+
+import (
+    "os"
+    "bytes"
+    "testing"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "compress/gzip"
+    "path/filepath"
+)
+
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) (err error) {
+    var s string
+    if err = json.Unmarshal(data, &s); err != nil {
+        return err
+    }
+
+    *b, err = hex.DecodeString(s)
+    return err
+}
+
+type acvp struct {
+    groups  []json.RawMessage
+    results map[int]json.RawMessage
+}
+
+func readACVPGroups(t *testing.T, path string) []json.RawMessage {
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var file struct {
+        TestGroups []json.RawMessage `json:"testGroups"`
+    }
+    if err := json.NewDecoder(r).Decode(&file); err != nil {
+        t.Fatal(err)
+    }
+
+    return file.TestGroups
+}
+
+func readACVP(t *testing.T, name string) *acvp {
+    dir := filepath.Join("testdata", name)
+
+    a := &acvp{
+        groups:  readACVPGroups(t, filepath.Join(dir, "prompt.json.gz")),
+        results: make(map[int]json.RawMessage),
+    }
+
+    for _, rawGroup := range readACVPGroups(t, filepath.Join(dir, "expectedResults.json.gz")) {
+        var group struct {
+            Tests []json.RawMessage `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        for _, rawTest := range group.Tests {
+            var tc struct {
+                TcID int `json:"tcId"`
+            }
+            if err := json.Unmarshal(rawTest, &tc); err != nil {
+                t.Fatal(err)
+            }
+
+            a.results[tc.TcID] = rawTest
+        }
+    }
+
+    return a
+}
+
+func (a *acvp) result(t *testing.T, tcID int, result any) {
+    raw, ok := a.results[tcID]
+    if !ok {
+        t.Fatalf("missing result: %d", tcID)
+    }
+
+    if err := json.Unmarshal(raw, result); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// NIST ACVP ML-KEM-keyGen-FIPS203
+func Test_ACVP_KeyGen(t *testing.T) {
+    vectors := readACVP(t, "ML-KEM-keyGen-FIPS203")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet string `json:"parameterSet"`
+            Tests        []struct {
+                TcID int      `json:"tcId"`
+                Z    hexBytes `json:"z"`
+                D    hexBytes `json:"d"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        for _, tc := range group.Tests {
+            var result struct {
+                Ek hexBytes `json:"ek"`
+                Dk hexBytes `json:"dk"`
+            }
+            vectors.result(t, tc.TcID, &result)
+
+            seed := append(append([]byte(nil), tc.D...), tc.Z...)
+
+            priv, err := NewKeyFromSeed(params, seed)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !bytes.Equal(priv.PublicKey.Bytes(), result.Ek) {
+                t.Fatalf("tc=%d: ek got %x, want %x", tc.TcID, priv.PublicKey.Bytes(), result.Ek)
+            }
+
+            if !bytes.Equal(priv.Bytes(), result.Dk) {
+                t.Fatalf("tc=%d: dk got %x, want %x", tc.TcID, priv.Bytes(), result.Dk)
+            }
+
+            priv2, err := NewPrivateKey(params, result.Dk)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !priv.Equal(priv2) {
+                t.Fatalf("tc=%d: NewPrivateKey fail", tc.TcID)
+            }
+        }
+    }
+}
+
+// NIST ACVP ML-KEM-encapDecap-FIPS203
+func Test_ACVP_EncapDecap(t *testing.T) {
+    vectors := readACVP(t, "ML-KEM-encapDecap-FIPS203")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            TestType     string   `json:"testType"`
+            ParameterSet string   `json:"parameterSet"`
+            Dk           hexBytes `json:"dk"`
+            Tests        []struct {
+                TcID int      `json:"tcId"`
+                Ek   hexBytes `json:"ek"`
+                M    hexBytes `json:"m"`
+                C    hexBytes `json:"c"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        switch group.TestType {
+            case "AFT":
+                for _, tc := range group.Tests {
+                    var result struct {
+                        C hexBytes `json:"c"`
+                        K hexBytes `json:"k"`
+                    }
+                    vectors.result(t, tc.TcID, &result)
+
+                    pub, err := NewPublicKey(params, tc.Ek)
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    K, c, err := pub.EncapsulateDeterministically(tc.M)
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    if !bytes.Equal(c, result.C) {
+                        t.Fatalf("tc=%d: c got %x, want %x", tc.TcID, c, result.C)
+                    }
+
+                    if !bytes.Equal(K, result.K) {
+                        t.Fatalf("tc=%d: k got %x, want %x", tc.TcID, K, result.K)
+                    }
+                }
+
+            case "VAL":
+                priv, err := NewPrivateKey(params, group.Dk)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                for _, tc := range group.Tests {
+                    var result struct {
+                        K hexBytes `json:"k"`
+                    }
+                    vectors.result(t, tc.TcID, &result)
+
+                    K, err := priv.Decapsulate(tc.C)
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    if !bytes.Equal(K, result.K) {
+                        t.Fatalf("tc=%d: k got %x, want %x", tc.TcID, K, result.K)
+                    }
+                }
+
+            default:
+                t.Fatalf("unknown test type %s", group.TestType)
+        }
+    }
+}
+
+func Test_EncapsulateDecapsulate(t *testing.T) {
+    for _, params := range []*Params{MLKEM512(), MLKEM768(), MLKEM1024()} {
+        priv, err := GenerateKey(rand.Reader, params)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        pub, err := NewPublicKey(params, priv.PublicKey.Bytes())
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if len(pub.Bytes()) != params.PublicKeySize() {
+            t.Errorf("%s: public key size fail", params)
+        }
+
+        K, c, err := pub.Encapsulate(rand.Reader)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if len(c) != params.CiphertextSize() {
+            t.Errorf("%s: ciphertext size fail", params)
+        }
+
+        K2, err := priv.Decapsulate(c)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !bytes.Equal(K, K2) {
+            t.Errorf("%s: shared key mismatch", params)
+        }
+
+        // implicit rejection
+        c[0] ^= 1
+        K3, err := priv.Decapsulate(c)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if bytes.Equal(K, K3) {
+            t.Errorf("%s: modified ciphertext should give other key", params)
+        }
+
+        if _, err := priv.Decapsulate(c[1:]); err != ErrInvalidCiphertext {
+            t.Errorf("%s: short ciphertext should fail", params)
+        }
+
+        priv2, err := NewKeyFromSeed(params, priv.Seed())
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !priv.Equal(priv2) {
+            t.Errorf("%s: NewKeyFromSeed fail", params)
+        }
+    }
+}
+
+func Test_InvalidPublicKey(t *testing.T) {
+    params := MLKEM768()
+
+    priv, _ := GenerateKey(rand.Reader, params)
+
+    // unreduced coefficient
+    ek := priv.PublicKey.Bytes()
+    ek[0], ek[1] = 0xff, 0xff
+    if _, err := NewPublicKey(params, ek); err != ErrInvalidPublicKey {
+        t.Error("unreduced public key should be rejected")
+    }
+
+    if _, err := NewPublicKey(MLKEM512(), priv.PublicKey.Bytes()); err != ErrInvalidPublicKey {
+        t.Error("public key of other params should be rejected")
+    }
+
+    // hash check
+    dk := priv.Bytes()
+    dk[len(dk)-33] ^= 1
+    if _, err := NewPrivateKey(params, dk); err != ErrInvalidPrivateKey {
+        t.Error("private key with bad hash should be rejected")
+    }
+}
@@ -0,0 +1,203 @@
+package oprf
+
+import (
+    "io"
+    "math/big"
+)
+
+// FinalizeData is the client state kept between Blind and Finalize.
+type FinalizeData struct {
+    suite   *Suite
+    inputs  [][]byte
+    blinds  []*big.Int
+    blinded []*point
+}
+
+// Blinds returns the serialized blinds.
+func (f *FinalizeData) Blinds() [][]byte {
+    out := make([][]byte, len(f.blinds))
+    for i, b := range f.blinds {
+        out[i] = f.suite.group.MarshalScalar(b)
+    }
+
+    return out
+}
+
+// Client is the client of an OPRF protocol.
+type Client struct {
+    suite *Suite
+    mode  Mode
+    pub   *PublicKey
+}
+
+// NewClient returns a client for mode. The server public key
+// is needed in VerifiableMode and PartialObliviousMode.
+func NewClient(suite *Suite, mode Mode, pub *PublicKey) (*Client, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    if !mode.valid() {
+        return nil, ErrInvalidMode
+    }
+
+    if mode != BaseMode {
+        if pub == nil || pub.Suite != suite || pub.e == nil ||
+            suite.group.IsIdentity(pub.e) {
+            return nil, ErrInvalidPublicKey
+        }
+    }
+
+    return &Client{
+        suite: suite,
+        mode:  mode,
+        pub:   pub,
+    }, nil
+}
+
+// Mode returns the mode of the client.
+func (c *Client) Mode() Mode {
+    return c.mode
+}
+
+// Blind blinds the inputs with random scalars.
+func (c *Client) Blind(rand io.Reader, inputs [][]byte) (*FinalizeData, *EvaluationRequest, error) {
+    blinds := make([]*big.Int, len(inputs))
+    for i := range inputs {
+        r, err := c.suite.group.RandomScalar(rand)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        blinds[i] = r
+    }
+
+    return c.blind(inputs, blinds)
+}
+
+// DeterministicBlind blinds the inputs with the given serialized blinds.
+func (c *Client) DeterministicBlind(inputs, blinds [][]byte) (*FinalizeData, *EvaluationRequest, error) {
+    if len(inputs) != len(blinds) {
+        return nil, nil, ErrInvalidInput
+    }
+
+    bs := make([]*big.Int, len(blinds))
+    for i, b := range blinds {
+        r, err := c.suite.group.UnmarshalScalar(b)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        bs[i] = r
+    }
+
+    return c.blind(inputs, bs)
+}
+
+// blind implements Blind of RFC 9497 section 3.3.1.
+func (c *Client) blind(inputs [][]byte, blinds []*big.Int) (*FinalizeData, *EvaluationRequest, error) {
+    if len(inputs) == 0 || len(inputs) > 0xffff {
+        return nil, nil, ErrInvalidInput
+    }
+
+    g := c.suite.group
+
+    data := &FinalizeData{
+        suite:   c.suite,
+        inputs:  make([][]byte, len(inputs)),
+        blinds:  blinds,
+        blinded: make([]*point, len(inputs)),
+    }
+    req := &EvaluationRequest{
+        Elements: make([][]byte, len(inputs)),
+    }
+
+    for i, input := range inputs {
+        if len(input) > 0xffff || blinds[i].Sign() == 0 {
+            return nil, nil, ErrInvalidInput
+        }
+
+        p, err := c.suite.hashToGroup(c.mode, input)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        if g.IsIdentity(p) {
+            return nil, nil, ErrInvalidInput
+        }
+
+        data.inputs[i] = append([]byte(nil), input...)
+        data.blinded[i] = g.Mul(p, blinds[i])
+        req.Elements[i] = g.Marshal(data.blinded[i])
+    }
+
+    return data, req, nil
+}
+
+// Finalize unblinds the evaluation and returns the PRF outputs.
+// The proof is checked in VerifiableMode and PartialObliviousMode,
+// info is only used in PartialObliviousMode.
+func (c *Client) Finalize(data *FinalizeData, eval *Evaluation, info []byte) ([][]byte, error) {
+    if data == nil || eval == nil {
+        return nil, ErrInvalidInput
+    }
+
+    n := len(data.inputs)
+    if n == 0 || len(data.blinds) != n || len(eval.Elements) != n {
+        return nil, ErrInvalidInput
+    }
+
+    g := c.suite.group
+
+    evaluated := make([]*point, n)
+    for i, e := range eval.Elements {
+        p, err := g.Unmarshal(e)
+        if err != nil {
+            return nil, err
+        }
+
+        evaluated[i] = p
+    }
+
+    switch c.mode {
+        case VerifiableMode:
+            proof, err := c.suite.unmarshalProof(eval.Proof)
+            if err != nil {
+                return nil, err
+            }
+
+            if !c.suite.verifyProof(c.mode, c.pub.e, data.blinded, evaluated, proof) {
+                return nil, ErrInvalidProof
+            }
+
+        case PartialObliviousMode:
+            proof, err := c.suite.unmarshalProof(eval.Proof)
+            if err != nil {
+                return nil, err
+            }
+
+            m, err := c.suite.infoScalar(info)
+            if err != nil {
+                return nil, err
+            }
+
+            tweakedKey := g.Add(g.Mul(g.Generator(), m), c.pub.e)
+            if g.IsIdentity(tweakedKey) {
+                return nil, ErrInvalidInfo
+            }
+
+            if !c.suite.verifyProof(c.mode, tweakedKey, evaluated, data.blinded, proof) {
+                return nil, ErrInvalidProof
+            }
+    }
+
+    outputs := make([][]byte, n)
+    for i := range evaluated {
+        inv := new(big.Int).ModInverse(data.blinds[i], g.Order())
+        unblinded := g.Marshal(g.Mul(evaluated[i], inv))
+
+        outputs[i] = c.suite.finalizeHash(c.mode, data.inputs[i], info, unblinded)
+    }
+
+    return outputs, nil
+}
@@ -0,0 +1,132 @@
+package oprf
+
+import (
+    "math/big"
+)
+
+// proof is a batched DLEQ proof of RFC 9497 section 2.2.
+type proof struct {
+    c, s *big.Int
+}
+
+func (s *Suite) marshalProof(p *proof) []byte {
+    out := s.group.MarshalScalar(p.c)
+    return append(out, s.group.MarshalScalar(p.s)...)
+}
+
+func (s *Suite) unmarshalProof(data []byte) (*proof, error) {
+    size := s.group.ScalarSize()
+    if len(data) != 2*size {
+        return nil, ErrInvalidProof
+    }
+
+    c, err := s.group.UnmarshalScalar(data[:size])
+    if err != nil {
+        return nil, ErrInvalidProof
+    }
+
+    sc, err := s.group.UnmarshalScalar(data[size:])
+    if err != nil {
+        return nil, ErrInvalidProof
+    }
+
+    return &proof{c: c, s: sc}, nil
+}
+
+// computeComposites implements ComputeComposites and ComputeCompositesFast.
+// If k is not nil, Z is computed as k*M.
+func (s *Suite) computeComposites(mode Mode, k *big.Int, B *point, C, D []*point) (M, Z *point, err error) {
+    g := s.group
+
+    h := s.hash()
+    h.Write(lengthPrefixed(g.Marshal(B)))
+    h.Write(lengthPrefixed(s.dst(labelSeed, mode)))
+    seed := h.Sum(nil)
+
+    M = g.Identity()
+    Z = g.Identity()
+
+    for i := range C {
+        ci := g.Marshal(C[i])
+        di := g.Marshal(D[i])
+
+        input := lengthPrefixed(seed)
+        input = append(input, byte(i >> 8), byte(i))
+        input = appendLengthPrefixed(input, ci)
+        input = appendLengthPrefixed(input, di)
+        input = append(input, labelComposite...)
+
+        d, err := s.hashToScalar(mode, input)
+        if err != nil {
+            return nil, nil, err
+        }
+
+        M = g.Add(g.Mul(C[i], d), M)
+        if k == nil {
+            Z = g.Add(g.Mul(D[i], d), Z)
+        }
+    }
+
+    if k != nil {
+        Z = g.Mul(M, k)
+    }
+
+    return M, Z, nil
+}
+
+// challenge returns the challenge scalar over B, M, Z, t2 and t3.
+func (s *Suite) challenge(mode Mode, points ...*point) (*big.Int, error) {
+    var input []byte
+    for _, p := range points {
+        input = appendLengthPrefixed(input, s.group.Marshal(p))
+    }
+    input = append(input, labelChallenge...)
+
+    return s.hashToScalar(mode, input)
+}
+
+// generateProof implements GenerateProof of RFC 9497 section 2.2.1,
+// proving k is the discrete log of B = k*G and D[i] = k*C[i].
+func (s *Suite) generateProof(mode Mode, k *big.Int, B *point, C, D []*point, r *big.Int) (*proof, error) {
+    g := s.group
+
+    M, Z, err := s.computeComposites(mode, k, B, C, D)
+    if err != nil {
+        return nil, err
+    }
+
+    t2 := g.Mul(g.Generator(), r)
+    t3 := g.Mul(M, r)
+
+    c, err := s.challenge(mode, B, M, Z, t2, t3)
+    if err != nil {
+        return nil, err
+    }
+
+    // s = r - c*k
+    sc := new(big.Int).Mul(c, k)
+    sc.Sub(r, sc)
+    sc.Mod(sc, g.Order())
+
+    return &proof{c: c, s: sc}, nil
+}
+
+// verifyProof implements VerifyProof of RFC 9497 section 2.2.2.
+func (s *Suite) verifyProof(mode Mode, B *point, C, D []*point, p *proof) bool {
+    g := s.group
+
+    M, Z, err := s.computeComposites(mode, nil, B, C, D)
+    if err != nil {
+        return false
+    }
+
+    t2 := g.Add(g.Mul(g.Generator(), p.s), g.Mul(B, p.c))
+    t3 := g.Add(g.Mul(M, p.s), g.Mul(Z, p.c))
+
+    c, err := s.challenge(mode, B, M, Z, t2, t3)
+    if err != nil {
+        return false
+    }
+
+    return c.Cmp(p.c) == 0
+}
@@ -0,0 +1,163 @@
+package oprf
+
+import (
+    "io"
+    "math/big"
+    "crypto/elliptic"
+
+    "github.com/deatil/go-cryptobin/elliptic/hash2curve"
+)
+
+// point is a group element. Weierstrass groups only use the affine
+// x and y, ristretto255 uses extended coordinates.
+type point struct {
+    x, y, z, t *big.Int
+}
+
+// group is the prime order group of a suite.
+type group interface {
+    Order() *big.Int
+    ElementSize() int
+    ScalarSize() int
+
+    Identity() *point
+    Generator() *point
+    Add(p, q *point) *point
+    Mul(p *point, k *big.Int) *point
+    IsIdentity(p *point) bool
+    Equal(p, q *point) bool
+
+    // Marshal implements SerializeElement.
+    Marshal(p *point) []byte
+    // Unmarshal implements DeserializeElement, the identity is rejected.
+    Unmarshal(data []byte) (*point, error)
+
+    MarshalScalar(k *big.Int) []byte
+    UnmarshalScalar(data []byte) (*big.Int, error)
+
+    HashToElement(msg, dst []byte) (*point, error)
+    HashToScalar(msg, dst []byte) (*big.Int, error)
+
+    // RandomScalar returns a random non-zero scalar.
+    RandomScalar(rand io.Reader) (*big.Int, error)
+}
+
+// weierstrass is a NIST curve with the hash-to-curve suite of RFC 9497.
+type weierstrass struct {
+    curve elliptic.Curve
+    h2c   *hash2curve.Suite
+    // l is the L parameter of hash_to_field for scalars.
+    l int
+}
+
+func (g weierstrass) Order() *big.Int {
+    return g.curve.Params().N
+}
+
+func (g weierstrass) ElementSize() int {
+    return 1 + g.ScalarSize()
+}
+
+func (g weierstrass) ScalarSize() int {
+    return (g.curve.Params().BitSize + 7) / 8
+}
+
+func (g weierstrass) Identity() *point {
+    return &point{x: new(big.Int), y: new(big.Int)}
+}
+
+func (g weierstrass) Generator() *point {
+    params := g.curve.Params()
+    return &point{x: params.Gx, y: params.Gy}
+}
+
+func (g weierstrass) Add(p, q *point) *point {
+    x, y := g.curve.Add(p.x, p.y, q.x, q.y)
+    return &point{x: x, y: y}
+}
+
+func (g weierstrass) Mul(p *point, k *big.Int) *point {
+    x, y := g.curve.ScalarMult(p.x, p.y, k.Bytes())
+    return &point{x: x, y: y}
+}
+
+func (g weierstrass) IsIdentity(p *point) bool {
+    return p.x.Sign() == 0 && p.y.Sign() == 0
+}
+
+func (g weierstrass) Equal(p, q *point) bool {
+    return p.x.Cmp(q.x) == 0 && p.y.Cmp(q.y) == 0
+}
+
+func (g weierstrass) Marshal(p *point) []byte {
+    return elliptic.MarshalCompressed(g.curve, p.x, p.y)
+}
+
+func (g weierstrass) Unmarshal(data []byte) (*point, error) {
+    if len(data) != g.ElementSize() {
+        return nil, ErrInvalidElement
+    }
+
+    x, y := elliptic.UnmarshalCompressed(g.curve, data)
+    if x == nil {
+        return nil, ErrInvalidElement
+    }
+
+    return &point{x: x, y: y}, nil
+}
+
+func (g weierstrass) MarshalScalar(k *big.Int) []byte {
+    return k.FillBytes(make([]byte, g.ScalarSize()))
+}
+
+func (g weierstrass) UnmarshalScalar(data []byte) (*big.Int, error) {
+    if len(data) != g.ScalarSize() {
+        return nil, ErrInvalidScalar
+    }
+
+    k := new(big.Int).SetBytes(data)
+    if k.Cmp(g.Order()) >= 0 {
+        return nil, ErrInvalidScalar
+    }
+
+    return k, nil
+}
+
+func (g weierstrass) HashToElement(msg, dst []byte) (*point, error) {
+    x, y, err := g.h2c.HashToCurve(msg, dst)
+    if err != nil {
+        return nil, err
+    }
+
+    return &point{x: x, y: y}, nil
+}
+
+func (g weierstrass) HashToScalar(msg, dst []byte) (*big.Int, error) {
+    u, err := hash2curve.HashToField(g.h2c.Expander(dst), msg, g.Order(), g.l, 1)
+    if err != nil {
+        return nil, err
+    }
+
+    return u[0], nil
+}
+
+func (g weierstrass) RandomScalar(rand io.Reader) (*big.Int, error) {
+    return randomScalar(rand, g.Order())
+}
+
+// randomScalar returns a uniform scalar in [1, n).
+func randomScalar(rand io.Reader, n *big.Int) (*big.Int, error) {
+    buf := make([]byte, (n.BitLen() + 7) / 8 + 16)
+
+    for {
+        if _, err := io.ReadFull(rand, buf); err != nil {
+            return nil, err
+        }
+
+        k := new(big.Int).SetBytes(buf)
+        k.Mod(k, n)
+        if k.Sign() != 0 {
+            return k, nil
+        }
+    }
+}
@@ -0,0 +1,152 @@
+package oprf
+
+import (
+    "io"
+    "math/big"
+    "crypto"
+)
+
+// PublicKey is the public key of an OPRF server.
+type PublicKey struct {
+    Suite *Suite
+
+    e *point
+}
+
+// NewPublicKey decodes a serialized public key of the suite.
+func NewPublicKey(suite *Suite, data []byte) (*PublicKey, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    e, err := suite.group.Unmarshal(data)
+    if err != nil {
+        return nil, ErrInvalidPublicKey
+    }
+
+    return &PublicKey{
+        Suite: suite,
+        e:     e,
+    }, nil
+}
+
+// Bytes returns the serialized public key.
+func (pub *PublicKey) Bytes() []byte {
+    return pub.Suite.group.Marshal(pub.e)
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok {
+        return false
+    }
+
+    return pub.Suite == xx.Suite &&
+        pub.Suite.group.Equal(pub.e, xx.e)
+}
+
+// PrivateKey is the private key of an OPRF server.
+type PrivateKey struct {
+    PublicKey
+
+    D *big.Int
+}
+
+// NewPrivateKey decodes a serialized private key of the suite.
+func NewPrivateKey(suite *Suite, data []byte) (*PrivateKey, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    d, err := suite.group.UnmarshalScalar(data)
+    if err != nil || d.Sign() == 0 {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    return newPrivateKey(suite, d), nil
+}
+
+func newPrivateKey(suite *Suite, d *big.Int) *PrivateKey {
+    priv := &PrivateKey{}
+    priv.Suite = suite
+    priv.D = d
+    priv.e = suite.group.Mul(suite.group.Generator(), d)
+
+    return priv
+}
+
+// GenerateKey generates a random private key of the suite.
+func GenerateKey(rand io.Reader, suite *Suite) (*PrivateKey, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    d, err := suite.group.RandomScalar(rand)
+    if err != nil {
+        return nil, err
+    }
+
+    return newPrivateKey(suite, d), nil
+}
+
+// DeriveKey implements DeriveKeyPair of RFC 9497 section 3.2.1,
+// deriving a private key from a 32 bytes seed and info.
+func DeriveKey(suite *Suite, mode Mode, seed, info []byte) (*PrivateKey, error) {
+    if suite == nil {
+        return nil, ErrInvalidSuite
+    }
+
+    if !mode.valid() {
+        return nil, ErrInvalidMode
+    }
+
+    if len(seed) != 32 {
+        return nil, ErrInvalidSeed
+    }
+
+    if len(info) > 0xffff {
+        return nil, ErrInvalidInfo
+    }
+
+    deriveInput := append([]byte(nil), seed...)
+    deriveInput = appendLengthPrefixed(deriveInput, info)
+
+    dst := suite.dst(labelDeriveKeyPair, mode)
+
+    for counter := 0; counter < 256; counter++ {
+        msg := append(deriveInput, byte(counter))
+
+        d, err := suite.group.HashToScalar(msg, dst)
+        if err != nil {
+            return nil, err
+        }
+
+        if d.Sign() != 0 {
+            return newPrivateKey(suite, d), nil
+        }
+    }
+
+    return nil, ErrDeriveKeyPair
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// Bytes returns the serialized private key.
+func (priv *PrivateKey) Bytes() []byte {
+    return priv.Suite.group.MarshalScalar(priv.D)
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok {
+        return false
+    }
+
+    return priv.PublicKey.Equal(&xx.PublicKey) &&
+        priv.D.Cmp(xx.D) == 0
+}
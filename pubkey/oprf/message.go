@@ -0,0 +1,125 @@
+package oprf
+
+import (
+    "encoding/binary"
+)
+
+// EvaluationRequest is sent by the client, it holds the blinded elements.
+type EvaluationRequest struct {
+    Elements [][]byte
+}
+
+// MarshalBinary encodes the request as a count followed by
+// length prefixed elements.
+func (r *EvaluationRequest) MarshalBinary() ([]byte, error) {
+    return marshalElements(nil, r.Elements)
+}
+
+// UnmarshalBinary decodes a request encoded by MarshalBinary.
+func (r *EvaluationRequest) UnmarshalBinary(data []byte) error {
+    elements, rest, err := unmarshalElements(data)
+    if err != nil {
+        return err
+    }
+
+    if len(rest) != 0 {
+        return ErrInvalidInput
+    }
+
+    r.Elements = elements
+    return nil
+}
+
+// Evaluation is sent by the server, it holds the evaluated elements
+// and, in the verifiable modes, the batched DLEQ proof.
+type Evaluation struct {
+    Elements [][]byte
+    Proof    []byte
+}
+
+// MarshalBinary encodes the evaluation as a count followed by
+// length prefixed elements and the length prefixed proof.
+func (e *Evaluation) MarshalBinary() ([]byte, error) {
+    out, err := marshalElements(nil, e.Elements)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(e.Proof) > 0xffff {
+        return nil, ErrInvalidProof
+    }
+
+    return appendLengthPrefixed(out, e.Proof), nil
+}
+
+// UnmarshalBinary decodes an evaluation encoded by MarshalBinary.
+func (e *Evaluation) UnmarshalBinary(data []byte) error {
+    elements, rest, err := unmarshalElements(data)
+    if err != nil {
+        return err
+    }
+
+    proof, rest, ok := readLengthPrefixed(rest)
+    if !ok || len(rest) != 0 {
+        return ErrInvalidInput
+    }
+
+    e.Elements = elements
+    e.Proof = nil
+    if len(proof) > 0 {
+        e.Proof = proof
+    }
+
+    return nil
+}
+
+func marshalElements(dst []byte, elements [][]byte) ([]byte, error) {
+    if len(elements) > 0xffff {
+        return nil, ErrInvalidInput
+    }
+
+    dst = binary.BigEndian.AppendUint16(dst, uint16(len(elements)))
+    for _, e := range elements {
+        if len(e) > 0xffff {
+            return nil, ErrInvalidElement
+        }
+
+        dst = appendLengthPrefixed(dst, e)
+    }
+
+    return dst, nil
+}
+
+func unmarshalElements(data []byte) ([][]byte, []byte, error) {
+    if len(data) < 2 {
+        return nil, nil, ErrInvalidInput
+    }
+
+    n := int(binary.BigEndian.Uint16(data))
+    data = data[2:]
+
+    elements := make([][]byte, n)
+    for i := range elements {
+        var ok bool
+        elements[i], data, ok = readLengthPrefixed(data)
+        if !ok {
+            return nil, nil, ErrInvalidInput
+        }
+    }
+
+    return elements, data, nil
+}
+
+func readLengthPrefixed(data []byte) (out, rest []byte, ok bool) {
+    if len(data) < 2 {
+        return nil, nil, false
+    }
+
+    n := int(binary.BigEndian.Uint16(data))
+    if len(data) < 2 + n {
+        return nil, nil, false
+    }
+
+    out = append([]byte(nil), data[2:2+n]...)
+    return out, data[2+n:], true
+}
@@ -0,0 +1,227 @@
+// Package oprf implements the oblivious pseudorandom functions of RFC 9497.
+//
+// An OPRF is a two-party protocol between a server holding a key and a client
+// holding an input. The client learns the PRF output of its input, the server
+// learns nothing about the input or output.
+//
+// Three modes are supported: OPRF (BaseMode), VOPRF (VerifiableMode), where
+// the server proves that its public key was used, and POPRF
+// (PartialObliviousMode), which adds public info to the evaluation. Inputs are
+// processed in batches, verifiable modes use a single batched DLEQ proof.
+//
+//    Client(input)                                  Server(sk, pk)
+//    ---------------------------------------------------------------
+//    data, req, _ := client.Blind(rand, inputs)
+//                                 req
+//                            ------------->
+//                                 eval, _ := server.Evaluate(rand, req, info)
+//                                 eval
+//                            <-------------
+//    outputs, _ := client.Finalize(data, eval, info)
+//
+// Suites are provided for ristretto255, P-256, P-384 and P-521.
+package oprf
+
+import (
+    "hash"
+    "errors"
+    "math/big"
+    "crypto/sha256"
+    "crypto/sha512"
+    "crypto/elliptic"
+    "encoding/binary"
+
+    "github.com/deatil/go-cryptobin/elliptic/hash2curve"
+)
+
+// Mode is the protocol variant.
+type Mode byte
+
+const (
+    // BaseMode is the OPRF mode.
+    BaseMode Mode = 0x00
+    // VerifiableMode is the VOPRF mode.
+    VerifiableMode Mode = 0x01
+    // PartialObliviousMode is the POPRF mode.
+    PartialObliviousMode Mode = 0x02
+)
+
+const (
+    version = "OPRFV1-"
+
+    labelFinalize      = "Finalize"
+    labelHashToGroup   = "HashToGroup-"
+    labelHashToScalar  = "HashToScalar-"
+    labelDeriveKeyPair = "DeriveKeyPair"
+    labelInfo          = "Info"
+    labelSeed          = "Seed-"
+    labelChallenge     = "Challenge"
+    labelComposite     = "Composite"
+)
+
+var (
+    ErrInvalidSuite      = errors.New("go-cryptobin/oprf: invalid suite")
+    ErrInvalidMode       = errors.New("go-cryptobin/oprf: invalid mode")
+    ErrInvalidInput      = errors.New("go-cryptobin/oprf: invalid input")
+    ErrInvalidInfo       = errors.New("go-cryptobin/oprf: invalid info")
+    ErrInvalidSeed       = errors.New("go-cryptobin/oprf: invalid seed size")
+    ErrInvalidElement    = errors.New("go-cryptobin/oprf: invalid element")
+    ErrInvalidScalar     = errors.New("go-cryptobin/oprf: invalid scalar")
+    ErrInvalidProof      = errors.New("go-cryptobin/oprf: proof verification failed")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/oprf: invalid public key")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/oprf: invalid private key")
+    ErrDeriveKeyPair     = errors.New("go-cryptobin/oprf: key pair derivation failed")
+)
+
+func (m Mode) valid() bool {
+    return m == BaseMode ||
+        m == VerifiableMode ||
+        m == PartialObliviousMode
+}
+
+// Suite is an OPRF ciphersuite.
+type Suite struct {
+    id    string
+    group group
+    hash  func() hash.Hash
+}
+
+// ID returns the identifier of the suite, such as "P256-SHA256".
+func (s *Suite) ID() string {
+    return s.id
+}
+
+// ElementSize returns the size of a serialized element.
+func (s *Suite) ElementSize() int {
+    return s.group.ElementSize()
+}
+
+// ScalarSize returns the size of a serialized scalar.
+func (s *Suite) ScalarSize() int {
+    return s.group.ScalarSize()
+}
+
+// OutputSize returns the size of the PRF output.
+func (s *Suite) OutputSize() int {
+    return s.hash().Size()
+}
+
+var (
+    ristretto255SHA512 = &Suite{
+        id:    "ristretto255-SHA512",
+        group: ristretto255{},
+        hash:  sha512.New,
+    }
+
+    p256SHA256 = &Suite{
+        id:    "P256-SHA256",
+        group: weierstrass{elliptic.P256(), hash2curve.P256(), 48},
+        hash:  sha256.New,
+    }
+
+    p384SHA384 = &Suite{
+        id:    "P384-SHA384",
+        group: weierstrass{elliptic.P384(), hash2curve.P384(), 72},
+        hash:  sha512.New384,
+    }
+
+    p521SHA512 = &Suite{
+        id:    "P521-SHA512",
+        group: weierstrass{elliptic.P521(), hash2curve.P521(), 98},
+        hash:  sha512.New,
+    }
+)
+
+// Ristretto255SHA512 returns the ristretto255-SHA512 suite.
+func Ristretto255SHA512() *Suite {
+    return ristretto255SHA512
+}
+
+// P256SHA256 returns the P256-SHA256 suite.
+func P256SHA256() *Suite {
+    return p256SHA256
+}
+
+// P384SHA384 returns the P384-SHA384 suite.
+func P384SHA384() *Suite {
+    return p384SHA384
+}
+
+// P521SHA512 returns the P521-SHA512 suite.
+func P521SHA512() *Suite {
+    return p521SHA512
+}
+
+// GetSuite returns the suite with the identifier id.
+func GetSuite(id string) (*Suite, error) {
+    suites := []*Suite{
+        ristretto255SHA512,
+        p256SHA256,
+        p384SHA384,
+        p521SHA512,
+    }
+
+    for _, s := range suites {
+        if s.id == id {
+            return s, nil
+        }
+    }
+
+    return nil, ErrInvalidSuite
+}
+
+// contextString returns "OPRFV1-" || I2OSP(mode, 1) || "-" || identifier.
+func (s *Suite) contextString(mode Mode) []byte {
+    out := []byte(version)
+    out = append(out, byte(mode), '-')
+    out = append(out, s.id...)
+
+    return out
+}
+
+func (s *Suite) dst(label string, mode Mode) []byte {
+    return append([]byte(label), s.contextString(mode)...)
+}
+
+func (s *Suite) hashToGroup(mode Mode, input []byte) (*point, error) {
+    return s.group.HashToElement(input, s.dst(labelHashToGroup, mode))
+}
+
+func (s *Suite) hashToScalar(mode Mode, input []byte) (*big.Int, error) {
+    return s.group.HashToScalar(input, s.dst(labelHashToScalar, mode))
+}
+
+// infoScalar returns m = HashToScalar("Info" || I2OSP(len(info), 2) || info).
+func (s *Suite) infoScalar(info []byte) (*big.Int, error) {
+    if len(info) > 0xffff {
+        return nil, ErrInvalidInfo
+    }
+
+    framed := []byte(labelInfo)
+    framed = appendLengthPrefixed(framed, info)
+
+    return s.hashToScalar(PartialObliviousMode, framed)
+}
+
+// finalizeHash returns the PRF output of input and the unblinded element.
+func (s *Suite) finalizeHash(mode Mode, input, info, element []byte) []byte {
+    h := s.hash()
+
+    h.Write(lengthPrefixed(input))
+    if mode == PartialObliviousMode {
+        h.Write(lengthPrefixed(info))
+    }
+    h.Write(lengthPrefixed(element))
+    h.Write([]byte(labelFinalize))
+
+    return h.Sum(nil)
+}
+
+func lengthPrefixed(data []byte) []byte {
+    return appendLengthPrefixed(nil, data)
+}
+
+func appendLengthPrefixed(dst, data []byte) []byte {
+    dst = binary.BigEndian.AppendUint16(dst, uint16(len(data)))
+    return append(dst, data...)
+}
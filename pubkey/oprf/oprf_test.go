@@ -0,0 +1,363 @@
+package oprf
+
+import (
+    "os"
+    "bytes"
+    "strings"
+    "testing"
+    "math/big"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "compress/gzip"
+)
+
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) (err error) {
+    var s string
+    if err = json.Unmarshal(data, &s); err != nil {
+        return err
+    }
+
+    *b, err = hex.DecodeString(s)
+    return err
+}
+
+type commaHexBytes [][]byte
+
+func (b *commaHexBytes) UnmarshalJSON(data []byte) error {
+    var s string
+    if err := json.Unmarshal(data, &s); err != nil {
+        return err
+    }
+
+    parts := strings.Split(s, ",")
+
+    *b = make([][]byte, len(parts))
+    for i, p := range parts {
+        v, err := hex.DecodeString(p)
+        if err != nil {
+            return err
+        }
+
+        (*b)[i] = v
+    }
+
+    return nil
+}
+
+type testVector struct {
+    Identifier string   `json:"identifier"`
+    Mode       Mode     `json:"mode"`
+    PkSm       hexBytes `json:"pkSm"`
+    SkSm       hexBytes `json:"skSm"`
+    Seed       hexBytes `json:"seed"`
+    KeyInfo    hexBytes `json:"keyInfo"`
+    Vectors    []struct {
+        Batch             int           `json:"Batch"`
+        Blind             commaHexBytes `json:"Blind"`
+        Info              hexBytes      `json:"Info"`
+        BlindedElement    commaHexBytes `json:"BlindedElement"`
+        EvaluationElement commaHexBytes `json:"EvaluationElement"`
+        Proof             struct {
+            Proof hexBytes `json:"proof"`
+            R     hexBytes `json:"r"`
+        } `json:"Proof"`
+        Input  commaHexBytes `json:"Input"`
+        Output commaHexBytes `json:"Output"`
+    } `json:"vectors"`
+}
+
+func readTestVectors(t *testing.T) []testVector {
+    f, err := os.Open("testdata/rfc9497.json.gz")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var vectors []testVector
+    if err := json.NewDecoder(r).Decode(&vectors); err != nil {
+        t.Fatal(err)
+    }
+
+    return vectors
+}
+
+func equalLists(a, b [][]byte) bool {
+    if len(a) != len(b) {
+        return false
+    }
+
+    for i := range a {
+        if !bytes.Equal(a[i], b[i]) {
+            return false
+        }
+    }
+
+    return true
+}
+
+// RFC 9497 appendix A
+func Test_Vectors(t *testing.T) {
+    for _, tv := range readTestVectors(t) {
+        suite, err := GetSuite(tv.Identifier)
+        if err != nil {
+            // decaf448 is not supported
+            continue
+        }
+
+        tv := tv
+        t.Run(tv.Identifier, func(t *testing.T) {
+            priv, err := DeriveKey(suite, tv.Mode, tv.Seed, tv.KeyInfo)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !bytes.Equal(priv.Bytes(), tv.SkSm) {
+                t.Fatalf("mode %d: skSm got %x, want %x", tv.Mode, priv.Bytes(), tv.SkSm)
+            }
+
+            if tv.Mode != BaseMode && !bytes.Equal(priv.PublicKey.Bytes(), tv.PkSm) {
+                t.Fatalf("mode %d: pkSm got %x, want %x", tv.Mode, priv.PublicKey.Bytes(), tv.PkSm)
+            }
+
+            server, err := NewServer(tv.Mode, priv)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            client, err := NewClient(suite, tv.Mode, &priv.PublicKey)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            for i, v := range tv.Vectors {
+                data, req, err := client.DeterministicBlind(v.Input, v.Blind)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                if !equalLists(req.Elements, v.BlindedElement) {
+                    t.Errorf("mode %d, %d: BlindedElement got %x, want %x", tv.Mode, i, req.Elements, v.BlindedElement)
+                }
+
+                var r *big.Int
+                if tv.Mode != BaseMode {
+                    r, err = suite.group.UnmarshalScalar(v.Proof.R)
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+                }
+
+                eval, err := server.evaluate(req, v.Info, r)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                if !equalLists(eval.Elements, v.EvaluationElement) {
+                    t.Errorf("mode %d, %d: EvaluationElement got %x, want %x", tv.Mode, i, eval.Elements, v.EvaluationElement)
+                }
+
+                if !bytes.Equal(eval.Proof, v.Proof.Proof) {
+                    t.Errorf("mode %d, %d: Proof got %x, want %x", tv.Mode, i, eval.Proof, v.Proof.Proof)
+                }
+
+                outputs, err := client.Finalize(data, eval, v.Info)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                if !equalLists(outputs, v.Output) {
+                    t.Errorf("mode %d, %d: Output got %x, want %x", tv.Mode, i, outputs, v.Output)
+                }
+
+                for j, input := range v.Input {
+                    out, err := server.FullEvaluate(input, v.Info)
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    if !bytes.Equal(out, v.Output[j]) {
+                        t.Errorf("mode %d, %d: FullEvaluate got %x, want %x", tv.Mode, i, out, v.Output[j])
+                    }
+
+                    if !server.VerifyFinalize(input, v.Info, out) {
+                        t.Errorf("mode %d, %d: VerifyFinalize fail", tv.Mode, i)
+                    }
+                }
+            }
+        })
+    }
+}
+
+func Test_Protocol(t *testing.T) {
+    suites := []*Suite{
+        Ristretto255SHA512(),
+        P256SHA256(),
+        P384SHA384(),
+    }
+    modes := []Mode{
+        BaseMode,
+        VerifiableMode,
+        PartialObliviousMode,
+    }
+
+    inputs := [][]byte{
+        []byte("input-1"),
+        []byte("input-2"),
+    }
+    info := []byte("test-info")
+
+    for _, suite := range suites {
+        for _, mode := range modes {
+            priv, err := GenerateKey(rand.Reader, suite)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            pub, err := NewPublicKey(suite, priv.PublicKey.Bytes())
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            server, err := NewServer(mode, priv)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            client, err := NewClient(suite, mode, pub)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            data, req, err := client.Blind(rand.Reader, inputs)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            reqBytes, _ := req.MarshalBinary()
+
+            var req2 EvaluationRequest
+            if err := req2.UnmarshalBinary(reqBytes); err != nil {
+                t.Fatal(err)
+            }
+
+            eval, err := server.Evaluate(rand.Reader, &req2, info)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            evalBytes, _ := eval.MarshalBinary()
+
+            var eval2 Evaluation
+            if err := eval2.UnmarshalBinary(evalBytes); err != nil {
+                t.Fatal(err)
+            }
+
+            outputs, err := client.Finalize(data, &eval2, info)
+            if err != nil {
+                t.Fatalf("%s mode %d: %v", suite.ID(), mode, err)
+            }
+
+            for i, input := range inputs {
+                if len(outputs[i]) != suite.OutputSize() {
+                    t.Errorf("%s mode %d: output size fail", suite.ID(), mode)
+                }
+
+                if !server.VerifyFinalize(input, info, outputs[i]) {
+                    t.Errorf("%s mode %d: VerifyFinalize fail", suite.ID(), mode)
+                }
+            }
+
+            if mode == BaseMode {
+                continue
+            }
+
+            // the proof must bind the server key
+            other, _ := GenerateKey(rand.Reader, suite)
+            otherClient, _ := NewClient(suite, mode, &other.PublicKey)
+            if _, err := otherClient.Finalize(data, eval, info); err != ErrInvalidProof {
+                t.Errorf("%s mode %d: proof with other key should fail", suite.ID(), mode)
+            }
+
+            if mode == PartialObliviousMode {
+                if _, err := client.Finalize(data, eval, []byte("other-info")); err != ErrInvalidProof {
+                    t.Errorf("%s mode %d: proof with other info should fail", suite.ID(), mode)
+                }
+            }
+        }
+    }
+}
+
+func Test_Keys(t *testing.T) {
+    for _, suite := range []*Suite{Ristretto255SHA512(), P256SHA256(), P384SHA384(), P521SHA512()} {
+        priv, err := GenerateKey(rand.Reader, suite)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        priv2, err := NewPrivateKey(suite, priv.Bytes())
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !priv2.Equal(priv) {
+            t.Errorf("%s: private key Equal fail", suite.ID())
+        }
+
+        if _, err := NewPrivateKey(suite, make([]byte, suite.ScalarSize())); err != ErrInvalidPrivateKey {
+            t.Errorf("%s: zero private key should be rejected", suite.ID())
+        }
+    }
+
+    // the identity is not a valid ristretto255 public key
+    if _, err := NewPublicKey(Ristretto255SHA512(), make([]byte, 32)); err != ErrInvalidPublicKey {
+        t.Error("identity public key should be rejected")
+    }
+
+    if _, err := NewClient(P256SHA256(), VerifiableMode, nil); err != ErrInvalidPublicKey {
+        t.Error("verifiable client without public key should fail")
+    }
+}
+
+// RFC 9496 appendix A.1, multiples of the generator
+func Test_Ristretto255Generator(t *testing.T) {
+    want := []string{
+        "0000000000000000000000000000000000000000000000000000000000000000",
+        "e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76",
+        "6a493210f7499cd17fecb510ae0cea23a110e8d5b901f8acadd3095c73a3b919",
+        "94741f5d5d52755ece4f23f044ee27d5d1ea1e2bd196b462166b16152a9d0259",
+    }
+
+    var g ristretto255
+    p := g.Identity()
+    for i, w := range want {
+        if got := hex.EncodeToString(g.Marshal(p)); got != w {
+            t.Errorf("%d: got %s, want %s", i, got, w)
+        }
+
+        if i > 0 {
+            q, err := g.Unmarshal(fromHexString(w))
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !g.Equal(p, q) {
+                t.Errorf("%d: Unmarshal fail", i)
+            }
+        }
+
+        p = g.Add(p, g.Generator())
+    }
+}
+
+func fromHexString(s string) []byte {
+    h, _ := hex.DecodeString(s)
+    return h
+}
@@ -0,0 +1,355 @@
+package oprf
+
+import (
+    "io"
+    "errors"
+    "math/big"
+    "crypto/sha512"
+
+    "github.com/deatil/go-cryptobin/elliptic/hash2curve"
+)
+
+// ristretto255 implements the prime order group of RFC 9496
+// on top of edwards25519 in extended coordinates.
+type ristretto255 struct{}
+
+var (
+    one = big.NewInt(1)
+    two = big.NewInt(2)
+
+    // p = 2^255 - 19
+    r255P = bigFromHex("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed")
+    // l = 2^252 + 27742317777372353535851937790883648493
+    r255L = bigFromHex("1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed")
+
+    r255D              = bigFromDec("37095705934669439343138083508754565189542113879843219016388785533085940283555")
+    r255SqrtM1         = bigFromDec("19681161376707505956807079304988542015446066515923890162744021073123829784752")
+    r255SqrtADMinusOne = bigFromDec("25063068953384623474111414158702152701244531502492656460079210482610430750235")
+    r255InvSqrtAMinusD = bigFromDec("54469307008909316920995813868745141605393597292927456921205312896311721017578")
+    r255OneMinusDSq    = bigFromDec("1159843021668779879193775521855586647937357759715417654439879720876111806838")
+    r255DMinusOneSq    = bigFromDec("40440834346308536858101042469323190826248399146238708352240133220865137265952")
+
+    r255Bx = bigFromHex("216936d3cd6e53fec0a4e231fdd6dc5c692cc7609525a7b2c9562d608f25d51a")
+    r255By = bigFromHex("6666666666666666666666666666666666666666666666666666666666666658")
+)
+
+// field operations mod p
+
+func feMul(a, b *big.Int) *big.Int {
+    r := new(big.Int).Mul(a, b)
+    return r.Mod(r, r255P)
+}
+
+func feAdd(a, b *big.Int) *big.Int {
+    r := new(big.Int).Add(a, b)
+    return r.Mod(r, r255P)
+}
+
+func feSub(a, b *big.Int) *big.Int {
+    r := new(big.Int).Sub(a, b)
+    return r.Mod(r, r255P)
+}
+
+func feNeg(a *big.Int) *big.Int {
+    r := new(big.Int).Neg(a)
+    return r.Mod(r, r255P)
+}
+
+func feIsNegative(a *big.Int) bool {
+    return a.Bit(0) == 1
+}
+
+func feAbs(a *big.Int) *big.Int {
+    if feIsNegative(a) {
+        return feNeg(a)
+    }
+
+    return a
+}
+
+// feSqrtRatioM1 implements SQRT_RATIO_M1 of RFC 9496 section 4.2.
+func feSqrtRatioM1(u, v *big.Int) (bool, *big.Int) {
+    v3 := feMul(feMul(v, v), v)
+    v7 := feMul(feMul(v3, v3), v)
+
+    e := new(big.Int).Sub(r255P, big.NewInt(5))
+    e.Rsh(e, 3)
+
+    r := new(big.Int).Exp(feMul(u, v7), e, r255P)
+    r = feMul(feMul(u, v3), r)
+
+    check := feMul(v, feMul(r, r))
+
+    uNeg := feNeg(u)
+    correctSignSqrt := check.Cmp(u) == 0
+    flippedSignSqrt := check.Cmp(uNeg) == 0
+    flippedSignSqrtI := check.Cmp(feMul(uNeg, r255SqrtM1)) == 0
+
+    if flippedSignSqrt || flippedSignSqrtI {
+        r = feMul(r, r255SqrtM1)
+    }
+
+    return correctSignSqrt || flippedSignSqrt, feAbs(r)
+}
+
+func (ristretto255) Order() *big.Int {
+    return r255L
+}
+
+func (ristretto255) ElementSize() int {
+    return 32
+}
+
+func (ristretto255) ScalarSize() int {
+    return 32
+}
+
+func (ristretto255) Identity() *point {
+    return &point{
+        x: new(big.Int),
+        y: big.NewInt(1),
+        z: big.NewInt(1),
+        t: new(big.Int),
+    }
+}
+
+func (ristretto255) Generator() *point {
+    return &point{
+        x: r255Bx,
+        y: r255By,
+        z: big.NewInt(1),
+        t: feMul(r255Bx, r255By),
+    }
+}
+
+// Add uses the unified addition of "Twisted Edwards Curves Revisited"
+// for a = -1, as RFC 8032 section 5.1.4.
+func (ristretto255) Add(p, q *point) *point {
+    a := feMul(feSub(p.y, p.x), feSub(q.y, q.x))
+    b := feMul(feAdd(p.y, p.x), feAdd(q.y, q.x))
+    c := feMul(feMul(feMul(p.t, two), r255D), q.t)
+    d := feMul(feMul(p.z, two), q.z)
+
+    e := feSub(b, a)
+    f := feSub(d, c)
+    g := feAdd(d, c)
+    h := feAdd(b, a)
+
+    return &point{
+        x: feMul(e, f),
+        y: feMul(g, h),
+        z: feMul(f, g),
+        t: feMul(e, h),
+    }
+}
+
+func (r ristretto255) Mul(p *point, k *big.Int) *point {
+    q := r.Identity()
+    for i := k.BitLen() - 1; i >= 0; i-- {
+        q = r.Add(q, q)
+        if k.Bit(i) == 1 {
+            q = r.Add(q, p)
+        }
+    }
+
+    return q
+}
+
+func (r ristretto255) IsIdentity(p *point) bool {
+    return r.Equal(p, r.Identity())
+}
+
+// Equal implements the equality check of RFC 9496 section 4.3.3.
+func (ristretto255) Equal(p, q *point) bool {
+    return feMul(p.x, q.y).Cmp(feMul(p.y, q.x)) == 0 ||
+        feMul(p.y, q.y).Cmp(feMul(p.x, q.x)) == 0
+}
+
+// Marshal implements the encode function of RFC 9496 section 4.3.2.
+func (ristretto255) Marshal(p *point) []byte {
+    u1 := feMul(feAdd(p.z, p.y), feSub(p.z, p.y))
+    u2 := feMul(p.x, p.y)
+
+    _, invsqrt := feSqrtRatioM1(one, feMul(u1, feMul(u2, u2)))
+
+    den1 := feMul(invsqrt, u1)
+    den2 := feMul(invsqrt, u2)
+    zInv := feMul(feMul(den1, den2), p.t)
+
+    x, y := p.x, p.y
+    denInv := den2
+
+    if feIsNegative(feMul(p.t, zInv)) {
+        x = feMul(p.y, r255SqrtM1)
+        y = feMul(p.x, r255SqrtM1)
+        denInv = feMul(den1, r255InvSqrtAMinusD)
+    }
+
+    if feIsNegative(feMul(x, zInv)) {
+        y = feNeg(y)
+    }
+
+    s := feAbs(feMul(denInv, feSub(p.z, y)))
+
+    return leBytes(s, 32)
+}
+
+// Unmarshal implements the decode function of RFC 9496 section 4.3.1.
+func (ristretto255) Unmarshal(data []byte) (*point, error) {
+    if len(data) != 32 {
+        return nil, ErrInvalidElement
+    }
+
+    s := leInt(data)
+    if s.Cmp(r255P) >= 0 || feIsNegative(s) {
+        return nil, ErrInvalidElement
+    }
+
+    // the identity is rejected as DeserializeElement of RFC 9497
+    if s.Sign() == 0 {
+        return nil, ErrInvalidElement
+    }
+
+    ss := feMul(s, s)
+    u1 := feSub(one, ss)
+    u2 := feAdd(one, ss)
+    u2Sqr := feMul(u2, u2)
+
+    v := feSub(feNeg(feMul(r255D, feMul(u1, u1))), u2Sqr)
+
+    wasSquare, invsqrt := feSqrtRatioM1(one, feMul(v, u2Sqr))
+
+    denX := feMul(invsqrt, u2)
+    denY := feMul(feMul(invsqrt, denX), v)
+
+    x := feAbs(feMul(feMul(two, s), denX))
+    y := feMul(u1, denY)
+    t := feMul(x, y)
+
+    if !wasSquare || feIsNegative(t) || y.Sign() == 0 {
+        return nil, ErrInvalidElement
+    }
+
+    return &point{x: x, y: y, z: big.NewInt(1), t: t}, nil
+}
+
+// mapToPoint implements MAP of RFC 9496 section 4.3.4.
+func (ristretto255) mapToPoint(b []byte) *point {
+    buf := make([]byte, 32)
+    copy(buf, b)
+    buf[31] &= 0x7f
+
+    t := leInt(buf)
+    t.Mod(t, r255P)
+
+    r := feMul(r255SqrtM1, feMul(t, t))
+    u := feMul(feAdd(r, one), r255OneMinusDSq)
+    v := feMul(feSub(feNeg(one), feMul(r, r255D)), feAdd(r, r255D))
+
+    wasSquare, s := feSqrtRatioM1(u, v)
+
+    c := feNeg(one)
+    if !wasSquare {
+        s = feNeg(feAbs(feMul(s, t)))
+        c = r
+    }
+
+    n := feSub(feMul(feMul(c, feSub(r, one)), r255DMinusOneSq), v)
+
+    w0 := feMul(feMul(two, s), v)
+    w1 := feMul(n, r255SqrtADMinusOne)
+    w2 := feSub(one, feMul(s, s))
+    w3 := feAdd(one, feMul(s, s))
+
+    return &point{
+        x: feMul(w0, w3),
+        y: feMul(w2, w1),
+        z: feMul(w1, w3),
+        t: feMul(w0, w2),
+    }
+}
+
+// FromUniformBytes implements the element derivation function
+// of RFC 9496 section 4.3.4.
+func (r ristretto255) FromUniformBytes(b []byte) *point {
+    return r.Add(r.mapToPoint(b[:32]), r.mapToPoint(b[32:64]))
+}
+
+func (r ristretto255) HashToElement(msg, dst []byte) (*point, error) {
+    uniform, err := hash2curve.ExpandMessageXMD(sha512.New, msg, dst, 64)
+    if err != nil {
+        return nil, err
+    }
+
+    return r.FromUniformBytes(uniform), nil
+}
+
+func (ristretto255) HashToScalar(msg, dst []byte) (*big.Int, error) {
+    uniform, err := hash2curve.ExpandMessageXMD(sha512.New, msg, dst, 64)
+    if err != nil {
+        return nil, err
+    }
+
+    k := leInt(uniform)
+    return k.Mod(k, r255L), nil
+}
+
+func (ristretto255) MarshalScalar(k *big.Int) []byte {
+    return leBytes(k, 32)
+}
+
+func (ristretto255) UnmarshalScalar(data []byte) (*big.Int, error) {
+    if len(data) != 32 {
+        return nil, ErrInvalidScalar
+    }
+
+    k := leInt(data)
+    if k.Cmp(r255L) >= 0 {
+        return nil, ErrInvalidScalar
+    }
+
+    return k, nil
+}
+
+func (r ristretto255) RandomScalar(rand io.Reader) (*big.Int, error) {
+    return randomScalar(rand, r255L)
+}
+
+func leInt(b []byte) *big.Int {
+    buf := make([]byte, len(b))
+    copy(buf, b)
+    reverse(buf)
+
+    return new(big.Int).SetBytes(buf)
+}
+
+func leBytes(k *big.Int, size int) []byte {
+    out := k.FillBytes(make([]byte, size))
+    reverse(out)
+
+    return out
+}
+
+func reverse(b []byte) {
+    for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+        b[i], b[j] = b[j], b[i]
+    }
+}
+
+func bigFromHex(s string) *big.Int {
+    b, ok := new(big.Int).SetString(s, 16)
+    if !ok {
+        panic(errors.New("go-cryptobin/oprf: internal error: invalid encoding"))
+    }
+
+    return b
+}
+
+func bigFromDec(s string) *big.Int {
+    b, ok := new(big.Int).SetString(s, 10)
+    if !ok {
+        panic(errors.New("go-cryptobin/oprf: internal error: invalid encoding"))
+    }
+
+    return b
+}
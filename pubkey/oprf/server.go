@@ -0,0 +1,182 @@
+package oprf
+
+import (
+    "io"
+    "math/big"
+    "crypto/subtle"
+)
+
+// Server is the server of an OPRF protocol.
+type Server struct {
+    suite *Suite
+    mode  Mode
+    priv  *PrivateKey
+}
+
+// NewServer returns a server for mode with the private key priv.
+func NewServer(mode Mode, priv *PrivateKey) (*Server, error) {
+    if !mode.valid() {
+        return nil, ErrInvalidMode
+    }
+
+    if priv == nil || priv.Suite == nil || priv.D == nil || priv.D.Sign() == 0 {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    return &Server{
+        suite: priv.Suite,
+        mode:  mode,
+        priv:  priv,
+    }, nil
+}
+
+// Mode returns the mode of the server.
+func (s *Server) Mode() Mode {
+    return s.mode
+}
+
+// PublicKey returns the public key of the server.
+func (s *Server) PublicKey() *PublicKey {
+    return &s.priv.PublicKey
+}
+
+// Evaluate evaluates the blinded elements of req. rand is used for the
+// proof in VerifiableMode and PartialObliviousMode, info is only used
+// in PartialObliviousMode.
+func (s *Server) Evaluate(rand io.Reader, req *EvaluationRequest, info []byte) (*Evaluation, error) {
+    var r *big.Int
+    if s.mode != BaseMode {
+        var err error
+        r, err = s.suite.group.RandomScalar(rand)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return s.evaluate(req, info, r)
+}
+
+// evaluate implements BlindEvaluate of RFC 9497 section 3.3,
+// r is the randomness of the proof.
+func (s *Server) evaluate(req *EvaluationRequest, info []byte, r *big.Int) (*Evaluation, error) {
+    if req == nil || len(req.Elements) == 0 || len(req.Elements) > 0xffff {
+        return nil, ErrInvalidInput
+    }
+
+    g := s.suite.group
+
+    blinded := make([]*point, len(req.Elements))
+    for i, e := range req.Elements {
+        p, err := g.Unmarshal(e)
+        if err != nil {
+            return nil, err
+        }
+
+        blinded[i] = p
+    }
+
+    k := s.priv.D
+    if s.mode == PartialObliviousMode {
+        t, err := s.tweakedKey(info)
+        if err != nil {
+            return nil, err
+        }
+
+        k = t
+    }
+
+    evalKey := k
+    if s.mode == PartialObliviousMode {
+        evalKey = new(big.Int).ModInverse(k, g.Order())
+    }
+
+    evaluated := make([]*point, len(blinded))
+    eval := &Evaluation{
+        Elements: make([][]byte, len(blinded)),
+    }
+
+    for i := range blinded {
+        evaluated[i] = g.Mul(blinded[i], evalKey)
+        eval.Elements[i] = g.Marshal(evaluated[i])
+    }
+
+    switch s.mode {
+        case VerifiableMode:
+            proof, err := s.suite.generateProof(s.mode, k, s.priv.e, blinded, evaluated, r)
+            if err != nil {
+                return nil, err
+            }
+
+            eval.Proof = s.suite.marshalProof(proof)
+
+        case PartialObliviousMode:
+            tweakedKey := g.Mul(g.Generator(), k)
+
+            proof, err := s.suite.generateProof(s.mode, k, tweakedKey, evaluated, blinded, r)
+            if err != nil {
+                return nil, err
+            }
+
+            eval.Proof = s.suite.marshalProof(proof)
+    }
+
+    return eval, nil
+}
+
+// tweakedKey returns t = sk + HashToScalar(framedInfo).
+func (s *Server) tweakedKey(info []byte) (*big.Int, error) {
+    m, err := s.suite.infoScalar(info)
+    if err != nil {
+        return nil, err
+    }
+
+    t := new(big.Int).Add(s.priv.D, m)
+    t.Mod(t, s.suite.group.Order())
+    if t.Sign() == 0 {
+        return nil, ErrInvalidInfo
+    }
+
+    return t, nil
+}
+
+// FullEvaluate computes the PRF output of input without blinding.
+func (s *Server) FullEvaluate(input, info []byte) ([]byte, error) {
+    if len(input) > 0xffff {
+        return nil, ErrInvalidInput
+    }
+
+    g := s.suite.group
+
+    k := s.priv.D
+    if s.mode == PartialObliviousMode {
+        t, err := s.tweakedKey(info)
+        if err != nil {
+            return nil, err
+        }
+
+        k = new(big.Int).ModInverse(t, g.Order())
+    }
+
+    p, err := s.suite.hashToGroup(s.mode, input)
+    if err != nil {
+        return nil, err
+    }
+
+    if g.IsIdentity(p) {
+        return nil, ErrInvalidInput
+    }
+
+    element := g.Marshal(g.Mul(p, k))
+
+    return s.suite.finalizeHash(s.mode, input, info, element), nil
+}
+
+// VerifyFinalize reports whether output is the PRF output of input.
+func (s *Server) VerifyFinalize(input, info, output []byte) bool {
+    expected, err := s.FullEvaluate(input, info)
+    if err != nil {
+        return false
+    }
+
+    return subtle.ConstantTimeCompare(expected, output) == 1
+}
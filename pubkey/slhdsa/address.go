@@ -0,0 +1,76 @@
+package slhdsa
+
+import (
+    "encoding/binary"
+)
+
+// address types, FIPS 205 section 4.2
+const (
+    addrWotsHash  = 0
+    addrWotsPk    = 1
+    addrTree      = 2
+    addrForsTree  = 3
+    addrForsRoots = 4
+    addrWotsPrf   = 5
+    addrForsPrf   = 6
+)
+
+// addressCompressedSize is the size of ADRSᶜ used by the SHA2 parameter sets.
+const addressCompressedSize = 22
+
+// address is the 32 bytes ADRS, FIPS 205 section 4.2.
+type address [32]byte
+
+func (a *address) setLayerAddress(l uint32) {
+    binary.BigEndian.PutUint32(a[0:], l)
+}
+
+// setTreeAddress sets the 12 bytes tree address. The tree index is
+// at most h - h/d = 64 bits, so the first 4 bytes are always zero.
+func (a *address) setTreeAddress(t uint64) {
+    binary.BigEndian.PutUint32(a[4:], 0)
+    binary.BigEndian.PutUint64(a[8:], t)
+}
+
+func (a *address) setTypeAndClear(y uint32) {
+    binary.BigEndian.PutUint32(a[16:], y)
+    for i := 20; i < 32; i++ {
+        a[i] = 0
+    }
+}
+
+func (a *address) setKeyPairAddress(i uint32) {
+    binary.BigEndian.PutUint32(a[20:], i)
+}
+
+func (a *address) getKeyPairAddress() uint32 {
+    return binary.BigEndian.Uint32(a[20:])
+}
+
+func (a *address) setChainAddress(i uint32) {
+    binary.BigEndian.PutUint32(a[24:], i)
+}
+
+func (a *address) setTreeHeight(z uint32) {
+    binary.BigEndian.PutUint32(a[24:], z)
+}
+
+func (a *address) setHashAddress(i uint32) {
+    binary.BigEndian.PutUint32(a[28:], i)
+}
+
+func (a *address) setTreeIndex(i uint32) {
+    binary.BigEndian.PutUint32(a[28:], i)
+}
+
+func (a *address) getTreeIndex() uint32 {
+    return binary.BigEndian.Uint32(a[28:])
+}
+
+// appendCompressed appends ADRSᶜ, FIPS 205 section 11.2.
+func (a *address) appendCompressed(b []byte) []byte {
+    b = append(b, a[3])
+    b = append(b, a[8:16]...)
+    b = append(b, a[19])
+    return append(b, a[20:32]...)
+}
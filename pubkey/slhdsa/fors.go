@@ -0,0 +1,99 @@
+package slhdsa
+
+// forsSkGen generates a FORS private key value, FIPS 205 Algorithm 14.
+func (h *hasher) forsSkGen(out []byte, adrs address, idx uint32) {
+    skAdrs := adrs
+    skAdrs.setTypeAndClear(addrForsPrf)
+    skAdrs.setKeyPairAddress(adrs.getKeyPairAddress())
+    skAdrs.setTreeIndex(idx)
+
+    h.prf(out, &skAdrs)
+}
+
+// forsNode computes the root of a Merkle subtree of FORS public values,
+// FIPS 205 Algorithm 15.
+func (h *hasher) forsNode(out []byte, i, z uint32, adrs address) {
+    n := h.p.n
+
+    if z == 0 {
+        sk := make([]byte, n)
+        h.forsSkGen(sk, adrs, i)
+
+        adrs.setTreeHeight(0)
+        adrs.setTreeIndex(i)
+        h.f(out, &adrs, sk)
+        return
+    }
+
+    node := make([]byte, 2*n)
+    h.forsNode(node[:n], 2*i, z-1, adrs)
+    h.forsNode(node[n:], 2*i+1, z-1, adrs)
+
+    adrs.setTreeHeight(z)
+    adrs.setTreeIndex(i)
+    h.h(out, &adrs, node[:n], node[n:])
+}
+
+// forsSigSize returns the size of a FORS signature.
+func (p *Params) forsSigSize() int {
+    return p.k * (1 + p.a) * p.n
+}
+
+// forsSign generates a FORS signature, FIPS 205 Algorithm 16.
+func (h *hasher) forsSign(sig, md []byte, adrs address) {
+    n, a, k := h.p.n, h.p.a, h.p.k
+
+    indices := make([]uint32, k)
+    base2b(indices, md, uint(a))
+
+    for i := 0; i < k; i++ {
+        part := sig[i*(1+a)*n:(i+1)*(1+a)*n]
+
+        h.forsSkGen(part[:n], adrs, uint32(i)<<uint(a)+indices[i])
+
+        auth := part[n:]
+        for j := 0; j < a; j++ {
+            s := (indices[i] >> uint(j)) ^ 1
+            h.forsNode(auth[j*n:(j+1)*n], uint32(i)<<uint(a-j)+s, uint32(j), adrs)
+        }
+    }
+}
+
+// forsPkFromSig computes a FORS public key from a FORS signature,
+// FIPS 205 Algorithm 17.
+func (h *hasher) forsPkFromSig(out, sig, md []byte, adrs address) {
+    n, a, k := h.p.n, h.p.a, h.p.k
+
+    indices := make([]uint32, k)
+    base2b(indices, md, uint(a))
+
+    root := make([]byte, k*n)
+    for i := 0; i < k; i++ {
+        part := sig[i*(1+a)*n:(i+1)*(1+a)*n]
+        node := root[i*n:(i+1)*n]
+
+        adrs.setTreeHeight(0)
+        adrs.setTreeIndex(uint32(i)<<uint(a) + indices[i])
+        h.f(node, &adrs, part[:n])
+
+        auth := part[n:]
+        for j := 0; j < a; j++ {
+            adrs.setTreeHeight(uint32(j + 1))
+
+            treeIndex := adrs.getTreeIndex()
+            if (indices[i]>>uint(j))&1 == 0 {
+                adrs.setTreeIndex(treeIndex / 2)
+                h.h(node, &adrs, node, auth[j*n:(j+1)*n])
+            } else {
+                adrs.setTreeIndex((treeIndex - 1) / 2)
+                h.h(node, &adrs, auth[j*n:(j+1)*n], node)
+            }
+        }
+    }
+
+    pkAdrs := adrs
+    pkAdrs.setTypeAndClear(addrForsRoots)
+    pkAdrs.setKeyPairAddress(adrs.getKeyPairAddress())
+
+    h.t(out, &pkAdrs, root)
+}
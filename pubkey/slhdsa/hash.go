@@ -0,0 +1,183 @@
+package slhdsa
+
+import (
+    "hash"
+    "crypto/hmac"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/binary"
+
+    "golang.org/x/crypto/sha3"
+)
+
+// hasher implements the tweakable hash functions PRF, F, H and Tₗ,
+// FIPS 205 sections 11.1 and 11.2, keyed with PK.seed and SK.seed.
+type hasher struct {
+    p      *Params
+    pkSeed []byte
+    skSeed []byte
+
+    // PK.seed padded to the block size of SHA-256 and SHA-512
+    pre256 []byte
+    pre512 []byte
+
+    shake sha3.ShakeHash
+    buf   []byte
+}
+
+func newHasher(p *Params, pkSeed, skSeed []byte) *hasher {
+    h := &hasher{
+        p:      p,
+        pkSeed: pkSeed,
+        skSeed: skSeed,
+    }
+
+    if p.isSHA2 {
+        h.pre256 = make([]byte, sha256.BlockSize)
+        copy(h.pre256, pkSeed)
+
+        h.pre512 = make([]byte, sha512.BlockSize)
+        copy(h.pre512, pkSeed)
+    } else {
+        h.shake = sha3.NewShake256()
+    }
+
+    h.buf = make([]byte, 0, sha512.BlockSize+32+(2*p.n+3)*p.n)
+
+    return h
+}
+
+// prf computes PRF(PK.seed, SK.seed, ADRS).
+func (h *hasher) prf(out []byte, adrs *address) {
+    h.sum(out, false, adrs, h.skSeed, nil)
+}
+
+// f computes F(PK.seed, ADRS, M₁).
+func (h *hasher) f(out []byte, adrs *address, m []byte) {
+    h.sum(out, false, adrs, m, nil)
+}
+
+// h computes H(PK.seed, ADRS, M₁ || M₂).
+func (h *hasher) h(out []byte, adrs *address, m1, m2 []byte) {
+    h.sum(out, true, adrs, m1, m2)
+}
+
+// t computes Tₗ(PK.seed, ADRS, M).
+func (h *hasher) t(out []byte, adrs *address, m []byte) {
+    h.sum(out, true, adrs, m, nil)
+}
+
+// sum hashes PK.seed || ADRS || m1 || m2 to n bytes. For the SHA2
+// parameter sets of category 3 and 5, H and T use SHA-512.
+func (h *hasher) sum(out []byte, large bool, adrs *address, m1, m2 []byte) {
+    n := h.p.n
+
+    b := h.buf[:0]
+    if h.p.isSHA2 {
+        if large && n > 16 {
+            b = append(b, h.pre512...)
+            b = adrs.appendCompressed(b)
+            b = append(b, m1...)
+            b = append(b, m2...)
+
+            s := sha512.Sum512(b)
+            copy(out[:n], s[:])
+        } else {
+            b = append(b, h.pre256...)
+            b = adrs.appendCompressed(b)
+            b = append(b, m1...)
+            b = append(b, m2...)
+
+            s := sha256.Sum256(b)
+            copy(out[:n], s[:])
+        }
+    } else {
+        b = append(b, h.pkSeed...)
+        b = append(b, adrs[:]...)
+        b = append(b, m1...)
+        b = append(b, m2...)
+
+        h.shake.Reset()
+        h.shake.Write(b)
+        h.shake.Read(out[:n])
+    }
+
+    h.buf = b[:0]
+}
+
+// prfMsg computes PRF_msg(SK.prf, opt_rand, M).
+func (p *Params) prfMsg(skPrf, optRand, msg []byte) []byte {
+    out := make([]byte, p.n)
+
+    if p.isSHA2 {
+        newHash := sha256.New
+        if p.n > 16 {
+            newHash = sha512.New
+        }
+
+        mac := hmac.New(newHash, skPrf)
+        mac.Write(optRand)
+        mac.Write(msg)
+        copy(out, mac.Sum(nil))
+    } else {
+        H := sha3.NewShake256()
+        H.Write(skPrf)
+        H.Write(optRand)
+        H.Write(msg)
+        H.Read(out)
+    }
+
+    return out
+}
+
+// hashMsg computes H_msg(R, PK.seed, PK.root, M).
+func (p *Params) hashMsg(r, pkSeed, pkRoot, msg []byte) []byte {
+    out := make([]byte, p.m)
+
+    if p.isSHA2 {
+        newHash := sha256.New
+        if p.n > 16 {
+            newHash = sha512.New
+        }
+
+        H := newHash()
+        H.Write(r)
+        H.Write(pkSeed)
+        H.Write(pkRoot)
+        H.Write(msg)
+
+        seed := make([]byte, 0, 2*p.n+sha512.Size)
+        seed = append(seed, r...)
+        seed = append(seed, pkSeed...)
+        seed = H.Sum(seed)
+
+        mgf1(out, newHash, seed)
+    } else {
+        H := sha3.NewShake256()
+        H.Write(r)
+        H.Write(pkSeed)
+        H.Write(pkRoot)
+        H.Write(msg)
+        H.Read(out)
+    }
+
+    return out
+}
+
+// mgf1 is MGF1 of RFC 8017 appendix B.2.1.
+func mgf1(out []byte, newHash func() hash.Hash, seed []byte) {
+    var counter [4]byte
+    var sum []byte
+
+    h := newHash()
+    for c := uint32(0); len(out) > 0; c++ {
+        binary.BigEndian.PutUint32(counter[:], c)
+
+        h.Reset()
+        h.Write(seed)
+        h.Write(counter[:])
+        sum = h.Sum(sum[:0])
+
+        out = out[copy(out, sum):]
+    }
+}
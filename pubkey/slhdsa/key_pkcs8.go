@@ -0,0 +1,170 @@
+package slhdsa
+
+import (
+    "errors"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+)
+
+var (
+    // NIST OIDs, FIPS 205
+    oidSHA2_128s  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 20}
+    oidSHA2_128f  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 21}
+    oidSHA2_192s  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 22}
+    oidSHA2_192f  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 23}
+    oidSHA2_256s  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 24}
+    oidSHA2_256f  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 25}
+    oidSHAKE_128s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 26}
+    oidSHAKE_128f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 27}
+    oidSHAKE_192s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 28}
+    oidSHAKE_192f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 29}
+    oidSHAKE_256s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 30}
+    oidSHAKE_256f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 31}
+)
+
+// 参数及 OID 对应
+var paramsOIDs = []struct {
+    params *Params
+    oid    asn1.ObjectIdentifier
+}{
+    {paramsSHA2_128s, oidSHA2_128s},
+    {paramsSHA2_128f, oidSHA2_128f},
+    {paramsSHA2_192s, oidSHA2_192s},
+    {paramsSHA2_192f, oidSHA2_192f},
+    {paramsSHA2_256s, oidSHA2_256s},
+    {paramsSHA2_256f, oidSHA2_256f},
+    {paramsSHAKE_128s, oidSHAKE_128s},
+    {paramsSHAKE_128f, oidSHAKE_128f},
+    {paramsSHAKE_192s, oidSHAKE_192s},
+    {paramsSHAKE_192f, oidSHAKE_192f},
+    {paramsSHAKE_256s, oidSHAKE_256s},
+    {paramsSHAKE_256f, oidSHAKE_256f},
+}
+
+// 私钥 - 包装
+type pkcs8 struct {
+    Version    int
+    Algo       pkix.AlgorithmIdentifier
+    PrivateKey []byte
+    Attributes []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// 公钥 - 包装
+type pkixPublicKey struct {
+    Algo      pkix.AlgorithmIdentifier
+    BitString asn1.BitString
+}
+
+// 公钥信息 - 解析
+type publicKeyInfo struct {
+    Raw       asn1.RawContent
+    Algorithm pkix.AlgorithmIdentifier
+    PublicKey asn1.BitString
+}
+
+// OID 获取参数
+func ParamsFromOID(oid asn1.ObjectIdentifier) (*Params, error) {
+    for _, po := range paramsOIDs {
+        if po.oid.Equal(oid) {
+            return po.params, nil
+        }
+    }
+
+    return nil, errors.New("go-cryptobin/slhdsa: unknown public key algorithm")
+}
+
+// 参数获取 OID
+func OIDFromParams(params *Params) (asn1.ObjectIdentifier, error) {
+    for _, po := range paramsOIDs {
+        if po.params == params {
+            return po.oid, nil
+        }
+    }
+
+    return nil, ErrInvalidParams
+}
+
+// 包装公钥
+func MarshalPublicKey(key *PublicKey) ([]byte, error) {
+    oid, err := OIDFromParams(key.Params)
+    if err != nil {
+        return nil, err
+    }
+
+    publicKeyBytes := key.Bytes()
+
+    pkix := pkixPublicKey{
+        Algo: pkix.AlgorithmIdentifier{
+            Algorithm: oid,
+        },
+        BitString: asn1.BitString{
+            Bytes:     publicKeyBytes,
+            BitLength: 8 * len(publicKeyBytes),
+        },
+    }
+
+    return asn1.Marshal(pkix)
+}
+
+// 解析公钥
+func ParsePublicKey(derBytes []byte) (*PublicKey, error) {
+    var pki publicKeyInfo
+    rest, err := asn1.Unmarshal(derBytes, &pki)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    params, err := ParamsFromOID(pki.Algorithm.Algorithm)
+    if err != nil {
+        return nil, err
+    }
+
+    // the parameters field must be absent
+    if len(pki.Algorithm.Parameters.FullBytes) != 0 {
+        return nil, errors.New("go-cryptobin/slhdsa: invalid public key algorithm parameters")
+    }
+
+    return NewPublicKey(params, pki.PublicKey.RightAlign())
+}
+
+// ====================
+
+// 包装私钥, privateKey 直接保存私钥数据, 不再包装为 OCTET STRING
+func MarshalPrivateKey(key *PrivateKey) ([]byte, error) {
+    oid, err := OIDFromParams(key.Params)
+    if err != nil {
+        return nil, err
+    }
+
+    var privKey pkcs8
+    privKey.Algo = pkix.AlgorithmIdentifier{
+        Algorithm: oid,
+    }
+    privKey.PrivateKey = key.Bytes()
+
+    return asn1.Marshal(privKey)
+}
+
+// 解析私钥
+func ParsePrivateKey(derBytes []byte) (*PrivateKey, error) {
+    var privKey pkcs8
+    _, err := asn1.Unmarshal(derBytes, &privKey)
+    if err != nil {
+        return nil, err
+    }
+
+    params, err := ParamsFromOID(privKey.Algo.Algorithm)
+    if err != nil {
+        return nil, errors.New("go-cryptobin/slhdsa: unknown private key algorithm")
+    }
+
+    if len(privKey.Algo.Parameters.FullBytes) != 0 {
+        return nil, errors.New("go-cryptobin/slhdsa: invalid private key algorithm parameters")
+    }
+
+    return NewPrivateKey(params, privKey.PrivateKey)
+}
@@ -0,0 +1,92 @@
+package slhdsa
+
+import (
+    "bytes"
+    "testing"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/pem"
+
+    cryptobin_pkcs8 "github.com/deatil/go-cryptobin/pkcs8"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func Test_Marshal(t *testing.T) {
+    for _, params := range allParams {
+        t.Run(params.Name, func(t *testing.T) {
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            // key generation of the small parameter sets is slow,
+            // the encoding does not check PK.root
+            b := make([]byte, params.PrivateKeySize())
+            rand.Read(b)
+
+            priv, err := NewPrivateKey(params, b)
+            assertError(err, "NewPrivateKey")
+
+            pubkey, err := MarshalPublicKey(&priv.PublicKey)
+            assertError(err, "MarshalPublicKey")
+
+            parsedPub, err := ParsePublicKey(pubkey)
+            assertError(err, "ParsePublicKey")
+
+            prikey, err := MarshalPrivateKey(priv)
+            assertError(err, "MarshalPrivateKey")
+
+            parsedPri, err := ParsePrivateKey(prikey)
+            assertError(err, "ParsePrivateKey")
+
+            assertBool(priv.PublicKey.Equal(parsedPub), "Equal")
+            assertBool(priv.Equal(parsedPri), "Equal")
+            assertBool(parsedPri.Params == params, "Params")
+        })
+    }
+}
+
+func Test_MarshalPrivateKey_Raw(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    b := make([]byte, SHA2_128s().PrivateKeySize())
+    for i := range b {
+        b[i] = byte(i)
+    }
+
+    priv, err := NewPrivateKey(SHA2_128s(), b)
+    assertError(err, "NewPrivateKey")
+
+    der, err := MarshalPrivateKey(priv)
+    assertError(err, "MarshalPrivateKey")
+
+    // PrivateKeyInfo with the raw private key in privateKey
+    prefix, _ := hex.DecodeString("3052020100300b060960864801650304031404400001020304")
+    if !bytes.HasPrefix(der, prefix) {
+        t.Errorf("MarshalPrivateKey got %x", der)
+    }
+}
+
+func Test_EncryptPKCS8(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    priv, err := GenerateKey(rand.Reader, SHAKE_128f())
+    assertError(err, "GenerateKey")
+
+    der, err := MarshalPrivateKey(priv)
+    assertError(err, "MarshalPrivateKey")
+
+    password := []byte("test-pass")
+
+    block, err := cryptobin_pkcs8.EncryptPEMBlock(rand.Reader, "ENCRYPTED PRIVATE KEY", der, password, cryptobin_pkcs8.DefaultOpts)
+    assertError(err, "EncryptPEMBlock")
+
+    block, _ = pem.Decode(pem.EncodeToMemory(block))
+
+    decrypted, err := cryptobin_pkcs8.DecryptPEMBlock(block, password)
+    assertError(err, "DecryptPEMBlock")
+
+    parsed, err := ParsePrivateKey(decrypted)
+    assertError(err, "ParsePrivateKey")
+
+    assertBool(priv.Equal(parsed), "Equal")
+}
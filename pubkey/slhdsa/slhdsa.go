@@ -0,0 +1,537 @@
+package slhdsa
+
+import (
+    "io"
+    "errors"
+    "crypto"
+    "crypto/subtle"
+)
+
+const (
+    // ContextMaxSize is the maximum length (in bytes) allowed for context.
+    ContextMaxSize = 255
+)
+
+var (
+    ErrInvalidParams     = errors.New("go-cryptobin/slhdsa: invalid params")
+    ErrInvalidSeed       = errors.New("go-cryptobin/slhdsa: invalid seed length")
+    ErrInvalidPublicKey  = errors.New("go-cryptobin/slhdsa: invalid public key")
+    ErrInvalidPrivateKey = errors.New("go-cryptobin/slhdsa: invalid private key")
+    ErrInvalidSignature  = errors.New("go-cryptobin/slhdsa: invalid signature")
+    ErrContextTooLong    = errors.New("go-cryptobin/slhdsa: context too long")
+    ErrInvalidHash       = errors.New("go-cryptobin/slhdsa: unsupported hash algorithm")
+    ErrInvalidDigest     = errors.New("go-cryptobin/slhdsa: invalid digest length")
+)
+
+// Params is a SLH-DSA parameter set, FIPS 205 section 11.
+type Params struct {
+    Name string
+
+    n      int  // security parameter, bytes
+    h      int  // total tree height
+    d      int  // number of hypertree layers
+    hp     int  // height of the XMSS trees, h' = h / d
+    a      int  // height of the FORS trees
+    k      int  // number of FORS trees
+    m      int  // message digest length, bytes
+    isSHA2 bool // SHA2 or SHAKE hash functions
+}
+
+// String returns the name of the parameter set.
+func (p *Params) String() string {
+    return p.Name
+}
+
+// SeedSize returns the size of SK.seed || SK.prf || PK.seed.
+func (p *Params) SeedSize() int {
+    return 3 * p.n
+}
+
+// PublicKeySize returns the size of a public key, PK.seed || PK.root.
+func (p *Params) PublicKeySize() int {
+    return 2 * p.n
+}
+
+// PrivateKeySize returns the size of a private key,
+// SK.seed || SK.prf || PK.seed || PK.root.
+func (p *Params) PrivateKeySize() int {
+    return 4 * p.n
+}
+
+// SignatureSize returns the size of a signature.
+func (p *Params) SignatureSize() int {
+    // R + FORS signature + hypertree signature
+    return p.n + p.forsSigSize() + p.d*p.xmssSigSize()
+}
+
+var (
+    paramsSHA2_128s  = &Params{Name: "SLH-DSA-SHA2-128s", n: 16, h: 63, d: 7, hp: 9, a: 12, k: 14, m: 30, isSHA2: true}
+    paramsSHA2_128f  = &Params{Name: "SLH-DSA-SHA2-128f", n: 16, h: 66, d: 22, hp: 3, a: 6, k: 33, m: 34, isSHA2: true}
+    paramsSHA2_192s  = &Params{Name: "SLH-DSA-SHA2-192s", n: 24, h: 63, d: 7, hp: 9, a: 14, k: 17, m: 39, isSHA2: true}
+    paramsSHA2_192f  = &Params{Name: "SLH-DSA-SHA2-192f", n: 24, h: 66, d: 22, hp: 3, a: 8, k: 33, m: 42, isSHA2: true}
+    paramsSHA2_256s  = &Params{Name: "SLH-DSA-SHA2-256s", n: 32, h: 64, d: 8, hp: 8, a: 14, k: 22, m: 47, isSHA2: true}
+    paramsSHA2_256f  = &Params{Name: "SLH-DSA-SHA2-256f", n: 32, h: 68, d: 17, hp: 4, a: 9, k: 35, m: 49, isSHA2: true}
+    paramsSHAKE_128s = &Params{Name: "SLH-DSA-SHAKE-128s", n: 16, h: 63, d: 7, hp: 9, a: 12, k: 14, m: 30}
+    paramsSHAKE_128f = &Params{Name: "SLH-DSA-SHAKE-128f", n: 16, h: 66, d: 22, hp: 3, a: 6, k: 33, m: 34}
+    paramsSHAKE_192s = &Params{Name: "SLH-DSA-SHAKE-192s", n: 24, h: 63, d: 7, hp: 9, a: 14, k: 17, m: 39}
+    paramsSHAKE_192f = &Params{Name: "SLH-DSA-SHAKE-192f", n: 24, h: 66, d: 22, hp: 3, a: 8, k: 33, m: 42}
+    paramsSHAKE_256s = &Params{Name: "SLH-DSA-SHAKE-256s", n: 32, h: 64, d: 8, hp: 8, a: 14, k: 22, m: 47}
+    paramsSHAKE_256f = &Params{Name: "SLH-DSA-SHAKE-256f", n: 32, h: 68, d: 17, hp: 4, a: 9, k: 35, m: 49}
+)
+
+var allParams = []*Params{
+    paramsSHA2_128s,
+    paramsSHA2_128f,
+    paramsSHA2_192s,
+    paramsSHA2_192f,
+    paramsSHA2_256s,
+    paramsSHA2_256f,
+    paramsSHAKE_128s,
+    paramsSHAKE_128f,
+    paramsSHAKE_192s,
+    paramsSHAKE_192f,
+    paramsSHAKE_256s,
+    paramsSHAKE_256f,
+}
+
+// SHA2_128s returns the SLH-DSA-SHA2-128s parameter set.
+func SHA2_128s() *Params {
+    return paramsSHA2_128s
+}
+
+// SHA2_128f returns the SLH-DSA-SHA2-128f parameter set.
+func SHA2_128f() *Params {
+    return paramsSHA2_128f
+}
+
+// SHA2_192s returns the SLH-DSA-SHA2-192s parameter set.
+func SHA2_192s() *Params {
+    return paramsSHA2_192s
+}
+
+// SHA2_192f returns the SLH-DSA-SHA2-192f parameter set.
+func SHA2_192f() *Params {
+    return paramsSHA2_192f
+}
+
+// SHA2_256s returns the SLH-DSA-SHA2-256s parameter set.
+func SHA2_256s() *Params {
+    return paramsSHA2_256s
+}
+
+// SHA2_256f returns the SLH-DSA-SHA2-256f parameter set.
+func SHA2_256f() *Params {
+    return paramsSHA2_256f
+}
+
+// SHAKE_128s returns the SLH-DSA-SHAKE-128s parameter set.
+func SHAKE_128s() *Params {
+    return paramsSHAKE_128s
+}
+
+// SHAKE_128f returns the SLH-DSA-SHAKE-128f parameter set.
+func SHAKE_128f() *Params {
+    return paramsSHAKE_128f
+}
+
+// SHAKE_192s returns the SLH-DSA-SHAKE-192s parameter set.
+func SHAKE_192s() *Params {
+    return paramsSHAKE_192s
+}
+
+// SHAKE_192f returns the SLH-DSA-SHAKE-192f parameter set.
+func SHAKE_192f() *Params {
+    return paramsSHAKE_192f
+}
+
+// SHAKE_256s returns the SLH-DSA-SHAKE-256s parameter set.
+func SHAKE_256s() *Params {
+    return paramsSHAKE_256s
+}
+
+// SHAKE_256f returns the SLH-DSA-SHAKE-256f parameter set.
+func SHAKE_256f() *Params {
+    return paramsSHAKE_256f
+}
+
+// GetParams returns the parameter set with the name,
+// like SLH-DSA-SHA2-128s.
+func GetParams(name string) (*Params, error) {
+    for _, p := range allParams {
+        if p.Name == name {
+            return p, nil
+        }
+    }
+
+    return nil, ErrInvalidParams
+}
+
+// Options implements crypto.SignerOpts and augments with parameters
+// that are specific to the SLH-DSA signature schemes.
+type Options struct {
+    // Hash is crypto.Hash(0) for SLH-DSA. Otherwise HashSLH-DSA is used,
+    // and the message passed to Sign must be the digest of the hash.
+    Hash crypto.Hash
+
+    // Context is an optional domain separation string for signing.
+    // Its length must be less or equal than 255 bytes.
+    Context string
+
+    // Deterministic makes Sign use the deterministic variant,
+    // which uses PK.seed in place of the additional randomness.
+    Deterministic bool
+}
+
+// HashFunc returns o.Hash.
+func (o *Options) HashFunc() crypto.Hash {
+    return o.Hash
+}
+
+// PublicKey is the type of SLH-DSA public keys.
+type PublicKey struct {
+    Params *Params
+
+    seed []byte
+    root []byte
+}
+
+// NewPublicKey parses an encoded public key, PK.seed || PK.root.
+func NewPublicKey(params *Params, b []byte) (*PublicKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(b) != params.PublicKeySize() {
+        return nil, ErrInvalidPublicKey
+    }
+
+    n := params.n
+
+    pub := &PublicKey{}
+    pub.Params = params
+    pub.seed = append([]byte(nil), b[:n]...)
+    pub.root = append([]byte(nil), b[n:]...)
+
+    return pub, nil
+}
+
+// Bytes returns the encoded public key.
+func (pub *PublicKey) Bytes() []byte {
+    b := make([]byte, 0, 2*len(pub.seed))
+    b = append(b, pub.seed...)
+    return append(b, pub.root...)
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+    xx, ok := x.(*PublicKey)
+    if !ok {
+        return false
+    }
+
+    return pub.Params == xx.Params &&
+        subtle.ConstantTimeCompare(pub.seed, xx.seed) == 1 &&
+        subtle.ConstantTimeCompare(pub.root, xx.root) == 1
+}
+
+// PrivateKey is the type of SLH-DSA private keys.
+type PrivateKey struct {
+    PublicKey
+
+    seed []byte
+    prf  []byte
+}
+
+// GenerateKey generates a new private key, reading SK.seed, SK.prf
+// and PK.seed from rand, FIPS 205 Algorithm 21.
+func GenerateKey(rand io.Reader, params *Params) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    seed := make([]byte, params.SeedSize())
+    if _, err := io.ReadFull(rand, seed); err != nil {
+        return nil, err
+    }
+
+    return NewKeyFromSeed(params, seed)
+}
+
+// NewKeyFromSeed derives a private key from SK.seed || SK.prf || PK.seed,
+// implementing slh_keygen_internal, FIPS 205 Algorithm 18.
+func NewKeyFromSeed(params *Params, seed []byte) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(seed) != params.SeedSize() {
+        return nil, ErrInvalidSeed
+    }
+
+    n := params.n
+
+    priv := &PrivateKey{}
+    priv.Params = params
+    priv.seed = append([]byte(nil), seed[:n]...)
+    priv.prf = append([]byte(nil), seed[n:2*n]...)
+    priv.PublicKey.seed = append([]byte(nil), seed[2*n:]...)
+
+    var adrs address
+    adrs.setLayerAddress(uint32(params.d - 1))
+
+    priv.root = make([]byte, n)
+
+    h := newHasher(params, priv.PublicKey.seed, priv.seed)
+    h.xmssNode(priv.root, 0, uint32(params.hp), adrs)
+
+    return priv, nil
+}
+
+// NewPrivateKey parses an encoded private key,
+// SK.seed || SK.prf || PK.seed || PK.root. PK.root is not
+// recomputed, which would cost as much as generating the key.
+func NewPrivateKey(params *Params, b []byte) (*PrivateKey, error) {
+    if params == nil {
+        return nil, ErrInvalidParams
+    }
+
+    if len(b) != params.PrivateKeySize() {
+        return nil, ErrInvalidPrivateKey
+    }
+
+    n := params.n
+
+    priv := &PrivateKey{}
+    priv.Params = params
+    priv.seed = append([]byte(nil), b[:n]...)
+    priv.prf = append([]byte(nil), b[n:2*n]...)
+    priv.PublicKey.seed = append([]byte(nil), b[2*n:3*n]...)
+    priv.root = append([]byte(nil), b[3*n:]...)
+
+    return priv, nil
+}
+
+// Bytes returns the encoded private key.
+func (priv *PrivateKey) Bytes() []byte {
+    b := make([]byte, 0, priv.Params.PrivateKeySize())
+    b = append(b, priv.seed...)
+    b = append(b, priv.prf...)
+    return append(b, priv.PublicKey.Bytes()...)
+}
+
+// Public returns the public key of priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+    return &priv.PublicKey
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+    xx, ok := x.(*PrivateKey)
+    if !ok {
+        return false
+    }
+
+    return priv.Params == xx.Params &&
+        subtle.ConstantTimeCompare(priv.Bytes(), xx.Bytes()) == 1
+}
+
+// Sign signs the message with priv. If opts.HashFunc() is zero the
+// message is signed with SLH-DSA, otherwise the message must be the
+// digest of the hash and it is signed with HashSLH-DSA.
+// The context and the deterministic variant can be set with *Options.
+func (priv *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+    var context string
+    var deterministic bool
+    var hash crypto.Hash
+
+    if opts != nil {
+        hash = opts.HashFunc()
+    }
+
+    if o, ok := opts.(*Options); ok {
+        context = o.Context
+        deterministic = o.Deterministic
+    }
+
+    msg, err := computeMessage(message, context, hash)
+    if err != nil {
+        return nil, err
+    }
+
+    addRand := priv.PublicKey.seed
+    if !deterministic {
+        addRand = make([]byte, priv.Params.n)
+        if _, err := io.ReadFull(rand, addRand); err != nil {
+            return nil, err
+        }
+    }
+
+    return priv.signInternal(msg, addRand), nil
+}
+
+// Sign signs the message with priv, using SLH-DSA and an empty context.
+func Sign(rand io.Reader, priv *PrivateKey, message []byte) ([]byte, error) {
+    return priv.Sign(rand, message, crypto.Hash(0))
+}
+
+// Verify reports whether sig is a valid SLH-DSA signature of message
+// by pub with an empty context.
+func Verify(pub *PublicKey, message, sig []byte) bool {
+    return VerifyWithOptions(pub, message, sig, crypto.Hash(0)) == nil
+}
+
+// VerifyWithOptions reports whether sig is a valid signature of message
+// by pub. A valid signature is indicated by returning a nil error.
+func VerifyWithOptions(pub *PublicKey, message, sig []byte, opts crypto.SignerOpts) error {
+    var context string
+    var hash crypto.Hash
+
+    if opts != nil {
+        hash = opts.HashFunc()
+    }
+
+    if o, ok := opts.(*Options); ok {
+        context = o.Context
+    }
+
+    msg, err := computeMessage(message, context, hash)
+    if err != nil {
+        return err
+    }
+
+    if !pub.verifyInternal(msg, sig) {
+        return ErrInvalidSignature
+    }
+
+    return nil
+}
+
+// DER encoded OIDs of the hash functions for HashSLH-DSA,
+// FIPS 205 Algorithm 23.
+var hashOIDs = map[crypto.Hash][]byte{
+    crypto.SHA256:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01},
+    crypto.SHA384:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02},
+    crypto.SHA512:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03},
+    crypto.SHA224:     {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x04},
+    crypto.SHA512_224: {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x05},
+    crypto.SHA512_256: {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x06},
+    crypto.SHA3_224:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x07},
+    crypto.SHA3_256:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x08},
+    crypto.SHA3_384:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x09},
+    crypto.SHA3_512:   {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x0a},
+}
+
+// computeMessage computes M' for SLH-DSA when hash is zero, and for
+// HashSLH-DSA with the digest otherwise, FIPS 205 Algorithms 22 to 25.
+func computeMessage(msg []byte, context string, hash crypto.Hash) ([]byte, error) {
+    if len(context) > ContextMaxSize {
+        return nil, ErrContextTooLong
+    }
+
+    if hash == crypto.Hash(0) {
+        m := make([]byte, 0, 2+len(context)+len(msg))
+        m = append(m, 0, byte(len(context)))
+        m = append(m, context...)
+        return append(m, msg...), nil
+    }
+
+    oid, ok := hashOIDs[hash]
+    if !ok {
+        return nil, ErrInvalidHash
+    }
+
+    if len(msg) != hash.Size() {
+        return nil, ErrInvalidDigest
+    }
+
+    m := make([]byte, 0, 2+len(context)+len(oid)+len(msg))
+    m = append(m, 1, byte(len(context)))
+    m = append(m, context...)
+    m = append(m, oid...)
+    return append(m, msg...), nil
+}
+
+// splitDigest splits the message digest into the FORS message,
+// the hypertree index and the leaf index, FIPS 205 Algorithm 19
+// lines 7 to 12.
+func (p *Params) splitDigest(digest []byte) (md []byte, idxTree uint64, idxLeaf uint32) {
+    mdLen := (p.k*p.a + 7) / 8
+    treeBits := p.h - p.hp
+    treeLen := (treeBits + 7) / 8
+    leafLen := (p.hp + 7) / 8
+
+    md = digest[:mdLen]
+
+    for _, b := range digest[mdLen : mdLen+treeLen] {
+        idxTree = idxTree<<8 | uint64(b)
+    }
+    if treeBits < 64 {
+        idxTree &= 1<<uint(treeBits) - 1
+    }
+
+    for _, b := range digest[mdLen+treeLen : mdLen+treeLen+leafLen] {
+        idxLeaf = idxLeaf<<8 | uint32(b)
+    }
+    idxLeaf &= 1<<uint(p.hp) - 1
+
+    return
+}
+
+// signInternal implements slh_sign_internal, FIPS 205 Algorithm 19.
+func (priv *PrivateKey) signInternal(msg, addRand []byte) []byte {
+    p := priv.Params
+    n := p.n
+    pkSeed := priv.PublicKey.seed
+
+    sig := make([]byte, p.SignatureSize())
+
+    r := p.prfMsg(priv.prf, addRand, msg)
+    copy(sig, r)
+
+    digest := p.hashMsg(r, pkSeed, priv.root, msg)
+    md, idxTree, idxLeaf := p.splitDigest(digest)
+
+    var adrs address
+    adrs.setTreeAddress(idxTree)
+    adrs.setTypeAndClear(addrForsTree)
+    adrs.setKeyPairAddress(idxLeaf)
+
+    h := newHasher(p, pkSeed, priv.seed)
+
+    sigFors := sig[n:n+p.forsSigSize()]
+    h.forsSign(sigFors, md, adrs)
+
+    pkFors := make([]byte, n)
+    h.forsPkFromSig(pkFors, sigFors, md, adrs)
+
+    h.htSign(sig[n+p.forsSigSize():], pkFors, idxTree, idxLeaf)
+
+    return sig
+}
+
+// verifyInternal implements slh_verify_internal, FIPS 205 Algorithm 20.
+func (pub *PublicKey) verifyInternal(msg, sig []byte) bool {
+    p := pub.Params
+    n := p.n
+
+    if len(sig) != p.SignatureSize() {
+        return false
+    }
+
+    r := sig[:n]
+
+    digest := p.hashMsg(r, pub.seed, pub.root, msg)
+    md, idxTree, idxLeaf := p.splitDigest(digest)
+
+    var adrs address
+    adrs.setTreeAddress(idxTree)
+    adrs.setTypeAndClear(addrForsTree)
+    adrs.setKeyPairAddress(idxLeaf)
+
+    h := newHasher(p, pub.seed, nil)
+
+    pkFors := make([]byte, n)
+    h.forsPkFromSig(pkFors, sig[n:n+p.forsSigSize()], md, adrs)
+
+    return h.htVerify(pkFors, sig[n+p.forsSigSize():], pub.root, idxTree, idxLeaf)
+}
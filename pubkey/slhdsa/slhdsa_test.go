@@ -0,0 +1,452 @@
+package slhdsa
+
+import (
+    "os"
+    "bytes"
+    "testing"
+    "crypto"
+    "crypto/rand"
+    "strings"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "compress/gzip"
+    "path/filepath"
+)
+
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalJSON(data []byte) (err error) {
+    var s string
+    if err = json.Unmarshal(data, &s); err != nil {
+        return err
+    }
+
+    *b, err = hex.DecodeString(s)
+    return err
+}
+
+type acvp struct {
+    groups  []json.RawMessage
+    results map[int]json.RawMessage
+}
+
+func readACVPGroups(t *testing.T, path string) []json.RawMessage {
+    f, err := os.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+
+    r, err := gzip.NewReader(f)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var file struct {
+        TestGroups []json.RawMessage `json:"testGroups"`
+    }
+    if err := json.NewDecoder(r).Decode(&file); err != nil {
+        t.Fatal(err)
+    }
+
+    return file.TestGroups
+}
+
+func readACVP(t *testing.T, name string) *acvp {
+    dir := filepath.Join("testdata", name)
+
+    a := &acvp{
+        groups:  readACVPGroups(t, filepath.Join(dir, "prompt.json.gz")),
+        results: make(map[int]json.RawMessage),
+    }
+
+    for _, rawGroup := range readACVPGroups(t, filepath.Join(dir, "expectedResults.json.gz")) {
+        var group struct {
+            Tests []json.RawMessage `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        for _, rawTest := range group.Tests {
+            var tc struct {
+                TcID int `json:"tcId"`
+            }
+            if err := json.Unmarshal(rawTest, &tc); err != nil {
+                t.Fatal(err)
+            }
+
+            a.results[tc.TcID] = rawTest
+        }
+    }
+
+    return a
+}
+
+func (a *acvp) result(t *testing.T, tcID int, result any) {
+    raw, ok := a.results[tcID]
+    if !ok {
+        t.Fatalf("missing result: %d", tcID)
+    }
+
+    if err := json.Unmarshal(raw, result); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// NIST ACVP ML-DSA-keyGen-FIPS204
+
+var acvpHashes = map[string]crypto.Hash{
+    "SHA2-224":     crypto.SHA224,
+    "SHA2-256":     crypto.SHA256,
+    "SHA2-384":     crypto.SHA384,
+    "SHA2-512":     crypto.SHA512,
+    "SHA2-512/224": crypto.SHA512_224,
+    "SHA2-512/256": crypto.SHA512_256,
+    "SHA3-224":     crypto.SHA3_224,
+    "SHA3-256":     crypto.SHA3_256,
+    "SHA3-384":     crypto.SHA3_384,
+    "SHA3-512":     crypto.SHA3_512,
+}
+
+// acvpMessage returns the message and the options of a test case
+// of the external interface.
+func acvpMessage(t *testing.T, preHash, hashAlg string, msg []byte, ctx []byte) ([]byte, *Options) {
+    opts := &Options{Context: string(ctx)}
+    if preHash != "preHash" {
+        return msg, opts
+    }
+
+    hash, ok := acvpHashes[hashAlg]
+    if !ok {
+        t.Fatalf("unsupported hash: %s", hashAlg)
+    }
+
+    h := hash.New()
+    h.Write(msg)
+
+    opts.Hash = hash
+
+    return h.Sum(nil), opts
+}
+
+func skipSlowParams(t *testing.T, name string) {
+    if testing.Short() && strings.HasSuffix(name, "s") {
+        t.Skip("skipping slow parameter set in short mode")
+    }
+}
+
+// NIST ACVP SLH-DSA-keyGen-FIPS205
+func Test_ACVP_KeyGen(t *testing.T) {
+    vectors := readACVP(t, "SLH-DSA-keyGen-FIPS205")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet string `json:"parameterSet"`
+            Tests        []struct {
+                TcID   int      `json:"tcId"`
+                SkSeed hexBytes `json:"skSeed"`
+                SkPrf  hexBytes `json:"skPrf"`
+                PkSeed hexBytes `json:"pkSeed"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        t.Run(params.Name, func(t *testing.T) {
+            skipSlowParams(t, params.Name)
+
+            for _, tc := range group.Tests {
+                var result struct {
+                    Pk hexBytes `json:"pk"`
+                    Sk hexBytes `json:"sk"`
+                }
+                vectors.result(t, tc.TcID, &result)
+
+                var seed []byte
+                seed = append(seed, tc.SkSeed...)
+                seed = append(seed, tc.SkPrf...)
+                seed = append(seed, tc.PkSeed...)
+
+                priv, err := GenerateKey(bytes.NewReader(seed), params)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                if !bytes.Equal(priv.PublicKey.Bytes(), result.Pk) {
+                    t.Fatalf("tc=%d: pk got %x, want %x", tc.TcID, priv.PublicKey.Bytes(), result.Pk)
+                }
+
+                if !bytes.Equal(priv.Bytes(), result.Sk) {
+                    t.Fatalf("tc=%d: sk got %x, want %x", tc.TcID, priv.Bytes(), result.Sk)
+                }
+            }
+        })
+    }
+}
+
+// NIST ACVP SLH-DSA-sigGen-FIPS205
+func Test_ACVP_SigGen(t *testing.T) {
+    vectors := readACVP(t, "SLH-DSA-sigGen-FIPS205")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            TgID               int    `json:"tgId"`
+            ParameterSet       string `json:"parameterSet"`
+            Deterministic      bool   `json:"deterministic"`
+            SignatureInterface string `json:"signatureInterface"`
+            PreHash            string `json:"preHash"`
+            Tests              []struct {
+                TcID                 int      `json:"tcId"`
+                Sk                   hexBytes `json:"sk"`
+                Message              hexBytes `json:"message"`
+                Context              hexBytes `json:"context"`
+                HashAlg              string   `json:"hashAlg"`
+                AdditionalRandomness hexBytes `json:"additionalRandomness"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        t.Run(params.Name, func(t *testing.T) {
+            skipSlowParams(t, params.Name)
+
+            for _, tc := range group.Tests {
+                var result struct {
+                    Signature hexBytes `json:"signature"`
+                }
+                vectors.result(t, tc.TcID, &result)
+
+                priv, err := NewPrivateKey(params, tc.Sk)
+                if err != nil {
+                    t.Fatal(err)
+                }
+
+                var sig []byte
+                if group.SignatureInterface == "internal" {
+                    addRand := priv.PublicKey.seed
+                    if !group.Deterministic {
+                        addRand = tc.AdditionalRandomness
+                    }
+
+                    sig = priv.signInternal(tc.Message, addRand)
+                } else {
+                    msg, opts := acvpMessage(t, group.PreHash, tc.HashAlg, tc.Message, tc.Context)
+                    opts.Deterministic = group.Deterministic
+
+                    sig, err = priv.Sign(bytes.NewReader(tc.AdditionalRandomness), msg, opts)
+                    if err != nil {
+                        t.Fatal(err)
+                    }
+
+                    if err := VerifyWithOptions(&priv.PublicKey, msg, sig, opts); err != nil {
+                        t.Fatalf("tc=%d: %v", tc.TcID, err)
+                    }
+                }
+
+                if !bytes.Equal(sig, result.Signature) {
+                    t.Fatalf("tg=%d, tc=%d: signature mismatch", group.TgID, tc.TcID)
+                }
+            }
+        })
+    }
+}
+
+// NIST ACVP SLH-DSA-sigVer-FIPS205
+func Test_ACVP_SigVer(t *testing.T) {
+    vectors := readACVP(t, "SLH-DSA-sigVer-FIPS205")
+
+    for _, rawGroup := range vectors.groups {
+        var group struct {
+            ParameterSet       string `json:"parameterSet"`
+            SignatureInterface string `json:"signatureInterface"`
+            PreHash            string `json:"preHash"`
+            Tests              []struct {
+                TcID      int      `json:"tcId"`
+                Pk        hexBytes `json:"pk"`
+                Message   hexBytes `json:"message"`
+                Context   hexBytes `json:"context"`
+                HashAlg   string   `json:"hashAlg"`
+                Signature hexBytes `json:"signature"`
+            } `json:"tests"`
+        }
+        if err := json.Unmarshal(rawGroup, &group); err != nil {
+            t.Fatal(err)
+        }
+
+        params, err := GetParams(group.ParameterSet)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        for _, tc := range group.Tests {
+            var result struct {
+                TestPassed bool `json:"testPassed"`
+            }
+            vectors.result(t, tc.TcID, &result)
+
+            pub, err := NewPublicKey(params, tc.Pk)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            var ok bool
+            if group.SignatureInterface == "internal" {
+                ok = pub.verifyInternal(tc.Message, tc.Signature)
+            } else {
+                msg, opts := acvpMessage(t, group.PreHash, tc.HashAlg, tc.Message, tc.Context)
+                ok = VerifyWithOptions(pub, msg, tc.Signature, opts) == nil
+            }
+
+            if ok != result.TestPassed {
+                t.Fatalf("tc=%d: verify want %v", tc.TcID, result.TestPassed)
+            }
+        }
+    }
+}
+
+func Test_SignVerify(t *testing.T) {
+    msg := []byte("test-pass")
+    digest := sha256.Sum256(msg)
+
+    // the small parameter sets are covered by the ACVP tests
+    fast := []*Params{
+        SHA2_128f(), SHA2_192f(), SHA2_256f(),
+        SHAKE_128f(), SHAKE_192f(), SHAKE_256f(),
+    }
+
+    for _, params := range fast {
+        t.Run(params.Name, func(t *testing.T) {
+            priv, err := GenerateKey(rand.Reader, params)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            pub := priv.Public().(*PublicKey)
+
+            if len(pub.Bytes()) != params.PublicKeySize() {
+                t.Errorf("%s: public key size fail", params)
+            }
+
+            if len(priv.Bytes()) != params.PrivateKeySize() {
+                t.Errorf("%s: private key size fail", params)
+            }
+
+            // SLH-DSA
+            sig, err := Sign(rand.Reader, priv, msg)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if len(sig) != params.SignatureSize() {
+                t.Errorf("%s: signature size fail", params)
+            }
+
+            if !Verify(pub, msg, sig) {
+                t.Errorf("%s: Verify fail", params)
+            }
+
+            if Verify(pub, []byte("test-pass2"), sig) {
+                t.Errorf("%s: Verify should fail with other message", params)
+            }
+
+            // SLH-DSA with context
+            opts := &Options{Context: "test-context"}
+
+            sig, err = priv.Sign(rand.Reader, msg, opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if err := VerifyWithOptions(pub, msg, sig, opts); err != nil {
+                t.Errorf("%s: VerifyWithOptions fail: %v", params, err)
+            }
+
+            if Verify(pub, msg, sig) {
+                t.Errorf("%s: Verify should fail with other context", params)
+            }
+
+            // HashSLH-DSA
+            opts = &Options{Hash: crypto.SHA256, Context: "test-context"}
+
+            sig, err = priv.Sign(rand.Reader, digest[:], opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if err := VerifyWithOptions(pub, digest[:], sig, opts); err != nil {
+                t.Errorf("%s: HashSLH-DSA VerifyWithOptions fail: %v", params, err)
+            }
+
+            if err := VerifyWithOptions(pub, digest[:], sig, &Options{Context: "test-context"}); err == nil {
+                t.Errorf("%s: HashSLH-DSA signature should not verify as SLH-DSA", params)
+            }
+
+            if _, err := priv.Sign(rand.Reader, msg, opts); err != ErrInvalidDigest {
+                t.Errorf("%s: HashSLH-DSA should check digest length", params)
+            }
+        })
+    }
+}
+
+func Test_Deterministic(t *testing.T) {
+    msg := []byte("test-pass")
+
+    seed := make([]byte, SHAKE_128f().SeedSize())
+    for i := range seed {
+        seed[i] = byte(i)
+    }
+
+    priv, err := NewKeyFromSeed(SHAKE_128f(), seed)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    opts := &Options{Deterministic: true}
+
+    sig1, _ := priv.Sign(nil, msg, opts)
+    sig2, _ := priv.Sign(nil, msg, opts)
+    if !bytes.Equal(sig1, sig2) {
+        t.Error("deterministic signatures mismatch")
+    }
+
+    sig3, _ := priv.Sign(rand.Reader, msg, nil)
+    if bytes.Equal(sig1, sig3) {
+        t.Error("randomized signature should differ")
+    }
+
+    if !Verify(&priv.PublicKey, msg, sig3) {
+        t.Error("randomized signature Verify fail")
+    }
+
+    priv2, err := NewPrivateKey(SHAKE_128f(), priv.Bytes())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if !priv.Equal(priv2) {
+        t.Error("NewPrivateKey fail")
+    }
+}
+
+func Test_ContextTooLong(t *testing.T) {
+    priv, _ := GenerateKey(rand.Reader, SHA2_128f())
+
+    opts := &Options{Context: string(make([]byte, ContextMaxSize+1))}
+    if _, err := priv.Sign(rand.Reader, []byte("test-pass"), opts); err != ErrContextTooLong {
+        t.Error("long context should be rejected")
+    }
+}
@@ -0,0 +1,128 @@
+package slhdsa
+
+// WOTS+ with w = 16, FIPS 205 section 5.
+const (
+    lgw  = 4
+    w    = 1 << lgw
+    len2 = 3
+)
+
+// wotsLen returns len = len₁ + len₂, with len₁ = 2n.
+func (p *Params) wotsLen() int {
+    return 2*p.n + len2
+}
+
+// chain implements the chaining function, FIPS 205 Algorithm 5.
+func (h *hasher) chain(out, x []byte, i, s uint32, adrs *address) {
+    copy(out, x)
+    for j := i; j < i+s; j++ {
+        adrs.setHashAddress(j)
+        h.f(out, adrs, out)
+    }
+}
+
+// wotsMsg computes the base-w digits of the message and of its
+// checksum, FIPS 205 Algorithm 7 lines 1 to 9.
+func (p *Params) wotsMsg(m []byte) []uint32 {
+    len1 := 2 * p.n
+
+    msg := make([]uint32, len1, len1+len2)
+    base2b(msg, m, lgw)
+
+    var csum uint32
+    for i := 0; i < len1; i++ {
+        csum += w - 1 - msg[i]
+    }
+
+    // left shift by (8 - ((len₂ ⋅ lg_w) mod 8)) mod 8 = 4
+    csum <<= 4
+
+    c := []byte{byte(csum >> 8), byte(csum)}
+
+    msg = msg[:len1+len2]
+    base2b(msg[len1:], c, lgw)
+
+    return msg
+}
+
+// wotsPkGen generates a WOTS+ public key, FIPS 205 Algorithm 6.
+func (h *hasher) wotsPkGen(out []byte, adrs address) {
+    n, l := h.p.n, h.p.wotsLen()
+
+    skAdrs := adrs
+    skAdrs.setTypeAndClear(addrWotsPrf)
+    skAdrs.setKeyPairAddress(adrs.getKeyPairAddress())
+
+    sk := make([]byte, n)
+    tmp := make([]byte, l*n)
+    for i := 0; i < l; i++ {
+        skAdrs.setChainAddress(uint32(i))
+        h.prf(sk, &skAdrs)
+
+        adrs.setChainAddress(uint32(i))
+        h.chain(tmp[i*n:(i+1)*n], sk, 0, w-1, &adrs)
+    }
+
+    pkAdrs := adrs
+    pkAdrs.setTypeAndClear(addrWotsPk)
+    pkAdrs.setKeyPairAddress(adrs.getKeyPairAddress())
+
+    h.t(out, &pkAdrs, tmp)
+}
+
+// wotsSign generates a WOTS+ signature on an n-byte message,
+// FIPS 205 Algorithm 7.
+func (h *hasher) wotsSign(sig, m []byte, adrs address) {
+    n := h.p.n
+    msg := h.p.wotsMsg(m)
+
+    skAdrs := adrs
+    skAdrs.setTypeAndClear(addrWotsPrf)
+    skAdrs.setKeyPairAddress(adrs.getKeyPairAddress())
+
+    sk := make([]byte, n)
+    for i := range msg {
+        skAdrs.setChainAddress(uint32(i))
+        h.prf(sk, &skAdrs)
+
+        adrs.setChainAddress(uint32(i))
+        h.chain(sig[i*n:(i+1)*n], sk, 0, msg[i], &adrs)
+    }
+}
+
+// wotsPkFromSig computes a WOTS+ public key from a message and its
+// signature, FIPS 205 Algorithm 8.
+func (h *hasher) wotsPkFromSig(out, sig, m []byte, adrs address) {
+    n := h.p.n
+    msg := h.p.wotsMsg(m)
+
+    tmp := make([]byte, len(msg)*n)
+    for i := range msg {
+        adrs.setChainAddress(uint32(i))
+        h.chain(tmp[i*n:(i+1)*n], sig[i*n:(i+1)*n], msg[i], w-1-msg[i], &adrs)
+    }
+
+    pkAdrs := adrs
+    pkAdrs.setTypeAndClear(addrWotsPk)
+    pkAdrs.setKeyPairAddress(adrs.getKeyPairAddress())
+
+    h.t(out, &pkAdrs, tmp)
+}
+
+// base2b splits x into len(out) b-bit integers, FIPS 205 Algorithm 4.
+func base2b(out []uint32, x []byte, b uint) {
+    in := 0
+    bits := uint(0)
+    total := uint32(0)
+
+    for i := range out {
+        for bits < b {
+            total = (total << 8) | uint32(x[in])
+            in++
+            bits += 8
+        }
+
+        bits -= b
+        out[i] = (total >> bits) & (1<<b - 1)
+    }
+}
@@ -0,0 +1,134 @@
+package slhdsa
+
+import (
+    "crypto/subtle"
+)
+
+// xmssNode computes the root of a Merkle subtree of WOTS+ public keys,
+// FIPS 205 Algorithm 9.
+func (h *hasher) xmssNode(out []byte, i, z uint32, adrs address) {
+    if z == 0 {
+        adrs.setTypeAndClear(addrWotsHash)
+        adrs.setKeyPairAddress(i)
+        h.wotsPkGen(out, adrs)
+        return
+    }
+
+    n := h.p.n
+
+    node := make([]byte, 2*n)
+    h.xmssNode(node[:n], 2*i, z-1, adrs)
+    h.xmssNode(node[n:], 2*i+1, z-1, adrs)
+
+    adrs.setTypeAndClear(addrTree)
+    adrs.setTreeHeight(z)
+    adrs.setTreeIndex(i)
+    h.h(out, &adrs, node[:n], node[n:])
+}
+
+// xmssSign generates an XMSS signature, FIPS 205 Algorithm 10.
+// The signature is the WOTS+ signature followed by the authentication path.
+func (h *hasher) xmssSign(sig, m []byte, idx uint32, adrs address) {
+    n, hp := h.p.n, h.p.hp
+    wotsSize := h.p.wotsLen() * n
+
+    auth := sig[wotsSize:]
+    for j := 0; j < hp; j++ {
+        k := (idx >> uint(j)) ^ 1
+        h.xmssNode(auth[j*n:(j+1)*n], k, uint32(j), adrs)
+    }
+
+    adrs.setTypeAndClear(addrWotsHash)
+    adrs.setKeyPairAddress(idx)
+    h.wotsSign(sig[:wotsSize], m, adrs)
+}
+
+// xmssPkFromSig computes an XMSS public key from an XMSS signature,
+// FIPS 205 Algorithm 11.
+func (h *hasher) xmssPkFromSig(out []byte, idx uint32, sig, m []byte, adrs address) {
+    n, hp := h.p.n, h.p.hp
+    wotsSize := h.p.wotsLen() * n
+
+    node := make([]byte, n)
+
+    adrs.setTypeAndClear(addrWotsHash)
+    adrs.setKeyPairAddress(idx)
+    h.wotsPkFromSig(node, sig[:wotsSize], m, adrs)
+
+    adrs.setTypeAndClear(addrTree)
+    adrs.setTreeIndex(idx)
+
+    auth := sig[wotsSize:]
+    for k := 0; k < hp; k++ {
+        adrs.setTreeHeight(uint32(k + 1))
+
+        treeIndex := adrs.getTreeIndex()
+        if (idx>>uint(k))&1 == 0 {
+            adrs.setTreeIndex(treeIndex / 2)
+            h.h(node, &adrs, node, auth[k*n:(k+1)*n])
+        } else {
+            adrs.setTreeIndex((treeIndex - 1) / 2)
+            h.h(node, &adrs, auth[k*n:(k+1)*n], node)
+        }
+    }
+
+    copy(out, node)
+}
+
+// xmssSigSize returns the size of an XMSS signature.
+func (p *Params) xmssSigSize() int {
+    return (p.wotsLen() + p.hp) * p.n
+}
+
+// htSign generates a hypertree signature, FIPS 205 Algorithm 12.
+func (h *hasher) htSign(sig, m []byte, idxTree uint64, idxLeaf uint32) {
+    p := h.p
+    size := p.xmssSigSize()
+
+    var adrs address
+    adrs.setTreeAddress(idxTree)
+
+    h.xmssSign(sig[:size], m, idxLeaf, adrs)
+
+    root := make([]byte, p.n)
+    h.xmssPkFromSig(root, idxLeaf, sig[:size], m, adrs)
+
+    for j := 1; j < p.d; j++ {
+        idxLeaf = uint32(idxTree & (1<<uint(p.hp) - 1))
+        idxTree >>= uint(p.hp)
+
+        adrs.setLayerAddress(uint32(j))
+        adrs.setTreeAddress(idxTree)
+
+        sigTmp := sig[j*size:(j+1)*size]
+        h.xmssSign(sigTmp, root, idxLeaf, adrs)
+
+        if j < p.d-1 {
+            h.xmssPkFromSig(root, idxLeaf, sigTmp, root, adrs)
+        }
+    }
+}
+
+// htVerify verifies a hypertree signature, FIPS 205 Algorithm 13.
+func (h *hasher) htVerify(m, sig, pkRoot []byte, idxTree uint64, idxLeaf uint32) bool {
+    p := h.p
+    size := p.xmssSigSize()
+
+    var adrs address
+    adrs.setTreeAddress(idxTree)
+
+    node := make([]byte, p.n)
+    h.xmssPkFromSig(node, idxLeaf, sig[:size], m, adrs)
+
+    for j := 1; j < p.d; j++ {
+        idxLeaf = uint32(idxTree & (1<<uint(p.hp) - 1))
+        idxTree >>= uint(p.hp)
+
+        adrs.setLayerAddress(uint32(j))
+        adrs.setTreeAddress(idxTree)
+
+        h.xmssPkFromSig(node, idxLeaf, sig[j*size:(j+1)*size], node, adrs)
+    }
+
+    return subtle.ConstantTimeCompare(node, pkRoot) == 1
+}
@@ -0,0 +1,145 @@
+package stateful
+
+import (
+    "os"
+    "errors"
+    "runtime"
+    "path/filepath"
+)
+
+var (
+    ErrExhausted = errors.New("go-cryptobin/stateful: private key is exhausted")
+    ErrNoState   = errors.New("go-cryptobin/stateful: no state stored")
+)
+
+// Store persists the serialized private key of a stateful hash-based
+// signature scheme, LMS/HSS or XMSS/XMSS^MT. Save must only return
+// once the state is durable, a signature is released only after the
+// index it uses has been saved.
+type Store interface {
+    // Load returns the last saved state.
+    Load() ([]byte, error)
+
+    // Save replaces the state.
+    Save(state []byte) error
+}
+
+// FileStore is a Store keeping the state in a file. The file is
+// replaced atomically: the state is written and synced to a temporary
+// file in the same directory, which is then renamed over the old file
+// and the directory is synced.
+type FileStore struct {
+    path string
+}
+
+// NewFileStore returns a FileStore using the file at path.
+func NewFileStore(path string) *FileStore {
+    return &FileStore{
+        path: path,
+    }
+}
+
+// Path returns the path of the state file.
+func (s *FileStore) Path() string {
+    return s.path
+}
+
+// Load reads the state file.
+func (s *FileStore) Load() ([]byte, error) {
+    state, err := os.ReadFile(s.path)
+    if err != nil {
+        if errors.Is(err, os.ErrNotExist) {
+            return nil, ErrNoState
+        }
+
+        return nil, err
+    }
+
+    return state, nil
+}
+
+// Save atomically replaces the state file.
+func (s *FileStore) Save(state []byte) (err error) {
+    dir := filepath.Dir(s.path)
+
+    f, err := os.CreateTemp(dir, "."+filepath.Base(s.path)+".tmp-*")
+    if err != nil {
+        return err
+    }
+
+    tmp := f.Name()
+    defer func() {
+        if err != nil {
+            os.Remove(tmp)
+        }
+    }()
+
+    if _, err = f.Write(state); err != nil {
+        f.Close()
+        return err
+    }
+
+    if err = f.Sync(); err != nil {
+        f.Close()
+        return err
+    }
+
+    if err = f.Close(); err != nil {
+        return err
+    }
+
+    if err = os.Rename(tmp, s.path); err != nil {
+        return err
+    }
+
+    return syncDir(dir)
+}
+
+// syncDir makes the rename durable. Directories can not be synced
+// on windows, where the rename is durable already.
+func syncDir(dir string) error {
+    if runtime.GOOS == "windows" {
+        return nil
+    }
+
+    d, err := os.Open(dir)
+    if err != nil {
+        return err
+    }
+    defer d.Close()
+
+    return d.Sync()
+}
+
+// Reservation tracks the indices reserved in a Store ahead of signing.
+// Next is the next index to use and Limit the first index that is not
+// reserved. Indices in [Next, Limit) may be used without saving, an
+// index at or after Limit needs a new reservation.
+type Reservation struct {
+    Next  uint64
+    Limit uint64
+}
+
+// NeedsReserve reports whether the next index is not reserved yet.
+func (r *Reservation) NeedsReserve() bool {
+    return r.Next >= r.Limit
+}
+
+// Reserve returns the limit of a new reservation of batch indices,
+// capped at max, the number of usable indices of the key.
+func (r *Reservation) Reserve(batch, max uint64) (uint64, error) {
+    if r.Next >= max {
+        return 0, ErrExhausted
+    }
+
+    if batch == 0 {
+        batch = 1
+    }
+
+    limit := r.Next + batch
+    if limit > max || limit < r.Next {
+        limit = max
+    }
+
+    return limit, nil
+}
@@ -0,0 +1,56 @@
+package stateful
+
+import (
+    "os"
+    "testing"
+    "path/filepath"
+
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func Test_FileStore(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    dir := t.TempDir()
+    store := NewFileStore(filepath.Join(dir, "key.state"))
+
+    _, err := store.Load()
+    assertEqual(err, ErrNoState, "Load empty")
+
+    assertError(store.Save([]byte("state 1")), "Save")
+    assertError(store.Save([]byte("state 2")), "Save")
+
+    state, err := store.Load()
+    assertError(err, "Load")
+    assertEqual(state, []byte("state 2"), "Load")
+
+    // no temporary file is left
+    files, err := os.ReadDir(dir)
+    assertError(err, "ReadDir")
+    assertEqual(len(files), 1, "ReadDir")
+}
+
+func Test_Reservation(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    var res Reservation
+    assertBool(res.NeedsReserve(), "NeedsReserve")
+
+    limit, err := res.Reserve(10, 32)
+    assertError(err, "Reserve")
+    assertEqual(limit, uint64(10), "Reserve")
+
+    res.Next, res.Limit = 30, 30
+
+    limit, err = res.Reserve(10, 32)
+    assertError(err, "Reserve")
+    assertEqual(limit, uint64(32), "Reserve capped")
+
+    res.Next = 32
+
+    _, err = res.Reserve(10, 32)
+    assertEqual(err, ErrExhausted, "Reserve exhausted")
+}
@@ -72,6 +72,16 @@ func (params *Params) SignBytes() int {
     return int(params.signBytes)
 }
 
+// PublicKeyBytes the length of the public key based on a given parameter set
+func (params *Params) PublicKeyBytes() int {
+    return int(params.pubBytes)
+}
+
+// PrivateKeyBytes the length of the private key based on a given parameter set
+func (params *Params) PrivateKeyBytes() int {
+    return int(params.prvBytes)
+}
+
 func (params *Params) Hash() hash.Hash {
     return params.hash()
 }
@@ -0,0 +1,146 @@
+package xmss
+
+import (
+    "sync"
+    "errors"
+
+    "github.com/deatil/go-cryptobin/pubkey/stateful"
+)
+
+// Signer signs with a PrivateKey whose state is kept in a
+// stateful.Store. Indices are reserved in batches: before the first
+// index of a batch is used, the key is saved with its index moved
+// past the whole batch. After a crash the reloaded key continues
+// after the reserved batch, so an index is never used twice at the
+// cost of skipping the unused rest of the batch.
+//
+// The stored key may start with a prefix, such as the 4 bytes OID
+// used by the xmss and xmssmt packages.
+type Signer struct {
+    mu     sync.Mutex
+    params *Params
+    prefix int
+    key    *PrivateKey
+    store  stateful.Store
+    batch  uint64
+    res    stateful.Reservation
+}
+
+// NewSigner returns a signer for key, the private key last saved in
+// store, reserving batch indices at a time. The first prefix bytes of
+// key.D are not part of the XMSS private key.
+func NewSigner(params *Params, prefix int, key *PrivateKey, store stateful.Store, batch uint64) (*Signer, error) {
+    if params == nil {
+        return nil, errors.New("xmss: params is nil")
+    }
+
+    if prefix < 0 || len(key.D) != prefix+int(params.prvBytes) {
+        return nil, errors.New("xmss: invalid private key")
+    }
+
+    s := &Signer{
+        params: params,
+        prefix: prefix,
+        key:    &PrivateKey{
+            D: append([]byte(nil), key.D...),
+        },
+        store:  store,
+        batch:  batch,
+    }
+
+    // nothing is reserved yet
+    s.res.Next = s.index()
+    s.res.Limit = s.res.Next
+
+    return s, nil
+}
+
+// PublicKey returns the public key of the signer, with the prefix of
+// the stored private key.
+func (s *Signer) PublicKey() *PublicKey {
+    pub := s.xmssKey().PublicKey(s.params)
+
+    x := make([]byte, 0, s.prefix+len(pub.X))
+    x = append(x, s.key.D[:s.prefix]...)
+    x = append(x, pub.X...)
+
+    return &PublicKey{
+        X: x,
+    }
+}
+
+// Remaining returns the number of signatures left.
+func (s *Signer) Remaining() uint64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    max := s.max()
+    if s.res.Next >= max {
+        return 0
+    }
+
+    return max - s.res.Next
+}
+
+// Sign signs m like PrivateKey.Sign, after the index used has been
+// reserved in the store.
+func (s *Signer) Sign(m []byte) ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if s.res.NeedsReserve() {
+        if err := s.reserve(); err != nil {
+            return nil, err
+        }
+    }
+
+    sig, err := s.xmssKey().Sign(s.params, m)
+    if err != nil {
+        return nil, err
+    }
+
+    s.res.Next = s.index()
+
+    return sig, nil
+}
+
+// reserve saves the key with its index moved to the end of a new batch.
+func (s *Signer) reserve() error {
+    limit, err := s.res.Reserve(s.batch, s.max())
+    if err != nil {
+        return err
+    }
+
+    data := append([]byte(nil), s.key.D...)
+
+    indexBytes := int(s.params.indexBytes)
+    copy(data[s.prefix:s.prefix+indexBytes], toBytes(int(limit), indexBytes))
+
+    if err = s.store.Save(data); err != nil {
+        return errors.New("xmss: save state fail: " + err.Error())
+    }
+
+    s.res.Limit = limit
+
+    return nil
+}
+
+// xmssKey returns the private key without the prefix.
+func (s *Signer) xmssKey() *PrivateKey {
+    return &PrivateKey{
+        D: s.key.D[s.prefix:],
+    }
+}
+
+// index returns the next index of the private key.
+func (s *Signer) index() uint64 {
+    indexBytes := int(s.params.indexBytes)
+
+    return fromBytes(s.key.D[s.prefix:s.prefix+indexBytes], indexBytes)
+}
+
+// max returns the number of usable indices. Sign wipes the private
+// key when the last index is reached, so that index is not usable.
+func (s *Signer) max() uint64 {
+    return (uint64(1) << uint(s.params.fullHeight)) - 1
+}
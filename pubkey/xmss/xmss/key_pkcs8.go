@@ -0,0 +1,192 @@
+package xmss
+
+import (
+    "fmt"
+    "errors"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+
+    "github.com/deatil/go-cryptobin/pubkey/xmss"
+)
+
+var (
+    // id-alg-xmss-hashsig, RFC 9802
+    oidXMSSHashSig = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 34}
+)
+
+// 私钥 - 包装
+type pkcs8 struct {
+    Version    int
+    Algo       pkix.AlgorithmIdentifier
+    PrivateKey []byte
+    Attributes []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// 公钥 - 包装
+type pkixPublicKey struct {
+    Algo      pkix.AlgorithmIdentifier
+    BitString asn1.BitString
+}
+
+// 公钥信息 - 解析
+type publicKeyInfo struct {
+    Raw       asn1.RawContent
+    Algorithm pkix.AlgorithmIdentifier
+    PublicKey asn1.BitString
+}
+
+// 包装公钥, 带 OID 前缀的公钥数据直接放入 BIT STRING
+func MarshalPublicKey(key *xmss.PublicKey) ([]byte, error) {
+    if err := checkPublicKey(key); err != nil {
+        return nil, err
+    }
+
+    publicKeyBytes := key.X
+
+    pkix := pkixPublicKey{
+        Algo: pkix.AlgorithmIdentifier{
+            Algorithm: oidXMSSHashSig,
+        },
+        BitString: asn1.BitString{
+            Bytes:     publicKeyBytes,
+            BitLength: 8 * len(publicKeyBytes),
+        },
+    }
+
+    return asn1.Marshal(pkix)
+}
+
+// 解析公钥
+func ParsePublicKey(derBytes []byte) (*xmss.PublicKey, error) {
+    var pki publicKeyInfo
+    rest, err := asn1.Unmarshal(derBytes, &pki)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    if !pki.Algorithm.Algorithm.Equal(oidXMSSHashSig) {
+        return nil, errors.New("xmss: unknown public key algorithm")
+    }
+
+    // the parameters field must be absent
+    if len(pki.Algorithm.Parameters.FullBytes) != 0 {
+        return nil, errors.New("xmss: invalid public key algorithm parameters")
+    }
+
+    pub := new(xmss.PublicKey)
+    pub.X = pki.PublicKey.RightAlign()
+
+    if err = checkPublicKey(pub); err != nil {
+        return nil, err
+    }
+
+    return pub, nil
+}
+
+// ====================
+
+// 包装私钥. 私钥包含签名索引, 导出后的私钥不能和原私钥同时使用
+func MarshalPrivateKey(key *xmss.PrivateKey) ([]byte, error) {
+    if err := checkPrivateKey(key); err != nil {
+        return nil, err
+    }
+
+    var privKey pkcs8
+    privKey.Algo = pkix.AlgorithmIdentifier{
+        Algorithm: oidXMSSHashSig,
+    }
+
+    var err error
+    privKey.PrivateKey, err = asn1.Marshal(key.D)
+    if err != nil {
+        return nil, fmt.Errorf("xmss: failed to marshal private key: %v", err)
+    }
+
+    return asn1.Marshal(privKey)
+}
+
+// 解析私钥
+func ParsePrivateKey(derBytes []byte) (*xmss.PrivateKey, error) {
+    var privKey pkcs8
+    _, err := asn1.Unmarshal(derBytes, &privKey)
+    if err != nil {
+        return nil, err
+    }
+
+    if !privKey.Algo.Algorithm.Equal(oidXMSSHashSig) {
+        return nil, errors.New("xmss: unknown private key algorithm")
+    }
+
+    var keyBytes []byte
+    rest, err := asn1.Unmarshal(privKey.PrivateKey, &keyBytes)
+    if err != nil {
+        return nil, fmt.Errorf("xmss: invalid private key: %v", err)
+    }
+
+    if len(rest) > 0 {
+        return nil, asn1.SyntaxError{Msg: "trailing data"}
+    }
+
+    priv := new(xmss.PrivateKey)
+    priv.D = keyBytes
+
+    if err = checkPrivateKey(priv); err != nil {
+        return nil, err
+    }
+
+    return priv, nil
+}
+
+// 检测公钥长度
+func checkPublicKey(pub *xmss.PublicKey) error {
+    if len(pub.X) < XMSS_OID_LEN {
+        return errors.New("xmss: invalid public key")
+    }
+
+    var oid uint32 = 0
+    var i uint32
+
+    for i = 0; i < XMSS_OID_LEN; i++ {
+        oid |= uint32(pub.X[XMSS_OID_LEN - i - 1]) << (i * 8)
+    }
+
+    params, err := NewParamsWithOid(oid)
+    if err != nil {
+        return err
+    }
+
+    if len(pub.X) != XMSS_OID_LEN + params.PublicKeyBytes() {
+        return errors.New("xmss: invalid public key")
+    }
+
+    return nil
+}
+
+// 检测私钥长度
+func checkPrivateKey(priv *xmss.PrivateKey) error {
+    if len(priv.D) < XMSS_OID_LEN {
+        return errors.New("xmss: invalid private key")
+    }
+
+    var oid uint32 = 0
+    var i uint32
+
+    for i = 0; i < XMSS_OID_LEN; i++ {
+        oid |= uint32(priv.D[XMSS_OID_LEN - i - 1]) << (i * 8)
+    }
+
+    params, err := NewParamsWithOid(oid)
+    if err != nil {
+        return err
+    }
+
+    if len(priv.D) != XMSS_OID_LEN + params.PrivateKeyBytes() {
+        return errors.New("xmss: invalid private key")
+    }
+
+    return nil
+}
@@ -0,0 +1,39 @@
+package xmss
+
+import (
+    "errors"
+
+    "github.com/deatil/go-cryptobin/pubkey/xmss"
+    "github.com/deatil/go-cryptobin/pubkey/stateful"
+)
+
+// NewSigner loads the private key from store and returns a signer
+// reserving batch indices at a time. The store must be initialized
+// with the D bytes of the private key.
+func NewSigner(store stateful.Store, batch uint64) (*xmss.Signer, error) {
+    data, err := store.Load()
+    if err != nil {
+        return nil, err
+    }
+
+    if len(data) < XMSS_OID_LEN {
+        return nil, errors.New("xmss: invalid private key")
+    }
+
+    var oid uint32 = 0
+    var i uint32
+
+    for i = 0; i < XMSS_OID_LEN; i++ {
+        oid |= uint32(data[XMSS_OID_LEN - i - 1]) << (i * 8)
+    }
+
+    params, err := NewParamsWithOid(oid)
+    if err != nil {
+        return nil, err
+    }
+
+    priv := new(xmss.PrivateKey)
+    priv.D = data
+
+    return xmss.NewSigner(params, XMSS_OID_LEN, priv, store, batch)
+}
@@ -0,0 +1,98 @@
+package xmssmt
+
+import (
+    "testing"
+    "crypto/rand"
+    "path/filepath"
+
+    "github.com/deatil/go-cryptobin/pubkey/xmss"
+    "github.com/deatil/go-cryptobin/pubkey/stateful"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func Test_Signer(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    prv, pub, err := GenerateKey(rand.Reader, 0x00000001)
+    assertError(err, "GenerateKey")
+
+    store := stateful.NewFileStore(filepath.Join(t.TempDir(), "xmssmt.state"))
+    assertError(store.Save(prv.D), "Save")
+
+    signer, err := NewSigner(store, 4)
+    assertError(err, "NewSigner")
+    assertEqual(signer.Remaining(), uint64(1<<20 - 1), "Remaining")
+    assertBool(signer.PublicKey().Equal(pub), "PublicKey")
+
+    msg := []byte("test data")
+
+    sig, err := signer.Sign(msg)
+    assertError(err, "Sign")
+
+    m := make([]byte, len(sig))
+    assertBool(Verify(pub, m, sig), "Verify")
+
+    // the whole batch is reserved before signing
+    data, err := store.Load()
+    assertError(err, "Load")
+    assertEqual(data[XMSS_OID_LEN:XMSS_OID_LEN+3], []byte{0, 0, 4}, "reserved index")
+
+    signer2, err := NewSigner(store, 4)
+    assertError(err, "NewSigner")
+    assertEqual(signer2.Remaining(), uint64(1<<20 - 5), "Remaining after reload")
+}
+
+func Test_Signer_Exhausted(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    prv, pub, err := GenerateKey(rand.Reader, 0x00000001)
+    assertError(err, "GenerateKey")
+
+    // only one index left
+    copy(prv.D[XMSS_OID_LEN:], []byte{0x0F, 0xFF, 0xFE})
+
+    store := stateful.NewFileStore(filepath.Join(t.TempDir(), "xmssmt.state"))
+    assertError(store.Save(prv.D), "Save")
+
+    signer, err := NewSigner(store, 4)
+    assertError(err, "NewSigner")
+    assertEqual(signer.Remaining(), uint64(1), "Remaining")
+
+    sig, err := signer.Sign([]byte("test data"))
+    assertError(err, "Sign")
+
+    m := make([]byte, len(sig))
+    assertBool(Verify(pub, m, sig), "Verify")
+
+    _, err = signer.Sign([]byte("test data"))
+    assertEqual(err, stateful.ErrExhausted, "Sign exhausted")
+}
+
+func Test_MarshalPKCS8(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    prv, pub, err := GenerateKey(rand.Reader, 0x00000001)
+    assertError(err, "GenerateKey")
+
+    pubDer, err := MarshalPublicKey(pub)
+    assertError(err, "MarshalPublicKey")
+
+    pub2, err := ParsePublicKey(pubDer)
+    assertError(err, "ParsePublicKey")
+    assertBool(pub2.Equal(pub), "ParsePublicKey")
+
+    prvDer, err := MarshalPrivateKey(prv)
+    assertError(err, "MarshalPrivateKey")
+
+    prv2, err := ParsePrivateKey(prvDer)
+    assertError(err, "ParsePrivateKey")
+    assertBool(prv2.Equal(prv), "ParsePrivateKey")
+
+    _, err = MarshalPublicKey(&xmss.PublicKey{X: pub.X[:10]})
+    assertBool(err != nil, "MarshalPublicKey short key")
+}
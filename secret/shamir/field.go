@@ -0,0 +1,37 @@
+package shamir
+
+// Arithmetic in GF(2^8) modulo x^8 + x^4 + x^3 + x + 1 (0x11b).
+// Multiplication and inversion do not branch on their operands.
+
+// gfAdd returns a + b, which is also a - b.
+func gfAdd(a, b uint8) uint8 {
+    return a ^ b
+}
+
+// gfMul returns a * b. Each bit of b selects a multiple of a, the
+// multiples being doubled modulo the polynomial with masks.
+func gfMul(a, b uint8) uint8 {
+    var p uint8
+
+    for i := 0; i < 8; i++ {
+        p ^= a & -(b & 1)
+
+        carry := -(a >> 7)
+        a = (a << 1) ^ (carry & 0x1b)
+        b >>= 1
+    }
+
+    return p
+}
+
+// gfInv returns a^-1 = a^254, 0 for a = 0.
+func gfInv(a uint8) uint8 {
+    // 254 = 0b11111110
+    r := uint8(1)
+    for i := 0; i < 7; i++ {
+        a = gfMul(a, a)
+        r = gfMul(r, a)
+    }
+
+    return r
+}
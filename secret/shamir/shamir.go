@@ -0,0 +1,203 @@
+package shamir
+
+import (
+    "io"
+    "errors"
+    "crypto/rand"
+)
+
+// Shamir's Secret Sharing over GF(2^8).
+//
+// Every byte of the secret is the constant term of its own random
+// polynomial of degree threshold-1. A share holds the values of these
+// polynomials at one point, followed by the point itself:
+//
+//     y_1 || y_2 || ... || y_n || x
+//
+// which is the share format of HashiCorp Vault.
+
+const (
+    // ShareOverhead is the number of bytes a share has on top of the
+    // secret, the x coordinate.
+    ShareOverhead = 1
+)
+
+var (
+    ErrPartsLessThanThreshold = errors.New("shamir: parts is less than threshold")
+    ErrPartsTooMany           = errors.New("shamir: parts is more than 255")
+    ErrThresholdTooSmall      = errors.New("shamir: threshold is less than 2")
+    ErrThresholdTooBig        = errors.New("shamir: threshold is more than 255")
+    ErrSecretEmpty            = errors.New("shamir: secret is empty")
+    ErrSharesTooFew           = errors.New("shamir: at least two shares are needed")
+    ErrSharesTooShort         = errors.New("shamir: share is shorter than two bytes")
+    ErrSharesLengthMismatch   = errors.New("shamir: shares have different lengths")
+    ErrSharesDuplicate        = errors.New("shamir: shares have the same x coordinate")
+)
+
+// Split shares secret into parts shares, any threshold of them
+// recover it with Combine. parts and threshold are between 2 and 255,
+// each share is ShareOverhead bytes longer than secret.
+func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+    return SplitWithReader(rand.Reader, secret, parts, threshold)
+}
+
+// SplitWithReader is like Split, but takes the randomness from random.
+func SplitWithReader(random io.Reader, secret []byte, parts, threshold int) ([][]byte, error) {
+    switch {
+        case parts < threshold:
+            return nil, ErrPartsLessThanThreshold
+        case parts > 255:
+            return nil, ErrPartsTooMany
+        case threshold < 2:
+            return nil, ErrThresholdTooSmall
+        case threshold > 255:
+            return nil, ErrThresholdTooBig
+        case len(secret) == 0:
+            return nil, ErrSecretEmpty
+    }
+
+    // distinct non-zero x coordinates, in a random order
+    xs, err := perm(random, 255)
+    if err != nil {
+        return nil, err
+    }
+
+    shares := make([][]byte, parts)
+    for i := range shares {
+        shares[i] = make([]byte, len(secret) + ShareOverhead)
+        shares[i][len(secret)] = xs[i] + 1
+    }
+
+    // coeffs[0] is the secret byte, the others are random
+    coeffs := make([]byte, threshold)
+    defer zero(coeffs)
+
+    for k, s := range secret {
+        coeffs[0] = s
+        if _, err := io.ReadFull(random, coeffs[1:]); err != nil {
+            return nil, err
+        }
+
+        for _, share := range shares {
+            share[k] = evaluate(coeffs, share[len(secret)])
+        }
+    }
+
+    return shares, nil
+}
+
+// Combine recovers the secret from at least threshold shares of Split.
+// With less shares, the result is a wrong secret and not an error.
+func Combine(parts [][]byte) ([]byte, error) {
+    if len(parts) < 2 {
+        return nil, ErrSharesTooFew
+    }
+
+    size := len(parts[0])
+    if size < 2 {
+        return nil, ErrSharesTooShort
+    }
+
+    xs := make([]uint8, len(parts))
+
+    var seen [256]bool
+    for i, part := range parts {
+        if len(part) != size {
+            return nil, ErrSharesLengthMismatch
+        }
+
+        x := part[size - 1]
+        if seen[x] {
+            return nil, ErrSharesDuplicate
+        }
+
+        seen[x] = true
+        xs[i] = x
+    }
+
+    // the secret is sum(l_i * y_i), l_i the Lagrange basis
+    // polynomials at 0, the same for every byte
+    ls := lagrangeAtZero(xs)
+
+    secret := make([]byte, size - ShareOverhead)
+    for k := range secret {
+        var s uint8
+        for i, part := range parts {
+            s = gfAdd(s, gfMul(ls[i], part[k]))
+        }
+
+        secret[k] = s
+    }
+
+    return secret, nil
+}
+
+// evaluate returns the polynomial with the coefficients at x, with the
+// Horner scheme.
+func evaluate(coeffs []uint8, x uint8) uint8 {
+    var y uint8
+    for i := len(coeffs) - 1; i >= 0; i-- {
+        y = gfAdd(gfMul(y, x), coeffs[i])
+    }
+
+    return y
+}
+
+// lagrangeAtZero returns the values at 0 of the Lagrange basis
+// polynomials of the distinct points xs,
+// l_i = prod(x_j / (x_i - x_j)) for j != i.
+func lagrangeAtZero(xs []uint8) []uint8 {
+    ls := make([]uint8, len(xs))
+
+    for i, xi := range xs {
+        num, den := uint8(1), uint8(1)
+        for j, xj := range xs {
+            if j != i {
+                num = gfMul(num, xj)
+                den = gfMul(den, gfAdd(xi, xj))
+            }
+        }
+
+        ls[i] = gfMul(num, gfInv(den))
+    }
+
+    return ls
+}
+
+// perm returns a random permutation of [0, n) read from random.
+func perm(random io.Reader, n int) ([]uint8, error) {
+    out := make([]uint8, n)
+    for i := range out {
+        out[i] = uint8(i)
+    }
+
+    var buf [2]byte
+    for i := n - 1; i > 0; i-- {
+        // rejection sampling to avoid modulo bias
+        bound := uint16(i + 1)
+        limit := 65536 - 65536 % uint32(bound)
+
+        var j uint16
+        for {
+            if _, err := io.ReadFull(random, buf[:]); err != nil {
+                return nil, err
+            }
+
+            v := uint16(buf[0]) << 8 | uint16(buf[1])
+            if uint32(v) < limit {
+                j = v % bound
+                break
+            }
+        }
+
+        out[i], out[j] = out[j], out[i]
+    }
+
+    return out, nil
+}
+
+func zero(b []byte) {
+    for i := range b {
+        b[i] = 0
+    }
+}
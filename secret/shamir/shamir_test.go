@@ -0,0 +1,196 @@
+package shamir
+
+import (
+    "bytes"
+    "testing"
+    "crypto/rand"
+
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+// slowMul multiplies as polynomials over GF(2), then reduces
+func slowMul(a, b uint8) uint8 {
+    var p uint16
+    for i := 0; i < 8; i++ {
+        if b >> i & 1 == 1 {
+            p ^= uint16(a) << i
+        }
+    }
+
+    for i := 15; i >= 8; i-- {
+        if p >> i & 1 == 1 {
+            p ^= 0x11b << (i - 8)
+        }
+    }
+
+    return uint8(p)
+}
+
+func Test_Field(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    assertEqual(gfAdd(0x57, 0x83), uint8(0xd4), "gfAdd")
+    assertEqual(gfMul(0x57, 0x83), uint8(0xc1), "gfMul")
+    assertEqual(gfMul(0x57, 0x13), uint8(0xfe), "gfMul")
+    assertEqual(gfInv(0), uint8(0), "gfInv(0)")
+
+    for a := 0; a < 256; a++ {
+        for b := 0; b < 256; b++ {
+            if gfMul(uint8(a), uint8(b)) != slowMul(uint8(a), uint8(b)) {
+                t.Fatalf("gfMul(%d, %d) fail", a, b)
+            }
+        }
+
+        if a > 0 && gfMul(uint8(a), gfInv(uint8(a))) != 1 {
+            t.Fatalf("gfInv(%d) fail", a)
+        }
+    }
+}
+
+func Test_Split_invalid(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    secret := []byte("test")
+
+    tests := []struct {
+        secret           []byte
+        parts, threshold int
+        err              error
+    }{
+        {secret, 2, 3, ErrPartsLessThanThreshold},
+        {secret, 256, 3, ErrPartsTooMany},
+        {secret, 10, 1, ErrThresholdTooSmall},
+        {secret, 0, 0, ErrThresholdTooSmall},
+        {nil, 3, 2, ErrSecretEmpty},
+    }
+
+    for _, test := range tests {
+        _, err := Split(test.secret, test.parts, test.threshold)
+        assertEqual(err, test.err, "Split")
+    }
+}
+
+func Test_Combine_invalid(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    tests := []struct {
+        parts [][]byte
+        err   error
+    }{
+        {nil, ErrSharesTooFew},
+        {[][]byte{[]byte("foo")}, ErrSharesTooFew},
+        {[][]byte{[]byte("foo"), []byte("ba")}, ErrSharesLengthMismatch},
+        {[][]byte{[]byte("f"), []byte("b")}, ErrSharesTooShort},
+        {[][]byte{[]byte("foo"), []byte("bao")}, ErrSharesDuplicate},
+    }
+
+    for _, test := range tests {
+        _, err := Combine(test.parts)
+        assertEqual(err, test.err, "Combine")
+    }
+}
+
+func Test_SplitCombine(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    secret := []byte("test secret")
+
+    shares, err := Split(secret, 5, 3)
+    assertError(err, "Split")
+    assertEqual(len(shares), 5, "Split")
+
+    xs := map[byte]bool{}
+    for _, share := range shares {
+        assertEqual(len(share), len(secret) + ShareOverhead, "Split")
+
+        x := share[len(secret)]
+        assertBool(x != 0 && !xs[x], "Split x")
+        xs[x] = true
+    }
+
+    // every subset of the shares
+    for mask := 1; mask < 1 << len(shares); mask++ {
+        var parts [][]byte
+        for i, share := range shares {
+            if mask >> i & 1 == 1 {
+                parts = append(parts, share)
+            }
+        }
+
+        if len(parts) < 2 {
+            continue
+        }
+
+        got, err := Combine(parts)
+        assertError(err, "Combine")
+
+        if len(parts) >= 3 {
+            assertEqual(got, secret, "Combine")
+        }
+    }
+}
+
+func Test_SplitMaxParts(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    secret := make([]byte, 32)
+    rand.Read(secret)
+
+    shares, err := Split(secret, 255, 255)
+    assertError(err, "Split")
+
+    got, err := Combine(shares)
+    assertError(err, "Combine")
+    assertEqual(got, secret, "Combine")
+}
+
+func Test_Combine_Check(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    // f(x) = 42 + x, at x = 1 and x = 2
+    got, err := Combine([][]byte{
+        {43, 1},
+        {40, 2},
+    })
+    assertError(err, "Combine")
+    assertEqual(got, []byte{42}, "Combine")
+
+    // f(x) = 7 + 3x + 5x^2, at x = 1, 2 and 3
+    var parts [][]byte
+    for _, x := range []uint8{1, 2, 3} {
+        y := evaluate([]uint8{7, 3, 5}, x)
+        assertEqual(y, gfAdd(gfAdd(7, slowMul(3, x)), slowMul(5, slowMul(x, x))), "evaluate")
+
+        parts = append(parts, []byte{y, x})
+    }
+
+    got, err = Combine(parts)
+    assertError(err, "Combine")
+    assertEqual(got, []byte{7}, "Combine")
+}
+
+func Test_SplitWithReader(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    secret := []byte("test")
+
+    random := bytes.Repeat([]byte{0x5a}, 1024)
+
+    a, err := SplitWithReader(bytes.NewReader(random), secret, 3, 2)
+    assertError(err, "SplitWithReader")
+
+    b, err := SplitWithReader(bytes.NewReader(random), secret, 3, 2)
+    assertError(err, "SplitWithReader")
+
+    for i := range a {
+        assertBool(bytes.Equal(a[i], b[i]), "SplitWithReader")
+    }
+
+    _, err = SplitWithReader(bytes.NewReader(random[:8]), secret, 3, 2)
+    assertBool(err != nil, "SplitWithReader short reader")
+}
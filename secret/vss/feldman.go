@@ -0,0 +1,89 @@
+package vss
+
+import (
+    "io"
+    "math/big"
+    "crypto/elliptic"
+)
+
+// FeldmanVerifier holds the public commitments of a Feldman split.
+// Commitments[0] is secret*G, so for a split private key it equals
+// the public key.
+type FeldmanVerifier struct {
+    Curve       elliptic.Curve
+    Commitments []Point
+}
+
+// FeldmanSplit splits secret into parts shares with threshold and
+// returns the commitments that let every holder verify their share.
+func FeldmanSplit(random io.Reader, curve elliptic.Curve, secret *big.Int, parts, threshold int) ([]*Share, *FeldmanVerifier, error) {
+    p, shares, err := split(random, curve.Params().N, secret, parts, threshold)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    commitments := make([]Point, len(p.coefficients))
+    for i, a := range p.coefficients {
+        x, y := curve.ScalarBaseMult(a.Bytes())
+        commitments[i] = Point{x, y}
+    }
+
+    verifier := &FeldmanVerifier{
+        Curve:       curve,
+        Commitments: commitments,
+    }
+
+    return shares, verifier, nil
+}
+
+// Threshold returns the number of shares needed to reconstruct the secret.
+func (v *FeldmanVerifier) Threshold() int {
+    return len(v.Commitments)
+}
+
+// PublicValue returns secret*G.
+func (v *FeldmanVerifier) PublicValue() Point {
+    return v.Commitments[0]
+}
+
+// Verify reports whether share is consistent with the commitments,
+// that is value*G == sum(C_j * index^j).
+func (v *FeldmanVerifier) Verify(share *Share) bool {
+    if share == nil || share.Value == nil || share.Index <= 0 {
+        return false
+    }
+
+    n := v.Curve.Params().N
+    if share.Value.Sign() < 0 || share.Value.Cmp(n) >= 0 {
+        return false
+    }
+
+    lx, ly := v.Curve.ScalarBaseMult(share.Value.Bytes())
+    rx, ry := evaluateCommitments(v.Curve, v.Commitments, share.Index)
+
+    return lx.Cmp(rx) == 0 && ly.Cmp(ry) == 0
+}
+
+// Marshal encodes the commitments as a sequence of
+// compressed points.
+func (v *FeldmanVerifier) Marshal() []byte {
+    return marshalPoints(v.Curve, v.Commitments)
+}
+
+// ParseFeldmanVerifier decodes commitments produced by Marshal.
+func ParseFeldmanVerifier(curve elliptic.Curve, data []byte) (*FeldmanVerifier, error) {
+    commitments, err := unmarshalPoints(curve, data)
+    if err != nil {
+        return nil, err
+    }
+
+    // one commitment per coefficient, the threshold is at least 2
+    if len(commitments) < 2 {
+        return nil, ErrPointInvalid
+    }
+
+    return &FeldmanVerifier{
+        Curve:       curve,
+        Commitments: commitments,
+    }, nil
+}
@@ -0,0 +1,221 @@
+package vss
+
+import (
+    "io"
+    "errors"
+    "math/big"
+    "crypto/sha256"
+    "crypto/elliptic"
+)
+
+// PedersenShare is a share together with its blinding value.
+type PedersenShare struct {
+    Share
+    Blind *big.Int
+}
+
+// PedersenVerifier holds the public commitments of a Pedersen split.
+// Unlike Feldman commitments they hide the secret.
+type PedersenVerifier struct {
+    Curve       elliptic.Curve
+    H           Point
+    Commitments []Point
+}
+
+// PedersenSplit splits secret into parts shares with threshold.
+// Each commitment is a_j*G + b_j*H where b_j are the coefficients
+// of a random blinding polynomial.
+func PedersenSplit(random io.Reader, curve elliptic.Curve, secret *big.Int, parts, threshold int) ([]*PedersenShare, *PedersenVerifier, error) {
+    n := curve.Params().N
+
+    p, shares, err := split(random, n, secret, parts, threshold)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    blind, err := randFieldElement(random, n)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    b, err := makePolynomial(random, n, blind, threshold - 1)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    hx, hy, err := GeneratorH(curve)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    commitments := make([]Point, len(p.coefficients))
+    for i := range p.coefficients {
+        commitments[i] = pedersenCommit(curve, hx, hy, p.coefficients[i], b.coefficients[i])
+    }
+
+    pshares := make([]*PedersenShare, len(shares))
+    for i, share := range shares {
+        pshares[i] = &PedersenShare{
+            Share: *share,
+            Blind: b.evaluate(big.NewInt(int64(share.Index)), n),
+        }
+    }
+
+    verifier := &PedersenVerifier{
+        Curve:       curve,
+        H:           Point{hx, hy},
+        Commitments: commitments,
+    }
+
+    return pshares, verifier, nil
+}
+
+// Threshold returns the number of shares needed to reconstruct the secret.
+func (v *PedersenVerifier) Threshold() int {
+    return len(v.Commitments)
+}
+
+// Verify reports whether share is consistent with the commitments,
+// that is value*G + blind*H == sum(C_j * index^j).
+func (v *PedersenVerifier) Verify(share *PedersenShare) bool {
+    if share == nil || share.Value == nil || share.Blind == nil || share.Index <= 0 {
+        return false
+    }
+
+    n := v.Curve.Params().N
+    if share.Value.Sign() < 0 || share.Value.Cmp(n) >= 0 ||
+        share.Blind.Sign() < 0 || share.Blind.Cmp(n) >= 0 {
+        return false
+    }
+
+    l := pedersenCommit(v.Curve, v.H.X, v.H.Y, share.Value, share.Blind)
+    rx, ry := evaluateCommitments(v.Curve, v.Commitments, share.Index)
+
+    return l.X.Cmp(rx) == 0 && l.Y.Cmp(ry) == 0
+}
+
+// Marshal encodes the commitments as a sequence of compressed
+// points. H is not encoded, it is derived from the curve.
+func (v *PedersenVerifier) Marshal() []byte {
+    return marshalPoints(v.Curve, v.Commitments)
+}
+
+// ParsePedersenVerifier decodes commitments produced by Marshal,
+// with H recomputed by GeneratorH.
+func ParsePedersenVerifier(curve elliptic.Curve, data []byte) (*PedersenVerifier, error) {
+    commitments, err := unmarshalPoints(curve, data)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(commitments) < 2 {
+        return nil, ErrPointInvalid
+    }
+
+    hx, hy, err := GeneratorH(curve)
+    if err != nil {
+        return nil, err
+    }
+
+    return &PedersenVerifier{
+        Curve:       curve,
+        H:           Point{hx, hy},
+        Commitments: commitments,
+    }, nil
+}
+
+// CombinePedersen reconstructs the secret from Pedersen shares.
+func CombinePedersen(curve elliptic.Curve, shares []*PedersenShare) (*big.Int, error) {
+    plain := make([]*Share, len(shares))
+    for i, share := range shares {
+        if share == nil {
+            return nil, ErrShareInvalid
+        }
+
+        plain[i] = &share.Share
+    }
+
+    return Combine(curve, plain)
+}
+
+func pedersenCommit(curve elliptic.Curve, hx, hy *big.Int, a, b *big.Int) Point {
+    ax, ay := curve.ScalarBaseMult(a.Bytes())
+    bx, by := curve.ScalarMult(hx, hy, b.Bytes())
+
+    x, y := addPoints(curve, ax, ay, bx, by)
+    return Point{x, y}
+}
+
+// GeneratorH returns the second Pedersen generator for curve.
+// It is derived by hashing to an x coordinate with try-and-increment,
+// so nobody knows its discrete logarithm with respect to G.
+func GeneratorH(curve elliptic.Curve) (*big.Int, *big.Int, error) {
+    params := curve.Params()
+
+    p := params.P
+    a := curveA(params)
+
+    byteLen := (p.BitLen() + 7) / 8
+
+    for ctr := uint32(0); ctr < 1 << 16; ctr++ {
+        // expand the seed to byteLen + 16 bytes so the
+        // reduction mod p is close to uniform
+        var buf []byte
+        for blk := byte(0); len(buf) < byteLen + 16; blk++ {
+            h := sha256.New()
+            h.Write([]byte("go-cryptobin/vss/pedersen-H"))
+            h.Write([]byte(params.Name))
+            h.Write(params.Gx.Bytes())
+            h.Write(params.Gy.Bytes())
+            h.Write([]byte{byte(ctr >> 24), byte(ctr >> 16), byte(ctr >> 8), byte(ctr), blk})
+            buf = h.Sum(buf)
+        }
+
+        x := new(big.Int).SetBytes(buf[:byteLen + 16])
+        x.Mod(x, p)
+
+        // y^2 = x^3 + a*x + b
+        y2 := new(big.Int).Mul(x, x)
+        y2.Mul(y2, x)
+        ax := new(big.Int).Mul(a, x)
+        y2.Add(y2, ax)
+        y2.Add(y2, params.B)
+        y2.Mod(y2, p)
+
+        y := new(big.Int).ModSqrt(y2, p)
+        if y == nil {
+            continue
+        }
+
+        if y.Bit(0) == 1 {
+            y.Sub(p, y)
+        }
+
+        if !curve.IsOnCurve(x, y) {
+            continue
+        }
+
+        return x, y, nil
+    }
+
+    return nil, nil, errors.New("vss: failed to derive generator H")
+}
+
+// curveA recovers the a coefficient of y^2 = x^3 + a*x + b
+// from the base point, so curves with a != -3 also work.
+func curveA(params *elliptic.CurveParams) *big.Int {
+    p := params.P
+
+    x3 := new(big.Int).Exp(params.Gx, big.NewInt(3), p)
+
+    a := new(big.Int).Mul(params.Gy, params.Gy)
+    a.Sub(a, x3)
+    a.Sub(a, params.B)
+    a.Mod(a, p)
+
+    inv := new(big.Int).ModInverse(params.Gx, p)
+    a.Mul(a, inv)
+    a.Mod(a, p)
+
+    return a
+}
@@ -0,0 +1,106 @@
+package vss
+
+import (
+    "math/big"
+    "crypto/elliptic"
+)
+
+// marshalPoints encodes points as one byte of count followed
+// by compressed points, the identity encodes as a single 0x00.
+func marshalPoints(curve elliptic.Curve, points []Point) []byte {
+    out := []byte{byte(len(points))}
+
+    byteLen := (curve.Params().BitSize + 7) / 8
+    for _, p := range points {
+        if p.X.Sign() == 0 && p.Y.Sign() == 0 {
+            out = append(out, make([]byte, 1 + byteLen)...)
+            continue
+        }
+
+        out = append(out, elliptic.MarshalCompressed(curve, p.X, p.Y)...)
+    }
+
+    return out
+}
+
+// unmarshalPoints decodes points encoded by marshalPoints.
+func unmarshalPoints(curve elliptic.Curve, data []byte) ([]Point, error) {
+    if len(data) < 1 {
+        return nil, ErrPointInvalid
+    }
+
+    count := int(data[0])
+    data = data[1:]
+
+    byteLen := (curve.Params().BitSize + 7) / 8
+    if len(data) != count * (1 + byteLen) {
+        return nil, ErrPointInvalid
+    }
+
+    points := make([]Point, count)
+    for i := 0; i < count; i++ {
+        enc := data[i * (1 + byteLen):(i + 1) * (1 + byteLen)]
+
+        if enc[0] == 0 {
+            for _, b := range enc {
+                if b != 0 {
+                    return nil, ErrPointInvalid
+                }
+            }
+
+            points[i] = Point{new(big.Int), new(big.Int)}
+            continue
+        }
+
+        x, y := unmarshalCompressed(curve, enc)
+        if x == nil {
+            return nil, ErrPointInvalid
+        }
+
+        points[i] = Point{x, y}
+    }
+
+    return points, nil
+}
+
+// unmarshalCompressed is like elliptic.UnmarshalCompressed
+// but does not assume a = -3.
+func unmarshalCompressed(curve elliptic.Curve, data []byte) (x, y *big.Int) {
+    params := curve.Params()
+    p := params.P
+
+    byteLen := (params.BitSize + 7) / 8
+    if len(data) != 1 + byteLen {
+        return nil, nil
+    }
+    if data[0] != 2 && data[0] != 3 {
+        return nil, nil
+    }
+
+    x = new(big.Int).SetBytes(data[1:])
+    if x.Cmp(p) >= 0 {
+        return nil, nil
+    }
+
+    // y^2 = x^3 + a*x + b
+    y2 := new(big.Int).Mul(x, x)
+    y2.Mul(y2, x)
+    y2.Add(y2, new(big.Int).Mul(curveA(params), x))
+    y2.Add(y2, params.B)
+    y2.Mod(y2, p)
+
+    y = new(big.Int).ModSqrt(y2, p)
+    if y == nil {
+        return nil, nil
+    }
+
+    if byte(y.Bit(0)) != data[0] & 1 {
+        y.Sub(p, y)
+    }
+
+    if !curve.IsOnCurve(x, y) {
+        return nil, nil
+    }
+
+    return x, y
+}
@@ -0,0 +1,223 @@
+package vss
+
+import (
+    "io"
+    "errors"
+    "math/big"
+    "crypto/elliptic"
+)
+
+// Verifiable Secret Sharing over prime-order elliptic curves.
+//
+// Feldman VSS publishes commitments C_j = a_j*G to the polynomial
+// coefficients, Pedersen VSS publishes C_j = a_j*G + b_j*H with a second
+// generator H whose discrete log to G is unknown.
+
+var (
+    ErrPartsLessThanThreshold = errors.New("vss: parts cannot be less than threshold")
+    ErrThresholdTooSmall      = errors.New("vss: threshold must be at least 2")
+    ErrSecretOutOfRange       = errors.New("vss: secret must be in [0, N)")
+    ErrSharesTooFew           = errors.New("vss: too few shares to reconstruct the secret")
+    ErrSharesDuplicate        = errors.New("vss: duplicate share index")
+    ErrShareInvalid           = errors.New("vss: invalid share")
+    ErrPointInvalid           = errors.New("vss: invalid commitment point")
+)
+
+// Point is an affine curve point.
+// The point at infinity is represented as (0, 0).
+type Point struct {
+    X, Y *big.Int
+}
+
+// Equal reports whether p and q are the same point.
+func (p Point) Equal(q Point) bool {
+    return p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) == 0
+}
+
+// Share is a single share, the value of the polynomial at Index.
+type Share struct {
+    Index int
+    Value *big.Int
+}
+
+// polynomial with coefficients in Z_N
+type polynomial struct {
+    coefficients []*big.Int
+}
+
+// makePolynomial returns a random polynomial of the given degree
+// with the intercept set to secret.
+func makePolynomial(random io.Reader, n *big.Int, secret *big.Int, degree int) (*polynomial, error) {
+    p := &polynomial{
+        coefficients: make([]*big.Int, degree + 1),
+    }
+
+    p.coefficients[0] = new(big.Int).Set(secret)
+
+    for i := 1; i <= degree; i++ {
+        k, err := randFieldElement(random, n)
+        if err != nil {
+            return nil, err
+        }
+
+        p.coefficients[i] = k
+    }
+
+    return p, nil
+}
+
+// evaluate returns p(x) mod n using Horner's method.
+func (p *polynomial) evaluate(x, n *big.Int) *big.Int {
+    degree := len(p.coefficients) - 1
+
+    out := new(big.Int).Set(p.coefficients[degree])
+    for i := degree - 1; i >= 0; i-- {
+        out.Mul(out, x)
+        out.Add(out, p.coefficients[i])
+        out.Mod(out, n)
+    }
+
+    return out
+}
+
+// split evaluates a fresh polynomial for secret at 1..parts.
+func split(random io.Reader, n *big.Int, secret *big.Int, parts, threshold int) (*polynomial, []*Share, error) {
+    if parts < threshold {
+        return nil, nil, ErrPartsLessThanThreshold
+    }
+    if threshold < 2 {
+        return nil, nil, ErrThresholdTooSmall
+    }
+    if secret == nil || secret.Sign() < 0 || secret.Cmp(n) >= 0 {
+        return nil, nil, ErrSecretOutOfRange
+    }
+
+    p, err := makePolynomial(random, n, secret, threshold - 1)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    shares := make([]*Share, parts)
+    for i := 0; i < parts; i++ {
+        x := big.NewInt(int64(i + 1))
+
+        shares[i] = &Share{
+            Index: i + 1,
+            Value: p.evaluate(x, n),
+        }
+    }
+
+    return p, shares, nil
+}
+
+// Split splits secret into parts shares of which threshold are
+// required to reconstruct it, without any commitments.
+func Split(random io.Reader, curve elliptic.Curve, secret *big.Int, parts, threshold int) ([]*Share, error) {
+    _, shares, err := split(random, curve.Params().N, secret, parts, threshold)
+    return shares, err
+}
+
+// Combine reconstructs the secret from shares by Lagrange
+// interpolation at zero. The caller must supply at least
+// threshold shares of the same split, all of them are
+// interpolated, so a single bad share gives a wrong secret.
+func Combine(curve elliptic.Curve, shares []*Share) (*big.Int, error) {
+    n := curve.Params().N
+
+    if len(shares) < 2 {
+        return nil, ErrSharesTooFew
+    }
+
+    seen := make(map[int]bool, len(shares))
+    for _, share := range shares {
+        if share == nil || share.Value == nil || share.Index <= 0 {
+            return nil, ErrShareInvalid
+        }
+
+        if seen[share.Index] {
+            return nil, ErrSharesDuplicate
+        }
+
+        seen[share.Index] = true
+    }
+
+    secret := new(big.Int)
+    for i, si := range shares {
+        xi := big.NewInt(int64(si.Index))
+
+        num := big.NewInt(1)
+        den := big.NewInt(1)
+        for j, sj := range shares {
+            if i == j {
+                continue
+            }
+
+            xj := big.NewInt(int64(sj.Index))
+
+            // basis *= xj / (xj - xi)
+            num.Mul(num, xj)
+            num.Mod(num, n)
+
+            t := new(big.Int).Sub(xj, xi)
+            den.Mul(den, t)
+            den.Mod(den, n)
+        }
+
+        den.ModInverse(den, n)
+
+        term := new(big.Int).Mul(si.Value, num)
+        term.Mul(term, den)
+
+        secret.Add(secret, term)
+        secret.Mod(secret, n)
+    }
+
+    return secret, nil
+}
+
+// randFieldElement returns a random element of [1, n).
+func randFieldElement(random io.Reader, n *big.Int) (*big.Int, error) {
+    b := make([]byte, (n.BitLen() + 7) / 8 + 8)
+    if _, err := io.ReadFull(random, b); err != nil {
+        return nil, err
+    }
+
+    k := new(big.Int).SetBytes(b)
+
+    nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
+    k.Mod(k, nMinus1)
+    k.Add(k, big.NewInt(1))
+
+    return k, nil
+}
+
+// evaluateCommitments returns sum(C_j * x^j).
+func evaluateCommitments(curve elliptic.Curve, commitments []Point, index int) (*big.Int, *big.Int) {
+    n := curve.Params().N
+
+    x := big.NewInt(int64(index))
+    e := big.NewInt(1)
+
+    rx, ry := new(big.Int), new(big.Int)
+    for _, c := range commitments {
+        px, py := curve.ScalarMult(c.X, c.Y, e.Bytes())
+        rx, ry = addPoints(curve, rx, ry, px, py)
+
+        e.Mul(e, x)
+        e.Mod(e, n)
+    }
+
+    return rx, ry
+}
+
+// addPoints adds two points, treating (0, 0) as the identity.
+func addPoints(curve elliptic.Curve, x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+    if x1.Sign() == 0 && y1.Sign() == 0 {
+        return new(big.Int).Set(x2), new(big.Int).Set(y2)
+    }
+    if x2.Sign() == 0 && y2.Sign() == 0 {
+        return new(big.Int).Set(x1), new(big.Int).Set(y1)
+    }
+
+    return curve.Add(x1, y1, x2, y2)
+}
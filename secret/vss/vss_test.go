@@ -0,0 +1,207 @@
+package vss
+
+import (
+    "testing"
+    "math/big"
+    "crypto/rand"
+    "crypto/elliptic"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/elliptic/secp256k1"
+)
+
+var testCurves = []struct {
+    name  string
+    curve elliptic.Curve
+}{
+    {"P256", elliptic.P256()},
+    {"P384", elliptic.P384()},
+    {"P521", elliptic.P521()},
+    {"SM2", sm2.P256()},
+    {"secp256k1", secp256k1.Curve()},
+}
+
+func Test_Feldman(t *testing.T) {
+    for _, c := range testCurves {
+        t.Run(c.name, func(t *testing.T) {
+            secret, _ := randFieldElement(rand.Reader, c.curve.Params().N)
+
+            shares, verifier, err := FeldmanSplit(rand.Reader, c.curve, secret, 5, 3)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if verifier.Threshold() != 3 {
+                t.Errorf("Threshold got %d", verifier.Threshold())
+            }
+
+            pub := verifier.PublicValue()
+            px, py := c.curve.ScalarBaseMult(secret.Bytes())
+            if pub.X.Cmp(px) != 0 || pub.Y.Cmp(py) != 0 {
+                t.Error("PublicValue is not secret*G")
+            }
+
+            for _, share := range shares {
+                if !verifier.Verify(share) {
+                    t.Errorf("share %d fail to verify", share.Index)
+                }
+            }
+
+            bad := &Share{
+                Index: shares[0].Index,
+                Value: new(big.Int).Add(shares[0].Value, big.NewInt(1)),
+            }
+            if verifier.Verify(bad) {
+                t.Error("bad share should not verify")
+            }
+
+            got, err := Combine(c.curve, []*Share{shares[4], shares[1], shares[2]})
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if got.Cmp(secret) != 0 {
+                t.Errorf("Combine got %x, want %x", got, secret)
+            }
+
+            got, err = Combine(c.curve, shares[:2])
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if got.Cmp(secret) == 0 {
+                t.Error("two shares should not recover the secret")
+            }
+
+            parsed, err := ParseFeldmanVerifier(c.curve, verifier.Marshal())
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            for _, share := range shares {
+                if !parsed.Verify(share) {
+                    t.Errorf("parsed verifier: share %d fail to verify", share.Index)
+                }
+            }
+        })
+    }
+}
+
+func Test_Pedersen(t *testing.T) {
+    for _, c := range testCurves {
+        t.Run(c.name, func(t *testing.T) {
+            secret, _ := randFieldElement(rand.Reader, c.curve.Params().N)
+
+            shares, verifier, err := PedersenSplit(rand.Reader, c.curve, secret, 4, 2)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            for _, share := range shares {
+                if !verifier.Verify(share) {
+                    t.Errorf("share %d fail to verify", share.Index)
+                }
+            }
+
+            bad := &PedersenShare{
+                Share: shares[1].Share,
+                Blind: new(big.Int).Add(shares[1].Blind, big.NewInt(1)),
+            }
+            if verifier.Verify(bad) {
+                t.Error("bad share should not verify")
+            }
+
+            got, err := CombinePedersen(c.curve, []*PedersenShare{shares[3], shares[0]})
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if got.Cmp(secret) != 0 {
+                t.Errorf("Combine got %x, want %x", got, secret)
+            }
+
+            parsed, err := ParsePedersenVerifier(c.curve, verifier.Marshal())
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !parsed.H.Equal(verifier.H) {
+                t.Error("parsed H mismatch")
+            }
+
+            if len(verifier.Marshal()) != len(marshalPoints(c.curve, verifier.Commitments)) {
+                t.Error("H should not be marshaled")
+            }
+
+            for _, share := range shares {
+                if !parsed.Verify(share) {
+                    t.Errorf("parsed verifier: share %d fail to verify", share.Index)
+                }
+            }
+        })
+    }
+}
+
+func Test_GeneratorH(t *testing.T) {
+    for _, c := range testCurves {
+        x, y, err := GeneratorH(c.curve)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        if !c.curve.IsOnCurve(x, y) {
+            t.Errorf("%s: H not on curve", c.name)
+        }
+
+        x2, y2, _ := GeneratorH(c.curve)
+        if x.Cmp(x2) != 0 || y.Cmp(y2) != 0 {
+            t.Errorf("%s: H not deterministic", c.name)
+        }
+    }
+}
+
+func Test_Split_invalid(t *testing.T) {
+    curve := elliptic.P256()
+
+    if _, err := Split(rand.Reader, curve, big.NewInt(1), 2, 3); err == nil {
+        t.Error("expect fail")
+    }
+    if _, err := Split(rand.Reader, curve, big.NewInt(1), 3, 1); err == nil {
+        t.Error("expect fail")
+    }
+    if _, err := Split(rand.Reader, curve, curve.Params().N, 3, 2); err == nil {
+        t.Error("expect fail")
+    }
+
+    shares, err := Split(rand.Reader, curve, big.NewInt(1), 3, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := Combine(curve, []*Share{shares[0], shares[0]}); err == nil {
+        t.Error("expect duplicate fail")
+    }
+}
+
+func Test_ParseVerifier_invalid(t *testing.T) {
+    curve := elliptic.P256()
+
+    secret, _ := randFieldElement(rand.Reader, curve.Params().N)
+
+    _, feldman, err := FeldmanSplit(rand.Reader, curve, secret, 3, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    one := marshalPoints(curve, feldman.Commitments[:1])
+
+    for _, data := range [][]byte{nil, {0}, one} {
+        if _, err := ParseFeldmanVerifier(curve, data); err == nil {
+            t.Errorf("ParseFeldmanVerifier(%x) should fail", data)
+        }
+
+        if _, err := ParsePedersenVerifier(curve, data); err == nil {
+            t.Errorf("ParsePedersenVerifier(%x) should fail", data)
+        }
+    }
+}
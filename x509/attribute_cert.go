@@ -0,0 +1,1176 @@
+package x509
+
+import (
+    "io"
+    "time"
+    "bytes"
+    "errors"
+    "math/big"
+    "crypto"
+    "encoding/pem"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+)
+
+var (
+    oidAttributeRole           = asn1.ObjectIdentifier{2, 5, 4, 72}
+    oidAttributeClearance      = asn1.ObjectIdentifier{2, 5, 4, 55}
+    oidAttributeAccessIdentity = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 10, 2}
+    oidAttributeGroup          = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 10, 4}
+
+    oidExtensionNoRevAvail = []int{2, 5, 29, 56}
+
+    oidDigestSHA1 = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+)
+
+// digestAlgorithms are the hashes of the object digests of the holders.
+var digestAlgorithms = []struct {
+    hash Hash
+    oid  asn1.ObjectIdentifier
+}{
+    {SHA1, oidDigestSHA1},
+    {SHA256, oidSHA256},
+    {SHA384, oidSHA384},
+    {SHA512, oidSHA512},
+    {SM3, oidHashSM3},
+    {GOST34112012256, oidGost2012Digest256},
+    {GOST34112012512, oidGost2012Digest512},
+}
+
+var pemAttributeCertificateType = "ATTRIBUTE CERTIFICATE"
+
+// GeneralName is a GeneralName of an attribute certificate, RFC 5280
+// section 4.2.1.6. One of its fields is set, the other name forms are
+// not supported.
+type GeneralName struct {
+    // DirectoryName is a DER encoded Name.
+    DirectoryName []byte
+
+    DNSName string
+    Email   string
+    URI     string
+}
+
+// ObjectDigestType is the type of the object of an ObjectDigestInfo.
+type ObjectDigestType int
+
+const (
+    // DigestedPublicKey is the digest of the DER encoded public key
+    // info of the holder.
+    DigestedPublicKey ObjectDigestType = 0
+    // DigestedPublicKeyCert is the digest of the certificate of the
+    // holder.
+    DigestedPublicKeyCert ObjectDigestType = 1
+)
+
+// IssuerSerial identifies a certificate by its issuer and its serial
+// number.
+type IssuerSerial struct {
+    // Issuer is the DER encoded name of the issuer.
+    Issuer       []byte
+    SerialNumber *big.Int
+}
+
+// ObjectDigestInfo identifies an object by its digest.
+type ObjectDigestInfo struct {
+    Type   ObjectDigestType
+    Hash   Hash
+    Digest []byte
+}
+
+// NewObjectDigestInfo returns the digest with h of the public key info
+// or of cert.
+func NewObjectDigestInfo(cert *Certificate, typ ObjectDigestType, h Hash) (*ObjectDigestInfo, error) {
+    digest, err := objectDigest(cert, typ, h)
+    if err != nil {
+        return nil, err
+    }
+
+    return &ObjectDigestInfo{
+        Type:   typ,
+        Hash:   h,
+        Digest: digest,
+    }, nil
+}
+
+// AttributeCertificateHolder is the holder of an attribute certificate,
+// RFC 5755 section 4.2.2. The holder is bound to its public key
+// certificate with BaseCertificateID or ObjectDigestInfo.
+type AttributeCertificateHolder struct {
+    // BaseCertificateID is the issuer and the serial number of the
+    // certificate of the holder.
+    BaseCertificateID *IssuerSerial
+
+    // EntityName are the names of the holder.
+    EntityName []GeneralName
+
+    // ObjectDigestInfo is the digest of the public key or of the
+    // certificate of the holder.
+    ObjectDigestInfo *ObjectDigestInfo
+}
+
+// NewAttributeCertificateHolder returns the holder bound to cert with
+// its issuer and serial number.
+func NewAttributeCertificateHolder(cert *Certificate) AttributeCertificateHolder {
+    return AttributeCertificateHolder{
+        BaseCertificateID: &IssuerSerial{
+            Issuer:       cert.RawIssuer,
+            SerialNumber: cert.SerialNumber,
+        },
+    }
+}
+
+// Role is the role attribute, RFC 5755 section 4.4.5.
+type Role struct {
+    // Authority are the names of the authorities of the role.
+    Authority []GeneralName
+    Name      GeneralName
+}
+
+// Group is the group attribute, RFC 5755 section 4.4.4.
+type Group struct {
+    // PolicyAuthority are the names of the authorities of the groups.
+    PolicyAuthority []GeneralName
+
+    // Values are the groups, UTF8 strings when created. The OCTET
+    // STRING values are parsed as strings and the OID values in their
+    // dotted form.
+    Values []string
+}
+
+// AccessIdentity is the access identity attribute, RFC 5755 section
+// 4.4.2, the identity of the holder for a service.
+type AccessIdentity struct {
+    Service  GeneralName
+    Ident    GeneralName
+    AuthInfo []byte
+}
+
+// ClassList are the classifications of a clearance.
+type ClassList int
+
+const (
+    ClassUnmarked ClassList = 1 << iota
+    ClassUnclassified
+    ClassRestricted
+    ClassConfidential
+    ClassSecret
+    ClassTopSecret
+)
+
+// SecurityCategory is a security category of a clearance.
+type SecurityCategory struct {
+    Type asn1.ObjectIdentifier
+
+    // Value is the DER encoded value.
+    Value []byte
+}
+
+// Clearance is the clearance attribute, RFC 5755 section 4.4.6.
+type Clearance struct {
+    Policy asn1.ObjectIdentifier
+
+    // ClassList is ClassUnclassified if zero.
+    ClassList ClassList
+
+    SecurityCategories []SecurityCategory
+}
+
+// AttributeCertificateAttribute is an attribute of an attribute
+// certificate with its DER encoded values.
+type AttributeCertificateAttribute struct {
+    Type   asn1.ObjectIdentifier
+    Values []asn1.RawValue `asn1:"set"`
+}
+
+// AttributeCertificate is a X.509 v2 attribute certificate, RFC 5755.
+// It binds attributes such as roles to a holder, usually the subject of
+// a public key certificate.
+type AttributeCertificate struct {
+    // Raw contains the complete ASN.1 DER content of the attribute
+    // certificate.
+    Raw []byte
+    // RawTBSAttributeCertificate contains just the signed acinfo.
+    RawTBSAttributeCertificate []byte
+
+    Signature []byte
+    // SignatureAlgorithm is used to determine the signature algorithm
+    // when creating the attribute certificate. If 0 the default
+    // algorithm for the signing key will be used.
+    SignatureAlgorithm SignatureAlgorithm
+
+    SerialNumber *big.Int
+
+    Holder AttributeCertificateHolder
+
+    // RawIssuer is the DER encoded name of the issuer. It is ignored
+    // when creating, the subject of the issuer certificate is used.
+    RawIssuer []byte
+    Issuer    pkix.Name
+
+    NotBefore, NotAfter time.Time
+
+    Roles            []Role
+    Groups           []Group
+    AccessIdentities []AccessIdentity
+    Clearances       []Clearance
+
+    // Attributes contains all the attributes when parsing, the parsed
+    // ones included. It is ignored when creating, see ExtraAttributes.
+    Attributes []AttributeCertificateAttribute
+
+    // ExtraAttributes are added as is when creating.
+    ExtraAttributes []AttributeCertificateAttribute
+
+    // AuthorityKeyId is the authority key identifier. When creating, it
+    // is the subject key identifier of the issuer certificate.
+    AuthorityKeyId []byte
+
+    // NoRevocationAvailable sets the noRevAvail extension, no
+    // revocation information is available for the attribute
+    // certificate.
+    NoRevocationAvailable bool
+
+    // Extensions contains the raw extensions when parsing. It is
+    // ignored when creating, see ExtraExtensions.
+    Extensions []pkix.Extension
+
+    // ExtraExtensions contains extensions to be copied, raw, into the
+    // created attribute certificate. Values override any extensions
+    // that would otherwise be produced based on the other fields.
+    ExtraExtensions []pkix.Extension
+}
+
+type attributeCertificate struct {
+    TBS                asn1.RawValue
+    SignatureAlgorithm pkix.AlgorithmIdentifier
+    SignatureValue     asn1.BitString
+}
+
+type tbsAttributeCertificate struct {
+    Version        int
+    Holder         acHolder
+    Issuer         asn1.RawValue
+    Signature      pkix.AlgorithmIdentifier
+    SerialNumber   *big.Int
+    Validity       acValidity
+    Attributes     []AttributeCertificateAttribute
+    IssuerUniqueID asn1.BitString   `asn1:"optional"`
+    Extensions     []pkix.Extension `asn1:"optional"`
+}
+
+type acHolder struct {
+    BaseCertificateID acIssuerSerial     `asn1:"optional,tag:0"`
+    EntityName        asn1.RawValue      `asn1:"optional,tag:1"`
+    ObjectDigestInfo  acObjectDigestInfo `asn1:"optional,tag:2"`
+}
+
+type acIssuerSerial struct {
+    Issuer    asn1.RawValue
+    Serial    *big.Int
+    IssuerUID asn1.BitString `asn1:"optional"`
+}
+
+type acObjectDigestInfo struct {
+    DigestedObjectType asn1.Enumerated
+    OtherObjectTypeID  asn1.ObjectIdentifier `asn1:"optional"`
+    DigestAlgorithm    pkix.AlgorithmIdentifier
+    ObjectDigest       asn1.BitString
+}
+
+type acValidity struct {
+    NotBefore time.Time `asn1:"generalized"`
+    NotAfter  time.Time `asn1:"generalized"`
+}
+
+// acRoleSyntax is a RoleSyntax, the explicit [1] of the role name is
+// added by hand, encoding/asn1 ignores it for a RawValue.
+type acRoleSyntax struct {
+    RoleAuthority asn1.RawValue `asn1:"optional,tag:0"`
+    RoleName      asn1.RawValue
+}
+
+type acIetfAttrSyntax struct {
+    PolicyAuthority asn1.RawValue `asn1:"optional,tag:0"`
+    Values          []asn1.RawValue
+}
+
+type acSvceAuthInfo struct {
+    Service  asn1.RawValue
+    Ident    asn1.RawValue
+    AuthInfo []byte `asn1:"optional"`
+}
+
+type acClearance struct {
+    PolicyID           asn1.ObjectIdentifier
+    ClassList          asn1.BitString       `asn1:"optional"`
+    SecurityCategories []acSecurityCategory `asn1:"optional,set"`
+}
+
+// acSecurityCategory is a SecurityCategory, its value is an explicit
+// [1] added by hand.
+type acSecurityCategory struct {
+    Type  asn1.ObjectIdentifier `asn1:"tag:0"`
+    Value asn1.RawValue
+}
+
+// CreateAttributeCertificate creates a new X.509 v2 attribute
+// certificate based on template, RFC 5755.
+//
+// The attribute certificate is signed by priv, the private key of
+// issuer, with the algorithms of CreateCertificate, so SM2 and GOST
+// keys are supported. Its issuer is the subject of issuer.
+func CreateAttributeCertificate(rand io.Reader, template *AttributeCertificate, issuer *Certificate, priv crypto.Signer) ([]byte, error) {
+    if template == nil {
+        return nil, errors.New("x509: template can not be nil")
+    }
+    if issuer == nil {
+        return nil, errors.New("x509: issuer can not be nil")
+    }
+    if template.SerialNumber == nil {
+        return nil, errors.New("x509: no SerialNumber given")
+    }
+    if template.SerialNumber.Sign() == -1 {
+        return nil, errors.New("x509: serial number must be positive")
+    }
+    if template.NotAfter.Before(template.NotBefore) {
+        return nil, errors.New("x509: template.NotBefore is after template.NotAfter")
+    }
+
+    holder, err := marshalACHolder(template.Holder)
+    if err != nil {
+        return nil, err
+    }
+
+    attributes, err := marshalACAttributes(template)
+    if err != nil {
+        return nil, err
+    }
+
+    var extensions []pkix.Extension
+    if len(issuer.SubjectKeyId) > 0 {
+        aki, err := asn1.Marshal(authKeyId{Id: issuer.SubjectKeyId})
+        if err != nil {
+            return nil, err
+        }
+
+        extensions = append(extensions, pkix.Extension{
+            Id:    oidExtensionAuthorityKeyId,
+            Value: aki,
+        })
+    }
+
+    if template.NoRevocationAvailable {
+        extensions = append(extensions, pkix.Extension{
+            Id:    oidExtensionNoRevAvail,
+            Value: asn1.NullBytes,
+        })
+    }
+
+    for _, ext := range template.ExtraExtensions {
+        extensions = removeExtension(extensions, ext.Id)
+    }
+    extensions = append(extensions, template.ExtraExtensions...)
+
+    // the issuer is a v2Form with the directory name of the issuer
+    issuerNames, err := marshalGeneralNames([]GeneralName{{DirectoryName: issuer.RawSubject}})
+    if err != nil {
+        return nil, err
+    }
+
+    v2Form, err := asn1.Marshal(asn1.RawValue{
+        Class:      asn1.ClassContextSpecific,
+        Tag:        0,
+        IsCompound: true,
+        Bytes:      issuerNames,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    // the algorithm identifier is part of the signed data
+    _, sigAlgo, err := signingParamsForPublicKey(priv.Public(), template.SignatureAlgorithm)
+    if err != nil {
+        return nil, err
+    }
+
+    tbs := tbsAttributeCertificate{
+        Version:      1, // v2
+        Holder:       holder,
+        Issuer:       asn1.RawValue{FullBytes: v2Form},
+        Signature:    sigAlgo,
+        SerialNumber: template.SerialNumber,
+        Validity:     acValidity{
+            NotBefore: template.NotBefore.UTC(),
+            NotAfter:  template.NotAfter.UTC(),
+        },
+        Attributes: attributes,
+        Extensions: extensions,
+    }
+
+    tbsContents, err := asn1.Marshal(tbs)
+    if err != nil {
+        return nil, err
+    }
+
+    _, signature, err := CreateSignature(rand, priv, template.SignatureAlgorithm, tbsContents)
+    if err != nil {
+        return nil, err
+    }
+
+    return asn1.Marshal(attributeCertificate{
+        TBS:                asn1.RawValue{FullBytes: tbsContents},
+        SignatureAlgorithm: sigAlgo,
+        SignatureValue:     asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+    })
+}
+
+// ParseAttributeCertificate parses a X.509 v2 attribute certificate
+// from the given ASN.1 DER data. PEM encoded data is accepted too.
+func ParseAttributeCertificate(der []byte) (*AttributeCertificate, error) {
+    if block, _ := pem.Decode(der); block != nil && block.Type == pemAttributeCertificateType {
+        der = block.Bytes
+    }
+
+    var cert attributeCertificate
+    if rest, err := asn1.Unmarshal(der, &cert); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after attribute certificate")
+    }
+
+    var tbs tbsAttributeCertificate
+    if rest, err := asn1.Unmarshal(cert.TBS.FullBytes, &tbs); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after attribute certificate info")
+    }
+
+    if tbs.Version != 1 {
+        return nil, errors.New("x509: unsupported attribute certificate version")
+    }
+
+    if !tbs.Signature.Algorithm.Equal(cert.SignatureAlgorithm.Algorithm) {
+        return nil, errors.New("x509: inner and outer signature algorithm identifiers don't match")
+    }
+
+    ac := &AttributeCertificate{
+        Raw:                        der,
+        RawTBSAttributeCertificate: cert.TBS.FullBytes,
+        Signature:                  cert.SignatureValue.RightAlign(),
+        SignatureAlgorithm:         getSignatureAlgorithmFromAI(cert.SignatureAlgorithm),
+        SerialNumber:               tbs.SerialNumber,
+        NotBefore:                  tbs.Validity.NotBefore,
+        NotAfter:                   tbs.Validity.NotAfter,
+        Attributes:                 tbs.Attributes,
+        Extensions:                 tbs.Extensions,
+    }
+
+    var err error
+    if ac.Holder, err = parseACHolder(tbs.Holder); err != nil {
+        return nil, err
+    }
+
+    if ac.RawIssuer, err = parseACIssuer(tbs.Issuer); err != nil {
+        return nil, err
+    }
+
+    var issuer pkix.RDNSequence
+    if rest, err := asn1.Unmarshal(ac.RawIssuer, &issuer); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after attribute certificate issuer")
+    }
+    ac.Issuer.FillFromRDNSequence(&issuer)
+
+    for _, attr := range tbs.Attributes {
+        if err = ac.parseAttribute(attr); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, ext := range tbs.Extensions {
+        switch {
+            case ext.Id.Equal(oidExtensionAuthorityKeyId):
+                var a authKeyId
+                if rest, err := asn1.Unmarshal(ext.Value, &a); err != nil {
+                    return nil, err
+                } else if len(rest) != 0 {
+                    return nil, errors.New("x509: trailing data after authority key identifier")
+                }
+
+                ac.AuthorityKeyId = a.Id
+            case ext.Id.Equal(oidExtensionNoRevAvail):
+                ac.NoRevocationAvailable = true
+            default:
+                if ext.Critical {
+                    return nil, UnhandledCriticalExtension{}
+                }
+        }
+    }
+
+    return ac, nil
+}
+
+// CheckSignatureFrom verifies that the signature on ac is a valid
+// signature from issuer. The issuer of an attribute certificate must
+// not be a CA, RFC 5755 section 4.5.
+func (ac *AttributeCertificate) CheckSignatureFrom(issuer *Certificate) error {
+    if issuer.BasicConstraintsValid && issuer.IsCA {
+        return ConstraintViolationError{}
+    }
+
+    if issuer.KeyUsage != 0 && issuer.KeyUsage&KeyUsageDigitalSignature == 0 {
+        return ConstraintViolationError{}
+    }
+
+    if !bytes.Equal(ac.RawIssuer, issuer.RawSubject) {
+        return errors.New("x509: attribute certificate issuer does not match the issuer certificate")
+    }
+
+    if issuer.PublicKeyAlgorithm == UnknownPublicKeyAlgorithm {
+        return ErrUnsupportedAlgorithm
+    }
+
+    return issuer.CheckSignature(ac.SignatureAlgorithm, ac.RawTBSAttributeCertificate, ac.Signature)
+}
+
+// IsHolder reports whether cert is the certificate of the holder of
+// ac. All the holder fields set in ac must match cert.
+func (ac *AttributeCertificate) IsHolder(cert *Certificate) bool {
+    h := ac.Holder
+    if h.BaseCertificateID == nil && len(h.EntityName) == 0 && h.ObjectDigestInfo == nil {
+        return false
+    }
+
+    if id := h.BaseCertificateID; id != nil {
+        if !bytes.Equal(id.Issuer, cert.RawIssuer) ||
+            id.SerialNumber == nil || id.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+            return false
+        }
+    }
+
+    if len(h.EntityName) > 0 {
+        found := false
+        for _, name := range h.EntityName {
+            if len(name.DirectoryName) > 0 && bytes.Equal(name.DirectoryName, cert.RawSubject) {
+                found = true
+                break
+            }
+        }
+
+        if !found {
+            return false
+        }
+    }
+
+    if odi := h.ObjectDigestInfo; odi != nil {
+        digest, err := objectDigest(cert, odi.Type, odi.Hash)
+        if err != nil || !bytes.Equal(digest, odi.Digest) {
+            return false
+        }
+    }
+
+    return true
+}
+
+// Verify checks the signature of ac with issuer, its validity at
+// currentTime, the current time if zero, and that holder is the
+// certificate of its holder. The certificates of issuer and holder are
+// not verified, see Certificate.Verify.
+func (ac *AttributeCertificate) Verify(issuer, holder *Certificate, currentTime time.Time) error {
+    if err := ac.CheckSignatureFrom(issuer); err != nil {
+        return err
+    }
+
+    now := currentTime
+    if now.IsZero() {
+        now = time.Now()
+    }
+
+    if now.Before(ac.NotBefore) || now.After(ac.NotAfter) {
+        return errors.New("x509: attribute certificate has expired or is not yet valid")
+    }
+
+    if !ac.IsHolder(holder) {
+        return errors.New("x509: certificate is not the holder of the attribute certificate")
+    }
+
+    return nil
+}
+
+func objectDigest(cert *Certificate, typ ObjectDigestType, h Hash) ([]byte, error) {
+    if !h.Available() {
+        return nil, errors.New("x509: hash function is not available")
+    }
+
+    hasher := h.New()
+
+    switch typ {
+        case DigestedPublicKey:
+            hasher.Write(cert.RawSubjectPublicKeyInfo)
+        case DigestedPublicKeyCert:
+            hasher.Write(cert.Raw)
+        default:
+            return nil, errors.New("x509: unsupported object digest type")
+    }
+
+    return hasher.Sum(nil), nil
+}
+
+func marshalACHolder(h AttributeCertificateHolder) (holder acHolder, err error) {
+    if h.BaseCertificateID == nil && len(h.EntityName) == 0 && h.ObjectDigestInfo == nil {
+        return holder, errors.New("x509: attribute certificate without holder")
+    }
+
+    if id := h.BaseCertificateID; id != nil {
+        if holder.BaseCertificateID, err = marshalIssuerSerial(id); err != nil {
+            return
+        }
+    }
+
+    if len(h.EntityName) > 0 {
+        var names []byte
+        if names, err = marshalGeneralNameList(h.EntityName); err != nil {
+            return
+        }
+
+        holder.EntityName = asn1.RawValue{
+            Class:      asn1.ClassContextSpecific,
+            Tag:        1,
+            IsCompound: true,
+            Bytes:      names,
+        }
+    }
+
+    if odi := h.ObjectDigestInfo; odi != nil {
+        oid, ok := digestAlgorithmOID(odi.Hash)
+        if !ok {
+            return holder, errors.New("x509: unsupported object digest hash")
+        }
+
+        holder.ObjectDigestInfo = acObjectDigestInfo{
+            DigestedObjectType: asn1.Enumerated(odi.Type),
+            DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oid},
+            ObjectDigest:       asn1.BitString{Bytes: odi.Digest, BitLength: len(odi.Digest) * 8},
+        }
+    }
+
+    return holder, nil
+}
+
+func parseACHolder(holder acHolder) (h AttributeCertificateHolder, err error) {
+    if holder.BaseCertificateID.Serial != nil {
+        if h.BaseCertificateID, err = parseIssuerSerial(holder.BaseCertificateID); err != nil {
+            return
+        }
+    }
+
+    if len(holder.EntityName.FullBytes) > 0 {
+        if h.EntityName, err = parseGeneralNameList(holder.EntityName.Bytes); err != nil {
+            return
+        }
+    }
+
+    if odi := holder.ObjectDigestInfo; len(odi.DigestAlgorithm.Algorithm) > 0 {
+        info := &ObjectDigestInfo{
+            Type:   ObjectDigestType(odi.DigestedObjectType),
+            Digest: odi.ObjectDigest.RightAlign(),
+        }
+
+        for _, details := range digestAlgorithms {
+            if details.oid.Equal(odi.DigestAlgorithm.Algorithm) {
+                info.Hash = details.hash
+                break
+            }
+        }
+
+        h.ObjectDigestInfo = info
+    }
+
+    return h, nil
+}
+
+func marshalIssuerSerial(id *IssuerSerial) (acIssuerSerial, error) {
+    if id.SerialNumber == nil {
+        return acIssuerSerial{}, errors.New("x509: holder without serial number")
+    }
+
+    names, err := marshalGeneralNames([]GeneralName{{DirectoryName: id.Issuer}})
+    if err != nil {
+        return acIssuerSerial{}, err
+    }
+
+    return acIssuerSerial{
+        Issuer: asn1.RawValue{FullBytes: names},
+        Serial: id.SerialNumber,
+    }, nil
+}
+
+func parseIssuerSerial(is acIssuerSerial) (*IssuerSerial, error) {
+    names, err := parseGeneralNameList(is.Issuer.Bytes)
+    if err != nil {
+        return nil, err
+    }
+
+    id := &IssuerSerial{
+        SerialNumber: is.Serial,
+    }
+
+    for _, name := range names {
+        if len(name.DirectoryName) > 0 {
+            id.Issuer = name.DirectoryName
+            break
+        }
+    }
+
+    return id, nil
+}
+
+// parseACIssuer returns the directory name of the issuer, a v1Form
+// GeneralNames or a v2Form.
+func parseACIssuer(issuer asn1.RawValue) ([]byte, error) {
+    names := issuer.Bytes
+
+    if issuer.Class == asn1.ClassContextSpecific && issuer.Tag == 0 {
+        // the issuerName of the v2Form
+        var v2Names asn1.RawValue
+        if _, err := asn1.Unmarshal(issuer.Bytes, &v2Names); err != nil {
+            return nil, err
+        }
+
+        if v2Names.Class != asn1.ClassUniversal || v2Names.Tag != asn1.TagSequence {
+            return nil, errors.New("x509: attribute certificate issuer without name")
+        }
+
+        names = v2Names.Bytes
+    } else if issuer.Class != asn1.ClassUniversal || issuer.Tag != asn1.TagSequence {
+        return nil, errors.New("x509: invalid attribute certificate issuer")
+    }
+
+    list, err := parseGeneralNameList(names)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, name := range list {
+        if len(name.DirectoryName) > 0 {
+            return name.DirectoryName, nil
+        }
+    }
+
+    return nil, errors.New("x509: attribute certificate issuer without directory name")
+}
+
+func marshalACAttributes(template *AttributeCertificate) ([]AttributeCertificateAttribute, error) {
+    var attributes []AttributeCertificateAttribute
+
+    add := func(oid asn1.ObjectIdentifier, values []any) error {
+        if len(values) == 0 {
+            return nil
+        }
+
+        attr := AttributeCertificateAttribute{
+            Type: oid,
+        }
+
+        for _, value := range values {
+            der, err := asn1.Marshal(value)
+            if err != nil {
+                return err
+            }
+
+            attr.Values = append(attr.Values, asn1.RawValue{FullBytes: der})
+        }
+
+        attributes = append(attributes, attr)
+
+        return nil
+    }
+
+    var values []any
+    for _, role := range template.Roles {
+        name, err := marshalGeneralName(role.Name)
+        if err != nil {
+            return nil, err
+        }
+
+        nameBytes, err := asn1.Marshal(name)
+        if err != nil {
+            return nil, err
+        }
+
+        syntax := acRoleSyntax{
+            RoleName: explicitValue(1, nameBytes),
+        }
+
+        if len(role.Authority) > 0 {
+            if syntax.RoleAuthority, err = generalNamesValue(0, role.Authority); err != nil {
+                return nil, err
+            }
+        }
+
+        values = append(values, syntax)
+    }
+
+    if err := add(oidAttributeRole, values); err != nil {
+        return nil, err
+    }
+
+    values = nil
+    for _, group := range template.Groups {
+        var syntax acIetfAttrSyntax
+
+        if len(group.PolicyAuthority) > 0 {
+            var err error
+            if syntax.PolicyAuthority, err = generalNamesValue(0, group.PolicyAuthority); err != nil {
+                return nil, err
+            }
+        }
+
+        for _, value := range group.Values {
+            der, err := asn1.MarshalWithParams(value, "utf8")
+            if err != nil {
+                return nil, err
+            }
+
+            syntax.Values = append(syntax.Values, asn1.RawValue{FullBytes: der})
+        }
+
+        values = append(values, syntax)
+    }
+
+    if err := add(oidAttributeGroup, values); err != nil {
+        return nil, err
+    }
+
+    values = nil
+    for _, id := range template.AccessIdentities {
+        service, err := marshalGeneralName(id.Service)
+        if err != nil {
+            return nil, err
+        }
+
+        ident, err := marshalGeneralName(id.Ident)
+        if err != nil {
+            return nil, err
+        }
+
+        values = append(values, acSvceAuthInfo{
+            Service:  service,
+            Ident:    ident,
+            AuthInfo: id.AuthInfo,
+        })
+    }
+
+    if err := add(oidAttributeAccessIdentity, values); err != nil {
+        return nil, err
+    }
+
+    values = nil
+    for _, c := range template.Clearances {
+        clearance := acClearance{
+            PolicyID: c.Policy,
+        }
+
+        // the default unclassified class list is omitted
+        if c.ClassList != 0 && c.ClassList != ClassUnclassified {
+            clearance.ClassList = classListBitString(c.ClassList)
+        }
+
+        for _, category := range c.SecurityCategories {
+            clearance.SecurityCategories = append(clearance.SecurityCategories, acSecurityCategory{
+                Type:  category.Type,
+                Value: explicitValue(1, category.Value),
+            })
+        }
+
+        values = append(values, clearance)
+    }
+
+    if err := add(oidAttributeClearance, values); err != nil {
+        return nil, err
+    }
+
+    for _, attr := range template.ExtraAttributes {
+        for i := 0; i < len(attributes); i++ {
+            if attributes[i].Type.Equal(attr.Type) {
+                attributes = append(attributes[:i], attributes[i+1:]...)
+                i--
+            }
+        }
+    }
+
+    return append(attributes, template.ExtraAttributes...), nil
+}
+
+func (ac *AttributeCertificate) parseAttribute(attr AttributeCertificateAttribute) error {
+    for _, value := range attr.Values {
+        switch {
+            case attr.Type.Equal(oidAttributeRole):
+                var syntax acRoleSyntax
+                if err := unmarshalAttributeValue(value, &syntax); err != nil {
+                    return err
+                }
+
+                name, err := explicitContent(syntax.RoleName, 1)
+                if err != nil {
+                    return err
+                }
+
+                var nameValue asn1.RawValue
+                if err = unmarshalAttributeValue(asn1.RawValue{FullBytes: name}, &nameValue); err != nil {
+                    return err
+                }
+
+                role := Role{
+                    Name: parseGeneralName(nameValue),
+                }
+
+                if len(syntax.RoleAuthority.FullBytes) > 0 {
+                    var err error
+                    if role.Authority, err = parseGeneralNameList(syntax.RoleAuthority.Bytes); err != nil {
+                        return err
+                    }
+                }
+
+                ac.Roles = append(ac.Roles, role)
+            case attr.Type.Equal(oidAttributeGroup):
+                var syntax acIetfAttrSyntax
+                if err := unmarshalAttributeValue(value, &syntax); err != nil {
+                    return err
+                }
+
+                var group Group
+                if len(syntax.PolicyAuthority.FullBytes) > 0 {
+                    var err error
+                    if group.PolicyAuthority, err = parseGeneralNameList(syntax.PolicyAuthority.Bytes); err != nil {
+                        return err
+                    }
+                }
+
+                for _, v := range syntax.Values {
+                    switch v.Tag {
+                        case asn1.TagUTF8String, asn1.TagOctetString:
+                            group.Values = append(group.Values, string(v.Bytes))
+                        case asn1.TagOID:
+                            var oid asn1.ObjectIdentifier
+                            if _, err := asn1.Unmarshal(v.FullBytes, &oid); err != nil {
+                                return err
+                            }
+
+                            group.Values = append(group.Values, oid.String())
+                    }
+                }
+
+                ac.Groups = append(ac.Groups, group)
+            case attr.Type.Equal(oidAttributeAccessIdentity):
+                var info acSvceAuthInfo
+                if err := unmarshalAttributeValue(value, &info); err != nil {
+                    return err
+                }
+
+                ac.AccessIdentities = append(ac.AccessIdentities, AccessIdentity{
+                    Service:  parseGeneralName(info.Service),
+                    Ident:    parseGeneralName(info.Ident),
+                    AuthInfo: info.AuthInfo,
+                })
+            case attr.Type.Equal(oidAttributeClearance):
+                var c acClearance
+                if err := unmarshalAttributeValue(value, &c); err != nil {
+                    return err
+                }
+
+                clearance := Clearance{
+                    Policy:    c.PolicyID,
+                    ClassList: ClassUnclassified,
+                }
+
+                if c.ClassList.BitLength > 0 {
+                    clearance.ClassList = 0
+                    for i := 0; i < c.ClassList.BitLength && i < 6; i++ {
+                        if c.ClassList.At(i) == 1 {
+                            clearance.ClassList |= 1 << i
+                        }
+                    }
+                }
+
+                for _, category := range c.SecurityCategories {
+                    value, err := explicitContent(category.Value, 1)
+                    if err != nil {
+                        return err
+                    }
+
+                    clearance.SecurityCategories = append(clearance.SecurityCategories, SecurityCategory{
+                        Type:  category.Type,
+                        Value: value,
+                    })
+                }
+
+                ac.Clearances = append(ac.Clearances, clearance)
+        }
+    }
+
+    return nil
+}
+
+// explicitValue returns the explicitly tagged DER encoded value.
+func explicitValue(tag int, der []byte) asn1.RawValue {
+    return asn1.RawValue{
+        Class:      asn1.ClassContextSpecific,
+        Tag:        tag,
+        IsCompound: true,
+        Bytes:      der,
+    }
+}
+
+// explicitContent returns the DER encoded content of an explicit tag.
+func explicitContent(v asn1.RawValue, tag int) ([]byte, error) {
+    if v.Class != asn1.ClassContextSpecific || v.Tag != tag || !v.IsCompound {
+        return nil, errors.New("x509: invalid explicit tag")
+    }
+
+    return v.Bytes, nil
+}
+
+func unmarshalAttributeValue(value asn1.RawValue, out any) error {
+    if rest, err := asn1.Unmarshal(value.FullBytes, out); err != nil {
+        return err
+    } else if len(rest) != 0 {
+        return errors.New("x509: trailing data after attribute value")
+    }
+
+    return nil
+}
+
+// classListBitString returns the named bit string of the class list.
+func classListBitString(c ClassList) asn1.BitString {
+    length := 0
+    for i := 0; i < 8; i++ {
+        if c & (1 << i) != 0 {
+            length = i + 1
+        }
+    }
+
+    b := make([]byte, 1)
+    for i := 0; i < length; i++ {
+        if c & (1 << i) != 0 {
+            b[0] |= 0x80 >> i
+        }
+    }
+
+    return asn1.BitString{Bytes: b, BitLength: length}
+}
+
+func digestAlgorithmOID(h Hash) (asn1.ObjectIdentifier, bool) {
+    for _, details := range digestAlgorithms {
+        if details.hash == h {
+            return details.oid, true
+        }
+    }
+
+    return nil, false
+}
+
+// marshalGeneralName returns the GeneralName with the first set field
+// of name.
+func marshalGeneralName(name GeneralName) (asn1.RawValue, error) {
+    switch {
+        case len(name.DirectoryName) > 0:
+            return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: name.DirectoryName}, nil
+        case name.DNSName != "":
+            return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: nameTypeDNS, Bytes: []byte(name.DNSName)}, nil
+        case name.Email != "":
+            return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: nameTypeEmail, Bytes: []byte(name.Email)}, nil
+        case name.URI != "":
+            return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: nameTypeURI, Bytes: []byte(name.URI)}, nil
+    }
+
+    return asn1.RawValue{}, errors.New("x509: empty general name")
+}
+
+func parseGeneralName(v asn1.RawValue) (name GeneralName) {
+    if v.Class != asn1.ClassContextSpecific {
+        return
+    }
+
+    switch v.Tag {
+        case 4:
+            name.DirectoryName = v.Bytes
+        case nameTypeDNS:
+            name.DNSName = string(v.Bytes)
+        case nameTypeEmail:
+            name.Email = string(v.Bytes)
+        case nameTypeURI:
+            name.URI = string(v.Bytes)
+    }
+
+    return
+}
+
+// marshalGeneralNameList returns the concatenated DER encoded names.
+func marshalGeneralNameList(names []GeneralName) ([]byte, error) {
+    var out []byte
+    for _, name := range names {
+        v, err := marshalGeneralName(name)
+        if err != nil {
+            return nil, err
+        }
+
+        der, err := asn1.Marshal(v)
+        if err != nil {
+            return nil, err
+        }
+
+        out = append(out, der...)
+    }
+
+    return out, nil
+}
+
+// marshalGeneralNames returns the DER encoded GeneralNames.
+func marshalGeneralNames(names []GeneralName) ([]byte, error) {
+    list, err := marshalGeneralNameList(names)
+    if err != nil {
+        return nil, err
+    }
+
+    return asn1.Marshal(asn1.RawValue{
+        Class:      asn1.ClassUniversal,
+        Tag:        asn1.TagSequence,
+        IsCompound: true,
+        Bytes:      list,
+    })
+}
+
+// generalNamesValue returns the implicitly tagged GeneralNames.
+func generalNamesValue(tag int, names []GeneralName) (asn1.RawValue, error) {
+    list, err := marshalGeneralNameList(names)
+    if err != nil {
+        return asn1.RawValue{}, err
+    }
+
+    return asn1.RawValue{
+        Class:      asn1.ClassContextSpecific,
+        Tag:        tag,
+        IsCompound: true,
+        Bytes:      list,
+    }, nil
+}
+
+func parseGeneralNameList(der []byte) ([]GeneralName, error) {
+    var names []GeneralName
+    for len(der) > 0 {
+        var v asn1.RawValue
+
+        rest, err := asn1.Unmarshal(der, &v)
+        if err != nil {
+            return nil, err
+        }
+
+        names = append(names, parseGeneralName(v))
+        der = rest
+    }
+
+    return names, nil
+}
@@ -0,0 +1,238 @@
+package x509
+
+import (
+    "time"
+    "testing"
+    "reflect"
+    "math/big"
+    "crypto"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func Test_AttributeCertificate(t *testing.T) {
+    keys := map[string]func() crypto.Signer{
+        "ECDSA": func() crypto.Signer {
+            k, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+            return k
+        },
+        "SM2": func() crypto.Signer {
+            k, _ := sm2.GenerateKey(rand.Reader)
+            return k
+        },
+        "GOST": func() crypto.Signer {
+            k, _ := gost.GenerateKey(rand.Reader, gost.CurveIdGostR34102001CryptoProAParamSet())
+            return k
+        },
+    }
+
+    for name, newKey := range keys {
+        t.Run(name, func(t *testing.T) {
+            testAttributeCertificate(t, newKey)
+        })
+    }
+}
+
+func testAttributeCertificate(t *testing.T, newKey func() crypto.Signer) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    now := time.Now()
+
+    create := func(template, parent *Certificate, pub any, priv crypto.Signer) *Certificate {
+        der, err := CreateCertificate(rand.Reader, template, parent, pub, priv)
+        assertError(err, "CreateCertificate")
+
+        cert, err := ParseCertificate(der)
+        assertError(err, "ParseCertificate")
+
+        return cert
+    }
+
+    caKey := newKey()
+    caTemplate := &Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: "AC Root"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              KeyUsageCertSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+    ca := create(caTemplate, caTemplate, caKey.Public(), caKey)
+
+    aaKey := newKey()
+    aa := create(&Certificate{
+        SerialNumber:          big.NewInt(2),
+        Subject:               pkix.Name{CommonName: "Attribute Authority"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              KeyUsageDigitalSignature,
+        BasicConstraintsValid: true,
+        SubjectKeyId:          []byte{1, 2, 3},
+    }, ca, aaKey.Public(), caKey)
+
+    holderKey := newKey()
+    holder := create(&Certificate{
+        SerialNumber: big.NewInt(3),
+        Subject:      pkix.Name{CommonName: "alice"},
+        NotBefore:    now.Add(-time.Hour),
+        NotAfter:     now.Add(time.Hour),
+    }, ca, holderKey.Public(), caKey)
+
+    other := create(&Certificate{
+        SerialNumber: big.NewInt(4),
+        Subject:      pkix.Name{CommonName: "bob"},
+        NotBefore:    now.Add(-time.Hour),
+        NotAfter:     now.Add(time.Hour),
+    }, ca, holderKey.Public(), caKey)
+
+    digest, err := NewObjectDigestInfo(holder, DigestedPublicKeyCert, SHA256)
+    assertError(err, "NewObjectDigestInfo")
+
+    category, err := asn1.Marshal(42)
+    assertError(err, "Marshal")
+
+    template := &AttributeCertificate{
+        SerialNumber: big.NewInt(100),
+        Holder:       NewAttributeCertificateHolder(holder),
+        NotBefore:    now.Add(-time.Minute),
+        NotAfter:     now.Add(10 * time.Minute),
+        Roles:        []Role{
+            {
+                Authority: []GeneralName{{URI: "https://aa.example.com"}},
+                Name:      GeneralName{URI: "urn:role:admin"},
+            },
+            {
+                Name: GeneralName{URI: "urn:role:auditor"},
+            },
+        },
+        Groups: []Group{
+            {
+                Values: []string{"operators", "finance"},
+            },
+        },
+        AccessIdentities: []AccessIdentity{
+            {
+                Service: GeneralName{DNSName: "db.example.com"},
+                Ident:   GeneralName{Email: "alice@example.com"},
+            },
+        },
+        Clearances: []Clearance{
+            {
+                Policy:    asn1.ObjectIdentifier{1, 2, 3, 4},
+                ClassList: ClassConfidential | ClassSecret,
+                SecurityCategories: []SecurityCategory{
+                    {Type: asn1.ObjectIdentifier{1, 2, 3, 5}, Value: category},
+                },
+            },
+            {
+                Policy: asn1.ObjectIdentifier{1, 2, 3, 6},
+            },
+        },
+        NoRevocationAvailable: true,
+    }
+    template.Holder.ObjectDigestInfo = digest
+
+    der, err := CreateAttributeCertificate(rand.Reader, template, aa, aaKey)
+    assertError(err, "CreateAttributeCertificate")
+
+    // PEM is accepted
+    ac, err := ParseAttributeCertificate(pem.EncodeToMemory(&pem.Block{
+        Type:  "ATTRIBUTE CERTIFICATE",
+        Bytes: der,
+    }))
+    assertError(err, "ParseAttributeCertificate")
+
+    assertEqual(ac.SerialNumber, template.SerialNumber, "SerialNumber")
+    assertEqual(ac.RawIssuer, aa.RawSubject, "RawIssuer")
+    assertEqual(ac.Issuer.CommonName, "Attribute Authority", "Issuer")
+    assertBool(ac.NotAfter.Equal(template.NotAfter.Truncate(time.Second)), "NotAfter")
+    assertEqual(ac.Holder.BaseCertificateID.Issuer, holder.RawIssuer, "Holder")
+    assertEqual(ac.Holder.BaseCertificateID.SerialNumber, holder.SerialNumber, "Holder")
+    assertEqual(ac.Holder.ObjectDigestInfo, digest, "ObjectDigestInfo")
+
+    // the values of an attribute are a DER sorted SET OF
+    contains := func(list, v any) bool {
+        l := reflect.ValueOf(list)
+        for i := 0; i < l.Len(); i++ {
+            if reflect.DeepEqual(l.Index(i).Interface(), v) {
+                return true
+            }
+        }
+
+        return false
+    }
+
+    assertEqual(len(ac.Roles), 2, "Roles")
+    assertBool(contains(ac.Roles, template.Roles[0]), "Roles")
+    assertBool(contains(ac.Roles, template.Roles[1]), "Roles")
+    assertEqual(ac.Groups, template.Groups, "Groups")
+    assertEqual(ac.AccessIdentities, template.AccessIdentities, "AccessIdentities")
+    assertEqual(len(ac.Clearances), 2, "Clearances")
+    assertBool(contains(ac.Clearances, template.Clearances[0]), "Clearances")
+    assertBool(contains(ac.Clearances, Clearance{
+        Policy:    template.Clearances[1].Policy,
+        ClassList: ClassUnclassified,
+    }), "Clearances")
+    assertEqual(ac.AuthorityKeyId, aa.SubjectKeyId, "AuthorityKeyId")
+    assertEqual(ac.NoRevocationAvailable, true, "NoRevocationAvailable")
+    assertEqual(len(ac.Attributes), 4, "Attributes")
+
+    assertError(ac.CheckSignatureFrom(aa), "CheckSignatureFrom")
+    assertError(ac.Verify(aa, holder, time.Time{}), "Verify")
+
+    assertBool(ac.IsHolder(holder), "IsHolder")
+    assertBool(!ac.IsHolder(other), "IsHolder other")
+    assertBool(ac.Verify(aa, other, time.Time{}) != nil, "Verify other holder")
+    assertBool(ac.Verify(aa, holder, now.Add(time.Hour)) != nil, "Verify expired")
+
+    // a CA can not issue attribute certificates
+    assertBool(ac.CheckSignatureFrom(ca) != nil, "CheckSignatureFrom CA")
+
+    // a tampered attribute certificate
+    tampered := *ac
+    tampered.Signature = append([]byte{}, ac.Signature...)
+    tampered.Signature[len(tampered.Signature) - 1] ^= 1
+    assertBool(tampered.CheckSignatureFrom(aa) != nil, "CheckSignatureFrom tampered")
+
+    // the holder bound with the digest of its public key only
+    pkDigest, err := NewObjectDigestInfo(holder, DigestedPublicKey, SM3)
+    assertError(err, "NewObjectDigestInfo")
+
+    der, err = CreateAttributeCertificate(rand.Reader, &AttributeCertificate{
+        SerialNumber: big.NewInt(101),
+        Holder:       AttributeCertificateHolder{
+            EntityName:       []GeneralName{{DirectoryName: holder.RawSubject}},
+            ObjectDigestInfo: pkDigest,
+        },
+        NotBefore: now.Add(-time.Minute),
+        NotAfter:  now.Add(10 * time.Minute),
+        Groups:    []Group{{Values: []string{"staff"}}},
+    }, aa, aaKey)
+    assertError(err, "CreateAttributeCertificate")
+
+    ac, err = ParseAttributeCertificate(der)
+    assertError(err, "ParseAttributeCertificate")
+    assertEqual(ac.Holder.EntityName[0].DirectoryName, holder.RawSubject, "EntityName")
+    assertError(ac.Verify(aa, holder, time.Time{}), "Verify")
+
+    // the same key, another subject
+    assertBool(!ac.IsHolder(other), "IsHolder other")
+
+    _, err = CreateAttributeCertificate(rand.Reader, &AttributeCertificate{
+        SerialNumber: big.NewInt(102),
+        NotBefore:    now,
+        NotAfter:     now.Add(time.Minute),
+    }, aa, aaKey)
+    assertBool(err != nil, "CreateAttributeCertificate without holder")
+}
@@ -0,0 +1,387 @@
+package cmp
+
+import (
+    "io"
+    "time"
+    "bytes"
+    "errors"
+    "context"
+    "crypto"
+    "net/http"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// Client is a CMP client of a server at URL, RFC 6712.
+type Client struct {
+    URL string
+
+    // HTTPClient sends the requests, http.DefaultClient if nil.
+    HTTPClient *http.Client
+
+    // Protector protects the requests, a MACProtection with the
+    // secret shared with the CA or a SignatureProtection.
+    Protector Protector
+
+    // CACertificate is the certificate of the CA, the recipient of
+    // the requests. The signed responses must be signed by it or by a
+    // certificate it issued.
+    CACertificate *x509.Certificate
+
+    // Sender is the DER encoded sender name, the subject of the
+    // requested certificate if empty and not set by Protector.
+    Sender []byte
+
+    // ImplicitConfirm asks the CA to not wait for the certConf.
+    ImplicitConfirm bool
+}
+
+// Initialize requests a certificate for template and the public key of
+// key with an ir, the first request of a client.
+func (c *Client) Initialize(ctx context.Context, template *x509.CertificateRequest, key crypto.Signer) (*x509.Certificate, error) {
+    return c.certify(ctx, BodyIR, template, key)
+}
+
+// Certify requests a certificate for template and the public key of
+// key with a cr.
+func (c *Client) Certify(ctx context.Context, template *x509.CertificateRequest, key crypto.Signer) (*x509.Certificate, error) {
+    return c.certify(ctx, BodyCR, template, key)
+}
+
+// P10Certify requests a certificate for a PKCS #10 request with a
+// p10cr.
+func (c *Client) P10Certify(ctx context.Context, csr *x509.CertificateRequest) (*x509.Certificate, error) {
+    return c.enroll(ctx, BodyP10CR, NewP10CertRequest(csr), c.Protector)
+}
+
+// KeyUpdate requests a certificate updating current.Certificate for
+// template and the public key of key with a kur, signed with current.
+func (c *Client) KeyUpdate(ctx context.Context, current *SignatureProtection, template *x509.CertificateRequest, key crypto.Signer) (*x509.Certificate, error) {
+    req, err := NewCertRequest(template, key)
+    if err != nil {
+        return nil, err
+    }
+
+    req.OldCert = NewCertID(current.Certificate)
+
+    if err = req.Sign(key); err != nil {
+        return nil, err
+    }
+
+    return c.enroll(ctx, BodyKUR, req, current)
+}
+
+// Revoke requests the revocation of cert with the CRL reason code.
+func (c *Client) Revoke(ctx context.Context, cert *x509.Certificate, reason int) error {
+    m, err := NewPKIMessage(BodyRR, c.Sender, c.recipient())
+    if err != nil {
+        return err
+    }
+
+    m.RevRequests = []*RevRequest{
+        {
+            Issuer:       cert.RawIssuer,
+            SerialNumber: cert.SerialNumber,
+            Reason:       reason,
+        },
+    }
+
+    rep, err := c.Exchange(ctx, m, c.Protector)
+    if err != nil {
+        return err
+    }
+
+    if rep.Type != BodyRP || len(rep.RevStatus) != 1 {
+        return errors.New("cmp: unexpected response to rr")
+    }
+
+    if status := rep.RevStatus[0]; !granted(status.Status) {
+        return &StatusError{status}
+    }
+
+    return nil
+}
+
+// Exchange sends m protected by p and returns the verified response.
+// An error message is returned as a *StatusError.
+func (c *Client) Exchange(ctx context.Context, m *PKIMessage, p Protector) (*PKIMessage, error) {
+    der, err := m.Marshal(p)
+    if err != nil {
+        return nil, err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(der))
+    if err != nil {
+        return nil, err
+    }
+
+    req.Header.Set("Content-Type", "application/pkixcmp")
+
+    client := c.HTTPClient
+    if client == nil {
+        client = http.DefaultClient
+    }
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, errors.New("cmp: server returned " + resp.Status)
+    }
+
+    body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+    if err != nil {
+        return nil, err
+    }
+
+    rep, err := ParsePKIMessage(body)
+    if err != nil {
+        return nil, err
+    }
+
+    if !bytes.Equal(rep.Header.TransactionID, m.Header.TransactionID) ||
+        !bytes.Equal(rep.Header.RecipNonce, m.Header.SenderNonce) {
+        return nil, errors.New("cmp: response does not match the request")
+    }
+
+    // the unprotected error messages are returned as is
+    if rep.Type == BodyError && rep.Protection == nil {
+        return nil, rep.statusError()
+    }
+
+    if err = c.verify(rep, p); err != nil {
+        return nil, err
+    }
+
+    if rep.Type == BodyError {
+        return nil, rep.statusError()
+    }
+
+    return rep, nil
+}
+
+// verify checks the protection of rep, the response to a request
+// protected by p.
+func (c *Client) verify(rep *PKIMessage, p Protector) error {
+    if rep.Protection == nil {
+        return errors.New("cmp: unprotected response")
+    }
+
+    if rep.MACProtected() {
+        mac, ok := p.(*MACProtection)
+        if !ok {
+            return errors.New("cmp: unexpected MAC protected response")
+        }
+
+        return rep.VerifyMAC(mac.Secret)
+    }
+
+    if c.CACertificate == nil {
+        return errors.New("cmp: no CA certificate to verify the response")
+    }
+
+    signer := rep.SignerCertificate()
+    if signer == nil {
+        return errors.New("cmp: no signer certificate")
+    }
+
+    if !bytes.Equal(signer.Raw, c.CACertificate.Raw) &&
+        signer.CheckSignatureFrom(c.CACertificate) != nil {
+        return errors.New("cmp: response is not signed by the CA")
+    }
+
+    return rep.VerifySignature(signer)
+}
+
+func (c *Client) certify(ctx context.Context, typ BodyType, template *x509.CertificateRequest, key crypto.Signer) (*x509.Certificate, error) {
+    req, err := NewCertRequest(template, key)
+    if err != nil {
+        return nil, err
+    }
+
+    if err = req.Sign(key); err != nil {
+        return nil, err
+    }
+
+    return c.enroll(ctx, typ, req, c.Protector)
+}
+
+// enroll sends a request, polls while it is waiting and confirms the
+// certificate.
+func (c *Client) enroll(ctx context.Context, typ BodyType, req *CertRequest, p Protector) (*x509.Certificate, error) {
+    sender := c.Sender
+    if len(sender) == 0 {
+        sender = req.RawSubject
+    }
+
+    m, err := NewPKIMessage(typ, sender, c.recipient())
+    if err != nil {
+        return nil, err
+    }
+
+    m.Header.ImplicitConfirm = c.ImplicitConfirm
+    m.CertRequests = []*CertRequest{req}
+
+    rep, err := c.Exchange(ctx, m, p)
+    if err != nil {
+        return nil, err
+    }
+
+    for {
+        var delay time.Duration
+
+        if rep.Type == BodyPollRep {
+            resp := rep.pollResponse(req.ID)
+            if resp == nil {
+                return nil, errors.New("cmp: no poll response for the request")
+            }
+
+            delay = resp.CheckAfter
+        } else if resp := rep.certResponse(req.ID); resp == nil || resp.Status.Status != StatusWaiting {
+            break
+        }
+
+        if err = sleep(ctx, delay); err != nil {
+            return nil, err
+        }
+
+        poll, err := rep.Reply(BodyPollReq)
+        if err != nil {
+            return nil, err
+        }
+
+        poll.PollRequests = []int{req.ID}
+
+        rep, err = c.Exchange(ctx, poll, p)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if rep.Type != responseType(typ) {
+        return nil, errors.New("cmp: unexpected response to " + typ.String())
+    }
+
+    resp := rep.certResponse(req.ID)
+    if resp == nil {
+        return nil, errors.New("cmp: no response for the request")
+    }
+
+    if !granted(resp.Status.Status) {
+        return nil, &StatusError{resp.Status}
+    }
+
+    cert := resp.Certificate
+    if cert == nil {
+        return nil, errors.New("cmp: no certificate in the response")
+    }
+
+    if !bytes.Equal(cert.RawSubjectPublicKeyInfo, req.RawSubjectPublicKeyInfo) {
+        return nil, errors.New("cmp: certificate does not match the request")
+    }
+
+    if rep.Header.ImplicitConfirm {
+        return cert, nil
+    }
+
+    conf, err := rep.Reply(BodyCertConf)
+    if err != nil {
+        return nil, err
+    }
+
+    cc, err := NewCertConfirm(cert, req.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(cc.HashAlg.Algorithm) > 0 {
+        conf.Header.Version = Version3
+    }
+
+    conf.CertConfirms = []*CertConfirm{cc}
+
+    ack, err := c.Exchange(ctx, conf, p)
+    if err != nil {
+        return nil, err
+    }
+
+    if ack.Type != BodyPKIConf {
+        return nil, errors.New("cmp: unexpected response to certConf")
+    }
+
+    return cert, nil
+}
+
+func (c *Client) recipient() []byte {
+    if c.CACertificate != nil {
+        return c.CACertificate.RawSubject
+    }
+
+    return nil
+}
+
+func (m *PKIMessage) certResponse(id int) *CertResponse {
+    for _, resp := range m.CertResponses {
+        if resp.ID == id {
+            return resp
+        }
+    }
+
+    return nil
+}
+
+func (m *PKIMessage) pollResponse(id int) *PollResponse {
+    for _, resp := range m.PollResponses {
+        if resp.ID == id {
+            return resp
+        }
+    }
+
+    return nil
+}
+
+func (m *PKIMessage) statusError() error {
+    if m.Error == nil {
+        return errors.New("cmp: empty error message")
+    }
+
+    info := m.Error.Status
+    info.Text = append(info.Text, m.Error.Details...)
+
+    return &StatusError{info}
+}
+
+// responseType returns the body type of the response to a request.
+func responseType(typ BodyType) BodyType {
+    switch typ {
+        case BodyIR:
+            return BodyIP
+        case BodyKUR:
+            return BodyKUP
+    }
+
+    return BodyCP
+}
+
+func granted(status PKIStatus) bool {
+    return status == StatusAccepted || status == StatusGrantedWithMods
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+    if d <= 0 {
+        return ctx.Err()
+    }
+
+    t := time.NewTimer(d)
+    defer t.Stop()
+
+    select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case <-t.C:
+            return nil
+    }
+}
@@ -0,0 +1,1470 @@
+package cmp
+
+import (
+    "time"
+    "bytes"
+    "errors"
+    "strconv"
+    "strings"
+    "math/big"
+    "math/bits"
+    "crypto/rand"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "golang.org/x/crypto/cryptobyte"
+    cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// see RFC 4210, RFC 9480 and RFC 9483
+
+// Versions of the PKIMessages.
+const (
+    // cmp2000, RFC 4210
+    Version2 = 2
+
+    // cmp2021, RFC 9480, needed by the hashAlg of certConf
+    Version3 = 3
+)
+
+// P10CertReqID is the certReqId of the responses to p10cr.
+const P10CertReqID = -1
+
+var (
+    oidPBMAC1 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 14}
+
+    oidImplicitConfirm  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 4, 13}
+    oidRegCtrlOldCertID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 5, 1, 5}
+
+    oidExtensionReasonCode = asn1.ObjectIdentifier{2, 5, 29, 21}
+)
+
+// BodyType is the type of the body of a PKIMessage.
+type BodyType int
+
+const (
+    BodyIR       BodyType = 0
+    BodyIP       BodyType = 1
+    BodyCR       BodyType = 2
+    BodyCP       BodyType = 3
+    BodyP10CR    BodyType = 4
+    BodyKUR      BodyType = 7
+    BodyKUP      BodyType = 8
+    BodyRR       BodyType = 11
+    BodyRP       BodyType = 12
+    BodyPKIConf  BodyType = 19
+    BodyError    BodyType = 23
+    BodyCertConf BodyType = 24
+    BodyPollReq  BodyType = 25
+    BodyPollRep  BodyType = 26
+)
+
+var bodyTypeNames = map[BodyType]string{
+    BodyIR:       "ir",
+    BodyIP:       "ip",
+    BodyCR:       "cr",
+    BodyCP:       "cp",
+    BodyP10CR:    "p10cr",
+    BodyKUR:      "kur",
+    BodyKUP:      "kup",
+    BodyRR:       "rr",
+    BodyRP:       "rp",
+    BodyPKIConf:  "pkiconf",
+    BodyError:    "error",
+    BodyCertConf: "certConf",
+    BodyPollReq:  "pollReq",
+    BodyPollRep:  "pollRep",
+}
+
+func (t BodyType) String() string {
+    if name, ok := bodyTypeNames[t]; ok {
+        return name
+    }
+
+    return "body " + strconv.Itoa(int(t))
+}
+
+// PKIStatus is the status of a response.
+type PKIStatus int
+
+const (
+    StatusAccepted PKIStatus = iota
+    StatusGrantedWithMods
+    StatusRejection
+    StatusWaiting
+    StatusRevocationWarning
+    StatusRevocationNotification
+    StatusKeyUpdateWarning
+)
+
+var statusNames = []string{
+    "accepted",
+    "grantedWithMods",
+    "rejection",
+    "waiting",
+    "revocationWarning",
+    "revocationNotification",
+    "keyUpdateWarning",
+}
+
+func (s PKIStatus) String() string {
+    if s >= 0 && int(s) < len(statusNames) {
+        return statusNames[s]
+    }
+
+    return "status " + strconv.Itoa(int(s))
+}
+
+// FailureInfo is the set of the failure reasons of a response.
+type FailureInfo uint32
+
+const (
+    FailBadAlg FailureInfo = 1 << iota
+    FailBadMessageCheck
+    FailBadRequest
+    FailBadTime
+    FailBadCertID
+    FailBadDataFormat
+    FailWrongAuthority
+    FailIncorrectData
+    FailMissingTimeStamp
+    FailBadPOP
+    FailCertRevoked
+    FailCertConfirmed
+    FailWrongIntegrity
+    FailBadRecipientNonce
+    FailTimeNotAvailable
+    FailUnacceptedPolicy
+    FailUnacceptedExtension
+    FailAddInfoNotAvailable
+    FailBadSenderNonce
+    FailBadCertTemplate
+    FailSignerNotTrusted
+    FailTransactionIDInUse
+    FailUnsupportedVersion
+    FailNotAuthorized
+    FailSystemUnavail
+    FailSystemFailure
+    FailDuplicateCertReq
+)
+
+var failureNames = []string{
+    "badAlg",
+    "badMessageCheck",
+    "badRequest",
+    "badTime",
+    "badCertId",
+    "badDataFormat",
+    "wrongAuthority",
+    "incorrectData",
+    "missingTimeStamp",
+    "badPOP",
+    "certRevoked",
+    "certConfirmed",
+    "wrongIntegrity",
+    "badRecipientNonce",
+    "timeNotAvailable",
+    "unacceptedPolicy",
+    "unacceptedExtension",
+    "addInfoNotAvailable",
+    "badSenderNonce",
+    "badCertTemplate",
+    "signerNotTrusted",
+    "transactionIdInUse",
+    "unsupportedVersion",
+    "notAuthorized",
+    "systemUnavail",
+    "systemFailure",
+    "duplicateCertReq",
+}
+
+func (f FailureInfo) String() string {
+    var names []string
+    for i, name := range failureNames {
+        if f & (1 << uint(i)) != 0 {
+            names = append(names, name)
+        }
+    }
+
+    return strings.Join(names, ",")
+}
+
+// StatusInfo is the PKIStatusInfo of a response.
+type StatusInfo struct {
+    Status   PKIStatus
+    Text     []string
+    FailInfo FailureInfo
+}
+
+// StatusError is returned for a rejected request.
+type StatusError struct {
+    StatusInfo
+}
+
+func (e *StatusError) Error() string {
+    msg := "cmp: " + e.Status.String()
+    if e.FailInfo != 0 {
+        msg += " (" + e.FailInfo.String() + ")"
+    }
+
+    if len(e.Text) > 0 {
+        msg += ": " + strings.Join(e.Text, "; ")
+    }
+
+    return msg
+}
+
+// Header is the PKIHeader of a PKIMessage. The names are DER encoded
+// directory names, an empty name is the NULL-DN.
+type Header struct {
+    Version       int
+    Sender        []byte
+    Recipient     []byte
+    MessageTime   time.Time
+    ProtectionAlg pkix.AlgorithmIdentifier
+    SenderKID     []byte
+    RecipKID      []byte
+    TransactionID []byte
+    SenderNonce   []byte
+    RecipNonce    []byte
+    FreeText      []string
+
+    // ImplicitConfirm is the implicitConfirm general info, asked by
+    // the client and granted by the server to skip certConf.
+    ImplicitConfirm bool
+}
+
+// CertResponse is the response to a certificate request.
+type CertResponse struct {
+    ID          int
+    Status      StatusInfo
+    Certificate *x509.Certificate
+}
+
+// RevRequest is a revocation request of a rr.
+type RevRequest struct {
+    // Issuer is the DER encoded issuer name of the certificate.
+    Issuer       []byte
+    SerialNumber *big.Int
+
+    // Reason is the CRL reason code.
+    Reason int
+}
+
+// CertConfirm is the CertStatus of a certConf.
+type CertConfirm struct {
+    CertHash []byte
+    ID       int
+
+    // Status is nil for the accepted certificates.
+    Status *StatusInfo
+
+    // HashAlg is the hash algorithm of CertHash when the signature
+    // algorithm of the certificate has none.
+    HashAlg pkix.AlgorithmIdentifier
+}
+
+// PollResponse is the response of a pollRep.
+type PollResponse struct {
+    ID         int
+    CheckAfter time.Duration
+    Reason     []string
+}
+
+// ErrorContent is the content of an error message.
+type ErrorContent struct {
+    Status  StatusInfo
+    Code    int
+    Details []string
+}
+
+// PKIMessage is a CMP message. The fields of the body type are set.
+type PKIMessage struct {
+    Raw    []byte
+    Header Header
+    Type   BodyType
+
+    // ir, cr, kur and p10cr, the request of a p10cr has a CSR
+    CertRequests []*CertRequest
+
+    // ip, cp and kup
+    CAPubs        []*x509.Certificate
+    CertResponses []*CertResponse
+
+    // rr and rp
+    RevRequests []*RevRequest
+    RevStatus   []StatusInfo
+
+    // certConf
+    CertConfirms []*CertConfirm
+
+    // pollReq and pollRep, the IDs of the certificate requests
+    PollRequests  []int
+    PollResponses []*PollResponse
+
+    // error
+    Error *ErrorContent
+
+    Protection []byte
+    ExtraCerts []*x509.Certificate
+
+    // the DER encoded ProtectedPart
+    protectedPart []byte
+}
+
+// NewPKIMessage returns a message of a new transaction, with a random
+// transaction ID and sender nonce.
+func NewPKIMessage(typ BodyType, sender, recipient []byte) (*PKIMessage, error) {
+    tid, err := newNonce()
+    if err != nil {
+        return nil, err
+    }
+
+    nonce, err := newNonce()
+    if err != nil {
+        return nil, err
+    }
+
+    return &PKIMessage{
+        Header: Header{
+            Version:       Version2,
+            Sender:        sender,
+            Recipient:     recipient,
+            MessageTime:   time.Now(),
+            TransactionID: tid,
+            SenderNonce:   nonce,
+        },
+        Type: typ,
+    }, nil
+}
+
+// Reply returns the next message of the transaction of m, sent back
+// to its sender.
+func (m *PKIMessage) Reply(typ BodyType) (*PKIMessage, error) {
+    nonce, err := newNonce()
+    if err != nil {
+        return nil, err
+    }
+
+    version := m.Header.Version
+    if version == 0 {
+        version = Version2
+    }
+
+    return &PKIMessage{
+        Header: Header{
+            Version:       version,
+            Sender:        m.Header.Recipient,
+            Recipient:     m.Header.Sender,
+            MessageTime:   time.Now(),
+            RecipKID:      m.Header.SenderKID,
+            TransactionID: m.Header.TransactionID,
+            SenderNonce:   nonce,
+            RecipNonce:    m.Header.SenderNonce,
+        },
+        Type: typ,
+    }, nil
+}
+
+// ErrorReply returns an error message answering m.
+func (m *PKIMessage) ErrorReply(info FailureInfo, text string) (*PKIMessage, error) {
+    rep, err := m.Reply(BodyError)
+    if err != nil {
+        return nil, err
+    }
+
+    rep.Error = &ErrorContent{
+        Status: rejection(info, text),
+    }
+
+    return rep, nil
+}
+
+// Marshal encodes m protected by p, unprotected if p is nil, and sets
+// its Raw and Protection.
+func (m *PKIMessage) Marshal(p Protector) ([]byte, error) {
+    if p != nil {
+        if err := p.Prepare(m); err != nil {
+            return nil, err
+        }
+    }
+
+    header, err := m.Header.marshal()
+    if err != nil {
+        return nil, err
+    }
+
+    body, err := m.marshalBody()
+    if err != nil {
+        return nil, err
+    }
+
+    m.protectedPart, err = protectedPart(header, body)
+    if err != nil {
+        return nil, err
+    }
+
+    m.Protection = nil
+    if p != nil {
+        m.Protection, err = p.Protect(m, m.protectedPart)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        b.AddBytes(header)
+        b.AddBytes(body)
+
+        if m.Protection != nil {
+            b.AddASN1(contextTag(0), func(b *cryptobyte.Builder) {
+                b.AddASN1BitString(m.Protection)
+            })
+        }
+
+        if len(m.ExtraCerts) > 0 {
+            b.AddASN1(contextTag(1), func(b *cryptobyte.Builder) {
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    for _, cert := range m.ExtraCerts {
+                        b.AddBytes(cert.Raw)
+                    }
+                })
+            })
+        }
+    })
+
+    m.Raw, err = b.Bytes()
+    if err != nil {
+        return nil, err
+    }
+
+    return m.Raw, nil
+}
+
+// MACProtected reports whether m is protected with a MAC.
+func (m *PKIMessage) MACProtected() bool {
+    return m.Header.ProtectionAlg.Algorithm.Equal(oidPBMAC1)
+}
+
+// SignerCertificate returns the extra certificate that signed m, the
+// one with the sender key ID or the first one, nil if m has none.
+func (m *PKIMessage) SignerCertificate() *x509.Certificate {
+    if len(m.ExtraCerts) == 0 {
+        return nil
+    }
+
+    if len(m.Header.SenderKID) > 0 {
+        for _, cert := range m.ExtraCerts {
+            if bytes.Equal(cert.SubjectKeyId, m.Header.SenderKID) {
+                return cert
+            }
+        }
+    }
+
+    return m.ExtraCerts[0]
+}
+
+// VerifySignature verifies the signature protection of m with the
+// public key of cert. Checking that cert is trusted is left to the
+// caller.
+func (m *PKIMessage) VerifySignature(cert *x509.Certificate) error {
+    if m.Protection == nil || m.MACProtected() {
+        return errors.New("cmp: message is not signed")
+    }
+
+    algo := x509.SignatureAlgorithmFromAI(m.Header.ProtectionAlg)
+    if algo == x509.UnknownSignatureAlgorithm {
+        return errors.New("cmp: unsupported protection algorithm")
+    }
+
+    return x509.CheckSignatureWithPublicKey(algo, m.protectedPart, m.Protection, cert.PublicKey)
+}
+
+// ParsePKIMessage parses a DER encoded PKIMessage. The protection is
+// not verified, see VerifySignature and VerifyMAC.
+func ParsePKIMessage(der []byte) (*PKIMessage, error) {
+    input := cryptobyte.String(der)
+
+    var msg cryptobyte.String
+    if !input.ReadASN1(&msg, cryptobyte_asn1.SEQUENCE) || !input.Empty() {
+        return nil, errors.New("cmp: invalid PKIMessage")
+    }
+
+    var header, body cryptobyte.String
+    var bodyTag cryptobyte_asn1.Tag
+    if !msg.ReadASN1Element(&header, cryptobyte_asn1.SEQUENCE) ||
+        !msg.ReadAnyASN1Element(&body, &bodyTag) {
+        return nil, errors.New("cmp: invalid PKIMessage")
+    }
+
+    m := &PKIMessage{
+        Raw: der,
+    }
+
+    var err error
+    if m.Header, err = parseHeader(header); err != nil {
+        return nil, err
+    }
+
+    if bodyTag & 0xe0 != 0xa0 {
+        return nil, errors.New("cmp: invalid PKIBody")
+    }
+
+    m.Type = BodyType(bodyTag & 0x1f)
+
+    // body is kept for the protected part
+    content := body
+    if !content.ReadASN1(&content, bodyTag) {
+        return nil, errors.New("cmp: invalid PKIBody")
+    }
+
+    if err = m.parseBody(content); err != nil {
+        return nil, err
+    }
+
+    var protection cryptobyte.String
+    var present bool
+    if !msg.ReadOptionalASN1(&protection, &present, contextTag(0)) {
+        return nil, errors.New("cmp: invalid protection")
+    }
+
+    if present {
+        var bs asn1.BitString
+        if !protection.ReadASN1BitString(&bs) || bs.BitLength % 8 != 0 {
+            return nil, errors.New("cmp: invalid protection")
+        }
+
+        m.Protection = bs.Bytes
+    }
+
+    var extra cryptobyte.String
+    if !msg.ReadOptionalASN1(&extra, &present, contextTag(1)) {
+        return nil, errors.New("cmp: invalid extraCerts")
+    }
+
+    if present {
+        if m.ExtraCerts, err = parseCertificates(extra); err != nil {
+            return nil, err
+        }
+    }
+
+    if !msg.Empty() {
+        return nil, errors.New("cmp: trailing data in PKIMessage")
+    }
+
+    m.protectedPart, err = protectedPart(header, body)
+    if err != nil {
+        return nil, err
+    }
+
+    return m, nil
+}
+
+// protectedPart returns the ProtectedPart of the DER encoded header
+// and body.
+func protectedPart(header, body []byte) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        b.AddBytes(header)
+        b.AddBytes(body)
+    })
+
+    return b.Bytes()
+}
+
+func (h *Header) marshal() ([]byte, error) {
+    var alg []byte
+    if len(h.ProtectionAlg.Algorithm) > 0 {
+        var err error
+        if alg, err = asn1.Marshal(h.ProtectionAlg); err != nil {
+            return nil, err
+        }
+    }
+
+    version := h.Version
+    if version == 0 {
+        version = Version2
+    }
+
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        b.AddASN1Int64(int64(version))
+        addGeneralName(b, h.Sender)
+        addGeneralName(b, h.Recipient)
+
+        if !h.MessageTime.IsZero() {
+            b.AddASN1(contextTag(0), func(b *cryptobyte.Builder) {
+                b.AddASN1GeneralizedTime(h.MessageTime.UTC().Truncate(time.Second))
+            })
+        }
+
+        if alg != nil {
+            b.AddASN1(contextTag(1), func(b *cryptobyte.Builder) {
+                b.AddBytes(alg)
+            })
+        }
+
+        for i, value := range [][]byte{
+            h.SenderKID,
+            h.RecipKID,
+            h.TransactionID,
+            h.SenderNonce,
+            h.RecipNonce,
+        } {
+            if len(value) == 0 {
+                continue
+            }
+
+            b.AddASN1(contextTag(2 + i), func(b *cryptobyte.Builder) {
+                b.AddASN1OctetString(value)
+            })
+        }
+
+        if len(h.FreeText) > 0 {
+            b.AddASN1(contextTag(7), func(b *cryptobyte.Builder) {
+                addFreeText(b, h.FreeText)
+            })
+        }
+
+        if h.ImplicitConfirm {
+            b.AddASN1(contextTag(8), func(b *cryptobyte.Builder) {
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                        b.AddASN1ObjectIdentifier(oidImplicitConfirm)
+                        b.AddASN1NULL()
+                    })
+                })
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+func parseHeader(der cryptobyte.String) (h Header, err error) {
+    invalid := errors.New("cmp: invalid PKIHeader")
+
+    var s cryptobyte.String
+    var version int64
+    if !der.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) || !s.ReadASN1Integer(&version) {
+        return h, invalid
+    }
+
+    h.Version = int(version)
+
+    if h.Sender, err = readGeneralName(&s); err != nil {
+        return h, err
+    }
+
+    if h.Recipient, err = readGeneralName(&s); err != nil {
+        return h, err
+    }
+
+    var field cryptobyte.String
+    var present bool
+
+    if !s.ReadOptionalASN1(&field, &present, contextTag(0)) ||
+        present && !field.ReadASN1GeneralizedTime(&h.MessageTime) {
+        return h, invalid
+    }
+
+    if !s.ReadOptionalASN1(&field, &present, contextTag(1)) {
+        return h, invalid
+    }
+
+    if present {
+        if rest, err := asn1.Unmarshal(field, &h.ProtectionAlg); err != nil || len(rest) > 0 {
+            return h, invalid
+        }
+    }
+
+    for i, out := range []*[]byte{
+        &h.SenderKID,
+        &h.RecipKID,
+        &h.TransactionID,
+        &h.SenderNonce,
+        &h.RecipNonce,
+    } {
+        if !s.ReadOptionalASN1(&field, &present, contextTag(2 + i)) ||
+            present && !field.ReadASN1Bytes(out, cryptobyte_asn1.OCTET_STRING) {
+            return h, invalid
+        }
+    }
+
+    if !s.ReadOptionalASN1(&field, &present, contextTag(7)) {
+        return h, invalid
+    }
+
+    if present {
+        if h.FreeText, err = readFreeText(&field); err != nil {
+            return h, err
+        }
+    }
+
+    if !s.ReadOptionalASN1(&field, &present, contextTag(8)) {
+        return h, invalid
+    }
+
+    if present {
+        var infos cryptobyte.String
+        if !field.ReadASN1(&infos, cryptobyte_asn1.SEQUENCE) {
+            return h, invalid
+        }
+
+        for !infos.Empty() {
+            var info cryptobyte.String
+            var oid asn1.ObjectIdentifier
+            if !infos.ReadASN1(&info, cryptobyte_asn1.SEQUENCE) || !info.ReadASN1ObjectIdentifier(&oid) {
+                return h, invalid
+            }
+
+            if oid.Equal(oidImplicitConfirm) {
+                h.ImplicitConfirm = true
+            }
+        }
+    }
+
+    if !s.Empty() {
+        return h, invalid
+    }
+
+    return h, nil
+}
+
+func (m *PKIMessage) marshalBody() ([]byte, error) {
+    var content []byte
+    var err error
+
+    switch m.Type {
+        case BodyIR, BodyCR, BodyKUR:
+            content, err = marshalCertReqMessages(m.CertRequests)
+        case BodyP10CR:
+            if len(m.CertRequests) != 1 || m.CertRequests[0].CSR == nil {
+                return nil, errors.New("cmp: p10cr needs a single PKCS #10 request")
+            }
+
+            content = m.CertRequests[0].CSR.Raw
+        case BodyIP, BodyCP, BodyKUP:
+            content, err = marshalCertRepMessage(m.CAPubs, m.CertResponses)
+        case BodyRR:
+            content, err = marshalRevRequests(m.RevRequests)
+        case BodyRP:
+            content, err = marshalRevStatus(m.RevStatus)
+        case BodyPKIConf:
+            content = []byte{0x05, 0x00}
+        case BodyError:
+            if m.Error == nil {
+                return nil, errors.New("cmp: error message without content")
+            }
+
+            content, err = marshalError(m.Error)
+        case BodyCertConf:
+            content, err = marshalCertConfirms(m.CertConfirms)
+        case BodyPollReq:
+            content, err = marshalPollRequests(m.PollRequests)
+        case BodyPollRep:
+            content, err = marshalPollResponses(m.PollResponses)
+        default:
+            return nil, errors.New("cmp: unsupported " + m.Type.String())
+    }
+
+    if err != nil {
+        return nil, err
+    }
+
+    var b cryptobyte.Builder
+    b.AddASN1(contextTag(int(m.Type)), func(b *cryptobyte.Builder) {
+        b.AddBytes(content)
+    })
+
+    return b.Bytes()
+}
+
+func (m *PKIMessage) parseBody(content cryptobyte.String) (err error) {
+    switch m.Type {
+        case BodyIR, BodyCR, BodyKUR:
+            m.CertRequests, err = parseCertReqMessages(content)
+        case BodyP10CR:
+            var csr *x509.CertificateRequest
+            if csr, err = x509.ParseCertificateRequest(content); err != nil {
+                return err
+            }
+
+            m.CertRequests = []*CertRequest{newP10CertRequest(csr)}
+        case BodyIP, BodyCP, BodyKUP:
+            m.CAPubs, m.CertResponses, err = parseCertRepMessage(content)
+        case BodyRR:
+            m.RevRequests, err = parseRevRequests(content)
+        case BodyRP:
+            m.RevStatus, err = parseRevStatus(content)
+        case BodyPKIConf:
+            if !content.SkipASN1(cryptobyte_asn1.NULL) || !content.Empty() {
+                return errors.New("cmp: invalid pkiconf")
+            }
+        case BodyError:
+            m.Error, err = parseError(content)
+        case BodyCertConf:
+            m.CertConfirms, err = parseCertConfirms(content)
+        case BodyPollReq:
+            m.PollRequests, err = parsePollRequests(content)
+        case BodyPollRep:
+            m.PollResponses, err = parsePollResponses(content)
+        default:
+            return errors.New("cmp: unsupported " + m.Type.String())
+    }
+
+    return err
+}
+
+func marshalCertRepMessage(caPubs []*x509.Certificate, responses []*CertResponse) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        if len(caPubs) > 0 {
+            b.AddASN1(contextTag(1), func(b *cryptobyte.Builder) {
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    for _, cert := range caPubs {
+                        b.AddBytes(cert.Raw)
+                    }
+                })
+            })
+        }
+
+        b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+            for _, resp := range responses {
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    b.AddASN1Int64(int64(resp.ID))
+                    addStatusInfo(b, resp.Status)
+
+                    if resp.Certificate != nil {
+                        b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                            b.AddASN1(contextTag(0), func(b *cryptobyte.Builder) {
+                                b.AddBytes(resp.Certificate.Raw)
+                            })
+                        })
+                    }
+                })
+            }
+        })
+    })
+
+    return b.Bytes()
+}
+
+func parseCertRepMessage(content cryptobyte.String) (caPubs []*x509.Certificate, responses []*CertResponse, err error) {
+    invalid := errors.New("cmp: invalid CertRepMessage")
+
+    var s, field cryptobyte.String
+    var present bool
+    if !content.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) ||
+        !s.ReadOptionalASN1(&field, &present, contextTag(1)) {
+        return nil, nil, invalid
+    }
+
+    if present {
+        if caPubs, err = parseCertificates(field); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    var seq cryptobyte.String
+    if !s.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) || !s.Empty() {
+        return nil, nil, invalid
+    }
+
+    for !seq.Empty() {
+        var r cryptobyte.String
+        var id int64
+        if !seq.ReadASN1(&r, cryptobyte_asn1.SEQUENCE) || !r.ReadASN1Integer(&id) {
+            return nil, nil, invalid
+        }
+
+        resp := &CertResponse{
+            ID: int(id),
+        }
+
+        if resp.Status, err = readStatusInfo(&r); err != nil {
+            return nil, nil, err
+        }
+
+        var pair cryptobyte.String
+        if !r.ReadOptionalASN1(&pair, &present, cryptobyte_asn1.SEQUENCE) {
+            return nil, nil, invalid
+        }
+
+        if present {
+            var cert cryptobyte.String
+            var tag cryptobyte_asn1.Tag
+            if !pair.ReadAnyASN1(&cert, &tag) {
+                return nil, nil, invalid
+            }
+
+            if tag != contextTag(0) {
+                return nil, nil, errors.New("cmp: encrypted certificates are not supported")
+            }
+
+            if resp.Certificate, err = x509.ParseCertificate(cert); err != nil {
+                return nil, nil, err
+            }
+        }
+
+        responses = append(responses, resp)
+    }
+
+    return caPubs, responses, nil
+}
+
+func marshalRevRequests(reqs []*RevRequest) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        for _, req := range reqs {
+            reason, err := asn1.Marshal(asn1.Enumerated(req.Reason))
+            if err != nil {
+                b.SetError(err)
+                return
+            }
+
+            b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                // the certTemplate with the issuer and the serial number
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    addImplicitBigInt(b, 1, req.SerialNumber)
+                    b.AddASN1(contextTag(3), func(b *cryptobyte.Builder) {
+                        b.AddBytes(req.Issuer)
+                    })
+                })
+
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                        b.AddASN1ObjectIdentifier(oidExtensionReasonCode)
+                        b.AddASN1OctetString(reason)
+                    })
+                })
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+func parseRevRequests(content cryptobyte.String) (reqs []*RevRequest, err error) {
+    invalid := errors.New("cmp: invalid RevReqContent")
+
+    var seq cryptobyte.String
+    if !content.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return nil, invalid
+    }
+
+    for !seq.Empty() {
+        var details cryptobyte.String
+        if !seq.ReadASN1(&details, cryptobyte_asn1.SEQUENCE) {
+            return nil, invalid
+        }
+
+        tmpl, err := readCertTemplate(&details)
+        if err != nil {
+            return nil, err
+        }
+
+        if tmpl.serialNumber == nil || tmpl.issuer == nil {
+            return nil, errors.New("cmp: revocation request without issuer and serial number")
+        }
+
+        req := &RevRequest{
+            Issuer:       tmpl.issuer,
+            SerialNumber: tmpl.serialNumber,
+        }
+
+        var exts cryptobyte.String
+        var present bool
+        if !details.ReadOptionalASN1(&exts, &present, cryptobyte_asn1.SEQUENCE) {
+            return nil, invalid
+        }
+
+        for !exts.Empty() {
+            var ext cryptobyte.String
+            if !exts.ReadASN1Element(&ext, cryptobyte_asn1.SEQUENCE) {
+                return nil, invalid
+            }
+
+            var e pkix.Extension
+            if _, err := asn1.Unmarshal(ext, &e); err != nil {
+                return nil, invalid
+            }
+
+            if e.Id.Equal(oidExtensionReasonCode) {
+                var reason asn1.Enumerated
+                if _, err := asn1.Unmarshal(e.Value, &reason); err != nil {
+                    return nil, invalid
+                }
+
+                req.Reason = int(reason)
+            }
+        }
+
+        reqs = append(reqs, req)
+    }
+
+    return reqs, nil
+}
+
+func marshalRevStatus(status []StatusInfo) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+            for _, si := range status {
+                addStatusInfo(b, si)
+            }
+        })
+    })
+
+    return b.Bytes()
+}
+
+func parseRevStatus(content cryptobyte.String) (status []StatusInfo, err error) {
+    var s, seq cryptobyte.String
+    if !content.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) || !s.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return nil, errors.New("cmp: invalid RevRepContent")
+    }
+
+    for !seq.Empty() {
+        si, err := readStatusInfo(&seq)
+        if err != nil {
+            return nil, err
+        }
+
+        status = append(status, si)
+    }
+
+    return status, nil
+}
+
+func marshalError(e *ErrorContent) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        addStatusInfo(b, e.Status)
+
+        if e.Code != 0 {
+            b.AddASN1Int64(int64(e.Code))
+        }
+
+        if len(e.Details) > 0 {
+            addFreeText(b, e.Details)
+        }
+    })
+
+    return b.Bytes()
+}
+
+func parseError(content cryptobyte.String) (*ErrorContent, error) {
+    var s cryptobyte.String
+    if !content.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) {
+        return nil, errors.New("cmp: invalid ErrorMsgContent")
+    }
+
+    status, err := readStatusInfo(&s)
+    if err != nil {
+        return nil, err
+    }
+
+    e := &ErrorContent{
+        Status: status,
+    }
+
+    if s.PeekASN1Tag(cryptobyte_asn1.INTEGER) {
+        var code int64
+        if !s.ReadASN1Integer(&code) {
+            return nil, errors.New("cmp: invalid ErrorMsgContent")
+        }
+
+        e.Code = int(code)
+    }
+
+    if s.PeekASN1Tag(cryptobyte_asn1.SEQUENCE) {
+        if e.Details, err = readFreeText(&s); err != nil {
+            return nil, err
+        }
+    }
+
+    return e, nil
+}
+
+func marshalCertConfirms(confirms []*CertConfirm) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        for _, c := range confirms {
+            var alg []byte
+            if len(c.HashAlg.Algorithm) > 0 {
+                var err error
+                if alg, err = asn1.Marshal(c.HashAlg); err != nil {
+                    b.SetError(err)
+                    return
+                }
+            }
+
+            b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                b.AddASN1OctetString(c.CertHash)
+                b.AddASN1Int64(int64(c.ID))
+
+                if c.Status != nil {
+                    addStatusInfo(b, *c.Status)
+                }
+
+                if alg != nil {
+                    b.AddASN1(contextTag(0), func(b *cryptobyte.Builder) {
+                        b.AddBytes(alg)
+                    })
+                }
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+func parseCertConfirms(content cryptobyte.String) (confirms []*CertConfirm, err error) {
+    invalid := errors.New("cmp: invalid CertConfirmContent")
+
+    var seq cryptobyte.String
+    if !content.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return nil, invalid
+    }
+
+    for !seq.Empty() {
+        var s cryptobyte.String
+        var id int64
+
+        c := new(CertConfirm)
+        if !seq.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) ||
+            !s.ReadASN1Bytes(&c.CertHash, cryptobyte_asn1.OCTET_STRING) ||
+            !s.ReadASN1Integer(&id) {
+            return nil, invalid
+        }
+
+        c.ID = int(id)
+
+        if s.PeekASN1Tag(cryptobyte_asn1.SEQUENCE) {
+            status, err := readStatusInfo(&s)
+            if err != nil {
+                return nil, err
+            }
+
+            c.Status = &status
+        }
+
+        var alg cryptobyte.String
+        var present bool
+        if !s.ReadOptionalASN1(&alg, &present, contextTag(0)) {
+            return nil, invalid
+        }
+
+        if present {
+            if _, err := asn1.Unmarshal(alg, &c.HashAlg); err != nil {
+                return nil, invalid
+            }
+        }
+
+        confirms = append(confirms, c)
+    }
+
+    return confirms, nil
+}
+
+func marshalPollRequests(ids []int) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        for _, id := range ids {
+            b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                b.AddASN1Int64(int64(id))
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+func parsePollRequests(content cryptobyte.String) (ids []int, err error) {
+    var seq cryptobyte.String
+    if !content.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return nil, errors.New("cmp: invalid PollReqContent")
+    }
+
+    for !seq.Empty() {
+        var s cryptobyte.String
+        var id int64
+        if !seq.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) || !s.ReadASN1Integer(&id) {
+            return nil, errors.New("cmp: invalid PollReqContent")
+        }
+
+        ids = append(ids, int(id))
+    }
+
+    return ids, nil
+}
+
+func marshalPollResponses(resps []*PollResponse) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        for _, r := range resps {
+            b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                b.AddASN1Int64(int64(r.ID))
+                b.AddASN1Int64(int64(r.CheckAfter / time.Second))
+
+                if len(r.Reason) > 0 {
+                    addFreeText(b, r.Reason)
+                }
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+func parsePollResponses(content cryptobyte.String) (resps []*PollResponse, err error) {
+    invalid := errors.New("cmp: invalid PollRepContent")
+
+    var seq cryptobyte.String
+    if !content.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return nil, invalid
+    }
+
+    for !seq.Empty() {
+        var s cryptobyte.String
+        var id, checkAfter int64
+        if !seq.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) ||
+            !s.ReadASN1Integer(&id) ||
+            !s.ReadASN1Integer(&checkAfter) {
+            return nil, invalid
+        }
+
+        r := &PollResponse{
+            ID:         int(id),
+            CheckAfter: time.Duration(checkAfter) * time.Second,
+        }
+
+        if !s.Empty() {
+            if r.Reason, err = readFreeText(&s); err != nil {
+                return nil, err
+            }
+        }
+
+        resps = append(resps, r)
+    }
+
+    return resps, nil
+}
+
+func addStatusInfo(b *cryptobyte.Builder, si StatusInfo) {
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        b.AddASN1Int64(int64(si.Status))
+
+        if len(si.Text) > 0 {
+            addFreeText(b, si.Text)
+        }
+
+        if si.FailInfo != 0 {
+            // a named bit list, without trailing zero bits
+            n := bits.Len32(uint32(si.FailInfo))
+            data := make([]byte, (n + 7) / 8)
+            for i := 0; i < n; i++ {
+                if si.FailInfo & (1 << uint(i)) != 0 {
+                    data[i / 8] |= 0x80 >> uint(i % 8)
+                }
+            }
+
+            b.AddASN1(cryptobyte_asn1.BIT_STRING, func(b *cryptobyte.Builder) {
+                b.AddUint8(uint8(len(data) * 8 - n))
+                b.AddBytes(data)
+            })
+        }
+    })
+}
+
+func readStatusInfo(s *cryptobyte.String) (si StatusInfo, err error) {
+    invalid := errors.New("cmp: invalid PKIStatusInfo")
+
+    var seq cryptobyte.String
+    var status int64
+    if !s.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) || !seq.ReadASN1Integer(&status) {
+        return si, invalid
+    }
+
+    si.Status = PKIStatus(status)
+
+    if seq.PeekASN1Tag(cryptobyte_asn1.SEQUENCE) {
+        if si.Text, err = readFreeText(&seq); err != nil {
+            return si, err
+        }
+    }
+
+    if !seq.Empty() {
+        var bs asn1.BitString
+        if !seq.ReadASN1BitString(&bs) || !seq.Empty() {
+            return si, invalid
+        }
+
+        for i := 0; i < bs.BitLength && i < len(failureNames); i++ {
+            if bs.At(i) == 1 {
+                si.FailInfo |= 1 << uint(i)
+            }
+        }
+    }
+
+    return si, nil
+}
+
+func addFreeText(b *cryptobyte.Builder, text []string) {
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        for _, t := range text {
+            b.AddASN1(cryptobyte_asn1.UTF8String, func(b *cryptobyte.Builder) {
+                b.AddBytes([]byte(t))
+            })
+        }
+    })
+}
+
+func readFreeText(s *cryptobyte.String) (text []string, err error) {
+    var seq cryptobyte.String
+    if !s.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return nil, errors.New("cmp: invalid PKIFreeText")
+    }
+
+    for !seq.Empty() {
+        var t cryptobyte.String
+        if !seq.ReadASN1(&t, cryptobyte_asn1.UTF8String) {
+            return nil, errors.New("cmp: invalid PKIFreeText")
+        }
+
+        text = append(text, string(t))
+    }
+
+    return text, nil
+}
+
+// addGeneralName adds the directoryName of the DER encoded name, the
+// NULL-DN if it is empty.
+func addGeneralName(b *cryptobyte.Builder, name []byte) {
+    b.AddASN1(contextTag(4), func(b *cryptobyte.Builder) {
+        if len(name) == 0 {
+            b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {})
+            return
+        }
+
+        b.AddBytes(name)
+    })
+}
+
+// readGeneralName reads a GeneralName and returns the DER encoded name
+// of a directoryName, nil for the other names.
+func readGeneralName(s *cryptobyte.String) ([]byte, error) {
+    var gn cryptobyte.String
+    var tag cryptobyte_asn1.Tag
+    if !s.ReadAnyASN1(&gn, &tag) {
+        return nil, errors.New("cmp: invalid GeneralName")
+    }
+
+    if tag != contextTag(4) {
+        return nil, nil
+    }
+
+    var name cryptobyte.String
+    if !gn.ReadASN1Element(&name, cryptobyte_asn1.SEQUENCE) || !gn.Empty() {
+        return nil, errors.New("cmp: invalid GeneralName")
+    }
+
+    return []byte(name), nil
+}
+
+func parseCertificates(s cryptobyte.String) (certs []*x509.Certificate, err error) {
+    var seq cryptobyte.String
+    if !s.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) || !s.Empty() {
+        return nil, errors.New("cmp: invalid certificates")
+    }
+
+    for !seq.Empty() {
+        var der cryptobyte.String
+        if !seq.ReadASN1Element(&der, cryptobyte_asn1.SEQUENCE) {
+            return nil, errors.New("cmp: invalid certificates")
+        }
+
+        cert, err := x509.ParseCertificate(der)
+        if err != nil {
+            return nil, err
+        }
+
+        certs = append(certs, cert)
+    }
+
+    return certs, nil
+}
+
+// addImplicitBigInt adds n as an INTEGER with the implicit context
+// specific tag.
+func addImplicitBigInt(b *cryptobyte.Builder, tag int, n *big.Int) {
+    var ib cryptobyte.Builder
+    ib.AddASN1BigInt(n)
+
+    der, err := ib.Bytes()
+    if err != nil {
+        b.SetError(err)
+        return
+    }
+
+    var content cryptobyte.String
+    input := cryptobyte.String(der)
+    input.ReadASN1(&content, cryptobyte_asn1.INTEGER)
+
+    b.AddASN1(cryptobyte_asn1.Tag(tag).ContextSpecific(), func(b *cryptobyte.Builder) {
+        b.AddBytes(content)
+    })
+}
+
+// parseImplicitBigInt parses the content of an implicitly tagged
+// INTEGER.
+func parseImplicitBigInt(content []byte) (*big.Int, bool) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.INTEGER, func(b *cryptobyte.Builder) {
+        b.AddBytes(content)
+    })
+
+    der, err := b.Bytes()
+    if err != nil {
+        return nil, false
+    }
+
+    n := new(big.Int)
+
+    input := cryptobyte.String(der)
+    if !input.ReadASN1Integer(n) {
+        return nil, false
+    }
+
+    return n, true
+}
+
+func rejection(info FailureInfo, text string) StatusInfo {
+    si := StatusInfo{
+        Status:   StatusRejection,
+        FailInfo: info,
+    }
+
+    if text != "" {
+        si.Text = []string{text}
+    }
+
+    return si
+}
+
+func contextTag(n int) cryptobyte_asn1.Tag {
+    return cryptobyte_asn1.Tag(n).ContextSpecific().Constructed()
+}
+
+func newNonce() ([]byte, error) {
+    nonce := make([]byte, 16)
+    if _, err := rand.Read(nonce); err != nil {
+        return nil, err
+    }
+
+    return nonce, nil
+}
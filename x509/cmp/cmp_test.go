@@ -0,0 +1,563 @@
+package cmp
+
+import (
+    "time"
+    "errors"
+    "context"
+    "testing"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "crypto"
+    "crypto/rsa"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/ed25519"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+type testCA struct {
+    cert    *x509.Certificate
+    key     crypto.Signer
+    serial  int64
+    revoked map[string]int
+
+    // the requests answered with ErrWaiting until approve
+    wait    bool
+    approve bool
+}
+
+func newTestCA(t *testing.T) *testCA {
+    key, err := sm2.GenerateKey(rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    template := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: "CMP Root"},
+        NotBefore:             time.Now().Add(-time.Hour),
+        NotAfter:              time.Now().Add(24 * time.Hour),
+        KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SubjectKeyId:          []byte{1, 2, 3, 4},
+        SignatureAlgorithm:    x509.SM2WithSM3,
+    }
+
+    der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return &testCA{
+        cert:    cert,
+        key:     key,
+        serial:  1,
+        revoked: make(map[string]int),
+    }
+}
+
+func (ca *testCA) signer() *SignatureProtection {
+    return &SignatureProtection{
+        Certificate: ca.cert,
+        Key:         ca.key,
+    }
+}
+
+func (ca *testCA) issue(req *CertRequest) (*x509.Certificate, error) {
+    ca.serial++
+
+    template := req.Template()
+    template.SerialNumber = big.NewInt(ca.serial)
+    template.NotBefore = time.Now().Add(-time.Minute)
+    template.NotAfter = time.Now().Add(time.Hour)
+    template.KeyUsage = x509.KeyUsageDigitalSignature
+    template.SubjectKeyId = big.NewInt(ca.serial).Bytes()
+    template.SignatureAlgorithm = x509.SM2WithSM3
+
+    der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, req.PublicKey, ca.key)
+    if err != nil {
+        return nil, err
+    }
+
+    return x509.ParseCertificate(der)
+}
+
+func (ca *testCA) Certify(ctx context.Context, req *CertRequest) (*x509.Certificate, error) {
+    if ca.wait {
+        return nil, ErrWaiting
+    }
+
+    return ca.issue(req)
+}
+
+func (ca *testCA) KeyUpdate(ctx context.Context, old *x509.Certificate, req *CertRequest) (*x509.Certificate, error) {
+    if _, ok := ca.revoked[old.SerialNumber.String()]; ok {
+        return nil, &StatusError{rejection(FailCertRevoked, "")}
+    }
+
+    return ca.issue(req)
+}
+
+func (ca *testCA) Revoke(ctx context.Context, serial *big.Int, reason int) error {
+    if _, ok := ca.revoked[serial.String()]; ok {
+        return &StatusError{rejection(FailCertRevoked, "already revoked")}
+    }
+
+    ca.revoked[serial.String()] = reason
+
+    return nil
+}
+
+func (ca *testCA) Poll(ctx context.Context, req *CertRequest) (*x509.Certificate, error) {
+    if !ca.approve {
+        return nil, ErrWaiting
+    }
+
+    return ca.issue(req)
+}
+
+func newTestServer(ca *testCA) *Server {
+    return &Server{
+        CA:     ca,
+        Signer: ca.signer(),
+        Secret: func(reference []byte) (string, error) {
+            if string(reference) != "device-1" {
+                return "", errors.New("unknown reference")
+            }
+
+            return "shared secret", nil
+        },
+    }
+}
+
+func newTemplate(cn string) *x509.CertificateRequest {
+    return &x509.CertificateRequest{
+        Subject:  pkix.Name{CommonName: cn},
+        DNSNames: []string{cn},
+    }
+}
+
+func Test_CMP(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    ca := newTestCA(t)
+
+    srv := httptest.NewServer(newTestServer(ca))
+    defer srv.Close()
+
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    assertError(err, "GenerateKey")
+
+    ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    sm2Key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    gostKey, err := gost.GenerateKey(rand.Reader, gost.CurveIdGostR34102001CryptoProAParamSet())
+    assertError(err, "GenerateKey")
+
+    for _, key := range []crypto.Signer{rsaKey, ecKey, sm2Key, gostKey} {
+        client := &Client{
+            URL:           srv.URL,
+            CACertificate: ca.cert,
+            Protector:     &MACProtection{
+                Reference: []byte("device-1"),
+                Secret:    "shared secret",
+            },
+        }
+
+        // ir protected by the shared secret
+        cert, err := client.Initialize(ctx, newTemplate("device.example.com"), key)
+        assertError(err, "Initialize")
+        assertEqual(cert.Subject.CommonName, "device.example.com", "Initialize")
+        assertEqual(cert.DNSNames, []string{"device.example.com"}, "Initialize")
+        assertError(cert.CheckSignatureFrom(ca.cert), "Initialize")
+
+        current := &SignatureProtection{
+            Certificate: cert,
+            Key:         key,
+        }
+
+        // cr signed with the issued certificate
+        client.Protector = current
+
+        other, err := client.Certify(ctx, newTemplate("other.example.com"), key)
+        assertError(err, "Certify")
+        assertEqual(other.Subject.CommonName, "other.example.com", "Certify")
+
+        // kur with a new key
+        newKey, err := sm2.GenerateKey(rand.Reader)
+        assertError(err, "GenerateKey")
+
+        updated, err := client.KeyUpdate(ctx, current, newTemplate("device.example.com"), newKey)
+        assertError(err, "KeyUpdate")
+        assertEqual(updated.PublicKey.(*sm2.PublicKey).Equal(&newKey.PublicKey), true, "KeyUpdate")
+
+        // rr signed with the certificate to revoke
+        err = client.Revoke(ctx, cert, 1)
+        assertError(err, "Revoke")
+        assertEqual(ca.revoked[cert.SerialNumber.String()], 1, "Revoke")
+
+        err = client.Revoke(ctx, other, 1)
+        assertBool(err != nil, "Revoke other")
+
+        var se *StatusError
+        assertBool(errors.As(err, &se), "Revoke other")
+        assertEqual(se.FailInfo, FailNotAuthorized, "Revoke other")
+    }
+}
+
+func Test_P10CR(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    ctx := context.Background()
+
+    ca := newTestCA(t)
+
+    server := newTestServer(ca)
+    server.ImplicitConfirm = true
+
+    srv := httptest.NewServer(server)
+    defer srv.Close()
+
+    _, edKey, err := ed25519.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    der, err := x509.CreateCertificateRequest(rand.Reader, newTemplate("p10.example.com"), edKey)
+    assertError(err, "CreateCertificateRequest")
+
+    csr, err := x509.ParseCertificateRequest(der)
+    assertError(err, "ParseCertificateRequest")
+
+    client := &Client{
+        URL:             srv.URL,
+        CACertificate:   ca.cert,
+        ImplicitConfirm: true,
+        Protector:       &MACProtection{
+            Reference: []byte("device-1"),
+            Secret:    "shared secret",
+        },
+    }
+
+    cert, err := client.P10Certify(ctx, csr)
+    assertError(err, "P10Certify")
+    assertEqual(cert.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo, "P10Certify")
+
+    // nothing waits for a certConf
+    assertEqual(len(server.transactions), 0, "ImplicitConfirm")
+
+    // the certConf of a certificate signed without hash
+    cc, err := NewCertConfirm(cert, P10CertReqID)
+    assertError(err, "NewCertConfirm")
+    assertEqual(cc.Matches(cert), true, "NewCertConfirm")
+}
+
+func Test_Polling(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    ca := newTestCA(t)
+    ca.wait = true
+
+    server := newTestServer(ca)
+    server.CheckAfter = 10 * time.Millisecond
+
+    srv := httptest.NewServer(server)
+    defer srv.Close()
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    client := &Client{
+        URL:           srv.URL,
+        CACertificate: ca.cert,
+        Protector:     &MACProtection{
+            Reference: []byte("device-1"),
+            Secret:    "shared secret",
+        },
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 100 * time.Millisecond)
+    _, err = client.Certify(ctx, newTemplate("wait.example.com"), key)
+    cancel()
+    assertEqual(errors.Is(err, context.DeadlineExceeded), true, "Certify waiting")
+
+    go func() {
+        time.Sleep(50 * time.Millisecond)
+        ca.approve = true
+    }()
+
+    cert, err := client.Certify(context.Background(), newTemplate("wait.example.com"), key)
+    assertError(err, "Certify")
+    assertEqual(cert.Subject.CommonName, "wait.example.com", "Certify")
+}
+
+func Test_Rejections(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ctx := context.Background()
+
+    ca := newTestCA(t)
+
+    srv := httptest.NewServer(newTestServer(ca))
+    defer srv.Close()
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    failInfo := func(err error) FailureInfo {
+        var se *StatusError
+        if !errors.As(err, &se) {
+            t.Fatalf("not a status error: %v", err)
+        }
+
+        return se.FailInfo
+    }
+
+    // the wrong secret
+    client := &Client{
+        URL:           srv.URL,
+        CACertificate: ca.cert,
+        Protector:     &MACProtection{
+            Reference: []byte("device-1"),
+            Secret:    "wrong secret",
+        },
+    }
+
+    _, err = client.Initialize(ctx, newTemplate("device.example.com"), key)
+    assertEqual(failInfo(err), FailBadMessageCheck, "wrong secret")
+
+    // too many PBKDF2 iterations
+    opts := DefaultPBMAC1Opts
+    opts.IterationCount = maxPBMAC1Iterations + 1
+
+    client.Protector = &MACProtection{
+        Reference: []byte("device-1"),
+        Secret:    "shared secret",
+        Opts:      &opts,
+    }
+
+    _, err = client.Initialize(ctx, newTemplate("device.example.com"), key)
+    assertEqual(failInfo(err), FailBadMessageCheck, "iteration count")
+
+    // an unknown reference
+    client.Protector = &MACProtection{
+        Reference: []byte("device-2"),
+        Secret:    "shared secret",
+    }
+
+    _, err = client.Initialize(ctx, newTemplate("device.example.com"), key)
+    assertEqual(failInfo(err), FailSignerNotTrusted, "unknown reference")
+
+    // a signer not issued by the CA
+    other := newTestCA(t)
+    client.Protector = other.signer()
+
+    _, err = client.Certify(ctx, newTemplate("device.example.com"), key)
+    assertEqual(failInfo(err), FailSignerNotTrusted, "untrusted signer")
+
+    // the proof of possession signed by another key
+    client.Protector = &MACProtection{
+        Reference: []byte("device-1"),
+        Secret:    "shared secret",
+    }
+
+    req, err := NewCertRequest(newTemplate("device.example.com"), key)
+    assertError(err, "NewCertRequest")
+
+    otherKey, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    assertError(req.Sign(otherKey), "Sign")
+
+    _, err = client.enroll(ctx, BodyCR, req, client.Protector)
+    assertEqual(failInfo(err), FailBadPOP, "badPOP")
+
+    // a kur can not be MAC protected
+    m, err := NewPKIMessage(BodyKUR, nil, ca.cert.RawSubject)
+    assertError(err, "NewPKIMessage")
+
+    assertError(req.Sign(key), "Sign")
+    m.CertRequests = []*CertRequest{req}
+
+    _, err = client.Exchange(ctx, m, client.Protector)
+    assertEqual(failInfo(err), FailNotAuthorized, "MAC kur")
+
+    // a response signed by another CA
+    cert, err := client.Initialize(ctx, newTemplate("device.example.com"), key)
+    assertError(err, "Initialize")
+
+    client.Protector = &SignatureProtection{
+        Certificate: cert,
+        Key:         key,
+    }
+    client.CACertificate = other.cert
+
+    _, err = client.Certify(ctx, newTemplate("device.example.com"), key)
+    assertBool(err != nil, "CACertificate")
+
+    // tampered messages are rejected with HTTP 400 or badMessageCheck
+    m, err = NewPKIMessage(BodyCR, nil, ca.cert.RawSubject)
+    assertError(err, "NewPKIMessage")
+
+    m.CertRequests = []*CertRequest{req}
+
+    der, err := m.Marshal(&MACProtection{
+        Reference: []byte("device-1"),
+        Secret:    "shared secret",
+    })
+    assertError(err, "Marshal")
+
+    der[len(der) - 1] ^= 1
+
+    parsed, err := ParsePKIMessage(der)
+    assertError(err, "ParsePKIMessage")
+    assertBool(parsed.VerifyMAC("shared secret") != nil, "VerifyMAC tampered")
+
+    resp, err := http.Post(srv.URL, "text/plain", nil)
+    assertError(err, "Post")
+    resp.Body.Close()
+    assertEqual(resp.StatusCode, http.StatusUnsupportedMediaType, "Post")
+}
+
+func Test_VerifyMACLimits(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ca := newTestCA(t)
+
+    m, err := NewPKIMessage(BodyPKIConf, nil, ca.cert.RawSubject)
+    assertError(err, "NewPKIMessage")
+
+    der, err := m.Marshal(&MACProtection{
+        Reference: []byte("device-1"),
+        Secret:    "shared secret",
+    })
+    assertError(err, "Marshal")
+
+    parsed, err := ParsePKIMessage(der)
+    assertError(err, "ParsePKIMessage")
+    assertError(parsed.VerifyMAC("shared secret"), "VerifyMAC")
+
+    var params pbmac1Params
+    _, err = asn1.Unmarshal(parsed.Header.ProtectionAlg.Parameters.FullBytes, &params)
+    assertError(err, "Unmarshal")
+
+    var kdf pbkdf2Params
+    _, err = asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf)
+    assertError(err, "Unmarshal")
+
+    setKDF := func(iterations, keyLength int) {
+        k := kdf
+        k.IterationCount = iterations
+        k.KeyLength = keyLength
+
+        p := params
+        p.KeyDerivationFunc.Parameters.FullBytes, err = asn1.Marshal(k)
+        assertError(err, "Marshal")
+
+        parsed.Header.ProtectionAlg.Parameters.FullBytes, err = asn1.Marshal(p)
+        assertError(err, "Marshal")
+    }
+
+    // rejected before deriving the key
+    setKDF(1 << 31 - 1, 0)
+    assertBool(parsed.VerifyMAC("shared secret") != nil, "VerifyMAC iterations")
+
+    setKDF(0, 0)
+    assertBool(parsed.VerifyMAC("shared secret") != nil, "VerifyMAC zero iterations")
+
+    setKDF(kdf.IterationCount, 1 << 30)
+    assertBool(parsed.VerifyMAC("shared secret") != nil, "VerifyMAC key length")
+}
+
+func Test_ParsePKIMessage(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    ca := newTestCA(t)
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    req, err := NewCertRequest(newTemplate("device.example.com"), key)
+    assertError(err, "NewCertRequest")
+
+    req.NotBefore = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+    req.NotAfter = time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+    req.OldCert = NewCertID(ca.cert)
+    assertError(req.Sign(key), "Sign")
+
+    m, err := NewPKIMessage(BodyKUR, req.RawSubject, ca.cert.RawSubject)
+    assertError(err, "NewPKIMessage")
+
+    m.Header.ImplicitConfirm = true
+    m.Header.FreeText = []string{"hello"}
+    m.CertRequests = []*CertRequest{req}
+
+    der, err := m.Marshal(ca.signer())
+    assertError(err, "Marshal")
+
+    parsed, err := ParsePKIMessage(der)
+    assertError(err, "ParsePKIMessage")
+
+    assertEqual(parsed.Type, BodyKUR, "Type")
+    assertEqual(parsed.Header.TransactionID, m.Header.TransactionID, "TransactionID")
+    assertEqual(parsed.Header.SenderNonce, m.Header.SenderNonce, "SenderNonce")
+    assertEqual(parsed.Header.SenderKID, ca.cert.SubjectKeyId, "SenderKID")
+    assertEqual(parsed.Header.FreeText, []string{"hello"}, "FreeText")
+    assertEqual(parsed.Header.ImplicitConfirm, true, "ImplicitConfirm")
+    assertEqual(parsed.MACProtected(), false, "MACProtected")
+    assertError(parsed.VerifySignature(parsed.SignerCertificate()), "VerifySignature")
+
+    assertEqual(len(parsed.CertRequests), 1, "CertRequests")
+
+    preq := parsed.CertRequests[0]
+    assertEqual(preq.RawSubject, req.RawSubject, "RawSubject")
+    assertEqual(preq.RawSubjectPublicKeyInfo, req.RawSubjectPublicKeyInfo, "RawSubjectPublicKeyInfo")
+    assertBool(preq.NotBefore.Equal(req.NotBefore), "NotBefore")
+    assertBool(preq.NotAfter.Equal(req.NotAfter), "NotAfter")
+    assertBool(preq.OldCert.Matches(ca.cert), "OldCert")
+    assertError(preq.CheckSignature(), "CheckSignature")
+
+    // an error message with the status and the failure info
+    rep, err := parsed.ErrorReply(FailBadPOP | FailBadTime, "rejected")
+    assertError(err, "ErrorReply")
+
+    der, err = rep.Marshal(nil)
+    assertError(err, "Marshal")
+
+    parsed, err = ParsePKIMessage(der)
+    assertError(err, "ParsePKIMessage")
+    assertEqual(parsed.Type, BodyError, "Type")
+    assertEqual(parsed.Header.RecipNonce, m.Header.SenderNonce, "RecipNonce")
+    assertEqual(parsed.Error.Status.Status, StatusRejection, "Status")
+    assertEqual(parsed.Error.Status.FailInfo, FailBadPOP | FailBadTime, "FailInfo")
+    assertEqual(parsed.Error.Status.Text, []string{"rejected"}, "Text")
+
+    _, err = ParsePKIMessage(der[:len(der) - 1])
+    assertBool(err != nil, "ParsePKIMessage truncated")
+}
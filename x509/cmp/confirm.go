@@ -0,0 +1,122 @@
+package cmp
+
+import (
+    "errors"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+var (
+    oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+    oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+    oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+    oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+    oidSM3    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}
+
+    oidGOST34112012256 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2}
+    oidGOST34112012512 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 3}
+)
+
+var hashOIDs = []struct {
+    hash x509.Hash
+    oid  asn1.ObjectIdentifier
+}{
+    {x509.SHA1, oidSHA1},
+    {x509.SHA256, oidSHA256},
+    {x509.SHA384, oidSHA384},
+    {x509.SHA512, oidSHA512},
+    {x509.SM3, oidSM3},
+    {x509.GOST34112012256, oidGOST34112012256},
+    {x509.GOST34112012512, oidGOST34112012512},
+}
+
+// certHashFunc returns the hash of the signature algorithm of a
+// certificate, RFC 4210 section 5.3.18, or false for the algorithms
+// without a hash.
+func certHashFunc(algo x509.SignatureAlgorithm) (x509.Hash, bool) {
+    switch algo {
+        case x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1, x509.SM2WithSHA1:
+            return x509.SHA1, true
+        case x509.SHA256WithRSA, x509.SHA256WithRSAPSS, x509.ECDSAWithSHA256,
+            x509.DSAWithSHA256, x509.SM2WithSHA256:
+            return x509.SHA256, true
+        case x509.SHA384WithRSA, x509.SHA384WithRSAPSS, x509.ECDSAWithSHA384:
+            return x509.SHA384, true
+        case x509.SHA512WithRSA, x509.SHA512WithRSAPSS, x509.ECDSAWithSHA512:
+            return x509.SHA512, true
+        case x509.SM2WithSM3, x509.SM3WithRSA:
+            return x509.SM3, true
+        case x509.GOST3410WithGOST34112012256:
+            return x509.GOST34112012256, true
+        case x509.GOST3410WithGOST34112012512:
+            return x509.GOST34112012512, true
+    }
+
+    return 0, false
+}
+
+// NewCertConfirm returns the confirmation of cert, issued for the
+// request id. The hash algorithm is the one of the signature of cert,
+// SHA-512 with an explicit hashAlg for the algorithms without one such
+// as Ed25519, which needs Version3.
+func NewCertConfirm(cert *x509.Certificate, id int) (*CertConfirm, error) {
+    c := &CertConfirm{
+        ID: id,
+    }
+
+    h, ok := certHashFunc(cert.SignatureAlgorithm)
+    if !ok {
+        h = x509.SHA512
+        c.HashAlg = pkix.AlgorithmIdentifier{
+            Algorithm: oidSHA512,
+        }
+    }
+
+    hashed, err := hashCertificate(cert, h)
+    if err != nil {
+        return nil, err
+    }
+
+    c.CertHash = hashed
+
+    return c, nil
+}
+
+// Matches reports whether c confirms cert.
+func (c *CertConfirm) Matches(cert *x509.Certificate) bool {
+    h, ok := certHashFunc(cert.SignatureAlgorithm)
+
+    if len(c.HashAlg.Algorithm) > 0 {
+        ok = false
+        for _, details := range hashOIDs {
+            if details.oid.Equal(c.HashAlg.Algorithm) {
+                h, ok = details.hash, true
+                break
+            }
+        }
+    }
+
+    if !ok {
+        return false
+    }
+
+    hashed, err := hashCertificate(cert, h)
+    if err != nil {
+        return false
+    }
+
+    return string(hashed) == string(c.CertHash)
+}
+
+func hashCertificate(cert *x509.Certificate, h x509.Hash) ([]byte, error) {
+    if !h.Available() {
+        return nil, errors.New("cmp: hash function is not available")
+    }
+
+    hasher := h.New()
+    hasher.Write(cert.Raw)
+
+    return hasher.Sum(nil), nil
+}
@@ -0,0 +1,562 @@
+package cmp
+
+import (
+    "time"
+    "errors"
+    "crypto"
+    "math/big"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "golang.org/x/crypto/cryptobyte"
+    cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+)
+
+// see RFC 4211
+
+// CertID identifies a certificate by its issuer and serial number.
+type CertID struct {
+    // Issuer is the DER encoded issuer name.
+    Issuer       []byte
+    SerialNumber *big.Int
+}
+
+// NewCertID returns the CertID of cert.
+func NewCertID(cert *x509.Certificate) *CertID {
+    return &CertID{
+        Issuer:       cert.RawIssuer,
+        SerialNumber: cert.SerialNumber,
+    }
+}
+
+// Matches reports whether id identifies cert.
+func (id *CertID) Matches(cert *x509.Certificate) bool {
+    return string(id.Issuer) == string(cert.RawIssuer) &&
+        id.SerialNumber != nil && id.SerialNumber.Cmp(cert.SerialNumber) == 0
+}
+
+// CertRequest is a certificate request of an ir, cr, kur or p10cr
+// message. The request of a p10cr is a PKCS #10 request, the others
+// are CRMF requests.
+type CertRequest struct {
+    // Raw is the DER encoded CRMF CertRequest, signed by the proof of
+    // possession. It is set by Sign and by the parser.
+    Raw []byte
+
+    ID int
+
+    RawSubject              []byte
+    RawSubjectPublicKeyInfo []byte
+    PublicKey               crypto.PublicKey
+
+    NotBefore, NotAfter time.Time
+
+    Extensions []pkix.Extension
+
+    // OldCert is the certificate updated by a kur.
+    OldCert *CertID
+
+    // CSR is the request of a p10cr.
+    CSR *x509.CertificateRequest
+
+    // RAVerified is the raVerified proof of possession, set by a RA
+    // that verified the request.
+    RAVerified bool
+
+    popAlgorithm pkix.AlgorithmIdentifier
+    popSignature []byte
+}
+
+// NewCertRequest returns a certificate request for the subject, the
+// names and the extensions of template, and the public key of key.
+// Sign it once its fields are set.
+func NewCertRequest(template *x509.CertificateRequest, key crypto.Signer) (*CertRequest, error) {
+    tmpl := *template
+    if tmpl.SignatureAlgorithm == x509.UnknownSignatureAlgorithm {
+        tmpl.SignatureAlgorithm = signatureAlgorithm(key)
+    }
+
+    // the subject, the public key and the extensions are taken from a
+    // PKCS #10 request of the template
+    der, err := x509.CreateCertificateRequest(rand.Reader, &tmpl, key)
+    if err != nil {
+        return nil, err
+    }
+
+    csr, err := x509.ParseCertificateRequest(der)
+    if err != nil {
+        return nil, err
+    }
+
+    req := newP10CertRequest(csr)
+    req.ID = 0
+    req.CSR = nil
+
+    return req, nil
+}
+
+// NewP10CertRequest returns the certificate request of a p10cr.
+func NewP10CertRequest(csr *x509.CertificateRequest) *CertRequest {
+    return newP10CertRequest(csr)
+}
+
+func newP10CertRequest(csr *x509.CertificateRequest) *CertRequest {
+    return &CertRequest{
+        ID:                      P10CertReqID,
+        RawSubject:              csr.RawSubject,
+        RawSubjectPublicKeyInfo: csr.RawSubjectPublicKeyInfo,
+        PublicKey:               csr.PublicKey,
+        Extensions:              csr.Extensions,
+        CSR:                     csr,
+    }
+}
+
+// Sign encodes r and signs it with key, the private key of its public
+// key, as the proof of possession.
+func (r *CertRequest) Sign(key crypto.Signer) error {
+    der, err := r.marshal()
+    if err != nil {
+        return err
+    }
+
+    alg, signature, err := x509.CreateSignature(rand.Reader, key, signatureAlgorithm(key), der)
+    if err != nil {
+        return err
+    }
+
+    r.Raw = der
+    r.popAlgorithm = alg
+    r.popSignature = signature
+
+    return nil
+}
+
+// CheckSignature verifies the proof of possession of r.
+func (r *CertRequest) CheckSignature() error {
+    if r.CSR != nil {
+        return r.CSR.CheckSignature()
+    }
+
+    if r.popSignature == nil {
+        return errors.New("cmp: request without signature proof of possession")
+    }
+
+    algo := x509.SignatureAlgorithmFromAI(r.popAlgorithm)
+    if algo == x509.UnknownSignatureAlgorithm {
+        return errors.New("cmp: unsupported proof of possession algorithm")
+    }
+
+    return x509.CheckSignatureWithPublicKey(algo, r.Raw, r.popSignature, r.PublicKey)
+}
+
+// Template returns the certificate template of r, to be completed
+// and signed by the CA.
+func (r *CertRequest) Template() *x509.Certificate {
+    return &x509.Certificate{
+        RawSubject:      r.RawSubject,
+        PublicKey:       r.PublicKey,
+        NotBefore:       r.NotBefore,
+        NotAfter:        r.NotAfter,
+        ExtraExtensions: r.Extensions,
+    }
+}
+
+// marshal returns the DER encoded CertRequest.
+func (r *CertRequest) marshal() ([]byte, error) {
+    var exts [][]byte
+    for _, ext := range r.Extensions {
+        der, err := asn1.Marshal(ext)
+        if err != nil {
+            return nil, err
+        }
+
+        exts = append(exts, der)
+    }
+
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        b.AddASN1Int64(int64(r.ID))
+
+        b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+            if !r.NotBefore.IsZero() || !r.NotAfter.IsZero() {
+                b.AddASN1(contextTag(4), func(b *cryptobyte.Builder) {
+                    if !r.NotBefore.IsZero() {
+                        b.AddASN1(contextTag(0), func(b *cryptobyte.Builder) {
+                            addTime(b, r.NotBefore)
+                        })
+                    }
+
+                    if !r.NotAfter.IsZero() {
+                        b.AddASN1(contextTag(1), func(b *cryptobyte.Builder) {
+                            addTime(b, r.NotAfter)
+                        })
+                    }
+                })
+            }
+
+            if len(r.RawSubject) > 0 {
+                b.AddASN1(contextTag(5), func(b *cryptobyte.Builder) {
+                    b.AddBytes(r.RawSubject)
+                })
+            }
+
+            if len(r.RawSubjectPublicKeyInfo) > 0 {
+                spki := cryptobyte.String(r.RawSubjectPublicKeyInfo)
+
+                var content cryptobyte.String
+                if !spki.ReadASN1(&content, cryptobyte_asn1.SEQUENCE) {
+                    b.SetError(errors.New("cmp: invalid public key"))
+                    return
+                }
+
+                b.AddASN1(contextTag(6), func(b *cryptobyte.Builder) {
+                    b.AddBytes(content)
+                })
+            }
+
+            if len(exts) > 0 {
+                b.AddASN1(contextTag(9), func(b *cryptobyte.Builder) {
+                    for _, ext := range exts {
+                        b.AddBytes(ext)
+                    }
+                })
+            }
+        })
+
+        if r.OldCert != nil {
+            b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    b.AddASN1ObjectIdentifier(oidRegCtrlOldCertID)
+                    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                        addGeneralName(b, r.OldCert.Issuer)
+                        b.AddASN1BigInt(r.OldCert.SerialNumber)
+                    })
+                })
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+func marshalCertReqMessages(reqs []*CertRequest) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        for _, r := range reqs {
+            if r.Raw == nil {
+                var err error
+                if r.Raw, err = r.marshal(); err != nil {
+                    b.SetError(err)
+                    return
+                }
+            }
+
+            var alg []byte
+            if r.popSignature != nil {
+                var err error
+                if alg, err = asn1.Marshal(r.popAlgorithm); err != nil {
+                    b.SetError(err)
+                    return
+                }
+            }
+
+            b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                b.AddBytes(r.Raw)
+
+                switch {
+                    case r.popSignature != nil:
+                        b.AddASN1(contextTag(1), func(b *cryptobyte.Builder) {
+                            b.AddBytes(alg)
+                            b.AddASN1BitString(r.popSignature)
+                        })
+                    case r.RAVerified:
+                        b.AddASN1(cryptobyte_asn1.Tag(0).ContextSpecific(), func(b *cryptobyte.Builder) {})
+                }
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+func parseCertReqMessages(content cryptobyte.String) (reqs []*CertRequest, err error) {
+    invalid := errors.New("cmp: invalid CertReqMessages")
+
+    var seq cryptobyte.String
+    if !content.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return nil, invalid
+    }
+
+    for !seq.Empty() {
+        var msg, raw cryptobyte.String
+        if !seq.ReadASN1(&msg, cryptobyte_asn1.SEQUENCE) ||
+            !msg.ReadASN1Element(&raw, cryptobyte_asn1.SEQUENCE) {
+            return nil, invalid
+        }
+
+        r, err := parseCertRequest(raw)
+        if err != nil {
+            return nil, err
+        }
+
+        if !msg.Empty() {
+            var pop cryptobyte.String
+            var tag cryptobyte_asn1.Tag
+            if !msg.ReadAnyASN1(&pop, &tag) {
+                return nil, invalid
+            }
+
+            switch tag {
+                case cryptobyte_asn1.Tag(0).ContextSpecific():
+                    r.RAVerified = true
+                case contextTag(1):
+                    if pop.PeekASN1Tag(contextTag(0)) {
+                        return nil, errors.New("cmp: POPOSigningKeyInput is not supported")
+                    }
+
+                    var alg cryptobyte.String
+                    if !pop.ReadASN1Element(&alg, cryptobyte_asn1.SEQUENCE) ||
+                        !pop.ReadASN1BitStringAsBytes(&r.popSignature) {
+                        return nil, invalid
+                    }
+
+                    if _, err := asn1.Unmarshal(alg, &r.popAlgorithm); err != nil {
+                        return nil, invalid
+                    }
+            }
+        }
+
+        reqs = append(reqs, r)
+    }
+
+    return reqs, nil
+}
+
+func parseCertRequest(raw cryptobyte.String) (*CertRequest, error) {
+    invalid := errors.New("cmp: invalid CertRequest")
+
+    var s cryptobyte.String
+    var id int64
+
+    input := raw
+    if !input.ReadASN1(&s, cryptobyte_asn1.SEQUENCE) || !s.ReadASN1Integer(&id) {
+        return nil, invalid
+    }
+
+    tmpl, err := readCertTemplate(&s)
+    if err != nil {
+        return nil, err
+    }
+
+    r := &CertRequest{
+        Raw:                     raw,
+        ID:                      int(id),
+        RawSubject:              tmpl.subject,
+        RawSubjectPublicKeyInfo: tmpl.publicKey,
+        NotBefore:               tmpl.notBefore,
+        NotAfter:                tmpl.notAfter,
+        Extensions:              tmpl.extensions,
+    }
+
+    if r.RawSubjectPublicKeyInfo != nil {
+        if r.PublicKey, err = x509.ParsePKIXPublicKey(r.RawSubjectPublicKeyInfo); err != nil {
+            return nil, err
+        }
+    }
+
+    // the controls
+    var controls cryptobyte.String
+    var present bool
+    if !s.ReadOptionalASN1(&controls, &present, cryptobyte_asn1.SEQUENCE) {
+        return nil, invalid
+    }
+
+    for !controls.Empty() {
+        var control cryptobyte.String
+        var oid asn1.ObjectIdentifier
+        if !controls.ReadASN1(&control, cryptobyte_asn1.SEQUENCE) ||
+            !control.ReadASN1ObjectIdentifier(&oid) {
+            return nil, invalid
+        }
+
+        if !oid.Equal(oidRegCtrlOldCertID) {
+            continue
+        }
+
+        var certID cryptobyte.String
+        if !control.ReadASN1(&certID, cryptobyte_asn1.SEQUENCE) {
+            return nil, invalid
+        }
+
+        issuer, err := readGeneralName(&certID)
+        if err != nil {
+            return nil, err
+        }
+
+        serial := new(big.Int)
+        if !certID.ReadASN1Integer(serial) {
+            return nil, invalid
+        }
+
+        r.OldCert = &CertID{
+            Issuer:       issuer,
+            SerialNumber: serial,
+        }
+    }
+
+    return r, nil
+}
+
+type certTemplate struct {
+    serialNumber *big.Int
+    issuer       []byte
+    notBefore    time.Time
+    notAfter     time.Time
+    subject      []byte
+    publicKey    []byte
+    extensions   []pkix.Extension
+}
+
+// readCertTemplate reads a CertTemplate, the fields a CA can not
+// honour are skipped.
+func readCertTemplate(s *cryptobyte.String) (t certTemplate, err error) {
+    invalid := errors.New("cmp: invalid CertTemplate")
+
+    var seq cryptobyte.String
+    if !s.ReadASN1(&seq, cryptobyte_asn1.SEQUENCE) {
+        return t, invalid
+    }
+
+    for !seq.Empty() {
+        var field cryptobyte.String
+        var tag cryptobyte_asn1.Tag
+        if !seq.ReadAnyASN1(&field, &tag) {
+            return t, invalid
+        }
+
+        switch tag {
+            case cryptobyte_asn1.Tag(1).ContextSpecific():
+                var ok bool
+                if t.serialNumber, ok = parseImplicitBigInt(field); !ok {
+                    return t, invalid
+                }
+            case contextTag(3):
+                t.issuer = field
+            case contextTag(4):
+                var present bool
+                var when cryptobyte.String
+                if !field.ReadOptionalASN1(&when, &present, contextTag(0)) {
+                    return t, invalid
+                }
+
+                if present {
+                    if t.notBefore, err = readTime(&when); err != nil {
+                        return t, err
+                    }
+                }
+
+                if !field.ReadOptionalASN1(&when, &present, contextTag(1)) {
+                    return t, invalid
+                }
+
+                if present {
+                    if t.notAfter, err = readTime(&when); err != nil {
+                        return t, err
+                    }
+                }
+            case contextTag(5):
+                t.subject = field
+            case contextTag(6):
+                var b cryptobyte.Builder
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    b.AddBytes(field)
+                })
+
+                if t.publicKey, err = b.Bytes(); err != nil {
+                    return t, err
+                }
+            case contextTag(9):
+                for !field.Empty() {
+                    var ext cryptobyte.String
+                    if !field.ReadASN1Element(&ext, cryptobyte_asn1.SEQUENCE) {
+                        return t, invalid
+                    }
+
+                    var e pkix.Extension
+                    if _, err := asn1.Unmarshal(ext, &e); err != nil {
+                        return t, invalid
+                    }
+
+                    t.extensions = append(t.extensions, e)
+                }
+        }
+    }
+
+    return t, nil
+}
+
+// addTime adds a Time, an UTCTime up to 2049.
+func addTime(b *cryptobyte.Builder, t time.Time) {
+    t = t.UTC().Truncate(time.Second)
+    if t.Year() >= 1950 && t.Year() < 2050 {
+        b.AddASN1UTCTime(t)
+        return
+    }
+
+    b.AddASN1GeneralizedTime(t)
+}
+
+func readTime(s *cryptobyte.String) (t time.Time, err error) {
+    switch {
+        case s.PeekASN1Tag(cryptobyte_asn1.UTCTime):
+            if !s.ReadASN1UTCTime(&t) {
+                return t, errors.New("cmp: invalid time")
+            }
+        case s.PeekASN1Tag(cryptobyte_asn1.GeneralizedTime):
+            if !s.ReadASN1GeneralizedTime(&t) {
+                return t, errors.New("cmp: invalid time")
+            }
+        default:
+            return t, errors.New("cmp: invalid time")
+    }
+
+    return t, nil
+}
+
+// signatureAlgorithm returns the signature algorithm of the requests
+// and the proofs of possession signed by key.
+func signatureAlgorithm(key crypto.Signer) x509.SignatureAlgorithm {
+    switch k := key.(type) {
+        case *rsa.PrivateKey:
+            return x509.SHA256WithRSA
+        case *ecdsa.PrivateKey:
+            switch k.Curve.Params().BitSize {
+                case 384:
+                    return x509.ECDSAWithSHA384
+                case 521:
+                    return x509.ECDSAWithSHA512
+            }
+
+            return x509.ECDSAWithSHA256
+        case ed25519.PrivateKey:
+            return x509.PureEd25519
+        case *sm2.PrivateKey:
+            return x509.SM2WithSM3
+        case *gost.PrivateKey:
+            if k.Curve.PointSize() == 64 {
+                return x509.GOST3410WithGOST34112012512
+            }
+
+            return x509.GOST3410WithGOST34112012256
+    }
+
+    return x509.UnknownSignatureAlgorithm
+}
@@ -0,0 +1,242 @@
+package cmp
+
+import (
+    "bytes"
+    "errors"
+    "crypto"
+    "crypto/rand"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/tool"
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/pkcs12"
+)
+
+// Protector protects the PKIMessages, see MACProtection and
+// SignatureProtection.
+type Protector interface {
+    // Prepare sets the protection algorithm and the sender key ID of
+    // the header of m, and adds its extra certificates.
+    Prepare(m *PKIMessage) error
+
+    // Protect returns the protection of the DER encoded ProtectedPart
+    // of m.
+    Protect(m *PKIMessage, protectedPart []byte) ([]byte, error)
+}
+
+const (
+    // maxPBMAC1Iterations is the largest PBKDF2 iteration count of a
+    // PBMAC1 protection VerifyMAC accepts.
+    maxPBMAC1Iterations = 100000
+
+    // maxPBMAC1KeyLength is the largest PBKDF2 key length of a PBMAC1
+    // protection VerifyMAC accepts, the size of HMAC-SHA512.
+    maxPBMAC1KeyLength = 64
+)
+
+// DefaultPBMAC1Opts are the options of a MACProtection without Opts.
+var DefaultPBMAC1Opts = pkcs12.PBMAC1Opts{
+    SaltSize:       16,
+    IterationCount: 10000,
+    KDFHash:        pkcs12.PBMAC1_SHA256,
+    HMACHash:       pkcs12.PBMAC1_SHA256,
+}
+
+// MACProtection protects the PKIMessages with a PBMAC1 of a secret
+// shared by the client and the server, RFC 9481 section 6.1.3.
+type MACProtection struct {
+    // Reference identifies the secret, it is the sender key ID of
+    // the messages.
+    Reference []byte
+
+    // Secret is the shared secret.
+    Secret string
+
+    // Opts are the PBMAC1 options, DefaultPBMAC1Opts if nil. VerifyMAC
+    // accepts at most 100000 iterations.
+    Opts *pkcs12.PBMAC1Opts
+}
+
+// Prepare implements Protector.
+func (p *MACProtection) Prepare(m *PKIMessage) error {
+    opts := DefaultPBMAC1Opts
+    if p.Opts != nil {
+        opts = *p.Opts
+    }
+
+    password, err := tool.BmpStringZeroTerminated(p.Secret)
+    if err != nil {
+        return err
+    }
+
+    // the PBMAC1 parameters with a new salt
+    params, err := opts.Compute(nil, password)
+    if err != nil {
+        return err
+    }
+
+    macData, ok := params.(pkcs12.MacData)
+    if !ok {
+        return errors.New("cmp: invalid PBMAC1 parameters")
+    }
+
+    m.Header.ProtectionAlg = macData.Mac.Algorithm
+    m.Header.SenderKID = p.Reference
+
+    return nil
+}
+
+// Protect implements Protector.
+func (p *MACProtection) Protect(m *PKIMessage, protectedPart []byte) ([]byte, error) {
+    password, err := tool.BmpStringZeroTerminated(p.Secret)
+    if err != nil {
+        return nil, err
+    }
+
+    macData := pkcs12.MacData{
+        Mac: pkcs12.DigestInfo{
+            Algorithm: m.Header.ProtectionAlg,
+        },
+    }
+
+    return macData.Sum(protectedPart, password)
+}
+
+// VerifyMAC verifies the MAC protection of m with the shared secret.
+// The PBKDF2 parameters are limited to 100000 iterations and a 64 byte
+// key, as they come from the unauthenticated message.
+func (m *PKIMessage) VerifyMAC(secret string) error {
+    if m.Protection == nil || !m.MACProtected() {
+        return errors.New("cmp: message is not MAC protected")
+    }
+
+    if err := checkPBMAC1(m.Header.ProtectionAlg); err != nil {
+        return err
+    }
+
+    password, err := tool.BmpStringZeroTerminated(secret)
+    if err != nil {
+        return err
+    }
+
+    macData := pkcs12.MacData{
+        Mac: pkcs12.DigestInfo{
+            Algorithm: m.Header.ProtectionAlg,
+            Digest:    m.Protection,
+        },
+    }
+
+    if err = macData.Verify(m.protectedPart, password); err != nil {
+        return errors.New("cmp: invalid MAC protection")
+    }
+
+    return nil
+}
+
+// PBMAC1-params, RFC 8018 appendix A.5
+type pbmac1Params struct {
+    KeyDerivationFunc pkix.AlgorithmIdentifier
+    MessageAuthScheme pkix.AlgorithmIdentifier
+}
+
+// PBKDF2-params, RFC 8018 appendix A.2
+type pbkdf2Params struct {
+    Salt           asn1.RawValue
+    IterationCount int
+    KeyLength      int                      `asn1:"optional"`
+    Prf            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// checkPBMAC1 checks the PBKDF2 parameters of the PBMAC1 alg against
+// maxPBMAC1Iterations and maxPBMAC1KeyLength.
+func checkPBMAC1(alg pkix.AlgorithmIdentifier) error {
+    var params pbmac1Params
+    if rest, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil || len(rest) > 0 {
+        return errors.New("cmp: invalid PBMAC1 parameters")
+    }
+
+    var kdf pbkdf2Params
+    if rest, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil || len(rest) > 0 {
+        return errors.New("cmp: invalid PBKDF2 parameters")
+    }
+
+    if kdf.IterationCount <= 0 || kdf.IterationCount > maxPBMAC1Iterations {
+        return errors.New("cmp: PBKDF2 iteration count out of range")
+    }
+
+    if kdf.KeyLength < 0 || kdf.KeyLength > maxPBMAC1KeyLength {
+        return errors.New("cmp: PBKDF2 key length out of range")
+    }
+
+    return nil
+}
+
+// SignatureProtection protects the PKIMessages with a signature. The
+// certificate is sent as the first extra certificate.
+type SignatureProtection struct {
+    Certificate *x509.Certificate
+    Key         crypto.Signer
+
+    // Chain are the certificates from the issuer of Certificate up,
+    // sent after it.
+    Chain []*x509.Certificate
+
+    // SignatureAlgorithm is the signature algorithm, the default one
+    // of the key if unknown.
+    SignatureAlgorithm x509.SignatureAlgorithm
+}
+
+// Prepare implements Protector.
+func (p *SignatureProtection) Prepare(m *PKIMessage) error {
+    alg, err := x509.SignatureAlgorithmIdentifier(p.Key.Public(), p.algorithm())
+    if err != nil {
+        return err
+    }
+
+    m.Header.ProtectionAlg = alg
+    m.Header.SenderKID = p.Certificate.SubjectKeyId
+
+    if len(m.Header.Sender) == 0 {
+        m.Header.Sender = p.Certificate.RawSubject
+    }
+
+    certs := append([]*x509.Certificate{p.Certificate}, p.Chain...)
+    for _, cert := range m.ExtraCerts {
+        if !containsCertificate(certs, cert) {
+            certs = append(certs, cert)
+        }
+    }
+
+    m.ExtraCerts = certs
+
+    return nil
+}
+
+// Protect implements Protector.
+func (p *SignatureProtection) Protect(m *PKIMessage, protectedPart []byte) ([]byte, error) {
+    _, signature, err := x509.CreateSignature(rand.Reader, p.Key, p.algorithm(), protectedPart)
+    if err != nil {
+        return nil, err
+    }
+
+    return signature, nil
+}
+
+func (p *SignatureProtection) algorithm() x509.SignatureAlgorithm {
+    if p.SignatureAlgorithm != x509.UnknownSignatureAlgorithm {
+        return p.SignatureAlgorithm
+    }
+
+    return signatureAlgorithm(p.Key)
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+    for _, c := range certs {
+        if bytes.Equal(c.Raw, cert.Raw) {
+            return true
+        }
+    }
+
+    return false
+}
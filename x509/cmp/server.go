@@ -0,0 +1,533 @@
+package cmp
+
+import (
+    "io"
+    "time"
+    "bytes"
+    "errors"
+    "context"
+    "strings"
+    "math/big"
+    "net/http"
+    "sync"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// ErrWaiting is returned by a CA that can not issue a certificate
+// right away. The request is answered with the waiting status and the
+// client polls with pollReq.
+var ErrWaiting = errors.New("cmp: request waiting")
+
+// maxMessageSize limits the size of the messages.
+const maxMessageSize = 1 << 20
+
+// transactionTimeout is how long a server waits for the certConf or
+// the pollReq of a transaction.
+const transactionTimeout = 10 * time.Minute
+
+// CA issues the certificates of a CMP server. A CA rejects a request
+// with a *StatusError to choose the failure info.
+type CA interface {
+    // Certify issues a certificate for req, the request of an ir, a cr
+    // or a p10cr with a verified proof of possession, or returns
+    // ErrWaiting.
+    Certify(ctx context.Context, req *CertRequest) (*x509.Certificate, error)
+
+    // KeyUpdate issues a certificate for req updating old, the
+    // certificate that signed the kur.
+    KeyUpdate(ctx context.Context, old *x509.Certificate, req *CertRequest) (*x509.Certificate, error)
+
+    // Revoke revokes the certificate with the serial number, with the
+    // CRL reason code.
+    Revoke(ctx context.Context, serial *big.Int, reason int) error
+}
+
+// PollingCA is implemented by the CAs returning ErrWaiting.
+type PollingCA interface {
+    // Poll returns the certificate of req, a request answered with
+    // ErrWaiting, or ErrWaiting.
+    Poll(ctx context.Context, req *CertRequest) (*x509.Certificate, error)
+}
+
+// Server is a CMP server, an http.Handler for the messages posted
+// with the application/pkixcmp content type, RFC 6712.
+type Server struct {
+    // CA issues the certificates.
+    CA CA
+
+    // Signer protects the responses, and the responses to the MAC
+    // protected requests that fail the MAC check.
+    Signer *SignatureProtection
+
+    // Issuer is the certificate of the CA, Signer.Certificate if nil.
+    // The revocation requests are for its certificates.
+    Issuer *x509.Certificate
+
+    // Secret returns the secret shared with a client, the reference is
+    // the sender key ID of its MAC protected requests. The MAC
+    // protected requests are rejected if it is nil. Their responses
+    // are MAC protected with the same secret.
+    Secret func(reference []byte) (string, error)
+
+    // VerifySigner checks the certificate that signed a request. If
+    // nil, it must be valid and issued by Issuer.
+    VerifySigner func(r *http.Request, signer *x509.Certificate, extraCerts []*x509.Certificate) error
+
+    // ImplicitConfirm grants the implicit confirmation asked by the
+    // clients.
+    ImplicitConfirm bool
+
+    // CheckAfter is the polling delay of the waiting requests.
+    CheckAfter time.Duration
+
+    mu           sync.Mutex
+    transactions map[string]*transaction
+}
+
+// transaction is an enrollment waiting for a pollReq or a certConf.
+type transaction struct {
+    typ      BodyType
+    implicit bool
+    nonce    []byte
+    updated  time.Time
+
+    // the requests in order, the waiting ones and the responses
+    ids       []int
+    waiting   map[int]*CertRequest
+    responses map[int]*CertResponse
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        w.Header().Set("Allow", http.MethodPost)
+        w.WriteHeader(http.StatusMethodNotAllowed)
+        return
+    }
+
+    if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/pkixcmp") {
+        w.WriteHeader(http.StatusUnsupportedMediaType)
+        return
+    }
+
+    der, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        return
+    }
+
+    msg, err := ParsePKIMessage(der)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    rep, err := s.Respond(r, msg)
+    if err != nil {
+        http.Error(w, "cmp: internal error", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/pkixcmp")
+    w.WriteHeader(http.StatusOK)
+    w.Write(rep)
+}
+
+// Respond verifies the protection of m and returns its DER encoded
+// response.
+func (s *Server) Respond(r *http.Request, m *PKIMessage) ([]byte, error) {
+    protector, signer, info, text := s.verify(r, m)
+
+    var rep *PKIMessage
+    var err error
+
+    if info != 0 {
+        rep, err = m.ErrorReply(info, text)
+    } else {
+        rep, err = s.handle(r.Context(), m, signer)
+    }
+
+    if err != nil {
+        return nil, err
+    }
+
+    return rep.Marshal(protector)
+}
+
+// verify checks the protection of m and returns the protection of
+// the response and the signer of m, nil if it is MAC protected.
+func (s *Server) verify(r *http.Request, m *PKIMessage) (Protector, *x509.Certificate, FailureInfo, string) {
+    if m.Protection == nil {
+        return s.Signer, nil, FailBadMessageCheck, "unprotected message"
+    }
+
+    if m.MACProtected() {
+        if s.Secret == nil {
+            return s.Signer, nil, FailWrongIntegrity, "MAC protection is not accepted"
+        }
+
+        secret, err := s.Secret(m.Header.SenderKID)
+        if err != nil {
+            return s.Signer, nil, FailSignerNotTrusted, "unknown sender key ID"
+        }
+
+        if err = m.VerifyMAC(secret); err != nil {
+            return s.Signer, nil, FailBadMessageCheck, "invalid MAC protection"
+        }
+
+        return &MACProtection{
+            Reference: m.Header.SenderKID,
+            Secret:    secret,
+        }, nil, 0, ""
+    }
+
+    signer := m.SignerCertificate()
+    if signer == nil {
+        return s.Signer, nil, FailBadMessageCheck, "no signer certificate"
+    }
+
+    if err := m.VerifySignature(signer); err != nil {
+        return s.Signer, nil, FailBadMessageCheck, "invalid signature protection"
+    }
+
+    if err := s.verifySigner(r, signer, m.ExtraCerts); err != nil {
+        return s.Signer, nil, FailSignerNotTrusted, err.Error()
+    }
+
+    return s.Signer, signer, 0, ""
+}
+
+func (s *Server) verifySigner(r *http.Request, signer *x509.Certificate, extraCerts []*x509.Certificate) error {
+    if s.VerifySigner != nil {
+        return s.VerifySigner(r, signer, extraCerts)
+    }
+
+    now := time.Now()
+    if now.Before(signer.NotBefore) || now.After(signer.NotAfter) {
+        return errors.New("cmp: signer certificate is not valid")
+    }
+
+    if err := signer.CheckSignatureFrom(s.issuer()); err != nil {
+        return errors.New("cmp: signer certificate is not issued by the CA")
+    }
+
+    return nil
+}
+
+func (s *Server) handle(ctx context.Context, m *PKIMessage, signer *x509.Certificate) (*PKIMessage, error) {
+    switch m.Type {
+        case BodyIR, BodyCR, BodyP10CR, BodyKUR:
+            return s.certify(ctx, m, signer)
+        case BodyRR:
+            return s.revoke(ctx, m, signer)
+        case BodyCertConf:
+            return s.confirm(ctx, m)
+        case BodyPollReq:
+            return s.poll(ctx, m)
+    }
+
+    return m.ErrorReply(FailBadRequest, m.Type.String() + " is not supported")
+}
+
+func (s *Server) certify(ctx context.Context, m *PKIMessage, signer *x509.Certificate) (*PKIMessage, error) {
+    if len(m.CertRequests) == 0 {
+        return m.ErrorReply(FailBadRequest, "no certificate request")
+    }
+
+    if len(m.Header.TransactionID) == 0 {
+        return m.ErrorReply(FailBadRequest, "no transaction ID")
+    }
+
+    if m.Type == BodyKUR && signer == nil {
+        return m.ErrorReply(FailNotAuthorized, "kur must be signed with the certificate to update")
+    }
+
+    if s.lookup(m.Header.TransactionID) != nil {
+        return m.ErrorReply(FailTransactionIDInUse, "")
+    }
+
+    typ := responseType(m.Type)
+
+    rep, err := m.Reply(typ)
+    if err != nil {
+        return nil, err
+    }
+
+    t := &transaction{
+        typ:       typ,
+        implicit:  m.Header.ImplicitConfirm && s.ImplicitConfirm,
+        nonce:     rep.Header.SenderNonce,
+        waiting:   make(map[int]*CertRequest),
+        responses: make(map[int]*CertResponse),
+    }
+
+    for _, req := range m.CertRequests {
+        resp := &CertResponse{
+            ID: req.ID,
+        }
+
+        cert, err := s.issue(ctx, m.Type, req, signer)
+        switch {
+            case errors.Is(err, ErrWaiting):
+                resp.Status = StatusInfo{Status: StatusWaiting}
+                t.waiting[req.ID] = req
+            case err != nil:
+                resp.Status = statusOf(err)
+            default:
+                resp.Status = StatusInfo{Status: StatusAccepted}
+                resp.Certificate = cert
+                t.responses[req.ID] = resp
+        }
+
+        t.ids = append(t.ids, req.ID)
+        rep.CertResponses = append(rep.CertResponses, resp)
+    }
+
+    s.keep(m.Header.TransactionID, t)
+
+    return s.certResponse(rep, t, m.MACProtected()), nil
+}
+
+// certResponse completes the ip, cp or kup of t.
+func (s *Server) certResponse(rep *PKIMessage, t *transaction, mac bool) *PKIMessage {
+    rep.Header.ImplicitConfirm = t.implicit
+
+    // the CA certificate, as the trust anchor of the MAC protected
+    // initial requests
+    issuer := s.issuer()
+    rep.ExtraCerts = append(rep.ExtraCerts, issuer)
+    if mac && rep.Type == BodyIP {
+        rep.CAPubs = []*x509.Certificate{issuer}
+    }
+
+    return rep
+}
+
+func (s *Server) issue(ctx context.Context, typ BodyType, req *CertRequest, signer *x509.Certificate) (*x509.Certificate, error) {
+    if err := req.CheckSignature(); err != nil {
+        return nil, &StatusError{rejection(FailBadPOP, "invalid proof of possession")}
+    }
+
+    if typ == BodyKUR {
+        if req.OldCert != nil && !req.OldCert.Matches(signer) {
+            return nil, &StatusError{rejection(FailBadCertID, "old certificate is not the signer certificate")}
+        }
+
+        return s.CA.KeyUpdate(ctx, signer, req)
+    }
+
+    return s.CA.Certify(ctx, req)
+}
+
+func (s *Server) revoke(ctx context.Context, m *PKIMessage, signer *x509.Certificate) (*PKIMessage, error) {
+    if signer == nil {
+        return m.ErrorReply(FailNotAuthorized, "rr must be signed with the certificate to revoke")
+    }
+
+    rep, err := m.Reply(BodyRP)
+    if err != nil {
+        return nil, err
+    }
+
+    issuer := s.issuer()
+
+    for _, req := range m.RevRequests {
+        si := StatusInfo{Status: StatusAccepted}
+
+        switch {
+            case !bytes.Equal(req.Issuer, issuer.RawSubject):
+                si = rejection(FailBadCertID, "unknown issuer")
+            case !bytes.Equal(req.Issuer, signer.RawIssuer) || req.SerialNumber.Cmp(signer.SerialNumber) != 0:
+                si = rejection(FailNotAuthorized, "not signed by the certificate to revoke")
+            default:
+                if err := s.CA.Revoke(ctx, req.SerialNumber, req.Reason); err != nil {
+                    si = statusOf(err)
+                }
+        }
+
+        rep.RevStatus = append(rep.RevStatus, si)
+    }
+
+    return rep, nil
+}
+
+func (s *Server) confirm(ctx context.Context, m *PKIMessage) (*PKIMessage, error) {
+    t := s.lookup(m.Header.TransactionID)
+    if t == nil || len(t.waiting) > 0 {
+        return m.ErrorReply(FailBadRequest, "no certificate to confirm")
+    }
+
+    if !bytes.Equal(m.Header.RecipNonce, t.nonce) {
+        return m.ErrorReply(FailBadRecipientNonce, "")
+    }
+
+    for _, c := range m.CertConfirms {
+        resp, ok := t.responses[c.ID]
+        if !ok || resp.Certificate == nil || !c.Matches(resp.Certificate) {
+            return m.ErrorReply(FailBadCertID, "certificate hash does not match")
+        }
+
+        // the certificates refused by the client are revoked
+        if c.Status != nil && c.Status.Status == StatusRejection {
+            s.CA.Revoke(ctx, resp.Certificate.SerialNumber, 0)
+        }
+    }
+
+    s.remove(m.Header.TransactionID)
+
+    return m.Reply(BodyPKIConf)
+}
+
+func (s *Server) poll(ctx context.Context, m *PKIMessage) (*PKIMessage, error) {
+    t := s.lookup(m.Header.TransactionID)
+    if t == nil || len(t.waiting) == 0 {
+        return m.ErrorReply(FailBadRequest, "no waiting request")
+    }
+
+    if !bytes.Equal(m.Header.RecipNonce, t.nonce) {
+        return m.ErrorReply(FailBadRecipientNonce, "")
+    }
+
+    ca, ok := s.CA.(PollingCA)
+    if !ok {
+        return m.ErrorReply(FailSystemFailure, "polling is not supported")
+    }
+
+    for _, id := range m.PollRequests {
+        req, ok := t.waiting[id]
+        if !ok {
+            return m.ErrorReply(FailBadCertID, "unknown certificate request")
+        }
+
+        cert, err := ca.Poll(ctx, req)
+        if errors.Is(err, ErrWaiting) {
+            continue
+        }
+
+        resp := &CertResponse{
+            ID: id,
+        }
+
+        if err != nil {
+            resp.Status = statusOf(err)
+        } else {
+            resp.Status = StatusInfo{Status: StatusAccepted}
+            resp.Certificate = cert
+        }
+
+        delete(t.waiting, id)
+        t.responses[id] = resp
+    }
+
+    // pollRep until all the requests are answered
+    if len(t.waiting) > 0 {
+        rep, err := m.Reply(BodyPollRep)
+        if err != nil {
+            return nil, err
+        }
+
+        for _, id := range t.ids {
+            if _, ok := t.waiting[id]; ok {
+                rep.PollResponses = append(rep.PollResponses, &PollResponse{
+                    ID:         id,
+                    CheckAfter: s.CheckAfter,
+                })
+            }
+        }
+
+        s.touch(t, rep.Header.SenderNonce)
+
+        return rep, nil
+    }
+
+    rep, err := m.Reply(t.typ)
+    if err != nil {
+        return nil, err
+    }
+
+    issued := false
+    for _, id := range t.ids {
+        resp := t.responses[id]
+        rep.CertResponses = append(rep.CertResponses, resp)
+        issued = issued || resp.Certificate != nil
+    }
+
+    if t.implicit || !issued {
+        s.remove(m.Header.TransactionID)
+    } else {
+        s.touch(t, rep.Header.SenderNonce)
+    }
+
+    return s.certResponse(rep, t, m.MACProtected()), nil
+}
+
+// keep keeps t if it waits for a pollReq or a certConf.
+func (s *Server) keep(tid []byte, t *transaction) {
+    issued := false
+    for _, resp := range t.responses {
+        issued = issued || resp.Certificate != nil
+    }
+
+    if len(t.waiting) == 0 && (t.implicit || !issued) {
+        return
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if s.transactions == nil {
+        s.transactions = make(map[string]*transaction)
+    }
+
+    t.updated = time.Now()
+    s.transactions[string(tid)] = t
+}
+
+func (s *Server) lookup(tid []byte) *transaction {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    // drop the abandoned transactions
+    now := time.Now()
+    for id, t := range s.transactions {
+        if now.Sub(t.updated) > transactionTimeout {
+            delete(s.transactions, id)
+        }
+    }
+
+    return s.transactions[string(tid)]
+}
+
+func (s *Server) touch(t *transaction, nonce []byte) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t.nonce = nonce
+    t.updated = time.Now()
+}
+
+func (s *Server) remove(tid []byte) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    delete(s.transactions, string(tid))
+}
+
+func (s *Server) issuer() *x509.Certificate {
+    if s.Issuer != nil {
+        return s.Issuer
+    }
+
+    return s.Signer.Certificate
+}
+
+// statusOf returns the status of a rejected request.
+func statusOf(err error) StatusInfo {
+    var se *StatusError
+    if errors.As(err, &se) {
+        return se.StatusInfo
+    }
+
+    return rejection(FailBadRequest, err.Error())
+}
@@ -0,0 +1,618 @@
+package x509
+
+import (
+    "io"
+    "time"
+    "bytes"
+    "errors"
+    "math/big"
+    "crypto"
+    "encoding/pem"
+    "encoding/asn1"
+    "crypto/x509/pkix"
+)
+
+var (
+    oidExtensionCRLNumber                = []int{2, 5, 29, 20}
+    oidExtensionReasonCode               = []int{2, 5, 29, 21}
+    oidExtensionInvalidityDate           = []int{2, 5, 29, 24}
+    oidExtensionDeltaCRLIndicator        = []int{2, 5, 29, 27}
+    oidExtensionIssuingDistributionPoint = []int{2, 5, 29, 28}
+)
+
+// The CRL reason codes, RFC 5280 section 5.3.1.
+const (
+    ReasonUnspecified          = 0
+    ReasonKeyCompromise        = 1
+    ReasonCACompromise         = 2
+    ReasonAffiliationChanged   = 3
+    ReasonSuperseded           = 4
+    ReasonCessationOfOperation = 5
+    ReasonCertificateHold      = 6
+    ReasonRemoveFromCRL        = 8
+    ReasonPrivilegeWithdrawn   = 9
+    ReasonAACompromise         = 10
+)
+
+// RevocationListEntry represents an entry in the revokedCertificates
+// sequence of a CRL.
+type RevocationListEntry struct {
+    // Raw contains the raw bytes of the revokedCertificates entry. It is set
+    // when parsing a CRL; it is ignored when generating a CRL.
+    Raw []byte
+
+    // SerialNumber represents the serial number of a revoked certificate. It
+    // is both used when creating a CRL and populated when parsing a CRL. It
+    // must not be nil.
+    SerialNumber *big.Int
+
+    // RevocationTime represents the time at which the certificate was
+    // revoked. It is both used when creating a CRL and populated when parsing
+    // a CRL. It must not be the zero time.
+    RevocationTime time.Time
+
+    // ReasonCode represents the reason for revocation, using the integer enum
+    // values specified in RFC 5280 Section 5.3.1. When creating a CRL, the
+    // zero value will result in the reasonCode extension being omitted. When
+    // parsing a CRL, the zero value may represent either the reasonCode
+    // extension being absent (which implies the default revocation reason of
+    // 0/Unspecified), or it may represent the reasonCode extension being
+    // present and explicitly containing a value of 0/Unspecified.
+    ReasonCode int
+
+    // InvalidityDate is the date on which the key is known or suspected
+    // to have been compromised, the invalidityDate extension. It is
+    // omitted when zero.
+    InvalidityDate time.Time
+
+    // Extensions contains raw X.509 extensions. When parsing CRL entries,
+    // this can be used to extract non-critical extensions that are not
+    // parsed by this package. When marshaling CRL entries, the Extensions
+    // field is ignored, see ExtraExtensions.
+    Extensions []pkix.Extension
+
+    // ExtraExtensions contains extensions to be copied, raw, into any
+    // marshaled CRL entries. Values override any extensions that would
+    // otherwise be produced based on the other fields.
+    ExtraExtensions []pkix.Extension
+}
+
+// IssuingDistributionPoint is the issuingDistributionPoint extension
+// of a CRL, RFC 5280 section 5.2.5. It limits the scope of the CRL.
+type IssuingDistributionPoint struct {
+    // DistributionPoints are the URIs of the fullName of the
+    // distribution point.
+    DistributionPoints []string
+
+    OnlyContainsUserCerts      bool
+    OnlyContainsCACerts        bool
+    IndirectCRL                bool
+    OnlyContainsAttributeCerts bool
+
+    // OnlySomeReasons are the reason codes the CRL covers, all the
+    // reasons if empty.
+    OnlySomeReasons []int
+}
+
+// RevocationList represents a Certificate Revocation List (CRL) as specified
+// by RFC 5280.
+type RevocationList struct {
+    // Raw contains the complete ASN.1 DER content of the CRL (tbsCertList,
+    // signatureAlgorithm, and signatureValue.)
+    Raw []byte
+    // RawTBSRevocationList contains just the tbsCertList portion of the ASN.1
+    // DER.
+    RawTBSRevocationList []byte
+    // RawIssuer contains the DER encoded Issuer.
+    RawIssuer []byte
+
+    // Issuer contains the DN of the issuing certificate.
+    Issuer pkix.Name
+    // AuthorityKeyId is used to identify the public key associated with the
+    // issuing certificate. It is populated from the authorityKeyIdentifier
+    // extension when parsing a CRL. It is ignored when creating a CRL; the
+    // extension is populated from the issuing certificate itself.
+    AuthorityKeyId []byte
+
+    Signature []byte
+    // SignatureAlgorithm is used to determine the signature algorithm to be
+    // used when signing the CRL. If 0 the default algorithm for the signing
+    // key will be used.
+    SignatureAlgorithm SignatureAlgorithm
+
+    // RevokedCertificateEntries represents the revokedCertificates sequence in
+    // the CRL.
+    RevokedCertificateEntries []RevocationListEntry
+
+    // Number is used to populate the X.509 v2 cRLNumber extension in the CRL,
+    // which should be a monotonically increasing sequence number for a given
+    // CRL scope and CRL issuer. It is also populated from the cRLNumber
+    // extension when parsing a CRL.
+    Number *big.Int
+
+    // BaseCRLNumber, if not nil, makes the CRL a delta CRL over the
+    // complete CRL with this number, the deltaCRLIndicator extension.
+    BaseCRLNumber *big.Int
+
+    // IssuingDistributionPoint, if not nil, is the issuingDistributionPoint
+    // extension of the CRL.
+    IssuingDistributionPoint *IssuingDistributionPoint
+
+    // ThisUpdate is used to populate the thisUpdate field in the CRL, which
+    // indicates the issuance date of the CRL.
+    ThisUpdate time.Time
+    // NextUpdate is used to populate the nextUpdate field in the CRL, which
+    // indicates the date by which the next CRL will be issued. NextUpdate
+    // must be greater than ThisUpdate.
+    NextUpdate time.Time
+
+    // Extensions contains raw X.509 extensions. When creating a CRL,
+    // the Extensions field is ignored, see ExtraExtensions.
+    Extensions []pkix.Extension
+
+    // ExtraExtensions contains any additional extensions to add directly to
+    // the CRL.
+    ExtraExtensions []pkix.Extension
+}
+
+// IsDelta reports whether the CRL is a delta CRL.
+func (rl *RevocationList) IsDelta() bool {
+    return rl.BaseCRLNumber != nil
+}
+
+// Entry returns the entry of the certificate with serial, or nil.
+func (rl *RevocationList) Entry(serial *big.Int) *RevocationListEntry {
+    for i := range rl.RevokedCertificateEntries {
+        if rl.RevokedCertificateEntries[i].SerialNumber.Cmp(serial) == 0 {
+            return &rl.RevokedCertificateEntries[i]
+        }
+    }
+
+    return nil
+}
+
+// CheckSignatureFrom verifies that the signature on rl is a valid signature
+// from issuer.
+func (rl *RevocationList) CheckSignatureFrom(parent *Certificate) error {
+    if parent.Version == 3 && !parent.BasicConstraintsValid ||
+        parent.BasicConstraintsValid && !parent.IsCA {
+        return ConstraintViolationError{}
+    }
+
+    if parent.KeyUsage != 0 && parent.KeyUsage&KeyUsageCRLSign == 0 {
+        return ConstraintViolationError{}
+    }
+
+    if parent.PublicKeyAlgorithm == UnknownPublicKeyAlgorithm {
+        return ErrUnsupportedAlgorithm
+    }
+
+    return parent.CheckSignature(rl.SignatureAlgorithm, rl.RawTBSRevocationList, rl.Signature)
+}
+
+type issuingDistributionPoint struct {
+    DistributionPoint          distributionPointName `asn1:"optional,tag:0"`
+    OnlyContainsUserCerts      bool                  `asn1:"optional,tag:1"`
+    OnlyContainsCACerts        bool                  `asn1:"optional,tag:2"`
+    OnlySomeReasons            asn1.BitString        `asn1:"optional,tag:3"`
+    IndirectCRL                bool                  `asn1:"optional,tag:4"`
+    OnlyContainsAttributeCerts bool                  `asn1:"optional,tag:5"`
+}
+
+// CreateRevocationList creates a new X.509 v2 Certificate Revocation List,
+// according to RFC 5280, based on template.
+//
+// The CRL is signed by priv which should be the private key associated with
+// the public key in the issuer certificate, with the algorithms of
+// CreateCertificate, so SM2 and GOST keys are supported.
+//
+// The issuer may not be nil, and the crlSign bit must be set in KeyUsage in
+// order to use it as a CRL issuer.
+//
+// The issuer distinguished name CRL field and authority key identifier
+// extension are populated using the issuer certificate. issuer must have
+// SubjectKeyId set.
+func CreateRevocationList(rand io.Reader, template *RevocationList, issuer *Certificate, priv crypto.Signer) ([]byte, error) {
+    if template == nil {
+        return nil, errors.New("x509: template can not be nil")
+    }
+    if issuer == nil {
+        return nil, errors.New("x509: issuer can not be nil")
+    }
+    if (issuer.KeyUsage & KeyUsageCRLSign) == 0 {
+        return nil, errors.New("x509: issuer must have the crlSign key usage bit set")
+    }
+    if len(issuer.SubjectKeyId) == 0 {
+        return nil, errors.New("x509: issuer certificate doesn't contain a subject key identifier")
+    }
+    if template.NextUpdate.Before(template.ThisUpdate) {
+        return nil, errors.New("x509: template.ThisUpdate is after template.NextUpdate")
+    }
+    if template.Number == nil {
+        return nil, errors.New("x509: template contains nil Number field")
+    }
+    if template.Number.Sign() == -1 {
+        return nil, errors.New("x509: CRL number must be non-negative")
+    }
+    // RFC 5280 Section 5.2.3: conforming CRL issuers MUST NOT use
+    // CRLNumber values longer than 20 octets.
+    if len(template.Number.Bytes()) > 20 {
+        return nil, errors.New("x509: CRL number exceeds 20 octets")
+    }
+    if template.BaseCRLNumber != nil && template.BaseCRLNumber.Cmp(template.Number) >= 0 {
+        return nil, errors.New("x509: delta CRL number must be greater than the base CRL number")
+    }
+
+    revokedCerts := make([]pkix.RevokedCertificate, len(template.RevokedCertificateEntries))
+    for i, rce := range template.RevokedCertificateEntries {
+        if rce.SerialNumber == nil {
+            return nil, errors.New("x509: template contains entry with nil SerialNumber field")
+        }
+        if rce.RevocationTime.IsZero() {
+            return nil, errors.New("x509: template contains entry with zero RevocationTime field")
+        }
+
+        rc := pkix.RevokedCertificate{
+            SerialNumber:   rce.SerialNumber,
+            RevocationTime: rce.RevocationTime.UTC(),
+        }
+
+        exts := make([]pkix.Extension, 0, len(rce.ExtraExtensions) + 2)
+        if rce.ReasonCode != 0 && !oidInExtensions(oidExtensionReasonCode, rce.ExtraExtensions) {
+            reasonBytes, err := asn1.Marshal(asn1.Enumerated(rce.ReasonCode))
+            if err != nil {
+                return nil, err
+            }
+
+            exts = append(exts, pkix.Extension{
+                Id:    oidExtensionReasonCode,
+                Value: reasonBytes,
+            })
+        }
+
+        if !rce.InvalidityDate.IsZero() && !oidInExtensions(oidExtensionInvalidityDate, rce.ExtraExtensions) {
+            dateBytes, err := asn1.MarshalWithParams(rce.InvalidityDate.UTC(), "generalized")
+            if err != nil {
+                return nil, err
+            }
+
+            exts = append(exts, pkix.Extension{
+                Id:    oidExtensionInvalidityDate,
+                Value: dateBytes,
+            })
+        }
+
+        exts = append(exts, rce.ExtraExtensions...)
+        if len(exts) > 0 {
+            rc.Extensions = exts
+        }
+
+        revokedCerts[i] = rc
+    }
+
+    aki, err := asn1.Marshal(authKeyId{Id: issuer.SubjectKeyId})
+    if err != nil {
+        return nil, err
+    }
+
+    crlNum, err := asn1.Marshal(template.Number)
+    if err != nil {
+        return nil, err
+    }
+
+    extensions := []pkix.Extension{
+        {
+            Id:    oidExtensionAuthorityKeyId,
+            Value: aki,
+        },
+        {
+            Id:    oidExtensionCRLNumber,
+            Value: crlNum,
+        },
+    }
+
+    if template.BaseCRLNumber != nil {
+        baseNum, err := asn1.Marshal(template.BaseCRLNumber)
+        if err != nil {
+            return nil, err
+        }
+
+        extensions = append(extensions, pkix.Extension{
+            Id:       oidExtensionDeltaCRLIndicator,
+            Critical: true,
+            Value:    baseNum,
+        })
+    }
+
+    if template.IssuingDistributionPoint != nil {
+        idp, err := marshalIssuingDistributionPoint(template.IssuingDistributionPoint)
+        if err != nil {
+            return nil, err
+        }
+
+        extensions = append(extensions, pkix.Extension{
+            Id:       oidExtensionIssuingDistributionPoint,
+            Critical: true,
+            Value:    idp,
+        })
+    }
+
+    for _, ext := range template.ExtraExtensions {
+        extensions = removeExtension(extensions, ext.Id)
+    }
+    extensions = append(extensions, template.ExtraExtensions...)
+
+    // the algorithm identifier is part of the signed data
+    _, sigAlgo, err := signingParamsForPublicKey(priv.Public(), template.SignatureAlgorithm)
+    if err != nil {
+        return nil, err
+    }
+
+    // The issuer is copied as is from the issuer certificate.
+    tbs := tbsCertList{
+        Version:             1, // v2
+        Signature:           sigAlgo,
+        Issuer:              asn1.RawValue{FullBytes: issuer.RawSubject},
+        ThisUpdate:          template.ThisUpdate.UTC(),
+        RevokedCertificates: revokedCerts,
+        Extensions:          extensions,
+    }
+    if !template.NextUpdate.IsZero() {
+        tbs.NextUpdate = template.NextUpdate.UTC()
+    }
+
+    tbsCertListContents, err := asn1.Marshal(tbs)
+    if err != nil {
+        return nil, err
+    }
+
+    _, signature, err := CreateSignature(rand, priv, template.SignatureAlgorithm, tbsCertListContents)
+    if err != nil {
+        return nil, err
+    }
+
+    return asn1.Marshal(certificateList{
+        TBSCertList:        asn1.RawValue{FullBytes: tbsCertListContents},
+        SignatureAlgorithm: sigAlgo,
+        SignatureValue:     asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+    })
+}
+
+type certificateList struct {
+    TBSCertList        asn1.RawValue
+    SignatureAlgorithm pkix.AlgorithmIdentifier
+    SignatureValue     asn1.BitString
+}
+
+// tbsCertList is pkix.TBSCertificateList with a raw issuer.
+type tbsCertList struct {
+    Version             int `asn1:"optional,default:0"`
+    Signature           pkix.AlgorithmIdentifier
+    Issuer              asn1.RawValue
+    ThisUpdate          time.Time
+    NextUpdate          time.Time                 `asn1:"optional"`
+    RevokedCertificates []pkix.RevokedCertificate `asn1:"optional"`
+    Extensions          []pkix.Extension          `asn1:"tag:0,optional,explicit"`
+}
+
+func removeExtension(extensions []pkix.Extension, oid asn1.ObjectIdentifier) []pkix.Extension {
+    out := extensions[:0]
+    for _, ext := range extensions {
+        if !ext.Id.Equal(oid) {
+            out = append(out, ext)
+        }
+    }
+
+    return out
+}
+
+func marshalIssuingDistributionPoint(idp *IssuingDistributionPoint) ([]byte, error) {
+    var out issuingDistributionPoint
+
+    if len(idp.DistributionPoints) > 0 {
+        var fullName []byte
+        for _, uri := range idp.DistributionPoints {
+            name, err := asn1.Marshal(asn1.RawValue{Tag: 6, Class: 2, Bytes: []byte(uri)})
+            if err != nil {
+                return nil, err
+            }
+
+            fullName = append(fullName, name...)
+        }
+
+        out.DistributionPoint = distributionPointName{
+            FullName: asn1.RawValue{Tag: 0, Class: 2, IsCompound: true, Bytes: fullName},
+        }
+    }
+
+    out.OnlyContainsUserCerts = idp.OnlyContainsUserCerts
+    out.OnlyContainsCACerts = idp.OnlyContainsCACerts
+    out.IndirectCRL = idp.IndirectCRL
+    out.OnlyContainsAttributeCerts = idp.OnlyContainsAttributeCerts
+
+    if len(idp.OnlySomeReasons) > 0 {
+        out.OnlySomeReasons = marshalReasonFlags(idp.OnlySomeReasons)
+    }
+
+    return asn1.Marshal(out)
+}
+
+func parseIssuingDistributionPoint(der []byte) (*IssuingDistributionPoint, error) {
+    var idp issuingDistributionPoint
+    if rest, err := asn1.Unmarshal(der, &idp); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after issuing distribution point")
+    }
+
+    out := &IssuingDistributionPoint{
+        OnlyContainsUserCerts:      idp.OnlyContainsUserCerts,
+        OnlyContainsCACerts:        idp.OnlyContainsCACerts,
+        IndirectCRL:                idp.IndirectCRL,
+        OnlyContainsAttributeCerts: idp.OnlyContainsAttributeCerts,
+    }
+
+    names := idp.DistributionPoint.FullName.Bytes
+    for len(names) > 0 {
+        var n asn1.RawValue
+        var err error
+        names, err = asn1.Unmarshal(names, &n)
+        if err != nil {
+            return nil, err
+        }
+
+        if n.Tag == 6 {
+            out.DistributionPoints = append(out.DistributionPoints, string(n.Bytes))
+        }
+    }
+
+    for i := 0; i < idp.OnlySomeReasons.BitLength; i++ {
+        if idp.OnlySomeReasons.At(i) == 1 {
+            out.OnlySomeReasons = append(out.OnlySomeReasons, i)
+        }
+    }
+
+    return out, nil
+}
+
+// marshalReasonFlags encodes reason codes as ReasonFlags, whose bit
+// numbers are the reason codes.
+func marshalReasonFlags(reasons []int) asn1.BitString {
+    max := 0
+    for _, r := range reasons {
+        if r > max {
+            max = r
+        }
+    }
+
+    b := make([]byte, max/8+1)
+    for _, r := range reasons {
+        b[r/8] |= 0x80 >> uint(r%8)
+    }
+
+    return asn1.BitString{
+        Bytes:     b,
+        BitLength: max + 1,
+    }
+}
+
+// ParseRevocationList parses a X509 v2 Certificate Revocation List from the
+// given ASN.1 DER data. PEM encoded data is accepted too.
+func ParseRevocationList(der []byte) (*RevocationList, error) {
+    if bytes.HasPrefix(der, pemCRLPrefix) {
+        block, _ := pem.Decode(der)
+        if block != nil && block.Type == pemType {
+            der = block.Bytes
+        }
+    }
+
+    var certList certificateList
+    if rest, err := asn1.Unmarshal(der, &certList); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after CRL")
+    }
+
+    var tbs tbsCertList
+    if rest, err := asn1.Unmarshal(certList.TBSCertList.FullBytes, &tbs); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after CRL tbsCertList")
+    }
+
+    if tbs.Version > 1 {
+        return nil, errors.New("x509: unsupported crl version")
+    }
+
+    if !tbs.Signature.Algorithm.Equal(certList.SignatureAlgorithm.Algorithm) {
+        return nil, errors.New("x509: inner and outer signature algorithm identifiers don't match")
+    }
+
+    rl := &RevocationList{
+        Raw:                  der,
+        RawTBSRevocationList: certList.TBSCertList.FullBytes,
+        RawIssuer:            tbs.Issuer.FullBytes,
+        Signature:            certList.SignatureValue.RightAlign(),
+        SignatureAlgorithm:   getSignatureAlgorithmFromAI(certList.SignatureAlgorithm),
+        ThisUpdate:           tbs.ThisUpdate,
+        NextUpdate:           tbs.NextUpdate,
+        Extensions:           tbs.Extensions,
+    }
+
+    var issuer pkix.RDNSequence
+    if rest, err := asn1.Unmarshal(rl.RawIssuer, &issuer); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after CRL issuer")
+    }
+    rl.Issuer.FillFromRDNSequence(&issuer)
+
+    for _, rc := range tbs.RevokedCertificates {
+        entry := RevocationListEntry{
+            SerialNumber:   rc.SerialNumber,
+            RevocationTime: rc.RevocationTime,
+            Extensions:     rc.Extensions,
+        }
+
+        entry.Raw, _ = asn1.Marshal(rc)
+
+        for _, ext := range rc.Extensions {
+            switch {
+                case ext.Id.Equal(oidExtensionReasonCode):
+                    var reason asn1.Enumerated
+                    if rest, err := asn1.Unmarshal(ext.Value, &reason); err != nil {
+                        return nil, err
+                    } else if len(rest) != 0 {
+                        return nil, errors.New("x509: trailing data after reasonCode")
+                    }
+
+                    entry.ReasonCode = int(reason)
+                case ext.Id.Equal(oidExtensionInvalidityDate):
+                    if rest, err := asn1.UnmarshalWithParams(ext.Value, &entry.InvalidityDate, "generalized"); err != nil {
+                        return nil, err
+                    } else if len(rest) != 0 {
+                        return nil, errors.New("x509: trailing data after invalidityDate")
+                    }
+                default:
+                    if ext.Critical {
+                        return nil, UnhandledCriticalExtension{}
+                    }
+            }
+        }
+
+        rl.RevokedCertificateEntries = append(rl.RevokedCertificateEntries, entry)
+    }
+
+    for _, ext := range tbs.Extensions {
+        var err error
+        var rest []byte
+
+        switch {
+            case ext.Id.Equal(oidExtensionAuthorityKeyId):
+                var a authKeyId
+                rest, err = asn1.Unmarshal(ext.Value, &a)
+                rl.AuthorityKeyId = a.Id
+            case ext.Id.Equal(oidExtensionCRLNumber):
+                rl.Number = new(big.Int)
+                rest, err = asn1.Unmarshal(ext.Value, &rl.Number)
+            case ext.Id.Equal(oidExtensionDeltaCRLIndicator):
+                rl.BaseCRLNumber = new(big.Int)
+                rest, err = asn1.Unmarshal(ext.Value, &rl.BaseCRLNumber)
+            case ext.Id.Equal(oidExtensionIssuingDistributionPoint):
+                rl.IssuingDistributionPoint, err = parseIssuingDistributionPoint(ext.Value)
+            default:
+                if ext.Critical {
+                    return nil, UnhandledCriticalExtension{}
+                }
+        }
+
+        if err != nil {
+            return nil, err
+        }
+        if len(rest) != 0 {
+            return nil, errors.New("x509: trailing data after CRL extension")
+        }
+    }
+
+    return rl, nil
+}
@@ -0,0 +1,402 @@
+package x509
+
+import (
+    "time"
+    "errors"
+    "testing"
+    "math/big"
+    "crypto"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+type crlTestPKI struct {
+    root, ca, leaf *Certificate
+    rootKey, caKey crypto.Signer
+    roots          *CertPool
+    intermediates  *CertPool
+}
+
+func newCRLTestPKI(t *testing.T, newKey func() crypto.Signer) *crlTestPKI {
+    now := time.Now()
+
+    create := func(template, parent *Certificate, pub any, priv crypto.Signer) *Certificate {
+        der, err := CreateCertificate(rand.Reader, template, parent, pub, priv)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        cert, err := ParseCertificate(der)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        return cert
+    }
+
+    rootKey := newKey()
+    rootTemplate := &Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: "Test Root"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              KeyUsageCertSign | KeyUsageCRLSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SubjectKeyId:          []byte{1, 2, 3, 4},
+    }
+    root := create(rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+
+    caKey := newKey()
+    ca := create(&Certificate{
+        SerialNumber:          big.NewInt(2),
+        Subject:               pkix.Name{CommonName: "Test CA"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              KeyUsageCertSign | KeyUsageCRLSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SubjectKeyId:          []byte{5, 6, 7, 8},
+    }, root, caKey.Public(), rootKey)
+
+    leafKey := newKey()
+    leaf := create(&Certificate{
+        SerialNumber:          big.NewInt(3),
+        Subject:               pkix.Name{CommonName: "test.example.com"},
+        DNSNames:              []string{"test.example.com"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        CRLDistributionPoints: []string{"http://crl.example.com/ca.crl"},
+    }, ca, leafKey.Public(), caKey)
+
+    roots := NewCertPool()
+    roots.AddCert(root)
+
+    intermediates := NewCertPool()
+    intermediates.AddCert(ca)
+
+    return &crlTestPKI{
+        root:          root,
+        ca:            ca,
+        leaf:          leaf,
+        rootKey:       rootKey,
+        caKey:         caKey,
+        roots:         roots,
+        intermediates: intermediates,
+    }
+}
+
+func (pki *crlTestPKI) createCRL(t *testing.T, template *RevocationList) *RevocationList {
+    if template.ThisUpdate.IsZero() {
+        template.ThisUpdate = time.Now().Add(-time.Minute)
+    }
+    if template.NextUpdate.IsZero() {
+        template.NextUpdate = time.Now().Add(time.Hour)
+    }
+
+    der, err := CreateRevocationList(rand.Reader, template, pki.ca, pki.caKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    crl, err := ParseRevocationList(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return crl
+}
+
+var crlTestKeys = []struct {
+    name string
+    key  func() crypto.Signer
+}{
+    {
+        name: "ECDSA",
+        key: func() crypto.Signer {
+            k, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+            return k
+        },
+    },
+    {
+        name: "SM2",
+        key: func() crypto.Signer {
+            k, _ := sm2.GenerateKey(rand.Reader)
+            return k
+        },
+    },
+    {
+        name: "GOST",
+        key: func() crypto.Signer {
+            k, _ := gost.GenerateKey(rand.Reader, gost.CurveIdGostR34102001CryptoProAParamSet())
+            return k
+        },
+    },
+}
+
+func Test_RevocationList(t *testing.T) {
+    for _, td := range crlTestKeys {
+        t.Run(td.name, func(t *testing.T) {
+            assertEqual := cryptobin_test.AssertEqualT(t)
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            pki := newCRLTestPKI(t, td.key)
+
+            revokedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+            invalidAt := revokedAt.Add(-time.Hour)
+
+            crl := pki.createCRL(t, &RevocationList{
+                Number: big.NewInt(7),
+                RevokedCertificateEntries: []RevocationListEntry{
+                    {
+                        SerialNumber:   pki.leaf.SerialNumber,
+                        RevocationTime: revokedAt,
+                        ReasonCode:     ReasonKeyCompromise,
+                        InvalidityDate: invalidAt,
+                    },
+                    {
+                        SerialNumber:   big.NewInt(100),
+                        RevocationTime: revokedAt,
+                    },
+                },
+                IssuingDistributionPoint: &IssuingDistributionPoint{
+                    DistributionPoints:    []string{"http://crl.example.com/ca.crl"},
+                    OnlyContainsUserCerts: true,
+                },
+            })
+
+            assertError(crl.CheckSignatureFrom(pki.ca), "CheckSignatureFrom")
+            assertBool(crl.CheckSignatureFrom(pki.root) != nil, "CheckSignatureFrom root")
+
+            assertEqual(crl.Number, big.NewInt(7), "Number")
+            assertEqual(crl.AuthorityKeyId, pki.ca.SubjectKeyId, "AuthorityKeyId")
+            assertEqual(crl.RawIssuer, pki.ca.RawSubject, "RawIssuer")
+            assertEqual(crl.Issuer.CommonName, "Test CA", "Issuer")
+            assertBool(!crl.IsDelta(), "IsDelta")
+
+            assertEqual(len(crl.RevokedCertificateEntries), 2, "entries")
+            entry := crl.Entry(pki.leaf.SerialNumber)
+            assertBool(entry != nil, "Entry")
+            assertEqual(entry.ReasonCode, ReasonKeyCompromise, "ReasonCode")
+            assertBool(entry.RevocationTime.Equal(revokedAt), "RevocationTime")
+            assertBool(entry.InvalidityDate.Equal(invalidAt), "InvalidityDate")
+            assertEqual(crl.RevokedCertificateEntries[1].ReasonCode, ReasonUnspecified, "ReasonCode")
+
+            idp := crl.IssuingDistributionPoint
+            assertBool(idp != nil, "IssuingDistributionPoint")
+            assertEqual(idp.DistributionPoints, []string{"http://crl.example.com/ca.crl"}, "DistributionPoints")
+            assertBool(idp.OnlyContainsUserCerts, "OnlyContainsUserCerts")
+            assertBool(!idp.OnlyContainsCACerts, "OnlyContainsCACerts")
+
+            // the old parser still reads v2 CRLs
+            certList, err := ParseDERCRL(crl.Raw)
+            assertError(err, "ParseDERCRL")
+            assertError(pki.ca.CheckCRLSignature(certList), "CheckCRLSignature")
+        })
+    }
+}
+
+func Test_CreateRevocationList_Errors(t *testing.T) {
+    pki := newCRLTestPKI(t, crlTestKeys[0].key)
+
+    now := time.Now()
+
+    tests := []struct {
+        name     string
+        template *RevocationList
+        issuer   *Certificate
+    }{
+        {"nil number", &RevocationList{ThisUpdate: now, NextUpdate: now.Add(time.Hour)}, pki.ca},
+        {"next update", &RevocationList{Number: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(-time.Hour)}, pki.ca},
+        {"delta number", &RevocationList{Number: big.NewInt(1), BaseCRLNumber: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(time.Hour)}, pki.ca},
+        {"issuer", &RevocationList{Number: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(time.Hour)}, pki.leaf},
+    }
+
+    for _, test := range tests {
+        _, err := CreateRevocationList(rand.Reader, test.template, test.issuer, pki.caKey)
+        if err == nil {
+            t.Errorf("%s: expected error", test.name)
+        }
+    }
+}
+
+func Test_VerifyRevocation(t *testing.T) {
+    for _, td := range crlTestKeys {
+        t.Run(td.name, func(t *testing.T) {
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            pki := newCRLTestPKI(t, td.key)
+
+            verify := func(revocation *RevocationOptions) error {
+                _, err := pki.leaf.Verify(VerifyOptions{
+                    Roots:         pki.roots,
+                    Intermediates: pki.intermediates,
+                    Revocation:    revocation,
+                })
+                return err
+            }
+
+            isRevoked := func(err error) bool {
+                e, ok := err.(CertificateInvalidError)
+                return ok && e.Reason == CertificateRevoked
+            }
+
+            emptyCRL := pki.createCRL(t, &RevocationList{
+                Number: big.NewInt(1),
+            })
+
+            revokedCRL := pki.createCRL(t, &RevocationList{
+                Number: big.NewInt(2),
+                RevokedCertificateEntries: []RevocationListEntry{
+                    {
+                        SerialNumber:   pki.leaf.SerialNumber,
+                        RevocationTime: time.Now().Add(-time.Minute),
+                        ReasonCode:     ReasonCertificateHold,
+                    },
+                },
+            })
+
+            // no CRL
+            assertError(verify(&RevocationOptions{}), "no CRL")
+
+            err := verify(&RevocationOptions{RequireStatus: true})
+            e, ok := err.(CertificateInvalidError)
+            assertBool(ok && e.Reason == RevocationStatusUnavailable, "RequireStatus")
+
+            // not revoked
+            assertError(verify(&RevocationOptions{CRLs: []*RevocationList{emptyCRL}}), "empty CRL")
+
+            // revoked, the most recent CRL is used
+            err = verify(&RevocationOptions{CRLs: []*RevocationList{emptyCRL, revokedCRL}})
+            assertBool(isRevoked(err), "revoked")
+
+            // delta CRL releasing the hold
+            removeDelta := pki.createCRL(t, &RevocationList{
+                Number:        big.NewInt(3),
+                BaseCRLNumber: big.NewInt(2),
+                RevokedCertificateEntries: []RevocationListEntry{
+                    {
+                        SerialNumber:   pki.leaf.SerialNumber,
+                        RevocationTime: time.Now().Add(-time.Minute),
+                        ReasonCode:     ReasonRemoveFromCRL,
+                    },
+                },
+            })
+            assertBool(removeDelta.IsDelta(), "IsDelta")
+            assertError(verify(&RevocationOptions{CRLs: []*RevocationList{revokedCRL, removeDelta}}), "remove delta")
+
+            // delta CRL revoking
+            revokeDelta := pki.createCRL(t, &RevocationList{
+                Number:        big.NewInt(2),
+                BaseCRLNumber: big.NewInt(1),
+                RevokedCertificateEntries: []RevocationListEntry{
+                    {
+                        SerialNumber:   pki.leaf.SerialNumber,
+                        RevocationTime: time.Now().Add(-time.Minute),
+                    },
+                },
+            })
+            err = verify(&RevocationOptions{CRLs: []*RevocationList{emptyCRL, revokeDelta}})
+            assertBool(isRevoked(err), "revoke delta")
+
+            // a delta CRL alone is not enough
+            assertError(verify(&RevocationOptions{CRLs: []*RevocationList{revokeDelta}}), "delta alone")
+
+            // expired CRL
+            expiredCRL := pki.createCRL(t, &RevocationList{
+                Number:     big.NewInt(4),
+                ThisUpdate: time.Now().Add(-2 * time.Hour),
+                NextUpdate: time.Now().Add(-time.Hour),
+                RevokedCertificateEntries: []RevocationListEntry{
+                    {
+                        SerialNumber:   pki.leaf.SerialNumber,
+                        RevocationTime: time.Now().Add(-2 * time.Hour),
+                    },
+                },
+            })
+            assertError(verify(&RevocationOptions{CRLs: []*RevocationList{expiredCRL}}), "expired CRL")
+
+            // CRL out of scope
+            caOnlyCRL := pki.createCRL(t, &RevocationList{
+                Number: big.NewInt(5),
+                RevokedCertificateEntries: []RevocationListEntry{
+                    {
+                        SerialNumber:   pki.leaf.SerialNumber,
+                        RevocationTime: time.Now().Add(-time.Minute),
+                    },
+                },
+                IssuingDistributionPoint: &IssuingDistributionPoint{
+                    OnlyContainsCACerts: true,
+                },
+            })
+            assertError(verify(&RevocationOptions{CRLs: []*RevocationList{caOnlyCRL}}), "CA only CRL")
+
+            // CRL signed by another key
+            other := newCRLTestPKI(t, td.key)
+            forged := other.createCRL(t, &RevocationList{
+                Number: big.NewInt(6),
+                RevokedCertificateEntries: []RevocationListEntry{
+                    {
+                        SerialNumber:   pki.leaf.SerialNumber,
+                        RevocationTime: time.Now().Add(-time.Minute),
+                    },
+                },
+            })
+            assertError(verify(&RevocationOptions{CRLs: []*RevocationList{forged}}), "forged CRL")
+
+            // checker answers first
+            err = verify(&RevocationOptions{
+                CRLs:    []*RevocationList{emptyCRL},
+                Checker: testChecker(RevocationStatusRevoked),
+            })
+            assertBool(isRevoked(err), "checker")
+        })
+    }
+}
+
+type testChecker RevocationStatus
+
+func (c testChecker) RevocationStatus(cert, issuer *Certificate, now time.Time) (RevocationStatus, error) {
+    return RevocationStatus(c), nil
+}
+
+func Test_FilterChains(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    cert := &Certificate{}
+    chain := []*Certificate{cert}
+
+    opts := &VerifyOptions{}
+
+    accept := func([]*Certificate, time.Time) error {
+        return nil
+    }
+    errReject := errors.New("rejected")
+    reject := func([]*Certificate, time.Time) error {
+        return errReject
+    }
+
+    chains, err := opts.filterChains(cert, [][]*Certificate{chain}, RevocationStatusUnavailable, accept)
+    assertError(err, "filterChains")
+    assertEqual(len(chains), 1, "filterChains")
+
+    _, err = opts.filterChains(cert, [][]*Certificate{chain}, RevocationStatusUnavailable, reject)
+    assertBool(err == errReject, "filterChains rejected")
+
+    // no chain is an error, not an empty result
+    chains, err = opts.filterChains(cert, nil, RevocationStatusUnavailable, accept)
+    assertEqual(len(chains), 0, "filterChains empty")
+
+    e, ok := err.(CertificateInvalidError)
+    assertBool(ok && e.Reason == RevocationStatusUnavailable && e.Cert == cert, "filterChains empty")
+}
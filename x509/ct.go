@@ -0,0 +1,437 @@
+package x509
+
+import (
+    "time"
+    "errors"
+    "strconv"
+    "crypto"
+    "crypto/sha256"
+    "encoding/asn1"
+
+    "golang.org/x/crypto/cryptobyte"
+    cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+    // oidExtensionSCTList is the embedded SCT list extension, RFC 6962
+    // section 3.3.
+    oidExtensionSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+    // oidExtensionCTPoison marks a precertificate, RFC 6962 section 3.1.
+    oidExtensionCTPoison = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+    // OIDExtensionOCSPSCTList is the SCT list extension of the OCSP
+    // responses, RFC 6962 section 3.3.
+    OIDExtensionOCSPSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 5}
+)
+
+// LogEntryType is the type of the entry signed by a SCT.
+type LogEntryType uint16
+
+const (
+    // X509LogEntryType is the entry of a certificate, the SCTs
+    // delivered with the TLS handshake or in OCSP responses.
+    X509LogEntryType LogEntryType = 0
+    // PrecertLogEntryType is the entry of a precertificate, the SCTs
+    // embedded in the certificates.
+    PrecertLogEntryType LogEntryType = 1
+)
+
+// SCT versions and algorithms, RFC 5246 section 7.4.1.4.1.
+const (
+    sctVersion1 = 0
+
+    sctHashSHA256 = 4
+
+    sctSignatureRSA   = 1
+    sctSignatureECDSA = 3
+)
+
+// SignedCertificateTimestamp is a SCT, the promise of a CT log to
+// include a certificate, RFC 6962 section 3.2.
+type SignedCertificateTimestamp struct {
+    // Raw is the TLS encoded SCT.
+    Raw []byte
+
+    Version    uint8
+    LogID      [32]byte
+    Timestamp  time.Time
+    Extensions []byte
+
+    HashAlgorithm      uint8
+    SignatureAlgorithm uint8
+    Signature          []byte
+}
+
+// ParseSignedCertificateTimestamp parses a TLS encoded SCT, as in
+// tls.ConnectionState.SignedCertificateTimestamps.
+func ParseSignedCertificateTimestamp(data []byte) (*SignedCertificateTimestamp, error) {
+    input := cryptobyte.String(data)
+
+    sct := &SignedCertificateTimestamp{
+        Raw: data,
+    }
+
+    var logID []byte
+    var timestamp uint64
+    var extensions, signature cryptobyte.String
+    if !input.ReadUint8(&sct.Version) {
+        return nil, errors.New("x509: invalid SCT")
+    }
+
+    if sct.Version != sctVersion1 {
+        return nil, errors.New("x509: unsupported SCT version " + strconv.Itoa(int(sct.Version)))
+    }
+
+    if !input.ReadBytes(&logID, 32) ||
+        !input.ReadUint64(&timestamp) ||
+        !input.ReadUint16LengthPrefixed(&extensions) ||
+        !input.ReadUint8(&sct.HashAlgorithm) ||
+        !input.ReadUint8(&sct.SignatureAlgorithm) ||
+        !input.ReadUint16LengthPrefixed(&signature) ||
+        !input.Empty() {
+        return nil, errors.New("x509: invalid SCT")
+    }
+
+    copy(sct.LogID[:], logID)
+    sct.Timestamp = time.UnixMilli(int64(timestamp)).UTC()
+    sct.Extensions = extensions
+    sct.Signature = signature
+
+    return sct, nil
+}
+
+// ParseSCTList parses a TLS encoded SignedCertificateTimestampList,
+// the content of the SCT list extensions.
+func ParseSCTList(data []byte) ([]*SignedCertificateTimestamp, error) {
+    input := cryptobyte.String(data)
+
+    var list cryptobyte.String
+    if !input.ReadUint16LengthPrefixed(&list) || !input.Empty() {
+        return nil, errors.New("x509: invalid SCT list")
+    }
+
+    var scts []*SignedCertificateTimestamp
+    for !list.Empty() {
+        var raw cryptobyte.String
+        if !list.ReadUint16LengthPrefixed(&raw) {
+            return nil, errors.New("x509: invalid SCT list")
+        }
+
+        sct, err := ParseSignedCertificateTimestamp(raw)
+        if err != nil {
+            return nil, err
+        }
+
+        scts = append(scts, sct)
+    }
+
+    return scts, nil
+}
+
+// ParseSCTListExtension parses the value of a SCT list extension of a
+// certificate or of an OCSP response, an OCTET STRING with the TLS
+// encoded list.
+func ParseSCTListExtension(value []byte) ([]*SignedCertificateTimestamp, error) {
+    var list []byte
+    rest, err := asn1.Unmarshal(value, &list)
+    if err != nil || len(rest) > 0 {
+        return nil, errors.New("x509: invalid SCT list extension")
+    }
+
+    return ParseSCTList(list)
+}
+
+// Marshal returns the TLS encoding of sct.
+func (sct *SignedCertificateTimestamp) Marshal() ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddUint8(sct.Version)
+    b.AddBytes(sct.LogID[:])
+    b.AddUint64(uint64(sct.Timestamp.UnixMilli()))
+    b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+        b.AddBytes(sct.Extensions)
+    })
+    b.AddUint8(sct.HashAlgorithm)
+    b.AddUint8(sct.SignatureAlgorithm)
+    b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+        b.AddBytes(sct.Signature)
+    })
+
+    return b.Bytes()
+}
+
+// MarshalSCTList returns the TLS encoded list of scts.
+func MarshalSCTList(scts []*SignedCertificateTimestamp) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+        for _, sct := range scts {
+            der, err := sct.Marshal()
+            if err != nil {
+                b.SetError(err)
+                return
+            }
+
+            b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+                b.AddBytes(der)
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+// SignedEntry returns the data signed by sct for an entry of type typ
+// for cert. The precertificate entries need issuer, the issuer of cert.
+func (sct *SignedCertificateTimestamp) SignedEntry(typ LogEntryType, cert, issuer *Certificate) ([]byte, error) {
+    var b cryptobyte.Builder
+    b.AddUint8(sct.Version)
+    b.AddUint8(0) // certificate_timestamp
+    b.AddUint64(uint64(sct.Timestamp.UnixMilli()))
+    b.AddUint16(uint16(typ))
+
+    switch typ {
+        case X509LogEntryType:
+            b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+                b.AddBytes(cert.Raw)
+            })
+        case PrecertLogEntryType:
+            if issuer == nil {
+                return nil, errors.New("x509: precertificate entry without issuer")
+            }
+
+            tbs, err := cert.PrecertificateTBS()
+            if err != nil {
+                return nil, err
+            }
+
+            issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+            b.AddBytes(issuerKeyHash[:])
+            b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+                b.AddBytes(tbs)
+            })
+        default:
+            return nil, errors.New("x509: unknown log entry type")
+    }
+
+    b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+        b.AddBytes(sct.Extensions)
+    })
+
+    return b.Bytes()
+}
+
+// Verify checks that sct is a valid SCT of log for an entry of type
+// typ for cert, issued by issuer.
+func (sct *SignedCertificateTimestamp) Verify(log *CTLog, typ LogEntryType, cert, issuer *Certificate) error {
+    if sct.LogID != log.ID {
+        return errors.New("x509: SCT of another log")
+    }
+
+    if !log.NotBefore.IsZero() && sct.Timestamp.Before(log.NotBefore) ||
+        !log.NotAfter.IsZero() && !sct.Timestamp.Before(log.NotAfter) {
+        return errors.New("x509: SCT timestamp outside of the log validity")
+    }
+
+    if sct.HashAlgorithm != sctHashSHA256 {
+        return errors.New("x509: unsupported SCT hash algorithm")
+    }
+
+    var algo SignatureAlgorithm
+    switch sct.SignatureAlgorithm {
+        case sctSignatureRSA:
+            algo = SHA256WithRSA
+        case sctSignatureECDSA:
+            algo = ECDSAWithSHA256
+        default:
+            return errors.New("x509: unsupported SCT signature algorithm")
+    }
+
+    signed, err := sct.SignedEntry(typ, cert, issuer)
+    if err != nil {
+        return err
+    }
+
+    return CheckSignatureWithPublicKey(algo, signed, sct.Signature, log.PublicKey)
+}
+
+// CTLog is a CT log trusted to issue SCTs.
+type CTLog struct {
+    Description string
+
+    // ID is the log ID, the SHA-256 of the DER encoded public key.
+    ID [32]byte
+
+    // PublicKey is the key of the log, an ECDSA or a RSA key.
+    PublicKey crypto.PublicKey
+
+    // NotBefore and NotAfter, if not zero, bound the timestamps of the
+    // accepted SCTs, NotAfter excluded.
+    NotBefore, NotAfter time.Time
+}
+
+// NewCTLog returns the log with the DER encoded public key.
+func NewCTLog(description string, publicKey []byte) (*CTLog, error) {
+    pub, err := ParsePKIXPublicKey(publicKey)
+    if err != nil {
+        return nil, err
+    }
+
+    return &CTLog{
+        Description: description,
+        ID:          sha256.Sum256(publicKey),
+        PublicKey:   pub,
+    }, nil
+}
+
+// SignedCertificateTimestamps returns the SCTs embedded in c, none if c
+// has no SCT list extension.
+func (c *Certificate) SignedCertificateTimestamps() ([]*SignedCertificateTimestamp, error) {
+    for _, ext := range c.Extensions {
+        if ext.Id.Equal(oidExtensionSCTList) {
+            return ParseSCTListExtension(ext.Value)
+        }
+    }
+
+    return nil, nil
+}
+
+// PrecertificateTBS returns the TBSCertificate of the precertificate
+// of c, without the SCT list and the poison extensions, RFC 6962
+// section 3.2.
+func (c *Certificate) PrecertificateTBS() ([]byte, error) {
+    input := cryptobyte.String(c.RawTBSCertificate)
+
+    var tbs cryptobyte.String
+    if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+        return nil, errors.New("x509: malformed tbs certificate")
+    }
+
+    extensionsTag := cryptobyte_asn1.Tag(3).ContextSpecific().Constructed()
+
+    var b cryptobyte.Builder
+    b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+        for !tbs.Empty() {
+            var field cryptobyte.String
+            var tag cryptobyte_asn1.Tag
+            if !tbs.ReadAnyASN1Element(&field, &tag) {
+                b.SetError(errors.New("x509: malformed tbs certificate"))
+                return
+            }
+
+            if tag != extensionsTag {
+                b.AddBytes(field)
+                continue
+            }
+
+            var exts cryptobyte.String
+            if !field.ReadASN1(&field, extensionsTag) ||
+                !field.ReadASN1(&exts, cryptobyte_asn1.SEQUENCE) {
+                b.SetError(errors.New("x509: malformed extensions"))
+                return
+            }
+
+            var kept [][]byte
+            for !exts.Empty() {
+                var ext, extBody cryptobyte.String
+                var oid asn1.ObjectIdentifier
+                if !exts.ReadASN1Element(&ext, cryptobyte_asn1.SEQUENCE) {
+                    b.SetError(errors.New("x509: malformed extension"))
+                    return
+                }
+
+                extBody = ext
+                if !extBody.ReadASN1(&extBody, cryptobyte_asn1.SEQUENCE) ||
+                    !extBody.ReadASN1ObjectIdentifier(&oid) {
+                    b.SetError(errors.New("x509: malformed extension"))
+                    return
+                }
+
+                if oid.Equal(oidExtensionSCTList) || oid.Equal(oidExtensionCTPoison) {
+                    continue
+                }
+
+                kept = append(kept, ext)
+            }
+
+            if len(kept) == 0 {
+                continue
+            }
+
+            b.AddASN1(extensionsTag, func(b *cryptobyte.Builder) {
+                b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+                    for _, ext := range kept {
+                        b.AddBytes(ext)
+                    }
+                })
+            })
+        }
+    })
+
+    return b.Bytes()
+}
+
+// CTOptions makes Certificate.Verify require valid SCTs for the leaf
+// certificate, RFC 6962.
+type CTOptions struct {
+    // Logs are the trusted logs.
+    Logs []*CTLog
+
+    // MinSCTs is the number of distinct logs with a valid SCT needed,
+    // 1 if zero.
+    MinSCTs int
+
+    // SCTs are the SCTs of the leaf delivered with the TLS handshake
+    // or in an OCSP response, checked with the embedded ones.
+    SCTs []*SignedCertificateTimestamp
+}
+
+// ValidSCTs returns the number of distinct logs with a valid SCT for
+// cert, issued by issuer, at time now.
+func (o *CTOptions) ValidSCTs(cert, issuer *Certificate, now time.Time) int {
+    valid := make(map[[32]byte]bool)
+
+    check := func(sct *SignedCertificateTimestamp, typ LogEntryType) {
+        if valid[sct.LogID] || sct.Timestamp.After(now) {
+            return
+        }
+
+        for _, log := range o.Logs {
+            if log.ID == sct.LogID && sct.Verify(log, typ, cert, issuer) == nil {
+                valid[sct.LogID] = true
+                return
+            }
+        }
+    }
+
+    if issuer != nil {
+        embedded, _ := cert.SignedCertificateTimestamps()
+        for _, sct := range embedded {
+            check(sct, PrecertLogEntryType)
+        }
+    }
+
+    for _, sct := range o.SCTs {
+        check(sct, X509LogEntryType)
+    }
+
+    return len(valid)
+}
+
+// checkChain checks the SCTs of the leaf of chain.
+func (o *CTOptions) checkChain(chain []*Certificate, now time.Time) error {
+    var issuer *Certificate
+    if len(chain) > 1 {
+        issuer = chain[1]
+    }
+
+    required := o.MinSCTs
+    if required <= 0 {
+        required = 1
+    }
+
+    valid := o.ValidSCTs(chain[0], issuer, now)
+    if valid < required {
+        detail := strconv.Itoa(valid) + " of " + strconv.Itoa(required) + " required"
+        return CertificateInvalidError{chain[0], InsufficientSCTs, detail}
+    }
+
+    return nil
+}
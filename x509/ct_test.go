@@ -0,0 +1,227 @@
+package x509
+
+import (
+    "time"
+    "errors"
+    "testing"
+    "math/big"
+    "crypto"
+    "crypto/rsa"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/sha256"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+type ctTestLog struct {
+    log *CTLog
+    key crypto.Signer
+}
+
+func newCTTestLog(t *testing.T, key crypto.Signer) *ctTestLog {
+    der, err := MarshalPKIXPublicKey(key.Public())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    log, err := NewCTLog("test log", der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return &ctTestLog{log, key}
+}
+
+func (l *ctTestLog) sign(t *testing.T, typ LogEntryType, cert, issuer *Certificate, timestamp time.Time) *SignedCertificateTimestamp {
+    sct := &SignedCertificateTimestamp{
+        LogID:         l.log.ID,
+        Timestamp:     timestamp.Truncate(time.Millisecond),
+        HashAlgorithm: sctHashSHA256,
+    }
+
+    signed, err := sct.SignedEntry(typ, cert, issuer)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    digest := sha256.Sum256(signed)
+
+    switch key := l.key.(type) {
+        case *ecdsa.PrivateKey:
+            sct.SignatureAlgorithm = sctSignatureECDSA
+            sct.Signature, err = ecdsa.SignASN1(rand.Reader, key, digest[:])
+        case *rsa.PrivateKey:
+            sct.SignatureAlgorithm = sctSignatureRSA
+            sct.Signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+    }
+
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return sct
+}
+
+func Test_SignedCertificateTimestamps(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    now := time.Now()
+
+    rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    rootTemplate := &Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: "CT Root"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              KeyUsageCertSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+
+    der, err := CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+    assertError(err, "CreateCertificate")
+
+    root, err := ParseCertificate(der)
+    assertError(err, "ParseCertificate")
+
+    leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    leafTemplate := &Certificate{
+        SerialNumber: big.NewInt(2),
+        Subject:      pkix.Name{CommonName: "ct.example.com"},
+        DNSNames:     []string{"ct.example.com"},
+        NotBefore:    now.Add(-time.Hour),
+        NotAfter:     now.Add(time.Hour),
+        KeyUsage:     KeyUsageDigitalSignature,
+        ExtKeyUsage:  []ExtKeyUsage{ExtKeyUsageServerAuth},
+    }
+
+    // the precertificate with the poison extension
+    leafTemplate.ExtraExtensions = []pkix.Extension{
+        {Id: oidExtensionCTPoison, Critical: true, Value: asn1.NullBytes},
+    }
+
+    der, err = CreateCertificate(rand.Reader, leafTemplate, root, leafKey.Public(), rootKey)
+    assertError(err, "CreateCertificate")
+
+    precert, err := ParseCertificate(der)
+    assertError(err, "ParseCertificate")
+
+    ecLogKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    rsaLogKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    assertError(err, "GenerateKey")
+
+    otherLogKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    ecLog := newCTTestLog(t, ecLogKey)
+    rsaLog := newCTTestLog(t, rsaLogKey)
+    otherLog := newCTTestLog(t, otherLogKey)
+
+    embedded := []*SignedCertificateTimestamp{
+        ecLog.sign(t, PrecertLogEntryType, precert, root, now.Add(-time.Minute)),
+        rsaLog.sign(t, PrecertLogEntryType, precert, root, now.Add(-time.Minute)),
+    }
+
+    list, err := MarshalSCTList(embedded)
+    assertError(err, "MarshalSCTList")
+
+    value, err := asn1.Marshal(list)
+    assertError(err, "Marshal")
+
+    // the certificate with the SCTs of the precertificate
+    leafTemplate.ExtraExtensions = []pkix.Extension{
+        {Id: oidExtensionSCTList, Value: value},
+    }
+
+    der, err = CreateCertificate(rand.Reader, leafTemplate, root, leafKey.Public(), rootKey)
+    assertError(err, "CreateCertificate")
+
+    leaf, err := ParseCertificate(der)
+    assertError(err, "ParseCertificate")
+
+    precertTBS, err := precert.PrecertificateTBS()
+    assertError(err, "PrecertificateTBS")
+
+    leafTBS, err := leaf.PrecertificateTBS()
+    assertError(err, "PrecertificateTBS")
+    assertEqual(leafTBS, precertTBS, "PrecertificateTBS")
+
+    scts, err := leaf.SignedCertificateTimestamps()
+    assertError(err, "SignedCertificateTimestamps")
+    assertEqual(len(scts), 2, "SignedCertificateTimestamps")
+    assertEqual(scts[0].LogID, ecLog.log.ID, "LogID")
+    assertBool(scts[0].Timestamp.Equal(embedded[0].Timestamp), "Timestamp")
+    assertEqual(scts[1].Signature, embedded[1].Signature, "Signature")
+
+    assertError(scts[0].Verify(ecLog.log, PrecertLogEntryType, leaf, root), "Verify")
+    assertError(scts[1].Verify(rsaLog.log, PrecertLogEntryType, leaf, root), "Verify")
+    assertBool(scts[0].Verify(rsaLog.log, PrecertLogEntryType, leaf, root) != nil, "Verify other log")
+    assertBool(scts[0].Verify(ecLog.log, X509LogEntryType, leaf, root) != nil, "Verify x509 entry")
+
+    // the SCT delivered with the TLS handshake
+    tlsSCT := otherLog.sign(t, X509LogEntryType, leaf, nil, now.Add(-time.Minute))
+
+    raw, err := tlsSCT.Marshal()
+    assertError(err, "Marshal")
+
+    tlsSCT, err = ParseSignedCertificateTimestamp(raw)
+    assertError(err, "ParseSignedCertificateTimestamp")
+    assertError(tlsSCT.Verify(otherLog.log, X509LogEntryType, leaf, nil), "Verify")
+
+    roots := NewCertPool()
+    roots.AddCert(root)
+
+    verify := func(ct *CTOptions) error {
+        _, err := leaf.Verify(VerifyOptions{
+            Roots:                   roots,
+            CertificateTransparency: ct,
+        })
+
+        return err
+    }
+
+    logs := []*CTLog{ecLog.log, rsaLog.log, otherLog.log}
+
+    assertError(verify(&CTOptions{Logs: logs, MinSCTs: 2}), "Verify 2 SCTs")
+    assertError(verify(&CTOptions{Logs: logs, MinSCTs: 3, SCTs: []*SignedCertificateTimestamp{tlsSCT}}), "Verify 3 SCTs")
+
+    err = verify(&CTOptions{Logs: logs, MinSCTs: 3})
+
+    var invalid CertificateInvalidError
+    assertBool(errors.As(err, &invalid), "Verify insufficient")
+    assertEqual(invalid.Reason, InsufficientSCTs, "Verify insufficient")
+
+    // the SCTs of unknown logs are ignored
+    assertBool(verify(&CTOptions{Logs: []*CTLog{otherLog.log}}) != nil, "Verify unknown logs")
+
+    // the log validity window
+    retired := *ecLog.log
+    retired.NotAfter = now.Add(-time.Hour)
+    assertBool(scts[0].Verify(&retired, PrecertLogEntryType, leaf, root) != nil, "Verify retired log")
+
+    // a tampered signature
+    tampered := *scts[0]
+    tampered.Signature = append([]byte{}, tampered.Signature...)
+    tampered.Signature[len(tampered.Signature) - 1] ^= 1
+    assertBool(tampered.Verify(ecLog.log, PrecertLogEntryType, leaf, root) != nil, "Verify tampered")
+
+    // the OCSP and certificate extension encoding
+    parsed, err := ParseSCTListExtension(value)
+    assertError(err, "ParseSCTListExtension")
+    assertEqual(len(parsed), 2, "ParseSCTListExtension")
+
+    _, err = ParseSCTList(list[:len(list) - 1])
+    assertBool(err != nil, "ParseSCTList truncated")
+}
@@ -0,0 +1,291 @@
+package lint
+
+import (
+    "io"
+    "fmt"
+    "sort"
+    "sync"
+    "errors"
+    "strings"
+    "crypto"
+    "crypto/rsa"
+    "crypto/rand"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// Severity is the importance of a finding.
+type Severity int
+
+const (
+    // Notice is a finding worth knowing about, not a problem.
+    Notice Severity = iota + 1
+
+    // Warning is a deviation from a SHOULD of the source.
+    Warning
+
+    // Error is a violation of a MUST of the source.
+    Error
+)
+
+func (s Severity) String() string {
+    switch s {
+        case Notice:
+            return "notice"
+        case Warning:
+            return "warning"
+        case Error:
+            return "error"
+    }
+
+    return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Source is the document a lint comes from.
+type Source string
+
+const (
+    RFC5280 Source = "RFC 5280"
+    CABF    Source = "CA/Browser Forum Baseline Requirements"
+    GMT0015 Source = "GM/T 0015-2012"
+)
+
+// Lint is a check of certificates.
+type Lint struct {
+    // Name identifies the lint in a Registry and in the findings.
+    Name string
+
+    // Description of what the lint checks.
+    Description string
+
+    Source   Source
+    Severity Severity
+
+    // Applies reports whether the lint is relevant for cert, all
+    // certificates if nil.
+    Applies func(cert *x509.Certificate) bool
+
+    // Check returns an error describing the problem found in cert, or
+    // nil if there is none.
+    Check func(cert *x509.Certificate) error
+}
+
+// Finding is a problem found by a lint.
+type Finding struct {
+    Lint     string
+    Source   Source
+    Severity Severity
+    Message  string
+}
+
+func (f Finding) String() string {
+    return fmt.Sprintf("%s: %s (%s, %s)", f.Lint, f.Message, f.Severity, f.Source)
+}
+
+// Result are the findings of the lints of a Registry on a certificate,
+// ordered by lint name.
+type Result struct {
+    Findings []Finding
+}
+
+// Max returns the highest severity of the findings, 0 if there are none.
+func (r *Result) Max() Severity {
+    var level Severity
+    for _, f := range r.Findings {
+        if f.Severity > level {
+            level = f.Severity
+        }
+    }
+
+    return level
+}
+
+// Filter returns the findings of level or higher.
+func (r *Result) Filter(level Severity) []Finding {
+    var findings []Finding
+    for _, f := range r.Findings {
+        if f.Severity >= level {
+            findings = append(findings, f)
+        }
+    }
+
+    return findings
+}
+
+// Err returns a *CertificateError with the findings of level or higher, or nil if
+// there are none.
+func (r *Result) Err(level Severity) error {
+    findings := r.Filter(level)
+    if len(findings) == 0 {
+        return nil
+    }
+
+    return &CertificateError{findings}
+}
+
+// CertificateError is returned for certificates with findings.
+type CertificateError struct {
+    Findings []Finding
+}
+
+func (e *CertificateError) Error() string {
+    msgs := make([]string, len(e.Findings))
+    for i, f := range e.Findings {
+        msgs[i] = f.Lint + ": " + f.Message
+    }
+
+    return "lint: certificate rejected: " + strings.Join(msgs, "; ")
+}
+
+// Registry is a set of lints run together on certificates. It is safe
+// for concurrent use.
+type Registry struct {
+    mu    sync.RWMutex
+    lints map[string]*Lint
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+    return &Registry{
+        lints: make(map[string]*Lint),
+    }
+}
+
+// Register adds l to the registry. Its name must be unique.
+func (r *Registry) Register(l *Lint) error {
+    if l.Name == "" || l.Check == nil {
+        return errors.New("lint: lint without name or check")
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, ok := r.lints[l.Name]; ok {
+        return errors.New("lint: lint " + l.Name + " is already registered")
+    }
+
+    r.lints[l.Name] = l
+
+    return nil
+}
+
+// Lint returns the lint with the name, or nil.
+func (r *Registry) Lint(name string) *Lint {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return r.lints[name]
+}
+
+// Lints returns the lints of the registry ordered by name.
+func (r *Registry) Lints() []*Lint {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    lints := make([]*Lint, 0, len(r.lints))
+    for _, l := range r.lints {
+        lints = append(lints, l)
+    }
+
+    sort.Slice(lints, func(i, j int) bool {
+        return lints[i].Name < lints[j].Name
+    })
+
+    return lints
+}
+
+// Filter returns a new registry with the lints keep returns true for,
+// as for example the ones of a source.
+func (r *Registry) Filter(keep func(l *Lint) bool) *Registry {
+    filtered := NewRegistry()
+    for _, l := range r.Lints() {
+        if keep(l) {
+            filtered.lints[l.Name] = l
+        }
+    }
+
+    return filtered
+}
+
+// Run runs the lints applying to cert.
+func (r *Registry) Run(cert *x509.Certificate) *Result {
+    res := &Result{}
+    for _, l := range r.Lints() {
+        if l.Applies != nil && !l.Applies(cert) {
+            continue
+        }
+
+        if err := l.Check(cert); err != nil {
+            res.Findings = append(res.Findings, Finding{
+                Lint:     l.Name,
+                Source:   l.Source,
+                Severity: l.Severity,
+                Message:  err.Error(),
+            })
+        }
+    }
+
+    return res
+}
+
+// RunTemplate runs the lints on the certificate x509.CreateCertificate
+// would create for template, parent and pub when signed by the key of
+// signerPub, without signing anything with it.
+func (r *Registry) RunTemplate(template, parent *x509.Certificate, pub, signerPub crypto.PublicKey) (*Result, error) {
+    // CreateCertificate sets the AuthorityKeyId of the template
+    tmpl := *template
+
+    der, err := x509.CreateCertificate(rand.Reader, &tmpl, parent, pub, templateSigner{signerPub})
+    if err != nil {
+        return nil, err
+    }
+
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        return nil, err
+    }
+
+    return r.Run(cert), nil
+}
+
+// templateSigner is a crypto.Signer for the public key of an issuer
+// returning placeholder signatures.
+type templateSigner struct {
+    pub crypto.PublicKey
+}
+
+func (s templateSigner) Public() crypto.PublicKey {
+    return s.pub
+}
+
+func (s templateSigner) Sign(_ io.Reader, _ []byte, _ crypto.SignerOpts) ([]byte, error) {
+    if pub, ok := s.pub.(*rsa.PublicKey); ok {
+        return make([]byte, pub.Size()), nil
+    }
+
+    return make([]byte, 64), nil
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry with the lints of the package,
+// to which Register adds.
+func DefaultRegistry() *Registry {
+    return defaultRegistry
+}
+
+// Register adds l to the default registry.
+func Register(l *Lint) error {
+    return defaultRegistry.Register(l)
+}
+
+// Run runs the lints of the default registry on cert.
+func Run(cert *x509.Certificate) *Result {
+    return defaultRegistry.Run(cert)
+}
+
+// RunTemplate runs the lints of the default registry on template, see
+// Registry.RunTemplate.
+func RunTemplate(template, parent *x509.Certificate, pub, signerPub crypto.PublicKey) (*Result, error) {
+    return defaultRegistry.RunTemplate(template, parent, pub, signerPub)
+}
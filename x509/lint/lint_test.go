@@ -0,0 +1,245 @@
+package lint
+
+import (
+    "time"
+    "errors"
+    "testing"
+    "math/big"
+    "crypto"
+    "crypto/rsa"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+func findingNames(res *Result) map[string]Severity {
+    names := make(map[string]Severity)
+    for _, f := range res.Findings {
+        names[f.Lint] = f.Severity
+    }
+
+    return names
+}
+
+func newRoot(t *testing.T, key crypto.Signer, sigAlgo x509.SignatureAlgorithm) *x509.Certificate {
+    now := time.Now()
+
+    template := &x509.Certificate{
+        SerialNumber:          new(big.Int).Lsh(big.NewInt(1), 100),
+        Subject:               pkix.Name{CommonName: "Lint Root"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+        KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SubjectKeyId:          []byte{1, 2, 3, 4},
+        SignatureAlgorithm:    sigAlgo,
+    }
+
+    der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return cert
+}
+
+func Test_Lints(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    root := newRoot(t, caKey, 0)
+    assertEqual(Run(root).Findings, []Finding(nil), "Run root")
+
+    leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    now := time.Now()
+    good := &x509.Certificate{
+        SerialNumber: new(big.Int).Lsh(big.NewInt(1), 100),
+        Subject:      pkix.Name{CommonName: "www.example.com"},
+        DNSNames:     []string{"www.example.com", "*.example.com"},
+        NotBefore:    now,
+        NotAfter:     now.Add(90 * 24 * time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+    }
+
+    res, err := RunTemplate(good, root, leafKey.Public(), caKey.Public())
+    assertError(err, "RunTemplate")
+    assertEqual(res.Findings, []Finding(nil), "RunTemplate good")
+    assertEqual(res.Max(), Severity(0), "Max")
+    assertError(res.Err(Notice), "Err")
+
+    // the template is not changed
+    assertEqual(len(good.AuthorityKeyId), 0, "AuthorityKeyId")
+
+    bad := *good
+    bad.SerialNumber = big.NewInt(42)
+    bad.Subject = pkix.Name{CommonName: "mail.example.com"}
+    bad.DNSNames = []string{"www.example.com", "bad_name.example.com", "www.*.example.com"}
+    bad.NotAfter = now.Add(2 * 365 * 24 * time.Hour)
+    bad.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+    res, err = RunTemplate(&bad, root, leafKey.Public(), caKey.Public())
+    assertError(err, "RunTemplate")
+
+    names := findingNames(res)
+    assertEqual(names, map[string]Severity{
+        "serial_number_entropy":      Error,
+        "tls_common_name_not_in_san": Error,
+        "dns_name_syntax":            Error,
+        "tls_validity_period":        Error,
+        "key_usage_algorithm":        Error,
+    }, "RunTemplate bad")
+    assertEqual(res.Max(), Error, "Max")
+
+    err = res.Err(Error)
+
+    var certErr *CertificateError
+    assertBool(errors.As(err, &certErr), "Err")
+    assertEqual(len(certErr.Findings), 5, "Err")
+
+    // findings are ordered by lint name
+    assertEqual(res.Findings[0].Lint, "dns_name_syntax", "Findings")
+    assertEqual(res.Findings[0].Source, RFC5280, "Findings")
+}
+
+func Test_CALints(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+    assertError(err, "GenerateKey")
+
+    now := time.Now()
+    template := &x509.Certificate{
+        SerialNumber:          new(big.Int).Lsh(big.NewInt(1), 100),
+        Subject:               pkix.Name{CommonName: "Weak Root"},
+        NotBefore:             now,
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageKeyAgreement,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SignatureAlgorithm:    x509.SHA1WithRSA,
+    }
+
+    res, err := RunTemplate(template, template, rsaKey.Public(), rsaKey.Public())
+    assertError(err, "RunTemplate")
+
+    assertEqual(findingNames(res), map[string]Severity{
+        "ca_key_usage_cert_sign":    Error,
+        "ca_subject_key_id_missing": Error,
+        "key_usage_algorithm":       Error,
+        "rsa_key_size":              Error,
+        "signature_algorithm_weak":  Error,
+    }, "RunTemplate")
+}
+
+func Test_SM2Lints(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    caKey, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    root := newRoot(t, caKey, x509.SM2WithSM3)
+    assertEqual(Run(root).Findings, []Finding(nil), "Run root")
+
+    key, err := sm2.GenerateKey(rand.Reader)
+    assertError(err, "GenerateKey")
+
+    now := time.Now()
+    template := &x509.Certificate{
+        SerialNumber: new(big.Int).Lsh(big.NewInt(1), 100),
+        Subject:      pkix.Name{CommonName: "alice"},
+        NotBefore:    now,
+        NotAfter:     now.Add(time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+    }
+
+    res, err := RunTemplate(template, root, key.Public(), caKey.Public())
+    assertError(err, "RunTemplate")
+
+    assertEqual(findingNames(res), map[string]Severity{
+        "sm2_key_identifiers":            Error,
+        "sm2_dual_certificate_key_usage": Warning,
+    }, "RunTemplate")
+
+    // signed by a RSA CA, without key usage
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    assertError(err, "GenerateKey")
+
+    rsaRoot := newRoot(t, rsaKey, 0)
+
+    template.KeyUsage = 0
+    template.SubjectKeyId = []byte{5, 6, 7, 8}
+
+    res, err = RunTemplate(template, rsaRoot, key.Public(), rsaKey.Public())
+    assertError(err, "RunTemplate")
+
+    assertEqual(findingNames(res), map[string]Severity{
+        "sm2_signature_algorithm": Error,
+        "sm2_key_usage_missing":   Error,
+    }, "RunTemplate RSA")
+}
+
+func Test_Registry(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    r := DefaultRegistry().Filter(func(l *Lint) bool {
+        return l.Source == GMT0015
+    })
+
+    for _, l := range r.Lints() {
+        assertEqual(l.Source, GMT0015, "Filter")
+    }
+    assertBool(r.Lint("rsa_key_size") == nil, "Filter")
+    assertBool(DefaultRegistry().Lint("rsa_key_size") != nil, "Filter")
+
+    err := r.Register(&Lint{
+        Name:     "organization_required",
+        Source:   "example policy",
+        Severity: Notice,
+        Check: func(cert *x509.Certificate) error {
+            if len(cert.Subject.Organization) == 0 {
+                return errors.New("no organization")
+            }
+
+            return nil
+        },
+    })
+    assertError(err, "Register")
+
+    err = r.Register(&Lint{Name: "organization_required", Check: r.Lint("organization_required").Check})
+    assertBool(err != nil, "Register twice")
+
+    err = r.Register(&Lint{Name: "no_check"})
+    assertBool(err != nil, "Register no check")
+
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    assertError(err, "GenerateKey")
+
+    res := r.Run(newRoot(t, key, 0))
+    assertEqual(len(res.Findings), 1, "Run")
+    assertEqual(res.Findings[0].Message, "no organization", "Run")
+    assertEqual(res.Findings[0].String(), "organization_required: no organization (notice, example policy)", "String")
+    assertError(res.Err(Warning), "Err")
+    assertBool(res.Err(Notice) != nil, "Err")
+}
@@ -0,0 +1,643 @@
+package lint
+
+import (
+    "fmt"
+    "net"
+    "time"
+    "bytes"
+    "errors"
+    "strings"
+    "math/big"
+    "crypto/rsa"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+var (
+    oidExtensionSubjectKeyId     = asn1.ObjectIdentifier{2, 5, 29, 14}
+    oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+    oidExtensionSubjectAltName   = asn1.ObjectIdentifier{2, 5, 29, 17}
+    oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+    oidExtensionNameConstraints  = asn1.ObjectIdentifier{2, 5, 29, 30}
+    oidExtensionAuthorityKeyId   = asn1.ObjectIdentifier{2, 5, 29, 35}
+)
+
+// maxTLSValidity is the longest validity of TLS server certificates
+// allowed by the Baseline Requirements, both ends included.
+const maxTLSValidity = 398 * 24 * time.Hour
+
+// minSerialBits are the bits of a serial number with the 64 random
+// bits asked by the Baseline Requirements.
+const minSerialBits = 64
+
+func init() {
+    for _, l := range builtinLints {
+        if err := Register(l); err != nil {
+            panic(err)
+        }
+    }
+}
+
+var builtinLints = []*Lint{
+    // RFC 5280
+    {
+        Name:        "serial_number_positive",
+        Description: "The serial number must be a positive integer.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            if cert.SerialNumber == nil || cert.SerialNumber.Sign() <= 0 {
+                return errors.New("serial number is not positive")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "serial_number_length",
+        Description: "The serial number must not be longer than 20 octets.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     hasSerialNumber,
+        Check: func(cert *x509.Certificate) error {
+            if n := serialLength(cert.SerialNumber); n > 20 {
+                return fmt.Errorf("serial number of %d octets", n)
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "validity_order",
+        Description: "The certificate must not expire before it is valid.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            if cert.NotAfter.Before(cert.NotBefore) {
+                return errors.New("notAfter is before notBefore")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "subject_empty_without_san",
+        Description: "A certificate with an empty subject must have a subject alternative name extension.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     hasEmptySubject,
+        Check: func(cert *x509.Certificate) error {
+            if findExtension(cert, oidExtensionSubjectAltName) == nil {
+                return errors.New("empty subject without subject alternative names")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "san_not_critical_empty_subject",
+        Description: "The subject alternative name extension must be critical when the subject is empty.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     hasEmptySubject,
+        Check: func(cert *x509.Certificate) error {
+            if ext := findExtension(cert, oidExtensionSubjectAltName); ext != nil && !ext.Critical {
+                return errors.New("subject alternative name extension of an empty subject is not critical")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "ca_subject_empty",
+        Description: "The subject of a CA certificate must not be empty.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     isCA,
+        Check: func(cert *x509.Certificate) error {
+            if hasEmptySubject(cert) {
+                return errors.New("CA certificate with an empty subject")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "ca_basic_constraints_not_critical",
+        Description: "The basic constraints extension of a CA certificate must be critical.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     isCA,
+        Check: func(cert *x509.Certificate) error {
+            if ext := findExtension(cert, oidExtensionBasicConstraints); ext == nil || !ext.Critical {
+                return errors.New("basic constraints extension is not critical")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "ca_key_usage_cert_sign",
+        Description: "A CA certificate must have a key usage extension with keyCertSign.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     isCA,
+        Check: func(cert *x509.Certificate) error {
+            if findExtension(cert, oidExtensionKeyUsage) == nil {
+                return errors.New("CA certificate without key usage extension")
+            }
+
+            if cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+                return errors.New("CA certificate without keyCertSign usage")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "ca_subject_key_id_missing",
+        Description: "A CA certificate must have a subject key identifier.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     isCA,
+        Check: func(cert *x509.Certificate) error {
+            if len(cert.SubjectKeyId) == 0 {
+                return errors.New("CA certificate without subject key identifier")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "authority_key_id_missing",
+        Description: "A certificate not self-issued must have an authority key identifier.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Applies:     notSelfIssued,
+        Check: func(cert *x509.Certificate) error {
+            if len(cert.AuthorityKeyId) == 0 {
+                return errors.New("no authority key identifier")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "key_identifier_critical",
+        Description: "The subject and authority key identifier extensions must not be critical.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            if ext := findExtension(cert, oidExtensionSubjectKeyId); ext != nil && ext.Critical {
+                return errors.New("subject key identifier extension is critical")
+            }
+
+            if ext := findExtension(cert, oidExtensionAuthorityKeyId); ext != nil && ext.Critical {
+                return errors.New("authority key identifier extension is critical")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "key_usage_not_critical",
+        Description: "The key usage extension should be critical.",
+        Source:      RFC5280,
+        Severity:    Warning,
+        Check: func(cert *x509.Certificate) error {
+            if ext := findExtension(cert, oidExtensionKeyUsage); ext != nil && !ext.Critical {
+                return errors.New("key usage extension is not critical")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "key_usage_empty",
+        Description: "The key usage extension must have at least one bit set.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            if findExtension(cert, oidExtensionKeyUsage) != nil && cert.KeyUsage == 0 {
+                return errors.New("key usage extension without usage")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "key_usage_algorithm",
+        Description: "The key usages must be ones the public key algorithm can be used for.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            // SM2 keys sign, encipher and agree on keys
+            var algo string
+            var forbidden x509.KeyUsage
+            switch cert.PublicKey.(type) {
+                case *rsa.PublicKey:
+                    algo = "RSA"
+                    forbidden = x509.KeyUsageKeyAgreement | x509.KeyUsageEncipherOnly | x509.KeyUsageDecipherOnly
+                case *ecdsa.PublicKey:
+                    algo = "ECDSA"
+                    forbidden = x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment
+                case ed25519.PublicKey:
+                    algo = "Ed25519"
+                    forbidden = x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment |
+                        x509.KeyUsageKeyAgreement | x509.KeyUsageEncipherOnly | x509.KeyUsageDecipherOnly
+            }
+
+            if usage := cert.KeyUsage & forbidden; usage != 0 {
+                return fmt.Errorf("key usage %s not allowed for %s keys", keyUsageNames(usage), algo)
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "name_constraints_not_critical",
+        Description: "The name constraints extension should be critical.",
+        Source:      RFC5280,
+        Severity:    Warning,
+        Check: func(cert *x509.Certificate) error {
+            if ext := findExtension(cert, oidExtensionNameConstraints); ext != nil && !ext.Critical {
+                return errors.New("name constraints extension is not critical")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "path_len_without_ca",
+        Description: "Only CA certificates can have a path length constraint.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            if !cert.IsCA && (cert.MaxPathLen > 0 || cert.MaxPathLenZero) {
+                return errors.New("path length constraint in a certificate that is not a CA")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "unknown_critical_extension",
+        Description: "Critical extensions not understood make the certificate unusable.",
+        Source:      RFC5280,
+        Severity:    Warning,
+        Check: func(cert *x509.Certificate) error {
+            if len(cert.UnhandledCriticalExtensions) > 0 {
+                return fmt.Errorf("unknown critical extension %s", cert.UnhandledCriticalExtensions[0])
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "dns_name_syntax",
+        Description: "The DNS names must be valid host names, with a wildcard as the leftmost label only.",
+        Source:      RFC5280,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            for _, name := range cert.DNSNames {
+                if !validDNSName(name) {
+                    return fmt.Errorf("invalid DNS name %q", name)
+                }
+            }
+
+            return nil
+        },
+    },
+
+    // CA/Browser Forum Baseline Requirements
+    {
+        Name:        "rsa_key_size",
+        Description: "RSA keys must have a modulus of at least 2048 bits.",
+        Source:      CABF,
+        Severity:    Error,
+        Applies:     hasRSAKey,
+        Check: func(cert *x509.Certificate) error {
+            pub := cert.PublicKey.(*rsa.PublicKey)
+            if pub.N.BitLen() < 2048 {
+                return fmt.Errorf("RSA modulus of %d bits", pub.N.BitLen())
+            }
+
+            if pub.N.BitLen() % 8 != 0 {
+                return fmt.Errorf("RSA modulus of %d bits is not a multiple of 8", pub.N.BitLen())
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "rsa_public_exponent",
+        Description: "The RSA public exponent must be an odd number of at least 65537.",
+        Source:      CABF,
+        Severity:    Error,
+        Applies:     hasRSAKey,
+        Check: func(cert *x509.Certificate) error {
+            pub := cert.PublicKey.(*rsa.PublicKey)
+            if pub.E % 2 == 0 || pub.E < 65537 {
+                return fmt.Errorf("RSA public exponent %d", pub.E)
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "signature_algorithm_weak",
+        Description: "The certificate must not be signed with MD2, MD5 or SHA-1.",
+        Source:      CABF,
+        Severity:    Error,
+        Check: func(cert *x509.Certificate) error {
+            switch cert.SignatureAlgorithm {
+                case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA,
+                    x509.DSAWithSHA1, x509.ECDSAWithSHA1, x509.SM2WithSHA1:
+                    return fmt.Errorf("signature algorithm %s", cert.SignatureAlgorithm)
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "serial_number_entropy",
+        Description: "The serial number must have at least 64 random bits.",
+        Source:      CABF,
+        Severity:    Error,
+        Applies:     hasSerialNumber,
+        Check: func(cert *x509.Certificate) error {
+            if cert.SerialNumber.BitLen() < minSerialBits {
+                return fmt.Errorf("serial number of %d bits", cert.SerialNumber.BitLen())
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "tls_ecdsa_curve",
+        Description: "The ECDSA keys of TLS server certificates must be on P-256, P-384 or P-521.",
+        Source:      CABF,
+        Severity:    Error,
+        Applies:     isTLSServer,
+        Check: func(cert *x509.Certificate) error {
+            pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+            if !ok {
+                return nil
+            }
+
+            switch pub.Curve {
+                case elliptic.P256(), elliptic.P384(), elliptic.P521():
+                    return nil
+            }
+
+            return fmt.Errorf("ECDSA curve %s", pub.Curve.Params().Name)
+        },
+    },
+    {
+        Name:        "tls_validity_period",
+        Description: "TLS server certificates must not be valid for more than 398 days.",
+        Source:      CABF,
+        Severity:    Error,
+        Applies:     isTLSServer,
+        Check: func(cert *x509.Certificate) error {
+            validity := cert.NotAfter.Sub(cert.NotBefore) + time.Second
+            if validity > maxTLSValidity {
+                return fmt.Errorf("valid for %d days", validity / (24 * time.Hour))
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "tls_san_missing",
+        Description: "TLS server certificates must have DNS names or IP addresses.",
+        Source:      CABF,
+        Severity:    Error,
+        Applies:     isTLSServer,
+        Check: func(cert *x509.Certificate) error {
+            if len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 0 {
+                return errors.New("no DNS name or IP address")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "tls_common_name_not_in_san",
+        Description: "The common name of TLS server certificates must be one of their DNS names or IP addresses.",
+        Source:      CABF,
+        Severity:    Error,
+        Applies:     isTLSServer,
+        Check: func(cert *x509.Certificate) error {
+            cn := cert.Subject.CommonName
+            if cn == "" {
+                return nil
+            }
+
+            if ip := net.ParseIP(cn); ip != nil {
+                for _, addr := range cert.IPAddresses {
+                    if addr.Equal(ip) {
+                        return nil
+                    }
+                }
+            }
+
+            for _, name := range cert.DNSNames {
+                if strings.EqualFold(name, cn) {
+                    return nil
+                }
+            }
+
+            return fmt.Errorf("common name %q not in the subject alternative names", cn)
+        },
+    },
+
+    // GM/T 0015-2012 SM2 certificates
+    {
+        Name:        "sm2_signature_algorithm",
+        Description: "SM2 certificates must be signed with SM2 and SM3.",
+        Source:      GMT0015,
+        Severity:    Error,
+        Applies:     hasSM2Key,
+        Check: func(cert *x509.Certificate) error {
+            if cert.SignatureAlgorithm != x509.SM2WithSM3 {
+                return fmt.Errorf("signature algorithm %s", cert.SignatureAlgorithm)
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "sm2_key_usage_missing",
+        Description: "SM2 certificates must have a key usage extension.",
+        Source:      GMT0015,
+        Severity:    Error,
+        Applies:     hasSM2Key,
+        Check: func(cert *x509.Certificate) error {
+            if findExtension(cert, oidExtensionKeyUsage) == nil {
+                return errors.New("no key usage extension")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "sm2_key_identifiers",
+        Description: "SM2 certificates must have a subject key identifier, and an authority key identifier when not self-issued.",
+        Source:      GMT0015,
+        Severity:    Error,
+        Applies:     hasSM2Key,
+        Check: func(cert *x509.Certificate) error {
+            if len(cert.SubjectKeyId) == 0 {
+                return errors.New("no subject key identifier")
+            }
+
+            if notSelfIssued(cert) && len(cert.AuthorityKeyId) == 0 {
+                return errors.New("no authority key identifier")
+            }
+
+            return nil
+        },
+    },
+    {
+        Name:        "sm2_dual_certificate_key_usage",
+        Description: "SM2 end entities should have a signature certificate and an encryption certificate, not one certificate for both.",
+        Source:      GMT0015,
+        Severity:    Warning,
+        Applies:     func(cert *x509.Certificate) bool {
+            return hasSM2Key(cert) && !cert.IsCA
+        },
+        Check: func(cert *x509.Certificate) error {
+            signing := x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment
+            encryption := x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment | x509.KeyUsageKeyAgreement
+
+            if cert.KeyUsage&signing != 0 && cert.KeyUsage&encryption != 0 {
+                return errors.New("signature and encryption key usages in one certificate")
+            }
+
+            return nil
+        },
+    },
+}
+
+func hasSerialNumber(cert *x509.Certificate) bool {
+    return cert.SerialNumber != nil
+}
+
+func hasEmptySubject(cert *x509.Certificate) bool {
+    return len(cert.Subject.Names) == 0 && len(cert.Subject.ExtraNames) == 0 &&
+        (len(cert.RawSubject) == 0 || bytes.Equal(cert.RawSubject, emptySubject))
+}
+
+// emptySubject is an empty RDNSequence.
+var emptySubject = []byte{0x30, 0x00}
+
+func isCA(cert *x509.Certificate) bool {
+    return cert.BasicConstraintsValid && cert.IsCA
+}
+
+func notSelfIssued(cert *x509.Certificate) bool {
+    return !bytes.Equal(cert.RawIssuer, cert.RawSubject)
+}
+
+func isTLSServer(cert *x509.Certificate) bool {
+    if cert.IsCA {
+        return false
+    }
+
+    for _, usage := range cert.ExtKeyUsage {
+        if usage == x509.ExtKeyUsageServerAuth || usage == x509.ExtKeyUsageAny {
+            return true
+        }
+    }
+
+    return false
+}
+
+func hasRSAKey(cert *x509.Certificate) bool {
+    _, ok := cert.PublicKey.(*rsa.PublicKey)
+    return ok
+}
+
+func hasSM2Key(cert *x509.Certificate) bool {
+    _, ok := cert.PublicKey.(*sm2.PublicKey)
+    return ok
+}
+
+func findExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) *pkix.Extension {
+    for i := range cert.Extensions {
+        if cert.Extensions[i].Id.Equal(oid) {
+            return &cert.Extensions[i]
+        }
+    }
+
+    return nil
+}
+
+// serialLength returns the length of the DER content of serial.
+func serialLength(serial *big.Int) int {
+    b := serial.Bytes()
+    if len(b) == 0 || b[0]&0x80 != 0 {
+        return len(b) + 1
+    }
+
+    return len(b)
+}
+
+// validDNSName reports whether name is a host name, possibly with a
+// wildcard leftmost label.
+func validDNSName(name string) bool {
+    if name == "" || len(name) > 253 {
+        return false
+    }
+
+    labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+    for i, label := range labels {
+        if i == 0 && label == "*" && len(labels) > 2 {
+            continue
+        }
+
+        if label == "" || len(label) > 63 || label[0] == '-' || label[len(label) - 1] == '-' {
+            return false
+        }
+
+        for _, c := range label {
+            switch {
+                case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-':
+                default:
+                    return false
+            }
+        }
+    }
+
+    return true
+}
+
+var keyUsageBitNames = []string{
+    "digitalSignature",
+    "contentCommitment",
+    "keyEncipherment",
+    "dataEncipherment",
+    "keyAgreement",
+    "keyCertSign",
+    "cRLSign",
+    "encipherOnly",
+    "decipherOnly",
+}
+
+func keyUsageNames(usage x509.KeyUsage) string {
+    var names []string
+    for i, name := range keyUsageBitNames {
+        if usage&(1 << i) != 0 {
+            names = append(names, name)
+        }
+    }
+
+    return strings.Join(names, ", ")
+}
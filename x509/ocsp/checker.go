@@ -0,0 +1,51 @@
+package ocsp
+
+import (
+    "time"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// Checker is an x509.RevocationChecker answering from OCSP responses,
+// for use with x509.RevocationOptions.
+type Checker struct {
+    responses [][]byte
+}
+
+// NewChecker returns a checker for the DER-encoded OCSP responses, such
+// as stapled responses.
+func NewChecker(responses ...[]byte) *Checker {
+    return &Checker{
+        responses: responses,
+    }
+}
+
+// RevocationStatus implements x509.RevocationChecker. A response is only
+// used if it is signed by issuer or its delegated responder, is about
+// cert and is current at now.
+func (c *Checker) RevocationStatus(cert, issuer *x509.Certificate, now time.Time) (x509.RevocationStatus, error) {
+    for _, der := range c.responses {
+        resp, err := ParseResponseForCert(der, cert, issuer)
+        if err != nil {
+            continue
+        }
+
+        if resp.SerialNumber == nil || resp.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+            continue
+        }
+
+        if now.Before(resp.ThisUpdate) ||
+            !resp.NextUpdate.IsZero() && now.After(resp.NextUpdate) {
+            continue
+        }
+
+        switch resp.Status {
+            case Good:
+                return x509.RevocationStatusGood, nil
+            case Revoked:
+                return x509.RevocationStatusRevoked, nil
+        }
+    }
+
+    return x509.RevocationStatusUnknown, nil
+}
@@ -0,0 +1,824 @@
+package ocsp
+
+import (
+    "time"
+    "bytes"
+    "errors"
+    "strconv"
+    "math/big"
+    "crypto"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+var (
+    idPKIXOCSPBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+    idPKIXOCSPNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+)
+
+// ResponseStatus contains the result of an OCSP request. See
+// https://tools.ietf.org/html/rfc6960#section-2.3
+type ResponseStatus int
+
+const (
+    Success       ResponseStatus = 0
+    Malformed     ResponseStatus = 1
+    InternalError ResponseStatus = 2
+    TryLater      ResponseStatus = 3
+    // Status code four is unused in OCSP. See
+    // https://tools.ietf.org/html/rfc6960#section-4.2.1
+    SignatureRequired ResponseStatus = 5
+    Unauthorized      ResponseStatus = 6
+)
+
+func (r ResponseStatus) String() string {
+    switch r {
+        case Success:
+            return "success"
+        case Malformed:
+            return "malformed"
+        case InternalError:
+            return "internal error"
+        case TryLater:
+            return "try later"
+        case SignatureRequired:
+            return "signature required"
+        case Unauthorized:
+            return "unauthorized"
+        default:
+            return "unknown OCSP status: " + strconv.Itoa(int(r))
+    }
+}
+
+// ResponseError is an error that may be returned by ParseResponse to indicate
+// that the response itself is an error, not just that it's indicating that a
+// certificate is revoked, unknown, etc.
+type ResponseError struct {
+    Status ResponseStatus
+}
+
+func (r ResponseError) Error() string {
+    return "ocsp: error from server: " + r.Status.String()
+}
+
+// ParseError results from an invalid OCSP response.
+type ParseError string
+
+func (p ParseError) Error() string {
+    return string(p)
+}
+
+// These are internal structures that reflect the ASN.1 structure of an OCSP
+// request and response. See RFC 6960, section 4.
+
+type certID struct {
+    HashAlgorithm pkix.AlgorithmIdentifier
+    NameHash      []byte
+    IssuerKeyHash []byte
+    SerialNumber  *big.Int
+}
+
+type ocspRequest struct {
+    TBSRequest tbsRequest
+}
+
+type tbsRequest struct {
+    Version           int              `asn1:"explicit,tag:0,default:0,optional"`
+    RequestorName     pkix.RDNSequence `asn1:"explicit,tag:1,optional"`
+    RequestList       []request
+    RequestExtensions []pkix.Extension `asn1:"explicit,tag:2,optional"`
+}
+
+type request struct {
+    Cert certID
+}
+
+type responseASN1 struct {
+    Status   asn1.Enumerated
+    Response responseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type responseBytes struct {
+    ResponseType asn1.ObjectIdentifier
+    Response     []byte
+}
+
+type basicResponse struct {
+    TBSResponseData    responseData
+    SignatureAlgorithm pkix.AlgorithmIdentifier
+    Signature          asn1.BitString
+    Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type responseData struct {
+    Raw                asn1.RawContent
+    Version            int `asn1:"optional,default:0,explicit,tag:0"`
+    RawResponderID     asn1.RawValue
+    ProducedAt         time.Time `asn1:"generalized"`
+    Responses          []singleResponse
+    ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type singleResponse struct {
+    CertID           certID
+    Good             asn1.Flag        `asn1:"tag:0,optional"`
+    Revoked          revokedInfo      `asn1:"tag:1,optional"`
+    Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+    ThisUpdate       time.Time        `asn1:"generalized"`
+    NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+    SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type revokedInfo struct {
+    RevocationTime time.Time       `asn1:"generalized"`
+    Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// CertID hash algorithms
+var hashOIDs = map[x509.Hash]asn1.ObjectIdentifier{
+    x509.SHA1:            asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26},
+    x509.SHA256:          asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1},
+    x509.SHA384:          asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2},
+    x509.SHA512:          asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3},
+    x509.SM3:             asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401},
+    x509.GOST34112001:    asn1.ObjectIdentifier{1, 2, 643, 2, 2, 9},
+    x509.GOST34112012256: asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2},
+    x509.GOST34112012512: asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 3},
+}
+
+func getHashAlgorithmFromOID(target asn1.ObjectIdentifier) x509.Hash {
+    for hash, oid := range hashOIDs {
+        if oid.Equal(target) {
+            return hash
+        }
+    }
+
+    return x509.Hash(0)
+}
+
+func getOIDFromHashAlgorithm(target x509.Hash) asn1.ObjectIdentifier {
+    for hash, oid := range hashOIDs {
+        if hash == target {
+            return oid
+        }
+    }
+
+    return nil
+}
+
+// The status values that can be expressed in OCSP. See RFC 6960.
+// These are used for the Response.Status field.
+const (
+    // Good means that the certificate is valid.
+    Good = 0
+    // Revoked means that the certificate has been deliberately revoked.
+    Revoked = 1
+    // Unknown means that the OCSP responder doesn't know about the certificate.
+    Unknown = 2
+)
+
+// The enumerated reasons for revoking a certificate. See RFC 5280.
+const (
+    Unspecified          = 0
+    KeyCompromise        = 1
+    CACompromise         = 2
+    AffiliationChanged   = 3
+    Superseded           = 4
+    CessationOfOperation = 5
+    CertificateHold      = 6
+
+    RemoveFromCRL      = 8
+    PrivilegeWithdrawn = 9
+    AACompromise       = 10
+)
+
+// Request represents an OCSP request. See RFC 6960.
+type Request struct {
+    HashAlgorithm  x509.Hash
+    IssuerNameHash []byte
+    IssuerKeyHash  []byte
+    SerialNumber   *big.Int
+
+    // Nonce is the value of the nonce extension, RFC 8954.
+    Nonce []byte
+
+    // Extensions contains the raw requestExtensions.
+    Extensions []pkix.Extension
+}
+
+// Marshal marshals the OCSP request to ASN.1 DER encoded form.
+func (req *Request) Marshal() ([]byte, error) {
+    hashAlg := getOIDFromHashAlgorithm(req.HashAlgorithm)
+    if hashAlg == nil {
+        return nil, errors.New("ocsp: unknown hash algorithm")
+    }
+
+    var extensions []pkix.Extension
+    if len(req.Nonce) > 0 {
+        ext, err := marshalNonce(req.Nonce)
+        if err != nil {
+            return nil, err
+        }
+
+        extensions = append(extensions, ext)
+    }
+
+    return asn1.Marshal(ocspRequest{
+        tbsRequest{
+            Version: 0,
+            RequestList: []request{
+                {
+                    Cert: certID{
+                        pkix.AlgorithmIdentifier{
+                            Algorithm:  hashAlg,
+                            Parameters: asn1.RawValue{Tag: 5 /* ASN.1 NULL */},
+                        },
+                        req.IssuerNameHash,
+                        req.IssuerKeyHash,
+                        req.SerialNumber,
+                    },
+                },
+            },
+            RequestExtensions: extensions,
+        },
+    })
+}
+
+// Matches reports whether the request is for a certificate of issuer.
+func (req *Request) Matches(issuer *x509.Certificate) bool {
+    nameHash, keyHash, err := issuerHashes(issuer, req.HashAlgorithm)
+    if err != nil {
+        return false
+    }
+
+    return bytes.Equal(nameHash, req.IssuerNameHash) &&
+        bytes.Equal(keyHash, req.IssuerKeyHash)
+}
+
+// Response represents an OCSP response containing a single SingleResponse. See
+// RFC 6960.
+type Response struct {
+    Raw []byte
+
+    // Status is one of {Good, Revoked, Unknown}
+    Status                                        int
+    SerialNumber                                  *big.Int
+    ProducedAt, ThisUpdate, NextUpdate, RevokedAt time.Time
+    RevocationReason                              int
+
+    // Certificate is the delegated responder certificate, which is
+    // embedded in the response.
+    Certificate *x509.Certificate
+
+    // TBSResponseData contains the raw bytes of the signed response. If
+    // Certificate is nil then this can be used to verify Signature.
+    TBSResponseData    []byte
+    Signature          []byte
+    SignatureAlgorithm x509.SignatureAlgorithm
+
+    // IssuerHash is the hash used to compute the IssuerNameHash and
+    // IssuerKeyHash. If zero, the default is x509.SHA1.
+    IssuerHash x509.Hash
+
+    // RawResponderName optionally contains the DER-encoded subject of the
+    // responder certificate. Exactly one of RawResponderName and
+    // ResponderKeyHash is set.
+    RawResponderName []byte
+    // ResponderKeyHash optionally contains the SHA-1 hash of the
+    // responder's public key. Exactly one of RawResponderName and
+    // ResponderKeyHash is set. When creating a response, a non-empty
+    // ResponderKeyHash selects the byKey responder id.
+    ResponderKeyHash []byte
+
+    // Nonce is the value of the nonce extension, RFC 8954.
+    Nonce []byte
+
+    // Extensions contains raw X.509 extensions from the singleExtensions field
+    // of the OCSP response. When marshaling OCSP responses, the Extensions
+    // field is ignored, see ExtraExtensions.
+    Extensions []pkix.Extension
+
+    // ExtraExtensions contains extensions to be copied, raw, into any marshaled
+    // OCSP response (in the singleExtensions field).
+    ExtraExtensions []pkix.Extension
+}
+
+// These are pre-serialized error responses for the various non-success codes
+// defined by OCSP.
+var (
+    MalformedRequestErrorResponse = []byte{0x30, 0x03, 0x0A, 0x01, 0x01}
+    InternalErrorErrorResponse    = []byte{0x30, 0x03, 0x0A, 0x01, 0x02}
+    TryLaterErrorResponse         = []byte{0x30, 0x03, 0x0A, 0x01, 0x03}
+    SigRequredErrorResponse       = []byte{0x30, 0x03, 0x0A, 0x01, 0x05}
+    UnauthorizedErrorResponse     = []byte{0x30, 0x03, 0x0A, 0x01, 0x06}
+)
+
+// CheckSignatureFrom checks that the signature in resp is a valid signature
+// from issuer.
+func (resp *Response) CheckSignatureFrom(issuer *x509.Certificate) error {
+    return issuer.CheckSignature(resp.SignatureAlgorithm, resp.TBSResponseData, resp.Signature)
+}
+
+// SignedCertificateTimestamps returns the SCTs of the SCT list
+// extension of resp, RFC 6962 section 3.3. They are the SCTs of the
+// certificate entry, see x509.CTOptions.SCTs.
+func (resp *Response) SignedCertificateTimestamps() ([]*x509.SignedCertificateTimestamp, error) {
+    for _, ext := range resp.Extensions {
+        if ext.Id.Equal(x509.OIDExtensionOCSPSCTList) {
+            return x509.ParseSCTListExtension(ext.Value)
+        }
+    }
+
+    return nil, nil
+}
+
+// ParseRequest parses an OCSP request in DER form. It only supports
+// requests for a single certificate. Signed requests are not supported.
+func ParseRequest(der []byte) (*Request, error) {
+    var req ocspRequest
+    rest, err := asn1.Unmarshal(der, &req)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, ParseError("ocsp: trailing data in OCSP request")
+    }
+
+    if len(req.TBSRequest.RequestList) == 0 {
+        return nil, ParseError("ocsp: OCSP request contains no request body")
+    }
+
+    innerRequest := req.TBSRequest.RequestList[0]
+
+    hashFunc := getHashAlgorithmFromOID(innerRequest.Cert.HashAlgorithm.Algorithm)
+    if hashFunc == x509.Hash(0) {
+        return nil, ParseError("ocsp: OCSP request uses unknown hash function")
+    }
+
+    nonce, err := parseNonce(req.TBSRequest.RequestExtensions)
+    if err != nil {
+        return nil, err
+    }
+
+    return &Request{
+        HashAlgorithm:  hashFunc,
+        IssuerNameHash: innerRequest.Cert.NameHash,
+        IssuerKeyHash:  innerRequest.Cert.IssuerKeyHash,
+        SerialNumber:   innerRequest.Cert.SerialNumber,
+        Nonce:          nonce,
+        Extensions:     req.TBSRequest.RequestExtensions,
+    }, nil
+}
+
+// ParseResponse parses an OCSP response in DER form. The response must contain
+// only one certificate status. To parse the status of a specific certificate
+// from a response which may contain multiple statuses, use ParseResponseForCert
+// instead.
+//
+// If issuer is not nil, the response must be signed by issuer, or by a
+// delegated responder certificate embedded in the response, issued by
+// issuer and having the OCSP signing extended key usage.
+func ParseResponse(der []byte, issuer *x509.Certificate) (*Response, error) {
+    return ParseResponseForCert(der, nil, issuer)
+}
+
+// ParseResponseForCert acts identically to ParseResponse, except it supports
+// parsing responses that contain multiple statuses. If the response contains
+// multiple statuses and cert is not nil, then ParseResponseForCert will return
+// the first status which contains a matching serial, otherwise it will return an
+// error. If cert is nil, then the first status in the response will be returned.
+func ParseResponseForCert(der []byte, cert, issuer *x509.Certificate) (*Response, error) {
+    var resp responseASN1
+    rest, err := asn1.Unmarshal(der, &resp)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, ParseError("ocsp: trailing data in OCSP response")
+    }
+
+    if status := ResponseStatus(resp.Status); status != Success {
+        return nil, ResponseError{status}
+    }
+
+    if !resp.Response.ResponseType.Equal(idPKIXOCSPBasic) {
+        return nil, ParseError("ocsp: bad OCSP response type")
+    }
+
+    var basicResp basicResponse
+    rest, err = asn1.Unmarshal(resp.Response.Response, &basicResp)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(rest) > 0 {
+        return nil, ParseError("ocsp: trailing data in OCSP response")
+    }
+
+    if n := len(basicResp.TBSResponseData.Responses); n == 0 || cert == nil && n > 1 {
+        return nil, ParseError("ocsp: OCSP response contains bad number of responses")
+    }
+
+    var singleResp singleResponse
+    if cert == nil {
+        singleResp = basicResp.TBSResponseData.Responses[0]
+    } else {
+        match := false
+        for _, resp := range basicResp.TBSResponseData.Responses {
+            if cert.SerialNumber.Cmp(resp.CertID.SerialNumber) == 0 {
+                singleResp = resp
+                match = true
+                break
+            }
+        }
+
+        if !match {
+            return nil, ParseError("ocsp: no response matching the supplied certificate")
+        }
+    }
+
+    ret := &Response{
+        Raw:                der,
+        TBSResponseData:    basicResp.TBSResponseData.Raw,
+        Signature:          basicResp.Signature.RightAlign(),
+        SignatureAlgorithm: x509.SignatureAlgorithmFromAI(basicResp.SignatureAlgorithm),
+        Extensions:         singleResp.SingleExtensions,
+        SerialNumber:       singleResp.CertID.SerialNumber,
+        ProducedAt:         basicResp.TBSResponseData.ProducedAt,
+        ThisUpdate:         singleResp.ThisUpdate,
+        NextUpdate:         singleResp.NextUpdate,
+    }
+
+    rawResponderID := basicResp.TBSResponseData.RawResponderID
+    switch rawResponderID.Tag {
+        case 1: // Name
+            var rdn pkix.RDNSequence
+            if rest, err := asn1.Unmarshal(rawResponderID.Bytes, &rdn); err != nil || len(rest) != 0 {
+                return nil, ParseError("ocsp: invalid responder name")
+            }
+            ret.RawResponderName = rawResponderID.Bytes
+        case 2: // KeyHash
+            if rest, err := asn1.Unmarshal(rawResponderID.Bytes, &ret.ResponderKeyHash); err != nil || len(rest) != 0 {
+                return nil, ParseError("ocsp: invalid responder key hash")
+            }
+        default:
+            return nil, ParseError("ocsp: invalid responder id tag")
+    }
+
+    ret.Nonce, err = parseNonce(basicResp.TBSResponseData.ResponseExtensions)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(basicResp.Certificates) > 0 {
+        // Responders should only send a single certificate that connects
+        // the responder's certificate to the original issuer, all but the
+        // first one are ignored.
+        ret.Certificate, err = x509.ParseCertificate(basicResp.Certificates[0].FullBytes)
+        if err != nil {
+            return nil, err
+        }
+
+        if err := ret.CheckSignatureFrom(ret.Certificate); err != nil {
+            return nil, ParseError("ocsp: bad signature on embedded certificate: " + err.Error())
+        }
+
+        if issuer != nil {
+            if err := checkResponder(ret.Certificate, issuer); err != nil {
+                return nil, err
+            }
+        }
+    } else if issuer != nil {
+        if err := ret.CheckSignatureFrom(issuer); err != nil {
+            return nil, ParseError("ocsp: bad OCSP signature: " + err.Error())
+        }
+    }
+
+    for _, ext := range singleResp.SingleExtensions {
+        if ext.Critical {
+            return nil, ParseError("ocsp: unsupported critical extension")
+        }
+    }
+
+    ret.IssuerHash = getHashAlgorithmFromOID(singleResp.CertID.HashAlgorithm.Algorithm)
+    if ret.IssuerHash == 0 {
+        return nil, ParseError("ocsp: unsupported issuer hash algorithm")
+    }
+
+    if issuer != nil {
+        nameHash, keyHash, err := issuerHashes(issuer, ret.IssuerHash)
+        if err != nil {
+            return nil, err
+        }
+
+        if !bytes.Equal(nameHash, singleResp.CertID.NameHash) ||
+            !bytes.Equal(keyHash, singleResp.CertID.IssuerKeyHash) {
+            return nil, ParseError("ocsp: response is for another issuer")
+        }
+    }
+
+    switch {
+        case bool(singleResp.Good):
+            ret.Status = Good
+        case bool(singleResp.Unknown):
+            ret.Status = Unknown
+        default:
+            ret.Status = Revoked
+            ret.RevokedAt = singleResp.Revoked.RevocationTime
+            ret.RevocationReason = int(singleResp.Revoked.Reason)
+    }
+
+    return ret, nil
+}
+
+// checkResponder checks that responder is the issuer itself, or a
+// delegated responder issued by issuer, RFC 6960 section 4.2.2.2.
+func checkResponder(responder, issuer *x509.Certificate) error {
+    if responder.Equal(issuer) {
+        return nil
+    }
+
+    if err := issuer.CheckSignature(responder.SignatureAlgorithm, responder.RawTBSCertificate, responder.Signature); err != nil {
+        return ParseError("ocsp: bad OCSP signature: " + err.Error())
+    }
+
+    for _, usage := range responder.ExtKeyUsage {
+        if usage == x509.ExtKeyUsageOCSPSigning {
+            return nil
+        }
+    }
+
+    return ParseError("ocsp: responder certificate is not authorized to sign OCSP responses")
+}
+
+// RequestOptions contains options for constructing OCSP requests.
+type RequestOptions struct {
+    // Hash contains the hash function that should be used when
+    // constructing the OCSP request. If zero, SHA-1 will be used.
+    Hash x509.Hash
+
+    // Nonce is sent in the nonce extension if not empty.
+    Nonce []byte
+}
+
+func (opts *RequestOptions) hash() x509.Hash {
+    if opts == nil || opts.Hash == 0 {
+        // SHA-1 is nearly universally used in OCSP.
+        return x509.SHA1
+    }
+
+    return opts.Hash
+}
+
+func (opts *RequestOptions) nonce() []byte {
+    if opts == nil {
+        return nil
+    }
+
+    return opts.Nonce
+}
+
+// CreateRequest returns a DER-encoded, OCSP request for the status of cert. If
+// opts is nil then sensible defaults are used.
+func CreateRequest(cert, issuer *x509.Certificate, opts *RequestOptions) ([]byte, error) {
+    hashFunc := opts.hash()
+
+    issuerNameHash, issuerKeyHash, err := issuerHashes(issuer, hashFunc)
+    if err != nil {
+        return nil, err
+    }
+
+    req := &Request{
+        HashAlgorithm:  hashFunc,
+        IssuerNameHash: issuerNameHash,
+        IssuerKeyHash:  issuerKeyHash,
+        SerialNumber:   cert.SerialNumber,
+        Nonce:          opts.nonce(),
+    }
+
+    return req.Marshal()
+}
+
+// CreateResponse returns a DER-encoded OCSP response with the specified contents.
+// The fields in the response are populated as follows:
+//
+// The responder cert is used to populate the responder's name field, or the
+// responder's key hash when template.ResponderKeyHash is set. A delegated
+// responder certificate is provided alongside the signature by setting
+// template.Certificate.
+//
+// The issuer cert is used to populate the IssuerNameHash and IssuerKeyHash fields.
+//
+// The template is used to populate the SerialNumber, Status, RevokedAt,
+// RevocationReason, ThisUpdate, NextUpdate and Nonce fields.
+//
+// If template.IssuerHash is not set, SHA1 will be used. If template.ProducedAt
+// is not set, the current time is used, to the nearest minute.
+func CreateResponse(issuer, responderCert *x509.Certificate, template Response, priv crypto.Signer) ([]byte, error) {
+    if template.IssuerHash == 0 {
+        template.IssuerHash = x509.SHA1
+    }
+
+    hashOID := getOIDFromHashAlgorithm(template.IssuerHash)
+    if hashOID == nil {
+        return nil, errors.New("ocsp: unsupported issuer hash algorithm")
+    }
+
+    issuerNameHash, issuerKeyHash, err := issuerHashes(issuer, template.IssuerHash)
+    if err != nil {
+        return nil, err
+    }
+
+    innerResponse := singleResponse{
+        CertID: certID{
+            HashAlgorithm: pkix.AlgorithmIdentifier{
+                Algorithm:  hashOID,
+                Parameters: asn1.RawValue{Tag: 5 /* ASN.1 NULL */},
+            },
+            NameHash:      issuerNameHash,
+            IssuerKeyHash: issuerKeyHash,
+            SerialNumber:  template.SerialNumber,
+        },
+        ThisUpdate:       template.ThisUpdate.UTC(),
+        NextUpdate:       template.NextUpdate.UTC(),
+        SingleExtensions: template.ExtraExtensions,
+    }
+
+    switch template.Status {
+        case Good:
+            innerResponse.Good = true
+        case Unknown:
+            innerResponse.Unknown = true
+        case Revoked:
+            innerResponse.Revoked = revokedInfo{
+                RevocationTime: template.RevokedAt.UTC(),
+                Reason:         asn1.Enumerated(template.RevocationReason),
+            }
+    }
+
+    var rawResponderID asn1.RawValue
+    if len(template.ResponderKeyHash) > 0 {
+        keyHash, err := asn1.Marshal(template.ResponderKeyHash)
+        if err != nil {
+            return nil, err
+        }
+
+        rawResponderID = asn1.RawValue{
+            Class:      2, // context-specific
+            Tag:        2, // KeyHash (explicit tag)
+            IsCompound: true,
+            Bytes:      keyHash,
+        }
+    } else {
+        rawResponderID = asn1.RawValue{
+            Class:      2, // context-specific
+            Tag:        1, // Name (explicit tag)
+            IsCompound: true,
+            Bytes:      responderCert.RawSubject,
+        }
+    }
+
+    producedAt := template.ProducedAt
+    if producedAt.IsZero() {
+        producedAt = time.Now().Truncate(time.Minute)
+    }
+
+    tbsResponseData := responseData{
+        Version:        0,
+        RawResponderID: rawResponderID,
+        ProducedAt:     producedAt.UTC(),
+        Responses:      []singleResponse{innerResponse},
+    }
+
+    if len(template.Nonce) > 0 {
+        ext, err := marshalNonce(template.Nonce)
+        if err != nil {
+            return nil, err
+        }
+
+        tbsResponseData.ResponseExtensions = []pkix.Extension{ext}
+    }
+
+    tbsResponseDataDER, err := asn1.Marshal(tbsResponseData)
+    if err != nil {
+        return nil, err
+    }
+
+    signatureAlgorithm, signature, err := x509.CreateSignature(rand.Reader, priv, template.SignatureAlgorithm, tbsResponseDataDER)
+    if err != nil {
+        return nil, err
+    }
+
+    response := basicResponse{
+        TBSResponseData:    tbsResponseData,
+        SignatureAlgorithm: signatureAlgorithm,
+        Signature: asn1.BitString{
+            Bytes:     signature,
+            BitLength: 8 * len(signature),
+        },
+    }
+
+    if template.Certificate != nil {
+        response.Certificates = []asn1.RawValue{
+            {FullBytes: template.Certificate.Raw},
+        }
+    }
+
+    responseDER, err := asn1.Marshal(response)
+    if err != nil {
+        return nil, err
+    }
+
+    return asn1.Marshal(responseASN1{
+        Status: asn1.Enumerated(Success),
+        Response: responseBytes{
+            ResponseType: idPKIXOCSPBasic,
+            Response:     responseDER,
+        },
+    })
+}
+
+// ResponderKeyHash returns the SHA-1 hash of the public key of cert, used
+// as the byKey responder id.
+func ResponderKeyHash(cert *x509.Certificate) ([]byte, error) {
+    publicKey, err := subjectPublicKey(cert)
+    if err != nil {
+        return nil, err
+    }
+
+    h := sha1.Sum(publicKey)
+    return h[:], nil
+}
+
+// issuerHashes returns the hashes of the name and the public key of issuer.
+func issuerHashes(issuer *x509.Certificate, hashFunc x509.Hash) (nameHash, keyHash []byte, err error) {
+    if _, ok := hashOIDs[hashFunc]; !ok || !hashFunc.Available() {
+        return nil, nil, x509.ErrUnsupportedAlgorithm
+    }
+
+    publicKey, err := subjectPublicKey(issuer)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    h := hashFunc.New()
+    h.Write(publicKey)
+    keyHash = h.Sum(nil)
+
+    h.Reset()
+    h.Write(issuer.RawSubject)
+    nameHash = h.Sum(nil)
+
+    return nameHash, keyHash, nil
+}
+
+// subjectPublicKey returns the subjectPublicKey BIT STRING of cert.
+func subjectPublicKey(cert *x509.Certificate) ([]byte, error) {
+    var publicKeyInfo struct {
+        Algorithm pkix.AlgorithmIdentifier
+        PublicKey asn1.BitString
+    }
+
+    if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+        return nil, err
+    }
+
+    return publicKeyInfo.PublicKey.RightAlign(), nil
+}
+
+// marshalNonce returns the nonce extension, RFC 8954.
+func marshalNonce(nonce []byte) (pkix.Extension, error) {
+    value, err := asn1.Marshal(nonce)
+    if err != nil {
+        return pkix.Extension{}, err
+    }
+
+    return pkix.Extension{
+        Id:    idPKIXOCSPNonce,
+        Value: value,
+    }, nil
+}
+
+// parseNonce returns the value of the nonce extension, if any.
+func parseNonce(extensions []pkix.Extension) ([]byte, error) {
+    for _, ext := range extensions {
+        if !ext.Id.Equal(idPKIXOCSPNonce) {
+            continue
+        }
+
+        var nonce []byte
+        if rest, err := asn1.Unmarshal(ext.Value, &nonce); err != nil || len(rest) != 0 {
+            return nil, ParseError("ocsp: invalid nonce extension")
+        }
+
+        return nonce, nil
+    }
+
+    return nil, nil
+}
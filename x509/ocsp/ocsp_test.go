@@ -0,0 +1,405 @@
+package ocsp
+
+import (
+    "io"
+    "time"
+    "bytes"
+    "testing"
+    "math/big"
+    "net/url"
+    "net/http"
+    "net/http/httptest"
+    "crypto"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+    "encoding/base64"
+
+    "github.com/deatil/go-cryptobin/x509"
+    "github.com/deatil/go-cryptobin/gm/sm2"
+    "github.com/deatil/go-cryptobin/pubkey/gost"
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+type testPKI struct {
+    ca, responder, leaf *x509.Certificate
+    caKey, responderKey crypto.Signer
+}
+
+func newTestPKI(t *testing.T, newKey func() crypto.Signer, eku []x509.ExtKeyUsage) *testPKI {
+    now := time.Now()
+
+    caKey := newKey()
+    caTemplate := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: "Test CA"},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.Add(time.Hour),
+        KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+
+    ca := createCert(t, caTemplate, caTemplate, caKey.Public(), caKey)
+
+    responderKey := newKey()
+    responder := createCert(t, &x509.Certificate{
+        SerialNumber: big.NewInt(2),
+        Subject:      pkix.Name{CommonName: "Test OCSP Responder"},
+        NotBefore:    now.Add(-time.Hour),
+        NotAfter:     now.Add(time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  eku,
+    }, ca, responderKey.Public(), caKey)
+
+    leafKey := newKey()
+    leaf := createCert(t, &x509.Certificate{
+        SerialNumber: big.NewInt(3),
+        Subject:      pkix.Name{CommonName: "test.example.com"},
+        NotBefore:    now.Add(-time.Hour),
+        NotAfter:     now.Add(time.Hour),
+    }, ca, leafKey.Public(), caKey)
+
+    return &testPKI{
+        ca:           ca,
+        responder:    responder,
+        leaf:         leaf,
+        caKey:        caKey,
+        responderKey: responderKey,
+    }
+}
+
+func createCert(t *testing.T, template, parent *x509.Certificate, pub any, priv crypto.Signer) *x509.Certificate {
+    der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return cert
+}
+
+var testKeys = []struct {
+    name string
+    hash x509.Hash
+    key  func() crypto.Signer
+}{
+    {
+        name: "ECDSA",
+        hash: x509.SHA256,
+        key: func() crypto.Signer {
+            k, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+            return k
+        },
+    },
+    {
+        name: "SM2",
+        hash: x509.SM3,
+        key: func() crypto.Signer {
+            k, _ := sm2.GenerateKey(rand.Reader)
+            return k
+        },
+    },
+    {
+        name: "GOST",
+        hash: x509.GOST34112012256,
+        key: func() crypto.Signer {
+            k, _ := gost.GenerateKey(rand.Reader, gost.CurveIdGostR34102001CryptoProAParamSet())
+            return k
+        },
+    },
+}
+
+func Test_RequestResponse(t *testing.T) {
+    for _, td := range testKeys {
+        t.Run(td.name, func(t *testing.T) {
+            assertEqual := cryptobin_test.AssertEqualT(t)
+            assertError := cryptobin_test.AssertErrorT(t)
+            assertBool := cryptobin_test.AssertBoolT(t)
+
+            pki := newTestPKI(t, td.key, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+
+            nonce := []byte("0123456789abcdef")
+
+            reqDer, err := CreateRequest(pki.leaf, pki.ca, &RequestOptions{
+                Hash:  td.hash,
+                Nonce: nonce,
+            })
+            assertError(err, "CreateRequest")
+
+            req, err := ParseRequest(reqDer)
+            assertError(err, "ParseRequest")
+            assertEqual(req.HashAlgorithm, td.hash, "HashAlgorithm")
+            assertEqual(req.SerialNumber, pki.leaf.SerialNumber, "SerialNumber")
+            assertEqual(req.Nonce, nonce, "Nonce")
+            assertBool(req.Matches(pki.ca), "Matches")
+            assertBool(!req.Matches(pki.responder), "Matches other issuer")
+
+            now := time.Now().Truncate(time.Second)
+
+            // delegated responder
+            respDer, err := CreateResponse(pki.ca, pki.responder, Response{
+                Status:           Revoked,
+                SerialNumber:     req.SerialNumber,
+                IssuerHash:       req.HashAlgorithm,
+                ThisUpdate:       now,
+                NextUpdate:       now.Add(time.Hour),
+                RevokedAt:        now.Add(-time.Hour),
+                RevocationReason: KeyCompromise,
+                Nonce:            req.Nonce,
+                Certificate:      pki.responder,
+            }, pki.responderKey)
+            assertError(err, "CreateResponse")
+
+            resp, err := ParseResponseForCert(respDer, pki.leaf, pki.ca)
+            assertError(err, "ParseResponseForCert")
+            assertEqual(resp.Status, Revoked, "Status")
+            assertEqual(resp.RevocationReason, KeyCompromise, "RevocationReason")
+            assertBool(resp.RevokedAt.Equal(now.Add(-time.Hour)), "RevokedAt")
+            assertEqual(resp.Nonce, nonce, "Nonce")
+            assertEqual(resp.IssuerHash, td.hash, "IssuerHash")
+            assertEqual(resp.RawResponderName, pki.responder.RawSubject, "RawResponderName")
+            assertBool(resp.Certificate.Equal(pki.responder), "Certificate")
+
+            // signed by the issuer itself, responder id by key
+            keyHash, err := ResponderKeyHash(pki.ca)
+            assertError(err, "ResponderKeyHash")
+
+            respDer, err = CreateResponse(pki.ca, pki.ca, Response{
+                Status:           Good,
+                SerialNumber:     req.SerialNumber,
+                IssuerHash:       req.HashAlgorithm,
+                ThisUpdate:       now,
+                ResponderKeyHash: keyHash,
+            }, pki.caKey)
+            assertError(err, "CreateResponse")
+
+            resp, err = ParseResponse(respDer, pki.ca)
+            assertError(err, "ParseResponse")
+            assertEqual(resp.Status, Good, "Status")
+            assertEqual(resp.ResponderKeyHash, keyHash, "ResponderKeyHash")
+            assertBool(resp.Certificate == nil, "Certificate")
+
+            // signed by an unknown key
+            _, err = ParseResponse(respDer, pki.responder)
+            assertBool(err != nil, "ParseResponse wrong issuer")
+        })
+    }
+}
+
+func Test_DelegatedResponderWithoutEKU(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    pki := newTestPKI(t, testKeys[0].key, nil)
+
+    respDer, err := CreateResponse(pki.ca, pki.responder, Response{
+        Status:       Good,
+        SerialNumber: pki.leaf.SerialNumber,
+        ThisUpdate:   time.Now(),
+        Certificate:  pki.responder,
+    }, pki.responderKey)
+    assertError(err, "CreateResponse")
+
+    _, err = ParseResponse(respDer, pki.ca)
+    assertBool(err != nil, "ParseResponse should fail")
+
+    // the signature itself is valid
+    _, err = ParseResponse(respDer, nil)
+    assertError(err, "ParseResponse")
+}
+
+func Test_ErrorResponse(t *testing.T) {
+    _, err := ParseResponse(UnauthorizedErrorResponse, nil)
+
+    respErr, ok := err.(ResponseError)
+    if !ok || respErr.Status != Unauthorized {
+        t.Errorf("got %v, want unauthorized", err)
+    }
+}
+
+func Test_Responder(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+
+    pki := newTestPKI(t, testKeys[1].key, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+
+    responder := &Responder{
+        Issuer:      pki.ca,
+        Certificate: pki.responder,
+        Signer:      pki.responderKey,
+        Lookup: func(req *Request) (Response, error) {
+            if req.SerialNumber.Cmp(pki.leaf.SerialNumber) != 0 {
+                return Response{}, ErrNotFound
+            }
+
+            return Response{
+                Status:     Good,
+                NextUpdate: time.Now().Add(time.Hour),
+            }, nil
+        },
+    }
+
+    server := httptest.NewServer(responder)
+    defer server.Close()
+
+    nonce := []byte("nonce")
+
+    reqDer, err := CreateRequest(pki.leaf, pki.ca, &RequestOptions{
+        Hash:  x509.SM3,
+        Nonce: nonce,
+    })
+    assertError(err, "CreateRequest")
+
+    // POST
+    httpResp, err := http.Post(server.URL, "application/ocsp-request", bytes.NewReader(reqDer))
+    assertError(err, "Post")
+
+    respDer, err := io.ReadAll(httpResp.Body)
+    httpResp.Body.Close()
+    assertError(err, "ReadAll")
+
+    resp, err := ParseResponseForCert(respDer, pki.leaf, pki.ca)
+    assertError(err, "ParseResponseForCert")
+    assertEqual(resp.Status, Good, "Status")
+    assertEqual(resp.Nonce, nonce, "Nonce")
+
+    // GET
+    httpResp, err = http.Get(server.URL + "/" + url.PathEscape(base64.StdEncoding.EncodeToString(reqDer)))
+    assertError(err, "Get")
+
+    respDer, err = io.ReadAll(httpResp.Body)
+    httpResp.Body.Close()
+    assertError(err, "ReadAll")
+
+    resp, err = ParseResponse(respDer, pki.ca)
+    assertError(err, "ParseResponse")
+    assertEqual(resp.Status, Good, "Status")
+
+    // unknown certificate
+    reqDer, err = CreateRequest(pki.responder, pki.ca, nil)
+    assertError(err, "CreateRequest")
+
+    httpResp, err = http.Post(server.URL, "application/ocsp-request", bytes.NewReader(reqDer))
+    assertError(err, "Post")
+
+    respDer, err = io.ReadAll(httpResp.Body)
+    httpResp.Body.Close()
+    assertError(err, "ReadAll")
+    assertEqual(respDer, UnauthorizedErrorResponse, "unauthorized")
+
+    // malformed request
+    httpResp, err = http.Post(server.URL, "application/ocsp-request", bytes.NewReader([]byte("bad")))
+    assertError(err, "Post")
+
+    respDer, err = io.ReadAll(httpResp.Body)
+    httpResp.Body.Close()
+    assertError(err, "ReadAll")
+    assertEqual(respDer, MalformedRequestErrorResponse, "malformed")
+}
+
+func Test_Checker(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    pki := newTestPKI(t, testKeys[1].key, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+
+    now := time.Now()
+
+    newResponse := func(status int) []byte {
+        der, err := CreateResponse(pki.ca, pki.responder, Response{
+            Status:       status,
+            SerialNumber: pki.leaf.SerialNumber,
+            IssuerHash:   x509.SM3,
+            ThisUpdate:   now.Add(-time.Minute),
+            NextUpdate:   now.Add(time.Hour),
+            RevokedAt:    now.Add(-time.Hour),
+            Certificate:  pki.responder,
+        }, pki.responderKey)
+        assertError(err, "CreateResponse")
+
+        return der
+    }
+
+    roots := x509.NewCertPool()
+    roots.AddCert(pki.ca)
+
+    verify := func(checker x509.RevocationChecker) error {
+        _, err := pki.leaf.Verify(x509.VerifyOptions{
+            Roots:      roots,
+            KeyUsages:  []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+            Revocation: &x509.RevocationOptions{
+                Checker:       checker,
+                RequireStatus: true,
+            },
+        })
+        return err
+    }
+
+    good := newResponse(Good)
+    revoked := newResponse(Revoked)
+
+    status, err := NewChecker(good).RevocationStatus(pki.leaf, pki.ca, now)
+    assertError(err, "RevocationStatus")
+    assertEqual(status, x509.RevocationStatusGood, "good")
+
+    status, err = NewChecker(good).RevocationStatus(pki.leaf, pki.ca, now.Add(2 * time.Hour))
+    assertError(err, "RevocationStatus")
+    assertEqual(status, x509.RevocationStatusUnknown, "stale")
+
+    status, err = NewChecker(good).RevocationStatus(pki.responder, pki.ca, now)
+    assertError(err, "RevocationStatus")
+    assertEqual(status, x509.RevocationStatusUnknown, "other certificate")
+
+    assertError(verify(NewChecker(good)), "Verify good")
+    assertBool(verify(NewChecker(revoked)) != nil, "Verify revoked")
+    assertBool(verify(NewChecker()) != nil, "Verify no response")
+}
+
+func Test_SignedCertificateTimestamps(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    pki := newTestPKI(t, testKeys[0].key, []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+
+    sct := &x509.SignedCertificateTimestamp{
+        LogID:              [32]byte{1, 2, 3},
+        Timestamp:          time.Now().Truncate(time.Millisecond),
+        HashAlgorithm:      4,
+        SignatureAlgorithm: 3,
+        Signature:          []byte{4, 5, 6},
+    }
+
+    list, err := x509.MarshalSCTList([]*x509.SignedCertificateTimestamp{sct})
+    assertError(err, "MarshalSCTList")
+
+    value, err := asn1.Marshal(list)
+    assertError(err, "Marshal")
+
+    respDer, err := CreateResponse(pki.ca, pki.responder, Response{
+        Status:          Good,
+        SerialNumber:    pki.leaf.SerialNumber,
+        ThisUpdate:      time.Now(),
+        Certificate:     pki.responder,
+        ExtraExtensions: []pkix.Extension{
+            {Id: x509.OIDExtensionOCSPSCTList, Value: value},
+        },
+    }, pki.responderKey)
+    assertError(err, "CreateResponse")
+
+    resp, err := ParseResponse(respDer, pki.ca)
+    assertError(err, "ParseResponse")
+
+    scts, err := resp.SignedCertificateTimestamps()
+    assertError(err, "SignedCertificateTimestamps")
+    assertEqual(len(scts), 1, "SignedCertificateTimestamps")
+    assertEqual(scts[0].LogID, sct.LogID, "LogID")
+    assertEqual(scts[0].Signature, sct.Signature, "Signature")
+}
@@ -0,0 +1,151 @@
+package ocsp
+
+import (
+    "io"
+    "time"
+    "errors"
+    "strings"
+    "net/url"
+    "net/http"
+    "crypto"
+    "encoding/base64"
+
+    "github.com/deatil/go-cryptobin/x509"
+)
+
+// ErrNotFound is returned by a Responder's Lookup for certificates it
+// is not authoritative for, the responder answers unauthorized.
+var ErrNotFound = errors.New("ocsp: certificate not found")
+
+// Responder is an http.Handler answering OCSP requests for the
+// certificates of one issuer, sent by POST or by GET, RFC 6960
+// Appendix A.
+type Responder struct {
+    // Issuer is the CA certificate the requests are for.
+    Issuer *x509.Certificate
+
+    // Certificate is the responder certificate. If it is not Issuer,
+    // it is a delegated responder certificate and is embedded in the
+    // responses. If nil, Issuer signs the responses.
+    Certificate *x509.Certificate
+
+    // Signer is the private key of Certificate.
+    Signer crypto.Signer
+
+    // SignatureAlgorithm is used to sign the responses, if zero the
+    // default algorithm of the key is used.
+    SignatureAlgorithm x509.SignatureAlgorithm
+
+    // Lookup returns the status of the certificate of req, the
+    // Status, RevokedAt, RevocationReason, ThisUpdate and NextUpdate
+    // fields are used.
+    Lookup func(req *Request) (Response, error)
+
+    // Now returns the current time, time.Now if nil.
+    Now func() time.Time
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Responder) ServeHTTP(w http.ResponseWriter, hreq *http.Request) {
+    var der []byte
+    var err error
+
+    switch hreq.Method {
+        case http.MethodGet:
+            der, err = r.readGet(hreq)
+        case http.MethodPost:
+            if hreq.Header.Get("Content-Type") != "application/ocsp-request" {
+                w.WriteHeader(http.StatusUnsupportedMediaType)
+                return
+            }
+
+            der, err = io.ReadAll(io.LimitReader(hreq.Body, 1 << 16))
+        default:
+            w.WriteHeader(http.StatusMethodNotAllowed)
+            return
+    }
+
+    if err != nil {
+        r.write(w, MalformedRequestErrorResponse)
+        return
+    }
+
+    req, err := ParseRequest(der)
+    if err != nil {
+        r.write(w, MalformedRequestErrorResponse)
+        return
+    }
+
+    r.write(w, r.Respond(req))
+}
+
+// Respond returns the DER-encoded response to req.
+func (r *Responder) Respond(req *Request) []byte {
+    if !req.Matches(r.Issuer) {
+        return UnauthorizedErrorResponse
+    }
+
+    template, err := r.Lookup(req)
+    if err != nil {
+        if errors.Is(err, ErrNotFound) {
+            return UnauthorizedErrorResponse
+        }
+
+        return InternalErrorErrorResponse
+    }
+
+    responderCert := r.Certificate
+    if responderCert == nil {
+        responderCert = r.Issuer
+    }
+
+    now := time.Now
+    if r.Now != nil {
+        now = r.Now
+    }
+
+    template.SerialNumber = req.SerialNumber
+    template.IssuerHash = req.HashAlgorithm
+    template.Nonce = req.Nonce
+    template.SignatureAlgorithm = r.SignatureAlgorithm
+    template.ProducedAt = now()
+
+    if template.ThisUpdate.IsZero() {
+        template.ThisUpdate = template.ProducedAt
+    }
+
+    template.Certificate = nil
+    if !responderCert.Equal(r.Issuer) {
+        template.Certificate = responderCert
+    }
+
+    resp, err := CreateResponse(r.Issuer, responderCert, template, r.Signer)
+    if err != nil {
+        return InternalErrorErrorResponse
+    }
+
+    return resp
+}
+
+// readGet returns the request of the last path segment of a GET request.
+func (r *Responder) readGet(hreq *http.Request) ([]byte, error) {
+    path := hreq.URL.EscapedPath()
+
+    i := strings.LastIndex(path, "/")
+    if i >= 0 {
+        path = path[i+1:]
+    }
+
+    path, err := url.PathUnescape(path)
+    if err != nil {
+        return nil, err
+    }
+
+    return base64.StdEncoding.DecodeString(path)
+}
+
+func (r *Responder) write(w http.ResponseWriter, resp []byte) {
+    w.Header().Set("Content-Type", "application/ocsp-response")
+    w.WriteHeader(http.StatusOK)
+    w.Write(resp)
+}
@@ -0,0 +1,500 @@
+package x509
+
+import (
+    "fmt"
+    "time"
+    "bytes"
+    "errors"
+    "strings"
+    "encoding/asn1"
+)
+
+const (
+    // defaultMaxCandidates is the number of candidate paths BuildPaths
+    // validates when PathOptions.MaxCandidates is zero.
+    defaultMaxCandidates = 32
+
+    // maxPathDepth is the longest path BuildPaths builds.
+    maxPathDepth = 16
+
+    // maxPathSignatureChecks is the number of issuers BuildPaths checks
+    // the signature of while building, as maxChainSignatureChecks of
+    // Verify, so that the issuers sharing a name can not make the
+    // building exponential.
+    maxPathSignatureChecks = 100
+
+    // maxPathFailures is the number of failures a PathReport keeps.
+    maxPathFailures = 64
+)
+
+// errPathLimit is the failure of the path building stopped by
+// MaxCandidates or maxPathSignatureChecks.
+var errPathLimit = errors.New("path building limit reached")
+
+// PathOptions are the inputs of ValidatePath and Certificate.BuildPaths.
+type PathOptions struct {
+    // Roots are the trust anchors, and Intermediates the certificates
+    // the paths can be built with. They are only used by BuildPaths.
+    Roots         *CertPool
+    Intermediates *CertPool
+
+    // FetchIssuers, if not nil, returns the certificates found at an
+    // URL of the authority information access extension of a
+    // certificate, the caIssuers. It is only used by BuildPaths, for the
+    // certificates without issuer in Roots and Intermediates.
+    FetchIssuers func(url string) ([]*Certificate, error)
+
+    // MaxCandidates is the largest number of candidate paths BuildPaths
+    // validates, 32 if zero. The building stops once they are validated,
+    // or once the signatures of 100 issuers are checked.
+    MaxCandidates int
+
+    // CurrentTime is the time the paths are validated at. If zero, the
+    // current time is used.
+    CurrentTime time.Time
+
+    // KeyUsages are the extended key usages the paths must allow one
+    // of. An empty list does not check them.
+    KeyUsages []ExtKeyUsage
+
+    // Policy are the certificate policy inputs.
+    Policy PolicyOptions
+
+    // Revocation, if not nil, checks the revocation status of the
+    // certificates of the paths.
+    Revocation *RevocationOptions
+
+    // MaxConstraintComparisions limits the comparisons of the name
+    // constraints checks, see VerifyOptions.
+    MaxConstraintComparisions int
+}
+
+// Path is a valid certification path.
+type Path struct {
+    // Chain is the path from the leaf certificate to the trust anchor.
+    Chain []*Certificate
+
+    // Policies is the user-constrained-policy-set, the policies of the
+    // domain of the trust anchor valid for the path and acceptable to
+    // the user. It holds OIDAnyPolicy if the path is valid for any
+    // policy, and is empty if the path is valid for none.
+    Policies []asn1.ObjectIdentifier
+}
+
+// PathError describes why a candidate path is not valid.
+type PathError struct {
+    // Chain is the candidate path, from the leaf certificate to the
+    // trust anchor, or up to the last certificate found.
+    Chain []*Certificate
+
+    // Index is the position in Chain of the certificate the failure is
+    // about, -1 if it is about the whole path.
+    Index int
+
+    Err error
+}
+
+func (e *PathError) Error() string {
+    if e.Index < 0 || e.Index >= len(e.Chain) {
+        return fmt.Sprintf("x509: path %s: %s", describeChain(e.Chain), e.Err)
+    }
+
+    return fmt.Sprintf("x509: path %s: certificate %d (%s): %s", describeChain(e.Chain), e.Index, e.Chain[e.Index].Subject, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+    return e.Err
+}
+
+// PathReport is the result of Certificate.BuildPaths.
+type PathReport struct {
+    // Leaf is the certificate the paths are built for.
+    Leaf *Certificate
+
+    // Paths are the valid paths.
+    Paths []*Path
+
+    // Failures are the candidate paths which are not valid, and the
+    // dead ends of the path building, at most 64 of them.
+    Failures []*PathError
+}
+
+// Err returns nil if there is a valid path, or an error joining the
+// failures.
+func (r *PathReport) Err() error {
+    if len(r.Paths) > 0 {
+        return nil
+    }
+
+    if len(r.Failures) == 0 {
+        return UnknownAuthorityError{Cert: r.Leaf}
+    }
+
+    errs := make([]error, len(r.Failures))
+    for i, f := range r.Failures {
+        errs[i] = f
+    }
+
+    return errors.Join(errs...)
+}
+
+// String returns the report, one line per candidate path.
+func (r *PathReport) String() string {
+    var b strings.Builder
+    for _, p := range r.Paths {
+        fmt.Fprintf(&b, "valid: %s\n", describeChain(p.Chain))
+    }
+
+    for _, f := range r.Failures {
+        fmt.Fprintf(&b, "invalid: %s\n", f.Error())
+    }
+
+    return b.String()
+}
+
+// ValidatePath validates chain, ordered from the leaf certificate to
+// the trust anchor, with the algorithm of RFC 5280 section 6.1. The
+// trust anchor only supplies the issuer name and public key of the
+// certificate following it. The name constraints are checked against
+// the subject alternative names, directory name constraints are not
+// supported. The returned error is a *PathError.
+func ValidatePath(chain []*Certificate, opts PathOptions) (*Path, error) {
+    if len(chain) == 0 {
+        return nil, &PathError{Index: -1, Err: errors.New("empty path")}
+    }
+
+    if err := validatePath(chain, &opts); err != nil {
+        return nil, pathError(chain, err)
+    }
+
+    policies, err := chainPolicies(chain, &opts.Policy)
+    if err != nil {
+        return nil, pathError(chain, err)
+    }
+
+    return &Path{
+        Chain:    chain,
+        Policies: policies,
+    }, nil
+}
+
+// validatePath is the path validation but the policy processing.
+func validatePath(chain []*Certificate, opts *PathOptions) error {
+    now := opts.CurrentTime
+    if now.IsZero() {
+        now = time.Now()
+    }
+
+    maxConstraintComparisons := opts.MaxConstraintComparisions
+    if maxConstraintComparisons == 0 {
+        maxConstraintComparisons = 250000
+    }
+    comparisonCount := 0
+
+    n := len(chain) - 1
+    maxPathLength := n
+
+    // the CA certificates with name constraints so far, the names must
+    // be permitted by all of them
+    var constraining []*Certificate
+
+    for i := 1; i <= n; i++ {
+        cert, issuer := chain[n - i], chain[n - i + 1]
+        last := i == n
+
+        if err := issuer.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+            return pathIndexError{n - i, err}
+        }
+
+        if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+            return pathIndexError{n - i, CertificateInvalidError{
+                Cert:   cert,
+                Reason: Expired,
+                Detail: fmt.Sprintf("current time %s is out of %s to %s", now.Format(time.RFC3339),
+                    cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339)),
+            }}
+        }
+
+        if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+            return pathIndexError{n - i, CertificateInvalidError{cert, NameMismatch, ""}}
+        }
+
+        if cert.hasSANExtension() && (!isSelfIssued(cert) || last) {
+            for _, ca := range constraining {
+                if err := ca.checkSANConstraints(cert, &comparisonCount, maxConstraintComparisons); err != nil {
+                    return pathIndexError{n - i, err}
+                }
+            }
+        }
+
+        if len(cert.UnhandledCriticalExtensions) > 0 {
+            return pathIndexError{n - i, UnhandledCriticalExtension{}}
+        }
+
+        if last {
+            break
+        }
+
+        if cert.hasNameConstraints() {
+            constraining = append(constraining, cert)
+        }
+
+        if !cert.BasicConstraintsValid || !cert.IsCA {
+            return pathIndexError{n - i, CertificateInvalidError{cert, NotAuthorizedToSign, ""}}
+        }
+
+        if !isSelfIssued(cert) {
+            if maxPathLength <= 0 {
+                return pathIndexError{n - i, CertificateInvalidError{cert, TooManyIntermediates, ""}}
+            }
+
+            maxPathLength--
+        }
+
+        if cert.MaxPathLen >= 0 && (cert.MaxPathLen > 0 || cert.MaxPathLenZero) && cert.MaxPathLen < maxPathLength {
+            maxPathLength = cert.MaxPathLen
+        }
+
+        if cert.KeyUsage != 0 && cert.KeyUsage&KeyUsageCertSign == 0 {
+            return pathIndexError{n - i, CertificateInvalidError{cert, NotAuthorizedToSign, ""}}
+        }
+    }
+
+    if opts.Revocation != nil {
+        if err := opts.Revocation.checkChain(chain, now); err != nil {
+            return err
+        }
+    }
+
+    if len(opts.KeyUsages) > 0 {
+        for _, eku := range opts.KeyUsages {
+            if eku == ExtKeyUsageAny {
+                return nil
+            }
+        }
+
+        if !checkChainForKeyUsage(chain, opts.KeyUsages) {
+            return pathIndexError{-1, CertificateInvalidError{chain[0], IncompatibleUsage, ""}}
+        }
+    }
+
+    return nil
+}
+
+// pathIndexError is an error about the certificate of a path at index.
+type pathIndexError struct {
+    index int
+    err   error
+}
+
+func (e pathIndexError) Error() string {
+    return e.err.Error()
+}
+
+// pathError returns the *PathError of err for chain, finding the
+// certificate at fault for the errors of the policy processing and of
+// the revocation checks.
+func pathError(chain []*Certificate, err error) *PathError {
+    if e, ok := err.(pathIndexError); ok {
+        return &PathError{chain, e.index, e.err}
+    }
+
+    index := -1
+
+    var invalid CertificateInvalidError
+    if errors.As(err, &invalid) {
+        for i, cert := range chain {
+            if cert == invalid.Cert {
+                index = i
+            }
+        }
+    }
+
+    return &PathError{chain, index, err}
+}
+
+// BuildPaths builds the candidate paths from c to the trust anchors of
+// opts.Roots, through the certificates of opts.Intermediates and the
+// ones fetched with opts.FetchIssuers, and validates each of them with
+// ValidatePath. The report has the valid paths and why the other
+// candidates are not.
+//
+// Unlike Verify, all the issuers with the name of the issuer of a
+// certificate are tried, so that the paths through cross-signed CA
+// certificates are all found.
+func (c *Certificate) BuildPaths(opts PathOptions) *PathReport {
+    b := &pathBuilder{
+        opts:    &opts,
+        report:  &PathReport{Leaf: c},
+        fetched: make(map[string][]*Certificate),
+        max:     opts.MaxCandidates,
+    }
+
+    if b.max <= 0 {
+        b.max = defaultMaxCandidates
+    }
+
+    if opts.Roots.contains(c) {
+        b.validate([]*Certificate{c}, nil)
+    }
+
+    b.build([]*Certificate{c})
+
+    return b.report
+}
+
+type pathBuilder struct {
+    opts    *PathOptions
+    report  *PathReport
+    fetched map[string][]*Certificate
+
+    candidates int
+    max        int
+    sigChecks  int
+    stopped    bool
+}
+
+// build extends chain with the issuers of its last certificate.
+func (b *pathBuilder) build(chain []*Certificate) {
+    if b.spent(chain) {
+        return
+    }
+
+    cert := chain[len(chain) - 1]
+
+    found := false
+    for _, root := range b.opts.Roots.findPotentialParents(cert) {
+        if alreadyInChain(root.cert, chain) {
+            continue
+        }
+
+        found = true
+        b.validate(appendToFreshChain(chain, root.cert), root.constraint)
+    }
+
+    if len(chain) >= maxPathDepth {
+        return
+    }
+
+    var issuers []*Certificate
+    for _, intermediate := range b.opts.Intermediates.findPotentialParents(cert) {
+        issuers = append(issuers, intermediate.cert)
+    }
+
+    if len(issuers) == 0 && !found {
+        issuers = b.fetch(chain)
+    }
+
+    for _, issuer := range issuers {
+        if alreadyInChain(issuer, chain) || b.opts.Roots.contains(issuer) {
+            continue
+        }
+
+        if b.spent(chain) {
+            return
+        }
+
+        found = true
+        next := appendToFreshChain(chain, issuer)
+
+        // a dead end is not extended further
+        b.sigChecks++
+        if err := issuer.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+            b.fail(&PathError{next, len(chain) - 1, err})
+            continue
+        }
+
+        b.build(next)
+    }
+
+    if !found && !b.opts.Roots.contains(cert) {
+        b.fail(&PathError{chain, len(chain) - 1, UnknownAuthorityError{Cert: cert}})
+    }
+}
+
+// validate validates the candidate path chain, with the constraint of
+// its trust anchor.
+func (b *pathBuilder) validate(chain []*Certificate, constraint func([]*Certificate) error) {
+    if b.candidates >= b.max {
+        return
+    }
+    b.candidates++
+
+    path, err := ValidatePath(chain, *b.opts)
+    if err == nil && constraint != nil {
+        if cerr := constraint(chain); cerr != nil {
+            err = &PathError{chain, len(chain) - 1, cerr}
+        }
+    }
+
+    if err != nil {
+        b.fail(err.(*PathError))
+        return
+    }
+
+    b.report.Paths = append(b.report.Paths, path)
+}
+
+// spent reports whether the budget of the path building is spent,
+// recording it as a failure the first time, even past maxPathFailures.
+func (b *pathBuilder) spent(chain []*Certificate) bool {
+    if b.candidates < b.max && b.sigChecks < maxPathSignatureChecks {
+        return false
+    }
+
+    if !b.stopped {
+        b.stopped = true
+        b.report.Failures = append(b.report.Failures, &PathError{chain, -1, errPathLimit})
+    }
+
+    return true
+}
+
+func (b *pathBuilder) fail(err *PathError) {
+    if len(b.report.Failures) >= maxPathFailures {
+        return
+    }
+
+    b.report.Failures = append(b.report.Failures, err)
+}
+
+// fetch returns the issuers of the last certificate of chain found at
+// the caIssuers URLs of its authority information access extension.
+func (b *pathBuilder) fetch(chain []*Certificate) []*Certificate {
+    cert := chain[len(chain) - 1]
+    if b.opts.FetchIssuers == nil {
+        return nil
+    }
+
+    var issuers []*Certificate
+    for _, url := range cert.IssuingCertificateURL {
+        certs, ok := b.fetched[url]
+        if !ok {
+            var err error
+            certs, err = b.opts.FetchIssuers(url)
+            if err != nil {
+                b.fail(&PathError{chain, len(chain) - 1, fmt.Errorf("fetching issuers from %s: %w", url, err)})
+            }
+
+            b.fetched[url] = certs
+        }
+
+        for _, issuer := range certs {
+            if bytes.Equal(issuer.RawSubject, cert.RawIssuer) {
+                issuers = append(issuers, issuer)
+            }
+        }
+    }
+
+    return issuers
+}
+
+// describeChain returns the subjects of the certificates of chain.
+func describeChain(chain []*Certificate) string {
+    names := make([]string, len(chain))
+    for i, cert := range chain {
+        names[i] = "[" + cert.Subject.String() + "]"
+    }
+
+    return strings.Join(names, " -> ")
+}
@@ -0,0 +1,448 @@
+package x509
+
+import (
+    "fmt"
+    "time"
+    "errors"
+    "strings"
+    "testing"
+    "math/big"
+    "crypto"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    cryptobin_test "github.com/deatil/go-cryptobin/tool/test"
+)
+
+type pathTestCA struct {
+    cert *Certificate
+    key  crypto.Signer
+}
+
+var pathTestSerial int64 = 100
+
+func newPathTestCert(t *testing.T, template *Certificate, issuer *pathTestCA, key crypto.Signer) *Certificate {
+    pathTestSerial++
+
+    now := time.Now()
+    template.SerialNumber = big.NewInt(pathTestSerial)
+    if template.NotBefore.IsZero() {
+        template.NotBefore = now.Add(-time.Hour)
+    }
+    if template.NotAfter.IsZero() {
+        template.NotAfter = now.Add(time.Hour)
+    }
+
+    parent, signer := template, key
+    if issuer != nil {
+        parent, signer = issuer.cert, issuer.key
+    }
+
+    der, err := CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    cert, err := ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    return cert
+}
+
+func newPathTestCA(t *testing.T, name string, issuer *pathTestCA, key crypto.Signer, setup func(*Certificate)) *pathTestCA {
+    if key == nil {
+        key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    }
+
+    template := &Certificate{
+        Subject:               pkix.Name{CommonName: name},
+        KeyUsage:              KeyUsageCertSign | KeyUsageCRLSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SubjectKeyId:          []byte(name),
+    }
+
+    if setup != nil {
+        setup(template)
+    }
+
+    return &pathTestCA{newPathTestCert(t, template, issuer, key), key}
+}
+
+func newPathTestLeaf(t *testing.T, issuer *pathTestCA, setup func(*Certificate)) *Certificate {
+    key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+    template := &Certificate{
+        Subject:     pkix.Name{CommonName: "www.example.com"},
+        DNSNames:    []string{"www.example.com"},
+        KeyUsage:    KeyUsageDigitalSignature,
+        ExtKeyUsage: []ExtKeyUsage{ExtKeyUsageServerAuth},
+    }
+
+    if setup != nil {
+        setup(template)
+    }
+
+    return newPathTestCert(t, template, issuer, key)
+}
+
+var (
+    testPolicy1 = asn1.ObjectIdentifier{1, 2, 3, 1}
+    testPolicy2 = asn1.ObjectIdentifier{1, 2, 3, 2}
+    testPolicy3 = asn1.ObjectIdentifier{1, 2, 3, 3}
+    testPolicy4 = asn1.ObjectIdentifier{1, 2, 3, 4}
+)
+
+func Test_PolicyExtensions(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+
+    root := newPathTestCA(t, "Root", nil, nil, nil)
+    ca := newPathTestCA(t, "CA", root, nil, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{testPolicy1}
+        c.PolicyMappings = []PolicyMapping{{testPolicy1, testPolicy2}}
+        c.RequireExplicitPolicyZero = true
+        c.InhibitPolicyMapping = 2
+        c.InhibitAnyPolicy = 1
+    }).cert
+
+    assertEqual(ca.PolicyMappings, []PolicyMapping{{testPolicy1, testPolicy2}}, "PolicyMappings")
+    assertEqual(ca.RequireExplicitPolicy, 0, "RequireExplicitPolicy")
+    assertEqual(ca.RequireExplicitPolicyZero, true, "RequireExplicitPolicyZero")
+    assertEqual(ca.InhibitPolicyMapping, 2, "InhibitPolicyMapping")
+    assertEqual(ca.InhibitPolicyMappingZero, false, "InhibitPolicyMappingZero")
+    assertEqual(ca.InhibitAnyPolicy, 1, "InhibitAnyPolicy")
+    assertEqual(len(ca.UnhandledCriticalExtensions), 0, "UnhandledCriticalExtensions")
+
+    // absent constraints
+    assertEqual(root.cert.RequireExplicitPolicyZero, false, "RequireExplicitPolicyZero")
+    assertEqual(root.cert.InhibitAnyPolicyZero, false, "InhibitAnyPolicyZero")
+    assertEqual(len(root.cert.PolicyMappings), 0, "PolicyMappings")
+}
+
+func Test_PolicyProcessing(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    root := newPathTestCA(t, "Root", nil, nil, nil)
+    ca := newPathTestCA(t, "CA", root, nil, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{testPolicy1, testPolicy2}
+        c.PolicyMappings = []PolicyMapping{{testPolicy2, testPolicy3}}
+    })
+    leaf := newPathTestLeaf(t, ca, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{testPolicy1, testPolicy3}
+    })
+
+    chain := []*Certificate{leaf, ca.cert, root.cert}
+
+    validate := func(policy PolicyOptions) (*Path, error) {
+        return ValidatePath(chain, PathOptions{Policy: policy})
+    }
+
+    // the policies are in the domain of the trust anchor
+    path, err := validate(PolicyOptions{})
+    assertError(err, "ValidatePath")
+    assertEqual(path.Policies, []asn1.ObjectIdentifier{testPolicy1, testPolicy2}, "Policies")
+
+    path, err = validate(PolicyOptions{
+        InitialPolicies:       []asn1.ObjectIdentifier{testPolicy2},
+        RequireExplicitPolicy: true,
+    })
+    assertError(err, "ValidatePath initial policies")
+    assertEqual(path.Policies, []asn1.ObjectIdentifier{testPolicy2}, "Policies")
+
+    // the mapping of policy 2 is not processed
+    path, err = validate(PolicyOptions{InhibitPolicyMapping: true})
+    assertError(err, "ValidatePath inhibit mapping")
+    assertEqual(path.Policies, []asn1.ObjectIdentifier{testPolicy1}, "Policies")
+
+    // no acceptable policy is only an error when a policy is required
+    path, err = validate(PolicyOptions{InitialPolicies: []asn1.ObjectIdentifier{testPolicy4}})
+    assertError(err, "ValidatePath no acceptable policy")
+    assertEqual(len(path.Policies), 0, "Policies")
+
+    _, err = validate(PolicyOptions{
+        InitialPolicies:       []asn1.ObjectIdentifier{testPolicy4},
+        RequireExplicitPolicy: true,
+    })
+
+    var pathErr *PathError
+    var invalid CertificateInvalidError
+    assertBool(errors.As(err, &pathErr), "PathError")
+    assertBool(errors.As(err, &invalid), "CertificateInvalidError")
+    assertEqual(invalid.Reason, InvalidPolicy, "InvalidPolicy")
+    assertEqual(pathErr.Index, 0, "Index")
+
+    // anyPolicy can be inhibited
+    anyCA := newPathTestCA(t, "Any CA", root, nil, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{OIDAnyPolicy}
+        c.InhibitAnyPolicyZero = true
+    })
+
+    anyLeaf := newPathTestLeaf(t, anyCA, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{OIDAnyPolicy}
+    })
+
+    _, err = ValidatePath([]*Certificate{anyLeaf, anyCA.cert, root.cert}, PathOptions{
+        Policy: PolicyOptions{RequireExplicitPolicy: true},
+    })
+    assertBool(errors.As(err, &invalid) && invalid.Reason == InvalidPolicy, "ValidatePath inhibit anyPolicy")
+
+    p1Leaf := newPathTestLeaf(t, anyCA, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{testPolicy1}
+    })
+
+    path, err = ValidatePath([]*Certificate{p1Leaf, anyCA.cert, root.cert}, PathOptions{
+        Policy: PolicyOptions{RequireExplicitPolicy: true},
+    })
+    assertError(err, "ValidatePath anyPolicy CA")
+    assertEqual(path.Policies, []asn1.ObjectIdentifier{testPolicy1}, "Policies")
+}
+
+func Test_VerifyPolicies(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    root := newPathTestCA(t, "Root", nil, nil, nil)
+    ca := newPathTestCA(t, "CA", root, nil, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{testPolicy1}
+        c.RequireExplicitPolicyZero = true
+    })
+
+    roots := NewCertPool()
+    roots.AddCert(root.cert)
+
+    intermediates := NewCertPool()
+    intermediates.AddCert(ca.cert)
+
+    verify := func(leaf *Certificate, policy *PolicyOptions) error {
+        _, err := leaf.Verify(VerifyOptions{
+            Roots:         roots,
+            Intermediates: intermediates,
+            Policy:        policy,
+        })
+
+        return err
+    }
+
+    withPolicy := newPathTestLeaf(t, ca, func(c *Certificate) {
+        c.PolicyIdentifiers = []asn1.ObjectIdentifier{testPolicy1}
+    })
+    assertError(verify(withPolicy, nil), "Verify")
+
+    // the CA requires a policy, which is only checked with policy options
+    withoutPolicy := newPathTestLeaf(t, ca, nil)
+    assertError(verify(withoutPolicy, nil), "Verify without policy options")
+
+    err := verify(withoutPolicy, &PolicyOptions{})
+
+    var invalid CertificateInvalidError
+    assertBool(errors.As(err, &invalid) && invalid.Reason == InvalidPolicy, "Verify without policy")
+
+    err = verify(withPolicy, &PolicyOptions{InitialPolicies: []asn1.ObjectIdentifier{testPolicy2}})
+    assertBool(errors.As(err, &invalid) && invalid.Reason == InvalidPolicy, "Verify unacceptable policy")
+}
+
+func Test_BuildPaths(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    now := time.Now()
+
+    oldRoot := newPathTestCA(t, "Old Root", nil, nil, nil)
+    newRoot := newPathTestCA(t, "New Root", nil, nil, nil)
+
+    // the new root cross-signed by the old one, expired
+    cross := newPathTestCA(t, "New Root", oldRoot, newRoot.key, func(c *Certificate) {
+        c.NotBefore = now.Add(-2 * time.Hour)
+        c.NotAfter = now.Add(-time.Hour)
+    })
+
+    ica := newPathTestCA(t, "Issuing CA", newRoot, nil, nil)
+    leaf := newPathTestLeaf(t, ica, nil)
+
+    roots := NewCertPool()
+    roots.AddCert(oldRoot.cert)
+    roots.AddCert(newRoot.cert)
+
+    intermediates := NewCertPool()
+    intermediates.AddCert(ica.cert)
+    intermediates.AddCert(cross.cert)
+
+    report := leaf.BuildPaths(PathOptions{
+        Roots:         roots,
+        Intermediates: intermediates,
+        KeyUsages:     []ExtKeyUsage{ExtKeyUsageServerAuth},
+    })
+    assertError(report.Err(), "BuildPaths")
+
+    assertEqual(len(report.Paths), 1, "Paths")
+    assertEqual(report.Paths[0].Chain, []*Certificate{leaf, ica.cert, newRoot.cert}, "Paths")
+    assertEqual(report.Paths[0].Policies, []asn1.ObjectIdentifier(nil), "Policies")
+
+    // the path through the cross certificate fails on its validity
+    assertEqual(len(report.Failures), 1, "Failures")
+    assertEqual(report.Failures[0].Chain, []*Certificate{leaf, ica.cert, cross.cert, oldRoot.cert}, "Failures")
+    assertEqual(report.Failures[0].Index, 2, "Index")
+
+    var invalid CertificateInvalidError
+    assertBool(errors.As(report.Failures[0], &invalid) && invalid.Reason == Expired, "Expired")
+    assertBool(strings.Contains(report.String(), "invalid: x509: path [CN=www.example.com]"), "String")
+
+    // the cross certificate still valid at another time
+    report = leaf.BuildPaths(PathOptions{
+        Roots:         roots,
+        Intermediates: intermediates,
+        CurrentTime:   now.Add(-90 * time.Minute),
+    })
+    assertEqual(len(report.Paths), 0, "Paths")
+
+    // a key usage not allowed
+    report = leaf.BuildPaths(PathOptions{
+        Roots:         roots,
+        Intermediates: intermediates,
+        KeyUsages:     []ExtKeyUsage{ExtKeyUsageCodeSigning},
+    })
+    assertBool(errors.As(report.Err(), &invalid) && invalid.Reason == IncompatibleUsage, "IncompatibleUsage")
+}
+
+func Test_BuildPathsAIA(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    root := newPathTestCA(t, "Root", nil, nil, nil)
+    ica := newPathTestCA(t, "Issuing CA", root, nil, nil)
+    leaf := newPathTestLeaf(t, ica, func(c *Certificate) {
+        c.IssuingCertificateURL = []string{"http://aia.example.com/ica.cer"}
+    })
+
+    roots := NewCertPool()
+    roots.AddCert(root.cert)
+
+    report := leaf.BuildPaths(PathOptions{Roots: roots})
+    assertBool(report.Err() != nil, "BuildPaths without AIA")
+    assertEqual(len(report.Failures), 1, "Failures")
+    assertEqual(report.Failures[0].Index, 0, "Index")
+    assertBool(errors.As(report.Failures[0], new(UnknownAuthorityError)), "UnknownAuthorityError")
+
+    fetched := 0
+    report = leaf.BuildPaths(PathOptions{
+        Roots:        roots,
+        FetchIssuers: func(url string) ([]*Certificate, error) {
+            fetched++
+
+            if url != "http://aia.example.com/ica.cer" {
+                return nil, errors.New("not found")
+            }
+
+            return []*Certificate{ica.cert}, nil
+        },
+    })
+    assertError(report.Err(), "BuildPaths with AIA")
+    assertEqual(report.Paths[0].Chain, []*Certificate{leaf, ica.cert, root.cert}, "Paths")
+    assertEqual(fetched, 1, "fetched")
+}
+
+func Test_BuildPathsLimit(t *testing.T) {
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    // self-issued CA certificates with the same name and key but other
+    // SANs, each of them an issuer of all the others, and no root
+    key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+    intermediates := NewCertPool()
+
+    var ca *pathTestCA
+    for i := 0; i < 10; i++ {
+        ca = newPathTestCA(t, "Loop CA", nil, key, func(c *Certificate) {
+            c.DNSNames = []string{fmt.Sprintf("ca%d.example.com", i)}
+        })
+        intermediates.AddCert(ca.cert)
+    }
+
+    leaf := newPathTestLeaf(t, ca, nil)
+
+    start := time.Now()
+    report := leaf.BuildPaths(PathOptions{
+        Roots:         NewCertPool(),
+        Intermediates: intermediates,
+    })
+    assertBool(time.Since(start) < 10 * time.Second, "BuildPaths time")
+
+    assertEqual(len(report.Paths), 0, "Paths")
+    assertBool(len(report.Failures) <= maxPathFailures + 1, "Failures")
+    assertBool(errors.Is(report.Err(), errPathLimit), "errPathLimit")
+
+    // an issuer with a bad signature is a dead end
+    other := newPathTestCA(t, "Other CA", nil, nil, nil)
+    bad := newPathTestCA(t, "Other CA", nil, nil, nil)
+    leaf = newPathTestLeaf(t, other, nil)
+
+    intermediates = NewCertPool()
+    intermediates.AddCert(bad.cert)
+
+    roots := NewCertPool()
+    roots.AddCert(other.cert)
+
+    report = leaf.BuildPaths(PathOptions{
+        Roots:         roots,
+        Intermediates: intermediates,
+    })
+    assertEqual(len(report.Paths), 1, "Paths")
+    assertEqual(len(report.Failures), 1, "Failures")
+    assertEqual(report.Failures[0].Chain, []*Certificate{leaf, bad.cert}, "Failures")
+    assertEqual(report.Failures[0].Index, 0, "Index")
+}
+
+func Test_ValidatePathConstraints(t *testing.T) {
+    assertError := cryptobin_test.AssertErrorT(t)
+    assertEqual := cryptobin_test.AssertEqualT(t)
+    assertBool := cryptobin_test.AssertBoolT(t)
+
+    root := newPathTestCA(t, "Root", nil, nil, nil)
+    ca := newPathTestCA(t, "CA", root, nil, func(c *Certificate) {
+        c.PermittedDNSDomains = []string{"example.com"}
+        c.MaxPathLenZero = true
+    })
+
+    leaf := newPathTestLeaf(t, ca, nil)
+    _, err := ValidatePath([]*Certificate{leaf, ca.cert, root.cert}, PathOptions{})
+    assertError(err, "ValidatePath")
+
+    // the name constraints of the CA
+    other := newPathTestLeaf(t, ca, func(c *Certificate) {
+        c.DNSNames = []string{"www.example.org"}
+    })
+
+    _, err = ValidatePath([]*Certificate{other, ca.cert, root.cert}, PathOptions{})
+
+    var pathErr *PathError
+    var invalid CertificateInvalidError
+    assertBool(errors.As(err, &pathErr) && errors.As(err, &invalid), "ValidatePath name constraints")
+    assertEqual(invalid.Reason, CANotAuthorizedForThisName, "Reason")
+    assertEqual(pathErr.Index, 0, "Index")
+
+    // the path length constraint of the CA applies to the sub CA
+    sub := newPathTestCA(t, "Sub CA", ca, nil, nil)
+    subLeaf := newPathTestLeaf(t, sub, nil)
+
+    _, err = ValidatePath([]*Certificate{subLeaf, sub.cert, ca.cert, root.cert}, PathOptions{})
+    assertBool(errors.As(err, &pathErr) && errors.As(err, &invalid), "ValidatePath path length")
+    assertEqual(invalid.Reason, TooManyIntermediates, "Reason")
+    assertEqual(pathErr.Index, 1, "Index")
+
+    // the certificates are not in order
+    _, err = ValidatePath([]*Certificate{leaf, root.cert, leaf}, PathOptions{})
+    assertBool(err != nil, "ValidatePath wrong order")
+
+    _, err = ValidatePath(nil, PathOptions{})
+    assertBool(err != nil, "ValidatePath empty")
+}
@@ -0,0 +1,417 @@
+package x509
+
+import (
+    "bytes"
+    "encoding/asn1"
+)
+
+// OIDAnyPolicy is the anyPolicy certificate policy, RFC 5280 4.2.1.4.
+var OIDAnyPolicy = asn1.ObjectIdentifier{2, 5, 29, 32, 0}
+
+// PolicyOptions are the certificate policy inputs of the path
+// validation, RFC 5280 section 6.1.1.
+type PolicyOptions struct {
+    // InitialPolicies is the user-initial-policy-set, the policies
+    // acceptable to the user. Any policy is acceptable if empty.
+    InitialPolicies []asn1.ObjectIdentifier
+
+    // InhibitPolicyMapping, RequireExplicitPolicy and InhibitAnyPolicy
+    // are the initial-policy-mapping-inhibit, initial-explicit-policy
+    // and initial-any-policy-inhibit inputs.
+    InhibitPolicyMapping  bool
+    RequireExplicitPolicy bool
+    InhibitAnyPolicy      bool
+}
+
+// policyNode is a node of the valid_policy_tree.
+type policyNode struct {
+    policy   asn1.ObjectIdentifier
+    expected []asn1.ObjectIdentifier
+    parent   *policyNode
+    removed  bool
+}
+
+func (n *policyNode) isAny() bool {
+    return n.policy.Equal(OIDAnyPolicy)
+}
+
+// policyTree is the valid_policy_tree, with the nodes of depth d in
+// levels[d]. A nil tree is the NULL tree.
+type policyTree struct {
+    levels [][]*policyNode
+}
+
+func newPolicyTree() *policyTree {
+    return &policyTree{
+        levels: [][]*policyNode{
+            {{policy: OIDAnyPolicy, expected: []asn1.ObjectIdentifier{OIDAnyPolicy}}},
+        },
+    }
+}
+
+func (t *policyTree) add(depth int, parent *policyNode, policy asn1.ObjectIdentifier, expected []asn1.ObjectIdentifier) {
+    for len(t.levels) <= depth {
+        t.levels = append(t.levels, nil)
+    }
+
+    t.levels[depth] = append(t.levels[depth], &policyNode{
+        policy:   policy,
+        expected: expected,
+        parent:   parent,
+    })
+}
+
+func (t *policyTree) hasChild(depth int, parent *policyNode, policy asn1.ObjectIdentifier) bool {
+    if len(t.levels) <= depth {
+        return false
+    }
+
+    for _, node := range t.levels[depth] {
+        if node.parent == parent && node.policy.Equal(policy) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// compact drops the removed nodes with their descendants, then the
+// nodes above depth without children. It returns false if the tree
+// became NULL.
+func (t *policyTree) compact(depth int) bool {
+    for d := range t.levels {
+        kept := t.levels[d][:0]
+        for _, node := range t.levels[d] {
+            if node.parent != nil && node.parent.removed {
+                node.removed = true
+            }
+
+            if !node.removed {
+                kept = append(kept, node)
+            }
+        }
+
+        t.levels[d] = kept
+    }
+
+    for d := depth - 1; d >= 0; d-- {
+        parents := make(map[*policyNode]bool)
+        if d + 1 < len(t.levels) {
+            for _, node := range t.levels[d + 1] {
+                parents[node.parent] = true
+            }
+        }
+
+        kept := t.levels[d][:0]
+        for _, node := range t.levels[d] {
+            if parents[node] {
+                kept = append(kept, node)
+            } else {
+                node.removed = true
+            }
+        }
+
+        t.levels[d] = kept
+    }
+
+    return len(t.levels[0]) > 0
+}
+
+// policyState is the policy part of the state of the path validation.
+type policyState struct {
+    tree *policyTree
+
+    explicitPolicy   int
+    policyMapping    int
+    inhibitAnyPolicy int
+}
+
+func newPolicyState(opts *PolicyOptions, n int) *policyState {
+    s := &policyState{
+        tree:             newPolicyTree(),
+        explicitPolicy:   n + 1,
+        policyMapping:    n + 1,
+        inhibitAnyPolicy: n + 1,
+    }
+
+    if opts.RequireExplicitPolicy {
+        s.explicitPolicy = 0
+    }
+    if opts.InhibitPolicyMapping {
+        s.policyMapping = 0
+    }
+    if opts.InhibitAnyPolicy {
+        s.inhibitAnyPolicy = 0
+    }
+
+    return s
+}
+
+// process is the processing of the policies of the certificate of
+// depth i, RFC 5280 6.1.3 (d) to (f).
+func (s *policyState) process(cert *Certificate, i int, last bool) error {
+    if s.tree != nil && len(cert.PolicyIdentifiers) == 0 {
+        s.tree = nil
+    }
+
+    if s.tree != nil {
+        parents := s.tree.levels[i - 1]
+
+        hasAny := false
+        for _, policy := range cert.PolicyIdentifiers {
+            if policy.Equal(OIDAnyPolicy) {
+                hasAny = true
+                continue
+            }
+
+            matched := false
+            for _, node := range parents {
+                if oidInList(policy, node.expected) {
+                    s.tree.add(i, node, policy, []asn1.ObjectIdentifier{policy})
+                    matched = true
+                }
+            }
+
+            if !matched {
+                for _, node := range parents {
+                    if node.isAny() {
+                        s.tree.add(i, node, policy, []asn1.ObjectIdentifier{policy})
+                    }
+                }
+            }
+        }
+
+        if hasAny && (s.inhibitAnyPolicy > 0 || (!last && isSelfIssued(cert))) {
+            for _, node := range parents {
+                for _, policy := range node.expected {
+                    if !s.tree.hasChild(i, node, policy) {
+                        s.tree.add(i, node, policy, []asn1.ObjectIdentifier{policy})
+                    }
+                }
+            }
+        }
+
+        if len(s.tree.levels) <= i || !s.tree.compact(i) {
+            s.tree = nil
+        }
+    }
+
+    if s.explicitPolicy == 0 && s.tree == nil {
+        return CertificateInvalidError{cert, InvalidPolicy, "no valid policy"}
+    }
+
+    return nil
+}
+
+// prepare is the preparation of the policy state for the certificate
+// following the one of depth i, RFC 5280 6.1.4 (a), (b) and (h) to (j).
+func (s *policyState) prepare(cert *Certificate, i int) error {
+    for _, m := range cert.PolicyMappings {
+        if m.IssuerDomainPolicy.Equal(OIDAnyPolicy) || m.SubjectDomainPolicy.Equal(OIDAnyPolicy) {
+            return CertificateInvalidError{cert, InvalidPolicy, "anyPolicy is mapped"}
+        }
+    }
+
+    if s.tree != nil && len(cert.PolicyMappings) > 0 {
+        if s.policyMapping > 0 {
+            s.mapPolicies(cert, i)
+        } else {
+            for _, node := range s.tree.levels[i] {
+                for _, m := range cert.PolicyMappings {
+                    if node.policy.Equal(m.IssuerDomainPolicy) {
+                        node.removed = true
+                    }
+                }
+            }
+
+            if !s.tree.compact(i) {
+                s.tree = nil
+            }
+        }
+    }
+
+    if !isSelfIssued(cert) {
+        if s.explicitPolicy > 0 {
+            s.explicitPolicy--
+        }
+        if s.policyMapping > 0 {
+            s.policyMapping--
+        }
+        if s.inhibitAnyPolicy > 0 {
+            s.inhibitAnyPolicy--
+        }
+    }
+
+    if v := policyConstraintValue(cert.RequireExplicitPolicy, cert.RequireExplicitPolicyZero); v >= 0 && v < s.explicitPolicy {
+        s.explicitPolicy = v
+    }
+    if v := policyConstraintValue(cert.InhibitPolicyMapping, cert.InhibitPolicyMappingZero); v >= 0 && v < s.policyMapping {
+        s.policyMapping = v
+    }
+    if v := policyConstraintValue(cert.InhibitAnyPolicy, cert.InhibitAnyPolicyZero); v >= 0 && v < s.inhibitAnyPolicy {
+        s.inhibitAnyPolicy = v
+    }
+
+    return nil
+}
+
+// mapPolicies sets the expected policies of the nodes of depth i to the
+// subject domain policies they are mapped to.
+func (s *policyState) mapPolicies(cert *Certificate, i int) {
+    var issuerPolicies []asn1.ObjectIdentifier
+    mapped := make(map[string][]asn1.ObjectIdentifier)
+
+    for _, m := range cert.PolicyMappings {
+        key := m.IssuerDomainPolicy.String()
+        if _, ok := mapped[key]; !ok {
+            issuerPolicies = append(issuerPolicies, m.IssuerDomainPolicy)
+        }
+
+        if !oidInList(m.SubjectDomainPolicy, mapped[key]) {
+            mapped[key] = append(mapped[key], m.SubjectDomainPolicy)
+        }
+    }
+
+    for _, policy := range issuerPolicies {
+        expected := mapped[policy.String()]
+
+        found := false
+        for _, node := range s.tree.levels[i] {
+            if node.policy.Equal(policy) {
+                node.expected = expected
+                found = true
+            }
+        }
+
+        if !found {
+            for _, node := range s.tree.levels[i] {
+                if node.isAny() {
+                    s.tree.add(i, node.parent, policy, expected)
+                    break
+                }
+            }
+        }
+    }
+}
+
+// finish is the wrap-up of the policy processing with the certificate
+// of depth n, RFC 5280 6.1.5 (a), (b) and (g). It returns the
+// user-constrained-policy-set.
+func (s *policyState) finish(cert *Certificate, n int, initial []asn1.ObjectIdentifier) ([]asn1.ObjectIdentifier, error) {
+    if s.explicitPolicy > 0 {
+        s.explicitPolicy--
+    }
+
+    if cert.RequireExplicitPolicyZero {
+        s.explicitPolicy = 0
+    }
+
+    if s.tree != nil && len(initial) > 0 && !oidInList(OIDAnyPolicy, initial) {
+        s.intersect(n, initial)
+    }
+
+    if s.explicitPolicy == 0 && s.tree == nil {
+        return nil, CertificateInvalidError{cert, InvalidPolicy, "no acceptable policy"}
+    }
+
+    if s.tree == nil {
+        return nil, nil
+    }
+
+    // the policies in the domain of the trust anchor, the ones of the
+    // nodes below anyPolicy
+    var policies []asn1.ObjectIdentifier
+    for _, node := range s.tree.levels[n] {
+        for node.parent != nil && !node.parent.isAny() {
+            node = node.parent
+        }
+
+        if !oidInList(node.policy, policies) {
+            policies = append(policies, node.policy)
+        }
+    }
+
+    return policies, nil
+}
+
+// intersect keeps the policies of the tree in the initial policies.
+func (s *policyState) intersect(n int, initial []asn1.ObjectIdentifier) {
+    var present []asn1.ObjectIdentifier
+    for _, level := range s.tree.levels[1:] {
+        for _, node := range level {
+            if node.isAny() || !node.parent.isAny() {
+                continue
+            }
+
+            if oidInList(node.policy, initial) {
+                present = append(present, node.policy)
+            } else {
+                node.removed = true
+            }
+        }
+    }
+
+    for _, node := range s.tree.levels[n] {
+        if !node.isAny() || node.removed {
+            continue
+        }
+
+        for _, policy := range initial {
+            if !oidInList(policy, present) {
+                s.tree.add(n, node.parent, policy, []asn1.ObjectIdentifier{policy})
+            }
+        }
+
+        node.removed = true
+    }
+
+    if !s.tree.compact(n) {
+        s.tree = nil
+    }
+}
+
+// chainPolicies runs the certificate policy processing of RFC 5280
+// section 6.1 on chain, from the leaf to the trust anchor, and returns
+// the user-constrained-policy-set.
+func chainPolicies(chain []*Certificate, opts *PolicyOptions) ([]asn1.ObjectIdentifier, error) {
+    n := len(chain) - 1
+    if n == 0 {
+        if len(opts.InitialPolicies) > 0 {
+            return opts.InitialPolicies, nil
+        }
+
+        return []asn1.ObjectIdentifier{OIDAnyPolicy}, nil
+    }
+
+    s := newPolicyState(opts, n)
+
+    for i := 1; i <= n; i++ {
+        cert := chain[n - i]
+
+        if err := s.process(cert, i, i == n); err != nil {
+            return nil, err
+        }
+
+        if i < n {
+            if err := s.prepare(cert, i); err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    return s.finish(chain[0], n, opts.InitialPolicies)
+}
+
+func isSelfIssued(cert *Certificate) bool {
+    return bytes.Equal(cert.RawIssuer, cert.RawSubject)
+}
+
+func oidInList(oid asn1.ObjectIdentifier, list []asn1.ObjectIdentifier) bool {
+    for _, o := range list {
+        if o.Equal(oid) {
+            return true
+        }
+    }
+
+    return false
+}
@@ -0,0 +1,205 @@
+package x509
+
+import (
+    "time"
+    "bytes"
+)
+
+// RevocationStatus is the revocation status of a certificate.
+type RevocationStatus int
+
+const (
+    // RevocationStatusUnknown means no authoritative status was found.
+    RevocationStatusUnknown RevocationStatus = iota
+    // RevocationStatusGood means the certificate is not revoked.
+    RevocationStatusGood
+    // RevocationStatusRevoked means the certificate is revoked.
+    RevocationStatusRevoked
+)
+
+func (s RevocationStatus) String() string {
+    switch s {
+        case RevocationStatusGood:
+            return "good"
+        case RevocationStatusRevoked:
+            return "revoked"
+        default:
+            return "unknown"
+    }
+}
+
+// RevocationChecker returns the revocation status of cert, issued by
+// issuer, at time now. The x509/ocsp package provides a checker for
+// OCSP responses.
+type RevocationChecker interface {
+    RevocationStatus(cert, issuer *Certificate, now time.Time) (RevocationStatus, error)
+}
+
+// RevocationOptions makes Certificate.Verify check the revocation status
+// of every certificate of the chains but the root.
+type RevocationOptions struct {
+    // CRLs are the CRLs to check, complete and delta CRLs of any issuer.
+    // A CRL is only used if its signature is valid, it is current and
+    // its scope covers the certificate.
+    CRLs []*RevocationList
+
+    // Checker, if not nil, is asked first, the CRLs are used when it
+    // returns RevocationStatusUnknown.
+    Checker RevocationChecker
+
+    // RequireStatus rejects the chains with a certificate whose status
+    // is unknown. Otherwise only revoked certificates are rejected.
+    RequireStatus bool
+}
+
+// checkChain checks the revocation status of the certificates of chain.
+func (r *RevocationOptions) checkChain(chain []*Certificate, now time.Time) error {
+    for i := 0; i+1 < len(chain); i++ {
+        cert, issuer := chain[i], chain[i+1]
+
+        status, err := r.status(cert, issuer, now)
+        if err != nil {
+            return err
+        }
+
+        switch status {
+            case RevocationStatusRevoked:
+                return CertificateInvalidError{cert, CertificateRevoked, "serial " + cert.SerialNumber.String()}
+            case RevocationStatusUnknown:
+                if r.RequireStatus {
+                    return CertificateInvalidError{cert, RevocationStatusUnavailable, "serial " + cert.SerialNumber.String()}
+                }
+        }
+    }
+
+    return nil
+}
+
+// status returns the revocation status of cert.
+func (r *RevocationOptions) status(cert, issuer *Certificate, now time.Time) (RevocationStatus, error) {
+    if r.Checker != nil {
+        status, err := r.Checker.RevocationStatus(cert, issuer, now)
+        if err != nil {
+            return RevocationStatusUnknown, err
+        }
+
+        if status != RevocationStatusUnknown {
+            return status, nil
+        }
+    }
+
+    return crlStatus(r.CRLs, cert, issuer, now), nil
+}
+
+// crlStatus returns the revocation status of cert from the most recent
+// complete CRL of issuer covering it, updated with the delta CRLs based
+// on that CRL.
+func crlStatus(crls []*RevocationList, cert, issuer *Certificate, now time.Time) RevocationStatus {
+    var base *RevocationList
+    var deltas []*RevocationList
+
+    for _, crl := range crls {
+        if !crlCovers(crl, cert, issuer, now) {
+            continue
+        }
+
+        if crl.IsDelta() {
+            deltas = append(deltas, crl)
+        } else if base == nil || newerCRL(crl, base) {
+            base = crl
+        }
+    }
+
+    if base == nil {
+        return RevocationStatusUnknown
+    }
+
+    status := RevocationStatusGood
+    if entry := base.Entry(cert.SerialNumber); entry != nil && entry.ReasonCode != ReasonRemoveFromCRL {
+        status = RevocationStatusRevoked
+    }
+
+    // the most recent delta CRL over the base CRL
+    var delta *RevocationList
+    for _, crl := range deltas {
+        if crl.Number == nil || base.Number == nil ||
+            crl.BaseCRLNumber.Cmp(base.Number) > 0 ||
+            crl.Number.Cmp(base.Number) <= 0 {
+            continue
+        }
+
+        if delta == nil || newerCRL(crl, delta) {
+            delta = crl
+        }
+    }
+
+    if delta != nil {
+        if entry := delta.Entry(cert.SerialNumber); entry != nil {
+            if entry.ReasonCode == ReasonRemoveFromCRL {
+                status = RevocationStatusGood
+            } else {
+                status = RevocationStatusRevoked
+            }
+        }
+    }
+
+    return status
+}
+
+// crlCovers reports whether crl is a valid and current CRL of issuer
+// whose scope includes cert.
+func crlCovers(crl *RevocationList, cert, issuer *Certificate, now time.Time) bool {
+    if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) ||
+        !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+        return false
+    }
+
+    if now.Before(crl.ThisUpdate) ||
+        !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+        return false
+    }
+
+    if idp := crl.IssuingDistributionPoint; idp != nil {
+        // indirect and partial CRLs are not used
+        if idp.IndirectCRL || idp.OnlyContainsAttributeCerts ||
+            len(idp.OnlySomeReasons) > 0 {
+            return false
+        }
+
+        if idp.OnlyContainsUserCerts && cert.IsCA ||
+            idp.OnlyContainsCACerts && !cert.IsCA {
+            return false
+        }
+
+        if len(idp.DistributionPoints) > 0 &&
+            !distributionPointsMatch(idp.DistributionPoints, cert.CRLDistributionPoints) {
+            return false
+        }
+    }
+
+    return crl.CheckSignatureFrom(issuer) == nil
+}
+
+func distributionPointsMatch(a, b []string) bool {
+    for _, x := range a {
+        for _, y := range b {
+            if x == y {
+                return true
+            }
+        }
+    }
+
+    return false
+}
+
+// newerCRL reports whether a has a greater CRL number than b.
+func newerCRL(a, b *RevocationList) bool {
+    if a.Number == nil {
+        return false
+    }
+    if b.Number == nil {
+        return true
+    }
+
+    return a.Number.Cmp(b.Number) > 0
+}
@@ -0,0 +1,103 @@
+package x509
+
+import (
+    "io"
+    "errors"
+    "crypto"
+    "crypto/dsa"
+    "crypto/rsa"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+
+    "github.com/deatil/go-cryptobin/gm/sm2"
+)
+
+// CreateSignature signs data with priv the same way CreateCertificate
+// signs a TBSCertificate, for protocols such as OCSP and time stamping
+// that carry their own signed structures. If sigAlgo is zero, the
+// default signature algorithm of the key is used. It returns the
+// signature algorithm identifier and the signature.
+func CreateSignature(rand io.Reader, priv any, sigAlgo SignatureAlgorithm, data []byte) (pkix.AlgorithmIdentifier, []byte, error) {
+    var pubKey crypto.PublicKey
+    switch prikey := priv.(type) {
+        case crypto.Signer:
+            pubKey = prikey.Public()
+        case *dsa.PrivateKey:
+            pubKey = &prikey.PublicKey
+        default:
+            return pkix.AlgorithmIdentifier{}, nil, errors.New("x509: private key does not implement crypto.Signer")
+    }
+
+    hashFunc, signatureAlgorithm, err := signingParamsForPublicKey(pubKey, sigAlgo)
+    if err != nil {
+        return pkix.AlgorithmIdentifier{}, nil, err
+    }
+
+    // SM2 hashes the data with the signer's Z value itself
+    digest := data
+    if _, ok := pubKey.(*sm2.PublicKey); !ok && hashFunc != 0 {
+        h := hashFunc.New()
+        h.Write(data)
+        digest = h.Sum(nil)
+    }
+
+    var signerOpts crypto.SignerOpts
+    signerOpts = hashFunc
+    if sigAlgo != 0 && sigAlgo.isRSAPSS() {
+        signerOpts = &rsa.PSSOptions{
+            SaltLength: rsa.PSSSaltLengthEqualsHash,
+            Hash:       crypto.Hash(hashFunc),
+        }
+    }
+
+    // when priv is rsa
+    if _, ok := priv.(*rsa.PrivateKey); ok {
+        if !isRSASignHash(crypto.Hash(hashFunc)) {
+            signerOpts = crypto.Hash(0)
+        }
+    }
+
+    var signature []byte
+    switch signer := priv.(type) {
+        case crypto.Signer:
+            signature, err = signer.Sign(rand, digest, signerOpts)
+            if err != nil {
+                return pkix.AlgorithmIdentifier{}, nil, err
+            }
+        case *dsa.PrivateKey:
+            r, s, err := dsa.Sign(rand, signer, digest)
+            if err != nil {
+                return pkix.AlgorithmIdentifier{}, nil, err
+            }
+
+            signature, err = asn1.Marshal(dsaSignature{
+                R: r,
+                S: s,
+            })
+            if err != nil {
+                return pkix.AlgorithmIdentifier{}, nil, err
+            }
+    }
+
+    return signatureAlgorithm, signature, nil
+}
+
+// CheckSignatureWithPublicKey verifies that signature is a valid
+// signature over signed from publicKey.
+func CheckSignatureWithPublicKey(algo SignatureAlgorithm, signed, signature []byte, publicKey crypto.PublicKey) error {
+    return checkSignature(algo, signed, signature, publicKey)
+}
+
+// SignatureAlgorithmFromAI returns the SignatureAlgorithm of an
+// AlgorithmIdentifier, or UnknownSignatureAlgorithm.
+func SignatureAlgorithmFromAI(ai pkix.AlgorithmIdentifier) SignatureAlgorithm {
+    return getSignatureAlgorithmFromAI(ai)
+}
+
+// SignatureAlgorithmIdentifier returns the AlgorithmIdentifier of the
+// signatures CreateSignature makes with a key of pub and sigAlgo, for
+// protocols such as CMP that sign structures holding the algorithm.
+func SignatureAlgorithmIdentifier(pub crypto.PublicKey, sigAlgo SignatureAlgorithm) (pkix.AlgorithmIdentifier, error) {
+    _, signatureAlgorithm, err := signingParamsForPublicKey(pub, sigAlgo)
+    return signatureAlgorithm, err
+}
@@ -52,6 +52,20 @@ const (
     // CANotAuthorizedForExtKeyUsage results when an intermediate or root
     // certificate does not permit a requested extended key usage.
     CANotAuthorizedForExtKeyUsage
+    // CertificateRevoked results when a certificate of the chain is
+    // revoked, see VerifyOptions.Revocation.
+    CertificateRevoked
+    // RevocationStatusUnavailable results when the revocation status of
+    // a certificate of the chain is unknown and
+    // RevocationOptions.RequireStatus is set.
+    RevocationStatusUnavailable
+    // InsufficientSCTs results when the leaf certificate has less valid
+    // SCTs than CTOptions.MinSCTs, see VerifyOptions.CertificateTransparency.
+    InsufficientSCTs
+    // InvalidPolicy results when the certificate policy processing of
+    // RFC 5280 section 6.1 finds no valid policy for the chain while one
+    // is required, or an invalid policy mapping, see VerifyOptions.Policy.
+    InvalidPolicy
 )
 
 // CertificateInvalidError results when an odd error occurs. Users of this
@@ -82,6 +96,14 @@ func (e CertificateInvalidError) Error() string {
         return "x509: issuer has name constraints but leaf doesn't have a SAN extension"
     case UnconstrainedName:
         return "x509: issuer has name constraints but leaf contains unknown or unconstrained name: " + e.Detail
+    case CertificateRevoked:
+        return "x509: certificate has been revoked: " + e.Detail
+    case RevocationStatusUnavailable:
+        return "x509: certificate revocation status is unknown: " + e.Detail
+    case InsufficientSCTs:
+        return "x509: certificate has not enough valid SCTs: " + e.Detail
+    case InvalidPolicy:
+        return "x509: invalid certificate policies: " + e.Detail
     }
     return "x509: unknown error"
 }
@@ -197,6 +219,23 @@ type VerifyOptions struct {
     // certificates from consuming excessive amounts of CPU time when
     // validating. It does not apply to the platform verifier.
     MaxConstraintComparisions int
+
+    // Revocation, if not nil, checks the revocation status of the
+    // certificates of the chains with CRLs and OCSP responses. The chains
+    // with a revoked certificate are rejected. It does not apply to the
+    // platform verifier.
+    Revocation *RevocationOptions
+
+    // CertificateTransparency, if not nil, requires valid SCTs of trusted
+    // CT logs for the leaf certificate. It does not apply to the platform
+    // verifier.
+    CertificateTransparency *CTOptions
+
+    // Policy are the certificate policy inputs of the chains, processed
+    // as in RFC 5280 section 6.1. If nil, the certificate policies and
+    // policy constraints of the chains are not processed. It does not
+    // apply to the platform verifier.
+    Policy *PolicyOptions
 }
 
 const (
@@ -549,6 +588,73 @@ func (c *Certificate) checkNameConstraints(count *int,
     return nil
 }
 
+// checkSANConstraints checks the subject alternative names of sanCert
+// against the name constraints of c.
+func (c *Certificate) checkSANConstraints(sanCert *Certificate, count *int, maxConstraintComparisons int) error {
+    return forEachSAN(sanCert.getSANExtension(), func(tag int, data []byte) error {
+        switch tag {
+        case nameTypeEmail:
+            name := string(data)
+            mailbox, ok := parseRFC2821Mailbox(name)
+            if !ok {
+                return fmt.Errorf("x509: cannot parse rfc822Name %q", mailbox)
+            }
+
+            if err := c.checkNameConstraints(count, maxConstraintComparisons, "email address", name, mailbox,
+                func(parsedName, constraint any) (bool, error) {
+                    return matchEmailConstraint(parsedName.(rfc2821Mailbox), constraint.(string))
+                }, c.PermittedEmailAddresses, c.ExcludedEmailAddresses); err != nil {
+                return err
+            }
+
+        case nameTypeDNS:
+            name := string(data)
+            if _, ok := domainToReverseLabels(name); !ok {
+                return fmt.Errorf("x509: cannot parse dnsName %q", name)
+            }
+
+            if err := c.checkNameConstraints(count, maxConstraintComparisons, "DNS name", name, name,
+                func(parsedName, constraint any) (bool, error) {
+                    return matchDomainConstraint(parsedName.(string), constraint.(string))
+                }, c.PermittedDNSDomains, c.ExcludedDNSDomains); err != nil {
+                return err
+            }
+
+        case nameTypeURI:
+            name := string(data)
+            uri, err := url.Parse(name)
+            if err != nil {
+                return fmt.Errorf("x509: internal error: URI SAN %q failed to parse", name)
+            }
+
+            if err := c.checkNameConstraints(count, maxConstraintComparisons, "URI", name, uri,
+                func(parsedName, constraint any) (bool, error) {
+                    return matchURIConstraint(parsedName.(*url.URL), constraint.(string))
+                }, c.PermittedURIDomains, c.ExcludedURIDomains); err != nil {
+                return err
+            }
+
+        case nameTypeIP:
+            ip := net.IP(data)
+            if l := len(ip); l != net.IPv4len && l != net.IPv6len {
+                return fmt.Errorf("x509: internal error: IP SAN %x failed to parse", data)
+            }
+
+            if err := c.checkNameConstraints(count, maxConstraintComparisons, "IP address", ip.String(), ip,
+                func(parsedName, constraint any) (bool, error) {
+                    return matchIPConstraint(parsedName.(net.IP), constraint.(*net.IPNet))
+                }, c.PermittedIPRanges, c.ExcludedIPRanges); err != nil {
+                return err
+            }
+
+        default:
+            // Unknown SAN types are ignored.
+        }
+
+        return nil
+    })
+}
+
 // isValid performs validity checks on c given that it is a candidate to append
 // to the chain in currentChain.
 func (c *Certificate) isValid(certType int, currentChain []*Certificate, opts *VerifyOptions) error {
@@ -602,70 +708,7 @@ func (c *Certificate) isValid(certType int, currentChain []*Certificate, opts *V
             }
         }
         for _, sanCert := range toCheck {
-            err := forEachSAN(sanCert.getSANExtension(), func(tag int, data []byte) error {
-                switch tag {
-                case nameTypeEmail:
-                    name := string(data)
-                    mailbox, ok := parseRFC2821Mailbox(name)
-                    if !ok {
-                        return fmt.Errorf("x509: cannot parse rfc822Name %q", mailbox)
-                    }
-
-                    if err := c.checkNameConstraints(&comparisonCount, maxConstraintComparisons, "email address", name, mailbox,
-                        func(parsedName, constraint any) (bool, error) {
-                            return matchEmailConstraint(parsedName.(rfc2821Mailbox), constraint.(string))
-                        }, c.PermittedEmailAddresses, c.ExcludedEmailAddresses); err != nil {
-                        return err
-                    }
-
-                case nameTypeDNS:
-                    name := string(data)
-                    if _, ok := domainToReverseLabels(name); !ok {
-                        return fmt.Errorf("x509: cannot parse dnsName %q", name)
-                    }
-
-                    if err := c.checkNameConstraints(&comparisonCount, maxConstraintComparisons, "DNS name", name, name,
-                        func(parsedName, constraint any) (bool, error) {
-                            return matchDomainConstraint(parsedName.(string), constraint.(string))
-                        }, c.PermittedDNSDomains, c.ExcludedDNSDomains); err != nil {
-                        return err
-                    }
-
-                case nameTypeURI:
-                    name := string(data)
-                    uri, err := url.Parse(name)
-                    if err != nil {
-                        return fmt.Errorf("x509: internal error: URI SAN %q failed to parse", name)
-                    }
-
-                    if err := c.checkNameConstraints(&comparisonCount, maxConstraintComparisons, "URI", name, uri,
-                        func(parsedName, constraint any) (bool, error) {
-                            return matchURIConstraint(parsedName.(*url.URL), constraint.(string))
-                        }, c.PermittedURIDomains, c.ExcludedURIDomains); err != nil {
-                        return err
-                    }
-
-                case nameTypeIP:
-                    ip := net.IP(data)
-                    if l := len(ip); l != net.IPv4len && l != net.IPv6len {
-                        return fmt.Errorf("x509: internal error: IP SAN %x failed to parse", data)
-                    }
-
-                    if err := c.checkNameConstraints(&comparisonCount, maxConstraintComparisons, "IP address", ip.String(), ip,
-                        func(parsedName, constraint any) (bool, error) {
-                            return matchIPConstraint(parsedName.(net.IP), constraint.(*net.IPNet))
-                        }, c.PermittedIPRanges, c.ExcludedIPRanges); err != nil {
-                        return err
-                    }
-
-                default:
-                    // Unknown SAN types are ignored.
-                }
-
-                return nil
-            })
-
-            if err != nil {
+            if err := c.checkSANConstraints(sanCert, &comparisonCount, maxConstraintComparisons); err != nil {
                 return err
             }
         }
@@ -807,6 +850,30 @@ func (c *Certificate) Verify(opts VerifyOptions) (chains [][]*Certificate, err e
         }
     }
 
+    if opts.Policy != nil {
+        candidateChains, err = opts.filterChains(c, candidateChains, InvalidPolicy, func(chain []*Certificate, _ time.Time) error {
+            _, err := chainPolicies(chain, opts.Policy)
+            return err
+        })
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if opts.Revocation != nil {
+        candidateChains, err = opts.filterChains(c, candidateChains, RevocationStatusUnavailable, opts.Revocation.checkChain)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if opts.CertificateTransparency != nil {
+        candidateChains, err = opts.filterChains(c, candidateChains, InsufficientSCTs, opts.CertificateTransparency.checkChain)
+        if err != nil {
+            return nil, err
+        }
+    }
+
     if len(opts.KeyUsages) == 0 {
         opts.KeyUsages = []ExtKeyUsage{ExtKeyUsageServerAuth}
     }
@@ -833,6 +900,42 @@ func (c *Certificate) Verify(opts VerifyOptions) (chains [][]*Certificate, err e
     return chains, nil
 }
 
+// filterChains returns the chains of c accepted by check. If there is
+// none, it returns the error of the first chain, or a
+// CertificateInvalidError with reason if there was no chain to check.
+func (opts *VerifyOptions) filterChains(c *Certificate, candidateChains [][]*Certificate, reason InvalidReason, check func([]*Certificate, time.Time) error) ([][]*Certificate, error) {
+    now := opts.CurrentTime
+    if now.IsZero() {
+        now = time.Now()
+    }
+
+    var firstErr error
+
+    chains := make([][]*Certificate, 0, len(candidateChains))
+    for _, candidate := range candidateChains {
+        err := check(candidate, now)
+        if err != nil {
+            if firstErr == nil {
+                firstErr = err
+            }
+
+            continue
+        }
+
+        chains = append(chains, candidate)
+    }
+
+    if len(chains) == 0 {
+        if firstErr == nil {
+            firstErr = CertificateInvalidError{c, reason, "no certificate chain"}
+        }
+
+        return nil, firstErr
+    }
+
+    return chains, nil
+}
+
 func appendToFreshChain(chain []*Certificate, cert *Certificate) []*Certificate {
     n := make([]*Certificate, len(chain)+1)
     copy(n, chain)
@@ -26,6 +26,10 @@ import (
 
     "github.com/deatil/go-cryptobin/gm/sm2"
     "github.com/deatil/go-cryptobin/pubkey/gost"
+    "github.com/deatil/go-cryptobin/pubkey/mlkem"
+    "github.com/deatil/go-cryptobin/pubkey/mldsa"
+    "github.com/deatil/go-cryptobin/pubkey/slhdsa"
+    "github.com/deatil/go-cryptobin/pubkey/composite"
 )
 
 const (
@@ -121,6 +125,42 @@ func marshalPublicKey(pub any) (publicKeyBytes []byte, publicKeyAlgorithm pkix.A
 
             publicKeyBytes = pki.PublicKey.RightAlign()
             publicKeyAlgorithm = pki.Algorithm
+        case *mlkem.PublicKey:
+            oid, err := mlkem.OIDFromParams(pub.Params)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, err
+            }
+
+            // FIPS 203, the parameters MUST be absent
+            publicKeyBytes = pub.Bytes()
+            publicKeyAlgorithm.Algorithm = oid
+        case *mldsa.PublicKey:
+            oid, err := mldsa.OIDFromParams(pub.Params)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, err
+            }
+
+            // FIPS 204, the parameters MUST be absent
+            publicKeyBytes = pub.Bytes()
+            publicKeyAlgorithm.Algorithm = oid
+        case *slhdsa.PublicKey:
+            oid, err := slhdsa.OIDFromParams(pub.Params)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, err
+            }
+
+            // FIPS 205, the parameters MUST be absent
+            publicKeyBytes = pub.Bytes()
+            publicKeyAlgorithm.Algorithm = oid
+        case *composite.PublicKey:
+            oid, err := composite.OIDFromParams(pub.Params)
+            if err != nil {
+                return nil, pkix.AlgorithmIdentifier{}, err
+            }
+
+            // composite signatures, the parameters MUST be absent
+            publicKeyBytes = pub.Bytes()
+            publicKeyAlgorithm.Algorithm = oid
         default:
             return nil, pkix.AlgorithmIdentifier{}, errors.New("x509: only RSA and ECDSA(SM2) public keys supported")
     }
@@ -128,6 +168,42 @@ func marshalPublicKey(pub any) (publicKeyBytes []byte, publicKeyAlgorithm pkix.A
     return publicKeyBytes, publicKeyAlgorithm, nil
 }
 
+// MarshalPKIXPublicKey converts a public key to PKIX, ASN.1 DER form,
+// with the key types supported by CreateCertificate.
+func MarshalPKIXPublicKey(pub any) ([]byte, error) {
+    publicKeyBytes, publicKeyAlgorithm, err := marshalPublicKey(pub)
+    if err != nil {
+        return nil, err
+    }
+
+    return asn1.Marshal(publicKeyInfo{
+        Algorithm: publicKeyAlgorithm,
+        PublicKey: asn1.BitString{
+            Bytes:     publicKeyBytes,
+            BitLength: 8 * len(publicKeyBytes),
+        },
+    })
+}
+
+// ParsePKIXPublicKey parses a public key in PKIX, ASN.1 DER form, as
+// found in the certificates, with the key types supported by
+// ParseCertificate.
+func ParsePKIXPublicKey(derBytes []byte) (any, error) {
+    var pki publicKeyInfo
+    if rest, err := asn1.Unmarshal(derBytes, &pki); err != nil {
+        return nil, err
+    } else if len(rest) != 0 {
+        return nil, errors.New("x509: trailing data after ASN.1 of public-key")
+    }
+
+    algo := getPublicKeyAlgorithmFromOID(pki.Algorithm.Algorithm)
+    if algo == UnknownPublicKeyAlgorithm {
+        return nil, errors.New("x509: unknown public key algorithm")
+    }
+
+    return parsePublicKey(algo, &pki)
+}
+
 func parsePublicKey(algo PublicKeyAlgorithm, keyData *publicKeyInfo) (any, error) {
     asn1Data := keyData.PublicKey.RightAlign()
     params := keyData.Algorithm.Parameters
@@ -254,6 +330,70 @@ func parsePublicKey(algo PublicKeyAlgorithm, keyData *publicKeyInfo) (any, error
                 return nil, errors.New("x509: failed to unmarshal GOST curve point")
             }
 
+            return pub, nil
+        case MLKEM:
+            if len(params.FullBytes) != 0 {
+                return nil, errors.New("x509: ML-KEM key encoded with illegal parameters")
+            }
+
+            mlkemParams, err := mlkem.ParamsFromOID(keyData.Algorithm.Algorithm)
+            if err != nil {
+                return nil, err
+            }
+
+            pub, err := mlkem.NewPublicKey(mlkemParams, asn1Data)
+            if err != nil {
+                return nil, errors.New("x509: failed to unmarshal ML-KEM public key")
+            }
+
+            return pub, nil
+        case MLDSA:
+            if len(params.FullBytes) != 0 {
+                return nil, errors.New("x509: ML-DSA key encoded with illegal parameters")
+            }
+
+            mldsaParams, err := mldsa.ParamsFromOID(keyData.Algorithm.Algorithm)
+            if err != nil {
+                return nil, err
+            }
+
+            pub, err := mldsa.NewPublicKey(mldsaParams, asn1Data)
+            if err != nil {
+                return nil, errors.New("x509: failed to unmarshal ML-DSA public key")
+            }
+
+            return pub, nil
+        case SLHDSA:
+            if len(params.FullBytes) != 0 {
+                return nil, errors.New("x509: SLH-DSA key encoded with illegal parameters")
+            }
+
+            slhdsaParams, err := slhdsa.ParamsFromOID(keyData.Algorithm.Algorithm)
+            if err != nil {
+                return nil, err
+            }
+
+            pub, err := slhdsa.NewPublicKey(slhdsaParams, asn1Data)
+            if err != nil {
+                return nil, errors.New("x509: failed to unmarshal SLH-DSA public key")
+            }
+
+            return pub, nil
+        case Composite:
+            if len(params.FullBytes) != 0 {
+                return nil, errors.New("x509: composite key encoded with illegal parameters")
+            }
+
+            compositeParams, err := composite.ParamsFromOID(keyData.Algorithm.Algorithm)
+            if err != nil {
+                return nil, err
+            }
+
+            pub, err := composite.NewPublicKey(compositeParams, asn1Data)
+            if err != nil {
+                return nil, errors.New("x509: failed to unmarshal composite public key")
+            }
+
             return pub, nil
         default:
             return nil, nil
@@ -334,6 +474,34 @@ const (
     GOST3410WithGOST34112001
     GOST3410WithGOST34112012256
     GOST3410WithGOST34112012512
+    MLDSA44
+    MLDSA65
+    MLDSA87
+    SLHDSA_SHA2_128s
+    SLHDSA_SHA2_128f
+    SLHDSA_SHA2_192s
+    SLHDSA_SHA2_192f
+    SLHDSA_SHA2_256s
+    SLHDSA_SHA2_256f
+    SLHDSA_SHAKE_128s
+    SLHDSA_SHAKE_128f
+    SLHDSA_SHAKE_192s
+    SLHDSA_SHAKE_192f
+    SLHDSA_SHAKE_256s
+    SLHDSA_SHAKE_256f
+    MLDSA44_RSA2048_PSS_SHA256
+    MLDSA44_Ed25519_SHA512
+    MLDSA44_ECDSA_P256_SHA256
+    MLDSA65_RSA3072_PSS_SHA512
+    MLDSA65_RSA4096_PSS_SHA512
+    MLDSA65_ECDSA_P256_SHA512
+    MLDSA65_ECDSA_P384_SHA512
+    MLDSA65_Ed25519_SHA512
+    MLDSA87_ECDSA_P384_SHA512
+    MLDSA87_RSA3072_PSS_SHA512
+    MLDSA87_RSA4096_PSS_SHA512
+    MLDSA87_ECDSA_P521_SHA512
+    MLDSA65_SM2_SM3
 )
 
 func (algo SignatureAlgorithm) isRSAPSS() bool {
@@ -369,6 +537,34 @@ var algoName = [...]string{
     GOST3410WithGOST34112001:    "GOST3410-GOST34112001",
     GOST3410WithGOST34112012256: "GOST3410-GOST34112012256",
     GOST3410WithGOST34112012512: "GOST3410-GOST34112012512",
+    MLDSA44:                     "ML-DSA-44",
+    MLDSA65:                     "ML-DSA-65",
+    MLDSA87:                     "ML-DSA-87",
+    SLHDSA_SHA2_128s:            "SLH-DSA-SHA2-128s",
+    SLHDSA_SHA2_128f:            "SLH-DSA-SHA2-128f",
+    SLHDSA_SHA2_192s:            "SLH-DSA-SHA2-192s",
+    SLHDSA_SHA2_192f:            "SLH-DSA-SHA2-192f",
+    SLHDSA_SHA2_256s:            "SLH-DSA-SHA2-256s",
+    SLHDSA_SHA2_256f:            "SLH-DSA-SHA2-256f",
+    SLHDSA_SHAKE_128s:           "SLH-DSA-SHAKE-128s",
+    SLHDSA_SHAKE_128f:           "SLH-DSA-SHAKE-128f",
+    SLHDSA_SHAKE_192s:           "SLH-DSA-SHAKE-192s",
+    SLHDSA_SHAKE_192f:           "SLH-DSA-SHAKE-192f",
+    SLHDSA_SHAKE_256s:           "SLH-DSA-SHAKE-256s",
+    SLHDSA_SHAKE_256f:           "SLH-DSA-SHAKE-256f",
+    MLDSA44_RSA2048_PSS_SHA256:  "MLDSA44-RSA2048-PSS-SHA256",
+    MLDSA44_Ed25519_SHA512:      "MLDSA44-Ed25519-SHA512",
+    MLDSA44_ECDSA_P256_SHA256:   "MLDSA44-ECDSA-P256-SHA256",
+    MLDSA65_RSA3072_PSS_SHA512:  "MLDSA65-RSA3072-PSS-SHA512",
+    MLDSA65_RSA4096_PSS_SHA512:  "MLDSA65-RSA4096-PSS-SHA512",
+    MLDSA65_ECDSA_P256_SHA512:   "MLDSA65-ECDSA-P256-SHA512",
+    MLDSA65_ECDSA_P384_SHA512:   "MLDSA65-ECDSA-P384-SHA512",
+    MLDSA65_Ed25519_SHA512:      "MLDSA65-Ed25519-SHA512",
+    MLDSA87_ECDSA_P384_SHA512:   "MLDSA87-ECDSA-P384-SHA512",
+    MLDSA87_RSA3072_PSS_SHA512:  "MLDSA87-RSA3072-PSS-SHA512",
+    MLDSA87_RSA4096_PSS_SHA512:  "MLDSA87-RSA4096-PSS-SHA512",
+    MLDSA87_ECDSA_P521_SHA512:   "MLDSA87-ECDSA-P521-SHA512",
+    MLDSA65_SM2_SM3:             "MLDSA65-SM2-SM3",
 }
 
 func (algo SignatureAlgorithm) String() string {
@@ -388,6 +584,10 @@ const (
     Ed25519
     SM2
     GOST3410
+    MLKEM
+    MLDSA
+    SLHDSA
+    Composite
 )
 
 // OIDs for signature algorithms
@@ -465,6 +665,36 @@ var (
     oidSignatureGOST3410WithGOST34112012256 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 2}
     oidSignatureGOST3410WithGOST34112012512 = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 3}
 
+    oidSignatureMLDSA44 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 17}
+    oidSignatureMLDSA65 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 18}
+    oidSignatureMLDSA87 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 19}
+
+    oidSignatureSLHDSA_SHA2_128s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 20}
+    oidSignatureSLHDSA_SHA2_128f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 21}
+    oidSignatureSLHDSA_SHA2_192s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 22}
+    oidSignatureSLHDSA_SHA2_192f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 23}
+    oidSignatureSLHDSA_SHA2_256s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 24}
+    oidSignatureSLHDSA_SHA2_256f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 25}
+    oidSignatureSLHDSA_SHAKE_128s= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 26}
+    oidSignatureSLHDSA_SHAKE_128f= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 27}
+    oidSignatureSLHDSA_SHAKE_192s= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 28}
+    oidSignatureSLHDSA_SHAKE_192f= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 29}
+    oidSignatureSLHDSA_SHAKE_256s= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 30}
+    oidSignatureSLHDSA_SHAKE_256f= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 31}
+
+    oidSignatureMLDSA44_RSA2048_PSS_SHA256 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 37}
+    oidSignatureMLDSA44_Ed25519_SHA512     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 39}
+    oidSignatureMLDSA44_ECDSA_P256_SHA256  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 40}
+    oidSignatureMLDSA65_RSA3072_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 41}
+    oidSignatureMLDSA65_RSA4096_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 43}
+    oidSignatureMLDSA65_ECDSA_P256_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 45}
+    oidSignatureMLDSA65_ECDSA_P384_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 46}
+    oidSignatureMLDSA65_Ed25519_SHA512     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 48}
+    oidSignatureMLDSA87_ECDSA_P384_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 49}
+    oidSignatureMLDSA87_RSA3072_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 52}
+    oidSignatureMLDSA87_RSA4096_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 53}
+    oidSignatureMLDSA87_ECDSA_P521_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 54}
+
     oidSM3     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401, 1}
     oidSHA256  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
     oidSHA384  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
@@ -513,6 +743,35 @@ var signatureAlgorithmDetails = []struct {
     {GOST3410WithGOST34112001, oidSignatureGOST3410WithGOST3411, GOST3410, GOST34112001},
     {GOST3410WithGOST34112012256, oidSignatureGOST3410WithGOST34112012256, GOST3410, GOST34112012256},
     {GOST3410WithGOST34112012512, oidSignatureGOST3410WithGOST34112012512, GOST3410, GOST34112012512},
+    {MLDSA44, oidSignatureMLDSA44, MLDSA, Hash(0)},
+    {MLDSA65, oidSignatureMLDSA65, MLDSA, Hash(0)},
+    {MLDSA87, oidSignatureMLDSA87, MLDSA, Hash(0)},
+    {SLHDSA_SHA2_128s, oidSignatureSLHDSA_SHA2_128s, SLHDSA, Hash(0)},
+    {SLHDSA_SHA2_128f, oidSignatureSLHDSA_SHA2_128f, SLHDSA, Hash(0)},
+    {SLHDSA_SHA2_192s, oidSignatureSLHDSA_SHA2_192s, SLHDSA, Hash(0)},
+    {SLHDSA_SHA2_192f, oidSignatureSLHDSA_SHA2_192f, SLHDSA, Hash(0)},
+    {SLHDSA_SHA2_256s, oidSignatureSLHDSA_SHA2_256s, SLHDSA, Hash(0)},
+    {SLHDSA_SHA2_256f, oidSignatureSLHDSA_SHA2_256f, SLHDSA, Hash(0)},
+    {SLHDSA_SHAKE_128s, oidSignatureSLHDSA_SHAKE_128s, SLHDSA, Hash(0)},
+    {SLHDSA_SHAKE_128f, oidSignatureSLHDSA_SHAKE_128f, SLHDSA, Hash(0)},
+    {SLHDSA_SHAKE_192s, oidSignatureSLHDSA_SHAKE_192s, SLHDSA, Hash(0)},
+    {SLHDSA_SHAKE_192f, oidSignatureSLHDSA_SHAKE_192f, SLHDSA, Hash(0)},
+    {SLHDSA_SHAKE_256s, oidSignatureSLHDSA_SHAKE_256s, SLHDSA, Hash(0)},
+    {SLHDSA_SHAKE_256f, oidSignatureSLHDSA_SHAKE_256f, SLHDSA, Hash(0)},
+    {MLDSA44_RSA2048_PSS_SHA256, oidSignatureMLDSA44_RSA2048_PSS_SHA256, Composite, Hash(0)},
+    {MLDSA44_Ed25519_SHA512, oidSignatureMLDSA44_Ed25519_SHA512, Composite, Hash(0)},
+    {MLDSA44_ECDSA_P256_SHA256, oidSignatureMLDSA44_ECDSA_P256_SHA256, Composite, Hash(0)},
+    {MLDSA65_RSA3072_PSS_SHA512, oidSignatureMLDSA65_RSA3072_PSS_SHA512, Composite, Hash(0)},
+    {MLDSA65_RSA4096_PSS_SHA512, oidSignatureMLDSA65_RSA4096_PSS_SHA512, Composite, Hash(0)},
+    {MLDSA65_ECDSA_P256_SHA512, oidSignatureMLDSA65_ECDSA_P256_SHA512, Composite, Hash(0)},
+    {MLDSA65_ECDSA_P384_SHA512, oidSignatureMLDSA65_ECDSA_P384_SHA512, Composite, Hash(0)},
+    {MLDSA65_Ed25519_SHA512, oidSignatureMLDSA65_Ed25519_SHA512, Composite, Hash(0)},
+    {MLDSA87_ECDSA_P384_SHA512, oidSignatureMLDSA87_ECDSA_P384_SHA512, Composite, Hash(0)},
+    {MLDSA87_RSA3072_PSS_SHA512, oidSignatureMLDSA87_RSA3072_PSS_SHA512, Composite, Hash(0)},
+    {MLDSA87_RSA4096_PSS_SHA512, oidSignatureMLDSA87_RSA4096_PSS_SHA512, Composite, Hash(0)},
+    {MLDSA87_ECDSA_P521_SHA512, oidSignatureMLDSA87_ECDSA_P521_SHA512, Composite, Hash(0)},
+    // no registered OID, see oidMLDSA65_SM2_SM3
+    {MLDSA65_SM2_SM3, nil, Composite, Hash(0)},
 }
 
 // pssParameters reflects the parameters in an AlgorithmIdentifier that
@@ -583,6 +842,11 @@ func getSignatureAlgorithmFromAI(ai pkix.AlgorithmIdentifier) SignatureAlgorithm
                 return details.algo
             }
         }
+
+        if len(ai.Algorithm) > 0 && ai.Algorithm.Equal(oidMLDSA65_SM2_SM3()) {
+            return MLDSA65_SM2_SM3
+        }
+
         return UnknownSignatureAlgorithm
     }
 
@@ -650,8 +914,50 @@ var (
     oidGOSTPublicKey         = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 19}
     oidGost2012PublicKey256  = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 1}
     oidGost2012PublicKey512  = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 2}
+
+    oidPublicKeyMLKEM512  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 1}
+    oidPublicKeyMLKEM768  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 2}
+    oidPublicKeyMLKEM1024 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 3}
+
+    oidPublicKeyMLDSA44 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 17}
+    oidPublicKeyMLDSA65 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 18}
+    oidPublicKeyMLDSA87 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 19}
+
+    oidPublicKeySLHDSA_SHA2_128s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 20}
+    oidPublicKeySLHDSA_SHA2_128f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 21}
+    oidPublicKeySLHDSA_SHA2_192s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 22}
+    oidPublicKeySLHDSA_SHA2_192f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 23}
+    oidPublicKeySLHDSA_SHA2_256s = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 24}
+    oidPublicKeySLHDSA_SHA2_256f = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 25}
+    oidPublicKeySLHDSA_SHAKE_128s= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 26}
+    oidPublicKeySLHDSA_SHAKE_128f= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 27}
+    oidPublicKeySLHDSA_SHAKE_192s= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 28}
+    oidPublicKeySLHDSA_SHAKE_192f= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 29}
+    oidPublicKeySLHDSA_SHAKE_256s= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 30}
+    oidPublicKeySLHDSA_SHAKE_256f= asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 31}
+
+    oidPublicKeyMLDSA44_RSA2048_PSS_SHA256 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 37}
+    oidPublicKeyMLDSA44_Ed25519_SHA512     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 39}
+    oidPublicKeyMLDSA44_ECDSA_P256_SHA256  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 40}
+    oidPublicKeyMLDSA65_RSA3072_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 41}
+    oidPublicKeyMLDSA65_RSA4096_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 43}
+    oidPublicKeyMLDSA65_ECDSA_P256_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 45}
+    oidPublicKeyMLDSA65_ECDSA_P384_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 46}
+    oidPublicKeyMLDSA65_Ed25519_SHA512     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 48}
+    oidPublicKeyMLDSA87_ECDSA_P384_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 49}
+    oidPublicKeyMLDSA87_RSA3072_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 52}
+    oidPublicKeyMLDSA87_RSA4096_PSS_SHA512 = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 53}
+    oidPublicKeyMLDSA87_ECDSA_P521_SHA512  = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 6, 54}
 )
 
+// oidMLDSA65_SM2_SM3 returns the OID of MLDSA65-SM2-SM3. The combination
+// is not standardized, its OID is nil until it is set with
+// composite.SetMLDSA65_SM2_SM3OID.
+func oidMLDSA65_SM2_SM3() asn1.ObjectIdentifier {
+    oid, _ := composite.OIDFromParams(composite.MLDSA65_SM2_SM3())
+    return oid
+}
+
 func getPublicKeyAlgorithmFromOID(oid asn1.ObjectIdentifier) PublicKeyAlgorithm {
     switch {
         case oid.Equal(oidPublicKeyRSA):
@@ -666,6 +972,42 @@ func getPublicKeyAlgorithmFromOID(oid asn1.ObjectIdentifier) PublicKeyAlgorithm
             oid.Equal(oidGost2012PublicKey256),
             oid.Equal(oidGost2012PublicKey512):
             return GOST3410
+        case oid.Equal(oidPublicKeyMLKEM512),
+            oid.Equal(oidPublicKeyMLKEM768),
+            oid.Equal(oidPublicKeyMLKEM1024):
+            return MLKEM
+        case oid.Equal(oidPublicKeyMLDSA44),
+            oid.Equal(oidPublicKeyMLDSA65),
+            oid.Equal(oidPublicKeyMLDSA87):
+            return MLDSA
+        case oid.Equal(oidPublicKeySLHDSA_SHA2_128s),
+            oid.Equal(oidPublicKeySLHDSA_SHA2_128f),
+            oid.Equal(oidPublicKeySLHDSA_SHA2_192s),
+            oid.Equal(oidPublicKeySLHDSA_SHA2_192f),
+            oid.Equal(oidPublicKeySLHDSA_SHA2_256s),
+            oid.Equal(oidPublicKeySLHDSA_SHA2_256f),
+            oid.Equal(oidPublicKeySLHDSA_SHAKE_128s),
+            oid.Equal(oidPublicKeySLHDSA_SHAKE_128f),
+            oid.Equal(oidPublicKeySLHDSA_SHAKE_192s),
+            oid.Equal(oidPublicKeySLHDSA_SHAKE_192f),
+            oid.Equal(oidPublicKeySLHDSA_SHAKE_256s),
+            oid.Equal(oidPublicKeySLHDSA_SHAKE_256f):
+            return SLHDSA
+        case oid.Equal(oidPublicKeyMLDSA44_RSA2048_PSS_SHA256),
+            oid.Equal(oidPublicKeyMLDSA44_Ed25519_SHA512),
+            oid.Equal(oidPublicKeyMLDSA44_ECDSA_P256_SHA256),
+            oid.Equal(oidPublicKeyMLDSA65_RSA3072_PSS_SHA512),
+            oid.Equal(oidPublicKeyMLDSA65_RSA4096_PSS_SHA512),
+            oid.Equal(oidPublicKeyMLDSA65_ECDSA_P256_SHA512),
+            oid.Equal(oidPublicKeyMLDSA65_ECDSA_P384_SHA512),
+            oid.Equal(oidPublicKeyMLDSA65_Ed25519_SHA512),
+            oid.Equal(oidPublicKeyMLDSA87_ECDSA_P384_SHA512),
+            oid.Equal(oidPublicKeyMLDSA87_RSA3072_PSS_SHA512),
+            oid.Equal(oidPublicKeyMLDSA87_RSA4096_PSS_SHA512),
+            oid.Equal(oidPublicKeyMLDSA87_ECDSA_P521_SHA512):
+            return Composite
+        case len(oid) > 0 && oid.Equal(oidMLDSA65_SM2_SM3()):
+            return Composite
     }
 
     return UnknownPublicKeyAlgorithm
@@ -912,6 +1254,25 @@ type Certificate struct {
     CRLDistributionPoints []string
 
     PolicyIdentifiers []asn1.ObjectIdentifier
+
+    // PolicyMappings maps the policies of the issuer domain to the ones
+    // of the subject domain in CA certificates.
+    PolicyMappings []PolicyMapping
+
+    // RequireExplicitPolicy and InhibitPolicyMapping are the policy
+    // constraints: the number of certificates following this one in a
+    // path before a valid policy is required, or before the policy
+    // mappings are no longer processed. InhibitAnyPolicy is the number
+    // of certificates following this one which can use anyPolicy.
+    //
+    // As for MaxPathLen, a zero value means the constraint is absent
+    // unless the matching Zero field is true.
+    RequireExplicitPolicy     int
+    RequireExplicitPolicyZero bool
+    InhibitPolicyMapping      int
+    InhibitPolicyMappingZero  bool
+    InhibitAnyPolicy          int
+    InhibitAnyPolicyZero      bool
 }
 
 // ErrUnsupportedAlgorithm results from attempting to perform an operation that
@@ -1054,7 +1415,20 @@ func checkSignature(algo SignatureAlgorithm, signed, signature []byte, publicKey
             hashType = SHA512
         case MD2WithRSA, MD5WithRSA:
             return InsecureAlgorithmError(algo)
-        case PureEd25519:
+        case PureEd25519, MLDSA44, MLDSA65, MLDSA87:
+            hashType = Hash(0)
+        case SLHDSA_SHA2_128s, SLHDSA_SHA2_128f, SLHDSA_SHA2_192s,
+            SLHDSA_SHA2_192f, SLHDSA_SHA2_256s, SLHDSA_SHA2_256f,
+            SLHDSA_SHAKE_128s, SLHDSA_SHAKE_128f, SLHDSA_SHAKE_192s,
+            SLHDSA_SHAKE_192f, SLHDSA_SHAKE_256s, SLHDSA_SHAKE_256f:
+            hashType = Hash(0)
+        case MLDSA44_RSA2048_PSS_SHA256, MLDSA44_Ed25519_SHA512,
+            MLDSA44_ECDSA_P256_SHA256, MLDSA65_RSA3072_PSS_SHA512,
+            MLDSA65_RSA4096_PSS_SHA512, MLDSA65_ECDSA_P256_SHA512,
+            MLDSA65_ECDSA_P384_SHA512, MLDSA65_Ed25519_SHA512,
+            MLDSA87_ECDSA_P384_SHA512, MLDSA87_RSA3072_PSS_SHA512,
+            MLDSA87_RSA4096_PSS_SHA512, MLDSA87_ECDSA_P521_SHA512,
+            MLDSA65_SM2_SM3:
             hashType = Hash(0)
         case SM2WithSM3, SM3WithRSA:
             hashType = SM3
@@ -1068,7 +1442,7 @@ func checkSignature(algo SignatureAlgorithm, signed, signature []byte, publicKey
             return ErrUnsupportedAlgorithm
     }
 
-    if algo != PureEd25519 {
+    if hashType != Hash(0) {
         if !hashType.Available() {
             return ErrUnsupportedAlgorithm
         }
@@ -1147,6 +1521,64 @@ func checkSignature(algo SignatureAlgorithm, signed, signature []byte, publicKey
                 return errors.New("x509: GOST verification failure")
             }
 
+            return
+        case *mldsa.PublicKey:
+            var mldsaParams *mldsa.Params
+            for _, details := range signatureAlgorithmDetails {
+                if details.algo == algo {
+                    mldsaParams, _ = mldsa.ParamsFromOID(details.oid)
+                    break
+                }
+            }
+
+            if mldsaParams != pub.Params {
+                return errors.New("x509: ML-DSA signature algorithm does not match public key")
+            }
+
+            if !mldsa.Verify(pub, signed, signature) {
+                return errors.New("x509: ML-DSA verification failure")
+            }
+
+            return
+        case *slhdsa.PublicKey:
+            var slhdsaParams *slhdsa.Params
+            for _, details := range signatureAlgorithmDetails {
+                if details.algo == algo {
+                    slhdsaParams, _ = slhdsa.ParamsFromOID(details.oid)
+                    break
+                }
+            }
+
+            if slhdsaParams != pub.Params {
+                return errors.New("x509: SLH-DSA signature algorithm does not match public key")
+            }
+
+            if !slhdsa.Verify(pub, signed, signature) {
+                return errors.New("x509: SLH-DSA verification failure")
+            }
+
+            return
+        case *composite.PublicKey:
+            var compositeParams *composite.Params
+            for _, details := range signatureAlgorithmDetails {
+                if details.algo == algo {
+                    compositeParams, _ = composite.ParamsFromOID(details.oid)
+                    break
+                }
+            }
+
+            if algo == MLDSA65_SM2_SM3 {
+                compositeParams = composite.MLDSA65_SM2_SM3()
+            }
+
+            if compositeParams != pub.Params {
+                return errors.New("x509: composite signature algorithm does not match public key")
+            }
+
+            if !composite.Verify(pub, signed, signature) {
+                return errors.New("x509: composite verification failure")
+            }
+
             return
     }
 
@@ -1176,6 +1608,19 @@ type policyInformation struct {
     // policyQualifiers omitted
 }
 
+// PolicyMapping maps a policy of the issuer domain to one of the
+// subject domain, RFC 5280 4.2.1.5.
+type PolicyMapping struct {
+    IssuerDomainPolicy  asn1.ObjectIdentifier
+    SubjectDomainPolicy asn1.ObjectIdentifier
+}
+
+// RFC 5280 4.2.1.11
+type policyConstraints struct {
+    RequireExplicitPolicy int `asn1:"optional,tag:0,default:-1"`
+    InhibitPolicyMapping  int `asn1:"optional,tag:1,default:-1"`
+}
+
 // RFC 5280, 4.2.1.10
 type nameConstraints struct {
     Permitted []generalSubtree `asn1:"optional,tag:0"`
@@ -1483,6 +1928,50 @@ func parseCertificate(in *certificate) (*Certificate, error) {
                     return nil, err
                 }
 
+            case 33:
+                // RFC 5280, 4.2.1.5
+                if rest, err := asn1.Unmarshal(e.Value, &out.PolicyMappings); err != nil {
+                    return nil, err
+                } else if len(rest) != 0 || len(out.PolicyMappings) == 0 {
+                    return nil, errors.New("x509: invalid X.509 policy mappings")
+                }
+
+            case 36:
+                // RFC 5280, 4.2.1.11
+                var constraints policyConstraints
+                if rest, err := asn1.Unmarshal(e.Value, &constraints); err != nil {
+                    return nil, err
+                } else if len(rest) != 0 {
+                    return nil, errors.New("x509: trailing data after X.509 policy constraints")
+                }
+
+                if constraints.RequireExplicitPolicy < -1 || constraints.InhibitPolicyMapping < -1 ||
+                    (constraints.RequireExplicitPolicy == -1 && constraints.InhibitPolicyMapping == -1) {
+                    return nil, errors.New("x509: invalid X.509 policy constraints")
+                }
+
+                if constraints.RequireExplicitPolicy >= 0 {
+                    out.RequireExplicitPolicy = constraints.RequireExplicitPolicy
+                    out.RequireExplicitPolicyZero = constraints.RequireExplicitPolicy == 0
+                }
+
+                if constraints.InhibitPolicyMapping >= 0 {
+                    out.InhibitPolicyMapping = constraints.InhibitPolicyMapping
+                    out.InhibitPolicyMappingZero = constraints.InhibitPolicyMapping == 0
+                }
+
+            case 54:
+                // RFC 5280, 4.2.1.14
+                var skipCerts int
+                if rest, err := asn1.Unmarshal(e.Value, &skipCerts); err != nil {
+                    return nil, err
+                } else if len(rest) != 0 || skipCerts < 0 {
+                    return nil, errors.New("x509: invalid X.509 inhibit anyPolicy")
+                }
+
+                out.InhibitAnyPolicy = skipCerts
+                out.InhibitAnyPolicyZero = skipCerts == 0
+
             default:
                 // Unknown extensions are recorded if critical.
                 unhandled = true
@@ -1597,6 +2086,9 @@ var (
     oidExtensionBasicConstraints      = []int{2, 5, 29, 19}
     oidExtensionSubjectAltName        = []int{2, 5, 29, 17}
     oidExtensionCertificatePolicies   = []int{2, 5, 29, 32}
+    oidExtensionPolicyMappings        = []int{2, 5, 29, 33}
+    oidExtensionPolicyConstraints     = []int{2, 5, 29, 36}
+    oidExtensionInhibitAnyPolicy      = []int{2, 5, 29, 54}
     oidExtensionNameConstraints       = []int{2, 5, 29, 30}
     oidExtensionCRLDistributionPoints = []int{2, 5, 29, 31}
     oidExtensionAuthorityInfoAccess   = []int{1, 3, 6, 1, 5, 5, 7, 1, 1}
@@ -1652,7 +2144,7 @@ func marshalSANs(dnsNames, emailAddresses []string, ipAddresses []net.IP, uris [
 }
 
 func buildExtensions(template *Certificate) (ret []pkix.Extension, err error) {
-    ret = make([]pkix.Extension, 10 /* maximum number of elements. */)
+    ret = make([]pkix.Extension, 13 /* maximum number of elements. */)
     n := 0
 
     if template.KeyUsage != 0 &&
@@ -1781,6 +2273,41 @@ func buildExtensions(template *Certificate) (ret []pkix.Extension, err error) {
         n++
     }
 
+    if len(template.PolicyMappings) > 0 &&
+        !oidInExtensions(oidExtensionPolicyMappings, template.ExtraExtensions) {
+        ret[n].Id = oidExtensionPolicyMappings
+        ret[n].Critical = true
+        ret[n].Value, err = asn1.Marshal(template.PolicyMappings)
+        if err != nil {
+            return
+        }
+        n++
+    }
+
+    requireExplicitPolicy := policyConstraintValue(template.RequireExplicitPolicy, template.RequireExplicitPolicyZero)
+    inhibitPolicyMapping := policyConstraintValue(template.InhibitPolicyMapping, template.InhibitPolicyMappingZero)
+    if (requireExplicitPolicy >= 0 || inhibitPolicyMapping >= 0) &&
+        !oidInExtensions(oidExtensionPolicyConstraints, template.ExtraExtensions) {
+        ret[n].Id = oidExtensionPolicyConstraints
+        ret[n].Critical = true
+        ret[n].Value, err = asn1.Marshal(policyConstraints{requireExplicitPolicy, inhibitPolicyMapping})
+        if err != nil {
+            return
+        }
+        n++
+    }
+
+    if inhibitAnyPolicy := policyConstraintValue(template.InhibitAnyPolicy, template.InhibitAnyPolicyZero);
+        inhibitAnyPolicy >= 0 && !oidInExtensions(oidExtensionInhibitAnyPolicy, template.ExtraExtensions) {
+        ret[n].Id = oidExtensionInhibitAnyPolicy
+        ret[n].Critical = true
+        ret[n].Value, err = asn1.Marshal(inhibitAnyPolicy)
+        if err != nil {
+            return
+        }
+        n++
+    }
+
     if (len(template.PermittedDNSDomains) > 0 || len(template.ExcludedDNSDomains) > 0 ||
         len(template.PermittedIPRanges) > 0 || len(template.ExcludedIPRanges) > 0 ||
         len(template.PermittedEmailAddresses) > 0 || len(template.ExcludedEmailAddresses) > 0 ||
@@ -1908,6 +2435,16 @@ func buildExtensions(template *Certificate) (ret []pkix.Extension, err error) {
     return append(ret[:n], template.ExtraExtensions...), nil
 }
 
+// policyConstraintValue returns the value of a policy constraint of a
+// template, -1 if it is absent.
+func policyConstraintValue(value int, zero bool) int {
+    if value > 0 || zero {
+        return value
+    }
+
+    return -1
+}
+
 func subjectBytes(cert *Certificate) ([]byte, error) {
     if len(cert.RawSubject) > 0 {
         return cert.RawSubject, nil
@@ -1983,6 +2520,18 @@ func signingParamsForPublicKey(pub any, requestedSigAlgo SignatureAlgorithm) (ha
                 default:
                     err = errors.New("x509: unknown GOST3410 curve")
             }
+        case *mldsa.PublicKey:
+            pubType = MLDSA
+            hashFunc = Hash(0)
+            sigAlgo.Algorithm, err = mldsa.OIDFromParams(pub.Params)
+        case *slhdsa.PublicKey:
+            pubType = SLHDSA
+            hashFunc = Hash(0)
+            sigAlgo.Algorithm, err = slhdsa.OIDFromParams(pub.Params)
+        case *composite.PublicKey:
+            pubType = Composite
+            hashFunc = Hash(0)
+            sigAlgo.Algorithm, err = composite.OIDFromParams(pub.Params)
 
         default:
             err = errors.New("x509: only RSA, SM2, GOST3410 and ECDSA keys supported")
@@ -2004,8 +2553,22 @@ func signingParamsForPublicKey(pub any, requestedSigAlgo SignatureAlgorithm) (ha
                 return
             }
 
-            sigAlgo.Algorithm, hashFunc = details.oid, details.hash
-            if hashFunc == 0 && pubType != Ed25519 {
+            oid := details.oid
+            if details.algo == MLDSA65_SM2_SM3 {
+                oid = oidMLDSA65_SM2_SM3()
+            }
+
+            // ML-DSA, SLH-DSA and composite key has only one signature algorithm
+            if (pubType == MLDSA || pubType == SLHDSA || pubType == Composite) &&
+                !oid.Equal(sigAlgo.Algorithm) {
+                err = errors.New("x509: requested SignatureAlgorithm does not match private key type")
+                return
+            }
+
+            sigAlgo.Algorithm, hashFunc = oid, details.hash
+            if hashFunc == 0 && pubType != Ed25519 &&
+                pubType != MLDSA && pubType != SLHDSA &&
+                pubType != Composite {
                 err = errors.New("x509: cannot sign with hash function requested")
                 return
             }
@@ -2751,7 +3314,8 @@ func CreateCertificate(rand io.Reader, template, parent *Certificate, publicKey
         case SM2WithSM3, SM2WithSHA1, SM2WithSHA256:
             break
         default:
-            if hashFunc != 0 {
+            // SM2 keys hash the data with the signer's Z value itself
+            if _, ok := pubKey.(*sm2.PublicKey); !ok && hashFunc != 0 {
                 h := hashFunc.New()
                 h.Write(tbsCertContents)
                 digest = h.Sum(nil)